@@ -0,0 +1,91 @@
+package apply
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/dkrizic/feature/cli/command"
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/urfave/cli/v3"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// operations parses one operation per line: KEY=VALUE sets a feature, -KEY deletes it.
+// Blank lines and lines starting with # are skipped.
+func operations(r io.Reader) ([]*feature.Operation, error) {
+	var result []*feature.Operation
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case text == "" || strings.HasPrefix(text, "#"):
+			continue
+		case strings.HasPrefix(text, "-"):
+			key := strings.TrimSpace(text[1:])
+			if key == "" {
+				return nil, fmt.Errorf("line %d: missing key to delete", line)
+			}
+			result = append(result, &feature.Operation{Operation: &feature.Operation_Delete{Delete: &feature.Key{Name: key}}})
+		default:
+			// the value may contain = itself, the key never does
+			key, value, ok := strings.Cut(text, "=")
+			key = strings.TrimSpace(key)
+			if !ok || key == "" {
+				return nil, fmt.Errorf("line %d: invalid operation %q, expected KEY=VALUE or -KEY", line, text)
+			}
+			result = append(result, &feature.Operation{Operation: &feature.Operation_Set{Set: &feature.KeyValue{Key: key, Value: value}}})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func Apply(ctx context.Context, cmd *cli.Command) error {
+	ctx, span := otel.Tracer("cli/command/apply").Start(ctx, "Apply")
+	defer span.End()
+
+	// without a file, or with -, the operations are read from stdin
+	var r io.Reader = os.Stdin
+	if file := cmd.StringArg("file"); file != "" && file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	ops, err := operations(r)
+	if err != nil {
+		return err
+	}
+	if len(ops) == 0 {
+		return fmt.Errorf("no operations to apply")
+	}
+
+	fc, err := command.FeatureClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Applying operations", "operations", len(ops))
+	_, err = fc.Batch(ctx, &feature.BatchRequest{Operations: ops})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok && (st.Code() == codes.PermissionDenied || st.Code() == codes.InvalidArgument || st.Code() == codes.FailedPrecondition) {
+			slog.Warn("Batch rejected, nothing was changed", "error", st.Message())
+			return fmt.Errorf("%s", st.Message())
+		}
+		return err
+	}
+	slog.InfoContext(ctx, "Applied operations", "operations", len(ops))
+	return nil
+}
@@ -0,0 +1,54 @@
+package apply
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+)
+
+func TestOperations(t *testing.T) {
+	ops, err := operations(strings.NewReader("# release 1.4\nCOLOR=blue\n\nQUERY=a=b\n-SIZE\nEMPTY=\n"))
+	require.NoError(t, err)
+	require.Len(t, ops, 4)
+	assert.Equal(t, &feature.KeyValue{Key: "COLOR", Value: "blue"}, ops[0].GetSet())
+	assert.Equal(t, "a=b", ops[1].GetSet().Value)
+	assert.Equal(t, "SIZE", ops[2].GetDelete().Name)
+	assert.Equal(t, "", ops[3].GetSet().Value)
+
+	_, err = operations(strings.NewReader("COLOR=blue\nSIZE\n"))
+	assert.ErrorContains(t, err, "line 2")
+
+	_, err = operations(strings.NewReader("-\n"))
+	assert.Error(t, err)
+}
+
+func TestApply_InvalidEndpoint(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "features")
+	require.NoError(t, os.WriteFile(file, []byte("COLOR=blue\n"), 0o600))
+
+	var buf bytes.Buffer
+	cmd := &cli.Command{
+		Writer: &buf,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "endpoint",
+				Value: "invalid:9999", // Use invalid endpoint to avoid actual connection
+			},
+		},
+		Arguments: []cli.Argument{
+			&cli.StringArg{Name: "file"},
+		},
+		Action: Apply,
+	}
+
+	err := cmd.Run(context.Background(), []string{"apply", file})
+	assert.Error(t, err, "Apply should return an error with invalid endpoint")
+}
@@ -11,8 +11,12 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 )
 
+// Redacted is shown instead of the value of a sensitive flag the caller may not reveal
+const Redacted = "<redacted>"
+
 // basicAuthCreds implements credentials.PerRPCCredentials for Basic Auth
 type basicAuthCreds struct {
 	username string
@@ -31,6 +35,44 @@ func (c *basicAuthCreds) RequireTransportSecurity() bool {
 	return false
 }
 
+// bearerTokenCreds implements credentials.PerRPCCredentials for API tokens
+type bearerTokenCreds struct {
+	token string
+}
+
+func (c *bearerTokenCreds) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{
+		"authorization": "Bearer " + c.token,
+	}, nil
+}
+
+func (c *bearerTokenCreds) RequireTransportSecurity() bool {
+	return false
+}
+
+// selectionOptions make every call work on the flag set and environment selected with --flag-set
+// and --environment
+func selectionOptions(cmd *cli.Command) []grpc.DialOption {
+	var pairs []string
+	if set := cmd.String(constant.FlagSet); set != "" {
+		pairs = append(pairs, constant.FlagSetMetadata, set)
+	}
+	if environment := cmd.String(constant.Environment); environment != "" {
+		pairs = append(pairs, constant.EnvironmentMetadata, environment)
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			return invoker(metadata.AppendToOutgoingContext(ctx, pairs...), method, req, reply, cc, opts...)
+		}),
+		grpc.WithChainStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return streamer(metadata.AppendToOutgoingContext(ctx, pairs...), desc, cc, method, opts...)
+		}),
+	}
+}
+
 func FeatureClient(cmd *cli.Command) (feature.FeatureClient, error) {
 	endpoint := cmd.String(constant.Endpoint)
 	username := cmd.String(constant.Username)
@@ -41,14 +83,18 @@ func FeatureClient(cmd *cli.Command) (feature.FeatureClient, error) {
 		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
 	}
 
-	// Add authentication if credentials are provided
-	if username != "" && password != "" {
+	// Add authentication if an API token or credentials are provided, the token takes precedence
+	if token := cmd.String(constant.Token); token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(&bearerTokenCreds{token: token}))
+	} else if username != "" && password != "" {
 		opts = append(opts, grpc.WithPerRPCCredentials(&basicAuthCreds{
 			username: username,
 			password: password,
 		}))
 	}
 
+	opts = append(opts, selectionOptions(cmd)...)
+
 	gc, err := grpc.NewClient(endpoint, opts...)
 	if err != nil {
 		return nil, err
@@ -68,14 +114,18 @@ func WorkloadClient(cmd *cli.Command) (workload.WorkloadClient, error) {
 		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
 	}
 
-	// Add authentication if credentials are provided
-	if username != "" && password != "" {
+	// Add authentication if an API token or credentials are provided, the token takes precedence
+	if token := cmd.String(constant.Token); token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(&bearerTokenCreds{token: token}))
+	} else if username != "" && password != "" {
 		opts = append(opts, grpc.WithPerRPCCredentials(&basicAuthCreds{
 			username: username,
 			password: password,
 		}))
 	}
 
+	opts = append(opts, selectionOptions(cmd)...)
+
 	gc, err := grpc.NewClient(endpoint, opts...)
 	if err != nil {
 		return nil, err
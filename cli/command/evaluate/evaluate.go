@@ -0,0 +1,84 @@
+package evaluate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/dkrizic/feature/cli/command"
+	"github.com/dkrizic/feature/cli/constant"
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/urfave/cli/v3"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// attributes parses name=value pairs into a map
+func attributes(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	result := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid attribute %q, expected name=value", pair)
+		}
+		result[name] = value
+	}
+	return result, nil
+}
+
+func Evaluate(ctx context.Context, cmd *cli.Command) error {
+	ctx, span := otel.Tracer("cli/command/evaluate").Start(ctx, "Evaluate")
+	defer span.End()
+
+	attrs, err := attributes(cmd.StringSlice(constant.Attribute))
+	if err != nil {
+		return err
+	}
+
+	fc, err := command.FeatureClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	key := cmd.StringArg("key")
+
+	slog.InfoContext(ctx, "Evaluating feature", "key", key)
+	result, err := fc.Evaluate(ctx, &feature.EvaluateRequest{
+		Key: key,
+		Context: &feature.EvaluationContext{
+			UserId:     cmd.String(constant.User),
+			Tenant:     cmd.String(constant.Tenant),
+			Region:     cmd.String(constant.Region),
+			Attributes: attrs,
+		},
+	})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+			return fmt.Errorf("%s", st.Message())
+		}
+		return err
+	}
+
+	reason := strings.ToLower(strings.TrimPrefix(result.Reason.String(), "REASON_"))
+	logAttrs := []any{"key", key, "reason", reason}
+	if explanation := result.GetExplanation(); explanation != nil {
+		logAttrs = append(logAttrs, "explanation", explanation.Message)
+		if explanation.Bucket >= 0 {
+			logAttrs = append(logAttrs, "bucketBy", explanation.BucketBy, "bucket", explanation.Bucket)
+		}
+	}
+	if result.RuleIndex >= 0 {
+		logAttrs = append(logAttrs, "rule", result.RuleIndex+1, "name", result.RuleName)
+	}
+	if result.Prerequisite != "" {
+		logAttrs = append(logAttrs, "prerequisite", result.Prerequisite)
+	}
+	slog.InfoContext(ctx, "Evaluated", logAttrs...)
+	cmd.Writer.Write([]byte(result.Value + "\n"))
+	return nil
+}
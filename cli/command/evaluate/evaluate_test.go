@@ -0,0 +1,45 @@
+package evaluate
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/dkrizic/feature/cli/constant"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v3"
+)
+
+func TestAttributes(t *testing.T) {
+	attrs, err := attributes([]string{"appVersion=1.4.2", "plan=pro", "empty="})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"appVersion": "1.4.2", "plan": "pro", "empty": ""}, attrs)
+
+	attrs, err = attributes(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, attrs)
+
+	_, err = attributes([]string{"plan"})
+	assert.Error(t, err)
+
+	_, err = attributes([]string{"=pro"})
+	assert.Error(t, err)
+}
+
+func TestEvaluate_InvalidEndpoint(t *testing.T) {
+	var buf bytes.Buffer
+	cmd := &cli.Command{
+		Writer: &buf,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "endpoint",
+				Value: "invalid:9999", // Use invalid endpoint to avoid actual connection
+			},
+			&cli.StringSliceFlag{Name: constant.Attribute},
+		},
+	}
+
+	err := Evaluate(context.Background(), cmd)
+	assert.Error(t, err, "Evaluate should return an error with invalid endpoint")
+	assert.Empty(t, buf.String())
+}
@@ -0,0 +1,96 @@
+package flagset
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/dkrizic/feature/cli/command"
+	"github.com/dkrizic/feature/cli/constant"
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/urfave/cli/v3"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func List(ctx context.Context, cmd *cli.Command) error {
+	ctx, span := otel.Tracer("cli/command/flagset").Start(ctx, "List")
+	defer span.End()
+
+	fc, err := command.FeatureClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Listing flag sets")
+	result, err := fc.ListSets(ctx, &emptypb.Empty{})
+	if err != nil {
+		return err
+	}
+	for _, set := range result.Sets {
+		cmd.Writer.Write([]byte(formatSet(set)))
+	}
+	return nil
+}
+
+// formatSet prints one set per line, e.g. "shop editable=COLOR,SIZE principals=alice restart=deployment/shop # Shop flags"
+func formatSet(set *feature.FlagSet) string {
+	var b strings.Builder
+	b.WriteString(set.Name)
+	if len(set.Editable) > 0 {
+		fmt.Fprintf(&b, " editable=%s", strings.Join(set.Editable, ","))
+	}
+	if len(set.Principals) > 0 {
+		fmt.Fprintf(&b, " principals=%s", strings.Join(set.Principals, ","))
+	}
+	if set.RestartName != "" {
+		restartType := set.RestartType
+		if restartType == "" {
+			restartType = "deployment"
+		}
+		fmt.Fprintf(&b, " restart=%s/%s", restartType, set.RestartName)
+	}
+	if set.Description != "" {
+		fmt.Fprintf(&b, " # %s", set.Description)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func Create(ctx context.Context, cmd *cli.Command) error {
+	ctx, span := otel.Tracer("cli/command/flagset").Start(ctx, "Create")
+	defer span.End()
+
+	fc, err := command.FeatureClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	name := cmd.StringArg("name")
+	slog.InfoContext(ctx, "Creating flag set", "name", name)
+	_, err = fc.CreateSet(ctx, &feature.FlagSet{
+		Name:        name,
+		Description: cmd.String(constant.Description),
+		Editable:    cmd.StringSlice(constant.Editable),
+		Principals:  cmd.StringSlice(constant.Principal),
+		RestartType: cmd.String(constant.RestartType),
+		RestartName: cmd.String(constant.RestartName),
+	})
+	return err
+}
+
+func Delete(ctx context.Context, cmd *cli.Command) error {
+	ctx, span := otel.Tracer("cli/command/flagset").Start(ctx, "Delete")
+	defer span.End()
+
+	fc, err := command.FeatureClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	name := cmd.StringArg("name")
+	slog.InfoContext(ctx, "Deleting flag set", "name", name)
+	_, err = fc.DeleteSet(ctx, &feature.FlagSet{Name: name})
+	return err
+}
@@ -0,0 +1,47 @@
+package flagset
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v3"
+)
+
+func TestFormatSet(t *testing.T) {
+	assert.Equal(t, "default\n", formatSet(&feature.FlagSet{Name: "default"}))
+	assert.Equal(t, "shop editable=COLOR,SIZE principals=alice restart=deployment/shop # Shop flags\n", formatSet(&feature.FlagSet{
+		Name:        "shop",
+		Description: "Shop flags",
+		Editable:    []string{"COLOR", "SIZE"},
+		Principals:  []string{"alice"},
+		RestartName: "shop",
+	}))
+	assert.Equal(t, "batch restart=statefulset/worker\n", formatSet(&feature.FlagSet{
+		Name:        "batch",
+		RestartType: "statefulset",
+		RestartName: "worker",
+	}))
+}
+
+func TestList_InvalidEndpoint(t *testing.T) {
+	var buf bytes.Buffer
+	cmd := &cli.Command{
+		Writer: &buf,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "endpoint",
+				Value: "invalid:9999", // Use invalid endpoint to avoid actual connection
+			},
+			&cli.StringFlag{
+				Name:  "flag-set",
+				Value: "shop",
+			},
+		},
+	}
+
+	err := List(context.Background(), cmd)
+	assert.Error(t, err, "List should return an error with invalid endpoint")
+}
@@ -2,14 +2,48 @@ package getall
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/dkrizic/feature/cli/command"
+	"github.com/dkrizic/feature/cli/constant"
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
 	"github.com/urfave/cli/v3"
 	"go.opentelemetry.io/otel"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
+// filter selects features by their key and metadata
+type filter struct {
+	search string
+	owner  string
+	tags   []string
+}
+
+// matches reports whether the feature passes all filters, empty filters match everything
+func (f filter) matches(kv *feature.KeyValue) bool {
+	metadata := kv.GetMetadata()
+	if f.owner != "" && !strings.EqualFold(f.owner, metadata.GetOwner()) {
+		return false
+	}
+	for _, tag := range f.tags {
+		if !slices.Contains(metadata.GetTags(), tag) {
+			return false
+		}
+	}
+	if f.search != "" {
+		search := strings.ToLower(f.search)
+		fields := append([]string{kv.Key, metadata.GetDescription(), metadata.GetOwner()}, metadata.GetTags()...)
+		return slices.ContainsFunc(fields, func(field string) bool {
+			return strings.Contains(strings.ToLower(field), search)
+		})
+	}
+	return true
+}
+
 func GetAll(ctx context.Context, cmd *cli.Command) error {
 	ctx, span := otel.Tracer("cli/command/getall").Start(ctx, "GetAll")
 	defer span.End()
@@ -19,6 +53,12 @@ func GetAll(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
+	f := filter{
+		search: cmd.String(constant.Search),
+		owner:  cmd.String(constant.Owner),
+		tags:   cmd.StringSlice(constant.Tag),
+	}
+
 	slog.InfoContext(ctx, "Getting all features")
 	all, err := fc.GetAll(ctx, &emptypb.Empty{})
 	if err != nil {
@@ -29,11 +69,60 @@ func GetAll(ctx context.Context, cmd *cli.Command) error {
 		if err != nil {
 			break
 		}
+		if !f.matches(kv) {
+			continue
+		}
 		editableStatus := "editable"
 		if !kv.Editable {
 			editableStatus = "read-only"
 		}
-		slog.InfoContext(ctx, "Feature", "key", kv.Key, "value", kv.Value, "editable", editableStatus)
+		valueType := strings.ToLower(strings.TrimPrefix(kv.Type.String(), "VALUE_TYPE_"))
+		value := kv.Value
+		if kv.Redacted {
+			value = command.Redacted
+		}
+		attrs := []any{"key", kv.Key, "value", value, "type", valueType, "editable", editableStatus, "revision", kv.Revision}
+		if variants := kv.GetRollout().GetVariants(); len(variants) > 0 {
+			rollout := make([]string, 0, len(variants))
+			for _, v := range variants {
+				rollout = append(rollout, fmt.Sprintf("%s=%g%%", v.Value, v.Percentage))
+			}
+			attrs = append(attrs, "rollout", strings.Join(rollout, ","))
+		}
+		if len(kv.Prerequisites) > 0 {
+			requires := make([]string, 0, len(kv.Prerequisites))
+			for _, p := range kv.Prerequisites {
+				requires = append(requires, p.Key+"="+p.Value)
+			}
+			attrs = append(attrs, "requires", strings.Join(requires, ","))
+			if kv.OffValue != "" {
+				attrs = append(attrs, "offValue", kv.OffValue)
+			}
+		}
+		if metadata := kv.GetMetadata(); metadata != nil {
+			if metadata.Description != "" {
+				attrs = append(attrs, "description", metadata.Description)
+			}
+			if metadata.Owner != "" {
+				attrs = append(attrs, "owner", metadata.Owner)
+			}
+			if len(metadata.Tags) > 0 {
+				attrs = append(attrs, "tags", strings.Join(metadata.Tags, ","))
+			}
+			if metadata.UpdatedAt != nil {
+				attrs = append(attrs, "updated", metadata.UpdatedAt.AsTime().Format(time.RFC3339))
+			}
+			if metadata.LastModifiedBy != "" {
+				attrs = append(attrs, "by", metadata.LastModifiedBy)
+			}
+			if metadata.ExpiresAt != nil {
+				attrs = append(attrs, "expires", metadata.ExpiresAt.AsTime().Format(time.DateOnly))
+			}
+			if metadata.Lifecycle != feature.Lifecycle_LIFECYCLE_UNSPECIFIED {
+				attrs = append(attrs, "lifecycle", strings.ToLower(strings.TrimPrefix(metadata.Lifecycle.String(), "LIFECYCLE_")))
+			}
+		}
+		slog.InfoContext(ctx, "Feature", attrs...)
 	}
 	return nil
 }
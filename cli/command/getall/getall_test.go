@@ -5,6 +5,7 @@ import (
 	"context"
 	"testing"
 
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/urfave/cli/v3"
 )
@@ -29,3 +30,27 @@ func TestGetAll_InvalidEndpoint(t *testing.T) {
 	// We expect an error because the connection will fail
 	assert.Error(t, err, "GetAll should return an error with invalid endpoint")
 }
+
+func TestFilter_Matches(t *testing.T) {
+	kv := &feature.KeyValue{
+		Key: "BOOKING",
+		Metadata: &feature.Metadata{
+			Description: "Enables the new booking flow",
+			Owner:       "team-checkout",
+			Tags:        []string{"checkout", "q3"},
+		},
+	}
+	untagged := &feature.KeyValue{Key: "COLOR"}
+
+	assert.True(t, filter{}.matches(kv))
+	assert.True(t, filter{}.matches(untagged))
+	assert.True(t, filter{owner: "Team-Checkout"}.matches(kv))
+	assert.False(t, filter{owner: "team-search"}.matches(kv))
+	assert.True(t, filter{tags: []string{"checkout", "q3"}}.matches(kv))
+	assert.False(t, filter{tags: []string{"checkout", "q4"}}.matches(kv))
+	assert.False(t, filter{tags: []string{"checkout"}}.matches(untagged))
+	assert.True(t, filter{search: "booking flow"}.matches(kv))
+	assert.True(t, filter{search: "Q3"}.matches(kv))
+	assert.False(t, filter{search: "payment"}.matches(kv))
+	assert.True(t, filter{search: "col"}.matches(untagged))
+}
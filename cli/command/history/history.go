@@ -0,0 +1,61 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/dkrizic/feature/cli/command"
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/urfave/cli/v3"
+	"go.opentelemetry.io/otel"
+)
+
+func History(ctx context.Context, cmd *cli.Command) error {
+	ctx, span := otel.Tracer("cli/command/history").Start(ctx, "History")
+	defer span.End()
+
+	fc, err := command.FeatureClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	key := cmd.StringArg("key")
+
+	slog.InfoContext(ctx, "Getting history", "key", key)
+	result, err := fc.History(ctx, &feature.Key{Name: key})
+	if err != nil {
+		return err
+	}
+	for _, entry := range result.Entries {
+		cmd.Writer.Write([]byte(formatEntry(entry)))
+	}
+	return nil
+}
+
+// formatEntry prints one change per line, e.g. "2 2025-06-01T12:00:00Z update red -> blue by alice via Set".
+// The values of sensitive flags are only shown to callers allowed to reveal them.
+func formatEntry(entry *feature.HistoryEntry) string {
+	oldValue, newValue := entry.OldValue, entry.NewValue
+	if entry.Redacted {
+		oldValue, newValue = command.Redacted, command.Redacted
+	}
+	var b strings.Builder
+	action := strings.ToLower(strings.TrimPrefix(entry.Type.String(), "EVENT_TYPE_"))
+	fmt.Fprintf(&b, "%d %s %s ", entry.Revision, entry.Timestamp.AsTime().Format(time.RFC3339), action)
+	switch entry.Type {
+	case feature.EventType_EVENT_TYPE_CREATE:
+		fmt.Fprintf(&b, "%s", newValue)
+	case feature.EventType_EVENT_TYPE_DELETE:
+		fmt.Fprintf(&b, "%s", oldValue)
+	default:
+		fmt.Fprintf(&b, "%s -> %s", oldValue, newValue)
+	}
+	if entry.Actor != "" {
+		fmt.Fprintf(&b, " by %s", entry.Actor)
+	}
+	fmt.Fprintf(&b, " via %s\n", entry.Source)
+	return b.String()
+}
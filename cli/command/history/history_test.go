@@ -0,0 +1,64 @@
+package history
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v3"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestFormatEntry(t *testing.T) {
+	changed := timestamppb.New(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	assert.Equal(t, "2 2025-06-01T12:00:00Z update red -> blue by alice via Set\n", formatEntry(&feature.HistoryEntry{
+		Revision:  2,
+		Type:      feature.EventType_EVENT_TYPE_UPDATE,
+		OldValue:  "red",
+		NewValue:  "blue",
+		Actor:     "alice",
+		Timestamp: changed,
+		Source:    "Set",
+	}))
+	assert.Equal(t, "1 2025-06-01T12:00:00Z create red via PreSet\n", formatEntry(&feature.HistoryEntry{
+		Revision:  1,
+		Type:      feature.EventType_EVENT_TYPE_CREATE,
+		NewValue:  "red",
+		Timestamp: changed,
+		Source:    "PreSet",
+	}))
+	assert.Equal(t, "3 2025-06-01T12:00:00Z delete blue via Delete\n", formatEntry(&feature.HistoryEntry{
+		Revision:  3,
+		Type:      feature.EventType_EVENT_TYPE_DELETE,
+		OldValue:  "blue",
+		Timestamp: changed,
+		Source:    "Delete",
+	}))
+	assert.Equal(t, "4 2025-06-01T12:00:00Z update <redacted> -> <redacted> by alice via Set\n", formatEntry(&feature.HistoryEntry{
+		Revision:  4,
+		Type:      feature.EventType_EVENT_TYPE_UPDATE,
+		Actor:     "alice",
+		Timestamp: changed,
+		Source:    "Set",
+		Redacted:  true,
+	}))
+}
+
+func TestHistory_InvalidEndpoint(t *testing.T) {
+	var buf bytes.Buffer
+	cmd := &cli.Command{
+		Writer: &buf,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "endpoint",
+				Value: "invalid:9999", // Use invalid endpoint to avoid actual connection
+			},
+		},
+	}
+
+	err := History(context.Background(), cmd)
+	assert.Error(t, err, "History should return an error with invalid endpoint")
+}
@@ -0,0 +1,65 @@
+package prerequisites
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/dkrizic/feature/cli/command"
+	"github.com/dkrizic/feature/cli/constant"
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/urfave/cli/v3"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// prerequisites parses KEY=VALUE pairs, the key never contains =
+func prerequisites(pairs []string) ([]*feature.Prerequisite, error) {
+	var result []*feature.Prerequisite
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid prerequisite %q, expected KEY=VALUE", pair)
+		}
+		result = append(result, &feature.Prerequisite{Key: key, Value: value})
+	}
+	return result, nil
+}
+
+func Prerequisites(ctx context.Context, cmd *cli.Command) error {
+	ctx, span := otel.Tracer("cli/command/prerequisites").Start(ctx, "Prerequisites")
+	defer span.End()
+
+	p, err := prerequisites(cmd.StringSlice(constant.Requires))
+	if err != nil {
+		return err
+	}
+
+	fc, err := command.FeatureClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	key := cmd.StringArg("key")
+
+	if len(p) == 0 {
+		slog.InfoContext(ctx, "Removing prerequisites", "key", key)
+	} else {
+		slog.InfoContext(ctx, "Setting prerequisites", "key", key, "prerequisites", len(p))
+	}
+	_, err = fc.SetPrerequisites(ctx, &feature.Prerequisites{
+		Key:           key,
+		Prerequisites: p,
+		OffValue:      cmd.String(constant.OffValue),
+	})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok && (st.Code() == codes.PermissionDenied || st.Code() == codes.InvalidArgument || st.Code() == codes.NotFound) {
+			slog.Warn("Prerequisites rejected", "key", key, "error", st.Message())
+			return fmt.Errorf("%s", st.Message())
+		}
+	}
+	return err
+}
@@ -0,0 +1,48 @@
+package prerequisites
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/dkrizic/feature/cli/constant"
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v3"
+)
+
+func TestPrerequisites(t *testing.T) {
+	p, err := prerequisites([]string{"NEW_CHECKOUT=true", "THEME=a=b", "LABEL="})
+	assert.NoError(t, err)
+	assert.Len(t, p, 3)
+	assert.Equal(t, &feature.Prerequisite{Key: "NEW_CHECKOUT", Value: "true"}, p[0])
+	assert.Equal(t, "a=b", p[1].Value)
+	assert.Equal(t, "", p[2].Value)
+
+	p, err = prerequisites(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, p)
+
+	_, err = prerequisites([]string{"NEW_CHECKOUT"})
+	assert.Error(t, err)
+
+	_, err = prerequisites([]string{"=true"})
+	assert.Error(t, err)
+}
+
+func TestPrerequisites_InvalidEndpoint(t *testing.T) {
+	var buf bytes.Buffer
+	cmd := &cli.Command{
+		Writer: &buf,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "endpoint",
+				Value: "invalid:9999", // Use invalid endpoint to avoid actual connection
+			},
+			&cli.StringSliceFlag{Name: constant.Requires},
+		},
+	}
+
+	err := Prerequisites(context.Background(), cmd)
+	assert.Error(t, err, "Prerequisites should return an error with invalid endpoint")
+}
@@ -0,0 +1,72 @@
+package promote
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/dkrizic/feature/cli/command"
+	"github.com/dkrizic/feature/cli/constant"
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/urfave/cli/v3"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Promote(ctx context.Context, cmd *cli.Command) error {
+	ctx, span := otel.Tracer("cli/command/promote").Start(ctx, "Promote")
+	defer span.End()
+
+	req := &feature.PromoteRequest{
+		Key:      cmd.StringArg("key"),
+		From:     cmd.StringArg("from"),
+		To:       cmd.StringArg("to"),
+		DryRun:   cmd.Bool(constant.DryRun),
+		Revision: cmd.Uint64(constant.Revision),
+	}
+	if req.Key == "" || req.From == "" || req.To == "" {
+		return fmt.Errorf("key, from and to are required")
+	}
+
+	fc, err := command.FeatureClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Promoting feature", "key", req.Key, "from", req.From, "to", req.To, "dryRun", req.DryRun)
+	result, err := fc.Promote(ctx, req)
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok && (st.Code() == codes.PermissionDenied || st.Code() == codes.InvalidArgument || st.Code() == codes.NotFound || st.Code() == codes.FailedPrecondition) {
+			slog.Warn("Promote rejected", "key", req.Key, "from", req.From, "to", req.To, "error", st.Message())
+			return fmt.Errorf("%s", st.Message())
+		}
+		return err
+	}
+	cmd.Writer.Write([]byte(formatPromotion(req, result)))
+	return nil
+}
+
+// formatPromotion prints the changes of a promotion, one per line, followed by the outcome, e.g.
+//
+//	COLOR staging -> prod
+//	  value: "red" -> "blue"
+//	promoted
+func formatPromotion(req *feature.PromoteRequest, result *feature.PromoteResponse) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s -> %s\n", req.Key, req.From, req.To)
+	for _, change := range result.Changes {
+		fmt.Fprintf(&b, "  %s\n", change)
+	}
+	switch {
+	case len(result.Changes) == 0:
+		b.WriteString("no changes\n")
+	case result.Applied:
+		b.WriteString("promoted\n")
+	default:
+		b.WriteString("dry run, nothing promoted\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,39 @@
+package promote
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v3"
+)
+
+func TestFormatPromotion(t *testing.T) {
+	req := &feature.PromoteRequest{Key: "COLOR", From: "staging", To: "prod"}
+	assert.Equal(t, "COLOR staging -> prod\n  value: \"red\" -> \"blue\"\npromoted\n", formatPromotion(req, &feature.PromoteResponse{
+		Changes: []string{`value: "red" -> "blue"`},
+		Applied: true,
+	}))
+	assert.Equal(t, "COLOR staging -> prod\n  new key\n  value: \"\" -> \"blue\"\ndry run, nothing promoted\n", formatPromotion(req, &feature.PromoteResponse{
+		Changes: []string{"new key", `value: "" -> "blue"`},
+	}))
+	assert.Equal(t, "COLOR staging -> prod\nno changes\n", formatPromotion(req, &feature.PromoteResponse{}))
+}
+
+func TestPromote_MissingArguments(t *testing.T) {
+	var buf bytes.Buffer
+	cmd := &cli.Command{
+		Writer: &buf,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "endpoint",
+				Value: "invalid:9999", // Use invalid endpoint to avoid actual connection
+			},
+		},
+	}
+
+	err := Promote(context.Background(), cmd)
+	assert.Error(t, err, "Promote should require key, from and to")
+}
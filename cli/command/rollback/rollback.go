@@ -0,0 +1,41 @@
+package rollback
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/dkrizic/feature/cli/command"
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/urfave/cli/v3"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Rollback(ctx context.Context, cmd *cli.Command) error {
+	ctx, span := otel.Tracer("cli/command/rollback").Start(ctx, "Rollback")
+	defer span.End()
+
+	key := cmd.StringArg("key")
+	revision := cmd.Uint64Arg("revision")
+	if revision == 0 {
+		return fmt.Errorf("revision is required, see the history of %s", key)
+	}
+
+	fc, err := command.FeatureClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Rolling back feature", "key", key, "revision", revision)
+	_, err = fc.Rollback(ctx, &feature.RollbackRequest{Key: key, Revision: revision})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok && (st.Code() == codes.PermissionDenied || st.Code() == codes.InvalidArgument || st.Code() == codes.NotFound) {
+			slog.Warn("Rollback rejected", "key", key, "revision", revision, "error", st.Message())
+			return fmt.Errorf("%s", st.Message())
+		}
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/dkrizic/feature/cli/command"
+	"github.com/dkrizic/feature/cli/constant"
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/urfave/cli/v3"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// variants parses value=percentage pairs, the percentage may end with %
+func variants(pairs []string) ([]*feature.Variant, error) {
+	var result []*feature.Variant
+	for _, pair := range pairs {
+		// the value may contain = itself, the percentage never does
+		i := strings.LastIndex(pair, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid variant %q, expected value=percentage", pair)
+		}
+		percentage, err := strconv.ParseFloat(strings.TrimSuffix(pair[i+1:], "%"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid percentage in variant %q: %w", pair, err)
+		}
+		result = append(result, &feature.Variant{Value: pair[:i], Percentage: percentage})
+	}
+	return result, nil
+}
+
+func Rollout(ctx context.Context, cmd *cli.Command) error {
+	ctx, span := otel.Tracer("cli/command/rollout").Start(ctx, "Rollout")
+	defer span.End()
+
+	v, err := variants(cmd.StringSlice(constant.Variant))
+	if err != nil {
+		return err
+	}
+
+	fc, err := command.FeatureClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	key := cmd.StringArg("key")
+
+	if len(v) == 0 {
+		slog.InfoContext(ctx, "Removing rollout", "key", key)
+	} else {
+		slog.InfoContext(ctx, "Setting rollout", "key", key, "variants", len(v))
+	}
+	_, err = fc.SetRollout(ctx, &feature.SetRolloutRequest{
+		Key: key,
+		Rollout: &feature.Rollout{
+			BucketBy: cmd.String(constant.BucketBy),
+			Variants: v,
+		},
+	})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok && (st.Code() == codes.PermissionDenied || st.Code() == codes.InvalidArgument || st.Code() == codes.NotFound) {
+			slog.Warn("Rollout rejected", "key", key, "error", st.Message())
+			return fmt.Errorf("%s", st.Message())
+		}
+	}
+	return err
+}
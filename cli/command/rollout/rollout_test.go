@@ -0,0 +1,48 @@
+package rollout
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/dkrizic/feature/cli/constant"
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v3"
+)
+
+func TestVariants(t *testing.T) {
+	v, err := variants([]string{"true=5", "blue=12.5%", "a=b=50"})
+	assert.NoError(t, err)
+	assert.Len(t, v, 3)
+	assert.Equal(t, &feature.Variant{Value: "true", Percentage: 5}, v[0])
+	assert.Equal(t, 12.5, v[1].Percentage)
+	assert.Equal(t, "a=b", v[2].Value)
+
+	v, err = variants(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, v)
+
+	_, err = variants([]string{"true"})
+	assert.Error(t, err)
+
+	_, err = variants([]string{"true=many"})
+	assert.Error(t, err)
+}
+
+func TestRollout_InvalidEndpoint(t *testing.T) {
+	var buf bytes.Buffer
+	cmd := &cli.Command{
+		Writer: &buf,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "endpoint",
+				Value: "invalid:9999", // Use invalid endpoint to avoid actual connection
+			},
+			&cli.StringSliceFlag{Name: constant.Variant},
+		},
+	}
+
+	err := Rollout(context.Background(), cmd)
+	assert.Error(t, err, "Rollout should return an error with invalid endpoint")
+}
@@ -0,0 +1,136 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/dkrizic/feature/cli/command"
+	"github.com/dkrizic/feature/cli/constant"
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/urfave/cli/v3"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ParseAt parses the time of a schedule, either RFC 3339 like 2025-06-01T08:00:00Z or a duration
+// from now like 2h30m
+func ParseAt(s string, now time.Time) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, s); err == nil {
+		return at, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return time.Time{}, fmt.Errorf("invalid time: %s, expected RFC 3339 or a positive duration", s)
+	}
+	return now.Add(d), nil
+}
+
+func Set(ctx context.Context, cmd *cli.Command) error {
+	ctx, span := otel.Tracer("cli/command/schedule").Start(ctx, "Set")
+	defer span.End()
+
+	key := cmd.StringArg("key")
+	value := cmd.StringArg("value")
+	if key == "" {
+		return fmt.Errorf("key is required")
+	}
+	at, err := ParseAt(cmd.String(constant.At), time.Now())
+	if err != nil {
+		return err
+	}
+
+	fc, err := command.FeatureClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Scheduling feature", "key", key, "at", at, "restart", cmd.Bool(constant.Restart))
+	schedule, err := fc.ScheduleSet(ctx, &feature.ScheduleSetRequest{
+		Key:     key,
+		Value:   value,
+		At:      timestamppb.New(at),
+		Restart: cmd.Bool(constant.Restart),
+	})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok && (st.Code() == codes.PermissionDenied || st.Code() == codes.InvalidArgument || st.Code() == codes.FailedPrecondition) {
+			slog.Warn("Schedule rejected", "key", key, "error", st.Message())
+			return fmt.Errorf("%s", st.Message())
+		}
+		return err
+	}
+	cmd.Writer.Write([]byte(formatSchedule(schedule)))
+	return nil
+}
+
+func List(ctx context.Context, cmd *cli.Command) error {
+	ctx, span := otel.Tracer("cli/command/schedule").Start(ctx, "List")
+	defer span.End()
+
+	fc, err := command.FeatureClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Listing schedules")
+	result, err := fc.ListSchedules(ctx, &emptypb.Empty{})
+	if err != nil {
+		return err
+	}
+	for _, schedule := range result.Schedules {
+		cmd.Writer.Write([]byte(formatSchedule(schedule)))
+	}
+	return nil
+}
+
+func Cancel(ctx context.Context, cmd *cli.Command) error {
+	ctx, span := otel.Tracer("cli/command/schedule").Start(ctx, "Cancel")
+	defer span.End()
+
+	id := cmd.StringArg("id")
+	if id == "" {
+		return fmt.Errorf("id is required")
+	}
+
+	fc, err := command.FeatureClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Cancelling schedule", "id", id)
+	_, err = fc.CancelSchedule(ctx, &feature.Schedule{Id: id})
+	return err
+}
+
+// formatSchedule prints one schedule per line, e.g.
+// "4f2a9c 2025-06-01T08:00:00Z prod COLOR=blue restart by alice". Failed schedules end with the
+// error.
+func formatSchedule(schedule *feature.Schedule) string {
+	value := schedule.Value
+	if schedule.Redacted {
+		value = command.Redacted
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s", schedule.Id, schedule.At.AsTime().Format(time.RFC3339))
+	if schedule.Environment != "" {
+		fmt.Fprintf(&b, " %s", schedule.Environment)
+	}
+	fmt.Fprintf(&b, " %s=%s", schedule.Key, value)
+	if schedule.Restart {
+		b.WriteString(" restart")
+	}
+	if schedule.CreatedBy != "" {
+		fmt.Fprintf(&b, " by %s", schedule.CreatedBy)
+	}
+	if schedule.Error != "" {
+		fmt.Fprintf(&b, " failed: %s", schedule.Error)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestParseAt(t *testing.T) {
+	now := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+
+	at, err := ParseAt("2025-06-02T10:30:00+02:00", now)
+	require.NoError(t, err)
+	assert.True(t, at.Equal(time.Date(2025, 6, 2, 8, 30, 0, 0, time.UTC)))
+	at, err = ParseAt("2h30m", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(150*time.Minute), at)
+
+	for _, s := range []string{"", "tomorrow", "-1h", "2025-06-02"} {
+		_, err := ParseAt(s, now)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestFormatSchedule(t *testing.T) {
+	at := timestamppb.New(time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC))
+	assert.Equal(t, "4f2a 2025-06-01T08:00:00Z prod COLOR=blue restart by alice\n", formatSchedule(&feature.Schedule{
+		Id: "4f2a", At: at, Environment: "prod", Key: "COLOR", Value: "blue", Restart: true, CreatedBy: "alice",
+	}))
+	assert.Equal(t, "4f2a 2025-06-01T08:00:00Z TOKEN=<redacted> failed: field 'TOKEN' is not editable\n", formatSchedule(&feature.Schedule{
+		Id: "4f2a", At: at, Key: "TOKEN", Redacted: true, Error: "field 'TOKEN' is not editable",
+	}))
+}
+
+func TestSet_MissingArguments(t *testing.T) {
+	var buf bytes.Buffer
+	cmd := &cli.Command{
+		Writer: &buf,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "endpoint",
+				Value: "invalid:9999", // Use invalid endpoint to avoid actual connection
+			},
+		},
+	}
+
+	err := Set(context.Background(), cmd)
+	assert.Error(t, err, "Set should require a key and a time")
+}
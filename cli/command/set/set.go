@@ -4,15 +4,107 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/dkrizic/feature/cli/command"
+	"github.com/dkrizic/feature/cli/constant"
 	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
 	"github.com/urfave/cli/v3"
 	"go.opentelemetry.io/otel"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// ValidType reports whether s names a value type, the empty string keeps the stored type
+func ValidType(s string) bool {
+	_, err := valueType(s)
+	return err == nil
+}
+
+func valueType(s string) (feature.ValueType, error) {
+	if s == "" {
+		return feature.ValueType_VALUE_TYPE_UNSPECIFIED, nil
+	}
+	t, ok := feature.ValueType_value["VALUE_TYPE_"+strings.ToUpper(s)]
+	if !ok || t == 0 {
+		return feature.ValueType_VALUE_TYPE_UNSPECIFIED, fmt.Errorf("invalid type: %s", s)
+	}
+	return feature.ValueType(t), nil
+}
+
+// constraints builds the constraints from the flags, nil if none are set
+func constraints(cmd *cli.Command) *feature.Constraints {
+	c := &feature.Constraints{
+		Pattern:       cmd.String(constant.Pattern),
+		AllowedValues: cmd.StringSlice(constant.Allowed),
+		JsonSchema:    cmd.String(constant.Schema),
+	}
+	if cmd.IsSet(constant.Min) {
+		min := cmd.Float64(constant.Min)
+		c.Min = &min
+	}
+	if cmd.IsSet(constant.Max) {
+		max := cmd.Float64(constant.Max)
+		c.Max = &max
+	}
+	if c.Min == nil && c.Max == nil && c.Pattern == "" && len(c.AllowedValues) == 0 && c.JsonSchema == "" {
+		return nil
+	}
+	return c
+}
+
+// ParseExpires parses an expiry date, RFC 3339 like 2025-06-01T08:00:00Z or a date like 2025-06-01
+// meaning its start in UTC
+func ParseExpires(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiry: %s, expected RFC 3339 or a date like 2025-06-01", s)
+	}
+	return t, nil
+}
+
+func lifecycle(s string) (feature.Lifecycle, error) {
+	if s == "" {
+		return feature.Lifecycle_LIFECYCLE_UNSPECIFIED, nil
+	}
+	l, ok := feature.Lifecycle_value["LIFECYCLE_"+strings.ToUpper(s)]
+	if !ok || l == 0 {
+		return feature.Lifecycle_LIFECYCLE_UNSPECIFIED, fmt.Errorf("invalid lifecycle: %s, expected temporary or permanent", s)
+	}
+	return feature.Lifecycle(l), nil
+}
+
+// metadata builds the metadata from the flags, nil keeps the stored metadata
+func metadata(cmd *cli.Command) (*feature.Metadata, error) {
+	if !cmd.IsSet(constant.Description) && !cmd.IsSet(constant.Owner) && !cmd.IsSet(constant.Tag) &&
+		!cmd.IsSet(constant.Expires) && !cmd.IsSet(constant.Lifecycle) {
+		return nil, nil
+	}
+	l, err := lifecycle(cmd.String(constant.Lifecycle))
+	if err != nil {
+		return nil, err
+	}
+	m := &feature.Metadata{
+		Description: cmd.String(constant.Description),
+		Owner:       cmd.String(constant.Owner),
+		Tags:        cmd.StringSlice(constant.Tag),
+		Lifecycle:   l,
+	}
+	if expires := cmd.String(constant.Expires); expires != "" {
+		t, err := ParseExpires(expires)
+		if err != nil {
+			return nil, err
+		}
+		m.ExpiresAt = timestamppb.New(t)
+	}
+	return m, nil
+}
+
 func Set(ctx context.Context, cmd *cli.Command) error {
 	ctx, span := otel.Tracer("cli/command/set").Start(ctx, "Set")
 	defer span.End()
@@ -24,21 +116,41 @@ func Set(ctx context.Context, cmd *cli.Command) error {
 
 	key := cmd.StringArg("key")
 	value := cmd.StringArg("value")
+	t, err := valueType(cmd.String(constant.Type))
+	if err != nil {
+		return err
+	}
+	m, err := metadata(cmd)
+	if err != nil {
+		return err
+	}
 
 	slog.Info("Setting feature", "key", key, "value", value)
 	_, err = fc.Set(ctx, &feature.KeyValue{
-		Key:   key,
-		Value: value,
+		Key:         key,
+		Value:       value,
+		Type:        t,
+		Constraints: constraints(cmd),
+		Metadata:    m,
+		Revision:    cmd.Uint64(constant.Revision),
 	})
-	
-	// Check if the error is a PermissionDenied error
+
+	// Check if the error is a PermissionDenied or InvalidArgument error
 	if err != nil {
 		st, ok := status.FromError(err)
 		if ok && st.Code() == codes.PermissionDenied {
 			slog.Warn("Permission denied", "key", key, "error", st.Message())
 			return fmt.Errorf("%s", st.Message())
 		}
+		if ok && st.Code() == codes.InvalidArgument {
+			slog.Warn("Invalid value", "key", key, "error", st.Message())
+			return fmt.Errorf("%s", st.Message())
+		}
+		if ok && st.Code() == codes.FailedPrecondition {
+			slog.Warn("Feature was changed", "key", key, "error", st.Message())
+			return fmt.Errorf("%s", st.Message())
+		}
 	}
-	
+
 	return err
 }
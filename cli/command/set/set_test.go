@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"testing"
+	"time"
 
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/urfave/cli/v3"
 )
@@ -30,3 +32,33 @@ func TestSet_InvalidEndpoint(t *testing.T) {
 	// We expect an error because the connection will fail
 	assert.Error(t, err, "Set should return an error with invalid endpoint")
 }
+
+func TestValidType(t *testing.T) {
+	assert.True(t, ValidType(""))
+	assert.True(t, ValidType("boolean"))
+	assert.True(t, ValidType("JSON"))
+	assert.False(t, ValidType("unspecified"))
+	assert.False(t, ValidType("date"))
+}
+
+func TestParseExpires(t *testing.T) {
+	expires, err := ParseExpires("2025-06-01")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), expires)
+	expires, err = ParseExpires("2025-06-01T10:00:00+02:00")
+	assert.NoError(t, err)
+	assert.True(t, expires.Equal(time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)))
+	_, err = ParseExpires("next week")
+	assert.Error(t, err)
+}
+
+func TestLifecycle(t *testing.T) {
+	l, err := lifecycle("Temporary")
+	assert.NoError(t, err)
+	assert.Equal(t, feature.Lifecycle_LIFECYCLE_TEMPORARY, l)
+	l, err = lifecycle("")
+	assert.NoError(t, err)
+	assert.Equal(t, feature.Lifecycle_LIFECYCLE_UNSPECIFIED, l)
+	_, err = lifecycle("unspecified")
+	assert.Error(t, err)
+}
@@ -0,0 +1,61 @@
+package stale
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/dkrizic/feature/cli/command"
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/urfave/cli/v3"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Stale prints the stale features of the flag set and fails if there are any, so it can be used as
+// a gate in CI
+func Stale(ctx context.Context, cmd *cli.Command) error {
+	ctx, span := otel.Tracer("cli/command/stale").Start(ctx, "Stale")
+	defer span.End()
+
+	fc, err := command.FeatureClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Listing stale features")
+	result, err := fc.ListStale(ctx, &emptypb.Empty{})
+	if err != nil {
+		return err
+	}
+	for _, flag := range result.Flags {
+		cmd.Writer.Write([]byte(formatStale(flag)))
+	}
+	if len(result.Flags) > 0 {
+		return fmt.Errorf("%d stale features", len(result.Flags))
+	}
+	return nil
+}
+
+// formatStale prints one stale feature per line, e.g.
+// "NEW_CHECKOUT expired 2025-06-01 owner shop last read 2025-05-30T08:00:00Z"
+func formatStale(flag *feature.StaleFlag) string {
+	reason := strings.ToLower(strings.TrimPrefix(flag.Reason.String(), "STALE_REASON_"))
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s", flag.Key, reason)
+	if flag.ExpiresAt != nil {
+		fmt.Fprintf(&b, " %s", flag.ExpiresAt.AsTime().Format(time.DateOnly))
+	}
+	if flag.Owner != "" {
+		fmt.Fprintf(&b, " owner %s", flag.Owner)
+	}
+	if flag.LastRead != nil {
+		fmt.Fprintf(&b, " last read %s", flag.LastRead.AsTime().Format(time.RFC3339))
+	} else {
+		b.WriteString(" never read")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,39 @@
+package stale
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v3"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestFormatStale(t *testing.T) {
+	at := timestamppb.New(time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC))
+	assert.Equal(t, "NEW_CHECKOUT expired 2025-06-01 owner shop last read 2025-06-01T08:00:00Z\n", formatStale(&feature.StaleFlag{
+		Key: "NEW_CHECKOUT", Reason: feature.StaleReason_STALE_REASON_EXPIRED, ExpiresAt: at, Owner: "shop", LastRead: at,
+	}))
+	assert.Equal(t, "OLD_BANNER unused never read\n", formatStale(&feature.StaleFlag{
+		Key: "OLD_BANNER", Reason: feature.StaleReason_STALE_REASON_UNUSED, Since: at,
+	}))
+}
+
+func TestStale_InvalidEndpoint(t *testing.T) {
+	var buf bytes.Buffer
+	cmd := &cli.Command{
+		Writer: &buf,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "endpoint",
+				Value: "invalid:9999", // Use invalid endpoint to avoid actual connection
+			},
+		},
+	}
+
+	err := Stale(context.Background(), cmd)
+	assert.Error(t, err, "Stale should fail without a reachable service")
+}
@@ -0,0 +1,152 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/dkrizic/feature/cli/command"
+	"github.com/dkrizic/feature/cli/command/schedule"
+	"github.com/dkrizic/feature/cli/constant"
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/urfave/cli/v3"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// scopes maps the names of the scopes to those of the API
+var scopes = map[string]feature.TokenScope{
+	"read":    feature.TokenScope_TOKEN_SCOPE_READ,
+	"write":   feature.TokenScope_TOKEN_SCOPE_WRITE,
+	"restart": feature.TokenScope_TOKEN_SCOPE_RESTART,
+}
+
+// ParseScope parses the name of a scope: read, write or restart
+func ParseScope(s string) (feature.TokenScope, error) {
+	scope, ok := scopes[strings.ToLower(s)]
+	if !ok {
+		return 0, fmt.Errorf("invalid scope: %s, expected read, write or restart", s)
+	}
+	return scope, nil
+}
+
+// scopeName returns the name of a scope of the API
+func scopeName(scope feature.TokenScope) string {
+	for name, value := range scopes {
+		if value == scope {
+			return name
+		}
+	}
+	return scope.String()
+}
+
+func Create(ctx context.Context, cmd *cli.Command) error {
+	ctx, span := otel.Tracer("cli/command/token").Start(ctx, "Create")
+	defer span.End()
+
+	name := cmd.StringArg("name")
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+	req := &feature.CreateTokenRequest{Name: name}
+	for _, s := range cmd.StringSlice(constant.Scope) {
+		scope, err := ParseScope(s)
+		if err != nil {
+			return err
+		}
+		req.Scopes = append(req.Scopes, scope)
+	}
+	if len(req.Scopes) == 0 {
+		return fmt.Errorf("at least one --scope is required")
+	}
+	if expires := cmd.String(constant.Expires); expires != "" {
+		at, err := schedule.ParseAt(expires, time.Now())
+		if err != nil {
+			return err
+		}
+		req.ExpiresAt = timestamppb.New(at)
+	}
+
+	fc, err := command.FeatureClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Creating token", "name", name, "scopes", cmd.StringSlice(constant.Scope))
+	created, err := fc.CreateToken(ctx, req)
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok && (st.Code() == codes.PermissionDenied || st.Code() == codes.InvalidArgument || st.Code() == codes.AlreadyExists) {
+			slog.Warn("Token rejected", "name", name, "error", st.Message())
+			return fmt.Errorf("%s", st.Message())
+		}
+		return err
+	}
+	// the secret is only shown once, it goes to the output on its own line for scripts
+	cmd.Writer.Write([]byte(formatToken(created.Token)))
+	cmd.Writer.Write([]byte(created.Secret + "\n"))
+	return nil
+}
+
+func List(ctx context.Context, cmd *cli.Command) error {
+	ctx, span := otel.Tracer("cli/command/token").Start(ctx, "List")
+	defer span.End()
+
+	fc, err := command.FeatureClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Listing tokens")
+	result, err := fc.ListTokens(ctx, &emptypb.Empty{})
+	if err != nil {
+		return err
+	}
+	for _, token := range result.Tokens {
+		cmd.Writer.Write([]byte(formatToken(token)))
+	}
+	return nil
+}
+
+func Revoke(ctx context.Context, cmd *cli.Command) error {
+	ctx, span := otel.Tracer("cli/command/token").Start(ctx, "Revoke")
+	defer span.End()
+
+	id := cmd.StringArg("id")
+	if id == "" {
+		return fmt.Errorf("id is required")
+	}
+
+	fc, err := command.FeatureClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Revoking token", "id", id)
+	_, err = fc.RevokeToken(ctx, &feature.Token{Id: id})
+	return err
+}
+
+// formatToken prints one token per line, e.g.
+// "4f2a9c0e1b7d3a55 ci read,write expires 2025-06-01T08:00:00Z by alice"
+func formatToken(token *feature.Token) string {
+	names := make([]string, 0, len(token.Scopes))
+	for _, scope := range token.Scopes {
+		names = append(names, scopeName(scope))
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s", token.Id, token.Name, strings.Join(names, ","))
+	if token.ExpiresAt != nil {
+		fmt.Fprintf(&b, " expires %s", token.ExpiresAt.AsTime().Format(time.RFC3339))
+	}
+	if token.CreatedBy != "" {
+		fmt.Fprintf(&b, " by %s", token.CreatedBy)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,50 @@
+package token
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestParseScope(t *testing.T) {
+	scope, err := ParseScope("Write")
+	require.NoError(t, err)
+	assert.Equal(t, feature.TokenScope_TOKEN_SCOPE_WRITE, scope)
+
+	_, err = ParseScope("admin")
+	assert.Error(t, err)
+}
+
+func TestFormatToken(t *testing.T) {
+	assert.Equal(t, "4f2a ci read,restart expires 2025-06-01T08:00:00Z by alice\n", formatToken(&feature.Token{
+		Id:        "4f2a",
+		Name:      "ci",
+		Scopes:    []feature.TokenScope{feature.TokenScope_TOKEN_SCOPE_READ, feature.TokenScope_TOKEN_SCOPE_RESTART},
+		ExpiresAt: timestamppb.New(time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)),
+		CreatedBy: "alice",
+	}))
+	assert.Equal(t, "4f2a app write\n", formatToken(&feature.Token{Id: "4f2a", Name: "app", Scopes: []feature.TokenScope{feature.TokenScope_TOKEN_SCOPE_WRITE}}))
+}
+
+func TestCreate_MissingArguments(t *testing.T) {
+	var buf bytes.Buffer
+	cmd := &cli.Command{
+		Writer: &buf,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "endpoint",
+				Value: "invalid:9999", // Use invalid endpoint to avoid actual connection
+			},
+		},
+	}
+
+	err := Create(context.Background(), cmd)
+	assert.Error(t, err, "Create should require a name and a scope")
+}
@@ -0,0 +1,84 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/dkrizic/feature/cli/command"
+	"github.com/dkrizic/feature/cli/constant"
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/urfave/cli/v3"
+	"go.opentelemetry.io/otel"
+)
+
+// reconnectDelay is the pause before resuming a broken watch
+const reconnectDelay = 2 * time.Second
+
+func Watch(ctx context.Context, cmd *cli.Command) error {
+	ctx, span := otel.Tracer("cli/command/watch").Start(ctx, "Watch")
+	defer span.End()
+
+	fc, err := command.FeatureClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	revision := cmd.Uint64(constant.Revision)
+	reconnect := cmd.Bool(constant.Reconnect)
+
+	for {
+		slog.InfoContext(ctx, "Watching features", "revision", revision)
+		revision, err = watch(ctx, cmd, fc, revision)
+		if !reconnect || ctx.Err() != nil {
+			return err
+		}
+		slog.WarnContext(ctx, "Watch interrupted, resuming", "revision", revision, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// watch prints events until the stream ends and returns the last seen revision
+func watch(ctx context.Context, cmd *cli.Command, fc feature.FeatureClient, revision uint64) (uint64, error) {
+	stream, err := fc.Watch(ctx, &feature.WatchRequest{
+		Revision: revision,
+	})
+	if err != nil {
+		return revision, err
+	}
+	for {
+		event, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return revision, nil
+		}
+		if err != nil {
+			return revision, err
+		}
+		revision = event.Revision
+		cmd.Writer.Write([]byte(formatEvent(event)))
+	}
+}
+
+func formatEvent(event *feature.WatchEvent) string {
+	eventType := strings.ToLower(strings.TrimPrefix(event.Type.String(), "EVENT_TYPE_"))
+	switch event.Type {
+	case feature.EventType_EVENT_TYPE_SYNCED:
+		return fmt.Sprintf("%d %s\n", event.Revision, eventType)
+	case feature.EventType_EVENT_TYPE_DELETE:
+		return fmt.Sprintf("%d %s %s\n", event.Revision, eventType, event.KeyValue.GetKey())
+	default:
+		value := event.KeyValue.GetValue()
+		if event.KeyValue.GetRedacted() {
+			value = command.Redacted
+		}
+		return fmt.Sprintf("%d %s %s=%s\n", event.Revision, eventType, event.KeyValue.GetKey(), value)
+	}
+}
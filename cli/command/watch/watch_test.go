@@ -0,0 +1,48 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	feature "github.com/dkrizic/feature/cli/repository/feature/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v3"
+)
+
+func TestWatch_InvalidEndpoint(t *testing.T) {
+	// Test that Watch handles connection errors gracefully
+
+	var buf bytes.Buffer
+	cmd := &cli.Command{
+		Writer: &buf,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "endpoint",
+				Value: "invalid:9999", // Use invalid endpoint to avoid actual connection
+			},
+		},
+	}
+
+	// Without --reconnect the first connection error is returned
+	err := Watch(context.Background(), cmd)
+
+	assert.Error(t, err, "Watch should return an error with invalid endpoint")
+}
+
+func TestFormatEvent(t *testing.T) {
+	assert.Equal(t, "3 update COLOR=blue\n", formatEvent(&feature.WatchEvent{
+		Type:     feature.EventType_EVENT_TYPE_UPDATE,
+		KeyValue: &feature.KeyValue{Key: "COLOR", Value: "blue"},
+		Revision: 3,
+	}))
+	assert.Equal(t, "4 delete COLOR\n", formatEvent(&feature.WatchEvent{
+		Type:     feature.EventType_EVENT_TYPE_DELETE,
+		KeyValue: &feature.KeyValue{Key: "COLOR"},
+		Revision: 4,
+	}))
+	assert.Equal(t, "4 synced\n", formatEvent(&feature.WatchEvent{
+		Type:     feature.EventType_EVENT_TYPE_SYNCED,
+		Revision: 4,
+	}))
+}
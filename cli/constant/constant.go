@@ -14,4 +14,42 @@ const (
 	OpenTelemetryEndpoint = "opentelemetry-endpoint"
 	Username              = "username"
 	Password              = "password"
+	Revision              = "revision"
+	Reconnect             = "reconnect"
+	Type                  = "type"
+	Min                   = "min"
+	Max                   = "max"
+	Pattern               = "pattern"
+	Allowed               = "allowed"
+	Schema                = "schema"
+	Description           = "description"
+	Owner                 = "owner"
+	Tag                   = "tag"
+	Search                = "search"
+	User                  = "user"
+	Tenant                = "tenant"
+	Region                = "region"
+	Attribute             = "attribute"
+	BucketBy              = "bucket-by"
+	Variant               = "variant"
+	FlagSet               = "flag-set"
+	// FlagSetMetadata is the request metadata selecting the flag set of a call
+	FlagSetMetadata = "feature-set"
+	Principal       = "principal"
+	Editable        = "editable"
+	RestartType     = "restart-type"
+	RestartName     = "restart-name"
+	Environment     = "environment"
+	// EnvironmentMetadata is the request metadata selecting the environment of a call
+	EnvironmentMetadata = "feature-environment"
+	DryRun              = "dry-run"
+	At                  = "at"
+	Restart             = "restart"
+	Expires             = "expires"
+	Lifecycle           = "lifecycle"
+	Requires            = "requires"
+	OffValue            = "off-value"
+	// Token is the API token sent instead of username and password
+	Token = "token"
+	Scope = "scope"
 )
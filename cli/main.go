@@ -8,13 +8,25 @@ import (
 	"os"
 	"time"
 
+	"github.com/dkrizic/feature/cli/command/apply"
 	"github.com/dkrizic/feature/cli/command/delete"
+	"github.com/dkrizic/feature/cli/command/evaluate"
+	"github.com/dkrizic/feature/cli/command/flagset"
 	"github.com/dkrizic/feature/cli/command/get"
 	"github.com/dkrizic/feature/cli/command/getall"
+	"github.com/dkrizic/feature/cli/command/history"
 	"github.com/dkrizic/feature/cli/command/info"
+	"github.com/dkrizic/feature/cli/command/prerequisites"
 	"github.com/dkrizic/feature/cli/command/preset"
+	"github.com/dkrizic/feature/cli/command/promote"
 	"github.com/dkrizic/feature/cli/command/restart"
+	"github.com/dkrizic/feature/cli/command/rollback"
+	"github.com/dkrizic/feature/cli/command/rollout"
+	"github.com/dkrizic/feature/cli/command/schedule"
 	"github.com/dkrizic/feature/cli/command/set"
+	"github.com/dkrizic/feature/cli/command/stale"
+	"github.com/dkrizic/feature/cli/command/token"
+	"github.com/dkrizic/feature/cli/command/watch"
 	"github.com/dkrizic/feature/cli/constant"
 	"github.com/dkrizic/feature/cli/meta"
 	metaversion "github.com/dkrizic/feature/cli/meta"
@@ -92,6 +104,27 @@ func main() {
 				Usage:    "Password for backend service authentication",
 				Sources:  cli.EnvVars("PASSWORD"),
 			},
+			&cli.StringFlag{
+				Name:     constant.Token,
+				Value:    "",
+				Category: "authentication",
+				Usage:    "API token for backend service authentication, used instead of username and password",
+				Sources:  cli.EnvVars("TOKEN"),
+			},
+			&cli.StringFlag{
+				Name:     constant.FlagSet,
+				Value:    "",
+				Category: "connection",
+				Usage:    "Flag set to work on, the default set if empty",
+				Sources:  cli.EnvVars("FLAG_SET"),
+			},
+			&cli.StringFlag{
+				Name:     constant.Environment,
+				Value:    "",
+				Category: "connection",
+				Usage:    "Environment to work on, the first environment of the service if empty",
+				Sources:  cli.EnvVars("ENVIRONMENT"),
+			},
 		},
 		Before: before,
 		After:  after,
@@ -108,6 +141,20 @@ func main() {
 				Name:   "getall",
 				Usage:  "Get all features",
 				Action: getall.GetAll,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  constant.Search,
+						Usage: "Only show features whose key, description, owner or tags contain this text",
+					},
+					&cli.StringFlag{
+						Name:  constant.Owner,
+						Usage: "Only show features owned by this owner",
+					},
+					&cli.StringSliceFlag{
+						Name:  constant.Tag,
+						Usage: "Only show features with this tag, repeatable (all must match)",
+					},
+				},
 			},
 			&cli.Command{
 				Name:   "get",
@@ -123,6 +170,62 @@ func main() {
 				Name:   "set",
 				Usage:  "Set a feature key-value",
 				Action: set.Set,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  constant.Type,
+						Usage: "Type of the value: string, boolean, integer, float, enum, json. Keeps the stored type if not set",
+						Action: func(ctx context.Context, cmd *cli.Command, s string) error {
+							if !set.ValidType(s) {
+								return fmt.Errorf("invalid type: %s", s)
+							}
+							return nil
+						},
+					},
+					&cli.Float64Flag{
+						Name:  constant.Min,
+						Usage: "Minimum of an integer or float value",
+					},
+					&cli.Float64Flag{
+						Name:  constant.Max,
+						Usage: "Maximum of an integer or float value",
+					},
+					&cli.StringFlag{
+						Name:  constant.Pattern,
+						Usage: "Regular expression a string value must match",
+					},
+					&cli.StringSliceFlag{
+						Name:  constant.Allowed,
+						Usage: "Allowed values of an enum or string value",
+					},
+					&cli.StringFlag{
+						Name:  constant.Schema,
+						Usage: "JSON schema a json value must conform to",
+					},
+					&cli.StringFlag{
+						Name:  constant.Description,
+						Usage: "Description of the feature. Description, owner, tags, expiry and lifecycle replace the stored ones together",
+					},
+					&cli.StringFlag{
+						Name:  constant.Owner,
+						Usage: "Owner of the feature, e.g. a team",
+					},
+					&cli.StringSliceFlag{
+						Name:  constant.Tag,
+						Usage: "Tag of the feature, repeatable",
+					},
+					&cli.StringFlag{
+						Name:  constant.Expires,
+						Usage: "Date after which the feature is reported as stale, RFC 3339 or a date like 2025-06-01",
+					},
+					&cli.StringFlag{
+						Name:  constant.Lifecycle,
+						Usage: "Lifecycle of the feature: temporary or permanent. Permanent features are never reported as unused",
+					},
+					&cli.Uint64Flag{
+						Name:  constant.Revision,
+						Usage: "Only set if the feature still has this revision (see getall), fails if someone else changed it",
+					},
+				},
 				Arguments: []cli.Argument{
 					&cli.StringArg{
 						Name: "key",
@@ -155,6 +258,198 @@ func main() {
 					},
 				},
 			},
+			&cli.Command{
+				Name:   "evaluate",
+				Usage:  "Evaluate a feature for a context, applying its targeting rules",
+				Action: evaluate.Evaluate,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  constant.User,
+						Usage: "User id of the context",
+					},
+					&cli.StringFlag{
+						Name:  constant.Tenant,
+						Usage: "Tenant of the context",
+					},
+					&cli.StringFlag{
+						Name:  constant.Region,
+						Usage: "Region of the context",
+					},
+					&cli.StringSliceFlag{
+						Name:  constant.Attribute,
+						Usage: "Additional attribute of the context as name=value, repeatable",
+					},
+				},
+				Arguments: []cli.Argument{
+					&cli.StringArg{
+						Name: "key",
+					},
+				},
+			},
+			&cli.Command{
+				Name:   "apply",
+				Usage:  "Apply sets (KEY=VALUE) and deletes (-KEY) from a file or stdin atomically, all or none",
+				Action: apply.Apply,
+				Arguments: []cli.Argument{
+					&cli.StringArg{
+						Name: "file",
+					},
+				},
+			},
+			&cli.Command{
+				Name:   "rollout",
+				Usage:  "Set the percentage rollout of a feature, without variants the rollout is removed",
+				Action: rollout.Rollout,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  constant.BucketBy,
+						Usage: "Attribute of the evaluation context users are bucketed by, userId if not set",
+					},
+					&cli.StringSliceFlag{
+						Name:  constant.Variant,
+						Usage: "Variant as value=percentage, repeatable",
+					},
+				},
+				Arguments: []cli.Argument{
+					&cli.StringArg{
+						Name: "key",
+					},
+				},
+			},
+			&cli.Command{
+				Name:   "prerequisites",
+				Usage:  "Set the prerequisites of a feature, without prerequisites they are removed",
+				Action: prerequisites.Prerequisites,
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  constant.Requires,
+						Usage: "Prerequisite as KEY=VALUE, the feature falls back to the off value unless KEY evaluates to VALUE, repeatable",
+					},
+					&cli.StringFlag{
+						Name:  constant.OffValue,
+						Usage: "Value served if a prerequisite is not met, false for boolean features if not set",
+					},
+				},
+				Arguments: []cli.Argument{
+					&cli.StringArg{
+						Name: "key",
+					},
+				},
+			},
+			&cli.Command{
+				Name:   "history",
+				Usage:  "Show the change history of a feature, oldest first",
+				Action: history.History,
+				Arguments: []cli.Argument{
+					&cli.StringArg{
+						Name: "key",
+					},
+				},
+			},
+			&cli.Command{
+				Name:   "rollback",
+				Usage:  "Restore a feature to its state after the given revision of its history",
+				Action: rollback.Rollback,
+				Arguments: []cli.Argument{
+					&cli.StringArg{
+						Name: "key",
+					},
+					&cli.Uint64Arg{
+						Name: "revision",
+					},
+				},
+			},
+			&cli.Command{
+				Name:   "promote",
+				Usage:  "Copy a feature of the flag set from one environment to another and print the changes",
+				Action: promote.Promote,
+				Arguments: []cli.Argument{
+					&cli.StringArg{
+						Name: "key",
+					},
+					&cli.StringArg{
+						Name: "from",
+					},
+					&cli.StringArg{
+						Name: "to",
+					},
+				},
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  constant.DryRun,
+						Usage: "Only print the changes",
+					},
+					&cli.Uint64Flag{
+						Name:  constant.Revision,
+						Usage: "Only promote if the feature in the target environment still has this revision",
+					},
+				},
+			},
+			&cli.Command{
+				Name:  "schedule",
+				Usage: "Change features of the flag set at a later time",
+				Commands: []*cli.Command{
+					&cli.Command{
+						Name:   "set",
+						Usage:  "Schedule a new value for a feature",
+						Action: schedule.Set,
+						Arguments: []cli.Argument{
+							&cli.StringArg{
+								Name: "key",
+							},
+							&cli.StringArg{
+								Name: "value",
+							},
+						},
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:     constant.At,
+								Usage:    "When to set the value, RFC 3339 like 2025-06-01T08:00:00Z or a duration from now like 2h",
+								Required: true,
+							},
+							&cli.BoolFlag{
+								Name:  constant.Restart,
+								Usage: "Restart the workload of the flag set after setting the value",
+							},
+						},
+					},
+					&cli.Command{
+						Name:   "list",
+						Usage:  "List the pending and failed schedules of the flag set",
+						Action: schedule.List,
+					},
+					&cli.Command{
+						Name:   "cancel",
+						Usage:  "Cancel a schedule",
+						Action: schedule.Cancel,
+						Arguments: []cli.Argument{
+							&cli.StringArg{
+								Name: "id",
+							},
+						},
+					},
+				},
+			},
+			&cli.Command{
+				Name:   "stale",
+				Usage:  "List the stale features of the flag set, fails if there are any",
+				Action: stale.Stale,
+			},
+			&cli.Command{
+				Name:   "watch",
+				Usage:  "Watch features and print every change",
+				Action: watch.Watch,
+				Flags: []cli.Flag{
+					&cli.Uint64Flag{
+						Name:  constant.Revision,
+						Usage: "Resume after this revision instead of starting with a snapshot",
+					},
+					&cli.BoolFlag{
+						Name:  constant.Reconnect,
+						Usage: "Reconnect and resume from the last seen revision when the stream breaks",
+					},
+				},
+			},
 			&cli.Command{
 				Name:   "info",
 				Usage:  "Get service info including restart configuration",
@@ -165,6 +460,101 @@ func main() {
 				Usage:  "Restart the configured service",
 				Action: restart.Restart,
 			},
+			&cli.Command{
+				Name:  "flagset",
+				Usage: "Manage the flag sets of the service",
+				Commands: []*cli.Command{
+					&cli.Command{
+						Name:   "list",
+						Usage:  "List the flag sets",
+						Action: flagset.List,
+					},
+					&cli.Command{
+						Name:   "create",
+						Usage:  "Create a flag set",
+						Action: flagset.Create,
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  constant.Description,
+								Usage: "Description of the flag set",
+							},
+							&cli.StringSliceFlag{
+								Name:  constant.Editable,
+								Usage: "Key that may be changed, repeatable. All keys are editable if not set",
+							},
+							&cli.StringSliceFlag{
+								Name:  constant.Principal,
+								Usage: "Principal that may use the flag set, repeatable. Everybody may use it if not set",
+							},
+							&cli.StringFlag{
+								Name:  constant.RestartType,
+								Usage: "Type of the workload restart restarts for the flag set: deployment, statefulset, daemonset",
+							},
+							&cli.StringFlag{
+								Name:  constant.RestartName,
+								Usage: "Name of the workload restart restarts for the flag set",
+							},
+						},
+						Arguments: []cli.Argument{
+							&cli.StringArg{
+								Name: "name",
+							},
+						},
+					},
+					&cli.Command{
+						Name:   "delete",
+						Usage:  "Delete an empty flag set",
+						Action: flagset.Delete,
+						Arguments: []cli.Argument{
+							&cli.StringArg{
+								Name: "name",
+							},
+						},
+					},
+				},
+			},
+			&cli.Command{
+				Name:  "token",
+				Usage: "Manage the API tokens of the service",
+				Commands: []*cli.Command{
+					&cli.Command{
+						Name:   "create",
+						Usage:  "Create an API token and print it, it cannot be shown again",
+						Action: token.Create,
+						Arguments: []cli.Argument{
+							&cli.StringArg{
+								Name: "name",
+							},
+						},
+						Flags: []cli.Flag{
+							&cli.StringSliceFlag{
+								Name:     constant.Scope,
+								Usage:    "What the token may do: read, write or restart, repeatable",
+								Required: true,
+							},
+							&cli.StringFlag{
+								Name:  constant.Expires,
+								Usage: "When the token expires, RFC 3339 like 2025-06-01T08:00:00Z or a duration from now like 720h. Never if not set",
+							},
+						},
+					},
+					&cli.Command{
+						Name:   "list",
+						Usage:  "List the API tokens",
+						Action: token.List,
+					},
+					&cli.Command{
+						Name:   "revoke",
+						Usage:  "Revoke an API token",
+						Action: token.Revoke,
+						Arguments: []cli.Argument{
+							&cli.StringArg{
+								Name: "id",
+							},
+						},
+					},
+				},
+			},
 		},
 	}
 
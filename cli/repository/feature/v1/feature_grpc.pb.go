@@ -20,11 +20,33 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Feature_GetAll_FullMethodName = "/feature.v1.Feature/GetAll"
-	Feature_PreSet_FullMethodName = "/feature.v1.Feature/PreSet"
-	Feature_Set_FullMethodName    = "/feature.v1.Feature/Set"
-	Feature_Get_FullMethodName    = "/feature.v1.Feature/Get"
-	Feature_Delete_FullMethodName = "/feature.v1.Feature/Delete"
+	Feature_GetAll_FullMethodName           = "/feature.v1.Feature/GetAll"
+	Feature_PreSet_FullMethodName           = "/feature.v1.Feature/PreSet"
+	Feature_Set_FullMethodName              = "/feature.v1.Feature/Set"
+	Feature_Get_FullMethodName              = "/feature.v1.Feature/Get"
+	Feature_Delete_FullMethodName           = "/feature.v1.Feature/Delete"
+	Feature_Watch_FullMethodName            = "/feature.v1.Feature/Watch"
+	Feature_Evaluate_FullMethodName         = "/feature.v1.Feature/Evaluate"
+	Feature_GetRules_FullMethodName         = "/feature.v1.Feature/GetRules"
+	Feature_SetRules_FullMethodName         = "/feature.v1.Feature/SetRules"
+	Feature_SetRollout_FullMethodName       = "/feature.v1.Feature/SetRollout"
+	Feature_History_FullMethodName          = "/feature.v1.Feature/History"
+	Feature_Rollback_FullMethodName         = "/feature.v1.Feature/Rollback"
+	Feature_Batch_FullMethodName            = "/feature.v1.Feature/Batch"
+	Feature_ListSets_FullMethodName         = "/feature.v1.Feature/ListSets"
+	Feature_CreateSet_FullMethodName        = "/feature.v1.Feature/CreateSet"
+	Feature_DeleteSet_FullMethodName        = "/feature.v1.Feature/DeleteSet"
+	Feature_ListEnvironments_FullMethodName = "/feature.v1.Feature/ListEnvironments"
+	Feature_Promote_FullMethodName          = "/feature.v1.Feature/Promote"
+	Feature_ScheduleSet_FullMethodName      = "/feature.v1.Feature/ScheduleSet"
+	Feature_ListSchedules_FullMethodName    = "/feature.v1.Feature/ListSchedules"
+	Feature_CancelSchedule_FullMethodName   = "/feature.v1.Feature/CancelSchedule"
+	Feature_ListStale_FullMethodName        = "/feature.v1.Feature/ListStale"
+	Feature_SetPrerequisites_FullMethodName = "/feature.v1.Feature/SetPrerequisites"
+	Feature_GetPermissions_FullMethodName   = "/feature.v1.Feature/GetPermissions"
+	Feature_CreateToken_FullMethodName      = "/feature.v1.Feature/CreateToken"
+	Feature_ListTokens_FullMethodName       = "/feature.v1.Feature/ListTokens"
+	Feature_RevokeToken_FullMethodName      = "/feature.v1.Feature/RevokeToken"
 )
 
 // FeatureClient is the client API for Feature service.
@@ -36,6 +58,44 @@ type FeatureClient interface {
 	Set(ctx context.Context, in *KeyValue, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	Get(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Value, error)
 	Delete(ctx context.Context, in *Key, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error)
+	Evaluate(ctx context.Context, in *EvaluateRequest, opts ...grpc.CallOption) (*EvaluateResponse, error)
+	GetRules(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Rules, error)
+	SetRules(ctx context.Context, in *Rules, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	SetRollout(ctx context.Context, in *SetRolloutRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	History(ctx context.Context, in *Key, opts ...grpc.CallOption) (*HistoryResponse, error)
+	Rollback(ctx context.Context, in *RollbackRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	Batch(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// ListSets returns the sets the caller may use
+	ListSets(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*FlagSets, error)
+	CreateSet(ctx context.Context, in *FlagSet, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// DeleteSet deletes an empty set, only the name is used
+	DeleteSet(ctx context.Context, in *FlagSet, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListEnvironments(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Environments, error)
+	// Promote copies value, type, constraints, rules, rollout, prerequisites, off value, description,
+	// owner, tags, expiry and lifecycle of a flag to another environment of the same set. The
+	// prerequisites have to exist in the target environment.
+	Promote(ctx context.Context, in *PromoteRequest, opts ...grpc.CallOption) (*PromoteResponse, error)
+	// ScheduleSet sets the value of a flag of the selected set and environment at a later time
+	ScheduleSet(ctx context.Context, in *ScheduleSetRequest, opts ...grpc.CallOption) (*Schedule, error)
+	// ListSchedules returns the pending and failed schedules of the selected set in all environments
+	ListSchedules(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Schedules, error)
+	// CancelSchedule removes a schedule, only the id is used
+	CancelSchedule(ctx context.Context, in *Schedule, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// ListStale returns the flags of the selected set and environment that are past their expiry date
+	// or were neither changed nor read for a while
+	ListStale(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*StaleFlags, error)
+	// SetPrerequisites replaces the prerequisites and the off value of a flag. Prerequisites must
+	// exist in the same set and environment and must not form a cycle.
+	SetPrerequisites(ctx context.Context, in *Prerequisites, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// GetPermissions returns the methods the caller may call, every authenticated caller may use it
+	GetPermissions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Permissions, error)
+	// CreateToken creates an API token, ListTokens and RevokeToken manage them. API tokens cannot
+	// call these methods.
+	CreateToken(ctx context.Context, in *CreateTokenRequest, opts ...grpc.CallOption) (*CreateTokenResponse, error)
+	ListTokens(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Tokens, error)
+	// RevokeToken deletes a token, only the id is used
+	RevokeToken(ctx context.Context, in *Token, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type featureClient struct {
@@ -105,6 +165,235 @@ func (c *featureClient) Delete(ctx context.Context, in *Key, opts ...grpc.CallOp
 	return out, nil
 }
 
+func (c *featureClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Feature_ServiceDesc.Streams[1], Feature_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, WatchEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Feature_WatchClient = grpc.ServerStreamingClient[WatchEvent]
+
+func (c *featureClient) Evaluate(ctx context.Context, in *EvaluateRequest, opts ...grpc.CallOption) (*EvaluateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EvaluateResponse)
+	err := c.cc.Invoke(ctx, Feature_Evaluate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *featureClient) GetRules(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Rules, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Rules)
+	err := c.cc.Invoke(ctx, Feature_GetRules_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *featureClient) SetRules(ctx context.Context, in *Rules, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Feature_SetRules_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *featureClient) SetRollout(ctx context.Context, in *SetRolloutRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Feature_SetRollout_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *featureClient) History(ctx context.Context, in *Key, opts ...grpc.CallOption) (*HistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HistoryResponse)
+	err := c.cc.Invoke(ctx, Feature_History_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *featureClient) Rollback(ctx context.Context, in *RollbackRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Feature_Rollback_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *featureClient) Batch(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Feature_Batch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *featureClient) ListSets(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*FlagSets, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FlagSets)
+	err := c.cc.Invoke(ctx, Feature_ListSets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *featureClient) CreateSet(ctx context.Context, in *FlagSet, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Feature_CreateSet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *featureClient) DeleteSet(ctx context.Context, in *FlagSet, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Feature_DeleteSet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *featureClient) ListEnvironments(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Environments, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Environments)
+	err := c.cc.Invoke(ctx, Feature_ListEnvironments_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *featureClient) Promote(ctx context.Context, in *PromoteRequest, opts ...grpc.CallOption) (*PromoteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PromoteResponse)
+	err := c.cc.Invoke(ctx, Feature_Promote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *featureClient) ScheduleSet(ctx context.Context, in *ScheduleSetRequest, opts ...grpc.CallOption) (*Schedule, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Schedule)
+	err := c.cc.Invoke(ctx, Feature_ScheduleSet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *featureClient) ListSchedules(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Schedules, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Schedules)
+	err := c.cc.Invoke(ctx, Feature_ListSchedules_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *featureClient) CancelSchedule(ctx context.Context, in *Schedule, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Feature_CancelSchedule_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *featureClient) ListStale(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*StaleFlags, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StaleFlags)
+	err := c.cc.Invoke(ctx, Feature_ListStale_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *featureClient) SetPrerequisites(ctx context.Context, in *Prerequisites, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Feature_SetPrerequisites_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *featureClient) GetPermissions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Permissions, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Permissions)
+	err := c.cc.Invoke(ctx, Feature_GetPermissions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *featureClient) CreateToken(ctx context.Context, in *CreateTokenRequest, opts ...grpc.CallOption) (*CreateTokenResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateTokenResponse)
+	err := c.cc.Invoke(ctx, Feature_CreateToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *featureClient) ListTokens(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*Tokens, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Tokens)
+	err := c.cc.Invoke(ctx, Feature_ListTokens_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *featureClient) RevokeToken(ctx context.Context, in *Token, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, Feature_RevokeToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // FeatureServer is the server API for Feature service.
 // All implementations must embed UnimplementedFeatureServer
 // for forward compatibility.
@@ -114,6 +403,44 @@ type FeatureServer interface {
 	Set(context.Context, *KeyValue) (*emptypb.Empty, error)
 	Get(context.Context, *Key) (*Value, error)
 	Delete(context.Context, *Key) (*emptypb.Empty, error)
+	Watch(*WatchRequest, grpc.ServerStreamingServer[WatchEvent]) error
+	Evaluate(context.Context, *EvaluateRequest) (*EvaluateResponse, error)
+	GetRules(context.Context, *Key) (*Rules, error)
+	SetRules(context.Context, *Rules) (*emptypb.Empty, error)
+	SetRollout(context.Context, *SetRolloutRequest) (*emptypb.Empty, error)
+	History(context.Context, *Key) (*HistoryResponse, error)
+	Rollback(context.Context, *RollbackRequest) (*emptypb.Empty, error)
+	Batch(context.Context, *BatchRequest) (*emptypb.Empty, error)
+	// ListSets returns the sets the caller may use
+	ListSets(context.Context, *emptypb.Empty) (*FlagSets, error)
+	CreateSet(context.Context, *FlagSet) (*emptypb.Empty, error)
+	// DeleteSet deletes an empty set, only the name is used
+	DeleteSet(context.Context, *FlagSet) (*emptypb.Empty, error)
+	ListEnvironments(context.Context, *emptypb.Empty) (*Environments, error)
+	// Promote copies value, type, constraints, rules, rollout, prerequisites, off value, description,
+	// owner, tags, expiry and lifecycle of a flag to another environment of the same set. The
+	// prerequisites have to exist in the target environment.
+	Promote(context.Context, *PromoteRequest) (*PromoteResponse, error)
+	// ScheduleSet sets the value of a flag of the selected set and environment at a later time
+	ScheduleSet(context.Context, *ScheduleSetRequest) (*Schedule, error)
+	// ListSchedules returns the pending and failed schedules of the selected set in all environments
+	ListSchedules(context.Context, *emptypb.Empty) (*Schedules, error)
+	// CancelSchedule removes a schedule, only the id is used
+	CancelSchedule(context.Context, *Schedule) (*emptypb.Empty, error)
+	// ListStale returns the flags of the selected set and environment that are past their expiry date
+	// or were neither changed nor read for a while
+	ListStale(context.Context, *emptypb.Empty) (*StaleFlags, error)
+	// SetPrerequisites replaces the prerequisites and the off value of a flag. Prerequisites must
+	// exist in the same set and environment and must not form a cycle.
+	SetPrerequisites(context.Context, *Prerequisites) (*emptypb.Empty, error)
+	// GetPermissions returns the methods the caller may call, every authenticated caller may use it
+	GetPermissions(context.Context, *emptypb.Empty) (*Permissions, error)
+	// CreateToken creates an API token, ListTokens and RevokeToken manage them. API tokens cannot
+	// call these methods.
+	CreateToken(context.Context, *CreateTokenRequest) (*CreateTokenResponse, error)
+	ListTokens(context.Context, *emptypb.Empty) (*Tokens, error)
+	// RevokeToken deletes a token, only the id is used
+	RevokeToken(context.Context, *Token) (*emptypb.Empty, error)
 	mustEmbedUnimplementedFeatureServer()
 }
 
@@ -139,6 +466,72 @@ func (UnimplementedFeatureServer) Get(context.Context, *Key) (*Value, error) {
 func (UnimplementedFeatureServer) Delete(context.Context, *Key) (*emptypb.Empty, error) {
 	return nil, status.Error(codes.Unimplemented, "method Delete not implemented")
 }
+func (UnimplementedFeatureServer) Watch(*WatchRequest, grpc.ServerStreamingServer[WatchEvent]) error {
+	return status.Error(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedFeatureServer) Evaluate(context.Context, *EvaluateRequest) (*EvaluateResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Evaluate not implemented")
+}
+func (UnimplementedFeatureServer) GetRules(context.Context, *Key) (*Rules, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetRules not implemented")
+}
+func (UnimplementedFeatureServer) SetRules(context.Context, *Rules) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetRules not implemented")
+}
+func (UnimplementedFeatureServer) SetRollout(context.Context, *SetRolloutRequest) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetRollout not implemented")
+}
+func (UnimplementedFeatureServer) History(context.Context, *Key) (*HistoryResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method History not implemented")
+}
+func (UnimplementedFeatureServer) Rollback(context.Context, *RollbackRequest) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method Rollback not implemented")
+}
+func (UnimplementedFeatureServer) Batch(context.Context, *BatchRequest) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method Batch not implemented")
+}
+func (UnimplementedFeatureServer) ListSets(context.Context, *emptypb.Empty) (*FlagSets, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListSets not implemented")
+}
+func (UnimplementedFeatureServer) CreateSet(context.Context, *FlagSet) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateSet not implemented")
+}
+func (UnimplementedFeatureServer) DeleteSet(context.Context, *FlagSet) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteSet not implemented")
+}
+func (UnimplementedFeatureServer) ListEnvironments(context.Context, *emptypb.Empty) (*Environments, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListEnvironments not implemented")
+}
+func (UnimplementedFeatureServer) Promote(context.Context, *PromoteRequest) (*PromoteResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Promote not implemented")
+}
+func (UnimplementedFeatureServer) ScheduleSet(context.Context, *ScheduleSetRequest) (*Schedule, error) {
+	return nil, status.Error(codes.Unimplemented, "method ScheduleSet not implemented")
+}
+func (UnimplementedFeatureServer) ListSchedules(context.Context, *emptypb.Empty) (*Schedules, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListSchedules not implemented")
+}
+func (UnimplementedFeatureServer) CancelSchedule(context.Context, *Schedule) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method CancelSchedule not implemented")
+}
+func (UnimplementedFeatureServer) ListStale(context.Context, *emptypb.Empty) (*StaleFlags, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListStale not implemented")
+}
+func (UnimplementedFeatureServer) SetPrerequisites(context.Context, *Prerequisites) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetPrerequisites not implemented")
+}
+func (UnimplementedFeatureServer) GetPermissions(context.Context, *emptypb.Empty) (*Permissions, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetPermissions not implemented")
+}
+func (UnimplementedFeatureServer) CreateToken(context.Context, *CreateTokenRequest) (*CreateTokenResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateToken not implemented")
+}
+func (UnimplementedFeatureServer) ListTokens(context.Context, *emptypb.Empty) (*Tokens, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListTokens not implemented")
+}
+func (UnimplementedFeatureServer) RevokeToken(context.Context, *Token) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method RevokeToken not implemented")
+}
 func (UnimplementedFeatureServer) mustEmbedUnimplementedFeatureServer() {}
 func (UnimplementedFeatureServer) testEmbeddedByValue()                 {}
 
@@ -243,34 +636,512 @@ func _Feature_Delete_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
-// Feature_ServiceDesc is the grpc.ServiceDesc for Feature service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var Feature_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "feature.v1.Feature",
-	HandlerType: (*FeatureServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "PreSet",
-			Handler:    _Feature_PreSet_Handler,
-		},
-		{
-			MethodName: "Set",
-			Handler:    _Feature_Set_Handler,
-		},
-		{
-			MethodName: "Get",
-			Handler:    _Feature_Get_Handler,
-		},
-		{
-			MethodName: "Delete",
-			Handler:    _Feature_Delete_Handler,
-		},
-	},
-	Streams: []grpc.StreamDesc{
-		{
-			StreamName:    "GetAll",
-			Handler:       _Feature_GetAll_Handler,
+func _Feature_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FeatureServer).Watch(m, &grpc.GenericServerStream[WatchRequest, WatchEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Feature_WatchServer = grpc.ServerStreamingServer[WatchEvent]
+
+func _Feature_Evaluate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EvaluateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServer).Evaluate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Feature_Evaluate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServer).Evaluate(ctx, req.(*EvaluateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Feature_GetRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Key)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServer).GetRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Feature_GetRules_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServer).GetRules(ctx, req.(*Key))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Feature_SetRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Rules)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServer).SetRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Feature_SetRules_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServer).SetRules(ctx, req.(*Rules))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Feature_SetRollout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRolloutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServer).SetRollout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Feature_SetRollout_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServer).SetRollout(ctx, req.(*SetRolloutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Feature_History_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Key)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServer).History(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Feature_History_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServer).History(ctx, req.(*Key))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Feature_Rollback_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RollbackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServer).Rollback(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Feature_Rollback_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServer).Rollback(ctx, req.(*RollbackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Feature_Batch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServer).Batch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Feature_Batch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServer).Batch(ctx, req.(*BatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Feature_ListSets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServer).ListSets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Feature_ListSets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServer).ListSets(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Feature_CreateSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FlagSet)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServer).CreateSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Feature_CreateSet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServer).CreateSet(ctx, req.(*FlagSet))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Feature_DeleteSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FlagSet)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServer).DeleteSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Feature_DeleteSet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServer).DeleteSet(ctx, req.(*FlagSet))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Feature_ListEnvironments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServer).ListEnvironments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Feature_ListEnvironments_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServer).ListEnvironments(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Feature_Promote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PromoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServer).Promote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Feature_Promote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServer).Promote(ctx, req.(*PromoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Feature_ScheduleSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScheduleSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServer).ScheduleSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Feature_ScheduleSet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServer).ScheduleSet(ctx, req.(*ScheduleSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Feature_ListSchedules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServer).ListSchedules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Feature_ListSchedules_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServer).ListSchedules(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Feature_CancelSchedule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Schedule)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServer).CancelSchedule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Feature_CancelSchedule_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServer).CancelSchedule(ctx, req.(*Schedule))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Feature_ListStale_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServer).ListStale(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Feature_ListStale_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServer).ListStale(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Feature_SetPrerequisites_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Prerequisites)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServer).SetPrerequisites(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Feature_SetPrerequisites_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServer).SetPrerequisites(ctx, req.(*Prerequisites))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Feature_GetPermissions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServer).GetPermissions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Feature_GetPermissions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServer).GetPermissions(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Feature_CreateToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServer).CreateToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Feature_CreateToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServer).CreateToken(ctx, req.(*CreateTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Feature_ListTokens_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServer).ListTokens(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Feature_ListTokens_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServer).ListTokens(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Feature_RevokeToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Token)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FeatureServer).RevokeToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Feature_RevokeToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FeatureServer).RevokeToken(ctx, req.(*Token))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Feature_ServiceDesc is the grpc.ServiceDesc for Feature service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Feature_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "feature.v1.Feature",
+	HandlerType: (*FeatureServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PreSet",
+			Handler:    _Feature_PreSet_Handler,
+		},
+		{
+			MethodName: "Set",
+			Handler:    _Feature_Set_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _Feature_Get_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _Feature_Delete_Handler,
+		},
+		{
+			MethodName: "Evaluate",
+			Handler:    _Feature_Evaluate_Handler,
+		},
+		{
+			MethodName: "GetRules",
+			Handler:    _Feature_GetRules_Handler,
+		},
+		{
+			MethodName: "SetRules",
+			Handler:    _Feature_SetRules_Handler,
+		},
+		{
+			MethodName: "SetRollout",
+			Handler:    _Feature_SetRollout_Handler,
+		},
+		{
+			MethodName: "History",
+			Handler:    _Feature_History_Handler,
+		},
+		{
+			MethodName: "Rollback",
+			Handler:    _Feature_Rollback_Handler,
+		},
+		{
+			MethodName: "Batch",
+			Handler:    _Feature_Batch_Handler,
+		},
+		{
+			MethodName: "ListSets",
+			Handler:    _Feature_ListSets_Handler,
+		},
+		{
+			MethodName: "CreateSet",
+			Handler:    _Feature_CreateSet_Handler,
+		},
+		{
+			MethodName: "DeleteSet",
+			Handler:    _Feature_DeleteSet_Handler,
+		},
+		{
+			MethodName: "ListEnvironments",
+			Handler:    _Feature_ListEnvironments_Handler,
+		},
+		{
+			MethodName: "Promote",
+			Handler:    _Feature_Promote_Handler,
+		},
+		{
+			MethodName: "ScheduleSet",
+			Handler:    _Feature_ScheduleSet_Handler,
+		},
+		{
+			MethodName: "ListSchedules",
+			Handler:    _Feature_ListSchedules_Handler,
+		},
+		{
+			MethodName: "CancelSchedule",
+			Handler:    _Feature_CancelSchedule_Handler,
+		},
+		{
+			MethodName: "ListStale",
+			Handler:    _Feature_ListStale_Handler,
+		},
+		{
+			MethodName: "SetPrerequisites",
+			Handler:    _Feature_SetPrerequisites_Handler,
+		},
+		{
+			MethodName: "GetPermissions",
+			Handler:    _Feature_GetPermissions_Handler,
+		},
+		{
+			MethodName: "CreateToken",
+			Handler:    _Feature_CreateToken_Handler,
+		},
+		{
+			MethodName: "ListTokens",
+			Handler:    _Feature_ListTokens_Handler,
+		},
+		{
+			MethodName: "RevokeToken",
+			Handler:    _Feature_RevokeToken_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetAll",
+			Handler:       _Feature_GetAll_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _Feature_Watch_Handler,
 			ServerStreams: true,
 		},
 	},
@@ -0,0 +1,269 @@
+package audit
+
+// records every mutating call of the Feature and Workload services to a Sink
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/dkrizic/feature/service/service/auth"
+	featurev1 "github.com/dkrizic/feature/service/service/feature/v1"
+	"github.com/dkrizic/feature/service/service/flagset"
+	workloadv1 "github.com/dkrizic/feature/service/service/workload/v1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+)
+
+// Record is one audited call
+type Record struct {
+	Timestamp time.Time `json:"timestamp"`
+	// Method is the name of the RPC, e.g. Set
+	Method string `json:"method"`
+	// Principal is the user authenticated by the auth interceptor, empty if authentication is
+	// disabled or rejected the call
+	Principal     string `json:"principal,omitempty"`
+	ClientAddress string `json:"clientAddress,omitempty"`
+	TraceID       string `json:"traceId,omitempty"`
+	// Set is the flag set of the call, empty for the default set
+	Set string `json:"set,omitempty"`
+	// Environment is the environment named by the call, the target of a promotion, empty if none is named
+	Environment string `json:"environment,omitempty"`
+	Key         string `json:"key,omitempty"`
+	// OldValue and NewValue are the values of the key before and after the call, nil if the key did not exist
+	OldValue *string `json:"oldValue,omitempty"`
+	NewValue *string `json:"newValue,omitempty"`
+	// Redacted is set instead of the values if the key is sensitive
+	Redacted bool `json:"redacted,omitempty"`
+	// Keys are the keys changed by a batch, in the order of its operations
+	Keys []string `json:"keys,omitempty"`
+	// Workload is the restarted workload as type/namespace/name, empty for the configured workload
+	Workload string  `json:"workload,omitempty"`
+	Outcome  Outcome `json:"outcome"`
+	// Code is the gRPC status code of the call
+	Code string `json:"code"`
+	// Message is the error or the message of a workload restart
+	Message string `json:"message,omitempty"`
+}
+
+type Sink interface {
+	Write(ctx context.Context, record Record) error
+}
+
+// auditedMethods are the mutating RPCs, reads are not audited
+var auditedMethods = map[string]bool{
+	featurev1.Feature_Set_FullMethodName:               true,
+	featurev1.Feature_PreSet_FullMethodName:            true,
+	featurev1.Feature_Delete_FullMethodName:            true,
+	featurev1.Feature_SetRules_FullMethodName:          true,
+	featurev1.Feature_SetRollout_FullMethodName:        true,
+	featurev1.Feature_SetPrerequisites_FullMethodName:  true,
+	featurev1.Feature_Rollback_FullMethodName:          true,
+	featurev1.Feature_Batch_FullMethodName:             true,
+	featurev1.Feature_CreateSet_FullMethodName:         true,
+	featurev1.Feature_DeleteSet_FullMethodName:         true,
+	featurev1.Feature_Promote_FullMethodName:           true,
+	featurev1.Feature_ScheduleSet_FullMethodName:       true,
+	featurev1.Feature_CancelSchedule_FullMethodName:    true,
+	featurev1.Feature_CreateToken_FullMethodName:       true,
+	featurev1.Feature_RevokeToken_FullMethodName:       true,
+	workloadv1.Workload_RestartWorkload_FullMethodName: true,
+	workloadv1.Workload_Restart_FullMethodName:         true,
+}
+
+// UnaryInterceptor writes a record for every mutating call. It has to run after the auth
+// interceptor to see the principal. The values of the key are read from the flag set and
+// environment of the call before and after the call, a promotion reports the target environment. A failure to write the record is logged, the call is not affected.
+func UnaryInterceptor(sink Sink, sets *flagset.Manager) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !auditedMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		if audited, ok := ctx.Value(auditedKey{}).(*bool); ok {
+			*audited = true
+		}
+
+		record := newRecord(ctx, info.FullMethod, req)
+		record.Principal = auth.PrincipalFromContext(ctx)
+		var sensitiveBefore, sensitiveAfter bool
+		if record.Key != "" {
+			record.OldValue, sensitiveBefore = lookup(ctx, sets, record.Environment, record.Key)
+		}
+
+		resp, err := handler(ctx, req)
+
+		record.Timestamp = time.Now().UTC()
+		if record.Key != "" {
+			record.NewValue, sensitiveAfter = lookup(ctx, sets, record.Environment, record.Key)
+		}
+		if sensitiveBefore || sensitiveAfter {
+			record.OldValue, record.NewValue = nil, nil
+			record.Redacted = true
+		}
+		record.Outcome = OutcomeSuccess
+		record.Code = status.Code(err).String()
+		if err != nil {
+			record.Outcome = OutcomeFailure
+			record.Message = status.Convert(err).Message()
+		} else if restart, ok := resp.(*workloadv1.RestartResponse); ok {
+			// restarts report failures in the response
+			if !restart.Success {
+				record.Outcome = OutcomeFailure
+			}
+			record.Message = restart.Message
+		} else if schedule, ok := resp.(*featurev1.Schedule); ok {
+			// the value only changes when the schedule is applied
+			record.Message = fmt.Sprintf("schedule %s at %s", schedule.Id, schedule.At.AsTime().Format(time.RFC3339))
+		} else if schedule, ok := req.(*featurev1.Schedule); ok {
+			record.Message = fmt.Sprintf("schedule %s cancelled", schedule.Id)
+		} else if created, ok := resp.(*featurev1.CreateTokenResponse); ok {
+			// never the secret
+			record.Message = fmt.Sprintf("token %s (%s) created", created.Token.Id, created.Token.Name)
+		} else if token, ok := req.(*featurev1.Token); ok {
+			record.Message = fmt.Sprintf("token %s revoked", token.Id)
+		}
+
+		write(ctx, sink, record)
+		return resp, err
+	}
+}
+
+// auditedKey is the context key marking a call as recorded by the UnaryInterceptor
+type auditedKey struct{}
+
+// RejectionInterceptor writes a record for every mutating call that fails before it reaches the
+// UnaryInterceptor, e.g. because the auth interceptor rejects it. It has to run before the auth
+// interceptor. The principal is only known for calls denied by the roles or scopes, and the
+// values of the key are left out as the caller may not be allowed to see them.
+func RejectionInterceptor(sink Sink) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !auditedMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		audited := new(bool)
+		callCtx, denied := auth.WithDeniedPrincipal(context.WithValue(ctx, auditedKey{}, audited))
+		resp, err := handler(callCtx, req)
+		if err == nil || *audited {
+			return resp, err
+		}
+
+		record := newRecord(ctx, info.FullMethod, req)
+		record.Principal = denied()
+		record.Timestamp = time.Now().UTC()
+		record.Outcome = OutcomeFailure
+		record.Code = status.Code(err).String()
+		record.Message = status.Convert(err).Message()
+		write(ctx, sink, record)
+		return resp, err
+	}
+}
+
+// newRecord returns the record of a call with what is known before the call
+func newRecord(ctx context.Context, fullMethod string, req any) Record {
+	record := Record{
+		Method:      fullMethod[strings.LastIndex(fullMethod, "/")+1:],
+		Set:         setOf(ctx, req),
+		Environment: environmentOf(ctx, req),
+		Key:         keyOf(req),
+		Keys:        keysOf(req),
+		Workload:    workloadOf(req),
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		record.ClientAddress = p.Addr.String()
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		record.TraceID = spanContext.TraceID().String()
+	}
+	return record
+}
+
+func write(ctx context.Context, sink Sink, record Record) {
+	ctx, span := otel.Tracer("audit").Start(ctx, "Write")
+	defer span.End()
+
+	if err := sink.Write(ctx, record); err != nil {
+		slog.ErrorContext(ctx, "Failed to write audit record", "method", record.Method, "key", record.Key, "error", err)
+	}
+}
+
+// keyOf returns the flag key a feature request refers to
+func keyOf(req any) string {
+	switch r := req.(type) {
+	case *featurev1.Key:
+		return r.GetName()
+	case interface{ GetKey() string }:
+		return r.GetKey()
+	}
+	return ""
+}
+
+// keysOf returns the keys a batch request changes
+func keysOf(req any) []string {
+	r, ok := req.(*featurev1.BatchRequest)
+	if !ok {
+		return nil
+	}
+	keys := make([]string, 0, len(r.Operations))
+	for _, op := range r.Operations {
+		if op.GetDelete() != nil {
+			keys = append(keys, op.GetDelete().GetName())
+		} else {
+			keys = append(keys, op.GetSet().GetKey())
+		}
+	}
+	return keys
+}
+
+// workloadOf returns the workload a restart request refers to as type/namespace/name
+func workloadOf(req any) string {
+	r, ok := req.(*workloadv1.RestartRequest)
+	if !ok {
+		return ""
+	}
+	kind := strings.ToLower(strings.TrimPrefix(r.Type.String(), "WORKLOAD_TYPE_"))
+	return kind + "/" + r.Namespace + "/" + r.Name
+}
+
+// setOf returns the flag set a request refers to, empty for the default set
+func setOf(ctx context.Context, req any) string {
+	if r, ok := req.(*featurev1.FlagSet); ok {
+		return r.GetName()
+	}
+	return flagset.NameFromContext(ctx)
+}
+
+// environmentOf returns the environment a request changes, empty if it does not name one
+func environmentOf(ctx context.Context, req any) string {
+	if r, ok := req.(*featurev1.PromoteRequest); ok {
+		return r.GetTo()
+	}
+	return flagset.EnvironmentFromContext(ctx)
+}
+
+// lookup returns the current value of a key in the flag set of the call in the environment, nil if
+// it does not exist, and whether it is sensitive
+func lookup(ctx context.Context, sets *flagset.Manager, environment, key string) (*string, bool) {
+	set, err := sets.InEnvironment(ctx, environment)
+	if err != nil {
+		return nil, false
+	}
+	kv, err := set.Persistence.Get(ctx, key)
+	if err != nil {
+		return nil, false
+	}
+	return &kv.Value, kv.Sensitive
+}
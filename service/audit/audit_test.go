@@ -0,0 +1,333 @@
+package audit
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dkrizic/feature/service/notifier"
+	"github.com/dkrizic/feature/service/service/auth"
+	featurev1 "github.com/dkrizic/feature/service/service/feature/v1"
+	"github.com/dkrizic/feature/service/service/flagset"
+	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/dkrizic/feature/service/service/persistence/inmemory"
+	workloadv1 "github.com/dkrizic/feature/service/service/workload/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type fakeSink struct {
+	records []Record
+}
+
+func (s *fakeSink) Write(ctx context.Context, record Record) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+// sets returns flag sets whose default set is stored in pers, the other sets in memory
+func sets(pers persistence.Persistence) *flagset.Manager {
+	return flagset.NewManager(inmemory.NewInMemoryPersistence(), flagset.Config{}, nil,
+		func(ctx context.Context, partition flagset.Partition, notifiers ...notifier.Notifier) persistence.Persistence {
+			if partition.Name == "" {
+				return pers
+			}
+			return inmemory.NewInMemoryPersistence()
+		})
+}
+
+func callContext() context.Context {
+	ctx := auth.WithPrincipal(context.Background(), "alice")
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 7), Port: 40000}})
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	return trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+}
+
+func TestUnaryInterceptor_Set(t *testing.T) {
+	ctx := callContext()
+	pers := inmemory.NewInMemoryPersistence()
+	require.NoError(t, pers.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	sink := &fakeSink{}
+	interceptor := UnaryInterceptor(sink, sets(pers))
+
+	req := &featurev1.KeyValue{Key: "COLOR", Value: "blue"}
+	info := &grpc.UnaryServerInfo{FullMethod: featurev1.Feature_Set_FullMethodName}
+	_, err := interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+		return &emptypb.Empty{}, pers.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "blue"})
+	})
+	require.NoError(t, err)
+
+	require.Len(t, sink.records, 1)
+	record := sink.records[0]
+	assert.Equal(t, "Set", record.Method)
+	assert.Equal(t, "alice", record.Principal)
+	assert.Equal(t, "10.0.0.7:40000", record.ClientAddress)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record.TraceID)
+	assert.Equal(t, "COLOR", record.Key)
+	assert.Equal(t, "red", *record.OldValue)
+	assert.Equal(t, "blue", *record.NewValue)
+	assert.Equal(t, OutcomeSuccess, record.Outcome)
+	assert.Equal(t, "OK", record.Code)
+	assert.False(t, record.Timestamp.IsZero())
+}
+
+func TestUnaryInterceptor_FailedDelete(t *testing.T) {
+	ctx := callContext()
+	pers := inmemory.NewInMemoryPersistence()
+	require.NoError(t, pers.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	sink := &fakeSink{}
+	interceptor := UnaryInterceptor(sink, sets(pers))
+
+	info := &grpc.UnaryServerInfo{FullMethod: featurev1.Feature_Delete_FullMethodName}
+	_, err := interceptor(ctx, &featurev1.Key{Name: "COLOR"}, info, func(ctx context.Context, req any) (any, error) {
+		return nil, status.Error(codes.PermissionDenied, "field COLOR is not editable")
+	})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	require.Len(t, sink.records, 1)
+	record := sink.records[0]
+	assert.Equal(t, "Delete", record.Method)
+	assert.Equal(t, OutcomeFailure, record.Outcome)
+	assert.Equal(t, "PermissionDenied", record.Code)
+	assert.Equal(t, "field COLOR is not editable", record.Message)
+	// the value is unchanged
+	assert.Equal(t, "red", *record.OldValue)
+	assert.Equal(t, "red", *record.NewValue)
+}
+
+func TestUnaryInterceptor_RestartWorkload(t *testing.T) {
+	sink := &fakeSink{}
+	interceptor := UnaryInterceptor(sink, sets(inmemory.NewInMemoryPersistence()))
+
+	req := &workloadv1.RestartRequest{Type: workloadv1.WorkloadType_WORKLOAD_TYPE_DEPLOYMENT, Name: "shop", Namespace: "prod"}
+	info := &grpc.UnaryServerInfo{FullMethod: workloadv1.Workload_RestartWorkload_FullMethodName}
+	_, err := interceptor(callContext(), req, info, func(ctx context.Context, req any) (any, error) {
+		return &workloadv1.RestartResponse{Success: false, Message: "deployment not found"}, nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, sink.records, 1)
+	record := sink.records[0]
+	assert.Equal(t, "RestartWorkload", record.Method)
+	assert.Equal(t, "deployment/prod/shop", record.Workload)
+	assert.Empty(t, record.Key)
+	assert.Nil(t, record.OldValue)
+	assert.Equal(t, OutcomeFailure, record.Outcome)
+	assert.Equal(t, "OK", record.Code)
+	assert.Equal(t, "deployment not found", record.Message)
+}
+
+func TestUnaryInterceptor_Batch(t *testing.T) {
+	sink := &fakeSink{}
+	interceptor := UnaryInterceptor(sink, sets(inmemory.NewInMemoryPersistence()))
+
+	req := &featurev1.BatchRequest{Operations: []*featurev1.Operation{
+		{Operation: &featurev1.Operation_Set{Set: &featurev1.KeyValue{Key: "COLOR", Value: "blue"}}},
+		{Operation: &featurev1.Operation_Delete{Delete: &featurev1.Key{Name: "SIZE"}}},
+	}}
+	info := &grpc.UnaryServerInfo{FullMethod: featurev1.Feature_Batch_FullMethodName}
+	_, err := interceptor(callContext(), req, info, func(ctx context.Context, req any) (any, error) {
+		return &emptypb.Empty{}, nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, sink.records, 1)
+	record := sink.records[0]
+	assert.Equal(t, "Batch", record.Method)
+	assert.Equal(t, []string{"COLOR", "SIZE"}, record.Keys)
+	assert.Empty(t, record.Key)
+	assert.Equal(t, OutcomeSuccess, record.Outcome)
+}
+
+func TestUnaryInterceptor_SkipsReads(t *testing.T) {
+	sink := &fakeSink{}
+	interceptor := UnaryInterceptor(sink, sets(inmemory.NewInMemoryPersistence()))
+
+	info := &grpc.UnaryServerInfo{FullMethod: featurev1.Feature_Get_FullMethodName}
+	_, err := interceptor(callContext(), &featurev1.Key{Name: "COLOR"}, info, func(ctx context.Context, req any) (any, error) {
+		return &featurev1.Value{}, nil
+	})
+	require.NoError(t, err)
+	assert.Empty(t, sink.records)
+}
+
+func TestUnaryInterceptor_FlagSet(t *testing.T) {
+	sink := &fakeSink{}
+	manager := sets(inmemory.NewInMemoryPersistence())
+	interceptor := UnaryInterceptor(sink, manager)
+
+	ctx := callContext()
+	info := &grpc.UnaryServerInfo{FullMethod: featurev1.Feature_CreateSet_FullMethodName}
+	_, err := interceptor(ctx, &featurev1.FlagSet{Name: "team-a"}, info, func(ctx context.Context, req any) (any, error) {
+		return &emptypb.Empty{}, manager.Create(ctx, flagset.Config{Name: "team-a"})
+	})
+	require.NoError(t, err)
+
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(flagset.MetadataKey, "team-a"))
+	info = &grpc.UnaryServerInfo{FullMethod: featurev1.Feature_Set_FullMethodName}
+	_, err = interceptor(ctx, &featurev1.KeyValue{Key: "COLOR", Value: "blue"}, info, func(ctx context.Context, req any) (any, error) {
+		set, err := manager.FromContext(ctx)
+		require.NoError(t, err)
+		return &emptypb.Empty{}, set.Persistence.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "blue"})
+	})
+	require.NoError(t, err)
+
+	require.Len(t, sink.records, 2)
+	assert.Equal(t, "CreateSet", sink.records[0].Method)
+	assert.Equal(t, "team-a", sink.records[0].Set)
+	assert.Equal(t, "team-a", sink.records[1].Set)
+	assert.Nil(t, sink.records[1].OldValue)
+	assert.Equal(t, "blue", *sink.records[1].NewValue)
+}
+
+func TestUnaryInterceptor_Promote(t *testing.T) {
+	sink := &fakeSink{}
+	manager := flagset.NewManager(inmemory.NewInMemoryPersistence(), flagset.Config{}, []string{"staging", "prod"},
+		func(ctx context.Context, partition flagset.Partition, notifiers ...notifier.Notifier) persistence.Persistence {
+			return inmemory.NewInMemoryPersistence()
+		})
+	interceptor := UnaryInterceptor(sink, manager)
+
+	ctx := callContext()
+	prod, err := manager.Get(ctx, "", "prod")
+	require.NoError(t, err)
+	require.NoError(t, prod.Persistence.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+
+	// the values are those of the target environment, whatever the metadata of the call says
+	info := &grpc.UnaryServerInfo{FullMethod: featurev1.Feature_Promote_FullMethodName}
+	req := &featurev1.PromoteRequest{Key: "COLOR", From: "staging", To: "prod"}
+	_, err = interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+		return &featurev1.PromoteResponse{Applied: true}, prod.Persistence.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "blue"})
+	})
+	require.NoError(t, err)
+
+	require.Len(t, sink.records, 1)
+	record := sink.records[0]
+	assert.Equal(t, "Promote", record.Method)
+	assert.Equal(t, "prod", record.Environment)
+	assert.Equal(t, "COLOR", record.Key)
+	assert.Equal(t, "red", *record.OldValue)
+	assert.Equal(t, "blue", *record.NewValue)
+}
+
+func TestUnaryInterceptor_ScheduleSet(t *testing.T) {
+	sink := &fakeSink{}
+	pers := inmemory.NewInMemoryPersistence()
+	interceptor := UnaryInterceptor(sink, sets(pers))
+	require.NoError(t, pers.Set(context.Background(), persistence.KeyValue{Key: "COLOR", Value: "red"}))
+
+	info := &grpc.UnaryServerInfo{FullMethod: featurev1.Feature_ScheduleSet_FullMethodName}
+	req := &featurev1.ScheduleSetRequest{Key: "COLOR", Value: "blue", At: timestamppb.New(time.Date(2030, 1, 1, 8, 0, 0, 0, time.UTC))}
+	_, err := interceptor(callContext(), req, info, func(ctx context.Context, req any) (any, error) {
+		return &featurev1.Schedule{Id: "4f2a", Key: "COLOR", At: req.(*featurev1.ScheduleSetRequest).At}, nil
+	})
+	require.NoError(t, err)
+	info = &grpc.UnaryServerInfo{FullMethod: featurev1.Feature_CancelSchedule_FullMethodName}
+	_, err = interceptor(callContext(), &featurev1.Schedule{Id: "4f2a"}, info, func(ctx context.Context, req any) (any, error) {
+		return &emptypb.Empty{}, nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, sink.records, 2)
+	record := sink.records[0]
+	assert.Equal(t, "ScheduleSet", record.Method)
+	assert.Equal(t, "COLOR", record.Key)
+	// the value is unchanged until the schedule is applied
+	assert.Equal(t, "red", *record.NewValue)
+	assert.Equal(t, "schedule 4f2a at 2030-01-01T08:00:00Z", record.Message)
+	assert.Equal(t, "CancelSchedule", sink.records[1].Method)
+	assert.Equal(t, "schedule 4f2a cancelled", sink.records[1].Message)
+}
+
+func TestUnaryInterceptor_Tokens(t *testing.T) {
+	sink := &fakeSink{}
+	interceptor := UnaryInterceptor(sink, sets(inmemory.NewInMemoryPersistence()))
+
+	info := &grpc.UnaryServerInfo{FullMethod: featurev1.Feature_CreateToken_FullMethodName}
+	_, err := interceptor(callContext(), &featurev1.CreateTokenRequest{Name: "ci"}, info, func(ctx context.Context, req any) (any, error) {
+		return &featurev1.CreateTokenResponse{Token: &featurev1.Token{Id: "4f2a", Name: "ci"}, Secret: "ft_4f2a_secret"}, nil
+	})
+	require.NoError(t, err)
+	info = &grpc.UnaryServerInfo{FullMethod: featurev1.Feature_RevokeToken_FullMethodName}
+	_, err = interceptor(callContext(), &featurev1.Token{Id: "4f2a"}, info, func(ctx context.Context, req any) (any, error) {
+		return &emptypb.Empty{}, nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, sink.records, 2)
+	assert.Equal(t, "token 4f2a (ci) created", sink.records[0].Message)
+	assert.Equal(t, "RevokeToken", sink.records[1].Method)
+	assert.Equal(t, "token 4f2a revoked", sink.records[1].Message)
+}
+
+func TestRejectionInterceptor(t *testing.T) {
+	policy := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(policy, []byte("users:\n  bob: [viewer]\n  alice: [admin]\n"), 0o600))
+	authorizer := auth.NewAuthorizer(auth.NewFileSource(policy))
+	require.NoError(t, authorizer.Reload(context.Background()))
+	users, err := auth.NewUsers("bob", "secret", nil, nil)
+	require.NoError(t, err)
+
+	pers := inmemory.NewInMemoryPersistence()
+	require.NoError(t, pers.Set(context.Background(), persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	sink := &fakeSink{}
+	rejections := RejectionInterceptor(sink)
+	authInterceptor := auth.SelectiveInterceptor(true, users, authorizer, nil)
+	audit := UnaryInterceptor(sink, sets(pers))
+
+	// the order of the interceptors in the service
+	call := func(credentials string) error {
+		header := "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+		ctx := metadata.NewIncomingContext(callContext(), metadata.Pairs("authorization", header))
+		info := &grpc.UnaryServerInfo{FullMethod: featurev1.Feature_Delete_FullMethodName}
+		handler := func(ctx context.Context, req any) (any, error) {
+			return &emptypb.Empty{}, nil
+		}
+		_, err := rejections(ctx, &featurev1.Key{Name: "COLOR"}, info, func(ctx context.Context, req any) (any, error) {
+			return authInterceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+				return audit(ctx, req, info, handler)
+			})
+		})
+		return err
+	}
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(call("bob:wrong")))
+	assert.Equal(t, codes.PermissionDenied, status.Code(call("bob:secret")))
+
+	require.Len(t, sink.records, 2)
+	unauthenticated := sink.records[0]
+	assert.Equal(t, "Delete", unauthenticated.Method)
+	assert.Equal(t, "COLOR", unauthenticated.Key)
+	assert.Empty(t, unauthenticated.Principal)
+	assert.Equal(t, OutcomeFailure, unauthenticated.Outcome)
+	assert.Equal(t, "Unauthenticated", unauthenticated.Code)
+	assert.Equal(t, "10.0.0.7:40000", unauthenticated.ClientAddress)
+	assert.Nil(t, unauthenticated.OldValue, "the values are not read for rejected calls")
+
+	denied := sink.records[1]
+	assert.Equal(t, "bob", denied.Principal)
+	assert.Equal(t, "PermissionDenied", denied.Code)
+	assert.Nil(t, denied.OldValue)
+
+	// an allowed call is recorded once, by the audit interceptor
+	users, err = auth.NewUsers("alice", "wonderland", nil, nil)
+	require.NoError(t, err)
+	authInterceptor = auth.SelectiveInterceptor(true, users, authorizer, nil)
+	require.NoError(t, call("alice:wonderland"))
+	require.Len(t, sink.records, 3)
+	assert.Equal(t, "alice", sink.records[2].Principal)
+	assert.Equal(t, "red", *sink.records[2].OldValue)
+}
@@ -0,0 +1,91 @@
+package event
+
+// implements a Sink that creates a Kubernetes Event for every record
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/dkrizic/feature/service/audit"
+	"github.com/dkrizic/feature/service/meta"
+	"go.opentelemetry.io/otel"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// eventClient is an interface for Event operations to allow testing
+type eventClient interface {
+	Create(ctx context.Context, event *v1.Event, opts metav1.CreateOptions) (*v1.Event, error)
+}
+
+type EventSink struct {
+	client    eventClient
+	namespace string
+	// pod is the pod of the service, the events are attached to it
+	pod string
+}
+
+// NewEventSink creates events in the namespace of the service using the in-cluster configuration
+func NewEventSink(namespace string, pod string) (*EventSink, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create in-cluster config: %w", err)
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes clientset: %w", err)
+	}
+	return newEventSink(clientset.CoreV1().Events(namespace), namespace, pod), nil
+}
+
+func newEventSink(client eventClient, namespace string, pod string) *EventSink {
+	return &EventSink{
+		client:    client,
+		namespace: namespace,
+		pod:       pod,
+	}
+}
+
+// Write creates an event with the method as reason and the record as JSON message.
+// Failed calls are reported as warnings.
+func (s *EventSink) Write(ctx context.Context, record audit.Record) error {
+	ctx, span := otel.Tracer("audit/event").Start(ctx, "Write")
+	defer span.End()
+
+	message, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	eventType := v1.EventTypeNormal
+	if record.Outcome != audit.OutcomeSuccess {
+		eventType = v1.EventTypeWarning
+	}
+	now := metav1.NewTime(record.Timestamp)
+
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "feature-audit-",
+			Namespace:    s.namespace,
+		},
+		InvolvedObject: v1.ObjectReference{
+			Kind:       "Pod",
+			APIVersion: "v1",
+			Namespace:  s.namespace,
+			Name:       s.pod,
+		},
+		Reason:              record.Method,
+		Message:             string(message),
+		Type:                eventType,
+		Source:              v1.EventSource{Component: meta.Service},
+		ReportingController: meta.Service,
+		ReportingInstance:   s.pod,
+		FirstTimestamp:      now,
+		LastTimestamp:       now,
+		Count:               1,
+	}
+	_, err = s.client.Create(ctx, event, metav1.CreateOptions{})
+	return err
+}
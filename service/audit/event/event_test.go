@@ -0,0 +1,67 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dkrizic/feature/service/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeEventClient implements eventClient interface for testing
+type fakeEventClient struct {
+	events []*v1.Event
+	err    error
+}
+
+func (f *fakeEventClient) Create(ctx context.Context, event *v1.Event, opts metav1.CreateOptions) (*v1.Event, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.events = append(f.events, event.DeepCopy())
+	return event, nil
+}
+
+func TestEventSink_Write(t *testing.T) {
+	client := &fakeEventClient{}
+	sink := newEventSink(client, "flags", "feature-7d9f")
+	value := "blue"
+	record := audit.Record{
+		Timestamp: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		Method:    "Set",
+		Principal: "alice",
+		Key:       "COLOR",
+		NewValue:  &value,
+		Outcome:   audit.OutcomeSuccess,
+		Code:      "OK",
+	}
+
+	require.NoError(t, sink.Write(context.Background(), record))
+	require.Len(t, client.events, 1)
+	event := client.events[0]
+	assert.Equal(t, "flags", event.Namespace)
+	assert.Equal(t, v1.ObjectReference{Kind: "Pod", APIVersion: "v1", Namespace: "flags", Name: "feature-7d9f"}, event.InvolvedObject)
+	assert.Equal(t, "Set", event.Reason)
+	assert.Equal(t, v1.EventTypeNormal, event.Type)
+	assert.True(t, event.LastTimestamp.Time.Equal(record.Timestamp))
+
+	var message audit.Record
+	require.NoError(t, json.Unmarshal([]byte(event.Message), &message))
+	assert.Equal(t, record, message)
+
+	// failed calls are warnings
+	record.Outcome = audit.OutcomeFailure
+	require.NoError(t, sink.Write(context.Background(), record))
+	assert.Equal(t, v1.EventTypeWarning, client.events[1].Type)
+}
+
+func TestEventSink_Write_Error(t *testing.T) {
+	sink := newEventSink(&fakeEventClient{err: errors.New("forbidden")}, "flags", "feature-7d9f")
+	assert.Error(t, sink.Write(context.Background(), audit.Record{Method: "Delete"}))
+}
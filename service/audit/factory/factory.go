@@ -0,0 +1,44 @@
+package factory
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+
+	"github.com/dkrizic/feature/service/audit"
+	"github.com/dkrizic/feature/service/audit/event"
+	"github.com/dkrizic/feature/service/audit/jsonl"
+	"github.com/dkrizic/feature/service/constant"
+	"github.com/urfave/cli/v3"
+)
+
+// NewSink creates the audit sink selected by the audit flags. It is only called if auditing is enabled.
+func NewSink(ctx context.Context, cmd *cli.Command) (audit.Sink, error) {
+	atype := cmd.String(constant.AuditType)
+
+	switch atype {
+	case constant.AuditTypeStdout:
+		slog.InfoContext(ctx, "Stdout audit sink selected")
+		return jsonl.NewJSONLinesSink(os.Stdout), nil
+	case constant.AuditTypeFile:
+		path := cmd.String(constant.AuditFile)
+		slog.InfoContext(ctx, "File audit sink selected", "path", path)
+		return jsonl.NewFileSink(path)
+	case constant.AuditTypeEvent:
+		namespace := os.Getenv("POD_NAMESPACE")
+		if namespace == "" {
+			namespace = "default"
+		}
+		// the pod name is the host name unless set explicitly
+		pod := os.Getenv("POD_NAME")
+		if pod == "" {
+			pod, _ = os.Hostname()
+		}
+		slog.InfoContext(ctx, "Kubernetes event audit sink selected", "namespace", namespace, "pod", pod)
+		return event.NewEventSink(namespace, pod)
+	default:
+		slog.ErrorContext(ctx, "Invalid audit type", "type", atype)
+		return nil, errors.New("Invalid audit type")
+	}
+}
@@ -0,0 +1,49 @@
+package jsonl
+
+// implements a Sink that writes every record as one line of JSON, to a file or stdout
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/dkrizic/feature/service/audit"
+)
+
+type JSONLinesSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONLinesSink writes the records to w, e.g. os.Stdout
+func NewJSONLinesSink(w io.Writer) *JSONLinesSink {
+	return &JSONLinesSink{
+		w: w,
+	}
+}
+
+// NewFileSink appends the records to the file at path, which is created if it does not exist
+func NewFileSink(path string) (*JSONLinesSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	return NewJSONLinesSink(file), nil
+}
+
+func (s *JSONLinesSink) Write(ctx context.Context, record audit.Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	// a single write per record keeps lines intact
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(line)
+	return err
+}
@@ -0,0 +1,51 @@
+package jsonl
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dkrizic/feature/service/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSink_AppendsOneLinePerRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	old, value := "red", "blue"
+	records := []audit.Record{
+		{Timestamp: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC), Method: "Set", Principal: "alice", Key: "COLOR", OldValue: &old, NewValue: &value, Outcome: audit.OutcomeSuccess, Code: "OK"},
+		{Timestamp: time.Date(2025, 6, 1, 12, 1, 0, 0, time.UTC), Method: "Delete", Key: "COLOR", Outcome: audit.OutcomeFailure, Code: "PermissionDenied", Message: "not editable"},
+	}
+
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+	require.NoError(t, sink.Write(context.Background(), records[0]))
+
+	// a second sink appends instead of truncating, e.g. after a restart
+	sink, err = NewFileSink(path)
+	require.NoError(t, err)
+	require.NoError(t, sink.Write(context.Background(), records[1]))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var written []audit.Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record audit.Record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		written = append(written, record)
+	}
+	assert.Equal(t, records, written)
+}
+
+func TestNewFileSink_InvalidPath(t *testing.T) {
+	_, err := NewFileSink(filepath.Join(t.TempDir(), "missing", "audit.jsonl"))
+	assert.Error(t, err)
+}
@@ -16,6 +16,17 @@ const (
 	ConfigMapName              = "configmap-name"
 	StorageTypeInMemory        = "inmemory"
 	StorageTypeConfigMap       = "configmap"
+	StorageTypeCRD             = "crd"
+	StorageTypeFile            = "file"
+	StorageFile                = "storage-file"
+	StorageFileReload          = "storage-file-reload"
+	StorageTypeSQL             = "sql"
+	SQLDialect                 = "sql-dialect"
+	SQLDialectSQLite           = "sqlite"
+	SQLDialectPostgres         = "postgres"
+	SQLDSN                     = "sql-dsn"
+	StorageTypeSecret          = "secret"
+	SecretName                 = "secret-name"
 	PreSet                     = "preset"
 	NotificationEnabled        = "notification-enabled"
 	NotificationType           = "notification-type"
@@ -23,11 +34,29 @@ const (
 	NotificationTypeRedisTopic = "redis_topic"
 	RedisEndpoint              = "redis-endpoint"
 	RedisNotificationTopic     = "redis-notification-topic"
+	AuditEnabled               = "audit-enabled"
+	AuditType                  = "audit-type"
+	AuditTypeStdout            = "stdout"
+	AuditTypeFile              = "file"
+	AuditTypeEvent             = "event"
+	AuditFile                  = "audit-file"
 	RestartEnabled             = "restart-enabled"
 	RestartType                = "restart-type"
 	RestartName                = "restart-name"
 	Editable                   = "editable"
+	Reveal                     = "reveal"
+	Environments               = "environments"
+	ScheduleInterval           = "schedule-interval"
+	StaleAfter                 = "stale-after"
+	StaleInterval              = "stale-interval"
 	AuthenticationEnabled      = "authentication-enabled"
 	AuthenticationUsername     = "authentication-username"
 	AuthenticationPassword     = "authentication-password"
+	AuthenticationUsersFile    = "authentication-users-file"
+	AuthenticationUsersSecret  = "authentication-users-secret"
+	AuthenticationUsersKey     = "authentication-users-key"
+	AuthenticationUsersReload  = "authentication-users-reload"
+	AuthenticationMaxFailures  = "authentication-max-failures"
+	AuthenticationLockout      = "authentication-lockout"
+	AuthorizationPolicy        = "authorization-policy"
 )
@@ -6,6 +6,8 @@ import (
 	"log"
 	"log/slog"
 	"os"
+	"slices"
+	"time"
 
 	"github.com/dkrizic/feature/service/constant"
 	"github.com/dkrizic/feature/service/meta"
@@ -87,10 +89,11 @@ func main() {
 					&cli.StringFlag{
 						Name:    constant.StorageType,
 						Value:   constant.StorageTypeInMemory,
-						Usage:   "Type of storage to use: inmemory, configmap",
+						Usage:   "Type of storage to use: inmemory, configmap, crd, file, sql, secret",
 						Sources: cli.EnvVars("STORAGE_TYPE"),
 						Action: func(ctx context.Context, cmd *cli.Command, s string) error {
-							if s != constant.StorageTypeInMemory && s != constant.StorageTypeConfigMap {
+							if !slices.Contains([]string{constant.StorageTypeInMemory, constant.StorageTypeConfigMap, constant.StorageTypeCRD,
+								constant.StorageTypeFile, constant.StorageTypeSQL, constant.StorageTypeSecret}, s) {
 								return fmt.Errorf("invalid storage type: %s", s)
 							}
 							if s == constant.StorageTypeConfigMap {
@@ -99,6 +102,15 @@ func main() {
 									return fmt.Errorf("configmap-name cannot be empty when storage-type is configmap")
 								}
 							}
+							if s == constant.StorageTypeFile && cmd.String(constant.StorageFile) == "" {
+								return fmt.Errorf("storage-file cannot be empty when storage-type is file")
+							}
+							if s == constant.StorageTypeSQL && cmd.String(constant.SQLDSN) == "" {
+								return fmt.Errorf("sql-dsn cannot be empty when storage-type is sql")
+							}
+							if s == constant.StorageTypeSecret && cmd.String(constant.SecretName) == "" {
+								return fmt.Errorf("secret-name cannot be empty when storage-type is secret")
+							}
 							return nil
 						},
 					},
@@ -107,6 +119,39 @@ func main() {
 						Usage:   "Name of the ConfigMap to use for configmap storage",
 						Sources: cli.EnvVars("CONFIGMAP_NAME"),
 					},
+					&cli.StringFlag{
+						Name:    constant.SecretName,
+						Usage:   "Name of the Secret to use for secret storage",
+						Sources: cli.EnvVars("SECRET_NAME"),
+					},
+					&cli.StringFlag{
+						Name:    constant.StorageFile,
+						Usage:   "Path of the JSON or YAML file to use for file storage",
+						Sources: cli.EnvVars("STORAGE_FILE"),
+					},
+					&cli.BoolFlag{
+						Name:    constant.StorageFileReload,
+						Usage:   "Pick up changes made to the storage file by others, disable if the service is its only writer",
+						Value:   true,
+						Sources: cli.EnvVars("STORAGE_FILE_RELOAD"),
+					},
+					&cli.StringFlag{
+						Name:    constant.SQLDialect,
+						Value:   constant.SQLDialectSQLite,
+						Usage:   "SQL dialect to use for sql storage: sqlite, postgres",
+						Sources: cli.EnvVars("SQL_DIALECT"),
+						Action: func(ctx context.Context, cmd *cli.Command, s string) error {
+							if s != constant.SQLDialectSQLite && s != constant.SQLDialectPostgres {
+								return fmt.Errorf("invalid sql dialect: %s", s)
+							}
+							return nil
+						},
+					},
+					&cli.StringFlag{
+						Name:    constant.SQLDSN,
+						Usage:   "Data source name for sql storage, a file name for sqlite or a connection URL for postgres",
+						Sources: cli.EnvVars("SQL_DSN"),
+					},
 					&cli.StringSliceFlag{
 						Name:    constant.PreSet,
 						Usage:   "Pre-set key-value pairs in the format key=value before starting the service",
@@ -143,7 +188,7 @@ func main() {
 					},
 					&cli.StringFlag{
 						Name:    constant.RedisEndpoint,
-						Usage:   "Redis endpoint for redis_topic notifications",
+						Usage:   "Redis endpoint for redis_topic notifications (host:port or redis:// URL)",
 						Sources: cli.EnvVars("REDIS_ENDPOINT"),
 					},
 					&cli.StringFlag{
@@ -152,6 +197,36 @@ func main() {
 						Value:   "feature_notifications",
 						Sources: cli.EnvVars("REDIS_NOTIFICATION_TOPIC"),
 					},
+					&cli.BoolFlag{
+						Name:     constant.AuditEnabled,
+						Usage:    "Enable the audit log of mutating calls",
+						Value:    false,
+						Category: "audit",
+						Sources:  cli.EnvVars("AUDIT_ENABLED"),
+					},
+					&cli.StringFlag{
+						Name:     constant.AuditType,
+						Usage:    "Where to write the audit log: stdout, file, event",
+						Value:    constant.AuditTypeStdout,
+						Category: "audit",
+						Sources:  cli.EnvVars("AUDIT_TYPE"),
+						Action: func(ctx context.Context, cmd *cli.Command, s string) error {
+							if s != constant.AuditTypeStdout && s != constant.AuditTypeFile && s != constant.AuditTypeEvent {
+								return fmt.Errorf("invalid audit type: %s", s)
+							}
+							// if audit type is file, the audit file must be set
+							if s == constant.AuditTypeFile && cmd.String(constant.AuditFile) == "" {
+								return fmt.Errorf("audit-file cannot be empty when audit-type is file")
+							}
+							return nil
+						},
+					},
+					&cli.StringFlag{
+						Name:     constant.AuditFile,
+						Usage:    "Path of the JSON lines file for the file audit type",
+						Category: "audit",
+						Sources:  cli.EnvVars("AUDIT_FILE"),
+					},
 					&cli.BoolFlag{
 						Name:     constant.RestartEnabled,
 						Usage:    "Enable workload restart feature",
@@ -186,6 +261,41 @@ func main() {
 						Category: "service",
 						Sources:  cli.EnvVars("EDITABLE"),
 					},
+					&cli.StringFlag{
+						Name:     constant.Reveal,
+						Usage:    "Comma-separated list of principals that may see the values of sensitive flags",
+						Value:    "",
+						Category: "service",
+						Sources:  cli.EnvVars("REVEAL"),
+					},
+					&cli.StringFlag{
+						Name:     constant.Environments,
+						Usage:    "Comma-separated list of environments, e.g. dev,staging,prod (the first one keeps the existing flags, empty disables environments)",
+						Value:    "",
+						Category: "service",
+						Sources:  cli.EnvVars("ENVIRONMENTS"),
+					},
+					&cli.DurationFlag{
+						Name:     constant.ScheduleInterval,
+						Usage:    "How often scheduled changes are checked and applied when due",
+						Value:    10 * time.Second,
+						Category: "service",
+						Sources:  cli.EnvVars("SCHEDULE_INTERVAL"),
+					},
+					&cli.DurationFlag{
+						Name:     constant.StaleAfter,
+						Usage:    "Period after which flags that were neither changed nor read are reported as stale, 0 only reports expired flags",
+						Value:    720 * time.Hour,
+						Category: "service",
+						Sources:  cli.EnvVars("STALE_AFTER"),
+					},
+					&cli.DurationFlag{
+						Name:     constant.StaleInterval,
+						Usage:    "How often flags are checked for being stale",
+						Value:    time.Hour,
+						Category: "service",
+						Sources:  cli.EnvVars("STALE_INTERVAL"),
+					},
 					&cli.BoolFlag{
 						Name:     constant.AuthenticationEnabled,
 						Usage:    "Enable authentication for Feature and Workload services",
@@ -202,11 +312,57 @@ func main() {
 					},
 					&cli.StringFlag{
 						Name:     constant.AuthenticationPassword,
-						Usage:    "Password for authentication",
+						Usage:    "Password for authentication, optional with a users file or Secret",
 						Value:    "",
 						Category: "authentication",
 						Sources:  cli.EnvVars("AUTHENTICATION_PASSWORD"),
 					},
+					&cli.StringFlag{
+						Name:     constant.AuthenticationUsersFile,
+						Usage:    "File with one username:hash line per user, bcrypt or argon2id hashes, reloaded when it changes",
+						Category: "authentication",
+						Sources:  cli.EnvVars("AUTHENTICATION_USERS_FILE"),
+					},
+					&cli.StringFlag{
+						Name:     constant.AuthenticationUsersSecret,
+						Usage:    "Secret in the own namespace with the users in the format of the users file, reloaded when it changes",
+						Category: "authentication",
+						Sources:  cli.EnvVars("AUTHENTICATION_USERS_SECRET"),
+					},
+					&cli.StringFlag{
+						Name:     constant.AuthenticationUsersKey,
+						Usage:    "Key of the users in the users Secret",
+						Value:    "users",
+						Category: "authentication",
+						Sources:  cli.EnvVars("AUTHENTICATION_USERS_KEY"),
+					},
+					&cli.DurationFlag{
+						Name:     constant.AuthenticationUsersReload,
+						Usage:    "How often the users file or Secret and the authorization policy are checked for changes",
+						Value:    10 * time.Second,
+						Category: "authentication",
+						Sources:  cli.EnvVars("AUTHENTICATION_USERS_RELOAD"),
+					},
+					&cli.IntFlag{
+						Name:     constant.AuthenticationMaxFailures,
+						Usage:    "Failed attempts in a row after which a user is locked out, 0 disables the lockout",
+						Value:    5,
+						Category: "authentication",
+						Sources:  cli.EnvVars("AUTHENTICATION_MAX_FAILURES"),
+					},
+					&cli.DurationFlag{
+						Name:     constant.AuthenticationLockout,
+						Usage:    "How long a user is locked out after too many failed attempts",
+						Value:    15 * time.Minute,
+						Category: "authentication",
+						Sources:  cli.EnvVars("AUTHENTICATION_LOCKOUT"),
+					},
+					&cli.StringFlag{
+						Name:     constant.AuthorizationPolicy,
+						Usage:    "File with the policy mapping users to roles and roles to methods and key patterns, reloaded when it changes, empty allows everything",
+						Category: "authentication",
+						Sources:  cli.EnvVars("AUTHORIZATION_POLICY"),
+					},
 				},
 			},
 		},
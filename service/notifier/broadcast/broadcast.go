@@ -0,0 +1,166 @@
+package broadcast
+
+// implements a Notifier that fans notifications out to in-process subscribers (e.g. Watch streams)
+// and keeps a bounded backlog so reconnecting subscribers can resume from a revision.
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dkrizic/feature/service/notifier"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// DefaultHistorySize is the number of events retained for resuming subscribers
+	DefaultHistorySize = 1024
+	// subscriberBuffer is the number of events a subscriber may lag behind before it is dropped
+	subscriberBuffer = 256
+)
+
+// Event is a notification that has been assigned a revision
+type Event struct {
+	Revision uint64
+	Type     notifier.ActionType
+	Key      string
+	Value    string
+	// Redacted is set if the value of a sensitive key was withheld
+	Redacted bool
+}
+
+type Broadcaster struct {
+	mu          sync.Mutex
+	revision    uint64
+	history     []Event
+	historySize int
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscription receives every event published after it was created
+type Subscription struct {
+	broadcaster *Broadcaster
+	events      chan Event
+	revision    uint64
+	overflowed  bool
+	closed      bool
+}
+
+func NewBroadcaster(historySize int) *Broadcaster {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	return &Broadcaster{
+		historySize: historySize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+func (b *Broadcaster) Notify(ctx context.Context, notification notifier.Notification) error {
+	ctx, span := otel.Tracer("notifier/broadcast").Start(ctx, "Notify")
+	defer span.End()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// the changes of a batch become consecutive events, published while holding the lock
+	// so that no other event ends up in between
+	for _, action := range notification.Actions() {
+		event := Event{
+			Type:     action.Type,
+			Key:      action.Key,
+			Redacted: action.Redacted,
+		}
+		if action.Value != nil {
+			event.Value = *action.Value
+		}
+
+		b.revision++
+		event.Revision = b.revision
+		b.history = append(b.history, event)
+		if len(b.history) > b.historySize {
+			b.history = b.history[len(b.history)-b.historySize:]
+		}
+
+		for sub := range b.subscribers {
+			select {
+			case sub.events <- event:
+			default:
+				// subscriber is too slow, drop it so it can resume from its last revision
+				sub.overflowed = true
+				b.remove(sub)
+			}
+		}
+	}
+	span.SetAttributes(attribute.Int64("revision", int64(b.revision)), attribute.Int("subscribers", len(b.subscribers)))
+	return nil
+}
+
+// Revision returns the revision of the latest event
+func (b *Broadcaster) Revision() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.revision
+}
+
+// Subscribe registers a new subscription. If since is non-zero and all events after it are
+// still retained, they are returned as backlog and resumed is true. Otherwise the caller
+// has to send a fresh snapshot; the subscription's Revision tells which revision it reflects.
+func (b *Broadcaster) Subscribe(since uint64) (sub *Subscription, backlog []Event, resumed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub = &Subscription{
+		broadcaster: b,
+		events:      make(chan Event, subscriberBuffer),
+		revision:    b.revision,
+	}
+	b.subscribers[sub] = struct{}{}
+
+	if since == 0 || since > b.revision {
+		return sub, nil, false
+	}
+	oldest := b.revision - uint64(len(b.history)) + 1
+	if since+1 < oldest {
+		return sub, nil, false
+	}
+	for _, event := range b.history {
+		if event.Revision > since {
+			backlog = append(backlog, event)
+		}
+	}
+	return sub, backlog, true
+}
+
+// remove must be called with b.mu held
+func (b *Broadcaster) remove(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(b.subscribers, sub)
+	close(sub.events)
+}
+
+// Events returns the channel of live events. It is closed when the subscription ends.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Revision returns the revision at which the subscription was created
+func (s *Subscription) Revision() uint64 {
+	return s.revision
+}
+
+// Overflowed reports whether the subscription was dropped because it could not keep up
+func (s *Subscription) Overflowed() bool {
+	s.broadcaster.mu.Lock()
+	defer s.broadcaster.mu.Unlock()
+	return s.overflowed
+}
+
+func (s *Subscription) Close() {
+	s.broadcaster.mu.Lock()
+	defer s.broadcaster.mu.Unlock()
+	s.broadcaster.remove(s)
+}
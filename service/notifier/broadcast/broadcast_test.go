@@ -0,0 +1,120 @@
+package broadcast
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dkrizic/feature/service/notifier"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroadcaster_DeliversToSubscribers(t *testing.T) {
+	ctx := context.Background()
+	b := NewBroadcaster(10)
+
+	sub, backlog, resumed := b.Subscribe(0)
+	defer sub.Close()
+	assert.False(t, resumed)
+	assert.Empty(t, backlog)
+	assert.Equal(t, uint64(0), sub.Revision())
+
+	assert.NoError(t, b.Notify(ctx, notifier.CreateNotifucation("k1", "v1")))
+	assert.NoError(t, b.Notify(ctx, notifier.DeleteNotification("k1")))
+
+	event := <-sub.Events()
+	assert.Equal(t, uint64(1), event.Revision)
+	assert.Equal(t, notifier.ActionCreate, event.Type)
+	assert.Equal(t, "k1", event.Key)
+	assert.Equal(t, "v1", event.Value)
+
+	event = <-sub.Events()
+	assert.Equal(t, uint64(2), event.Revision)
+	assert.Equal(t, notifier.ActionDelete, event.Type)
+	assert.Equal(t, "", event.Value)
+}
+
+func TestBroadcaster_BatchIsSplitIntoEvents(t *testing.T) {
+	ctx := context.Background()
+	b := NewBroadcaster(10)
+
+	sub, _, _ := b.Subscribe(0)
+	defer sub.Close()
+
+	value := "v1"
+	assert.NoError(t, b.Notify(ctx, notifier.BatchNotification([]notifier.Action{
+		{Type: notifier.ActionUpdate, Key: "k1", Value: &value},
+		{Type: notifier.ActionDelete, Key: "k2"},
+	})))
+
+	event := <-sub.Events()
+	assert.Equal(t, Event{Revision: 1, Type: notifier.ActionUpdate, Key: "k1", Value: "v1"}, event)
+	event = <-sub.Events()
+	assert.Equal(t, Event{Revision: 2, Type: notifier.ActionDelete, Key: "k2"}, event)
+}
+
+func TestBroadcaster_ResumeFromRevision(t *testing.T) {
+	ctx := context.Background()
+	b := NewBroadcaster(10)
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, b.Notify(ctx, notifier.UpdateNotification("k", "v")))
+	}
+
+	sub, backlog, resumed := b.Subscribe(3)
+	defer sub.Close()
+	assert.True(t, resumed)
+	assert.Len(t, backlog, 2)
+	assert.Equal(t, uint64(4), backlog[0].Revision)
+	assert.Equal(t, uint64(5), backlog[1].Revision)
+
+	// already up to date
+	sub2, backlog, resumed := b.Subscribe(5)
+	defer sub2.Close()
+	assert.True(t, resumed)
+	assert.Empty(t, backlog)
+}
+
+func TestBroadcaster_ResumeNotPossible(t *testing.T) {
+	ctx := context.Background()
+	b := NewBroadcaster(3)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, b.Notify(ctx, notifier.UpdateNotification("k", "v")))
+	}
+
+	// revision 5 is no longer retained
+	sub, backlog, resumed := b.Subscribe(5)
+	defer sub.Close()
+	assert.False(t, resumed)
+	assert.Empty(t, backlog)
+	assert.Equal(t, uint64(10), sub.Revision())
+
+	// the oldest retained revision is 8, so resuming after 7 still works
+	sub2, backlog, resumed := b.Subscribe(7)
+	defer sub2.Close()
+	assert.True(t, resumed)
+	assert.Len(t, backlog, 3)
+
+	// revision from the future (e.g. after a service restart)
+	sub3, _, resumed := b.Subscribe(100)
+	defer sub3.Close()
+	assert.False(t, resumed)
+}
+
+func TestBroadcaster_SlowSubscriberIsDropped(t *testing.T) {
+	ctx := context.Background()
+	b := NewBroadcaster(10)
+
+	sub, _, _ := b.Subscribe(0)
+	for i := 0; i < subscriberBuffer+1; i++ {
+		assert.NoError(t, b.Notify(ctx, notifier.UpdateNotification("k", "v")))
+	}
+
+	count := 0
+	for range sub.Events() {
+		count++
+	}
+	assert.Equal(t, subscriberBuffer, count)
+	assert.True(t, sub.Overflowed())
+
+	// closing an already dropped subscription is a no-op
+	sub.Close()
+}
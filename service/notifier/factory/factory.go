@@ -7,6 +7,7 @@ import (
 	"github.com/dkrizic/feature/service/notifier"
 	"github.com/dkrizic/feature/service/notifier/log"
 	"github.com/dkrizic/feature/service/notifier/none"
+	"github.com/dkrizic/feature/service/notifier/redis"
 	"github.com/urfave/cli/v3"
 
 	"context"
@@ -26,6 +27,11 @@ func NewNotifier(ctx context.Context, cmd *cli.Command) (notifier.Notifier, erro
 	case constant.NotificationTypeLog:
 		slog.InfoContext(ctx, "Log notifier selected")
 		return log.NewLogNotifier(), nil
+	case constant.NotificationTypeRedisTopic:
+		endpoint := cmd.String(constant.RedisEndpoint)
+		topic := cmd.String(constant.RedisNotificationTopic)
+		slog.InfoContext(ctx, "Redis notifier selected", "endpoint", endpoint, "topic", topic)
+		return redis.NewRedisNotifier(ctx, endpoint, topic)
 	default:
 		slog.ErrorContext(ctx, "Invalid notifier type", "type", ntype)
 		return nil, errors.New("Invalid notifier type")
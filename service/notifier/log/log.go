@@ -20,6 +20,14 @@ func (n *LogNotifier) Notify(ctx context.Context, notification notifier.Notifica
 	ctx, span := otel.Tracer("notifier/log").Start(ctx, "Notify")
 	defer span.End()
 
-	slog.Info("Notification", "action_type", notification.Action.Type, "key", notification.Action.Key, "value", notification.Action.Value)
+	if notification.Action.Type == notifier.ActionBatch {
+		changes := make([]string, 0, len(notification.Batch))
+		for _, action := range notification.Batch {
+			changes = append(changes, string(action.Type)+" "+action.Key)
+		}
+		slog.InfoContext(ctx, "Notification", "action_type", notification.Action.Type, "changes", changes, "set", notification.Set, "environment", notification.Environment, "timestamp", notification.Timestamp, "actor", notification.Actor)
+		return nil
+	}
+	slog.InfoContext(ctx, "Notification", "action_type", notification.Action.Type, "key", notification.Action.Key, "value", notification.Action.Value, "redacted", notification.Action.Redacted, "set", notification.Set, "environment", notification.Environment, "timestamp", notification.Timestamp, "actor", notification.Actor)
 	return nil
 }
@@ -2,6 +2,7 @@ package notifier
 
 import (
 	"context"
+	"time"
 )
 
 type ActionType string
@@ -11,22 +12,69 @@ const (
 	ActionCreate  ActionType = "create"
 	ActionUpdate  ActionType = "update"
 	ActionDelete  ActionType = "delete"
+	// ActionBatch groups the changes of an atomic batch, they are in Notification.Batch
+	ActionBatch ActionType = "batch"
 )
 
+// ActorExternal is the actor of changes made to the storage by others than the service, e.g. an
+// edit of the ConfigMap with kubectl
+const ActorExternal = "external"
+
 type Action struct {
 	Type  ActionType
 	Key   string
 	Value *string
+	// Redacted is set if the value of a sensitive key was withheld
+	Redacted bool
+}
+
+// Redact withholds the value of the action
+func (a Action) Redact() Action {
+	if a.Value != nil {
+		a.Value = nil
+		a.Redacted = true
+	}
+	return a
 }
 
 type Notification struct {
-	Action Action
+	Action    Action
+	Timestamp time.Time
+	// Actor is the authenticated principal that caused the change, empty if unknown
+	Actor string
+	// Batch holds the changes of an ActionBatch notification in the order they were applied
+	Batch []Action
+	// Set is the flag set of the changed keys, empty for the default set
+	Set string
+	// Environment is the environment of the changed keys, empty if environments are not configured
+	Environment string
 }
 
 type Notifier interface {
 	Notify(ctx context.Context, notification Notification) error
 }
 
+// setNotifier stamps the flag set and environment on the notifications it forwards
+type setNotifier struct {
+	wrapped     Notifier
+	set         string
+	environment string
+}
+
+// WithSet returns a notifier that forwards the notifications of a flag set in an environment to n
+func WithSet(n Notifier, set, environment string) Notifier {
+	if set == "" && environment == "" {
+		return n
+	}
+	return &setNotifier{wrapped: n, set: set, environment: environment}
+}
+
+func (n *setNotifier) Notify(ctx context.Context, notification Notification) error {
+	notification.Set = n.set
+	notification.Environment = n.environment
+	return n.wrapped.Notify(ctx, notification)
+}
+
 func CreateNotifucation(key string, value string) Notification {
 	return Notification{
 		Action: Action{
@@ -34,6 +82,7 @@ func CreateNotifucation(key string, value string) Notification {
 			Key:   key,
 			Value: &value,
 		},
+		Timestamp: time.Now(),
 	}
 }
 
@@ -44,6 +93,7 @@ func UpdateNotification(key string, value string) Notification {
 			Key:   key,
 			Value: &value,
 		},
+		Timestamp: time.Now(),
 	}
 }
 
@@ -53,5 +103,24 @@ func DeleteNotification(key string) Notification {
 			Type: ActionDelete,
 			Key:  key,
 		},
+		Timestamp: time.Now(),
+	}
+}
+
+func BatchNotification(actions []Action) Notification {
+	return Notification{
+		Action: Action{
+			Type: ActionBatch,
+		},
+		Batch:     actions,
+		Timestamp: time.Now(),
+	}
+}
+
+// Actions returns the changes of a notification, the grouped changes for a batch
+func (n Notification) Actions() []Action {
+	if n.Action.Type == ActionBatch {
+		return n.Batch
 	}
+	return []Action{n.Action}
 }
@@ -0,0 +1,151 @@
+package redis
+
+// implements a Notifier that publishes notifications as JSON to a Redis pub/sub channel.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/dkrizic/feature/service/notifier"
+	goredis "github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// publishTimeout bounds a single publish including all retries
+	publishTimeout = 10 * time.Second
+	// reconnect/backoff settings, the client reconnects transparently on the next command
+	maxRetries      = 5
+	minRetryBackoff = 100 * time.Millisecond
+	maxRetryBackoff = 2 * time.Second
+)
+
+// Message is the JSON document published for every notification
+type Message struct {
+	Action notifier.ActionType `json:"action"`
+	Key    string              `json:"key"`
+	Value  *string             `json:"value,omitempty"`
+	// Redacted is set instead of Value for sensitive keys
+	Redacted bool `json:"redacted,omitempty"`
+	// Changes are the changes of a batch, Key and Value are empty then
+	Changes   []Change  `json:"changes,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Actor     string    `json:"actor,omitempty"`
+	// Set is the flag set of the change, omitted for the default set
+	Set string `json:"set,omitempty"`
+	// Environment is the environment of the change, omitted without environments
+	Environment string `json:"environment,omitempty"`
+	// TraceContext carries the W3C trace context (traceparent/tracestate) of the change
+	TraceContext map[string]string `json:"trace_context,omitempty"`
+}
+
+// Change is one change of a batch
+type Change struct {
+	Action   notifier.ActionType `json:"action"`
+	Key      string              `json:"key"`
+	Value    *string             `json:"value,omitempty"`
+	Redacted bool                `json:"redacted,omitempty"`
+}
+
+type RedisNotifier struct {
+	client  *goredis.Client
+	channel string
+}
+
+// NewRedisNotifier creates a notifier publishing to the given channel. The endpoint is either
+// host:port or a redis:// (rediss://) URL that may include credentials and a database.
+func NewRedisNotifier(ctx context.Context, endpoint string, channel string) (*RedisNotifier, error) {
+	opts, err := options(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	client := goredis.NewClient(opts)
+
+	// the service can start while Redis is unavailable, publishing reconnects later
+	if err := client.Ping(ctx).Err(); err != nil {
+		slog.WarnContext(ctx, "Redis not reachable yet, will retry on publish", "endpoint", opts.Addr, "error", err)
+	} else {
+		slog.InfoContext(ctx, "Connected to Redis", "endpoint", opts.Addr, "channel", channel)
+	}
+
+	return &RedisNotifier{
+		client:  client,
+		channel: channel,
+	}, nil
+}
+
+func options(endpoint string) (*goredis.Options, error) {
+	var opts *goredis.Options
+	if strings.HasPrefix(endpoint, "redis://") || strings.HasPrefix(endpoint, "rediss://") {
+		parsed, err := goredis.ParseURL(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis endpoint: %w", err)
+		}
+		opts = parsed
+	} else {
+		opts = &goredis.Options{Addr: endpoint}
+	}
+	opts.MaxRetries = maxRetries
+	opts.MinRetryBackoff = minRetryBackoff
+	opts.MaxRetryBackoff = maxRetryBackoff
+	return opts, nil
+}
+
+func (n *RedisNotifier) Notify(ctx context.Context, notification notifier.Notification) error {
+	ctx, span := otel.Tracer("notifier/redis").Start(ctx, "Notify",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("peer.service", "redis"),
+			attribute.String("messaging.system", "redis"),
+			attribute.String("messaging.destination.name", n.channel),
+		),
+	)
+	defer span.End()
+
+	message := Message{
+		Action:       notification.Action.Type,
+		Key:          notification.Action.Key,
+		Value:        notification.Action.Value,
+		Redacted:     notification.Action.Redacted,
+		Timestamp:    notification.Timestamp,
+		Actor:        notification.Actor,
+		Set:          notification.Set,
+		Environment:  notification.Environment,
+		TraceContext: map[string]string{},
+	}
+	for _, action := range notification.Batch {
+		message.Changes = append(message.Changes, Change{Action: action.Type, Key: action.Key, Value: action.Value, Redacted: action.Redacted})
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(message.TraceContext))
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+
+	receivers, err := n.client.Publish(ctx, n.channel, payload).Result()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to publish notification", "channel", n.channel, "key", message.Key, "error", err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to publish notification: %w", err)
+	}
+	span.SetAttributes(attribute.Int64("receivers", receivers))
+	slog.DebugContext(ctx, "Published notification", "channel", n.channel, "key", message.Key, "receivers", receivers)
+	return nil
+}
+
+func (n *RedisNotifier) Close() error {
+	return n.client.Close()
+}
@@ -0,0 +1,149 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/dkrizic/feature/service/notifier"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func subscribe(t *testing.T, addr string, channel string) <-chan *goredis.Message {
+	t.Helper()
+	client := goredis.NewClient(&goredis.Options{Addr: addr})
+	t.Cleanup(func() { client.Close() })
+	pubsub := client.Subscribe(context.Background(), channel)
+	t.Cleanup(func() { pubsub.Close() })
+	_, err := pubsub.Receive(context.Background())
+	require.NoError(t, err)
+	return pubsub.Channel()
+}
+
+func receive(t *testing.T, messages <-chan *goredis.Message) Message {
+	t.Helper()
+	select {
+	case msg := <-messages:
+		var message Message
+		require.NoError(t, json.Unmarshal([]byte(msg.Payload), &message))
+		return message
+	case <-time.After(2 * time.Second):
+		t.Fatal("no message received")
+		return Message{}
+	}
+}
+
+func TestRedisNotifier_PublishesJSON(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	messages := subscribe(t, server.Addr(), "features")
+
+	n, err := NewRedisNotifier(ctx, server.Addr(), "features")
+	require.NoError(t, err)
+	defer n.Close()
+
+	notification := notifier.UpdateNotification("color", "blue")
+	notification.Actor = "alice"
+	require.NoError(t, n.Notify(ctx, notification))
+
+	message := receive(t, messages)
+	assert.Equal(t, notifier.ActionUpdate, message.Action)
+	assert.Equal(t, "color", message.Key)
+	require.NotNil(t, message.Value)
+	assert.Equal(t, "blue", *message.Value)
+	assert.Equal(t, "alice", message.Actor)
+	assert.WithinDuration(t, notification.Timestamp, message.Timestamp, time.Millisecond)
+
+	require.NoError(t, n.Notify(ctx, notifier.DeleteNotification("color")))
+	message = receive(t, messages)
+	assert.Equal(t, notifier.ActionDelete, message.Action)
+	assert.Nil(t, message.Value)
+	assert.Empty(t, message.Set)
+
+	// notifications of a flag set carry its name and environment
+	require.NoError(t, notifier.WithSet(n, "shop", "prod").Notify(ctx, notifier.DeleteNotification("color")))
+	message = receive(t, messages)
+	assert.Equal(t, "shop", message.Set)
+	assert.Equal(t, "prod", message.Environment)
+}
+
+func TestRedisNotifier_PublishesBatch(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	messages := subscribe(t, server.Addr(), "features")
+
+	n, err := NewRedisNotifier(ctx, server.Addr(), "features")
+	require.NoError(t, err)
+	defer n.Close()
+
+	value := "blue"
+	require.NoError(t, n.Notify(ctx, notifier.BatchNotification([]notifier.Action{
+		{Type: notifier.ActionUpdate, Key: "color", Value: &value},
+		{Type: notifier.ActionDelete, Key: "size"},
+	})))
+
+	message := receive(t, messages)
+	assert.Equal(t, notifier.ActionBatch, message.Action)
+	assert.Empty(t, message.Key)
+	assert.Equal(t, []Change{
+		{Action: notifier.ActionUpdate, Key: "color", Value: &value},
+		{Action: notifier.ActionDelete, Key: "size"},
+	}, message.Changes)
+}
+
+func TestRedisNotifier_PropagatesTraceContext(t *testing.T) {
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(previous)
+
+	server := miniredis.RunT(t)
+	messages := subscribe(t, server.Addr(), "features")
+
+	n, err := NewRedisNotifier(context.Background(), "redis://"+server.Addr()+"/0", "features")
+	require.NoError(t, err)
+	defer n.Close()
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	require.NoError(t, n.Notify(ctx, notifier.CreateNotifucation("k", "v")))
+
+	message := receive(t, messages)
+	assert.Contains(t, message.TraceContext["traceparent"], traceID.String())
+}
+
+func TestRedisNotifier_ReconnectsAfterServerRestart(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	addr := server.Addr()
+
+	n, err := NewRedisNotifier(ctx, addr, "features")
+	require.NoError(t, err)
+	defer n.Close()
+	require.NoError(t, n.Notify(ctx, notifier.CreateNotifucation("k", "v1")))
+
+	server.Close()
+	assert.Error(t, n.Notify(ctx, notifier.UpdateNotification("k", "v2")))
+
+	require.NoError(t, server.Restart())
+	messages := subscribe(t, addr, "features")
+	require.NoError(t, n.Notify(ctx, notifier.UpdateNotification("k", "v3")))
+	message := receive(t, messages)
+	assert.Equal(t, "v3", *message.Value)
+}
+
+func TestNewRedisNotifier_InvalidURL(t *testing.T) {
+	_, err := NewRedisNotifier(context.Background(), "redis://host:notaport/x", "features")
+	assert.Error(t, err)
+}
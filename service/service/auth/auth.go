@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"log/slog"
 	"strings"
 
@@ -14,8 +15,42 @@ import (
 
 // protectedServicePaths defines the gRPC service paths that require authentication
 var protectedServicePaths = []string{
-	"/feature.Feature/",
-	"/workload.Workload/",
+	"/feature.v1.Feature/",
+	"/workload.v1.Workload/",
+}
+
+// principalKey is the context key for the authenticated principal
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the authenticated principal
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the authenticated principal, or an empty string if there is none
+func PrincipalFromContext(ctx context.Context) string {
+	principal, _ := ctx.Value(principalKey{}).(string)
+	return principal
+}
+
+// deniedKey is the context key for the principal of a call the authorization denies
+type deniedKey struct{}
+
+// WithDeniedPrincipal returns a context in which the auth interceptor records the principal of a
+// call it authenticates but does not authorize, and a function returning it, empty if there is none
+func WithDeniedPrincipal(ctx context.Context) (context.Context, func() string) {
+	principal := new(string)
+	return context.WithValue(ctx, deniedKey{}, principal), func() string { return *principal }
+}
+
+// authenticatedStream overrides the context of a server stream with one carrying the principal
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
 }
 
 // requiresAuthentication checks if a method requires authentication
@@ -28,57 +63,97 @@ func requiresAuthentication(fullMethod string) bool {
 	return false
 }
 
-// validateCredentials extracts and validates credentials from the context metadata
-func validateCredentials(ctx context.Context, fullMethod, username, password string) error {
+// validateCredentials extracts credentials from the context metadata, authenticates them
+// against the users and returns the authenticated principal
+func validateCredentials(ctx context.Context, fullMethod string, users *Users) (string, error) {
 	// Extract metadata from context
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
 		slog.WarnContext(ctx, "Missing metadata in request", "method", fullMethod)
-		return status.Error(codes.Unauthenticated, "missing metadata")
+		return "", status.Error(codes.Unauthenticated, "missing metadata")
 	}
 
 	// Check for authorization header
 	authHeaders := md.Get("authorization")
 	if len(authHeaders) == 0 {
 		slog.WarnContext(ctx, "Missing authorization header", "method", fullMethod)
-		return status.Error(codes.Unauthenticated, "missing authorization header")
+		return "", status.Error(codes.Unauthenticated, "missing authorization header")
 	}
 
 	// Parse Basic Auth header
 	auth := authHeaders[0]
 	if !strings.HasPrefix(auth, "Basic ") {
 		slog.WarnContext(ctx, "Invalid authorization header format", "method", fullMethod)
-		return status.Error(codes.Unauthenticated, "invalid authorization header")
+		return "", status.Error(codes.Unauthenticated, "invalid authorization header")
 	}
 
 	// Decode base64 credentials
 	payload, err := base64.StdEncoding.DecodeString(auth[6:])
 	if err != nil {
 		slog.WarnContext(ctx, "Failed to decode authorization header", "method", fullMethod, "error", err)
-		return status.Error(codes.Unauthenticated, "invalid authorization header")
+		return "", status.Error(codes.Unauthenticated, "invalid authorization header")
 	}
 
 	// Split username:password
 	pair := strings.SplitN(string(payload), ":", 2)
 	if len(pair) != 2 {
 		slog.WarnContext(ctx, "Invalid credentials format", "method", fullMethod)
-		return status.Error(codes.Unauthenticated, "invalid credentials format")
+		return "", status.Error(codes.Unauthenticated, "invalid credentials format")
 	}
 
 	// Validate credentials
-	if pair[0] != username || pair[1] != password {
+	if err := users.Authenticate(ctx, pair[0], pair[1]); err != nil {
+		if errors.Is(err, ErrLockedOut) {
+			slog.WarnContext(ctx, "User is locked out", "method", fullMethod, "username", pair[0])
+			return "", status.Error(codes.ResourceExhausted, err.Error())
+		}
 		slog.WarnContext(ctx, "Invalid credentials", "method", fullMethod, "username", pair[0])
-		return status.Error(codes.Unauthenticated, "invalid credentials")
+		return "", status.Error(codes.Unauthenticated, "invalid credentials")
 	}
 
 	// Credentials are valid
 	slog.DebugContext(ctx, "Authentication successful", "method", fullMethod, "username", pair[0])
-	return nil
+	return pair[0], nil
+}
+
+// bearerToken returns the secret of an authorization: Bearer header
+func bearerToken(ctx context.Context) (string, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	authHeaders := md.Get("authorization")
+	if len(authHeaders) == 0 || !strings.HasPrefix(authHeaders[0], "Bearer ") {
+		return "", false
+	}
+	return strings.TrimPrefix(authHeaders[0], "Bearer "), true
+}
+
+// authenticate authenticates the caller with an API token or the credentials of a user and
+// returns the principal and its permissions. The permissions of a token come from its scopes,
+// those of a user from the policy of the authorizer.
+func authenticate(ctx context.Context, fullMethod string, users *Users, authorizer *Authorizer, tokens *Tokens) (string, *Permissions, error) {
+	if secret, ok := bearerToken(ctx); ok {
+		token, err := tokens.Authenticate(ctx, secret)
+		if err != nil {
+			if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrTokenExpired) {
+				slog.WarnContext(ctx, "Invalid token", "method", fullMethod, "error", err)
+				return "", nil, status.Error(codes.Unauthenticated, err.Error())
+			}
+			slog.ErrorContext(ctx, "Failed to check token", "method", fullMethod, "error", err)
+			return "", nil, status.Error(codes.Internal, "failed to check token")
+		}
+		slog.DebugContext(ctx, "Authentication successful", "method", fullMethod, "token", token.Name)
+		return token.Principal(), token.Permissions(), nil
+	}
+	principal, err := validateCredentials(ctx, fullMethod, users)
+	if err != nil {
+		return "", nil, err
+	}
+	return principal, authorizer.Permissions(principal), nil
 }
 
 // SelectiveInterceptor creates a gRPC unary server interceptor that only applies
-// authentication to specific services (Feature and Workload)
-func SelectiveInterceptor(enabled bool, username, password string) grpc.UnaryServerInterceptor {
+// authentication to specific services (Feature and Workload) and checks the method and
+// keys of the request against the policy of the authorizer or the scopes of the API token
+func SelectiveInterceptor(enabled bool, users *Users, authorizer *Authorizer, tokens *Tokens) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
 		req interface{},
@@ -96,19 +171,30 @@ func SelectiveInterceptor(enabled bool, username, password string) grpc.UnarySer
 			return handler(ctx, req)
 		}
 
-		// Validate credentials
-		if err := validateCredentials(ctx, info.FullMethod, username, password); err != nil {
+		// Validate the token or credentials
+		principal, permissions, err := authenticate(ctx, info.FullMethod, users, authorizer, tokens)
+		if err != nil {
+			return nil, err
+		}
+
+		// Check the roles or scopes of the principal
+		if err := authorize(ctx, info.FullMethod, req, permissions); err != nil {
+			if denied, ok := ctx.Value(deniedKey{}).(*string); ok {
+				*denied = principal
+			}
 			return nil, err
 		}
 
 		// Credentials are valid, proceed with the request
-		return handler(ctx, req)
+		return handler(WithPermissions(WithPrincipal(ctx, principal), permissions), req)
 	}
 }
 
 // SelectiveStreamInterceptor creates a gRPC stream server interceptor that only applies
-// authentication to specific services (Feature and Workload)
-func SelectiveStreamInterceptor(enabled bool, username, password string) grpc.StreamServerInterceptor {
+// authentication to specific services (Feature and Workload) and checks the method against
+// the policy of the authorizer or the scopes of the API token. The streaming methods only
+// read, so there are no keys to check.
+func SelectiveStreamInterceptor(enabled bool, users *Users, authorizer *Authorizer, tokens *Tokens) grpc.StreamServerInterceptor {
 	return func(
 		srv interface{},
 		ss grpc.ServerStream,
@@ -126,12 +212,21 @@ func SelectiveStreamInterceptor(enabled bool, username, password string) grpc.St
 			return handler(srv, ss)
 		}
 
-		// Validate credentials
-		if err := validateCredentials(ss.Context(), info.FullMethod, username, password); err != nil {
+		// Validate the token or credentials
+		principal, permissions, err := authenticate(ss.Context(), info.FullMethod, users, authorizer, tokens)
+		if err != nil {
+			return err
+		}
+
+		// Check the roles or scopes of the principal
+		if err := authorize(ss.Context(), info.FullMethod, nil, permissions); err != nil {
 			return err
 		}
 
 		// Credentials are valid, proceed with the request
-		return handler(srv, ss)
+		return handler(srv, &authenticatedStream{
+			ServerStream: ss,
+			ctx:          WithPermissions(WithPrincipal(ss.Context(), principal), permissions),
+		})
 	}
 }
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// pruneAbove is the number of tracked users above which expired entries are dropped
+const pruneAbove = 1000
+
+// failures are the recent failed attempts of a user
+type failures struct {
+	count       int
+	last        time.Time
+	lockedUntil time.Time
+}
+
+// Lockout locks a user out for a while after repeated failed attempts. Failures are forgotten
+// once the user was not locked out and had no failure for the lockout duration. A nil Lockout
+// never locks anyone out.
+type Lockout struct {
+	maxFailures int
+	duration    time.Duration
+	now         func() time.Time
+
+	mu       sync.Mutex
+	failures map[string]*failures
+}
+
+// NewLockout locks a user out for the duration after maxFailures failed attempts in a row, a
+// maxFailures of 0 disables the lockout
+func NewLockout(maxFailures int, duration time.Duration) *Lockout {
+	if maxFailures <= 0 {
+		return nil
+	}
+	return &Lockout{
+		maxFailures: maxFailures,
+		duration:    duration,
+		now:         time.Now,
+		failures:    make(map[string]*failures),
+	}
+}
+
+// Locked reports whether the user is locked out
+func (l *Lockout) Locked(username string) bool {
+	if l == nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, ok := l.failures[username]
+	return ok && l.now().Before(f.lockedUntil)
+}
+
+// Fail records a failed attempt and reports whether the user is now locked out
+func (l *Lockout) Fail(username string) bool {
+	if l == nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if len(l.failures) > pruneAbove {
+		for name, f := range l.failures {
+			if l.expired(f, now) {
+				delete(l.failures, name)
+			}
+		}
+	}
+	f, ok := l.failures[username]
+	if !ok || l.expired(f, now) {
+		f = &failures{}
+		l.failures[username] = f
+	}
+	f.count++
+	f.last = now
+	if f.count >= l.maxFailures {
+		f.count = 0
+		f.lockedUntil = now.Add(l.duration)
+		return true
+	}
+	return false
+}
+
+// Reset forgets the failed attempts of the user after a successful one
+func (l *Lockout) Reset(username string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.failures, username)
+}
+
+func (l *Lockout) expired(f *failures, now time.Time) bool {
+	return !now.Before(f.lockedUntil) && now.Sub(f.last) >= l.duration
+}
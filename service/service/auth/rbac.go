@@ -0,0 +1,366 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	featurev1 "github.com/dkrizic/feature/service/service/feature/v1"
+	workloadv1 "github.com/dkrizic/feature/service/service/workload/v1"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sigs.k8s.io/yaml"
+)
+
+// Built-in roles, a policy may change their keys and methods
+const (
+	RoleViewer    = "viewer"
+	RoleEditor    = "editor"
+	RoleAdmin     = "admin"
+	RoleRestarter = "restarter"
+)
+
+// anyUser binds roles to every user the policy does not list
+const anyUser = "*"
+
+// readMethods only read flags
+var readMethods = []string{"GetAll", "Get", "Watch", "Evaluate", "GetRules", "History", "ListSets", "ListEnvironments", "ListSchedules", "ListStale", "GetPermissions", "Info"}
+
+// keyedMethods change flags, the key patterns of a role apply to them
+var keyedMethods = []string{"PreSet", "Set", "Delete", "SetRules", "SetRollout", "SetPrerequisites", "Rollback", "Batch", "Promote", "ScheduleSet", "CancelSchedule"}
+
+// builtinRoles are the methods of the built-in roles, * stands for all methods
+var builtinRoles = map[string][]string{
+	RoleViewer:    readMethods,
+	RoleEditor:    append(slices.Clone(readMethods), keyedMethods...),
+	RoleRestarter: {"Info", "GetPermissions", "Restart", "RestartWorkload"},
+	RoleAdmin:     {"*"},
+}
+
+// methods are the names of all RPCs of the Feature and Workload services
+func methods() []string {
+	var names []string
+	for _, desc := range []grpc.ServiceDesc{featurev1.Feature_ServiceDesc, workloadv1.Workload_ServiceDesc} {
+		for _, method := range desc.Methods {
+			names = append(names, method.MethodName)
+		}
+		for _, stream := range desc.Streams {
+			names = append(names, stream.StreamName)
+		}
+	}
+	return names
+}
+
+// methodName returns the name of the RPC of a full method, e.g. Set for /feature.v1.Feature/Set
+func methodName(fullMethod string) string {
+	return fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+}
+
+// Role grants methods, the methods that change flags only on the keys matching the patterns
+type Role struct {
+	// Methods are the names of the RPCs, e.g. Set or Restart, * stands for all
+	Methods []string `json:"methods,omitempty"`
+	// Keys are path.Match patterns of the keys, e.g. CHECKOUT_*, empty means all
+	Keys []string `json:"keys,omitempty"`
+}
+
+// Policy maps users to roles and roles to methods and keys
+type Policy struct {
+	// Roles defines roles or changes the built-in ones. Built-in roles keep their methods if none are
+	// given.
+	Roles map[string]Role `json:"roles,omitempty"`
+	// Users maps usernames to their roles, * maps all users that are not listed
+	Users map[string][]string `json:"users,omitempty"`
+}
+
+// ParsePolicy parses a policy in YAML or JSON and checks its methods, patterns and roles
+func ParsePolicy(data []byte) (*Policy, error) {
+	var policy Policy
+	if err := yaml.UnmarshalStrict(data, &policy); err != nil {
+		return nil, err
+	}
+	known := methods()
+	roles := make(map[string]Role, len(builtinRoles)+len(policy.Roles))
+	for name, methods := range builtinRoles {
+		roles[name] = Role{Methods: methods}
+	}
+	for name, role := range policy.Roles {
+		if len(role.Methods) == 0 {
+			builtin, ok := builtinRoles[name]
+			if !ok {
+				return nil, fmt.Errorf("role %q has no methods", name)
+			}
+			role.Methods = builtin
+		}
+		for _, method := range role.Methods {
+			if method != "*" && !slices.Contains(known, method) {
+				return nil, fmt.Errorf("role %q: unknown method %q", name, method)
+			}
+		}
+		for _, pattern := range role.Keys {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("role %q: invalid key pattern %q", name, pattern)
+			}
+		}
+		roles[name] = role
+	}
+	for user, names := range policy.Users {
+		for _, name := range names {
+			if _, ok := roles[name]; !ok {
+				return nil, fmt.Errorf("user %q: unknown role %q", user, name)
+			}
+		}
+	}
+	policy.Roles = roles
+	return &policy, nil
+}
+
+// grant are the keys a method may change, all if all is set
+type grant struct {
+	all      bool
+	patterns []string
+}
+
+// Permissions are the methods a user may call. Nil permissions allow everything, they are used
+// if there is no policy or authentication is disabled.
+type Permissions struct {
+	Principal string
+	Roles     []string
+	grants    map[string]*grant
+	// policy is set for the permissions of a user from the policy, not for those of a token
+	policy bool
+}
+
+// Permissions returns the permissions of the user, without roles it may call nothing
+func (p *Policy) Permissions(principal string) *Permissions {
+	roles, ok := p.Users[principal]
+	if !ok {
+		roles = p.Users[anyUser]
+	}
+	perms := &Permissions{Principal: principal, Roles: roles, grants: map[string]*grant{}, policy: true}
+	all := methods()
+	for _, name := range roles {
+		role := p.Roles[name]
+		granted := role.Methods
+		if slices.Contains(granted, "*") {
+			granted = all
+		}
+		for _, method := range granted {
+			g, ok := perms.grants[method]
+			if !ok {
+				g = &grant{}
+				perms.grants[method] = g
+			}
+			if len(role.Keys) == 0 || !slices.Contains(keyedMethods, method) {
+				g.all = true
+			}
+			g.patterns = append(g.patterns, role.Keys...)
+		}
+	}
+	return perms
+}
+
+// FromPolicy reports whether the permissions come from the policy. The key patterns of its roles
+// replace the editable keys of the flag sets then.
+func (p *Permissions) FromPolicy() bool {
+	return p != nil && p.policy
+}
+
+// Allows reports whether the method may be called on all of the keys
+func (p *Permissions) Allows(method string, keys ...string) bool {
+	if p == nil {
+		return true
+	}
+	g, ok := p.grants[method]
+	if !ok {
+		return false
+	}
+	if g.all {
+		return true
+	}
+	for _, key := range keys {
+		if !slices.ContainsFunc(g.patterns, func(pattern string) bool {
+			matched, _ := path.Match(pattern, key)
+			return matched
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// ToProto converts the permissions for GetPermissions
+func (p *Permissions) ToProto() *featurev1.Permissions {
+	if p == nil {
+		return &featurev1.Permissions{Unrestricted: true}
+	}
+	result := &featurev1.Permissions{Principal: p.Principal, Roles: p.Roles}
+	for method, g := range p.grants {
+		grant := &featurev1.Grant{Method: method}
+		if !g.all {
+			grant.Keys = g.patterns
+		}
+		result.Grants = append(result.Grants, grant)
+	}
+	sort.Slice(result.Grants, func(i, j int) bool {
+		return result.Grants[i].Method < result.Grants[j].Method
+	})
+	return result
+}
+
+// permissionsKey is the context key for the permissions of the caller
+type permissionsKey struct{}
+
+// WithPermissions returns a context carrying the permissions of the caller
+func WithPermissions(ctx context.Context, permissions *Permissions) context.Context {
+	return context.WithValue(ctx, permissionsKey{}, permissions)
+}
+
+// PermissionsFromContext returns the permissions of the caller, nil allows everything
+func PermissionsFromContext(ctx context.Context) *Permissions {
+	permissions, _ := ctx.Value(permissionsKey{}).(*Permissions)
+	return permissions
+}
+
+// Authorizer checks calls against a policy read from a source and reloaded while the service runs
+type Authorizer struct {
+	source Source
+
+	mu      sync.RWMutex
+	policy  *Policy
+	version string
+}
+
+// NewAuthorizer returns an authorizer for the policy of the source. Without a source or before the
+// first Reload everything is allowed.
+func NewAuthorizer(source Source) *Authorizer {
+	return &Authorizer{source: source}
+}
+
+// Reload reads the source and replaces the policy if it changed. The policy is kept if the source
+// cannot be read or is invalid.
+func (a *Authorizer) Reload(ctx context.Context) error {
+	if a == nil || a.source == nil {
+		return nil
+	}
+	ctx, span := otel.Tracer("service/auth").Start(ctx, "Authorizer.Reload")
+	defer span.End()
+
+	data, version, err := a.source.Load(ctx)
+	if err != nil {
+		return err
+	}
+	a.mu.RLock()
+	unchanged := version == a.version
+	a.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+	policy, err := ParsePolicy(data)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.policy = policy
+	a.version = version
+	a.mu.Unlock()
+	slog.InfoContext(ctx, "Authorization policy loaded", "roles", len(policy.Roles), "users", len(policy.Users), "version", version)
+	return nil
+}
+
+// Run reloads the policy every interval until the context is done
+func (a *Authorizer) Run(ctx context.Context, interval time.Duration) {
+	if a == nil || a.source == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.Reload(ctx); err != nil {
+				slog.ErrorContext(ctx, "Failed to reload the authorization policy, keeping the previous one", "error", err)
+			}
+		}
+	}
+}
+
+// Permissions returns the permissions of the principal, nil without a policy
+func (a *Authorizer) Permissions(principal string) *Permissions {
+	if a == nil {
+		return nil
+	}
+	a.mu.RLock()
+	policy := a.policy
+	a.mu.RUnlock()
+	if policy == nil {
+		return nil
+	}
+	return policy.Permissions(principal)
+}
+
+// authorize checks the method and the keys of the request against the permissions. GetPermissions
+// is always allowed. A scheduled change with a restart needs the permission to restart as well,
+// and a token may only be created with scopes whose methods are allowed on all keys.
+func authorize(ctx context.Context, fullMethod string, req any, permissions *Permissions) error {
+	method := methodName(fullMethod)
+	if permissions == nil || method == "GetPermissions" {
+		return nil
+	}
+	keys := requestKeys(req)
+	if !permissions.Allows(method, keys...) {
+		slog.WarnContext(ctx, "Permission denied", "method", fullMethod, "principal", permissions.Principal, "roles", permissions.Roles, "keys", keys)
+		if len(keys) > 0 && permissions.Allows(method) {
+			return status.Errorf(codes.PermissionDenied, "%s is not allowed on '%s'", method, strings.Join(keys, "', '"))
+		}
+		return status.Errorf(codes.PermissionDenied, "%s is not allowed", method)
+	}
+	if r, ok := req.(*featurev1.ScheduleSetRequest); ok && r.Restart && !permissions.Allows("Restart") {
+		return status.Error(codes.PermissionDenied, "Restart is not allowed")
+	}
+	if r, ok := req.(*featurev1.CreateTokenRequest); ok {
+		for _, scope := range r.Scopes {
+			name := ScopeFromProto(scope)
+			for _, m := range scopeMethods[name] {
+				if g := permissions.grants[m]; g == nil || !g.all {
+					return status.Errorf(codes.PermissionDenied, "scope %s is not allowed, %s is not allowed on all keys", name, m)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// requestKeys returns the flag keys a request changes, like the audit records them
+func requestKeys(req any) []string {
+	switch r := req.(type) {
+	case *featurev1.Key:
+		return []string{r.GetName()}
+	case *featurev1.BatchRequest:
+		keys := make([]string, 0, len(r.Operations))
+		for _, op := range r.Operations {
+			if op.GetDelete() != nil {
+				keys = append(keys, op.GetDelete().GetName())
+			} else {
+				keys = append(keys, op.GetSet().GetKey())
+			}
+		}
+		return keys
+	case interface{ GetKey() string }:
+		if key := r.GetKey(); key != "" {
+			return []string{key}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	featurev1 "github.com/dkrizic/feature/service/service/feature/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const testPolicy = `
+roles:
+  editor:
+    keys: ["CHECKOUT_*", "THEME"]
+  release:
+    methods: [Set, Restart]
+users:
+  alice: [admin]
+  bob: [editor, restarter]
+  ci: [release]
+  "*": [viewer]
+`
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy([]byte(testPolicy))
+	require.NoError(t, err)
+	assert.Contains(t, policy.Roles[RoleEditor].Methods, "Delete", "built-in methods are kept")
+	assert.Equal(t, []string{"CHECKOUT_*", "THEME"}, policy.Roles[RoleEditor].Keys)
+
+	invalid := map[string]string{
+		"unknown method":  "roles: {ops: {methods: [Drop]}}",
+		"no methods":      "roles: {ops: {keys: [A]}}",
+		"bad pattern":     "roles: {editor: {keys: ['[']}}",
+		"unknown role":    "users: {alice: [owner]}",
+		"unknown field":   "groups: {}",
+		"not a policy":    "- admin",
+		"methods no list": "roles: {ops: {methods: Set}}",
+	}
+	for name, data := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParsePolicy([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPolicy_Permissions(t *testing.T) {
+	policy, err := ParsePolicy([]byte(testPolicy))
+	require.NoError(t, err)
+
+	admin := policy.Permissions("alice")
+	assert.True(t, admin.Allows("DeleteSet"))
+	assert.True(t, admin.Allows("Delete", "ANYTHING"))
+
+	editor := policy.Permissions("bob")
+	assert.True(t, editor.Allows("Set", "CHECKOUT_V2"))
+	assert.True(t, editor.Allows("Batch", "CHECKOUT_V2", "THEME"))
+	assert.False(t, editor.Allows("Batch", "CHECKOUT_V2", "COLOR"))
+	assert.False(t, editor.Allows("Delete", "COLOR"))
+	assert.True(t, editor.Allows("Get", "COLOR"), "key patterns do not restrict reads")
+	assert.True(t, editor.Allows("Restart"))
+	assert.False(t, editor.Allows("CreateSet"))
+
+	release := policy.Permissions("ci")
+	assert.True(t, release.Allows("Set", "COLOR"))
+	assert.False(t, release.Allows("Get", "COLOR"))
+
+	viewer := policy.Permissions("mallory")
+	assert.Equal(t, []string{RoleViewer}, viewer.Roles)
+	assert.True(t, viewer.Allows("GetAll"))
+	assert.False(t, viewer.Allows("Set", "COLOR"))
+	assert.False(t, viewer.Allows("Restart"))
+
+	onlyEditor := &Policy{Roles: policy.Roles, Users: map[string][]string{"dave": {RoleEditor}}}
+	err = authorize(context.Background(), "/feature.v1.Feature/ScheduleSet", &featurev1.ScheduleSetRequest{Key: "THEME", Restart: true}, onlyEditor.Permissions("dave"))
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "a scheduled restart needs Restart")
+
+	var none *Permissions
+	assert.True(t, none.Allows("DeleteSet"), "no policy allows everything")
+	assert.True(t, none.ToProto().Unrestricted)
+
+	grants := editor.ToProto().Grants
+	for _, grant := range grants {
+		switch grant.Method {
+		case "Set":
+			assert.Equal(t, []string{"CHECKOUT_*", "THEME"}, grant.Keys)
+		case "Restart", "GetAll":
+			assert.Empty(t, grant.Keys)
+		}
+	}
+}
+
+func TestSelectiveInterceptor_Authorization(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testPolicy), 0o600))
+	authorizer := NewAuthorizer(NewFileSource(path))
+	require.NoError(t, authorizer.Reload(context.Background()))
+	users, err := NewUsers("bob", "secret", nil, nil)
+	require.NoError(t, err)
+	interceptor := SelectiveInterceptor(true, users, authorizer, nil)
+
+	call := func(method string, req any) (any, error) {
+		header := "Basic " + base64.StdEncoding.EncodeToString([]byte("bob:secret"))
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", header))
+		info := &grpc.UnaryServerInfo{FullMethod: "/feature.v1.Feature/" + method}
+		return interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+			return PermissionsFromContext(ctx), nil
+		})
+	}
+
+	resp, err := call("Set", &featurev1.KeyValue{Key: "CHECKOUT_V2", Value: "true"})
+	require.NoError(t, err)
+	assert.Equal(t, "bob", resp.(*Permissions).Principal)
+
+	_, err = call("Set", &featurev1.KeyValue{Key: "COLOR", Value: "red"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.ErrorContains(t, err, "Set is not allowed on 'COLOR'")
+
+	_, err = call("Delete", &featurev1.Key{Name: "COLOR"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// the principal of a denied call is reported for the audit
+	header := "Basic " + base64.StdEncoding.EncodeToString([]byte("bob:secret"))
+	ctx, denied := WithDeniedPrincipal(metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", header)))
+	_, err = interceptor(ctx, &featurev1.Key{Name: "COLOR"}, &grpc.UnaryServerInfo{FullMethod: "/feature.v1.Feature/Delete"}, nil)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, "bob", denied())
+
+	_, err = call("DeleteSet", &featurev1.FlagSet{Name: "team-a"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.ErrorContains(t, err, "DeleteSet is not allowed")
+
+	_, err = call("ScheduleSet", &featurev1.ScheduleSetRequest{Key: "THEME", Restart: true})
+	assert.NoError(t, err, "bob is a restarter as well")
+
+	_, err = call("GetPermissions", nil)
+	assert.NoError(t, err)
+}
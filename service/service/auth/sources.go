@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// FileSource reads the users from a file, e.g. a mounted Secret. Its version is the size and
+// modification time of the file, which change when the kubelet updates the mount.
+type FileSource struct {
+	path string
+}
+
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: path}
+}
+
+func (s *FileSource) Load(ctx context.Context) ([]byte, string, error) {
+	_, span := otel.Tracer("service/auth").Start(ctx, "FileSource.Load")
+	defer span.End()
+	span.SetAttributes(attribute.String("path", s.path))
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, fmt.Sprintf("%d-%d", info.Size(), info.ModTime().UnixNano()), nil
+}
+
+// secretGetter is the part of the Secret client the SecretSource needs, to allow testing
+type secretGetter interface {
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.Secret, error)
+}
+
+// SecretSource reads the users from a data entry of a Secret in the own namespace. Its version is
+// the resource version of the Secret.
+type SecretSource struct {
+	name string
+	key  string
+
+	// mu guards client, it is created on first use
+	mu     sync.Mutex
+	client secretGetter
+}
+
+func NewSecretSource(name, key string) *SecretSource {
+	return &SecretSource{name: name, key: key}
+}
+
+func (s *SecretSource) Load(ctx context.Context) ([]byte, string, error) {
+	ctx, span := otel.Tracer("service/auth").Start(ctx, "SecretSource.Load")
+	defer span.End()
+	span.SetAttributes(attribute.String("secret", s.name), attribute.String("key", s.key))
+
+	client, err := s.getClient()
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := client.Get(ctx, s.name, metav1.GetOptions{})
+	if err != nil {
+		return nil, "", err
+	}
+	data, ok := secret.Data[s.key]
+	if !ok {
+		return nil, "", fmt.Errorf("secret %s has no key %s", s.name, s.key)
+	}
+	return data, secret.ResourceVersion, nil
+}
+
+func (s *SecretSource) getClient() (secretGetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client != nil {
+		return s.client, nil
+	}
+
+	rc, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	namespace, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(rc)
+	if err != nil {
+		return nil, err
+	}
+	s.client = clientset.CoreV1().Secrets(strings.TrimSpace(string(namespace)))
+	return s.client, nil
+}
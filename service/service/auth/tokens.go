@@ -0,0 +1,286 @@
+package auth
+
+// API tokens let machine clients call the service with authorization: Bearer <secret> instead of
+// the credentials of a user. A token is kept as JSON in a storage partition keyed by its ID, with
+// the SHA-256 hash of its secret. The secret carries the ID, so a call needs a single lookup.
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	featurev1 "github.com/dkrizic/feature/service/service/feature/v1"
+	"github.com/dkrizic/feature/service/service/persistence"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Scope is what a token may do
+type Scope string
+
+const (
+	// ScopeRead allows the methods that only read flags
+	ScopeRead Scope = "read"
+	// ScopeWrite allows the methods that read and change flags
+	ScopeWrite Scope = "write"
+	// ScopeRestart allows restarting workloads
+	ScopeRestart Scope = "restart"
+)
+
+// scopeMethods are the methods of the scopes, tokens cannot manage sets, environments or tokens
+var scopeMethods = map[Scope][]string{
+	ScopeRead:    readMethods,
+	ScopeWrite:   append(slices.Clone(readMethods), keyedMethods...),
+	ScopeRestart: {"Info", "GetPermissions", "Restart", "RestartWorkload"},
+}
+
+const (
+	// TokenPrincipalPrefix prefixes the name of a token to form the principal of its calls, so
+	// tokens cannot be mistaken for users
+	TokenPrincipalPrefix = "token:"
+	// tokenPrefix marks the secrets of tokens, e.g. ft_4f2a9c0e1b7d3a55_<random>
+	tokenPrefix = "ft_"
+)
+
+var (
+	ErrInvalidToken  = errors.New("invalid token")
+	ErrTokenExpired  = errors.New("token expired")
+	ErrTokenNotFound = errors.New("token not found")
+	ErrTokenExists   = errors.New("token already exists")
+	ErrTokenInvalid  = errors.New("invalid token request")
+)
+
+// validTokenName keeps the principals of tokens usable in comma-separated lists like --reveal
+var validTokenName = regexp.MustCompile(`^[A-Za-z0-9][-_.A-Za-z0-9]{0,62}$`)
+
+// Token is an API token, its secret is only known to its clients
+type Token struct {
+	ID        string    `json:"-"`
+	Name      string    `json:"name"`
+	Hash      string    `json:"hash"`
+	Scopes    []Scope   `json:"scopes"`
+	ExpiresAt time.Time `json:"expiresAt,omitzero"`
+	CreatedBy string    `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Principal is the principal of the calls made with the token
+func (t Token) Principal() string {
+	return TokenPrincipalPrefix + t.Name
+}
+
+// Expired reports whether the token has expired at the time
+func (t Token) Expired(now time.Time) bool {
+	return !t.ExpiresAt.IsZero() && !now.Before(t.ExpiresAt)
+}
+
+// Permissions returns the methods the scopes of the token allow on all keys
+func (t Token) Permissions() *Permissions {
+	permissions := &Permissions{Principal: t.Principal(), grants: map[string]*grant{}}
+	for _, scope := range t.Scopes {
+		for _, method := range scopeMethods[scope] {
+			permissions.grants[method] = &grant{all: true}
+		}
+	}
+	return permissions
+}
+
+// ParseScope parses the name of a scope
+func ParseScope(s string) (Scope, error) {
+	scope := Scope(s)
+	if _, ok := scopeMethods[scope]; !ok {
+		return "", fmt.Errorf("%w: unknown scope %q", ErrTokenInvalid, s)
+	}
+	return scope, nil
+}
+
+// scopeProtos maps the scopes to those of the API
+var scopeProtos = map[Scope]featurev1.TokenScope{
+	ScopeRead:    featurev1.TokenScope_TOKEN_SCOPE_READ,
+	ScopeWrite:   featurev1.TokenScope_TOKEN_SCOPE_WRITE,
+	ScopeRestart: featurev1.TokenScope_TOKEN_SCOPE_RESTART,
+}
+
+// ScopeFromProto converts a scope of the API, an unspecified scope converts to ""
+func ScopeFromProto(scope featurev1.TokenScope) Scope {
+	for name, value := range scopeProtos {
+		if value == scope {
+			return name
+		}
+	}
+	return ""
+}
+
+// ToProto converts the token for ListTokens, the hash is left out
+func (t Token) ToProto() *featurev1.Token {
+	result := &featurev1.Token{
+		Id:        t.ID,
+		Name:      t.Name,
+		CreatedBy: t.CreatedBy,
+		CreatedAt: timestamppb.New(t.CreatedAt),
+	}
+	for _, scope := range t.Scopes {
+		result.Scopes = append(result.Scopes, scopeProtos[scope])
+	}
+	if !t.ExpiresAt.IsZero() {
+		result.ExpiresAt = timestamppb.New(t.ExpiresAt)
+	}
+	return result
+}
+
+// Tokens keeps the API tokens in a storage partition
+type Tokens struct {
+	persistence persistence.Persistence
+	now         func() time.Time
+}
+
+func NewTokens(p persistence.Persistence) *Tokens {
+	return &Tokens{persistence: p, now: time.Now}
+}
+
+// Create stores a new token and returns it with its ID and its secret, which is not stored. The
+// name must be unique, there must be at least one scope and the expiry must be in the future.
+func (t *Tokens) Create(ctx context.Context, token Token) (Token, string, error) {
+	ctx, span := otel.Tracer("service/auth").Start(ctx, "Tokens.Create")
+	defer span.End()
+
+	if !validTokenName.MatchString(token.Name) {
+		return Token{}, "", fmt.Errorf("%w: name %q must be letters, digits, '-', '_' or '.'", ErrTokenInvalid, token.Name)
+	}
+	if len(token.Scopes) == 0 {
+		return Token{}, "", fmt.Errorf("%w: at least one scope is required", ErrTokenInvalid)
+	}
+	for _, scope := range token.Scopes {
+		if _, err := ParseScope(string(scope)); err != nil {
+			return Token{}, "", err
+		}
+	}
+	if !token.ExpiresAt.IsZero() && !token.ExpiresAt.After(t.now()) {
+		return Token{}, "", fmt.Errorf("%w: expiry must be in the future", ErrTokenInvalid)
+	}
+	existing, err := t.List(ctx)
+	if err != nil {
+		return Token{}, "", err
+	}
+	if slices.ContainsFunc(existing, func(other Token) bool { return other.Name == token.Name }) {
+		return Token{}, "", fmt.Errorf("%w: %s", ErrTokenExists, token.Name)
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return Token{}, "", err
+	}
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return Token{}, "", err
+	}
+	token.ID = hex.EncodeToString(id)
+	secret := tokenPrefix + token.ID + "_" + base64.RawURLEncoding.EncodeToString(random)
+	token.Hash = hashToken(secret)
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = t.now().UTC()
+	}
+	data, err := json.Marshal(token)
+	if err != nil {
+		return Token{}, "", err
+	}
+	if err := t.persistence.Set(ctx, persistence.KeyValue{Key: token.ID, Value: string(data)}); err != nil {
+		return Token{}, "", err
+	}
+	return token, secret, nil
+}
+
+// List returns all tokens including the expired ones, ordered by name
+func (t *Tokens) List(ctx context.Context) ([]Token, error) {
+	kvs, err := t.persistence.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]Token, 0, len(kvs))
+	for _, kv := range kvs {
+		token, err := decodeToken(kv)
+		if err != nil {
+			slog.WarnContext(ctx, "Ignoring invalid token", "id", kv.Key, "error", err)
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	slices.SortFunc(tokens, func(a, b Token) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return tokens, nil
+}
+
+// Revoke deletes the token with the ID, it returns ErrTokenNotFound if there is none
+func (t *Tokens) Revoke(ctx context.Context, id string) error {
+	if _, err := t.get(ctx, id); err != nil {
+		return err
+	}
+	return t.persistence.Delete(ctx, id)
+}
+
+// Authenticate returns the token of a secret. It fails with ErrInvalidToken for unknown or revoked
+// tokens and with ErrTokenExpired for expired ones.
+func (t *Tokens) Authenticate(ctx context.Context, secret string) (Token, error) {
+	if t == nil {
+		return Token{}, ErrInvalidToken
+	}
+	ctx, span := otel.Tracer("service/auth").Start(ctx, "Tokens.Authenticate")
+	defer span.End()
+
+	id, _, ok := strings.Cut(strings.TrimPrefix(secret, tokenPrefix), "_")
+	if !strings.HasPrefix(secret, tokenPrefix) || !ok {
+		return Token{}, ErrInvalidToken
+	}
+	token, err := t.get(ctx, id)
+	if errors.Is(err, ErrTokenNotFound) {
+		return Token{}, ErrInvalidToken
+	}
+	if err != nil {
+		return Token{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(token.Hash), []byte(hashToken(secret))) != 1 {
+		return Token{}, ErrInvalidToken
+	}
+	if token.Expired(t.now()) {
+		return Token{}, ErrTokenExpired
+	}
+	return token, nil
+}
+
+func (t *Tokens) get(ctx context.Context, id string) (Token, error) {
+	kv, err := t.persistence.Get(ctx, id)
+	if errors.Is(err, persistence.ErrKeyNotFound) {
+		return Token{}, fmt.Errorf("%w: %s", ErrTokenNotFound, id)
+	}
+	if err != nil {
+		return Token{}, err
+	}
+	return decodeToken(kv)
+}
+
+// hashToken hashes a secret for storage. The secrets are random, so a fast hash suffices.
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func decodeToken(kv persistence.KeyValue) (Token, error) {
+	var token Token
+	if err := json.Unmarshal([]byte(kv.Value), &token); err != nil {
+		return Token{}, err
+	}
+	token.ID = kv.Key
+	return token, nil
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	featurev1 "github.com/dkrizic/feature/service/service/feature/v1"
+	"github.com/dkrizic/feature/service/service/persistence/inmemory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestTokens(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	storage := inmemory.NewInMemoryPersistence()
+	tokens := NewTokens(storage)
+	tokens.now = func() time.Time { return now }
+
+	token, secret, err := tokens.Create(ctx, Token{Name: "ci", Scopes: []Scope{ScopeWrite}, ExpiresAt: now.Add(time.Hour), CreatedBy: "alice"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, "ft_"+token.ID+"_"))
+
+	// only the hash is stored
+	stored, err := storage.Get(ctx, token.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, stored.Value, secret)
+	assert.Contains(t, stored.Value, hashToken(secret))
+
+	authenticated, err := tokens.Authenticate(ctx, secret)
+	require.NoError(t, err)
+	assert.Equal(t, "token:ci", authenticated.Principal())
+	assert.True(t, authenticated.Permissions().Allows("Set", "COLOR"))
+	assert.False(t, authenticated.Permissions().Allows("Restart"))
+	assert.False(t, authenticated.Permissions().Allows("CreateToken"))
+
+	_, err = tokens.Authenticate(ctx, secret+"x")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = tokens.Authenticate(ctx, "ft_0000000000000000_x")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = tokens.Authenticate(ctx, "secret")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	now = now.Add(time.Hour)
+	_, err = tokens.Authenticate(ctx, secret)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+
+	invalid := map[string]Token{
+		"no name":       {Scopes: []Scope{ScopeRead}},
+		"bad name":      {Name: "ci,admin", Scopes: []Scope{ScopeRead}},
+		"no scopes":     {Name: "app"},
+		"unknown scope": {Name: "app", Scopes: []Scope{"admin"}},
+		"expired":       {Name: "app", Scopes: []Scope{ScopeRead}, ExpiresAt: now.Add(-time.Minute)},
+	}
+	for name, token := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := tokens.Create(ctx, token)
+			assert.ErrorIs(t, err, ErrTokenInvalid)
+		})
+	}
+	_, _, err = tokens.Create(ctx, Token{Name: "ci", Scopes: []Scope{ScopeRead}})
+	assert.ErrorIs(t, err, ErrTokenExists)
+
+	list, err := tokens.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "alice", list[0].CreatedBy)
+
+	require.NoError(t, tokens.Revoke(ctx, token.ID))
+	assert.ErrorIs(t, tokens.Revoke(ctx, token.ID), ErrTokenNotFound)
+	now = now.Add(-time.Hour)
+	_, err = tokens.Authenticate(ctx, secret)
+	assert.ErrorIs(t, err, ErrInvalidToken, "revoked")
+}
+
+func TestSelectiveInterceptor_Token(t *testing.T) {
+	ctx := context.Background()
+	tokens := NewTokens(inmemory.NewInMemoryPersistence())
+	_, reader, err := tokens.Create(ctx, Token{Name: "app", Scopes: []Scope{ScopeRead}})
+	require.NoError(t, err)
+	users, err := NewUsers("admin", "secret", nil, nil)
+	require.NoError(t, err)
+	interceptor := SelectiveInterceptor(true, users, nil, tokens)
+
+	call := func(secret, method string, req any) (any, error) {
+		ctx := metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+secret))
+		info := &grpc.UnaryServerInfo{FullMethod: "/feature.v1.Feature/" + method}
+		return interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+			return PrincipalFromContext(ctx), nil
+		})
+	}
+
+	principal, err := call(reader, "Get", &featurev1.Key{Name: "COLOR"})
+	require.NoError(t, err)
+	assert.Equal(t, "token:app", principal)
+
+	_, err = call(reader, "Set", &featurev1.KeyValue{Key: "COLOR", Value: "red"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "the scopes apply without a policy")
+
+	_, err = call(reader, "CreateToken", &featurev1.CreateTokenRequest{Name: "other", Scopes: []featurev1.TokenScope{featurev1.TokenScope_TOKEN_SCOPE_READ}})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "tokens cannot create tokens")
+
+	_, err = call("ft_0000000000000000_x", "Get", &featurev1.Key{Name: "COLOR"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestAuthorize_CreateToken(t *testing.T) {
+	policy, err := ParsePolicy([]byte(testPolicy))
+	require.NoError(t, err)
+	ctx := context.Background()
+	req := &featurev1.CreateTokenRequest{Name: "ci", Scopes: []featurev1.TokenScope{featurev1.TokenScope_TOKEN_SCOPE_WRITE}}
+
+	assert.NoError(t, authorize(ctx, "/feature.v1.Feature/CreateToken", req, policy.Permissions("alice")))
+
+	// an editor restricted to some keys may not hand out a token for all of them
+	withTokens := &Policy{Roles: policy.Roles, Users: map[string][]string{"bob": {RoleEditor, "tokens"}}}
+	withTokens.Roles["tokens"] = Role{Methods: []string{"CreateToken"}}
+	err = authorize(ctx, "/feature.v1.Feature/CreateToken", req, withTokens.Permissions("bob"))
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.ErrorContains(t, err, "scope write is not allowed")
+}
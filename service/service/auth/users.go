@@ -0,0 +1,291 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvalidCredentials is returned for an unknown user or a wrong password
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrLockedOut is returned while a user is locked out after repeated failures, even for the
+	// right password
+	ErrLockedOut = errors.New("too many failed attempts, try again later")
+)
+
+// dummyHash is compared against for unknown users, so that they take as long as known ones
+const dummyHash = "$2a$10$s4V6ERf4HYmimEryhpiuD.c.h4nf8DBE7AyC29dHWOVSn9dmr5mIe"
+
+// Source provides the content of a users file. The version changes whenever the content does.
+type Source interface {
+	Load(ctx context.Context) (data []byte, version string, err error)
+}
+
+// verifier checks a password against a stored hash
+type verifier interface {
+	verify(password []byte) bool
+}
+
+type bcryptHash []byte
+
+func (h bcryptHash) verify(password []byte) bool {
+	return bcrypt.CompareHashAndPassword(h, password) == nil
+}
+
+type argon2idHash struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+func (h argon2idHash) verify(password []byte) bool {
+	key := argon2.IDKey(password, h.salt, h.time, h.memory, h.threads, uint32(len(h.key)))
+	return subtle.ConstantTimeCompare(key, h.key) == 1
+}
+
+// plainPassword is the single user configured with a plaintext password, the digests are
+// compared so that the comparison does not depend on the length of the password
+type plainPassword [sha256.Size]byte
+
+func (p plainPassword) verify(password []byte) bool {
+	digest := sha256.Sum256(password)
+	return subtle.ConstantTimeCompare(digest[:], p[:]) == 1
+}
+
+// parseHash parses a bcrypt hash ($2a$, $2b$ or $2y$) or an argon2id hash in the PHC string
+// format, $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>
+func parseHash(hash string) (verifier, error) {
+	switch {
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return nil, err
+		}
+		return bcryptHash(hash), nil
+	case strings.HasPrefix(hash, "$argon2id$"):
+		parts := strings.Split(hash, "$")
+		if len(parts) != 6 {
+			return nil, fmt.Errorf("invalid argon2id hash")
+		}
+		var version int
+		if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+			return nil, fmt.Errorf("unsupported argon2id version %q", parts[2])
+		}
+		var h argon2idHash
+		if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.memory, &h.time, &h.threads); err != nil {
+			return nil, fmt.Errorf("invalid argon2id parameters %q", parts[3])
+		}
+		var err error
+		if h.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+			return nil, fmt.Errorf("invalid argon2id salt: %w", err)
+		}
+		if h.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(h.key) == 0 {
+			return nil, fmt.Errorf("invalid argon2id key")
+		}
+		return h, nil
+	default:
+		return nil, fmt.Errorf("unsupported hash, expected bcrypt or argon2id")
+	}
+}
+
+// parseUsers parses a users file of username:hash lines like the ones of htpasswd -B. Empty
+// lines and lines starting with # are ignored.
+func parseUsers(data []byte) (map[string]verifier, error) {
+	users := make(map[string]verifier)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		username, hash, ok := strings.Cut(text, ":")
+		if !ok || username == "" {
+			return nil, fmt.Errorf("line %d: expected username:hash", line)
+		}
+		if _, exists := users[username]; exists {
+			return nil, fmt.Errorf("line %d: duplicate user %q", line, username)
+		}
+		v, err := parseHash(hash)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: user %q: %w", line, username, err)
+		}
+		users[username] = v
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// Users authenticates usernames and passwords against the users of a source, reloaded while the
+// service runs, and an optional single user with a plaintext password
+type Users struct {
+	source  Source
+	lockout *Lockout
+
+	// plain is the user with the plaintext password, it survives reloads
+	plainUsername string
+	plain         verifier
+
+	mu      sync.RWMutex
+	users   map[string]verifier
+	version string
+	// verified caches the HMAC of the last password that matched per user, so that a client
+	// sending its credentials on every call does not pay for the slow hash each time. It is
+	// cleared on every reload.
+	verified  map[string][]byte
+	verifyKey []byte
+}
+
+// NewUsers returns the users of the source, which may be nil, and the user with the plaintext
+// password if that is not empty. The source is not read before the first Reload.
+func NewUsers(username, password string, source Source, lockout *Lockout) (*Users, error) {
+	u := &Users{
+		source:   source,
+		lockout:  lockout,
+		users:    map[string]verifier{},
+		verified: map[string][]byte{},
+	}
+	if password != "" {
+		u.plainUsername = username
+		u.plain = plainPassword(sha256.Sum256([]byte(password)))
+	}
+	u.verifyKey = make([]byte, 32)
+	if _, err := rand.Read(u.verifyKey); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Reload reads the source and replaces the users if it changed. The users are kept if the
+// source cannot be read or is invalid.
+func (u *Users) Reload(ctx context.Context) error {
+	if u.source == nil {
+		return nil
+	}
+	ctx, span := otel.Tracer("service/auth").Start(ctx, "Reload")
+	defer span.End()
+
+	data, version, err := u.source.Load(ctx)
+	if err != nil {
+		return err
+	}
+	u.mu.RLock()
+	unchanged := version == u.version
+	u.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+	users, err := parseUsers(data)
+	if err != nil {
+		return err
+	}
+
+	u.mu.Lock()
+	u.users = users
+	u.version = version
+	u.verified = map[string][]byte{}
+	u.mu.Unlock()
+	span.SetAttributes(attribute.Int("users", len(users)))
+	slog.InfoContext(ctx, "Users loaded", "users", len(users), "version", version)
+	return nil
+}
+
+// Run reloads the users every interval until the context is done
+func (u *Users) Run(ctx context.Context, interval time.Duration) {
+	if u.source == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := u.Reload(ctx); err != nil {
+				slog.ErrorContext(ctx, "Failed to reload users, keeping the previous ones", "error", err)
+			}
+		}
+	}
+}
+
+// Len returns the number of users, including the one with the plaintext password
+func (u *Users) Len() int {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	n := len(u.users)
+	if _, ok := u.users[u.plainUsername]; u.plain != nil && !ok {
+		n++
+	}
+	return n
+}
+
+// Authenticate checks the password of the user. It returns ErrLockedOut while the user is locked
+// out and ErrInvalidCredentials for an unknown user or a wrong password.
+func (u *Users) Authenticate(ctx context.Context, username, password string) error {
+	if u.lockout.Locked(username) {
+		return ErrLockedOut
+	}
+
+	mac := hmac.New(sha256.New, u.verifyKey)
+	mac.Write([]byte(password))
+	digest := mac.Sum(nil)
+
+	u.mu.RLock()
+	v, ok := u.users[username]
+	if !ok && u.plain != nil && username == u.plainUsername {
+		v, ok = u.plain, true
+	}
+	cached := u.verified[username]
+	version := u.version
+	u.mu.RUnlock()
+
+	var valid bool
+	switch {
+	case !ok:
+		// compare anyway so that unknown users cannot be told apart by the response time
+		bcryptHash(dummyHash).verify([]byte(password))
+	case cached != nil && hmac.Equal(cached, digest):
+		valid = true
+	default:
+		valid = v.verify([]byte(password))
+	}
+
+	if !valid {
+		if u.lockout.Fail(username) {
+			slog.WarnContext(ctx, "User locked out after repeated failed attempts", "username", username)
+		}
+		return ErrInvalidCredentials
+	}
+	u.lockout.Reset(username)
+	if cached == nil || !hmac.Equal(cached, digest) {
+		u.mu.Lock()
+		// a reload in between may have changed the password
+		if u.version == version {
+			u.verified[username] = digest
+		}
+		u.mu.Unlock()
+	}
+	return nil
+}
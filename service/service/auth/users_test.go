@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func bcryptOf(t *testing.T, password string) string {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+	return string(hash)
+}
+
+func argon2idOf(t *testing.T, password string) string {
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	require.NoError(t, err)
+	key := argon2.IDKey([]byte(password), salt, 1, 1024, 1, 32)
+	return fmt.Sprintf("$argon2id$v=%d$m=1024,t=1,p=1$%s$%s", argon2.Version,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func writeUsers(t *testing.T, path string, lines ...string) {
+	content := ""
+	for _, line := range lines {
+		content += line + "\n"
+	}
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestParseUsers(t *testing.T) {
+	users, err := parseUsers([]byte("# admins\n\nalice:" + bcryptOf(t, "a") + "\nbob:" + argon2idOf(t, "b") + "\n"))
+	require.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.True(t, users["alice"].verify([]byte("a")))
+	assert.False(t, users["alice"].verify([]byte("b")))
+	assert.True(t, users["bob"].verify([]byte("b")))
+	assert.False(t, users["bob"].verify([]byte("a")))
+
+	invalid := map[string]string{
+		"no hash":     "alice",
+		"no name":     ":" + bcryptOf(t, "a"),
+		"plaintext":   "alice:secret",
+		"md5":         "alice:$apr1$salt$hash",
+		"argon2i":     "alice:$argon2i$v=19$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"bad params":  "alice:$argon2id$v=19$m=x$c2FsdA$a2V5",
+		"bad version": "alice:$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"duplicate":   "alice:" + bcryptOf(t, "a") + "\nalice:" + bcryptOf(t, "b"),
+	}
+	for name, content := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseUsers([]byte(content))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestUsers_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "users")
+	writeUsers(t, path, "alice:"+bcryptOf(t, "wonderland"))
+	users, err := NewUsers("admin", "secret", NewFileSource(path), nil)
+	require.NoError(t, err)
+	require.NoError(t, users.Reload(ctx))
+	assert.Equal(t, 2, users.Len())
+
+	assert.NoError(t, users.Authenticate(ctx, "alice", "wonderland"))
+	assert.NoError(t, users.Authenticate(ctx, "alice", "wonderland"), "cached")
+	assert.ErrorIs(t, users.Authenticate(ctx, "alice", "secret"), ErrInvalidCredentials)
+	assert.NoError(t, users.Authenticate(ctx, "admin", "secret"), "plaintext user")
+	assert.ErrorIs(t, users.Authenticate(ctx, "admin", "secret2"), ErrInvalidCredentials)
+	assert.ErrorIs(t, users.Authenticate(ctx, "mallory", "wonderland"), ErrInvalidCredentials)
+
+	// a changed file replaces the users and forgets the cached passwords
+	writeUsers(t, path, "alice:"+argon2idOf(t, "looking-glass"), "bob:"+bcryptOf(t, "builder"))
+	require.NoError(t, users.Reload(ctx))
+	assert.ErrorIs(t, users.Authenticate(ctx, "alice", "wonderland"), ErrInvalidCredentials)
+	assert.NoError(t, users.Authenticate(ctx, "alice", "looking-glass"))
+	assert.NoError(t, users.Authenticate(ctx, "bob", "builder"))
+
+	// an invalid file keeps the previous users
+	writeUsers(t, path, "alice")
+	assert.Error(t, users.Reload(ctx))
+	assert.NoError(t, users.Authenticate(ctx, "bob", "builder"))
+}
+
+func TestLockout(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	lockout := NewLockout(3, 15*time.Minute)
+	lockout.now = func() time.Time { return now }
+	users, err := NewUsers("admin", "secret", nil, lockout)
+	require.NoError(t, err)
+
+	// a success resets the failures
+	assert.ErrorIs(t, users.Authenticate(ctx, "admin", "wrong"), ErrInvalidCredentials)
+	assert.ErrorIs(t, users.Authenticate(ctx, "admin", "wrong"), ErrInvalidCredentials)
+	assert.NoError(t, users.Authenticate(ctx, "admin", "secret"))
+
+	for range 3 {
+		assert.ErrorIs(t, users.Authenticate(ctx, "admin", "wrong"), ErrInvalidCredentials)
+	}
+	assert.ErrorIs(t, users.Authenticate(ctx, "admin", "secret"), ErrLockedOut)
+	assert.False(t, lockout.Locked("mallory"), "lockout is per user")
+
+	now = now.Add(15 * time.Minute)
+	assert.NoError(t, users.Authenticate(ctx, "admin", "secret"))
+
+	// failures far apart do not add up
+	for range 3 {
+		assert.ErrorIs(t, users.Authenticate(ctx, "admin", "wrong"), ErrInvalidCredentials)
+		now = now.Add(time.Hour)
+	}
+	assert.False(t, lockout.Locked("admin"))
+
+	assert.Nil(t, NewLockout(0, time.Minute), "disabled")
+}
+
+type fakeSecrets map[string]*v1.Secret
+
+func (f fakeSecrets) Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.Secret, error) {
+	secret, ok := f[name]
+	if !ok {
+		return nil, fmt.Errorf("secret %s not found", name)
+	}
+	return secret, nil
+}
+
+func TestSecretSource(t *testing.T) {
+	ctx := context.Background()
+	secrets := fakeSecrets{"feature-users": {
+		ObjectMeta: metav1.ObjectMeta{Name: "feature-users", ResourceVersion: "7"},
+		Data:       map[string][]byte{"users": []byte("alice:" + bcryptOf(t, "wonderland"))},
+	}}
+	source := NewSecretSource("feature-users", "users")
+	source.client = secrets
+	users, err := NewUsers("", "", source, nil)
+	require.NoError(t, err)
+	require.NoError(t, users.Reload(ctx))
+	assert.NoError(t, users.Authenticate(ctx, "alice", "wonderland"))
+
+	source.key = "htpasswd"
+	_, _, err = source.Load(ctx)
+	assert.ErrorContains(t, err, "has no key htpasswd")
+}
+
+func TestValidateCredentials(t *testing.T) {
+	users, err := NewUsers("admin", "secret", nil, NewLockout(1, time.Minute))
+	require.NoError(t, err)
+	basic := func(username, password string) context.Context {
+		header := "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", header))
+	}
+
+	principal, err := validateCredentials(basic("admin", "secret"), "/feature.v1.Feature/Get", users)
+	require.NoError(t, err)
+	assert.Equal(t, "admin", principal)
+
+	_, err = validateCredentials(basic("admin", "wrong"), "/feature.v1.Feature/Get", users)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = validateCredentials(basic("admin", "secret"), "/feature.v1.Feature/Get", users)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.ErrorContains(t, err, "too many failed attempts")
+
+	_, err = validateCredentials(context.Background(), "/feature.v1.Feature/Get", users)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
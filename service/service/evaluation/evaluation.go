@@ -0,0 +1,244 @@
+package evaluation
+
+// evaluates the targeting rules of a flag against the context of a caller
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/dkrizic/feature/service/service/persistence"
+	"golang.org/x/mod/semver"
+)
+
+// well-known attributes of the evaluation context
+const (
+	AttributeUserID = "userId"
+	AttributeTenant = "tenant"
+	AttributeRegion = "region"
+)
+
+// Context describes the caller a flag is evaluated for
+type Context struct {
+	UserID     string
+	Tenant     string
+	Region     string
+	Attributes map[string]string
+}
+
+// Lookup returns the value of an attribute, well-known attributes take precedence over Attributes
+func (c Context) Lookup(attribute string) (string, bool) {
+	switch attribute {
+	case AttributeUserID:
+		return c.UserID, c.UserID != ""
+	case AttributeTenant:
+		return c.Tenant, c.Tenant != ""
+	case AttributeRegion:
+		return c.Region, c.Region != ""
+	}
+	value, ok := c.Attributes[attribute]
+	return value, ok
+}
+
+type Reason string
+
+const (
+	// ReasonDefault means no rule matched and the stored value was returned
+	ReasonDefault   Reason = "default"
+	ReasonRuleMatch Reason = "rule_match"
+	// ReasonRollout means a variant of the rollout of the matching rule or the flag was served
+	ReasonRollout Reason = "rollout"
+)
+
+// Result is the outcome of an evaluation
+type Result struct {
+	Value  string
+	Reason Reason
+	// RuleIndex is the position of the matching rule, -1 if none matched
+	RuleIndex int
+	RuleName  string
+	// BucketBy is the attribute used for bucketing, empty if no rollout applied
+	BucketBy string
+	// Bucket is the bucket of the caller, -1 if the caller was not bucketed
+	Bucket int
+	// VariantIndex is the position of the served variant, -1 if none was served
+	VariantIndex int
+	// Prerequisite is the key of the prerequisite that was not met, empty if all were met
+	Prerequisite string
+}
+
+// Evaluate returns the value of the first rule whose conditions all match, or the stored value.
+// If the rule or the flag has a rollout, callers in the buckets of a variant get its value instead.
+func Evaluate(kv persistence.KeyValue, ctx Context) Result {
+	for i, rule := range kv.Rules {
+		if matchesAll(rule.Conditions, ctx) {
+			result := Result{Value: rule.Value, Reason: ReasonRuleMatch, RuleIndex: i, RuleName: rule.Name, Bucket: -1, VariantIndex: -1}
+			result.rollout(kv.Key, rule.Rollout, ctx)
+			return result
+		}
+	}
+	result := Result{Value: kv.Value, Reason: ReasonDefault, RuleIndex: -1, Bucket: -1, VariantIndex: -1}
+	result.rollout(kv.Key, kv.Rollout, ctx)
+	return result
+}
+
+// Explain summarizes how the result came about, for debugging
+func (r Result) Explain() string {
+	if r.Reason == ReasonPrerequisiteFailed {
+		return fmt.Sprintf("prerequisite %s not met, off value served", r.Prerequisite)
+	}
+	var parts []string
+	if r.RuleIndex >= 0 {
+		rule := fmt.Sprintf("rule %d", r.RuleIndex+1)
+		if r.RuleName != "" {
+			rule += fmt.Sprintf(" (%s)", r.RuleName)
+		}
+		parts = append(parts, rule+" matched")
+	} else {
+		parts = append(parts, "no rule matched")
+	}
+	switch {
+	case r.BucketBy == "":
+	case r.Bucket < 0:
+		parts = append(parts, fmt.Sprintf("%s missing, not bucketed", r.BucketBy))
+	case r.VariantIndex >= 0:
+		parts = append(parts, fmt.Sprintf("%s in bucket %d of %d, variant %d served", r.BucketBy, r.Bucket, Buckets, r.VariantIndex+1))
+	default:
+		parts = append(parts, fmt.Sprintf("%s in bucket %d of %d, outside all variants", r.BucketBy, r.Bucket, Buckets))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func matchesAll(conditions []persistence.Condition, ctx Context) bool {
+	for _, condition := range conditions {
+		if !matches(condition, ctx) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches tests a single condition, a missing attribute never matches
+func matches(condition persistence.Condition, ctx Context) bool {
+	value, ok := ctx.Lookup(condition.Attribute)
+	if !ok || len(condition.Values) == 0 {
+		return false
+	}
+	operand := condition.Values[0]
+
+	switch condition.Operator {
+	case persistence.OperatorEquals:
+		return value == operand
+	case persistence.OperatorNotEquals:
+		return value != operand
+	case persistence.OperatorIn:
+		return slices.Contains(condition.Values, value)
+	case persistence.OperatorNotIn:
+		return !slices.Contains(condition.Values, value)
+	case persistence.OperatorRegex:
+		re, err := compile(operand)
+		return err == nil && re.MatchString(value)
+	case persistence.OperatorSemverEquals:
+		return compareVersions(value, operand, func(c int) bool { return c == 0 })
+	case persistence.OperatorSemverLessThan:
+		return compareVersions(value, operand, func(c int) bool { return c < 0 })
+	case persistence.OperatorSemverLessOrEqual:
+		return compareVersions(value, operand, func(c int) bool { return c <= 0 })
+	case persistence.OperatorSemverGreaterThan:
+		return compareVersions(value, operand, func(c int) bool { return c > 0 })
+	case persistence.OperatorSemverGreaterOrEqual:
+		return compareVersions(value, operand, func(c int) bool { return c >= 0 })
+	}
+	return false
+}
+
+// compareVersions compares two semantic versions, an attribute that is no version never matches
+func compareVersions(value, operand string, accept func(int) bool) bool {
+	v, w := canonical(value), canonical(operand)
+	if !semver.IsValid(v) || !semver.IsValid(w) {
+		return false
+	}
+	return accept(semver.Compare(v, w))
+}
+
+func canonical(version string) string {
+	if !strings.HasPrefix(version, "v") {
+		return "v" + version
+	}
+	return version
+}
+
+// patterns caches compiled regular expressions, rules are evaluated far more often than changed
+var patterns sync.Map
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+// ValidateRules checks that the rules are well-formed. Rule values are not checked against the
+// type of the flag, that is up to the caller.
+func ValidateRules(rules []persistence.Rule) error {
+	for i, rule := range rules {
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if len(rule.Conditions) == 0 {
+			return fmt.Errorf("rule %s has no conditions", name)
+		}
+		for _, condition := range rule.Conditions {
+			if err := validateCondition(condition); err != nil {
+				return fmt.Errorf("rule %s: %w", name, err)
+			}
+		}
+		if err := ValidateRollout(rule.Rollout); err != nil {
+			return fmt.Errorf("rule %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func validateCondition(condition persistence.Condition) error {
+	if condition.Attribute == "" {
+		return fmt.Errorf("condition without attribute")
+	}
+	switch condition.Operator {
+	case persistence.OperatorIn, persistence.OperatorNotIn:
+		if len(condition.Values) == 0 {
+			return fmt.Errorf("%s on %s needs at least one value", condition.Operator, condition.Attribute)
+		}
+		return nil
+	case persistence.OperatorEquals, persistence.OperatorNotEquals, persistence.OperatorRegex,
+		persistence.OperatorSemverEquals, persistence.OperatorSemverLessThan, persistence.OperatorSemverLessOrEqual,
+		persistence.OperatorSemverGreaterThan, persistence.OperatorSemverGreaterOrEqual:
+	default:
+		return fmt.Errorf("unknown operator %q", condition.Operator)
+	}
+
+	if len(condition.Values) != 1 {
+		return fmt.Errorf("%s on %s needs exactly one value", condition.Operator, condition.Attribute)
+	}
+	operand := condition.Values[0]
+	switch condition.Operator {
+	case persistence.OperatorRegex:
+		if _, err := compile(operand); err != nil {
+			return fmt.Errorf("invalid regular expression %q: %w", operand, err)
+		}
+	case persistence.OperatorSemverEquals, persistence.OperatorSemverLessThan, persistence.OperatorSemverLessOrEqual,
+		persistence.OperatorSemverGreaterThan, persistence.OperatorSemverGreaterOrEqual:
+		if !semver.IsValid(canonical(operand)) {
+			return fmt.Errorf("invalid semantic version %q", operand)
+		}
+	}
+	return nil
+}
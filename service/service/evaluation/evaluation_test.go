@@ -0,0 +1,99 @@
+package evaluation
+
+import (
+	"testing"
+
+	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func condition(attribute string, operator persistence.Operator, values ...string) persistence.Condition {
+	return persistence.Condition{Attribute: attribute, Operator: operator, Values: values}
+}
+
+func TestEvaluate_FirstMatchingRuleWins(t *testing.T) {
+	kv := persistence.KeyValue{
+		Key:   "CHECKOUT",
+		Value: "classic",
+		Rules: []persistence.Rule{
+			{Name: "beta tenants in eu", Value: "beta", Conditions: []persistence.Condition{
+				condition(AttributeTenant, persistence.OperatorIn, "acme", "globex"),
+				condition(AttributeRegion, persistence.OperatorEquals, "eu"),
+			}},
+			{Name: "acme", Value: "new", Conditions: []persistence.Condition{
+				condition(AttributeTenant, persistence.OperatorEquals, "acme"),
+			}},
+		},
+	}
+
+	result := Evaluate(kv, Context{Tenant: "acme", Region: "eu"})
+	assert.Equal(t, Result{Value: "beta", Reason: ReasonRuleMatch, RuleIndex: 0, RuleName: "beta tenants in eu", Bucket: -1, VariantIndex: -1}, result)
+
+	result = Evaluate(kv, Context{Tenant: "acme", Region: "us"})
+	assert.Equal(t, "new", result.Value)
+	assert.Equal(t, 1, result.RuleIndex)
+
+	result = Evaluate(kv, Context{Tenant: "initech"})
+	assert.Equal(t, Result{Value: "classic", Reason: ReasonDefault, RuleIndex: -1, Bucket: -1, VariantIndex: -1}, result)
+}
+
+func TestMatches(t *testing.T) {
+	ctx := Context{
+		UserID:     "user-42",
+		Attributes: map[string]string{"appVersion": "1.4.2", "plan": "pro", "email": "jane@example.com"},
+	}
+	tests := []struct {
+		name      string
+		condition persistence.Condition
+		match     bool
+	}{
+		{"equals", condition(AttributeUserID, persistence.OperatorEquals, "user-42"), true},
+		{"equals other", condition(AttributeUserID, persistence.OperatorEquals, "user-43"), false},
+		{"not equals", condition("plan", persistence.OperatorNotEquals, "free"), true},
+		{"in", condition("plan", persistence.OperatorIn, "pro", "enterprise"), true},
+		{"not in", condition("plan", persistence.OperatorNotIn, "pro", "enterprise"), false},
+		{"regex", condition("email", persistence.OperatorRegex, `.*@example\.com`), true},
+		{"regex must match completely", condition("email", persistence.OperatorRegex, `example`), false},
+		{"semver equals", condition("appVersion", persistence.OperatorSemverEquals, "v1.4.2"), true},
+		{"semver less than", condition("appVersion", persistence.OperatorSemverLessThan, "1.10.0"), true},
+		{"semver less or equal", condition("appVersion", persistence.OperatorSemverLessOrEqual, "1.4.2"), true},
+		{"semver greater than", condition("appVersion", persistence.OperatorSemverGreaterThan, "1.4.2"), false},
+		{"semver greater or equal", condition("appVersion", persistence.OperatorSemverGreaterOrEqual, "1.4.0"), true},
+		{"semver on no version", condition("plan", persistence.OperatorSemverGreaterThan, "1.0.0"), false},
+		{"missing attribute", condition(AttributeTenant, persistence.OperatorNotEquals, "acme"), false},
+		{"unknown operator", condition("plan", "contains", "pro"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.match, matches(tt.condition, ctx))
+		})
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	valid := []persistence.Rule{{Value: "on", Conditions: []persistence.Condition{
+		condition(AttributeTenant, persistence.OperatorIn, "a", "b"),
+		condition("appVersion", persistence.OperatorSemverGreaterOrEqual, "2.0"),
+	}}}
+	assert.NoError(t, ValidateRules(valid))
+	assert.NoError(t, ValidateRules(nil))
+
+	invalid := map[string]persistence.Rule{
+		"no conditions":     {Value: "on"},
+		"no attribute":      {Conditions: []persistence.Condition{condition("", persistence.OperatorEquals, "a")}},
+		"unknown operator":  {Conditions: []persistence.Condition{condition("plan", "like", "a")}},
+		"in without values": {Conditions: []persistence.Condition{condition("plan", persistence.OperatorIn)}},
+		"equals two values": {Conditions: []persistence.Condition{condition("plan", persistence.OperatorEquals, "a", "b")}},
+		"invalid regex":     {Conditions: []persistence.Condition{condition("plan", persistence.OperatorRegex, "(")}},
+		"invalid version":   {Conditions: []persistence.Condition{condition("appVersion", persistence.OperatorSemverLessThan, "latest")}},
+		"invalid rollout": {
+			Conditions: []persistence.Condition{condition("plan", persistence.OperatorEquals, "pro")},
+			Rollout:    &persistence.Rollout{Variants: []persistence.Variant{{Value: "on", Percentage: 120}}},
+		},
+	}
+	for name, rule := range invalid {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, ValidateRules([]persistence.Rule{rule}))
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package evaluation
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dkrizic/feature/service/service/persistence"
+)
+
+// ReasonPrerequisiteFailed means a prerequisite was not met and the off value was served
+const ReasonPrerequisiteFailed Reason = "prerequisite_failed"
+
+// Flags are the flags of a set and environment by key, prerequisites are looked up in them
+type Flags map[string]persistence.KeyValue
+
+// NewFlags indexes flags by key
+func NewFlags(all []persistence.KeyValue) Flags {
+	flags := make(Flags, len(all))
+	for _, kv := range all {
+		flags[kv.Key] = kv
+	}
+	return flags
+}
+
+// EvaluateFlag checks the prerequisites of the flag before evaluating it. Prerequisites are
+// evaluated for the same context, a prerequisite that is missing, evaluates to another value or
+// depends on the flag itself is not met and the off value is served.
+func EvaluateFlag(kv persistence.KeyValue, ctx Context, flags Flags) Result {
+	return evaluateFlag(kv, ctx, flags, map[string]bool{})
+}
+
+func evaluateFlag(kv persistence.KeyValue, ctx Context, flags Flags, visiting map[string]bool) Result {
+	visiting[kv.Key] = true
+	defer delete(visiting, kv.Key)
+	for _, prerequisite := range kv.Prerequisites {
+		if !met(prerequisite, ctx, flags, visiting) {
+			return Result{Value: OffValue(kv), Reason: ReasonPrerequisiteFailed, RuleIndex: -1, Bucket: -1, VariantIndex: -1, Prerequisite: prerequisite.Key}
+		}
+	}
+	return Evaluate(kv, ctx)
+}
+
+func met(prerequisite persistence.Prerequisite, ctx Context, flags Flags, visiting map[string]bool) bool {
+	if visiting[prerequisite.Key] {
+		return false
+	}
+	kv, ok := flags[prerequisite.Key]
+	if !ok {
+		return false
+	}
+	return evaluateFlag(kv, ctx, flags, visiting).Value == prerequisite.Value
+}
+
+// OffValue is the value served if a prerequisite of the flag is not met
+func OffValue(kv persistence.KeyValue) string {
+	if kv.OffValue == "" && kv.Type == persistence.TypeBoolean {
+		return "false"
+	}
+	return kv.OffValue
+}
+
+// ValidatePrerequisites checks the prerequisites of the flag key against the other flags: each
+// must name an existing flag once and they must not form a cycle. Expected values are not checked
+// against the type of the prerequisite, that is up to the caller.
+func ValidatePrerequisites(key string, prerequisites []persistence.Prerequisite, flags Flags) error {
+	seen := make(map[string]bool)
+	for _, prerequisite := range prerequisites {
+		switch {
+		case prerequisite.Key == "":
+			return fmt.Errorf("prerequisite without key")
+		case prerequisite.Key == key:
+			return fmt.Errorf("flag '%s' cannot be its own prerequisite", key)
+		case seen[prerequisite.Key]:
+			return fmt.Errorf("prerequisite '%s' is listed twice", prerequisite.Key)
+		}
+		seen[prerequisite.Key] = true
+		if _, ok := flags[prerequisite.Key]; !ok {
+			return fmt.Errorf("prerequisite '%s' does not exist", prerequisite.Key)
+		}
+	}
+
+	next := func(k string) []persistence.Prerequisite {
+		if k == key {
+			return prerequisites
+		}
+		return flags[k].Prerequisites
+	}
+	// depth first search from the flag, a path back to it is a cycle
+	var path []string
+	done := make(map[string]bool)
+	var visit func(k string) error
+	visit = func(k string) error {
+		if slices.Contains(path, k) {
+			return fmt.Errorf("prerequisites form a cycle: %s", strings.Join(append(path, k), " -> "))
+		}
+		if done[k] {
+			return nil
+		}
+		path = append(path, k)
+		for _, prerequisite := range next(k) {
+			if err := visit(prerequisite.Key); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		done[k] = true
+		return nil
+	}
+	return visit(key)
+}
+
+// Dependents returns the sorted keys of the flags that have key as a prerequisite
+func Dependents(key string, flags Flags) []string {
+	var result []string
+	for k, kv := range flags {
+		if slices.ContainsFunc(kv.Prerequisites, func(p persistence.Prerequisite) bool { return p.Key == key }) {
+			result = append(result, k)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
@@ -0,0 +1,80 @@
+package evaluation
+
+import (
+	"testing"
+
+	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateFlag_Prerequisites(t *testing.T) {
+	flags := NewFlags([]persistence.KeyValue{
+		{Key: "NEW_CHECKOUT", Value: "false", Type: persistence.TypeBoolean, Rules: []persistence.Rule{
+			{Value: "true", Conditions: []persistence.Condition{condition(AttributeTenant, persistence.OperatorEquals, "acme")}},
+		}},
+		{Key: "NEW_CHECKOUT_V2", Value: "true", Type: persistence.TypeBoolean,
+			Prerequisites: []persistence.Prerequisite{{Key: "NEW_CHECKOUT", Value: "true"}}},
+		{Key: "CHECKOUT_THEME", Value: "dark", OffValue: "classic",
+			Prerequisites: []persistence.Prerequisite{{Key: "NEW_CHECKOUT_V2", Value: "true"}}},
+		{Key: "ORPHAN", Value: "on", Prerequisites: []persistence.Prerequisite{{Key: "DELETED", Value: "true"}}},
+	})
+
+	result := EvaluateFlag(flags["NEW_CHECKOUT_V2"], Context{Tenant: "acme"}, flags)
+	assert.Equal(t, "true", result.Value)
+	assert.Equal(t, ReasonDefault, result.Reason)
+
+	result = EvaluateFlag(flags["NEW_CHECKOUT_V2"], Context{Tenant: "initech"}, flags)
+	assert.Equal(t, Result{Value: "false", Reason: ReasonPrerequisiteFailed, RuleIndex: -1, Bucket: -1, VariantIndex: -1, Prerequisite: "NEW_CHECKOUT"}, result)
+	assert.Equal(t, "prerequisite NEW_CHECKOUT not met, off value served", result.Explain())
+
+	// prerequisites of prerequisites count
+	assert.Equal(t, "dark", EvaluateFlag(flags["CHECKOUT_THEME"], Context{Tenant: "acme"}, flags).Value)
+	result = EvaluateFlag(flags["CHECKOUT_THEME"], Context{}, flags)
+	assert.Equal(t, "classic", result.Value)
+	assert.Equal(t, "NEW_CHECKOUT_V2", result.Prerequisite)
+
+	assert.Equal(t, ReasonPrerequisiteFailed, EvaluateFlag(flags["ORPHAN"], Context{}, flags).Reason)
+}
+
+func TestEvaluateFlag_Cycle(t *testing.T) {
+	flags := NewFlags([]persistence.KeyValue{
+		{Key: "A", Value: "true", Prerequisites: []persistence.Prerequisite{{Key: "B", Value: "true"}}},
+		{Key: "B", Value: "true", Prerequisites: []persistence.Prerequisite{{Key: "A", Value: "true"}}},
+	})
+	assert.Equal(t, ReasonPrerequisiteFailed, EvaluateFlag(flags["A"], Context{}, flags).Reason)
+}
+
+func TestValidatePrerequisites(t *testing.T) {
+	flags := NewFlags([]persistence.KeyValue{
+		{Key: "A"},
+		{Key: "B", Prerequisites: []persistence.Prerequisite{{Key: "A", Value: "true"}}},
+		{Key: "C", Prerequisites: []persistence.Prerequisite{{Key: "B", Value: "true"}}},
+	})
+	assert.NoError(t, ValidatePrerequisites("C", []persistence.Prerequisite{{Key: "A", Value: "true"}, {Key: "B", Value: "true"}}, flags))
+	assert.NoError(t, ValidatePrerequisites("A", nil, flags))
+
+	err := ValidatePrerequisites("A", []persistence.Prerequisite{{Key: "C", Value: "true"}}, flags)
+	assert.EqualError(t, err, "prerequisites form a cycle: A -> C -> B -> A")
+
+	invalid := map[string][]persistence.Prerequisite{
+		"self":      {{Key: "A", Value: "true"}},
+		"missing":   {{Key: "D", Value: "true"}},
+		"no key":    {{Value: "true"}},
+		"duplicate": {{Key: "B", Value: "true"}, {Key: "B", Value: "false"}},
+	}
+	for name, prerequisites := range invalid {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, ValidatePrerequisites("A", prerequisites, flags))
+		})
+	}
+}
+
+func TestDependents(t *testing.T) {
+	flags := NewFlags([]persistence.KeyValue{
+		{Key: "A"},
+		{Key: "C", Prerequisites: []persistence.Prerequisite{{Key: "A", Value: "true"}}},
+		{Key: "B", Prerequisites: []persistence.Prerequisite{{Key: "A", Value: "true"}}},
+	})
+	assert.Equal(t, []string{"B", "C"}, Dependents("A", flags))
+	assert.Empty(t, Dependents("B", flags))
+}
@@ -0,0 +1,77 @@
+package evaluation
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/dkrizic/feature/service/service/persistence"
+)
+
+// Buckets is the number of buckets callers are split into, one bucket is 0.01%
+const Buckets = 10000
+
+// Bucket returns the bucket of a caller for a flag. Hashing the key along with the attribute keeps
+// the bucket stable for a caller while spreading callers differently across flags.
+func Bucket(key, value string) int {
+	sum := sha256.Sum256([]byte(key + "/" + value))
+	return int(binary.BigEndian.Uint64(sum[:8]) % Buckets)
+}
+
+// BucketBy returns the attribute a rollout hashes, userId by default
+func BucketBy(r *persistence.Rollout) string {
+	if r.BucketBy == "" {
+		return AttributeUserID
+	}
+	return r.BucketBy
+}
+
+// rollout replaces the value with the variant whose buckets contain the caller, if any
+func (r *Result) rollout(key string, rollout *persistence.Rollout, ctx Context) {
+	if rollout == nil || len(rollout.Variants) == 0 {
+		return
+	}
+	r.BucketBy = BucketBy(rollout)
+	value, ok := ctx.Lookup(r.BucketBy)
+	if !ok {
+		return
+	}
+	r.Bucket = Bucket(key, value)
+
+	// the variants take consecutive ranges of buckets starting at 0
+	upper := 0
+	for i, variant := range rollout.Variants {
+		upper += buckets(variant.Percentage)
+		if r.Bucket < upper {
+			r.Value = variant.Value
+			r.Reason = ReasonRollout
+			r.VariantIndex = i
+			return
+		}
+	}
+}
+
+// buckets converts a percentage into a number of buckets
+func buckets(percentage float64) int {
+	return int(math.Round(percentage * Buckets / 100))
+}
+
+// ValidateRollout checks that the percentages are within 0 and 100 and add up to at most 100.
+// Variant values are not checked against the type of the flag, that is up to the caller.
+func ValidateRollout(r *persistence.Rollout) error {
+	if r == nil {
+		return nil
+	}
+	total := 0
+	for i, variant := range r.Variants {
+		if math.IsNaN(variant.Percentage) || variant.Percentage < 0 || variant.Percentage > 100 {
+			return fmt.Errorf("percentage %v of variant %d is not between 0 and 100", variant.Percentage, i+1)
+		}
+		total += buckets(variant.Percentage)
+	}
+	if total > Buckets {
+		return fmt.Errorf("percentages of the variants add up to %.2f, more than 100", float64(total)*100/Buckets)
+	}
+	return nil
+}
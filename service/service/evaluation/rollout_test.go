@@ -0,0 +1,114 @@
+package evaluation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucket_Sticky(t *testing.T) {
+	bucket := Bucket("CHECKOUT", "user-42")
+	assert.GreaterOrEqual(t, bucket, 0)
+	assert.Less(t, bucket, Buckets)
+	for range 10 {
+		assert.Equal(t, bucket, Bucket("CHECKOUT", "user-42"))
+	}
+}
+
+func TestBucket_Distribution(t *testing.T) {
+	// a 25% rollout should reach roughly a quarter of the users
+	kv := persistence.KeyValue{
+		Key:     "CHECKOUT",
+		Value:   "classic",
+		Rollout: &persistence.Rollout{Variants: []persistence.Variant{{Value: "new", Percentage: 25}}},
+	}
+	served := 0
+	for i := range 10000 {
+		if Evaluate(kv, Context{UserID: fmt.Sprintf("user-%d", i)}).Value == "new" {
+			served++
+		}
+	}
+	assert.InDelta(t, 2500, served, 200)
+}
+
+func TestEvaluate_Rollout(t *testing.T) {
+	kv := persistence.KeyValue{
+		Key:     "CHECKOUT",
+		Value:   "classic",
+		Rollout: &persistence.Rollout{BucketBy: AttributeTenant, Variants: []persistence.Variant{{Value: "new", Percentage: 100}}},
+	}
+	result := Evaluate(kv, Context{Tenant: "acme"})
+	assert.Equal(t, "new", result.Value)
+	assert.Equal(t, ReasonRollout, result.Reason)
+	assert.Equal(t, AttributeTenant, result.BucketBy)
+	assert.Equal(t, Bucket("CHECKOUT", "acme"), result.Bucket)
+	assert.Equal(t, 0, result.VariantIndex)
+	assert.Contains(t, result.Explain(), "variant 1 served")
+
+	// callers without the attribute get the fallback
+	result = Evaluate(kv, Context{UserID: "user-42"})
+	assert.Equal(t, "classic", result.Value)
+	assert.Equal(t, ReasonDefault, result.Reason)
+	assert.Equal(t, -1, result.Bucket)
+	assert.Equal(t, "no rule matched, tenant missing, not bucketed", result.Explain())
+
+	// a 0% rollout serves nobody
+	kv.Rollout.Variants[0].Percentage = 0
+	result = Evaluate(kv, Context{Tenant: "acme"})
+	assert.Equal(t, "classic", result.Value)
+	assert.Equal(t, -1, result.VariantIndex)
+	assert.Contains(t, result.Explain(), "outside all variants")
+}
+
+func TestEvaluate_RuleRollout(t *testing.T) {
+	kv := persistence.KeyValue{
+		Key:   "CHECKOUT",
+		Value: "classic",
+		Rules: []persistence.Rule{{
+			Name:       "beta",
+			Value:      "classic",
+			Conditions: []persistence.Condition{condition(AttributeTenant, persistence.OperatorEquals, "acme")},
+			Rollout:    &persistence.Rollout{Variants: []persistence.Variant{{Value: "new", Percentage: 100}}},
+		}},
+	}
+	result := Evaluate(kv, Context{Tenant: "acme", UserID: "user-42"})
+	assert.Equal(t, "new", result.Value)
+	assert.Equal(t, ReasonRollout, result.Reason)
+	assert.Equal(t, 0, result.RuleIndex)
+	assert.Equal(t, AttributeUserID, result.BucketBy)
+	assert.Contains(t, result.Explain(), "rule 1 (beta) matched, userId in bucket")
+
+	// the rollout of the flag does not apply to callers matching a rule
+	result = Evaluate(kv, Context{Tenant: "initech", UserID: "user-42"})
+	assert.Equal(t, "classic", result.Value)
+	assert.Equal(t, "", result.BucketBy)
+}
+
+func TestEvaluate_RolloutVariantsInOrder(t *testing.T) {
+	kv := persistence.KeyValue{
+		Key:   "COLOR",
+		Value: "red",
+		Rollout: &persistence.Rollout{Variants: []persistence.Variant{
+			{Value: "green", Percentage: 50},
+			{Value: "blue", Percentage: 50},
+		}},
+	}
+	for i := range 100 {
+		user := fmt.Sprintf("user-%d", i)
+		result := Evaluate(kv, Context{UserID: user})
+		if Bucket("COLOR", user) < 5000 {
+			assert.Equal(t, "green", result.Value)
+		} else {
+			assert.Equal(t, "blue", result.Value)
+		}
+	}
+}
+
+func TestValidateRollout(t *testing.T) {
+	assert.NoError(t, ValidateRollout(nil))
+	assert.NoError(t, ValidateRollout(&persistence.Rollout{Variants: []persistence.Variant{{Percentage: 33.33}, {Percentage: 66.67}}}))
+	assert.Error(t, ValidateRollout(&persistence.Rollout{Variants: []persistence.Variant{{Percentage: -1}}}))
+	assert.Error(t, ValidateRollout(&persistence.Rollout{Variants: []persistence.Variant{{Percentage: 60}, {Percentage: 50}}}))
+}
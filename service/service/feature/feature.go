@@ -2,9 +2,16 @@ package feature
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/dkrizic/feature/service/notifier"
+	"github.com/dkrizic/feature/service/notifier/broadcast"
+	"github.com/dkrizic/feature/service/service/auth"
+	"github.com/dkrizic/feature/service/service/evaluation"
 	"github.com/dkrizic/feature/service/service/feature/v1"
 	"github.com/dkrizic/feature/service/service/persistence"
 	"github.com/dkrizic/feature/service/telemetry/localmetrics"
@@ -19,6 +26,11 @@ type FeatureService struct {
 	featurev1.UnimplementedFeatureServer
 	persistence    persistence.Persistence
 	editableFields map[string]bool // map of editable field names, empty means all are editable
+	// revealPrincipals may see the values of sensitive flags
+	revealPrincipals map[string]bool
+	broadcaster      *broadcast.Broadcaster
+	// onRead is called with the keys read by Get, GetAll and Evaluate, if set
+	onRead func(keys ...string)
 }
 
 // parseEditableFields parses a comma-separated list of field names and returns a map
@@ -36,7 +48,10 @@ func parseEditableFields(editableFieldsStr string) map[string]bool {
 	return editableFields
 }
 
-func NewFeatureService(p persistence.Persistence, editableFieldsStr string) (*FeatureService, error) {
+// NewFeatureService creates the feature service. revealStr lists the principals that may see the values of
+// sensitive flags, in the same format as the editable fields. The broadcaster is optional; without it Watch
+// is unavailable.
+func NewFeatureService(p persistence.Persistence, editableFieldsStr string, revealStr string, broadcaster *broadcast.Broadcaster) (*FeatureService, error) {
 	err := localmetrics.New()
 	if err != nil {
 		slog.Error("Failed to initialize local metrics", "error", err)
@@ -51,12 +66,26 @@ func NewFeatureService(p persistence.Persistence, editableFieldsStr string) (*Fe
 		slog.Info("All fields are editable (no restrictions)")
 	}
 
+	revealPrincipals := parseEditableFields(revealStr)
+	if len(revealPrincipals) > 0 {
+		slog.Info("Principals allowed to reveal sensitive values configured", "count", len(revealPrincipals))
+	}
+
 	return &FeatureService{
-		persistence:    p,
-		editableFields: editableFields,
+		persistence:      p,
+		editableFields:   editableFields,
+		revealPrincipals: revealPrincipals,
+		broadcaster:      broadcaster,
 	}, nil
 }
 
+// read reports the keys read by a client to onRead
+func (fs *FeatureService) read(keys ...string) {
+	if fs.onRead != nil && len(keys) > 0 {
+		fs.onRead(keys...)
+	}
+}
+
 // isEditable checks if a field is editable
 func (fs *FeatureService) isEditable(key string) bool {
 	// If editableFields is empty, all fields are editable
@@ -67,6 +96,155 @@ func (fs *FeatureService) isEditable(key string) bool {
 	return fs.editableFields[key]
 }
 
+// restricted reports whether the editable keys apply to the caller, the roles of the policy
+// replace them
+func (fs *FeatureService) restricted(ctx context.Context) bool {
+	return len(fs.editableFields) > 0 && !auth.PermissionsFromContext(ctx).FromPolicy()
+}
+
+// editable reports whether the caller may change the key, by the roles of the policy or else by
+// the editable keys
+func (fs *FeatureService) editable(ctx context.Context, key string) bool {
+	if permissions := auth.PermissionsFromContext(ctx); permissions.FromPolicy() {
+		return permissions.Allows("Set", key)
+	}
+	return fs.isEditable(key)
+}
+
+// find looks up the stored key value and reports whether it exists
+func (fs *FeatureService) find(ctx context.Context, key string) (persistence.KeyValue, bool, error) {
+	values, err := fs.persistence.GetAll(ctx)
+	if err != nil {
+		return persistence.KeyValue{}, false, err
+	}
+	for _, kv := range values {
+		if kv.Key == key {
+			return kv, true, nil
+		}
+	}
+	return persistence.KeyValue{}, false, nil
+}
+
+// keyValue converts a stored key value for the caller, withholding the values of sensitive flags
+func (fs *FeatureService) keyValue(ctx context.Context, kv persistence.KeyValue) *featurev1.KeyValue {
+	redacted := fs.redacted(ctx, kv.Sensitive)
+	if redacted {
+		kv = redact(kv)
+	}
+	return &featurev1.KeyValue{
+		Key:           kv.Key,
+		Value:         kv.Value,
+		Editable:      fs.editable(ctx, kv.Key),
+		Type:          toProtoType(kv.Type),
+		Constraints:   toProtoConstraints(kv.Constraints),
+		Metadata:      toProtoMetadata(kv.Metadata),
+		Rules:         toProtoRules(kv.Rules),
+		Rollout:       toProtoRollout(kv.Rollout),
+		Prerequisites: toProtoPrerequisites(kv.Prerequisites),
+		OffValue:      kv.OffValue,
+		Revision:      kv.Revision,
+		Redacted:      redacted,
+	}
+}
+
+// typedKeyValue converts a request into the stored representation
+func typedKeyValue(kv *featurev1.KeyValue) (persistence.KeyValue, error) {
+	valueType, err := fromProtoType(kv.Type)
+	if err != nil {
+		return persistence.KeyValue{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return persistence.KeyValue{
+		Key:         kv.Key,
+		Value:       kv.Value,
+		Type:        valueType,
+		Constraints: fromProtoConstraints(kv.Constraints),
+		Revision:    kv.Revision,
+	}, nil
+}
+
+// writeError maps a failed write, a revision mismatch means someone else changed the key in the meantime
+func writeError(key string, err error) error {
+	if errors.Is(err, persistence.ErrRevisionMismatch) {
+		return status.Errorf(codes.FailedPrecondition, "'%s' was changed by someone else", key)
+	}
+	return err
+}
+
+// stampMetadata applies the requested description, owner, tags, expiry and lifecycle to the stored
+// metadata and records when and by whom the key was changed
+func stampMetadata(ctx context.Context, stored persistence.Metadata, requested *featurev1.Metadata) persistence.Metadata {
+	metadata := stored
+	if requested != nil {
+		metadata.Description = requested.Description
+		metadata.Owner = requested.Owner
+		metadata.Tags = requested.Tags
+		metadata.ExpiresAt = time.Time{}
+		if requested.ExpiresAt != nil {
+			metadata.ExpiresAt = requested.ExpiresAt.AsTime().UTC()
+		}
+		metadata.Lifecycle = lifecycles[requested.Lifecycle]
+	}
+	now := time.Now().UTC()
+	if metadata.CreatedAt.IsZero() {
+		metadata.CreatedAt = now
+	}
+	metadata.UpdatedAt = now
+	metadata.LastModifiedBy = auth.PrincipalFromContext(ctx)
+	return metadata
+}
+
+// validate checks type, constraints and value and reports violations as InvalidArgument
+func validate(ctx context.Context, kv persistence.KeyValue) error {
+	if err := validateSpec(kv.Type, kv.Constraints); err != nil {
+		slog.WarnContext(ctx, "Invalid type or constraints", "key", kv.Key, "type", kv.Type, "error", err)
+		return status.Errorf(codes.InvalidArgument, "invalid definition of '%s': %v", kv.Key, err)
+	}
+	if err := validateValue(kv); err != nil {
+		slog.WarnContext(ctx, "Invalid value", "key", kv.Key, "type", kv.Type, "error", err)
+		return status.Errorf(codes.InvalidArgument, "invalid value for '%s': %v", kv.Key, err)
+	}
+	// rules and rollouts serve values too, so they have to fit the type as well
+	for i, rule := range kv.Rules {
+		served := kv
+		served.Value = rule.Value
+		if err := validateValue(served); err != nil {
+			slog.WarnContext(ctx, "Invalid rule value", "key", kv.Key, "rule", i, "type", kv.Type, "error", err)
+			return status.Errorf(codes.InvalidArgument, "invalid value in rule %d of '%s': %v", i+1, kv.Key, err)
+		}
+		if err := validateVariants(kv, rule.Rollout); err != nil {
+			slog.WarnContext(ctx, "Invalid variant value", "key", kv.Key, "rule", i, "type", kv.Type, "error", err)
+			return status.Errorf(codes.InvalidArgument, "invalid variant in rule %d of '%s': %v", i+1, kv.Key, err)
+		}
+	}
+	if err := validateVariants(kv, kv.Rollout); err != nil {
+		slog.WarnContext(ctx, "Invalid variant value", "key", kv.Key, "type", kv.Type, "error", err)
+		return status.Errorf(codes.InvalidArgument, "invalid variant of '%s': %v", kv.Key, err)
+	}
+	if kv.OffValue != "" {
+		served := kv
+		served.Value = kv.OffValue
+		if err := validateValue(served); err != nil {
+			slog.WarnContext(ctx, "Invalid off value", "key", kv.Key, "type", kv.Type, "error", err)
+			return status.Errorf(codes.InvalidArgument, "invalid off value of '%s': %v", kv.Key, err)
+		}
+	}
+	return nil
+}
+
+func validateVariants(kv persistence.KeyValue, rollout *persistence.Rollout) error {
+	if rollout == nil {
+		return nil
+	}
+	for _, variant := range rollout.Variants {
+		served := kv
+		served.Value = variant.Value
+		if err := validateValue(served); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (fs *FeatureService) GetAll(empty *emptypb.Empty, stream grpc.ServerStreamingServer[featurev1.KeyValue]) error {
 	ctx, span := otel.Tracer("feature/service").Start(stream.Context(), "GetAll")
 	defer span.End()
@@ -76,15 +254,16 @@ func (fs *FeatureService) GetAll(empty *emptypb.Empty, stream grpc.ServerStreami
 		return err
 	}
 	for _, kv := range values {
-		err := stream.Send(&featurev1.KeyValue{
-			Key:      kv.Key,
-			Value:    kv.Value,
-			Editable: fs.isEditable(kv.Key),
-		})
+		err := stream.Send(fs.keyValue(ctx, kv))
 		if err != nil {
 			return err
 		}
 	}
+	keys := make([]string, 0, len(values))
+	for _, kv := range values {
+		keys = append(keys, kv.Key)
+	}
+	fs.read(keys...)
 	count := len(values)
 	localmetrics.ActiveGauge().Record(ctx, int64(count))
 	localmetrics.GetAllCounter().Add(ctx, 1)
@@ -97,14 +276,20 @@ func (fs *FeatureService) PreSet(ctx context.Context, kv *featurev1.KeyValue) (*
 	ctx, span := otel.Tracer("feature/service").Start(ctx, "PreSet")
 	defer span.End()
 
-	err := fs.persistence.PreSet(ctx, persistence.KeyValue{
-		Key:   kv.Key,
-		Value: kv.Value,
-	})
+	typed, err := typedKeyValue(kv)
+	if err != nil {
+		return nil, err
+	}
+	typed.Metadata = stampMetadata(ctx, persistence.Metadata{}, kv.Metadata)
+	if err := validate(ctx, typed); err != nil {
+		return nil, err
+	}
+
+	err = fs.persistence.PreSet(ctx, typed)
 	if err != nil {
 		return nil, err
 	}
-	slog.InfoContext(ctx, "PreSet completed", "key", kv.Key, "value", kv.Value)
+	slog.InfoContext(ctx, "PreSet completed", "key", kv.Key)
 	count, err := fs.persistence.Count(ctx)
 	if err != nil {
 		return nil, err
@@ -118,50 +303,74 @@ func (fs *FeatureService) Set(ctx context.Context, kv *featurev1.KeyValue) (*emp
 	ctx, span := otel.Tracer("feature/service").Start(ctx, "Set")
 	defer span.End()
 
-	// If editable fields are configured (not empty), additional restrictions apply
-	if len(fs.editableFields) > 0 {
-		// Check if the field already exists by getting all fields
-		allFields, err := fs.persistence.GetAll(ctx)
-		if err != nil {
-			return nil, err
-		}
-		
-		fieldExists := false
-		for _, field := range allFields {
-			if field.Key == kv.Key {
-				fieldExists = true
-				break
-			}
-		}
-		
+	// The stored key value provides type, constraints and metadata that the request does not change
+	existing, fieldExists, err := fs.find(ctx, kv.Key)
+	if err != nil {
+		return nil, err
+	}
+	typed, err := fs.prepareSet(ctx, kv, existing, fieldExists)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fs.persistence.Set(ctx, typed)
+	if err != nil {
+		return nil, writeError(kv.Key, err)
+	}
+	slog.InfoContext(ctx, "Set completed", "key", kv.Key, "revision", kv.Revision)
+	count, err := fs.persistence.Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+	localmetrics.ActiveGauge().Record(ctx, int64(count))
+	localmetrics.SetCounter().Add(ctx, 1)
+	return &emptypb.Empty{}, nil
+}
+
+// prepareSet checks a set request against the stored key value and returns what is to be stored
+func (fs *FeatureService) prepareSet(ctx context.Context, kv *featurev1.KeyValue, existing persistence.KeyValue, fieldExists bool) (persistence.KeyValue, error) {
+	// If editable fields are configured (not empty) and no role policy applies, additional restrictions apply
+	if fs.restricted(ctx) {
 		// If field doesn't exist, creating new fields is not allowed
 		if !fieldExists {
 			slog.WarnContext(ctx, "Attempt to create new field when editable restrictions are active", "key", kv.Key)
-			return nil, status.Errorf(codes.PermissionDenied, "creating new fields is not allowed when editable restrictions are active")
+			return persistence.KeyValue{}, status.Errorf(codes.PermissionDenied, "creating new fields is not allowed when editable restrictions are active")
 		}
 		
 		// Check if the existing field is editable
 		if !fs.isEditable(kv.Key) {
 			slog.WarnContext(ctx, "Attempt to set non-editable field", "key", kv.Key)
-			return nil, status.Errorf(codes.PermissionDenied, "field '%s' is not editable", kv.Key)
+			return persistence.KeyValue{}, status.Errorf(codes.PermissionDenied, "field '%s' is not editable", kv.Key)
 		}
 	}
 
-	err := fs.persistence.Set(ctx, persistence.KeyValue{
-		Key:   kv.Key,
-		Value: kv.Value,
-	})
-	if err != nil {
-		return nil, err
+	// a conditional write fails early if the key has changed since the caller read it
+	if kv.Revision != 0 && (!fieldExists || existing.Revision != kv.Revision) {
+		slog.WarnContext(ctx, "Revision mismatch", "key", kv.Key, "revision", kv.Revision, "stored", existing.Revision)
+		return persistence.KeyValue{}, status.Errorf(codes.FailedPrecondition, "'%s' was changed by someone else, revision %d does not match %d", kv.Key, kv.Revision, existing.Revision)
 	}
-	slog.InfoContext(ctx, "Set completed", "key", kv.Key, "value", kv.Value)
-	count, err := fs.persistence.Count(ctx)
+
+	typed, err := typedKeyValue(kv)
 	if err != nil {
-		return nil, err
+		return persistence.KeyValue{}, err
 	}
-	localmetrics.ActiveGauge().Record(ctx, int64(count))
-	localmetrics.SetCounter().Add(ctx, 1)
-	return &emptypb.Empty{}, nil
+	if kv.Type == featurev1.ValueType_VALUE_TYPE_UNSPECIFIED {
+		// keep the stored type and constraints, only the value changes
+		if kv.Constraints != nil {
+			return persistence.KeyValue{}, status.Error(codes.InvalidArgument, "constraints require a type")
+		}
+		typed.Type = existing.Type
+		typed.Constraints = existing.Constraints
+	}
+	typed.Metadata = stampMetadata(ctx, existing.Metadata, kv.Metadata)
+	typed.Rules = existing.Rules
+	typed.Rollout = existing.Rollout
+	typed.Prerequisites = existing.Prerequisites
+	typed.OffValue = existing.OffValue
+	if err := validate(ctx, typed); err != nil {
+		return persistence.KeyValue{}, err
+	}
+	return typed, nil
 }
 
 func (fs *FeatureService) Get(ctx context.Context, kv *featurev1.Key) (*featurev1.Value, error) {
@@ -169,10 +378,14 @@ func (fs *FeatureService) Get(ctx context.Context, kv *featurev1.Key) (*featurev
 	defer span.End()
 
 	result, err := fs.persistence.Get(ctx, kv.Name)
+	if errors.Is(err, persistence.ErrKeyNotFound) {
+		return nil, status.Errorf(codes.NotFound, "feature '%s' not found", kv.Name)
+	}
 	if err != nil {
 		return nil, err
 	}
-	slog.InfoContext(ctx, "Get completed", "key", kv.Name, "value", result)
+	slog.InfoContext(ctx, "Get completed", "key", kv.Name, "value", logValue(result.Sensitive, result.Value))
+	fs.read(kv.Name)
 	count, err := fs.persistence.Count(ctx)
 	if err != nil {
 		return nil, err
@@ -188,13 +401,26 @@ func (fs *FeatureService) Delete(ctx context.Context, kv *featurev1.Key) (*empty
 	ctx, span := otel.Tracer("feature/service").Start(ctx, "Delete")
 	defer span.End()
 
-	// If editable fields are configured (not empty), deletion is not allowed
-	if len(fs.editableFields) > 0 {
-		slog.WarnContext(ctx, "Attempt to delete field when editable restrictions are active", "key", kv.Name)
-		return nil, status.Errorf(codes.PermissionDenied, "deleting fields is not allowed when editable restrictions are active")
+	if err := fs.checkDelete(ctx, kv.Name); err != nil {
+		return nil, err
+	}
+	// the persistence deletes missing keys without complaint, the API reports them
+	_, err := fs.persistence.Get(ctx, kv.Name)
+	if errors.Is(err, persistence.ErrKeyNotFound) {
+		return nil, status.Errorf(codes.NotFound, "feature '%s' not found", kv.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	values, err := fs.persistence.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDependents(ctx, kv.Name, evaluation.NewFlags(values), nil); err != nil {
+		return nil, err
 	}
 
-	err := fs.persistence.Delete(ctx, kv.Name)
+	err = fs.persistence.Delete(ctx, kv.Name)
 	if err != nil {
 		return nil, err
 	}
@@ -207,3 +433,392 @@ func (fs *FeatureService) Delete(ctx context.Context, kv *featurev1.Key) (*empty
 	localmetrics.DeleteCounter().Add(ctx, 1)
 	return &emptypb.Empty{}, nil
 }
+
+// checkDelete rejects deleting a key
+func (fs *FeatureService) checkDelete(ctx context.Context, key string) error {
+	// If editable fields are configured (not empty) and no role policy applies, deletion is not allowed
+	if fs.restricted(ctx) {
+		slog.WarnContext(ctx, "Attempt to delete field when editable restrictions are active", "key", key)
+		return status.Errorf(codes.PermissionDenied, "deleting fields is not allowed when editable restrictions are active")
+	}
+	return nil
+}
+
+func (fs *FeatureService) Evaluate(ctx context.Context, req *featurev1.EvaluateRequest) (*featurev1.EvaluateResponse, error) {
+	ctx, span := otel.Tracer("feature/service").Start(ctx, "Evaluate")
+	defer span.End()
+
+	values, err := fs.persistence.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	flags := evaluation.NewFlags(values)
+	kv, exists := flags[req.Key]
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "feature '%s' not found", req.Key)
+	}
+
+	ec := req.GetContext()
+	result := evaluation.EvaluateFlag(kv, evaluation.Context{
+		UserID:     ec.GetUserId(),
+		Tenant:     ec.GetTenant(),
+		Region:     ec.GetRegion(),
+		Attributes: ec.GetAttributes(),
+	}, flags)
+	slog.DebugContext(ctx, "Evaluate completed", "key", req.Key, "value", logValue(kv.Sensitive, result.Value), "reason", result.Reason, "rule", result.RuleIndex)
+	localmetrics.EvaluateCounter().Add(ctx, 1)
+	fs.read(req.Key)
+
+	reason := featurev1.Reason_REASON_DEFAULT
+	switch result.Reason {
+	case evaluation.ReasonRuleMatch:
+		reason = featurev1.Reason_REASON_RULE_MATCH
+	case evaluation.ReasonRollout:
+		reason = featurev1.Reason_REASON_ROLLOUT
+	case evaluation.ReasonPrerequisiteFailed:
+		reason = featurev1.Reason_REASON_PREREQUISITE_FAILED
+	}
+	return &featurev1.EvaluateResponse{
+		Key:       req.Key,
+		Value:     result.Value,
+		Reason:    reason,
+		RuleIndex: int32(result.RuleIndex),
+		RuleName:  result.RuleName,
+		Explanation: &featurev1.Explanation{
+			BucketBy:     result.BucketBy,
+			Bucket:       int32(result.Bucket),
+			VariantIndex: int32(result.VariantIndex),
+			Message:      result.Explain(),
+		},
+		Prerequisite: result.Prerequisite,
+	}, nil
+}
+
+func (fs *FeatureService) GetRules(ctx context.Context, key *featurev1.Key) (*featurev1.Rules, error) {
+	ctx, span := otel.Tracer("feature/service").Start(ctx, "GetRules")
+	defer span.End()
+
+	kv, exists, err := fs.find(ctx, key.Name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "feature '%s' not found", key.Name)
+	}
+	redacted := fs.redacted(ctx, kv.Sensitive)
+	if redacted {
+		kv = redact(kv)
+	}
+	return &featurev1.Rules{
+		Key:      kv.Key,
+		Rules:    toProtoRules(kv.Rules),
+		Redacted: redacted,
+	}, nil
+}
+
+// SetRules replaces the targeting rules of an existing flag
+func (fs *FeatureService) SetRules(ctx context.Context, rules *featurev1.Rules) (*emptypb.Empty, error) {
+	ctx, span := otel.Tracer("feature/service").Start(ctx, "SetRules")
+	defer span.End()
+
+	if !fs.editable(ctx, rules.Key) {
+		slog.WarnContext(ctx, "Attempt to set rules of non-editable field", "key", rules.Key)
+		return nil, status.Errorf(codes.PermissionDenied, "field '%s' is not editable", rules.Key)
+	}
+
+	kv, exists, err := fs.find(ctx, rules.Key)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "feature '%s' not found", rules.Key)
+	}
+
+	kv.Rules, err = fromProtoRules(rules.Rules)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := evaluation.ValidateRules(kv.Rules); err != nil {
+		slog.WarnContext(ctx, "Invalid rules", "key", rules.Key, "error", err)
+		return nil, status.Errorf(codes.InvalidArgument, "invalid rules for '%s': %v", rules.Key, err)
+	}
+	if err := validate(ctx, kv); err != nil {
+		return nil, err
+	}
+	kv.Metadata = stampMetadata(ctx, kv.Metadata, nil)
+
+	err = fs.persistence.Set(ctx, kv)
+	if err != nil {
+		return nil, writeError(kv.Key, err)
+	}
+	slog.InfoContext(ctx, "SetRules completed", "key", rules.Key, "rules", len(kv.Rules))
+	localmetrics.SetRulesCounter().Add(ctx, 1)
+	return &emptypb.Empty{}, nil
+}
+
+// SetRollout replaces the rollout of an existing flag
+func (fs *FeatureService) SetRollout(ctx context.Context, req *featurev1.SetRolloutRequest) (*emptypb.Empty, error) {
+	ctx, span := otel.Tracer("feature/service").Start(ctx, "SetRollout")
+	defer span.End()
+
+	if !fs.editable(ctx, req.Key) {
+		slog.WarnContext(ctx, "Attempt to set rollout of non-editable field", "key", req.Key)
+		return nil, status.Errorf(codes.PermissionDenied, "field '%s' is not editable", req.Key)
+	}
+
+	kv, exists, err := fs.find(ctx, req.Key)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "feature '%s' not found", req.Key)
+	}
+
+	kv.Rollout = fromProtoRollout(req.Rollout)
+	if err := evaluation.ValidateRollout(kv.Rollout); err != nil {
+		slog.WarnContext(ctx, "Invalid rollout", "key", req.Key, "error", err)
+		return nil, status.Errorf(codes.InvalidArgument, "invalid rollout for '%s': %v", req.Key, err)
+	}
+	if err := validate(ctx, kv); err != nil {
+		return nil, err
+	}
+	kv.Metadata = stampMetadata(ctx, kv.Metadata, nil)
+
+	err = fs.persistence.Set(ctx, kv)
+	if err != nil {
+		return nil, writeError(kv.Key, err)
+	}
+	slog.InfoContext(ctx, "SetRollout completed", "key", req.Key, "variants", len(req.GetRollout().GetVariants()))
+	localmetrics.SetRolloutCounter().Add(ctx, 1)
+	return &emptypb.Empty{}, nil
+}
+
+// History returns the recorded changes of a key, also of a deleted key
+func (fs *FeatureService) History(ctx context.Context, key *featurev1.Key) (*featurev1.HistoryResponse, error) {
+	ctx, span := otel.Tracer("feature/service").Start(ctx, "History")
+	defer span.End()
+
+	entries, err := fs.persistence.History(ctx, key.Name)
+	if err != nil {
+		return nil, err
+	}
+	response := &featurev1.HistoryResponse{}
+	for _, entry := range entries {
+		protoEntry := toProtoHistoryEntry(entry)
+		if fs.redacted(ctx, entry.Sensitive) {
+			protoEntry.OldValue, protoEntry.NewValue = "", ""
+			protoEntry.Redacted = true
+		}
+		response.Entries = append(response.Entries, protoEntry)
+	}
+	slog.DebugContext(ctx, "History completed", "key", key.Name, "entries", len(entries))
+	localmetrics.HistoryCounter().Add(ctx, 1)
+	return response, nil
+}
+
+// Rollback restores the value a key had after the change with the given revision. Rolling back to
+// a deletion deletes the key. The rollback goes through Set and Delete, so it is subject to the same
+// checks and is recorded in the history itself.
+func (fs *FeatureService) Rollback(ctx context.Context, req *featurev1.RollbackRequest) (*emptypb.Empty, error) {
+	ctx, span := otel.Tracer("feature/service").Start(ctx, "Rollback")
+	defer span.End()
+
+	entries, err := fs.persistence.History(ctx, req.Key)
+	if err != nil {
+		return nil, err
+	}
+	index := slices.IndexFunc(entries, func(entry persistence.HistoryEntry) bool {
+		return entry.Revision == req.Revision
+	})
+	if index < 0 {
+		return nil, status.Errorf(codes.NotFound, "revision %d of '%s' not found", req.Revision, req.Key)
+	}
+	entry := entries[index]
+
+	slog.InfoContext(ctx, "Rolling back", "key", req.Key, "revision", req.Revision, "action", entry.Action)
+	if entry.Action == persistence.ActionDelete {
+		_, err = fs.Delete(ctx, &featurev1.Key{Name: req.Key})
+	} else {
+		_, err = fs.Set(ctx, &featurev1.KeyValue{Key: req.Key, Value: entry.NewValue})
+	}
+	if err != nil {
+		return nil, err
+	}
+	localmetrics.RollbackCounter().Add(ctx, 1)
+	return &emptypb.Empty{}, nil
+}
+
+// Batch applies sets and deletes atomically. Every operation is checked like the single call it
+// stands for, and if one of them is rejected nothing is changed.
+func (fs *FeatureService) Batch(ctx context.Context, req *featurev1.BatchRequest) (*emptypb.Empty, error) {
+	ctx, span := otel.Tracer("feature/service").Start(ctx, "Batch")
+	defer span.End()
+
+	if len(req.Operations) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "batch has no operations")
+	}
+
+	values, err := fs.persistence.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stored := evaluation.NewFlags(values)
+
+	operations := make([]persistence.Operation, 0, len(req.Operations))
+	seen := make(map[string]bool, len(req.Operations))
+	for i, op := range req.Operations {
+		var operation persistence.Operation
+		var err error
+		switch o := op.Operation.(type) {
+		case *featurev1.Operation_Set:
+			existing, exists := stored[o.Set.GetKey()]
+			operation.KeyValue, err = fs.prepareSet(ctx, o.Set, existing, exists)
+		case *featurev1.Operation_Delete:
+			operation = persistence.Operation{KeyValue: persistence.KeyValue{Key: o.Delete.GetName()}, Delete: true}
+			err = fs.checkDelete(ctx, o.Delete.GetName())
+		default:
+			err = status.Error(codes.InvalidArgument, "operation is neither set nor delete")
+		}
+		if err == nil && seen[operation.KeyValue.Key] {
+			// the outcome would depend on the order, which is easy to get wrong
+			err = status.Errorf(codes.InvalidArgument, "'%s' is changed more than once", operation.KeyValue.Key)
+		}
+		if err != nil {
+			// keep the code of the failed check and tell which operation failed
+			s := status.Convert(err)
+			return nil, status.Errorf(s.Code(), "operation %d: %s", i+1, s.Message())
+		}
+		seen[operation.KeyValue.Key] = true
+		operations = append(operations, operation)
+	}
+	// prerequisites may only go together with the flags that depend on them
+	deleted := make(map[string]bool)
+	for _, operation := range operations {
+		if operation.Delete {
+			deleted[operation.KeyValue.Key] = true
+		}
+	}
+	for i, operation := range operations {
+		if !operation.Delete {
+			continue
+		}
+		if err := checkDependents(ctx, operation.KeyValue.Key, stored, deleted); err != nil {
+			s := status.Convert(err)
+			return nil, status.Errorf(s.Code(), "operation %d: %s", i+1, s.Message())
+		}
+	}
+
+	err = fs.persistence.Batch(ctx, operations)
+	if errors.Is(err, persistence.ErrRevisionMismatch) {
+		return nil, status.Error(codes.FailedPrecondition, "a key of the batch was changed by someone else")
+	}
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Batch completed", "operations", len(operations))
+	count, err := fs.persistence.Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+	localmetrics.ActiveGauge().Record(ctx, int64(count))
+	localmetrics.BatchCounter().Add(ctx, 1)
+	return &emptypb.Empty{}, nil
+}
+
+func (fs *FeatureService) Watch(req *featurev1.WatchRequest, stream grpc.ServerStreamingServer[featurev1.WatchEvent]) error {
+	ctx, span := otel.Tracer("feature/service").Start(stream.Context(), "Watch")
+	defer span.End()
+
+	if fs.broadcaster == nil {
+		return status.Error(codes.Unimplemented, "watch is not available")
+	}
+
+	// subscribe before taking the snapshot so that no change can slip through in between
+	sub, backlog, resumed := fs.broadcaster.Subscribe(req.Revision)
+	defer sub.Close()
+
+	localmetrics.WatchCounter().Add(ctx, 1)
+	slog.InfoContext(ctx, "Watch started", "revision", req.Revision, "resumed", resumed)
+
+	if resumed {
+		for _, event := range backlog {
+			if err := stream.Send(fs.watchEvent(ctx, event)); err != nil {
+				return err
+			}
+		}
+	} else {
+		values, err := fs.persistence.GetAll(ctx)
+		if err != nil {
+			return err
+		}
+		for _, kv := range values {
+			err := stream.Send(&featurev1.WatchEvent{
+				Type:     featurev1.EventType_EVENT_TYPE_SNAPSHOT,
+				KeyValue: fs.keyValue(ctx, kv),
+				Revision: sub.Revision(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	synced := sub.Revision()
+	if len(backlog) > 0 {
+		synced = backlog[len(backlog)-1].Revision
+	}
+	err := stream.Send(&featurev1.WatchEvent{
+		Type:     featurev1.EventType_EVENT_TYPE_SYNCED,
+		Revision: synced,
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "Watch ended", "revision", synced)
+			return nil
+		case event, ok := <-sub.Events():
+			if !ok {
+				if sub.Overflowed() {
+					slog.WarnContext(ctx, "Watcher fell behind, closing stream", "revision", synced)
+					return status.Errorf(codes.ResourceExhausted, "watcher fell behind, resume from revision %d", synced)
+				}
+				return nil
+			}
+			if event.Revision <= synced {
+				// already delivered as part of the backlog
+				continue
+			}
+			if err := stream.Send(fs.watchEvent(ctx, event)); err != nil {
+				return err
+			}
+			synced = event.Revision
+		}
+	}
+}
+
+// watchEvent converts a broadcast event into its protobuf representation for the watcher
+func (fs *FeatureService) watchEvent(ctx context.Context, event broadcast.Event) *featurev1.WatchEvent {
+	eventType := featurev1.EventType_EVENT_TYPE_UNSPECIFIED
+	switch event.Type {
+	case notifier.ActionCreate:
+		eventType = featurev1.EventType_EVENT_TYPE_CREATE
+	case notifier.ActionUpdate:
+		eventType = featurev1.EventType_EVENT_TYPE_UPDATE
+	case notifier.ActionDelete:
+		eventType = featurev1.EventType_EVENT_TYPE_DELETE
+	}
+	return &featurev1.WatchEvent{
+		Type: eventType,
+		KeyValue: &featurev1.KeyValue{
+			Key:      event.Key,
+			Value:    event.Value,
+			Editable: fs.editable(ctx, event.Key),
+			Redacted: event.Redacted,
+		},
+		Revision: event.Revision,
+	}
+}
@@ -4,12 +4,20 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/dkrizic/feature/service/notifier"
+	"github.com/dkrizic/feature/service/notifier/broadcast"
+	"github.com/dkrizic/feature/service/service/auth"
+	"github.com/dkrizic/feature/service/service/evaluation"
 	featurev1 "github.com/dkrizic/feature/service/service/feature/v1"
 	"github.com/dkrizic/feature/service/service/persistence"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type fakePersistence struct {
@@ -22,6 +30,11 @@ type fakePersistence struct {
 	deleteErr   error
 	countResult int
 	countErr    error
+	lastSet     persistence.KeyValue
+	lastDeleted string
+	history     []persistence.HistoryEntry
+	batchErr    error
+	lastBatch   []persistence.Operation
 }
 
 func (f *fakePersistence) GetAll(ctx context.Context) ([]persistence.KeyValue, error) {
@@ -33,6 +46,7 @@ func (f *fakePersistence) PreSet(ctx context.Context, kv persistence.KeyValue) e
 }
 
 func (f *fakePersistence) Set(ctx context.Context, kv persistence.KeyValue) error {
+	f.lastSet = kv
 	return f.setErr
 }
 
@@ -41,6 +55,7 @@ func (f *fakePersistence) Get(ctx context.Context, key string) (persistence.KeyV
 }
 
 func (f *fakePersistence) Delete(ctx context.Context, key string) error {
+	f.lastDeleted = key
 	return f.deleteErr
 }
 
@@ -48,6 +63,26 @@ func (f *fakePersistence) Count(ctx context.Context) (int, error) {
 	return f.countResult, f.countErr
 }
 
+func (f *fakePersistence) AppendHistory(ctx context.Context, entry persistence.HistoryEntry) error {
+	f.history = persistence.AppendEntry(f.history, entry)
+	return nil
+}
+
+func (f *fakePersistence) History(ctx context.Context, key string) ([]persistence.HistoryEntry, error) {
+	var entries []persistence.HistoryEntry
+	for _, entry := range f.history {
+		if entry.Key == key {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (f *fakePersistence) Batch(ctx context.Context, operations []persistence.Operation) error {
+	f.lastBatch = operations
+	return f.batchErr
+}
+
 type fakeServerStream struct {
 	grpc.ServerStreamingServer[featurev1.KeyValue]
 	ctx  context.Context
@@ -65,7 +100,7 @@ func TestFeatureService_GetAll_Success(t *testing.T) {
 	fp := &fakePersistence{
 		values: []persistence.KeyValue{{Key: "k1", Value: "v1"}, {Key: "k2", Value: "v2"}},
 	}
-	fs, err := NewFeatureService(fp, "")
+	fs, err := NewFeatureService(fp, "", "", nil)
 	assert.NoError(t, err)
 
 	ctx := context.Background()
@@ -81,7 +116,7 @@ func TestFeatureService_GetAll_Success(t *testing.T) {
 
 func TestFeatureService_GetAll_PersistenceError(t *testing.T) {
 	fp := &fakePersistence{getAllErr: errors.New("boom")}
-	fs, err := NewFeatureService(fp, "")
+	fs, err := NewFeatureService(fp, "", "", nil)
 	assert.NoError(t, err)
 
 	ctx := context.Background()
@@ -93,7 +128,7 @@ func TestFeatureService_GetAll_PersistenceError(t *testing.T) {
 
 func TestFeatureService_PreSet_Success(t *testing.T) {
 	fp := &fakePersistence{countResult: 1}
-	fs, err := NewFeatureService(fp, "")
+	fs, err := NewFeatureService(fp, "", "", nil)
 	assert.NoError(t, err)
 
 	ctx := context.Background()
@@ -103,7 +138,7 @@ func TestFeatureService_PreSet_Success(t *testing.T) {
 
 func TestFeatureService_PreSet_PersistenceError(t *testing.T) {
 	fp := &fakePersistence{preSetErr: errors.New("boom")}
-	fs, err := NewFeatureService(fp, "")
+	fs, err := NewFeatureService(fp, "", "", nil)
 	assert.NoError(t, err)
 
 	ctx := context.Background()
@@ -113,7 +148,7 @@ func TestFeatureService_PreSet_PersistenceError(t *testing.T) {
 
 func TestFeatureService_Set_Success(t *testing.T) {
 	fp := &fakePersistence{countResult: 1}
-	fs, err := NewFeatureService(fp, "")
+	fs, err := NewFeatureService(fp, "", "", nil)
 	assert.NoError(t, err)
 
 	ctx := context.Background()
@@ -123,7 +158,7 @@ func TestFeatureService_Set_Success(t *testing.T) {
 
 func TestFeatureService_Set_PersistenceError(t *testing.T) {
 	fp := &fakePersistence{setErr: errors.New("boom")}
-	fs, err := NewFeatureService(fp, "")
+	fs, err := NewFeatureService(fp, "", "", nil)
 	assert.NoError(t, err)
 
 	ctx := context.Background()
@@ -131,9 +166,531 @@ func TestFeatureService_Set_PersistenceError(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestFeatureService_Set_Conditional(t *testing.T) {
+	fp := &fakePersistence{
+		values:      []persistence.KeyValue{{Key: "COLOR", Value: "red", Revision: 3}},
+		countResult: 1,
+	}
+	fs, err := NewFeatureService(fp, "", "", nil)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = fs.Set(ctx, &featurev1.KeyValue{Key: "COLOR", Value: "blue", Revision: 2})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.Contains(t, err.Error(), "changed by someone else")
+
+	// a key deleted in the meantime does not match either
+	_, err = fs.Set(ctx, &featurev1.KeyValue{Key: "THEME", Value: "dark", Revision: 1})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = fs.Set(ctx, &featurev1.KeyValue{Key: "COLOR", Value: "blue", Revision: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), fp.lastSet.Revision)
+
+	// the backend detects changes that happen after the check
+	fp.setErr = persistence.ErrRevisionMismatch
+	_, err = fs.Set(ctx, &featurev1.KeyValue{Key: "COLOR", Value: "blue", Revision: 3})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestFeatureService_Set_InvalidValue(t *testing.T) {
+	fp := &fakePersistence{
+		values: []persistence.KeyValue{{Key: "BOOKING", Value: "true", Type: persistence.TypeBoolean}},
+	}
+	fs, err := NewFeatureService(fp, "", "", nil)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = fs.Set(ctx, &featurev1.KeyValue{Key: "BOOKING", Value: "ture"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, err.Error(), "BOOKING")
+}
+
+func TestFeatureService_Set_KeepsStoredType(t *testing.T) {
+	max := 10.0
+	fp := &fakePersistence{
+		values: []persistence.KeyValue{{
+			Key:         "RETRIES",
+			Value:       "3",
+			Type:        persistence.TypeInteger,
+			Constraints: &persistence.Constraints{Max: &max},
+		}},
+		countResult: 1,
+	}
+	fs, err := NewFeatureService(fp, "", "", nil)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = fs.Set(ctx, &featurev1.KeyValue{Key: "RETRIES", Value: "11"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = fs.Set(ctx, &featurev1.KeyValue{Key: "RETRIES", Value: "5"})
+	assert.NoError(t, err)
+	assert.Equal(t, persistence.TypeInteger, fp.lastSet.Type)
+	assert.Equal(t, &max, fp.lastSet.Constraints.Max)
+}
+
+func TestFeatureService_Set_ChangesType(t *testing.T) {
+	fp := &fakePersistence{
+		values:      []persistence.KeyValue{{Key: "MODE", Value: "fast"}},
+		countResult: 1,
+	}
+	fs, err := NewFeatureService(fp, "", "", nil)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = fs.Set(ctx, &featurev1.KeyValue{
+		Key:         "MODE",
+		Value:       "slow",
+		Type:        featurev1.ValueType_VALUE_TYPE_ENUM,
+		Constraints: &featurev1.Constraints{AllowedValues: []string{"fast", "slow"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, persistence.TypeEnum, fp.lastSet.Type)
+
+	// constraints that do not fit the type are rejected
+	_, err = fs.Set(ctx, &featurev1.KeyValue{
+		Key:         "MODE",
+		Value:       "slow",
+		Type:        featurev1.ValueType_VALUE_TYPE_BOOLEAN,
+		Constraints: &featurev1.Constraints{Pattern: "s.*"},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// constraints without a type are rejected
+	_, err = fs.Set(ctx, &featurev1.KeyValue{
+		Key:         "MODE",
+		Value:       "slow",
+		Constraints: &featurev1.Constraints{Pattern: "s.*"},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestFeatureService_Set_Metadata(t *testing.T) {
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	fp := &fakePersistence{
+		values: []persistence.KeyValue{{
+			Key:   "BOOKING",
+			Value: "false",
+			Metadata: persistence.Metadata{
+				Description: "Enables the booking flow",
+				Owner:       "team-checkout",
+				Tags:        []string{"checkout"},
+				CreatedAt:   created,
+				UpdatedAt:   created,
+			},
+		}},
+		countResult: 1,
+	}
+	fs, err := NewFeatureService(fp, "", "", nil)
+	assert.NoError(t, err)
+
+	// without metadata in the request description, owner and tags are kept
+	ctx := auth.WithPrincipal(context.Background(), "alice")
+	_, err = fs.Set(ctx, &featurev1.KeyValue{Key: "BOOKING", Value: "true"})
+	assert.NoError(t, err)
+	metadata := fp.lastSet.Metadata
+	assert.Equal(t, "Enables the booking flow", metadata.Description)
+	assert.Equal(t, "team-checkout", metadata.Owner)
+	assert.Equal(t, []string{"checkout"}, metadata.Tags)
+	assert.Equal(t, created, metadata.CreatedAt)
+	assert.WithinDuration(t, time.Now(), metadata.UpdatedAt, time.Minute)
+	assert.Equal(t, "alice", metadata.LastModifiedBy)
+
+	// with metadata they are replaced, the timestamps stay under control of the service
+	_, err = fs.Set(context.Background(), &featurev1.KeyValue{
+		Key:   "BOOKING",
+		Value: "true",
+		Metadata: &featurev1.Metadata{
+			Description:    "Booking v2",
+			LastModifiedBy: "mallory",
+			ExpiresAt:      timestamppb.New(created.AddDate(1, 0, 0)),
+			Lifecycle:      featurev1.Lifecycle_LIFECYCLE_TEMPORARY,
+		},
+	})
+	assert.NoError(t, err)
+	metadata = fp.lastSet.Metadata
+	assert.Equal(t, "Booking v2", metadata.Description)
+	assert.Equal(t, created.AddDate(1, 0, 0), metadata.ExpiresAt)
+	assert.Equal(t, persistence.LifecycleTemporary, metadata.Lifecycle)
+	assert.Empty(t, metadata.Owner)
+	assert.Empty(t, metadata.Tags)
+	assert.Equal(t, created, metadata.CreatedAt)
+	assert.Empty(t, metadata.LastModifiedBy)
+}
+
+func TestFeatureService_Evaluate(t *testing.T) {
+	fp := &fakePersistence{
+		values: []persistence.KeyValue{{
+			Key:   "CHECKOUT",
+			Value: "classic",
+			Rules: []persistence.Rule{{
+				Name:       "beta",
+				Value:      "new",
+				Conditions: []persistence.Condition{{Attribute: "tenant", Operator: persistence.OperatorIn, Values: []string{"acme"}}},
+			}},
+		}},
+		countResult: 1,
+	}
+	fs, err := NewFeatureService(fp, "", "", nil)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	resp, err := fs.Evaluate(ctx, &featurev1.EvaluateRequest{
+		Key:     "CHECKOUT",
+		Context: &featurev1.EvaluationContext{Tenant: "acme"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "new", resp.Value)
+	assert.Equal(t, featurev1.Reason_REASON_RULE_MATCH, resp.Reason)
+	assert.Equal(t, int32(0), resp.RuleIndex)
+	assert.Equal(t, "beta", resp.RuleName)
+
+	// no context at all falls back to the stored value
+	resp, err = fs.Evaluate(ctx, &featurev1.EvaluateRequest{Key: "CHECKOUT"})
+	assert.NoError(t, err)
+	assert.Equal(t, "classic", resp.Value)
+	assert.Equal(t, featurev1.Reason_REASON_DEFAULT, resp.Reason)
+	assert.Equal(t, int32(-1), resp.RuleIndex)
+
+	_, err = fs.Evaluate(ctx, &featurev1.EvaluateRequest{Key: "MISSING"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestFeatureService_SetRules(t *testing.T) {
+	fp := &fakePersistence{
+		values: []persistence.KeyValue{
+			{Key: "LIMIT", Value: "10", Type: persistence.TypeInteger},
+			{Key: "READONLY", Value: "x"},
+		},
+		countResult: 2,
+	}
+	fs, err := NewFeatureService(fp, "LIMIT", "", nil)
+	assert.NoError(t, err)
+
+	ctx := auth.WithPrincipal(context.Background(), "alice")
+	tenant := []*featurev1.Condition{{Attribute: "tenant", Operator: featurev1.Operator_OPERATOR_EQUALS, Values: []string{"acme"}}}
+	_, err = fs.SetRules(ctx, &featurev1.Rules{Key: "LIMIT", Rules: []*featurev1.Rule{{Conditions: tenant, Value: "20"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, "10", fp.lastSet.Value)
+	assert.Len(t, fp.lastSet.Rules, 1)
+	assert.Equal(t, persistence.OperatorEquals, fp.lastSet.Rules[0].Conditions[0].Operator)
+	assert.Equal(t, "alice", fp.lastSet.Metadata.LastModifiedBy)
+
+	// rule values must fit the type of the flag
+	_, err = fs.SetRules(ctx, &featurev1.Rules{Key: "LIMIT", Rules: []*featurev1.Rule{{Conditions: tenant, Value: "many"}}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// malformed rules are rejected
+	_, err = fs.SetRules(ctx, &featurev1.Rules{Key: "LIMIT", Rules: []*featurev1.Rule{{Value: "20"}}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = fs.SetRules(ctx, &featurev1.Rules{Key: "READONLY"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	unrestricted, err := NewFeatureService(fp, "", "", nil)
+	assert.NoError(t, err)
+	_, err = unrestricted.SetRules(ctx, &featurev1.Rules{Key: "MISSING"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestFeatureService_GetRules(t *testing.T) {
+	fp := &fakePersistence{
+		values: []persistence.KeyValue{{
+			Key:   "CHECKOUT",
+			Value: "classic",
+			Rules: []persistence.Rule{{Value: "new", Conditions: []persistence.Condition{{Attribute: "region", Operator: persistence.OperatorEquals, Values: []string{"eu"}}}}},
+		}},
+		countResult: 1,
+	}
+	fs, err := NewFeatureService(fp, "", "", nil)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	rules, err := fs.GetRules(ctx, &featurev1.Key{Name: "CHECKOUT"})
+	assert.NoError(t, err)
+	assert.Len(t, rules.Rules, 1)
+	assert.Equal(t, featurev1.Operator_OPERATOR_EQUALS, rules.Rules[0].Conditions[0].Operator)
+
+	_, err = fs.GetRules(ctx, &featurev1.Key{Name: "MISSING"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestFeatureService_Set_KeepsRules(t *testing.T) {
+	rollout := &persistence.Rollout{Variants: []persistence.Variant{{Value: "12", Percentage: 10}}}
+	rules := []persistence.Rule{{Value: "20", Conditions: []persistence.Condition{{Attribute: "tenant", Operator: persistence.OperatorEquals, Values: []string{"acme"}}}}}
+	fp := &fakePersistence{
+		values:      []persistence.KeyValue{{Key: "LIMIT", Value: "10", Rules: rules, Rollout: rollout}},
+		countResult: 1,
+	}
+	fs, err := NewFeatureService(fp, "", "", nil)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = fs.Set(ctx, &featurev1.KeyValue{Key: "LIMIT", Value: "15"})
+	assert.NoError(t, err)
+	assert.Equal(t, rules, fp.lastSet.Rules)
+	assert.Equal(t, rollout, fp.lastSet.Rollout)
+
+	// a type the rule values do not fit is rejected
+	_, err = fs.Set(ctx, &featurev1.KeyValue{
+		Key:         "LIMIT",
+		Value:       "15",
+		Type:        featurev1.ValueType_VALUE_TYPE_INTEGER,
+		Constraints: &featurev1.Constraints{Max: float(15)},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestFeatureService_SetRollout(t *testing.T) {
+	fp := &fakePersistence{
+		values:      []persistence.KeyValue{{Key: "BOOKING", Value: "false", Type: persistence.TypeBoolean}},
+		countResult: 1,
+	}
+	fs, err := NewFeatureService(fp, "", "", nil)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = fs.SetRollout(ctx, &featurev1.SetRolloutRequest{
+		Key:     "BOOKING",
+		Rollout: &featurev1.Rollout{Variants: []*featurev1.Variant{{Value: "true", Percentage: 5}}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "false", fp.lastSet.Value)
+	assert.Equal(t, &persistence.Rollout{Variants: []persistence.Variant{{Value: "true", Percentage: 5}}}, fp.lastSet.Rollout)
+
+	// variant values must fit the type of the flag
+	_, err = fs.SetRollout(ctx, &featurev1.SetRolloutRequest{
+		Key:     "BOOKING",
+		Rollout: &featurev1.Rollout{Variants: []*featurev1.Variant{{Value: "yes", Percentage: 5}}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// percentages must not exceed 100
+	_, err = fs.SetRollout(ctx, &featurev1.SetRolloutRequest{
+		Key:     "BOOKING",
+		Rollout: &featurev1.Rollout{Variants: []*featurev1.Variant{{Value: "true", Percentage: 101}}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// no variants remove the rollout
+	_, err = fs.SetRollout(ctx, &featurev1.SetRolloutRequest{Key: "BOOKING"})
+	assert.NoError(t, err)
+	assert.Nil(t, fp.lastSet.Rollout)
+
+	_, err = fs.SetRollout(ctx, &featurev1.SetRolloutRequest{Key: "MISSING"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestFeatureService_Evaluate_Rollout(t *testing.T) {
+	fp := &fakePersistence{
+		values: []persistence.KeyValue{{
+			Key:     "BOOKING",
+			Value:   "false",
+			Rollout: &persistence.Rollout{Variants: []persistence.Variant{{Value: "true", Percentage: 100}}},
+		}},
+		countResult: 1,
+	}
+	fs, err := NewFeatureService(fp, "", "", nil)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	resp, err := fs.Evaluate(ctx, &featurev1.EvaluateRequest{
+		Key:     "BOOKING",
+		Context: &featurev1.EvaluationContext{UserId: "user-42"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "true", resp.Value)
+	assert.Equal(t, featurev1.Reason_REASON_ROLLOUT, resp.Reason)
+	assert.Equal(t, "userId", resp.Explanation.BucketBy)
+	assert.Equal(t, int32(evaluation.Bucket("BOOKING", "user-42")), resp.Explanation.Bucket)
+	assert.Equal(t, int32(0), resp.Explanation.VariantIndex)
+	assert.Contains(t, resp.Explanation.Message, "variant 1 served")
+
+	// without a user id nobody is bucketed
+	resp, err = fs.Evaluate(ctx, &featurev1.EvaluateRequest{Key: "BOOKING"})
+	assert.NoError(t, err)
+	assert.Equal(t, "false", resp.Value)
+	assert.Equal(t, int32(-1), resp.Explanation.Bucket)
+}
+
+func TestFeatureService_History(t *testing.T) {
+	changed := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	fp := &fakePersistence{
+		history: []persistence.HistoryEntry{
+			{Key: "COLOR", Revision: 1, Action: persistence.ActionCreate, NewValue: "red", Timestamp: changed, Source: "PreSet"},
+			{Key: "COLOR", Revision: 2, Action: persistence.ActionUpdate, OldValue: "red", NewValue: "blue", Actor: "alice", Timestamp: changed, Source: "Set"},
+			{Key: "THEME", Revision: 1, Action: persistence.ActionCreate, NewValue: "dark", Timestamp: changed, Source: "Set"},
+		},
+	}
+	fs, err := NewFeatureService(fp, "", "", nil)
+	assert.NoError(t, err)
+
+	resp, err := fs.History(context.Background(), &featurev1.Key{Name: "COLOR"})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Entries, 2)
+	assert.Equal(t, featurev1.EventType_EVENT_TYPE_CREATE, resp.Entries[0].Type)
+	update := resp.Entries[1]
+	assert.Equal(t, uint64(2), update.Revision)
+	assert.Equal(t, featurev1.EventType_EVENT_TYPE_UPDATE, update.Type)
+	assert.Equal(t, "red", update.OldValue)
+	assert.Equal(t, "blue", update.NewValue)
+	assert.Equal(t, "alice", update.Actor)
+	assert.Equal(t, "Set", update.Source)
+	assert.Equal(t, changed, update.Timestamp.AsTime())
+
+	resp, err = fs.History(context.Background(), &featurev1.Key{Name: "MISSING"})
+	assert.NoError(t, err)
+	assert.Empty(t, resp.Entries)
+}
+
+func TestFeatureService_Rollback(t *testing.T) {
+	fp := &fakePersistence{
+		values: []persistence.KeyValue{{Key: "COLOR", Value: "green"}},
+		history: []persistence.HistoryEntry{
+			{Key: "COLOR", Revision: 1, Action: persistence.ActionCreate, NewValue: "red"},
+			{Key: "COLOR", Revision: 2, Action: persistence.ActionDelete, OldValue: "red"},
+			{Key: "COLOR", Revision: 3, Action: persistence.ActionCreate, NewValue: "green"},
+		},
+		countResult: 1,
+	}
+	fs, err := NewFeatureService(fp, "", "", nil)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = fs.Rollback(ctx, &featurev1.RollbackRequest{Key: "COLOR", Revision: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "COLOR", fp.lastSet.Key)
+	assert.Equal(t, "red", fp.lastSet.Value)
+
+	// rolling back to a deletion deletes the key
+	_, err = fs.Rollback(ctx, &featurev1.RollbackRequest{Key: "COLOR", Revision: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "COLOR", fp.lastDeleted)
+
+	_, err = fs.Rollback(ctx, &featurev1.RollbackRequest{Key: "COLOR", Revision: 7})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestFeatureService_Rollback_ChecksLikeSet(t *testing.T) {
+	fp := &fakePersistence{
+		values: []persistence.KeyValue{{Key: "BOOKING", Value: "true", Type: persistence.TypeBoolean}},
+		history: []persistence.HistoryEntry{
+			{Key: "BOOKING", Revision: 1, Action: persistence.ActionCreate, NewValue: "yes"},
+			{Key: "BOOKING", Revision: 2, Action: persistence.ActionUpdate, OldValue: "yes", NewValue: "true"},
+		},
+		countResult: 1,
+	}
+	fs, err := NewFeatureService(fp, "OTHER", "", nil)
+	assert.NoError(t, err)
+
+	// the key is not editable
+	_, err = fs.Rollback(context.Background(), &featurev1.RollbackRequest{Key: "BOOKING", Revision: 2})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	unrestricted, err := NewFeatureService(fp, "", "", nil)
+	assert.NoError(t, err)
+	// the old value does not fit the current type
+	_, err = unrestricted.Rollback(context.Background(), &featurev1.RollbackRequest{Key: "BOOKING", Revision: 1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestFeatureService_PreSet_InvalidValue(t *testing.T) {
+	fp := &fakePersistence{countResult: 1}
+	fs, err := NewFeatureService(fp, "", "", nil)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = fs.PreSet(ctx, &featurev1.KeyValue{Key: "LIMIT", Value: "ten", Type: featurev1.ValueType_VALUE_TYPE_INTEGER})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestFeatureService_GetAll_ExposesType(t *testing.T) {
+	fp := &fakePersistence{
+		values: []persistence.KeyValue{
+			{Key: "BOOKING", Value: "true", Type: persistence.TypeBoolean},
+			{Key: "COLOR", Value: "red"},
+		},
+	}
+	fs, err := NewFeatureService(fp, "", "", nil)
+	assert.NoError(t, err)
+
+	stream := &fakeServerStream{ctx: context.Background()}
+	err = fs.GetAll(&emptypb.Empty{}, stream)
+	assert.NoError(t, err)
+	assert.Equal(t, featurev1.ValueType_VALUE_TYPE_BOOLEAN, stream.sent[0].Type)
+	assert.Equal(t, featurev1.ValueType_VALUE_TYPE_STRING, stream.sent[1].Type)
+}
+
+func TestFeatureService_GetAll_ExposesMetadata(t *testing.T) {
+	updated := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	fp := &fakePersistence{
+		values: []persistence.KeyValue{
+			{Key: "BOOKING", Value: "true", Metadata: persistence.Metadata{Owner: "team-checkout", UpdatedAt: updated, LastModifiedBy: "alice"}},
+			{Key: "COLOR", Value: "red"},
+		},
+	}
+	fs, err := NewFeatureService(fp, "", "", nil)
+	assert.NoError(t, err)
+
+	stream := &fakeServerStream{ctx: context.Background()}
+	err = fs.GetAll(&emptypb.Empty{}, stream)
+	assert.NoError(t, err)
+	assert.Equal(t, "team-checkout", stream.sent[0].Metadata.Owner)
+	assert.Equal(t, "alice", stream.sent[0].Metadata.LastModifiedBy)
+	assert.Equal(t, updated, stream.sent[0].Metadata.UpdatedAt.AsTime())
+	assert.Nil(t, stream.sent[0].Metadata.CreatedAt)
+	assert.Nil(t, stream.sent[1].Metadata)
+}
+
+func TestFeatureService_GetAll_RedactsSensitive(t *testing.T) {
+	fp := &fakePersistence{
+		values: []persistence.KeyValue{
+			{Key: "API_KEY", Value: "s3cr3t", Sensitive: true, Rules: []persistence.Rule{{Name: "beta", Value: "b3ta"}}},
+			{Key: "COLOR", Value: "red"},
+		},
+	}
+	fs, err := NewFeatureService(fp, "", "alice", nil)
+	assert.NoError(t, err)
+
+	for principal, revealed := range map[string]bool{"": false, "bob": false, "alice": true} {
+		stream := &fakeServerStream{ctx: auth.WithPrincipal(context.Background(), principal)}
+		assert.NoError(t, fs.GetAll(&emptypb.Empty{}, stream))
+		assert.Equal(t, !revealed, stream.sent[0].Redacted, principal)
+		assert.Equal(t, revealed, stream.sent[0].Value == "s3cr3t", principal)
+		assert.Equal(t, revealed, stream.sent[0].Rules[0].Value == "b3ta", principal)
+		assert.Equal(t, "red", stream.sent[1].Value)
+		assert.False(t, stream.sent[1].Redacted)
+	}
+	// the stored rules are not touched
+	assert.Equal(t, "b3ta", fp.values[0].Rules[0].Value)
+}
+
+func TestFeatureService_History_RedactsSensitive(t *testing.T) {
+	fp := &fakePersistence{
+		history: []persistence.HistoryEntry{
+			{Key: "API_KEY", Revision: 2, Action: persistence.ActionUpdate, OldValue: "old", NewValue: "new", Actor: "alice", Sensitive: true},
+		},
+	}
+	fs, err := NewFeatureService(fp, "", "alice", nil)
+	assert.NoError(t, err)
+
+	resp, err := fs.History(auth.WithPrincipal(context.Background(), "bob"), &featurev1.Key{Name: "API_KEY"})
+	assert.NoError(t, err)
+	assert.True(t, resp.Entries[0].Redacted)
+	assert.Empty(t, resp.Entries[0].OldValue)
+	assert.Empty(t, resp.Entries[0].NewValue)
+	assert.Equal(t, "alice", resp.Entries[0].Actor)
+
+	resp, err = fs.History(auth.WithPrincipal(context.Background(), "alice"), &featurev1.Key{Name: "API_KEY"})
+	assert.NoError(t, err)
+	assert.False(t, resp.Entries[0].Redacted)
+	assert.Equal(t, "new", resp.Entries[0].NewValue)
+}
+
 func TestFeatureService_Get_Found(t *testing.T) {
 	fp := &fakePersistence{getResult: persistence.KeyValue{Key: "k1", Value: "v1"}, countResult: 1}
-	fs, err := NewFeatureService(fp, "")
+	fs, err := NewFeatureService(fp, "", "", nil)
 	assert.NoError(t, err)
 
 	ctx := context.Background()
@@ -144,7 +701,7 @@ func TestFeatureService_Get_Found(t *testing.T) {
 
 func TestFeatureService_Get_Error(t *testing.T) {
 	fp := &fakePersistence{getErr: errors.New("boom")}
-	fs, err := NewFeatureService(fp, "")
+	fs, err := NewFeatureService(fp, "", "", nil)
 	assert.NoError(t, err)
 
 	ctx := context.Background()
@@ -152,9 +709,19 @@ func TestFeatureService_Get_Error(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestFeatureService_Get_NotFound(t *testing.T) {
+	fp := &fakePersistence{getErr: persistence.ErrKeyNotFound}
+	fs, err := NewFeatureService(fp, "", "", nil)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = fs.Get(ctx, &featurev1.Key{Name: "k1"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
 func TestFeatureService_Delete_Success(t *testing.T) {
 	fp := &fakePersistence{countResult: 0}
-	fs, err := NewFeatureService(fp, "")
+	fs, err := NewFeatureService(fp, "", "", nil)
 	assert.NoError(t, err)
 
 	ctx := context.Background()
@@ -162,9 +729,20 @@ func TestFeatureService_Delete_Success(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestFeatureService_Delete_NotFound(t *testing.T) {
+	fp := &fakePersistence{getErr: persistence.ErrKeyNotFound}
+	fs, err := NewFeatureService(fp, "", "", nil)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = fs.Delete(ctx, &featurev1.Key{Name: "k1"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Empty(t, fp.lastDeleted)
+}
+
 func TestFeatureService_Delete_Error(t *testing.T) {
 	fp := &fakePersistence{deleteErr: errors.New("boom")}
-	fs, err := NewFeatureService(fp, "")
+	fs, err := NewFeatureService(fp, "", "", nil)
 	assert.NoError(t, err)
 
 	ctx := context.Background()
@@ -178,7 +756,7 @@ func TestFeatureService_EditableFields_AllEditable(t *testing.T) {
 		values: []persistence.KeyValue{{Key: "k1", Value: "v1"}, {Key: "k2", Value: "v2"}},
 	}
 	// Empty string means all fields are editable
-	fs, err := NewFeatureService(fp, "")
+	fs, err := NewFeatureService(fp, "", "", nil)
 	assert.NoError(t, err)
 
 	ctx := context.Background()
@@ -199,7 +777,7 @@ func TestFeatureService_EditableFields_RestrictedList(t *testing.T) {
 		},
 	}
 	// Only EDITABLE_FIELD can be edited
-	fs, err := NewFeatureService(fp, "EDITABLE_FIELD")
+	fs, err := NewFeatureService(fp, "EDITABLE_FIELD", "", nil)
 	assert.NoError(t, err)
 
 	ctx := context.Background()
@@ -228,7 +806,7 @@ func TestFeatureService_EditableFields_MultipleAllowed(t *testing.T) {
 		},
 	}
 	// FIELD1 and FIELD2 can be edited, FIELD3 cannot
-	fs, err := NewFeatureService(fp, "FIELD1,FIELD2")
+	fs, err := NewFeatureService(fp, "FIELD1,FIELD2", "", nil)
 	assert.NoError(t, err)
 
 	ctx := context.Background()
@@ -257,7 +835,7 @@ func TestFeatureService_Set_NonEditableField_Denied(t *testing.T) {
 		countResult: 1,
 	}
 	// Only ALLOWED_FIELD can be edited
-	fs, err := NewFeatureService(fp, "ALLOWED_FIELD")
+	fs, err := NewFeatureService(fp, "ALLOWED_FIELD", "", nil)
 	assert.NoError(t, err)
 
 	ctx := context.Background()
@@ -277,7 +855,7 @@ func TestFeatureService_Set_EditableField_Allowed(t *testing.T) {
 		countResult: 1,
 	}
 	// Only ALLOWED_FIELD can be edited
-	fs, err := NewFeatureService(fp, "ALLOWED_FIELD")
+	fs, err := NewFeatureService(fp, "ALLOWED_FIELD", "", nil)
 	assert.NoError(t, err)
 
 	ctx := context.Background()
@@ -290,7 +868,7 @@ func TestFeatureService_Set_EditableField_Allowed(t *testing.T) {
 func TestFeatureService_PreSet_BypassesEditableCheck(t *testing.T) {
 	fp := &fakePersistence{countResult: 1}
 	// Only ALLOWED_FIELD can be edited
-	fs, err := NewFeatureService(fp, "ALLOWED_FIELD")
+	fs, err := NewFeatureService(fp, "ALLOWED_FIELD", "", nil)
 	assert.NoError(t, err)
 
 	ctx := context.Background()
@@ -306,7 +884,7 @@ func TestFeatureService_Set_CreateNewField_Denied(t *testing.T) {
 		countResult: 1,
 	}
 	// Only ALLOWED_FIELD can be edited (creating new fields not allowed)
-	fs, err := NewFeatureService(fp, "ALLOWED_FIELD")
+	fs, err := NewFeatureService(fp, "ALLOWED_FIELD", "", nil)
 	assert.NoError(t, err)
 
 	ctx := context.Background()
@@ -323,7 +901,7 @@ func TestFeatureService_Set_CreateNewField_AllowedWhenNoRestrictions(t *testing.
 		countResult: 1,
 	}
 	// No restrictions (empty editable list)
-	fs, err := NewFeatureService(fp, "")
+	fs, err := NewFeatureService(fp, "", "", nil)
 	assert.NoError(t, err)
 
 	ctx := context.Background()
@@ -336,7 +914,7 @@ func TestFeatureService_Set_CreateNewField_AllowedWhenNoRestrictions(t *testing.
 func TestFeatureService_Delete_Denied_WhenRestrictionsActive(t *testing.T) {
 	fp := &fakePersistence{countResult: 0}
 	// Editable fields configured
-	fs, err := NewFeatureService(fp, "ALLOWED_FIELD")
+	fs, err := NewFeatureService(fp, "ALLOWED_FIELD", "", nil)
 	assert.NoError(t, err)
 
 	ctx := context.Background()
@@ -350,7 +928,7 @@ func TestFeatureService_Delete_Denied_WhenRestrictionsActive(t *testing.T) {
 func TestFeatureService_Delete_Allowed_WhenNoRestrictions(t *testing.T) {
 	fp := &fakePersistence{countResult: 0}
 	// No restrictions
-	fs, err := NewFeatureService(fp, "")
+	fs, err := NewFeatureService(fp, "", "", nil)
 	assert.NoError(t, err)
 
 	ctx := context.Background()
@@ -359,3 +937,154 @@ func TestFeatureService_Delete_Allowed_WhenNoRestrictions(t *testing.T) {
 	_, err = fs.Delete(ctx, &featurev1.Key{Name: "ANY_FIELD"})
 	assert.NoError(t, err)
 }
+
+type fakeWatchStream struct {
+	grpc.ServerStreamingServer[featurev1.WatchEvent]
+	ctx    context.Context
+	events chan *featurev1.WatchEvent
+}
+
+func (f *fakeWatchStream) Context() context.Context { return f.ctx }
+
+func (f *fakeWatchStream) Send(ev *featurev1.WatchEvent) error {
+	f.events <- ev
+	return nil
+}
+
+func set(key, value string) *featurev1.Operation {
+	return &featurev1.Operation{Operation: &featurev1.Operation_Set{Set: &featurev1.KeyValue{Key: key, Value: value}}}
+}
+
+func del(key string) *featurev1.Operation {
+	return &featurev1.Operation{Operation: &featurev1.Operation_Delete{Delete: &featurev1.Key{Name: key}}}
+}
+
+func TestFeatureService_Batch(t *testing.T) {
+	fp := &fakePersistence{
+		values:      []persistence.KeyValue{{Key: "RETRIES", Value: "3", Type: persistence.TypeInteger}, {Key: "COLOR", Value: "red"}},
+		countResult: 2,
+	}
+	fs, err := NewFeatureService(fp, "", "", nil)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = fs.Batch(ctx, &featurev1.BatchRequest{Operations: []*featurev1.Operation{set("RETRIES", "5"), set("THEME", "dark"), del("COLOR")}})
+	assert.NoError(t, err)
+	assert.Len(t, fp.lastBatch, 3)
+	assert.Equal(t, persistence.TypeInteger, fp.lastBatch[0].KeyValue.Type)
+	assert.Equal(t, "5", fp.lastBatch[0].KeyValue.Value)
+	assert.Equal(t, "THEME", fp.lastBatch[1].KeyValue.Key)
+	assert.Equal(t, persistence.Operation{KeyValue: persistence.KeyValue{Key: "COLOR"}, Delete: true}, fp.lastBatch[2])
+
+	// the backend detects changes that happen after the checks
+	fp.batchErr = persistence.ErrRevisionMismatch
+	_, err = fs.Batch(ctx, &featurev1.BatchRequest{Operations: []*featurev1.Operation{set("COLOR", "blue")}})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestFeatureService_Batch_Rejected(t *testing.T) {
+	fp := &fakePersistence{
+		values: []persistence.KeyValue{{Key: "RETRIES", Value: "3", Type: persistence.TypeInteger}, {Key: "COLOR", Value: "red"}},
+	}
+	fs, err := NewFeatureService(fp, "", "", nil)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = fs.Batch(ctx, &featurev1.BatchRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// an invalid operation fails the whole batch and tells which one it was
+	_, err = fs.Batch(ctx, &featurev1.BatchRequest{Operations: []*featurev1.Operation{set("COLOR", "blue"), set("RETRIES", "many")}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, err.Error(), "operation 2:")
+
+	_, err = fs.Batch(ctx, &featurev1.BatchRequest{Operations: []*featurev1.Operation{set("COLOR", "blue"), del("COLOR")}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, err.Error(), "more than once")
+
+	_, err = fs.Batch(ctx, &featurev1.BatchRequest{Operations: []*featurev1.Operation{{}}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Nil(t, fp.lastBatch)
+}
+
+func TestFeatureService_Batch_EditableRestrictions(t *testing.T) {
+	fp := &fakePersistence{
+		values: []persistence.KeyValue{{Key: "COLOR", Value: "red"}, {Key: "SIZE", Value: "large"}},
+	}
+	fs, err := NewFeatureService(fp, "COLOR", "", nil)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = fs.Batch(ctx, &featurev1.BatchRequest{Operations: []*featurev1.Operation{set("COLOR", "blue"), set("SIZE", "small")}})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Contains(t, err.Error(), "operation 2: field 'SIZE' is not editable")
+
+	_, err = fs.Batch(ctx, &featurev1.BatchRequest{Operations: []*featurev1.Operation{set("COLOR", "blue"), del("SIZE")}})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = fs.Batch(ctx, &featurev1.BatchRequest{Operations: []*featurev1.Operation{set("THEME", "dark")}})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Nil(t, fp.lastBatch)
+}
+
+func TestFeatureService_Watch_Unavailable(t *testing.T) {
+	fs, err := NewFeatureService(&fakePersistence{}, "", "", nil)
+	assert.NoError(t, err)
+
+	err = fs.Watch(&featurev1.WatchRequest{}, &fakeWatchStream{ctx: context.Background()})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+func TestFeatureService_Watch_SnapshotThenChanges(t *testing.T) {
+	fp := &fakePersistence{
+		values: []persistence.KeyValue{{Key: "k1", Value: "v1"}},
+	}
+	b := broadcast.NewBroadcaster(10)
+	fs, err := NewFeatureService(fp, "", "", b)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &fakeWatchStream{ctx: ctx, events: make(chan *featurev1.WatchEvent, 10)}
+	done := make(chan error)
+	go func() { done <- fs.Watch(&featurev1.WatchRequest{}, stream) }()
+
+	ev := <-stream.events
+	assert.Equal(t, featurev1.EventType_EVENT_TYPE_SNAPSHOT, ev.Type)
+	assert.Equal(t, "k1", ev.KeyValue.Key)
+	ev = <-stream.events
+	assert.Equal(t, featurev1.EventType_EVENT_TYPE_SYNCED, ev.Type)
+
+	assert.NoError(t, b.Notify(ctx, notifier.UpdateNotification("k1", "v2")))
+	ev = <-stream.events
+	assert.Equal(t, featurev1.EventType_EVENT_TYPE_UPDATE, ev.Type)
+	assert.Equal(t, "v2", ev.KeyValue.Value)
+	assert.Equal(t, uint64(1), ev.Revision)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestFeatureService_Watch_Resume(t *testing.T) {
+	b := broadcast.NewBroadcaster(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.NoError(t, b.Notify(ctx, notifier.CreateNotifucation("k1", "v1")))
+	assert.NoError(t, b.Notify(ctx, notifier.DeleteNotification("k1")))
+
+	fs, err := NewFeatureService(&fakePersistence{}, "", "", b)
+	assert.NoError(t, err)
+
+	stream := &fakeWatchStream{ctx: ctx, events: make(chan *featurev1.WatchEvent, 10)}
+	done := make(chan error)
+	go func() { done <- fs.Watch(&featurev1.WatchRequest{Revision: 1}, stream) }()
+
+	// only the missed delete is replayed, no snapshot
+	ev := <-stream.events
+	assert.Equal(t, featurev1.EventType_EVENT_TYPE_DELETE, ev.Type)
+	assert.Equal(t, uint64(2), ev.Revision)
+	ev = <-stream.events
+	assert.Equal(t, featurev1.EventType_EVENT_TYPE_SYNCED, ev.Type)
+	assert.Equal(t, uint64(2), ev.Revision)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
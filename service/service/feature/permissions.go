@@ -0,0 +1,21 @@
+package feature
+
+import (
+	"context"
+
+	"github.com/dkrizic/feature/service/service/auth"
+	"github.com/dkrizic/feature/service/service/feature/v1"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// GetPermissions returns the roles of the caller and the methods they allow, so that clients can
+// hide what the caller may not do. The auth interceptor enforces them.
+func (r *Router) GetPermissions(ctx context.Context, _ *emptypb.Empty) (*featurev1.Permissions, error) {
+	ctx, span := otel.Tracer("feature/service").Start(ctx, "GetPermissions")
+	defer span.End()
+
+	permissions := auth.PermissionsFromContext(ctx).ToProto()
+	permissions.Principal = auth.PrincipalFromContext(ctx)
+	return permissions, nil
+}
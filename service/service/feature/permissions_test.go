@@ -0,0 +1,85 @@
+package feature
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dkrizic/feature/service/service/auth"
+	"github.com/dkrizic/feature/service/service/feature/v1"
+	"github.com/dkrizic/feature/service/service/flagset"
+	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/dkrizic/feature/service/service/persistence/inmemory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRouter_GetPermissions(t *testing.T) {
+	ctx := auth.WithPrincipal(context.Background(), "bob")
+	r := newTestRouter(flagset.Config{})
+
+	permissions, err := r.GetPermissions(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.True(t, permissions.Unrestricted)
+	assert.Equal(t, "bob", permissions.Principal)
+
+	policy, err := auth.ParsePolicy([]byte("roles: {editor: {keys: [CHECKOUT_*]}}\nusers: {bob: [editor]}"))
+	require.NoError(t, err)
+	ctx = auth.WithPermissions(ctx, policy.Permissions("bob"))
+	permissions, err = r.GetPermissions(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.False(t, permissions.Unrestricted)
+	assert.Equal(t, []string{auth.RoleEditor}, permissions.Roles)
+	assert.Contains(t, permissions.Grants, &featurev1.Grant{Method: "Delete", Keys: []string{"CHECKOUT_*"}})
+}
+
+func TestRouter_CancelSchedule_Permissions(t *testing.T) {
+	ctx := auth.WithPrincipal(context.Background(), "bob")
+	r := newTestRouter(flagset.Config{})
+	at := timestamppb.New(time.Now().Add(time.Hour))
+	schedule, err := r.ScheduleSet(ctx, &featurev1.ScheduleSetRequest{Key: "COLOR", Value: "red", At: at})
+	require.NoError(t, err)
+
+	policy, err := auth.ParsePolicy([]byte("roles: {editor: {keys: [CHECKOUT_*]}}\nusers: {bob: [editor]}"))
+	require.NoError(t, err)
+	_, err = r.CancelSchedule(auth.WithPermissions(ctx, policy.Permissions("bob")), &featurev1.Schedule{Id: schedule.Id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = r.CancelSchedule(ctx, &featurev1.Schedule{Id: schedule.Id})
+	assert.NoError(t, err)
+}
+
+func TestFeatureService_Editable_Roles(t *testing.T) {
+	p := inmemory.NewInMemoryPersistence()
+	ctx := context.Background()
+	require.NoError(t, p.PreSet(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	require.NoError(t, p.PreSet(ctx, persistence.KeyValue{Key: "CHECKOUT_V2", Value: "false"}))
+	fs, err := NewFeatureService(p, "COLOR", "", nil)
+	require.NoError(t, err)
+
+	editable := func(ctx context.Context) map[string]bool {
+		stream := &fakeServerStream{ctx: ctx}
+		require.NoError(t, fs.GetAll(&emptypb.Empty{}, stream))
+		result := map[string]bool{}
+		for _, kv := range stream.sent {
+			result[kv.Key] = kv.Editable
+		}
+		return result
+	}
+	assert.Equal(t, map[string]bool{"COLOR": true, "CHECKOUT_V2": false}, editable(ctx))
+	_, err = fs.Set(ctx, &featurev1.KeyValue{Key: "CHECKOUT_V2", Value: "true"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// the key patterns of the role replace the editable keys
+	policy, err := auth.ParsePolicy([]byte("roles: {editor: {keys: [CHECKOUT_*]}}\nusers: {bob: [editor]}"))
+	require.NoError(t, err)
+	ctx = auth.WithPermissions(ctx, policy.Permissions("bob"))
+	assert.Equal(t, map[string]bool{"COLOR": false, "CHECKOUT_V2": true}, editable(ctx))
+	_, err = fs.Set(ctx, &featurev1.KeyValue{Key: "CHECKOUT_V2", Value: "true"})
+	assert.NoError(t, err)
+	_, err = fs.SetRules(ctx, &featurev1.Rules{Key: "COLOR"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
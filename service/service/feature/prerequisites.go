@@ -0,0 +1,80 @@
+package feature
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/dkrizic/feature/service/service/evaluation"
+	"github.com/dkrizic/feature/service/service/feature/v1"
+	"github.com/dkrizic/feature/service/telemetry/localmetrics"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// SetPrerequisites replaces the prerequisites and the off value of an existing flag
+func (fs *FeatureService) SetPrerequisites(ctx context.Context, req *featurev1.Prerequisites) (*emptypb.Empty, error) {
+	ctx, span := otel.Tracer("feature/service").Start(ctx, "SetPrerequisites")
+	defer span.End()
+
+	if !fs.editable(ctx, req.Key) {
+		slog.WarnContext(ctx, "Attempt to set prerequisites of non-editable field", "key", req.Key)
+		return nil, status.Errorf(codes.PermissionDenied, "field '%s' is not editable", req.Key)
+	}
+
+	values, err := fs.persistence.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	flags := evaluation.NewFlags(values)
+	kv, exists := flags[req.Key]
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "feature '%s' not found", req.Key)
+	}
+
+	kv.Prerequisites = fromProtoPrerequisites(req.Prerequisites)
+	kv.OffValue = req.OffValue
+	if err := evaluation.ValidatePrerequisites(req.Key, kv.Prerequisites, flags); err != nil {
+		slog.WarnContext(ctx, "Invalid prerequisites", "key", req.Key, "error", err)
+		return nil, status.Errorf(codes.InvalidArgument, "invalid prerequisites for '%s': %v", req.Key, err)
+	}
+	// the expected value has to be one the prerequisite can take
+	for _, prerequisite := range kv.Prerequisites {
+		expected := flags[prerequisite.Key]
+		expected.Value = prerequisite.Value
+		if err := validateValue(expected); err != nil {
+			slog.WarnContext(ctx, "Invalid prerequisite value", "key", req.Key, "prerequisite", prerequisite.Key, "error", err)
+			return nil, status.Errorf(codes.InvalidArgument, "invalid value for prerequisite '%s' of '%s': %v", prerequisite.Key, req.Key, err)
+		}
+	}
+	if err := validate(ctx, kv); err != nil {
+		return nil, err
+	}
+	kv.Metadata = stampMetadata(ctx, kv.Metadata, nil)
+
+	err = fs.persistence.Set(ctx, kv)
+	if err != nil {
+		return nil, writeError(kv.Key, err)
+	}
+	slog.InfoContext(ctx, "SetPrerequisites completed", "key", req.Key, "prerequisites", len(kv.Prerequisites))
+	localmetrics.SetPrerequisitesCounter().Add(ctx, 1)
+	return &emptypb.Empty{}, nil
+}
+
+// checkDependents rejects deleting a key that is a prerequisite of other flags, unless they are
+// deleted as well
+func checkDependents(ctx context.Context, key string, flags evaluation.Flags, deleted map[string]bool) error {
+	var dependents []string
+	for _, dependent := range evaluation.Dependents(key, flags) {
+		if !deleted[dependent] {
+			dependents = append(dependents, dependent)
+		}
+	}
+	if len(dependents) > 0 {
+		slog.WarnContext(ctx, "Attempt to delete a prerequisite", "key", key, "dependents", dependents)
+		return status.Errorf(codes.FailedPrecondition, "'%s' is a prerequisite of %s", key, strings.Join(dependents, ", "))
+	}
+	return nil
+}
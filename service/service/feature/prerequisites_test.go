@@ -0,0 +1,117 @@
+package feature
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/dkrizic/feature/service/service/feature/v1"
+	"github.com/dkrizic/feature/service/service/flagset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func requires(key, value string) *featurev1.Prerequisite {
+	return &featurev1.Prerequisite{Key: key, Value: value}
+}
+
+func newCheckoutRouter(t *testing.T, environments ...string) *Router {
+	r := newTestRouter(flagset.Config{}, environments...)
+	ctx := context.Background()
+	for _, key := range []string{"NEW_CHECKOUT", "NEW_CHECKOUT_V2"} {
+		_, err := r.Set(ctx, &featurev1.KeyValue{Key: key, Value: "true", Type: featurev1.ValueType_VALUE_TYPE_BOOLEAN})
+		require.NoError(t, err)
+	}
+	_, err := r.SetPrerequisites(ctx, &featurev1.Prerequisites{Key: "NEW_CHECKOUT_V2", Prerequisites: []*featurev1.Prerequisite{requires("NEW_CHECKOUT", "true")}})
+	require.NoError(t, err)
+	return r
+}
+
+func TestRouter_Prerequisites_Evaluate(t *testing.T) {
+	ctx := context.Background()
+	r := newCheckoutRouter(t)
+
+	resp, err := r.Evaluate(ctx, &featurev1.EvaluateRequest{Key: "NEW_CHECKOUT_V2"})
+	require.NoError(t, err)
+	assert.Equal(t, "true", resp.Value)
+	assert.Equal(t, featurev1.Reason_REASON_DEFAULT, resp.Reason)
+
+	_, err = r.Set(ctx, &featurev1.KeyValue{Key: "NEW_CHECKOUT", Value: "false"})
+	require.NoError(t, err)
+	resp, err = r.Evaluate(ctx, &featurev1.EvaluateRequest{Key: "NEW_CHECKOUT_V2"})
+	require.NoError(t, err)
+	assert.Equal(t, "false", resp.Value, "off value of a boolean flag")
+	assert.Equal(t, featurev1.Reason_REASON_PREREQUISITE_FAILED, resp.Reason)
+	assert.Equal(t, "NEW_CHECKOUT", resp.Prerequisite)
+
+	// a set keeps the prerequisites, GetAll reports them
+	_, err = r.Set(ctx, &featurev1.KeyValue{Key: "NEW_CHECKOUT_V2", Value: "true"})
+	require.NoError(t, err)
+	stream := &fakeServerStream{ctx: ctx}
+	require.NoError(t, r.GetAll(nil, stream))
+	require.Len(t, stream.sent, 2)
+	// the in-memory persistence returns the flags in no particular order
+	i := slices.IndexFunc(stream.sent, func(kv *featurev1.KeyValue) bool { return kv.Key == "NEW_CHECKOUT_V2" })
+	require.GreaterOrEqual(t, i, 0)
+	require.Len(t, stream.sent[i].Prerequisites, 1)
+	assert.Equal(t, "NEW_CHECKOUT", stream.sent[i].Prerequisites[0].Key)
+}
+
+func TestRouter_SetPrerequisites_Invalid(t *testing.T) {
+	ctx := context.Background()
+	r := newCheckoutRouter(t)
+	_, err := r.Set(ctx, &featurev1.KeyValue{Key: "THEME", Value: "dark"})
+	require.NoError(t, err)
+
+	invalid := map[string]*featurev1.Prerequisites{
+		"cycle":          {Key: "NEW_CHECKOUT", Prerequisites: []*featurev1.Prerequisite{requires("NEW_CHECKOUT_V2", "true")}},
+		"self":           {Key: "THEME", Prerequisites: []*featurev1.Prerequisite{requires("THEME", "dark")}},
+		"missing":        {Key: "THEME", Prerequisites: []*featurev1.Prerequisite{requires("OLD_CHECKOUT", "true")}},
+		"wrong type":     {Key: "THEME", Prerequisites: []*featurev1.Prerequisite{requires("NEW_CHECKOUT", "yes")}},
+		"wrong off type": {Key: "NEW_CHECKOUT", OffValue: "off"},
+	}
+	for name, req := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := r.SetPrerequisites(ctx, req)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+	_, err = r.SetPrerequisites(ctx, &featurev1.Prerequisites{Key: "NEW_CHECKOUT", Prerequisites: []*featurev1.Prerequisite{requires("NEW_CHECKOUT_V2", "true")}})
+	assert.ErrorContains(t, err, "NEW_CHECKOUT -> NEW_CHECKOUT_V2 -> NEW_CHECKOUT")
+
+	_, err = r.SetPrerequisites(ctx, &featurev1.Prerequisites{Key: "MISSING"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestRouter_Prerequisites_Delete(t *testing.T) {
+	ctx := context.Background()
+	r := newCheckoutRouter(t)
+
+	_, err := r.Delete(ctx, &featurev1.Key{Name: "NEW_CHECKOUT"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.ErrorContains(t, err, "'NEW_CHECKOUT' is a prerequisite of NEW_CHECKOUT_V2")
+	_, err = r.Batch(ctx, &featurev1.BatchRequest{Operations: []*featurev1.Operation{del("NEW_CHECKOUT")}})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// together with the dependent flag it can go
+	_, err = r.Batch(ctx, &featurev1.BatchRequest{Operations: []*featurev1.Operation{del("NEW_CHECKOUT"), del("NEW_CHECKOUT_V2")}})
+	assert.NoError(t, err)
+}
+
+func TestRouter_Promote_Prerequisites(t *testing.T) {
+	ctx := context.Background()
+	r := newCheckoutRouter(t, "staging", "prod")
+
+	req := &featurev1.PromoteRequest{Key: "NEW_CHECKOUT_V2", From: "staging", To: "prod"}
+	_, err := r.Promote(ctx, req)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err), "prerequisite missing in prod")
+
+	_, err = r.Promote(ctx, &featurev1.PromoteRequest{Key: "NEW_CHECKOUT", From: "staging", To: "prod"})
+	require.NoError(t, err)
+	resp, err := r.Promote(ctx, req)
+	require.NoError(t, err)
+	assert.Contains(t, resp.Changes, "prerequisites: changed")
+	assert.True(t, resp.Applied)
+}
@@ -0,0 +1,166 @@
+package feature
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/dkrizic/feature/service/service/evaluation"
+	"github.com/dkrizic/feature/service/service/feature/v1"
+	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/dkrizic/feature/service/telemetry/localmetrics"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// promote copies a flag of this service, the source environment, to the target service. The
+// target checks editability and the revision like Set; a dry run or a promotion without changes
+// only reports the differences.
+func (fs *FeatureService) promote(ctx context.Context, req *featurev1.PromoteRequest, target *FeatureService) (*featurev1.PromoteResponse, error) {
+	ctx, span := otel.Tracer("feature/service").Start(ctx, "Promote")
+	defer span.End()
+
+	src, exists, err := fs.find(ctx, req.Key)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "feature '%s' not found in %s", req.Key, req.From)
+	}
+	dst, targetExists, err := target.find(ctx, req.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &featurev1.PromoteResponse{
+		Source:  fs.keyValue(ctx, src),
+		Changes: changes(src, dst, targetExists, fs.redacted(ctx, src.Sensitive || dst.Sensitive)),
+	}
+	if targetExists {
+		response.Target = target.keyValue(ctx, dst)
+	}
+	if len(response.Changes) == 0 {
+		slog.InfoContext(ctx, "Promote found no changes", "key", req.Key, "from", req.From, "to", req.To)
+		return response, nil
+	}
+
+	// the target environment restricts the promotion like a Set
+	if target.restricted(ctx) {
+		if !targetExists {
+			slog.WarnContext(ctx, "Attempt to promote new field when editable restrictions are active", "key", req.Key)
+			return nil, status.Errorf(codes.PermissionDenied, "creating new fields is not allowed when editable restrictions are active")
+		}
+		if !target.isEditable(req.Key) {
+			slog.WarnContext(ctx, "Attempt to promote non-editable field", "key", req.Key)
+			return nil, status.Errorf(codes.PermissionDenied, "field '%s' is not editable", req.Key)
+		}
+	}
+	if req.Revision != 0 && (!targetExists || dst.Revision != req.Revision) {
+		slog.WarnContext(ctx, "Revision mismatch", "key", req.Key, "revision", req.Revision, "stored", dst.Revision)
+		return nil, status.Errorf(codes.FailedPrecondition, "'%s' was changed by someone else, revision %d does not match %d", req.Key, req.Revision, dst.Revision)
+	}
+
+	promoted := src
+	promoted.Revision = req.Revision
+	promoted.Sensitive = false
+	metadata := dst.Metadata
+	metadata.Description = src.Metadata.Description
+	metadata.Owner = src.Metadata.Owner
+	metadata.Tags = src.Metadata.Tags
+	metadata.ExpiresAt = src.Metadata.ExpiresAt
+	metadata.Lifecycle = src.Metadata.Lifecycle
+	promoted.Metadata = stampMetadata(ctx, metadata, nil)
+	if err := validate(ctx, promoted); err != nil {
+		return nil, err
+	}
+	if len(promoted.Prerequisites) > 0 {
+		values, err := target.persistence.GetAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := evaluation.ValidatePrerequisites(req.Key, promoted.Prerequisites, evaluation.NewFlags(values)); err != nil {
+			slog.WarnContext(ctx, "Prerequisites do not fit the target", "key", req.Key, "to", req.To, "error", err)
+			return nil, status.Errorf(codes.FailedPrecondition, "cannot promote '%s' to %s: %v", req.Key, req.To, err)
+		}
+	}
+	if req.DryRun {
+		slog.InfoContext(ctx, "Promote dry run completed", "key", req.Key, "from", req.From, "to", req.To, "changes", len(response.Changes))
+		return response, nil
+	}
+
+	if err := target.persistence.Set(ctx, promoted); err != nil {
+		return nil, writeError(req.Key, err)
+	}
+	response.Applied = true
+	slog.InfoContext(ctx, "Promote completed", "key", req.Key, "from", req.From, "to", req.To, "changes", len(response.Changes))
+	localmetrics.PromoteCounter().Add(ctx, 1)
+	return response, nil
+}
+
+// changes describes what a promotion changes in the target, e.g. `value: "red" -> "blue"`. The
+// values are left out if the caller may not see them.
+func changes(src, dst persistence.KeyValue, targetExists, redacted bool) []string {
+	var result []string
+	if !targetExists {
+		result = append(result, "new key")
+	}
+	if src.Value != dst.Value {
+		if redacted {
+			result = append(result, "value: changed")
+		} else {
+			result = append(result, fmt.Sprintf("value: %q -> %q", dst.Value, src.Value))
+		}
+	}
+	if src.Type != dst.Type {
+		result = append(result, fmt.Sprintf("type: %q -> %q", dst.Type, src.Type))
+	}
+	if !reflect.DeepEqual(src.Constraints, dst.Constraints) {
+		result = append(result, "constraints: changed")
+	}
+	if len(src.Rules) != len(dst.Rules) {
+		result = append(result, fmt.Sprintf("rules: %d -> %d", len(dst.Rules), len(src.Rules)))
+	} else if len(src.Rules) > 0 && !reflect.DeepEqual(src.Rules, dst.Rules) {
+		result = append(result, "rules: changed")
+	}
+	if !reflect.DeepEqual(src.Rollout, dst.Rollout) {
+		result = append(result, "rollout: changed")
+	}
+	if !slices.Equal(src.Prerequisites, dst.Prerequisites) {
+		result = append(result, "prerequisites: changed")
+	}
+	if src.OffValue != dst.OffValue {
+		if redacted {
+			result = append(result, "off value: changed")
+		} else {
+			result = append(result, fmt.Sprintf("off value: %q -> %q", dst.OffValue, src.OffValue))
+		}
+	}
+	if src.Metadata.Description != dst.Metadata.Description {
+		result = append(result, fmt.Sprintf("description: %q -> %q", dst.Metadata.Description, src.Metadata.Description))
+	}
+	if src.Metadata.Owner != dst.Metadata.Owner {
+		result = append(result, fmt.Sprintf("owner: %q -> %q", dst.Metadata.Owner, src.Metadata.Owner))
+	}
+	if !slices.Equal(src.Metadata.Tags, dst.Metadata.Tags) {
+		result = append(result, fmt.Sprintf("tags: %v -> %v", dst.Metadata.Tags, src.Metadata.Tags))
+	}
+	if !src.Metadata.ExpiresAt.Equal(dst.Metadata.ExpiresAt) {
+		result = append(result, fmt.Sprintf("expires: %s -> %s", formatExpiry(dst.Metadata.ExpiresAt), formatExpiry(src.Metadata.ExpiresAt)))
+	}
+	if src.Metadata.Lifecycle != dst.Metadata.Lifecycle {
+		result = append(result, fmt.Sprintf("lifecycle: %q -> %q", dst.Metadata.Lifecycle, src.Metadata.Lifecycle))
+	}
+	return result
+}
+
+// formatExpiry formats an expiry date for changes, "never" if there is none
+func formatExpiry(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Format(time.DateOnly)
+}
@@ -0,0 +1,133 @@
+package feature
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dkrizic/feature/service/service/feature/v1"
+	"github.com/dkrizic/feature/service/service/flagset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func inEnvironment(ctx context.Context, set, environment string) context.Context {
+	return metadata.NewIncomingContext(ctx, metadata.Pairs(flagset.MetadataKey, set, flagset.EnvironmentMetadataKey, environment))
+}
+
+func TestRouter_SeparatesEnvironments(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRouter(flagset.Config{}, "staging", "prod")
+
+	environments, err := r.ListEnvironments(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"staging", "prod"}, environments.Names)
+
+	_, err = r.Set(ctx, &featurev1.KeyValue{Key: "COLOR", Value: "red"})
+	require.NoError(t, err)
+	_, err = r.Set(inEnvironment(ctx, "", "prod"), &featurev1.KeyValue{Key: "COLOR", Value: "blue"})
+	require.NoError(t, err)
+
+	// the default environment is the first one
+	value, err := r.Get(inEnvironment(ctx, "", "staging"), &featurev1.Key{Name: "COLOR"})
+	require.NoError(t, err)
+	assert.Equal(t, "red", value.Name)
+	value, err = r.Get(inEnvironment(ctx, flagset.DefaultName, "prod"), &featurev1.Key{Name: "COLOR"})
+	require.NoError(t, err)
+	assert.Equal(t, "blue", value.Name)
+
+	_, err = r.Get(inEnvironment(ctx, "", "dev"), &featurev1.Key{Name: "COLOR"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRouter_Promote(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRouter(flagset.Config{}, "staging", "prod")
+	staging := inEnvironment(ctx, "", "staging")
+	prod := inEnvironment(ctx, "", "prod")
+
+	_, err := r.Set(staging, &featurev1.KeyValue{Key: "COLOR", Value: "red", Type: featurev1.ValueType_VALUE_TYPE_STRING,
+		Metadata: &featurev1.Metadata{Owner: "shop", Lifecycle: featurev1.Lifecycle_LIFECYCLE_TEMPORARY}})
+	require.NoError(t, err)
+	_, err = r.SetRollout(staging, &featurev1.SetRolloutRequest{Key: "COLOR", Rollout: &featurev1.Rollout{
+		Variants: []*featurev1.Variant{{Value: "blue", Percentage: 10}},
+	}})
+	require.NoError(t, err)
+
+	// a new key is previewed without being written
+	preview, err := r.Promote(ctx, &featurev1.PromoteRequest{Key: "COLOR", From: "staging", To: "prod", DryRun: true})
+	require.NoError(t, err)
+	assert.False(t, preview.Applied)
+	assert.Nil(t, preview.Target)
+	assert.Equal(t, "red", preview.Source.Value)
+	assert.Equal(t, []string{`new key`, `value: "" -> "red"`, `type: "" -> "string"`, `rollout: changed`, `owner: "" -> "shop"`, `lifecycle: "" -> "temporary"`}, preview.Changes)
+	_, err = r.Get(prod, &featurev1.Key{Name: "COLOR"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	promoted, err := r.Promote(ctx, &featurev1.PromoteRequest{Key: "COLOR", From: "staging", To: "prod"})
+	require.NoError(t, err)
+	assert.True(t, promoted.Applied)
+	stream := &fakeServerStream{ctx: prod}
+	require.NoError(t, r.GetAll(&emptypb.Empty{}, stream))
+	require.Len(t, stream.sent, 1)
+	assert.Equal(t, "red", stream.sent[0].Value)
+	assert.Equal(t, featurev1.ValueType_VALUE_TYPE_STRING, stream.sent[0].Type)
+	assert.Equal(t, "shop", stream.sent[0].Metadata.Owner)
+	require.NotNil(t, stream.sent[0].Rollout)
+	assert.Equal(t, "blue", stream.sent[0].Rollout.Variants[0].Value)
+
+	// promoting again changes nothing
+	again, err := r.Promote(ctx, &featurev1.PromoteRequest{Key: "COLOR", From: "staging", To: "prod"})
+	require.NoError(t, err)
+	assert.False(t, again.Applied)
+	assert.Empty(t, again.Changes)
+	require.NotNil(t, again.Target)
+
+	// the revision of the target makes the promotion conditional
+	_, err = r.Set(staging, &featurev1.KeyValue{Key: "COLOR", Value: "green"})
+	require.NoError(t, err)
+	_, err = r.Promote(ctx, &featurev1.PromoteRequest{Key: "COLOR", From: "staging", To: "prod", Revision: again.Target.Revision + 1})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	back, err := r.Promote(ctx, &featurev1.PromoteRequest{Key: "COLOR", From: "staging", To: "prod", Revision: again.Target.Revision})
+	require.NoError(t, err)
+	assert.Equal(t, []string{`value: "red" -> "green"`}, back.Changes)
+	value, err := r.Get(prod, &featurev1.Key{Name: "COLOR"})
+	require.NoError(t, err)
+	assert.Equal(t, "green", value.Name)
+}
+
+func TestRouter_PromoteErrors(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRouter(flagset.Config{Editable: []string{"COLOR"}}, "staging", "prod")
+	staging := inEnvironment(ctx, "", "staging")
+	for _, key := range []string{"COLOR", "SIZE"} {
+		_, err := r.PreSet(staging, &featurev1.KeyValue{Key: key, Value: "1"})
+		require.NoError(t, err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		req  *featurev1.PromoteRequest
+		code codes.Code
+	}{
+		{"same environment", &featurev1.PromoteRequest{Key: "COLOR", From: "prod", To: "prod"}, codes.InvalidArgument},
+		{"missing key", &featurev1.PromoteRequest{From: "staging", To: "prod"}, codes.InvalidArgument},
+		{"unknown environment", &featurev1.PromoteRequest{Key: "COLOR", From: "staging", To: "dev"}, codes.InvalidArgument},
+		{"unknown key", &featurev1.PromoteRequest{Key: "SHAPE", From: "staging", To: "prod"}, codes.NotFound},
+		{"new key with editable restrictions", &featurev1.PromoteRequest{Key: "COLOR", From: "staging", To: "prod", DryRun: true}, codes.PermissionDenied},
+	} {
+		_, err := r.Promote(ctx, tc.req)
+		assert.Equal(t, tc.code, status.Code(err), tc.name)
+	}
+
+	_, err := r.PreSet(inEnvironment(ctx, "", "prod"), &featurev1.KeyValue{Key: "SIZE", Value: "2"})
+	require.NoError(t, err)
+	_, err = r.Promote(ctx, &featurev1.PromoteRequest{Key: "SIZE", From: "staging", To: "prod"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = newTestRouter(flagset.Config{}).Promote(ctx, &featurev1.PromoteRequest{Key: "COLOR", From: "staging", To: "prod"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
@@ -0,0 +1,152 @@
+package feature
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/dkrizic/feature/service/service/auth"
+	"github.com/dkrizic/feature/service/service/feature/v1"
+	"github.com/dkrizic/feature/service/service/flagset"
+	"github.com/dkrizic/feature/service/service/scheduler"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ScheduleSet checks a change like Set and stores it to be applied at the requested time by the
+// scheduler. The change is checked again when it is applied.
+func (r *Router) ScheduleSet(ctx context.Context, req *featurev1.ScheduleSetRequest) (*featurev1.Schedule, error) {
+	ctx, span := otel.Tracer("feature/service").Start(ctx, "ScheduleSet")
+	defer span.End()
+
+	if r.schedules == nil {
+		return nil, status.Error(codes.Unimplemented, "scheduling is not available")
+	}
+	if req.Key == "" {
+		return nil, status.Error(codes.InvalidArgument, "key is required")
+	}
+	if req.At == nil || !req.At.AsTime().After(time.Now()) {
+		return nil, status.Error(codes.InvalidArgument, "at must be in the future")
+	}
+	set, err := r.sets.FromContext(ctx)
+	if err != nil {
+		return nil, setError(err)
+	}
+	if req.Restart && set.RestartName == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "flag set '%s' has no restart target", set.Name)
+	}
+	fs, err := r.serviceOf(set)
+	if err != nil {
+		return nil, err
+	}
+	existing, exists, err := fs.find(ctx, req.Key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fs.prepareSet(ctx, &featurev1.KeyValue{Key: req.Key, Value: req.Value}, existing, exists); err != nil {
+		return nil, err
+	}
+
+	schedule, err := r.schedules.Create(ctx, scheduler.Schedule{
+		Set:         flagset.NameFromContext(ctx),
+		Environment: set.Partition.Environment,
+		Key:         req.Key,
+		Value:       req.Value,
+		At:          req.At.AsTime().UTC(),
+		Restart:     req.Restart,
+		CreatedBy:   auth.PrincipalFromContext(ctx),
+		CreatedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to store schedule", "key", req.Key, "error", err)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Schedule created", "id", schedule.ID, "set", set.Name, "key", req.Key, "at", schedule.At, "restart", req.Restart)
+	return toProtoSchedule(schedule, false), nil
+}
+
+// ListSchedules returns the schedules of the set named in the request metadata in all environments
+func (r *Router) ListSchedules(ctx context.Context, _ *emptypb.Empty) (*featurev1.Schedules, error) {
+	ctx, span := otel.Tracer("feature/service").Start(ctx, "ListSchedules")
+	defer span.End()
+
+	if r.schedules == nil {
+		return &featurev1.Schedules{}, nil
+	}
+	fs, err := r.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := r.schedules.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name := flagset.NameFromContext(ctx)
+	result := &featurev1.Schedules{}
+	for _, schedule := range schedules {
+		if schedule.Set == name {
+			result.Schedules = append(result.Schedules, toProtoSchedule(schedule, fs.redacted(ctx, schedule.Sensitive)))
+		}
+	}
+	return result, nil
+}
+
+// CancelSchedule removes a schedule of the set named in the request metadata
+func (r *Router) CancelSchedule(ctx context.Context, req *featurev1.Schedule) (*emptypb.Empty, error) {
+	ctx, span := otel.Tracer("feature/service").Start(ctx, "CancelSchedule")
+	defer span.End()
+
+	if r.schedules == nil {
+		return nil, status.Error(codes.Unimplemented, "scheduling is not available")
+	}
+	fs, err := r.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := r.schedules.Get(ctx, req.Id)
+	// schedules of other sets are not revealed
+	if errors.Is(err, scheduler.ErrNotFound) || (err == nil && schedule.Set != flagset.NameFromContext(ctx)) {
+		return nil, status.Errorf(codes.NotFound, "schedule '%s' not found", req.Id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !fs.editable(ctx, schedule.Key) {
+		return nil, status.Errorf(codes.PermissionDenied, "field '%s' is not editable", schedule.Key)
+	}
+	// the request only carries the id, so the interceptor could not check the key
+	if !auth.PermissionsFromContext(ctx).Allows("CancelSchedule", schedule.Key) {
+		return nil, status.Errorf(codes.PermissionDenied, "CancelSchedule is not allowed on '%s'", schedule.Key)
+	}
+	if err := r.schedules.Delete(ctx, req.Id); err != nil {
+		if errors.Is(err, scheduler.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "schedule '%s' not found", req.Id)
+		}
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Schedule cancelled", "id", req.Id, "key", schedule.Key)
+	return &emptypb.Empty{}, nil
+}
+
+func toProtoSchedule(schedule scheduler.Schedule, redacted bool) *featurev1.Schedule {
+	result := &featurev1.Schedule{
+		Id:          schedule.ID,
+		Key:         schedule.Key,
+		Value:       schedule.Value,
+		At:          timestamppb.New(schedule.At),
+		Restart:     schedule.Restart,
+		Environment: schedule.Environment,
+		CreatedBy:   schedule.CreatedBy,
+		CreatedAt:   timestamppb.New(schedule.CreatedAt),
+		Error:       schedule.Error,
+		Redacted:    redacted,
+	}
+	if redacted {
+		result.Value = ""
+	}
+	return result
+}
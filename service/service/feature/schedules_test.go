@@ -0,0 +1,97 @@
+package feature
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dkrizic/feature/service/service/auth"
+	"github.com/dkrizic/feature/service/service/feature/v1"
+	"github.com/dkrizic/feature/service/service/flagset"
+	"github.com/dkrizic/feature/service/service/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRouter_Schedules(t *testing.T) {
+	ctx := auth.WithPrincipal(context.Background(), "alice")
+	r := newTestRouter(flagset.Config{}, "staging", "prod")
+	_, err := r.CreateSet(ctx, &featurev1.FlagSet{Name: "team-a"})
+	require.NoError(t, err)
+	prod := inEnvironment(ctx, "", "prod")
+	at := timestamppb.New(time.Now().Add(time.Hour))
+
+	schedule, err := r.ScheduleSet(prod, &featurev1.ScheduleSetRequest{Key: "COLOR", Value: "red", At: at})
+	require.NoError(t, err)
+	assert.NotEmpty(t, schedule.Id)
+	assert.Equal(t, "prod", schedule.Environment)
+	assert.Equal(t, "alice", schedule.CreatedBy)
+	_, err = r.ScheduleSet(inSet(ctx, "team-a"), &featurev1.ScheduleSetRequest{Key: "SIZE", Value: "1", At: at})
+	require.NoError(t, err)
+
+	// the schedules of the set in all environments
+	schedules, err := r.ListSchedules(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, schedules.Schedules, 1)
+	assert.Equal(t, "red", schedules.Schedules[0].Value)
+	assert.True(t, at.AsTime().Equal(schedules.Schedules[0].At.AsTime()))
+
+	// schedules of other sets cannot be cancelled
+	_, err = r.CancelSchedule(inSet(ctx, "team-a"), &featurev1.Schedule{Id: schedule.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = r.CancelSchedule(ctx, &featurev1.Schedule{Id: schedule.Id})
+	require.NoError(t, err)
+	_, err = r.CancelSchedule(ctx, &featurev1.Schedule{Id: schedule.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	schedules, err = r.ListSchedules(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.Empty(t, schedules.Schedules)
+}
+
+func TestRouter_ScheduleSetErrors(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRouter(flagset.Config{Editable: []string{"COLOR"}})
+	_, err := r.PreSet(ctx, &featurev1.KeyValue{Key: "COLOR", Value: "1", Type: featurev1.ValueType_VALUE_TYPE_INTEGER})
+	require.NoError(t, err)
+	at := timestamppb.New(time.Now().Add(time.Hour))
+
+	for _, tc := range []struct {
+		name string
+		req  *featurev1.ScheduleSetRequest
+		code codes.Code
+	}{
+		{"missing key", &featurev1.ScheduleSetRequest{Value: "2", At: at}, codes.InvalidArgument},
+		{"missing time", &featurev1.ScheduleSetRequest{Key: "COLOR", Value: "2"}, codes.InvalidArgument},
+		{"past time", &featurev1.ScheduleSetRequest{Key: "COLOR", Value: "2", At: timestamppb.New(time.Now().Add(-time.Minute))}, codes.InvalidArgument},
+		{"invalid value", &featurev1.ScheduleSetRequest{Key: "COLOR", Value: "red", At: at}, codes.InvalidArgument},
+		{"not editable", &featurev1.ScheduleSetRequest{Key: "SIZE", Value: "2", At: at}, codes.PermissionDenied},
+		{"no restart target", &featurev1.ScheduleSetRequest{Key: "COLOR", Value: "2", At: at, Restart: true}, codes.FailedPrecondition},
+	} {
+		_, err := r.ScheduleSet(ctx, tc.req)
+		assert.Equal(t, tc.code, status.Code(err), tc.name)
+	}
+}
+
+func TestRouter_ApplySchedule(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRouter(flagset.Config{}, "staging", "prod")
+	_, err := r.Set(ctx, &featurev1.KeyValue{Key: "COLOR", Value: "red"})
+	require.NoError(t, err)
+
+	// a schedule that became due
+	_, err = r.schedules.Create(ctx, scheduler.Schedule{Key: "COLOR", Value: "blue", At: time.Now().Add(-time.Second), Environment: "prod", CreatedBy: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, scheduler.NewScheduler(r.schedules, r, nil).RunDue(ctx))
+
+	prod := inEnvironment(ctx, "", "prod")
+	value, err := r.Get(prod, &featurev1.Key{Name: "COLOR"})
+	require.NoError(t, err)
+	assert.Equal(t, "blue", value.Name)
+	value, err = r.Get(ctx, &featurev1.Key{Name: "COLOR"})
+	require.NoError(t, err)
+	assert.Equal(t, "red", value.Name)
+}
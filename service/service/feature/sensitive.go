@@ -0,0 +1,62 @@
+package feature
+
+import (
+	"context"
+
+	"github.com/dkrizic/feature/service/service/auth"
+	"github.com/dkrizic/feature/service/service/persistence"
+)
+
+// Sensitive flags, e.g. those of the Secret backend, are listed without their values unless the
+// caller is allowed to reveal them. Get and Evaluate serve the value, they are how workloads read
+// their configuration.
+
+// mayReveal reports whether the caller may see the values of sensitive flags, which requires an
+// authenticated principal listed with --reveal
+func (fs *FeatureService) mayReveal(ctx context.Context) bool {
+	principal := auth.PrincipalFromContext(ctx)
+	return principal != "" && fs.revealPrincipals[principal]
+}
+
+// redacted reports whether the values of a sensitive flag have to be withheld from the caller
+func (fs *FeatureService) redacted(ctx context.Context, sensitive bool) bool {
+	return sensitive && !fs.mayReveal(ctx)
+}
+
+// redact removes the value and the values served by rules, rollout and failed prerequisites
+func redact(kv persistence.KeyValue) persistence.KeyValue {
+	kv.Value = ""
+	kv.OffValue = ""
+	if kv.Rules != nil {
+		rules := make([]persistence.Rule, len(kv.Rules))
+		for i, rule := range kv.Rules {
+			rule.Value = ""
+			rule.Rollout = redactRollout(rule.Rollout)
+			rules[i] = rule
+		}
+		kv.Rules = rules
+	}
+	kv.Rollout = redactRollout(kv.Rollout)
+	return kv
+}
+
+func redactRollout(rollout *persistence.Rollout) *persistence.Rollout {
+	if rollout == nil {
+		return nil
+	}
+	redacted := *rollout
+	redacted.Variants = make([]persistence.Variant, len(rollout.Variants))
+	for i, variant := range rollout.Variants {
+		variant.Value = ""
+		redacted.Variants[i] = variant
+	}
+	return &redacted
+}
+
+// logValue returns the value to log, the values of sensitive flags are never logged
+func logValue(sensitive bool, value string) string {
+	if sensitive {
+		return "[redacted]"
+	}
+	return value
+}
@@ -0,0 +1,322 @@
+package feature
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/dkrizic/feature/service/service/auth"
+	"github.com/dkrizic/feature/service/service/feature/v1"
+	"github.com/dkrizic/feature/service/service/flagset"
+	"github.com/dkrizic/feature/service/service/lifecycle"
+	"github.com/dkrizic/feature/service/service/scheduler"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Router serves the Feature API for all flag sets and environments. Every call is forwarded to the
+// FeatureService of the set and environment named in the request metadata, see
+// flagset.MetadataKey and flagset.EnvironmentMetadataKey.
+type Router struct {
+	featurev1.UnimplementedFeatureServer
+	sets      *flagset.Manager
+	schedules *scheduler.Store
+	lifecycle *lifecycle.Monitor
+	tokens    *auth.Tokens
+	reveal    string
+
+	mu sync.Mutex
+	// services holds the service of every partition by name, for as long as the definition of its
+	// set is unchanged
+	services map[string]routed
+}
+
+type routed struct {
+	set     *flagset.Set
+	service *FeatureService
+}
+
+// NewRouter creates the router for the sets of the manager. The schedules, the lifecycle monitor
+// and the tokens are optional; without them scheduling and API tokens are unavailable and no flags
+// are reported as stale. revealStr is passed on to NewFeatureService and applies to all sets.
+func NewRouter(sets *flagset.Manager, schedules *scheduler.Store, monitor *lifecycle.Monitor, tokens *auth.Tokens, revealStr string) *Router {
+	return &Router{
+		sets:      sets,
+		schedules: schedules,
+		lifecycle: monitor,
+		tokens:    tokens,
+		reveal:    revealStr,
+		services:  make(map[string]routed),
+	}
+}
+
+// setError maps the errors of the flag set manager to gRPC status errors
+func setError(err error) error {
+	switch {
+	case errors.Is(err, flagset.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, flagset.ErrExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, flagset.ErrInvalid), errors.Is(err, flagset.ErrDefault), errors.Is(err, flagset.ErrEnvironment):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, flagset.ErrNotEmpty):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, flagset.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return err
+}
+
+// service returns the service of the set and environment named in the request metadata
+func (r *Router) service(ctx context.Context) (*FeatureService, error) {
+	set, err := r.sets.FromContext(ctx)
+	if err != nil {
+		return nil, setError(err)
+	}
+	return r.serviceOf(set)
+}
+
+// serviceOf returns the service of a partition of a set
+func (r *Router) serviceOf(set *flagset.Set) (*FeatureService, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cached, ok := r.services[set.Partition.Name]; ok && cached.set == set {
+		return cached.service, nil
+	}
+	fs, err := NewFeatureService(set.Persistence, strings.Join(set.Editable, ","), r.reveal, set.Broadcaster)
+	if err != nil {
+		return nil, err
+	}
+	if r.lifecycle != nil {
+		partition := set.Partition.Name
+		fs.onRead = func(keys ...string) { r.lifecycle.Read(partition, keys...) }
+	}
+	r.services[set.Partition.Name] = routed{set: set, service: fs}
+	return fs, nil
+}
+
+func (r *Router) GetAll(empty *emptypb.Empty, stream grpc.ServerStreamingServer[featurev1.KeyValue]) error {
+	fs, err := r.service(stream.Context())
+	if err != nil {
+		return err
+	}
+	return fs.GetAll(empty, stream)
+}
+
+func (r *Router) PreSet(ctx context.Context, kv *featurev1.KeyValue) (*emptypb.Empty, error) {
+	fs, err := r.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fs.PreSet(ctx, kv)
+}
+
+func (r *Router) Set(ctx context.Context, kv *featurev1.KeyValue) (*emptypb.Empty, error) {
+	fs, err := r.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Set(ctx, kv)
+}
+
+func (r *Router) Get(ctx context.Context, key *featurev1.Key) (*featurev1.Value, error) {
+	fs, err := r.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Get(ctx, key)
+}
+
+func (r *Router) Delete(ctx context.Context, key *featurev1.Key) (*emptypb.Empty, error) {
+	fs, err := r.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Delete(ctx, key)
+}
+
+func (r *Router) Watch(req *featurev1.WatchRequest, stream grpc.ServerStreamingServer[featurev1.WatchEvent]) error {
+	fs, err := r.service(stream.Context())
+	if err != nil {
+		return err
+	}
+	return fs.Watch(req, stream)
+}
+
+func (r *Router) Evaluate(ctx context.Context, req *featurev1.EvaluateRequest) (*featurev1.EvaluateResponse, error) {
+	fs, err := r.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Evaluate(ctx, req)
+}
+
+func (r *Router) GetRules(ctx context.Context, key *featurev1.Key) (*featurev1.Rules, error) {
+	fs, err := r.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fs.GetRules(ctx, key)
+}
+
+func (r *Router) SetRules(ctx context.Context, rules *featurev1.Rules) (*emptypb.Empty, error) {
+	fs, err := r.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fs.SetRules(ctx, rules)
+}
+
+func (r *Router) SetRollout(ctx context.Context, req *featurev1.SetRolloutRequest) (*emptypb.Empty, error) {
+	fs, err := r.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fs.SetRollout(ctx, req)
+}
+
+func (r *Router) SetPrerequisites(ctx context.Context, req *featurev1.Prerequisites) (*emptypb.Empty, error) {
+	fs, err := r.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fs.SetPrerequisites(ctx, req)
+}
+
+func (r *Router) History(ctx context.Context, key *featurev1.Key) (*featurev1.HistoryResponse, error) {
+	fs, err := r.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fs.History(ctx, key)
+}
+
+func (r *Router) Rollback(ctx context.Context, req *featurev1.RollbackRequest) (*emptypb.Empty, error) {
+	fs, err := r.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Rollback(ctx, req)
+}
+
+func (r *Router) Batch(ctx context.Context, req *featurev1.BatchRequest) (*emptypb.Empty, error) {
+	fs, err := r.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Batch(ctx, req)
+}
+
+func toProtoFlagSet(config flagset.Config) *featurev1.FlagSet {
+	return &featurev1.FlagSet{
+		Name:        config.Name,
+		Description: config.Description,
+		Editable:    config.Editable,
+		Principals:  config.Principals,
+		RestartType: config.RestartType,
+		RestartName: config.RestartName,
+	}
+}
+
+func (r *Router) ListSets(ctx context.Context, _ *emptypb.Empty) (*featurev1.FlagSets, error) {
+	ctx, span := otel.Tracer("feature/service").Start(ctx, "ListSets")
+	defer span.End()
+
+	configs, err := r.sets.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	principal := auth.PrincipalFromContext(ctx)
+	sets := &featurev1.FlagSets{}
+	for _, config := range configs {
+		if config.Allows(principal) {
+			sets.Sets = append(sets.Sets, toProtoFlagSet(config))
+		}
+	}
+	slog.InfoContext(ctx, "ListSets completed", "count", len(sets.Sets))
+	return sets, nil
+}
+
+func (r *Router) CreateSet(ctx context.Context, set *featurev1.FlagSet) (*emptypb.Empty, error) {
+	ctx, span := otel.Tracer("feature/service").Start(ctx, "CreateSet")
+	defer span.End()
+
+	err := r.sets.Create(ctx, flagset.Config{
+		Name:        set.Name,
+		Description: set.Description,
+		Editable:    set.Editable,
+		Principals:  set.Principals,
+		RestartType: set.RestartType,
+		RestartName: set.RestartName,
+	})
+	if err != nil {
+		return nil, setError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (r *Router) DeleteSet(ctx context.Context, set *featurev1.FlagSet) (*emptypb.Empty, error) {
+	ctx, span := otel.Tracer("feature/service").Start(ctx, "DeleteSet")
+	defer span.End()
+
+	existing, err := r.sets.Get(ctx, set.Name, "")
+	if err != nil {
+		return nil, setError(err)
+	}
+	if !existing.Allows(auth.PrincipalFromContext(ctx)) {
+		return nil, setError(flagset.ErrPermissionDenied)
+	}
+	if err := r.sets.Delete(ctx, set.Name); err != nil {
+		return nil, setError(err)
+	}
+	r.mu.Lock()
+	for partition, cached := range r.services {
+		if cached.set.Name == set.Name {
+			delete(r.services, partition)
+		}
+	}
+	r.mu.Unlock()
+	return &emptypb.Empty{}, nil
+}
+
+func (r *Router) ListEnvironments(ctx context.Context, _ *emptypb.Empty) (*featurev1.Environments, error) {
+	_, span := otel.Tracer("feature/service").Start(ctx, "ListEnvironments")
+	defer span.End()
+
+	return &featurev1.Environments{Names: r.sets.Environments()}, nil
+}
+
+// Promote copies a flag of the set named in the request metadata between two of its environments
+func (r *Router) Promote(ctx context.Context, req *featurev1.PromoteRequest) (*featurev1.PromoteResponse, error) {
+	if len(r.sets.Environments()) == 0 {
+		return nil, status.Error(codes.FailedPrecondition, "no environments are configured")
+	}
+	if req.Key == "" || req.From == "" || req.To == "" {
+		return nil, status.Error(codes.InvalidArgument, "key, from and to are required")
+	}
+	if req.From == req.To {
+		return nil, status.Error(codes.InvalidArgument, "from and to must be different environments")
+	}
+	from, err := r.sets.InEnvironment(ctx, req.From)
+	if err != nil {
+		return nil, setError(err)
+	}
+	to, err := r.sets.InEnvironment(ctx, req.To)
+	if err != nil {
+		return nil, setError(err)
+	}
+	source, err := r.serviceOf(from)
+	if err != nil {
+		return nil, err
+	}
+	target, err := r.serviceOf(to)
+	if err != nil {
+		return nil, err
+	}
+	return source.promote(ctx, req, target)
+}
@@ -0,0 +1,145 @@
+package feature
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dkrizic/feature/service/notifier"
+	"github.com/dkrizic/feature/service/service/auth"
+	featurev1 "github.com/dkrizic/feature/service/service/feature/v1"
+	"github.com/dkrizic/feature/service/service/flagset"
+	"github.com/dkrizic/feature/service/service/lifecycle"
+	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/dkrizic/feature/service/service/persistence/inmemory"
+	"github.com/dkrizic/feature/service/service/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func newTestRouter(defaults flagset.Config, environments ...string) *Router {
+	open := func(ctx context.Context, partition flagset.Partition, notifiers ...notifier.Notifier) persistence.Persistence {
+		return inmemory.NewInMemoryPersistence()
+	}
+	sets := flagset.NewManager(inmemory.NewInMemoryPersistence(), defaults, environments, open)
+	return NewRouter(sets, scheduler.NewStore(inmemory.NewInMemoryPersistence()),
+		lifecycle.NewMonitor(sets, inmemory.NewInMemoryPersistence(), 720*time.Hour),
+		auth.NewTokens(inmemory.NewInMemoryPersistence()), "")
+}
+
+func inSet(ctx context.Context, name string) context.Context {
+	return metadata.NewIncomingContext(ctx, metadata.Pairs(flagset.MetadataKey, name))
+}
+
+func TestRouter_SeparatesSets(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRouter(flagset.Config{})
+	_, err := r.CreateSet(ctx, &featurev1.FlagSet{Name: "team-a"})
+	require.NoError(t, err)
+
+	_, err = r.Set(ctx, &featurev1.KeyValue{Key: "COLOR", Value: "red"})
+	require.NoError(t, err)
+	_, err = r.Set(inSet(ctx, "team-a"), &featurev1.KeyValue{Key: "COLOR", Value: "blue"})
+	require.NoError(t, err)
+
+	value, err := r.Get(ctx, &featurev1.Key{Name: "COLOR"})
+	require.NoError(t, err)
+	assert.Equal(t, "red", value.Name)
+	value, err = r.Get(inSet(ctx, flagset.DefaultName), &featurev1.Key{Name: "COLOR"})
+	require.NoError(t, err)
+	assert.Equal(t, "red", value.Name)
+	value, err = r.Get(inSet(ctx, "team-a"), &featurev1.Key{Name: "COLOR"})
+	require.NoError(t, err)
+	assert.Equal(t, "blue", value.Name)
+
+	stream := &fakeServerStream{ctx: inSet(ctx, "team-a")}
+	require.NoError(t, r.GetAll(&emptypb.Empty{}, stream))
+	require.Len(t, stream.sent, 1)
+	assert.Equal(t, "blue", stream.sent[0].Value)
+
+	_, err = r.Get(inSet(ctx, "unknown"), &featurev1.Key{Name: "COLOR"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestRouter_EditablePerSet(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRouter(flagset.Config{Editable: []string{"COLOR"}})
+	_, err := r.CreateSet(ctx, &featurev1.FlagSet{Name: "team-a", Editable: []string{"SIZE"}})
+	require.NoError(t, err)
+
+	for _, name := range []string{flagset.DefaultName, "team-a"} {
+		for _, key := range []string{"COLOR", "SIZE"} {
+			_, err = r.PreSet(inSet(ctx, name), &featurev1.KeyValue{Key: key, Value: "1"})
+			require.NoError(t, err)
+		}
+	}
+
+	_, err = r.Set(ctx, &featurev1.KeyValue{Key: "SIZE", Value: "10"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = r.Set(inSet(ctx, "team-a"), &featurev1.KeyValue{Key: "SIZE", Value: "10"})
+	assert.NoError(t, err)
+	_, err = r.Set(inSet(ctx, "team-a"), &featurev1.KeyValue{Key: "COLOR", Value: "red"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestRouter_Principals(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRouter(flagset.Config{})
+	_, err := r.CreateSet(ctx, &featurev1.FlagSet{Name: "team-a", Principals: []string{"alice"}})
+	require.NoError(t, err)
+	_, err = r.CreateSet(ctx, &featurev1.FlagSet{Name: "team-b"})
+	require.NoError(t, err)
+
+	alice := auth.WithPrincipal(ctx, "alice")
+	bob := auth.WithPrincipal(ctx, "bob")
+	_, err = r.Set(inSet(bob, "team-a"), &featurev1.KeyValue{Key: "COLOR", Value: "red"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = r.Set(inSet(alice, "team-a"), &featurev1.KeyValue{Key: "COLOR", Value: "red"})
+	assert.NoError(t, err)
+
+	sets, err := r.ListSets(bob, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, sets.Sets, 2)
+	assert.Equal(t, flagset.DefaultName, sets.Sets[0].Name)
+	assert.Equal(t, "team-b", sets.Sets[1].Name)
+	sets, err = r.ListSets(alice, &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.Len(t, sets.Sets, 3)
+
+	_, err = r.DeleteSet(bob, &featurev1.FlagSet{Name: "team-a"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestRouter_CreateAndDeleteSet(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRouter(flagset.Config{})
+
+	_, err := r.CreateSet(ctx, &featurev1.FlagSet{Name: "Team A"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = r.CreateSet(ctx, &featurev1.FlagSet{Name: "team-a", RestartType: "deployment", RestartName: "app-a"})
+	require.NoError(t, err)
+	_, err = r.CreateSet(ctx, &featurev1.FlagSet{Name: "team-a"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	sets, err := r.ListSets(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, sets.Sets, 2)
+	assert.Equal(t, "app-a", sets.Sets[1].RestartName)
+
+	_, err = r.Set(inSet(ctx, "team-a"), &featurev1.KeyValue{Key: "COLOR", Value: "red"})
+	require.NoError(t, err)
+	_, err = r.DeleteSet(ctx, &featurev1.FlagSet{Name: "team-a"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = r.Delete(inSet(ctx, "team-a"), &featurev1.Key{Name: "COLOR"})
+	require.NoError(t, err)
+	_, err = r.DeleteSet(ctx, &featurev1.FlagSet{Name: "team-a"})
+	assert.NoError(t, err)
+	_, err = r.DeleteSet(ctx, &featurev1.FlagSet{Name: flagset.DefaultName})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = r.Get(inSet(ctx, "team-a"), &featurev1.Key{Name: "COLOR"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
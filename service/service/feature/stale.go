@@ -0,0 +1,57 @@
+package feature
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/dkrizic/feature/service/service/feature/v1"
+	"github.com/dkrizic/feature/service/service/lifecycle"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var staleReasons = map[lifecycle.Reason]featurev1.StaleReason{
+	lifecycle.ReasonExpired: featurev1.StaleReason_STALE_REASON_EXPIRED,
+	lifecycle.ReasonUnused:  featurev1.StaleReason_STALE_REASON_UNUSED,
+}
+
+// ListStale returns the stale flags of the set and environment named in the request metadata
+func (r *Router) ListStale(ctx context.Context, _ *emptypb.Empty) (*featurev1.StaleFlags, error) {
+	ctx, span := otel.Tracer("feature/service").Start(ctx, "ListStale")
+	defer span.End()
+
+	set, err := r.sets.FromContext(ctx)
+	if err != nil {
+		return nil, setError(err)
+	}
+	result := &featurev1.StaleFlags{}
+	if r.lifecycle == nil {
+		return result, nil
+	}
+	flags, err := r.lifecycle.Stale(ctx, set)
+	if err != nil {
+		return nil, err
+	}
+	for _, flag := range flags {
+		result.Flags = append(result.Flags, toProtoStaleFlag(flag))
+	}
+	slog.InfoContext(ctx, "ListStale completed", "count", len(result.Flags))
+	return result, nil
+}
+
+func toProtoStaleFlag(flag lifecycle.Flag) *featurev1.StaleFlag {
+	result := &featurev1.StaleFlag{
+		Key:    flag.Key,
+		Reason: staleReasons[flag.Reason],
+		Since:  timestamppb.New(flag.Since),
+		Owner:  flag.Owner,
+	}
+	if !flag.LastRead.IsZero() {
+		result.LastRead = timestamppb.New(flag.LastRead)
+	}
+	if !flag.ExpiresAt.IsZero() {
+		result.ExpiresAt = timestamppb.New(flag.ExpiresAt)
+	}
+	return result
+}
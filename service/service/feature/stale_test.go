@@ -0,0 +1,47 @@
+package feature
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dkrizic/feature/service/service/feature/v1"
+	"github.com/dkrizic/feature/service/service/flagset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRouter_ListStale(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRouter(flagset.Config{}, "staging", "prod")
+	expired := timestamppb.New(time.Now().Add(-time.Hour))
+	prod := inEnvironment(ctx, "", "prod")
+
+	_, err := r.Set(ctx, &featurev1.KeyValue{Key: "RELEASE", Value: "true", Metadata: &featurev1.Metadata{ExpiresAt: expired, Owner: "shop"}})
+	require.NoError(t, err)
+	_, err = r.Set(prod, &featurev1.KeyValue{Key: "RELEASE", Value: "false"})
+	require.NoError(t, err)
+	_, err = r.Get(ctx, &featurev1.Key{Name: "RELEASE"})
+	require.NoError(t, err)
+
+	// nothing is stale before the job marked it
+	stale, err := r.ListStale(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.Empty(t, stale.Flags)
+
+	assert.Equal(t, 1, r.lifecycle.Check(ctx))
+	stale, err = r.ListStale(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, stale.Flags, 1)
+	assert.Equal(t, "RELEASE", stale.Flags[0].Key)
+	assert.Equal(t, featurev1.StaleReason_STALE_REASON_EXPIRED, stale.Flags[0].Reason)
+	assert.Equal(t, "shop", stale.Flags[0].Owner)
+	assert.True(t, expired.AsTime().Equal(stale.Flags[0].ExpiresAt.AsTime()))
+	assert.NotNil(t, stale.Flags[0].LastRead, "read with Get")
+
+	stale, err = r.ListStale(prod, &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.Empty(t, stale.Flags)
+}
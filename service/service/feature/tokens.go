@@ -0,0 +1,85 @@
+package feature
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/dkrizic/feature/service/service/auth"
+	"github.com/dkrizic/feature/service/service/feature/v1"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// tokenError maps the errors of the token store to gRPC status errors
+func tokenError(err error) error {
+	switch {
+	case errors.Is(err, auth.ErrTokenInvalid):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, auth.ErrTokenExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, auth.ErrTokenNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return err
+}
+
+// CreateToken creates an API token on behalf of the caller. The secret is only returned here.
+func (r *Router) CreateToken(ctx context.Context, req *featurev1.CreateTokenRequest) (*featurev1.CreateTokenResponse, error) {
+	ctx, span := otel.Tracer("feature/service").Start(ctx, "CreateToken")
+	defer span.End()
+
+	if r.tokens == nil {
+		return nil, status.Error(codes.Unimplemented, "API tokens require authentication")
+	}
+	token := auth.Token{Name: req.Name, CreatedBy: auth.PrincipalFromContext(ctx)}
+	for _, scope := range req.Scopes {
+		token.Scopes = append(token.Scopes, auth.ScopeFromProto(scope))
+	}
+	if req.ExpiresAt != nil {
+		token.ExpiresAt = req.ExpiresAt.AsTime().UTC()
+	}
+	token, secret, err := r.tokens.Create(ctx, token)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to create token", "name", req.Name, "error", err)
+		return nil, tokenError(err)
+	}
+	slog.InfoContext(ctx, "Token created", "id", token.ID, "name", token.Name, "scopes", token.Scopes, "expiresAt", token.ExpiresAt)
+	return &featurev1.CreateTokenResponse{Token: token.ToProto(), Secret: secret}, nil
+}
+
+// ListTokens returns all API tokens including the expired ones, without their secrets
+func (r *Router) ListTokens(ctx context.Context, _ *emptypb.Empty) (*featurev1.Tokens, error) {
+	ctx, span := otel.Tracer("feature/service").Start(ctx, "ListTokens")
+	defer span.End()
+
+	if r.tokens == nil {
+		return nil, status.Error(codes.Unimplemented, "API tokens require authentication")
+	}
+	tokens, err := r.tokens.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &featurev1.Tokens{}
+	for _, token := range tokens {
+		result.Tokens = append(result.Tokens, token.ToProto())
+	}
+	return result, nil
+}
+
+// RevokeToken deletes an API token, calls with it fail from then on
+func (r *Router) RevokeToken(ctx context.Context, req *featurev1.Token) (*emptypb.Empty, error) {
+	ctx, span := otel.Tracer("feature/service").Start(ctx, "RevokeToken")
+	defer span.End()
+
+	if r.tokens == nil {
+		return nil, status.Error(codes.Unimplemented, "API tokens require authentication")
+	}
+	if err := r.tokens.Revoke(ctx, req.Id); err != nil {
+		return nil, tokenError(err)
+	}
+	slog.InfoContext(ctx, "Token revoked", "id", req.Id)
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,48 @@
+package feature
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dkrizic/feature/service/service/auth"
+	featurev1 "github.com/dkrizic/feature/service/service/feature/v1"
+	"github.com/dkrizic/feature/service/service/flagset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRouter_Tokens(t *testing.T) {
+	ctx := auth.WithPrincipal(context.Background(), "alice")
+	r := newTestRouter(flagset.Config{})
+
+	created, err := r.CreateToken(ctx, &featurev1.CreateTokenRequest{
+		Name:      "ci",
+		Scopes:    []featurev1.TokenScope{featurev1.TokenScope_TOKEN_SCOPE_READ, featurev1.TokenScope_TOKEN_SCOPE_RESTART},
+		ExpiresAt: timestamppb.New(time.Now().Add(time.Hour)),
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.Secret)
+	assert.Equal(t, "alice", created.Token.CreatedBy)
+
+	_, err = r.CreateToken(ctx, &featurev1.CreateTokenRequest{Name: "ci", Scopes: created.Token.Scopes})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = r.CreateToken(ctx, &featurev1.CreateTokenRequest{Name: "app", Scopes: []featurev1.TokenScope{featurev1.TokenScope_TOKEN_SCOPE_UNSPECIFIED}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	tokens, err := r.ListTokens(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, tokens.Tokens, 1)
+	assert.Equal(t, created.Token.Id, tokens.Tokens[0].Id)
+	assert.Equal(t, created.Token.Scopes, tokens.Tokens[0].Scopes)
+	assert.NotNil(t, tokens.Tokens[0].ExpiresAt)
+
+	_, err = r.RevokeToken(ctx, &featurev1.Token{Id: created.Token.Id})
+	require.NoError(t, err)
+	_, err = r.RevokeToken(ctx, &featurev1.Token{Id: created.Token.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,360 @@
+package feature
+
+// conversion between the protobuf and persistence representation of typed flags and validation of their values
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dkrizic/feature/service/service/feature/v1"
+	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var valueTypes = map[featurev1.ValueType]persistence.ValueType{
+	featurev1.ValueType_VALUE_TYPE_STRING:  persistence.TypeString,
+	featurev1.ValueType_VALUE_TYPE_BOOLEAN: persistence.TypeBoolean,
+	featurev1.ValueType_VALUE_TYPE_INTEGER: persistence.TypeInteger,
+	featurev1.ValueType_VALUE_TYPE_FLOAT:   persistence.TypeFloat,
+	featurev1.ValueType_VALUE_TYPE_ENUM:    persistence.TypeEnum,
+	featurev1.ValueType_VALUE_TYPE_JSON:    persistence.TypeJSON,
+}
+
+var operators = map[featurev1.Operator]persistence.Operator{
+	featurev1.Operator_OPERATOR_EQUALS:                  persistence.OperatorEquals,
+	featurev1.Operator_OPERATOR_NOT_EQUALS:              persistence.OperatorNotEquals,
+	featurev1.Operator_OPERATOR_IN:                      persistence.OperatorIn,
+	featurev1.Operator_OPERATOR_NOT_IN:                  persistence.OperatorNotIn,
+	featurev1.Operator_OPERATOR_REGEX:                   persistence.OperatorRegex,
+	featurev1.Operator_OPERATOR_SEMVER_EQUALS:           persistence.OperatorSemverEquals,
+	featurev1.Operator_OPERATOR_SEMVER_LESS_THAN:        persistence.OperatorSemverLessThan,
+	featurev1.Operator_OPERATOR_SEMVER_LESS_OR_EQUAL:    persistence.OperatorSemverLessOrEqual,
+	featurev1.Operator_OPERATOR_SEMVER_GREATER_THAN:     persistence.OperatorSemverGreaterThan,
+	featurev1.Operator_OPERATOR_SEMVER_GREATER_OR_EQUAL: persistence.OperatorSemverGreaterOrEqual,
+}
+
+var lifecycles = map[featurev1.Lifecycle]persistence.Lifecycle{
+	featurev1.Lifecycle_LIFECYCLE_TEMPORARY: persistence.LifecycleTemporary,
+	featurev1.Lifecycle_LIFECYCLE_PERMANENT: persistence.LifecyclePermanent,
+}
+
+// fromProtoType converts a protobuf value type, VALUE_TYPE_UNSPECIFIED becomes the empty type
+func fromProtoType(t featurev1.ValueType) (persistence.ValueType, error) {
+	if t == featurev1.ValueType_VALUE_TYPE_UNSPECIFIED {
+		return "", nil
+	}
+	vt, ok := valueTypes[t]
+	if !ok {
+		return "", fmt.Errorf("unknown value type %d", t)
+	}
+	return vt, nil
+}
+
+// toProtoType converts a stored value type, untyped flags are reported as strings
+func toProtoType(t persistence.ValueType) featurev1.ValueType {
+	for pt, vt := range valueTypes {
+		if vt == t {
+			return pt
+		}
+	}
+	return featurev1.ValueType_VALUE_TYPE_STRING
+}
+
+func fromProtoConstraints(c *featurev1.Constraints) *persistence.Constraints {
+	// an empty message is the same as no constraints
+	if c == nil || (c.Min == nil && c.Max == nil && c.Pattern == "" && len(c.AllowedValues) == 0 && c.JsonSchema == "") {
+		return nil
+	}
+	return &persistence.Constraints{
+		Min:           c.Min,
+		Max:           c.Max,
+		Pattern:       c.Pattern,
+		AllowedValues: c.AllowedValues,
+		JSONSchema:    c.JsonSchema,
+	}
+}
+
+func toProtoConstraints(c *persistence.Constraints) *featurev1.Constraints {
+	if c == nil {
+		return nil
+	}
+	return &featurev1.Constraints{
+		Min:           c.Min,
+		Max:           c.Max,
+		Pattern:       c.Pattern,
+		AllowedValues: c.AllowedValues,
+		JsonSchema:    c.JSONSchema,
+	}
+}
+
+func toProtoMetadata(m persistence.Metadata) *featurev1.Metadata {
+	if m.IsZero() {
+		return nil
+	}
+	metadata := &featurev1.Metadata{
+		Description:    m.Description,
+		Owner:          m.Owner,
+		Tags:           m.Tags,
+		LastModifiedBy: m.LastModifiedBy,
+	}
+	if !m.CreatedAt.IsZero() {
+		metadata.CreatedAt = timestamppb.New(m.CreatedAt)
+	}
+	if !m.UpdatedAt.IsZero() {
+		metadata.UpdatedAt = timestamppb.New(m.UpdatedAt)
+	}
+	if !m.ExpiresAt.IsZero() {
+		metadata.ExpiresAt = timestamppb.New(m.ExpiresAt)
+	}
+	for pl, l := range lifecycles {
+		if l == m.Lifecycle {
+			metadata.Lifecycle = pl
+		}
+	}
+	return metadata
+}
+
+func fromProtoRules(rules []*featurev1.Rule) ([]persistence.Rule, error) {
+	var result []persistence.Rule
+	for _, rule := range rules {
+		r := persistence.Rule{Name: rule.Name, Value: rule.Value, Rollout: fromProtoRollout(rule.Rollout)}
+		for _, condition := range rule.Conditions {
+			operator, ok := operators[condition.Operator]
+			if !ok {
+				return nil, fmt.Errorf("unknown operator %s in rule %q", condition.Operator, rule.Name)
+			}
+			r.Conditions = append(r.Conditions, persistence.Condition{
+				Attribute: condition.Attribute,
+				Operator:  operator,
+				Values:    condition.Values,
+			})
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+func toProtoRules(rules []persistence.Rule) []*featurev1.Rule {
+	var result []*featurev1.Rule
+	for _, rule := range rules {
+		r := &featurev1.Rule{Name: rule.Name, Value: rule.Value, Rollout: toProtoRollout(rule.Rollout)}
+		for _, condition := range rule.Conditions {
+			c := &featurev1.Condition{Attribute: condition.Attribute, Values: condition.Values}
+			for po, o := range operators {
+				if o == condition.Operator {
+					c.Operator = po
+				}
+			}
+			r.Conditions = append(r.Conditions, c)
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+func fromProtoPrerequisites(prerequisites []*featurev1.Prerequisite) []persistence.Prerequisite {
+	var result []persistence.Prerequisite
+	for _, p := range prerequisites {
+		result = append(result, persistence.Prerequisite{Key: p.Key, Value: p.Value})
+	}
+	return result
+}
+
+func toProtoPrerequisites(prerequisites []persistence.Prerequisite) []*featurev1.Prerequisite {
+	var result []*featurev1.Prerequisite
+	for _, p := range prerequisites {
+		result = append(result, &featurev1.Prerequisite{Key: p.Key, Value: p.Value})
+	}
+	return result
+}
+
+// fromProtoRollout converts a rollout, a rollout without variants is none
+func fromProtoRollout(r *featurev1.Rollout) *persistence.Rollout {
+	if len(r.GetVariants()) == 0 {
+		return nil
+	}
+	rollout := &persistence.Rollout{BucketBy: r.BucketBy}
+	for _, v := range r.Variants {
+		rollout.Variants = append(rollout.Variants, persistence.Variant{Value: v.Value, Percentage: v.Percentage})
+	}
+	return rollout
+}
+
+func toProtoRollout(r *persistence.Rollout) *featurev1.Rollout {
+	if r == nil {
+		return nil
+	}
+	rollout := &featurev1.Rollout{BucketBy: r.BucketBy}
+	for _, v := range r.Variants {
+		rollout.Variants = append(rollout.Variants, &featurev1.Variant{Value: v.Value, Percentage: v.Percentage})
+	}
+	return rollout
+}
+
+// validateSpec checks that the constraints are well-formed and fit the type
+func validateSpec(t persistence.ValueType, c *persistence.Constraints) error {
+	switch t {
+	case "", persistence.TypeString, persistence.TypeBoolean, persistence.TypeInteger,
+		persistence.TypeFloat, persistence.TypeEnum, persistence.TypeJSON:
+	default:
+		return fmt.Errorf("unknown value type %q", t)
+	}
+	if c == nil {
+		if t == persistence.TypeEnum {
+			return fmt.Errorf("enum requires allowed values")
+		}
+		return nil
+	}
+	if t == "" {
+		return fmt.Errorf("constraints require a type")
+	}
+
+	if c.Min != nil || c.Max != nil {
+		if t != persistence.TypeInteger && t != persistence.TypeFloat {
+			return fmt.Errorf("min and max only apply to integer and float")
+		}
+		if c.Min != nil && c.Max != nil && *c.Min > *c.Max {
+			return fmt.Errorf("min %v is greater than max %v", *c.Min, *c.Max)
+		}
+	}
+	if c.Pattern != "" {
+		if t != persistence.TypeString {
+			return fmt.Errorf("pattern only applies to string")
+		}
+		if _, err := compilePattern(c.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	if len(c.AllowedValues) > 0 && t != persistence.TypeString && t != persistence.TypeEnum {
+		return fmt.Errorf("allowed values only apply to string and enum")
+	}
+	if t == persistence.TypeEnum && len(c.AllowedValues) == 0 {
+		return fmt.Errorf("enum requires allowed values")
+	}
+	if c.JSONSchema != "" {
+		if t != persistence.TypeJSON {
+			return fmt.Errorf("JSON schema only applies to json")
+		}
+		if _, err := compileSchema(c.JSONSchema); err != nil {
+			return fmt.Errorf("invalid JSON schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateValue checks that the value of kv conforms to its type and constraints
+func validateValue(kv persistence.KeyValue) error {
+	c := kv.Constraints
+	if c == nil {
+		c = &persistence.Constraints{}
+	}
+
+	switch kv.Type {
+	case "", persistence.TypeString:
+		if c.Pattern != "" {
+			re, err := compilePattern(c.Pattern)
+			if err != nil {
+				return err
+			}
+			if !re.MatchString(kv.Value) {
+				return fmt.Errorf("value %q does not match pattern %q", kv.Value, c.Pattern)
+			}
+		}
+		if len(c.AllowedValues) > 0 && !slices.Contains(c.AllowedValues, kv.Value) {
+			return fmt.Errorf("value %q is not one of %s", kv.Value, strings.Join(c.AllowedValues, ", "))
+		}
+	case persistence.TypeBoolean:
+		if kv.Value != "true" && kv.Value != "false" {
+			return fmt.Errorf("value %q is not a boolean, use true or false", kv.Value)
+		}
+	case persistence.TypeInteger:
+		i, err := strconv.ParseInt(kv.Value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("value %q is not an integer", kv.Value)
+		}
+		return checkRange(kv.Value, float64(i), c)
+	case persistence.TypeFloat:
+		f, err := strconv.ParseFloat(kv.Value, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("value %q is not a finite float", kv.Value)
+		}
+		return checkRange(kv.Value, f, c)
+	case persistence.TypeEnum:
+		if !slices.Contains(c.AllowedValues, kv.Value) {
+			return fmt.Errorf("value %q is not one of %s", kv.Value, strings.Join(c.AllowedValues, ", "))
+		}
+	case persistence.TypeJSON:
+		if !json.Valid([]byte(kv.Value)) {
+			return fmt.Errorf("value is not valid JSON")
+		}
+		if c.JSONSchema != "" {
+			schema, err := compileSchema(c.JSONSchema)
+			if err != nil {
+				return err
+			}
+			instance, err := jsonschema.UnmarshalJSON(strings.NewReader(kv.Value))
+			if err != nil {
+				return fmt.Errorf("value is not valid JSON")
+			}
+			if err := schema.Validate(instance); err != nil {
+				return fmt.Errorf("value does not conform to JSON schema: %v", err)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown value type %q", kv.Type)
+	}
+	return nil
+}
+
+func checkRange(value string, n float64, c *persistence.Constraints) error {
+	if c.Min != nil && n < *c.Min {
+		return fmt.Errorf("value %s is less than minimum %v", value, *c.Min)
+	}
+	if c.Max != nil && n > *c.Max {
+		return fmt.Errorf("value %s is greater than maximum %v", value, *c.Max)
+	}
+	return nil
+}
+
+// compilePattern compiles the pattern so that it has to match the whole value
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+func compileSchema(schema string) (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(schema))
+	if err != nil {
+		return nil, err
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("schema.json", doc); err != nil {
+		return nil, err
+	}
+	return compiler.Compile("schema.json")
+}
+
+// historyTypes maps the actions of history entries to the event types of the API
+var historyTypes = map[persistence.Action]featurev1.EventType{
+	persistence.ActionCreate: featurev1.EventType_EVENT_TYPE_CREATE,
+	persistence.ActionUpdate: featurev1.EventType_EVENT_TYPE_UPDATE,
+	persistence.ActionDelete: featurev1.EventType_EVENT_TYPE_DELETE,
+}
+
+func toProtoHistoryEntry(entry persistence.HistoryEntry) *featurev1.HistoryEntry {
+	return &featurev1.HistoryEntry{
+		Key:       entry.Key,
+		Revision:  entry.Revision,
+		Type:      historyTypes[entry.Action],
+		OldValue:  entry.OldValue,
+		NewValue:  entry.NewValue,
+		Actor:     entry.Actor,
+		Timestamp: timestamppb.New(entry.Timestamp),
+		Source:    entry.Source,
+	}
+}
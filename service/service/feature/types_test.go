@@ -0,0 +1,118 @@
+package feature
+
+import (
+	"testing"
+
+	featurev1 "github.com/dkrizic/feature/service/service/feature/v1"
+	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/stretchr/testify/assert"
+)
+
+func float(f float64) *float64 { return &f }
+
+func TestValidateValue(t *testing.T) {
+	schema := `{"type":"object","properties":{"limit":{"type":"integer"}},"required":["limit"]}`
+	tests := []struct {
+		name  string
+		kv    persistence.KeyValue
+		valid bool
+	}{
+		{"untyped accepts anything", persistence.KeyValue{Value: "ture"}, true},
+		{"boolean true", persistence.KeyValue{Type: persistence.TypeBoolean, Value: "true"}, true},
+		{"boolean typo", persistence.KeyValue{Type: persistence.TypeBoolean, Value: "ture"}, false},
+		{"boolean numeric", persistence.KeyValue{Type: persistence.TypeBoolean, Value: "1"}, false},
+		{"integer", persistence.KeyValue{Type: persistence.TypeInteger, Value: "42"}, true},
+		{"integer with fraction", persistence.KeyValue{Type: persistence.TypeInteger, Value: "4.2"}, false},
+		{"integer in range", persistence.KeyValue{Type: persistence.TypeInteger, Value: "5", Constraints: &persistence.Constraints{Min: float(1), Max: float(5)}}, true},
+		{"integer above max", persistence.KeyValue{Type: persistence.TypeInteger, Value: "6", Constraints: &persistence.Constraints{Max: float(5)}}, false},
+		{"float", persistence.KeyValue{Type: persistence.TypeFloat, Value: "0.25"}, true},
+		{"float below min", persistence.KeyValue{Type: persistence.TypeFloat, Value: "-0.1", Constraints: &persistence.Constraints{Min: float(0)}}, false},
+		{"float NaN", persistence.KeyValue{Type: persistence.TypeFloat, Value: "NaN"}, false},
+		{"string matches pattern", persistence.KeyValue{Type: persistence.TypeString, Value: "v1.2", Constraints: &persistence.Constraints{Pattern: `v\d+\.\d+`}}, true},
+		{"pattern must match whole value", persistence.KeyValue{Type: persistence.TypeString, Value: "xv1.2", Constraints: &persistence.Constraints{Pattern: `v\d+\.\d+`}}, false},
+		{"string allowed value", persistence.KeyValue{Type: persistence.TypeString, Value: "b", Constraints: &persistence.Constraints{AllowedValues: []string{"a", "b"}}}, true},
+		{"enum allowed value", persistence.KeyValue{Type: persistence.TypeEnum, Value: "red", Constraints: &persistence.Constraints{AllowedValues: []string{"red", "green"}}}, true},
+		{"enum other value", persistence.KeyValue{Type: persistence.TypeEnum, Value: "blue", Constraints: &persistence.Constraints{AllowedValues: []string{"red", "green"}}}, false},
+		{"json", persistence.KeyValue{Type: persistence.TypeJSON, Value: `{"a":[1,2]}`}, true},
+		{"json malformed", persistence.KeyValue{Type: persistence.TypeJSON, Value: `{"a":`}, false},
+		{"json conforms to schema", persistence.KeyValue{Type: persistence.TypeJSON, Value: `{"limit":3}`, Constraints: &persistence.Constraints{JSONSchema: schema}}, true},
+		{"json violates schema", persistence.KeyValue{Type: persistence.TypeJSON, Value: `{"limit":"3"}`, Constraints: &persistence.Constraints{JSONSchema: schema}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateValue(tt.kv)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateSpec(t *testing.T) {
+	tests := []struct {
+		name        string
+		valueType   persistence.ValueType
+		constraints *persistence.Constraints
+		valid       bool
+	}{
+		{"untyped", "", nil, true},
+		{"constraints without type", "", &persistence.Constraints{Pattern: "a"}, false},
+		{"unknown type", "date", nil, false},
+		{"enum without values", persistence.TypeEnum, nil, false},
+		{"range on integer", persistence.TypeInteger, &persistence.Constraints{Min: float(0), Max: float(10)}, true},
+		{"inverted range", persistence.TypeInteger, &persistence.Constraints{Min: float(10), Max: float(0)}, false},
+		{"range on string", persistence.TypeString, &persistence.Constraints{Max: float(10)}, false},
+		{"invalid pattern", persistence.TypeString, &persistence.Constraints{Pattern: "("}, false},
+		{"pattern on enum", persistence.TypeEnum, &persistence.Constraints{Pattern: "a", AllowedValues: []string{"a"}}, false},
+		{"invalid schema", persistence.TypeJSON, &persistence.Constraints{JSONSchema: `{"type": 5}`}, false},
+		{"schema on string", persistence.TypeString, &persistence.Constraints{JSONSchema: `{}`}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSpec(tt.valueType, tt.constraints)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestProtoConversion(t *testing.T) {
+	for pt, vt := range valueTypes {
+		converted, err := fromProtoType(pt)
+		assert.NoError(t, err)
+		assert.Equal(t, vt, converted)
+		assert.Equal(t, pt, toProtoType(vt))
+	}
+	assert.Equal(t, featurev1.ValueType_VALUE_TYPE_STRING, toProtoType(""))
+
+	_, err := fromProtoType(featurev1.ValueType(99))
+	assert.Error(t, err)
+
+	assert.Nil(t, fromProtoConstraints(&featurev1.Constraints{}))
+	c := fromProtoConstraints(&featurev1.Constraints{Min: float(1)})
+	assert.Equal(t, 1.0, *c.Min)
+}
+
+func TestRulesConversion(t *testing.T) {
+	rules := []*featurev1.Rule{{
+		Name:  "beta",
+		Value: "on",
+		Conditions: []*featurev1.Condition{
+			{Attribute: "tenant", Operator: featurev1.Operator_OPERATOR_IN, Values: []string{"acme", "globex"}},
+			{Attribute: "appVersion", Operator: featurev1.Operator_OPERATOR_SEMVER_GREATER_OR_EQUAL, Values: []string{"2.0.0"}},
+		},
+	}}
+	converted, err := fromProtoRules(rules)
+	assert.NoError(t, err)
+	assert.Equal(t, persistence.OperatorIn, converted[0].Conditions[0].Operator)
+	assert.Equal(t, persistence.OperatorSemverGreaterOrEqual, converted[0].Conditions[1].Operator)
+	assert.Equal(t, rules[0].Conditions[1].Operator, toProtoRules(converted)[0].Conditions[1].Operator)
+
+	_, err = fromProtoRules([]*featurev1.Rule{{Conditions: []*featurev1.Condition{{Attribute: "tenant"}}}})
+	assert.Error(t, err)
+}
@@ -10,6 +10,7 @@ import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -22,6 +23,424 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// ValueType is the declared type of a flag value
+type ValueType int32
+
+const (
+	// VALUE_TYPE_UNSPECIFIED keeps the stored type on Set, untyped flags behave like strings
+	ValueType_VALUE_TYPE_UNSPECIFIED ValueType = 0
+	ValueType_VALUE_TYPE_STRING      ValueType = 1
+	ValueType_VALUE_TYPE_BOOLEAN     ValueType = 2
+	ValueType_VALUE_TYPE_INTEGER     ValueType = 3
+	ValueType_VALUE_TYPE_FLOAT       ValueType = 4
+	ValueType_VALUE_TYPE_ENUM        ValueType = 5
+	ValueType_VALUE_TYPE_JSON        ValueType = 6
+)
+
+// Enum value maps for ValueType.
+var (
+	ValueType_name = map[int32]string{
+		0: "VALUE_TYPE_UNSPECIFIED",
+		1: "VALUE_TYPE_STRING",
+		2: "VALUE_TYPE_BOOLEAN",
+		3: "VALUE_TYPE_INTEGER",
+		4: "VALUE_TYPE_FLOAT",
+		5: "VALUE_TYPE_ENUM",
+		6: "VALUE_TYPE_JSON",
+	}
+	ValueType_value = map[string]int32{
+		"VALUE_TYPE_UNSPECIFIED": 0,
+		"VALUE_TYPE_STRING":      1,
+		"VALUE_TYPE_BOOLEAN":     2,
+		"VALUE_TYPE_INTEGER":     3,
+		"VALUE_TYPE_FLOAT":       4,
+		"VALUE_TYPE_ENUM":        5,
+		"VALUE_TYPE_JSON":        6,
+	}
+)
+
+func (x ValueType) Enum() *ValueType {
+	p := new(ValueType)
+	*p = x
+	return p
+}
+
+func (x ValueType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ValueType) Descriptor() protoreflect.EnumDescriptor {
+	return file_feature_proto_enumTypes[0].Descriptor()
+}
+
+func (ValueType) Type() protoreflect.EnumType {
+	return &file_feature_proto_enumTypes[0]
+}
+
+func (x ValueType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ValueType.Descriptor instead.
+func (ValueType) EnumDescriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{0}
+}
+
+// Lifecycle tells whether a flag is meant to be removed eventually
+type Lifecycle int32
+
+const (
+	Lifecycle_LIFECYCLE_UNSPECIFIED Lifecycle = 0
+	// LIFECYCLE_TEMPORARY flags like release toggles are removed once rolled out
+	Lifecycle_LIFECYCLE_TEMPORARY Lifecycle = 1
+	// LIFECYCLE_PERMANENT flags like kill switches are never reported as unused
+	Lifecycle_LIFECYCLE_PERMANENT Lifecycle = 2
+)
+
+// Enum value maps for Lifecycle.
+var (
+	Lifecycle_name = map[int32]string{
+		0: "LIFECYCLE_UNSPECIFIED",
+		1: "LIFECYCLE_TEMPORARY",
+		2: "LIFECYCLE_PERMANENT",
+	}
+	Lifecycle_value = map[string]int32{
+		"LIFECYCLE_UNSPECIFIED": 0,
+		"LIFECYCLE_TEMPORARY":   1,
+		"LIFECYCLE_PERMANENT":   2,
+	}
+)
+
+func (x Lifecycle) Enum() *Lifecycle {
+	p := new(Lifecycle)
+	*p = x
+	return p
+}
+
+func (x Lifecycle) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Lifecycle) Descriptor() protoreflect.EnumDescriptor {
+	return file_feature_proto_enumTypes[1].Descriptor()
+}
+
+func (Lifecycle) Type() protoreflect.EnumType {
+	return &file_feature_proto_enumTypes[1]
+}
+
+func (x Lifecycle) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Lifecycle.Descriptor instead.
+func (Lifecycle) EnumDescriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{1}
+}
+
+// Operator compares an attribute of the evaluation context with the values of a condition
+type Operator int32
+
+const (
+	Operator_OPERATOR_UNSPECIFIED Operator = 0
+	Operator_OPERATOR_EQUALS      Operator = 1
+	Operator_OPERATOR_NOT_EQUALS  Operator = 2
+	Operator_OPERATOR_IN          Operator = 3
+	Operator_OPERATOR_NOT_IN      Operator = 4
+	// OPERATOR_REGEX matches if the attribute matches the regular expression completely
+	Operator_OPERATOR_REGEX Operator = 5
+	// the semver operators compare semantic versions, a leading "v" is optional
+	Operator_OPERATOR_SEMVER_EQUALS           Operator = 6
+	Operator_OPERATOR_SEMVER_LESS_THAN        Operator = 7
+	Operator_OPERATOR_SEMVER_LESS_OR_EQUAL    Operator = 8
+	Operator_OPERATOR_SEMVER_GREATER_THAN     Operator = 9
+	Operator_OPERATOR_SEMVER_GREATER_OR_EQUAL Operator = 10
+)
+
+// Enum value maps for Operator.
+var (
+	Operator_name = map[int32]string{
+		0:  "OPERATOR_UNSPECIFIED",
+		1:  "OPERATOR_EQUALS",
+		2:  "OPERATOR_NOT_EQUALS",
+		3:  "OPERATOR_IN",
+		4:  "OPERATOR_NOT_IN",
+		5:  "OPERATOR_REGEX",
+		6:  "OPERATOR_SEMVER_EQUALS",
+		7:  "OPERATOR_SEMVER_LESS_THAN",
+		8:  "OPERATOR_SEMVER_LESS_OR_EQUAL",
+		9:  "OPERATOR_SEMVER_GREATER_THAN",
+		10: "OPERATOR_SEMVER_GREATER_OR_EQUAL",
+	}
+	Operator_value = map[string]int32{
+		"OPERATOR_UNSPECIFIED":             0,
+		"OPERATOR_EQUALS":                  1,
+		"OPERATOR_NOT_EQUALS":              2,
+		"OPERATOR_IN":                      3,
+		"OPERATOR_NOT_IN":                  4,
+		"OPERATOR_REGEX":                   5,
+		"OPERATOR_SEMVER_EQUALS":           6,
+		"OPERATOR_SEMVER_LESS_THAN":        7,
+		"OPERATOR_SEMVER_LESS_OR_EQUAL":    8,
+		"OPERATOR_SEMVER_GREATER_THAN":     9,
+		"OPERATOR_SEMVER_GREATER_OR_EQUAL": 10,
+	}
+)
+
+func (x Operator) Enum() *Operator {
+	p := new(Operator)
+	*p = x
+	return p
+}
+
+func (x Operator) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Operator) Descriptor() protoreflect.EnumDescriptor {
+	return file_feature_proto_enumTypes[2].Descriptor()
+}
+
+func (Operator) Type() protoreflect.EnumType {
+	return &file_feature_proto_enumTypes[2]
+}
+
+func (x Operator) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Operator.Descriptor instead.
+func (Operator) EnumDescriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{2}
+}
+
+// Reason tells why an evaluation returned its value
+type Reason int32
+
+const (
+	Reason_REASON_UNSPECIFIED Reason = 0
+	// REASON_DEFAULT means no rule matched and the stored value was returned
+	Reason_REASON_DEFAULT    Reason = 1
+	Reason_REASON_RULE_MATCH Reason = 2
+	// REASON_ROLLOUT means the value is a variant of the rollout of the matching rule or the flag
+	Reason_REASON_ROLLOUT Reason = 3
+	// REASON_PREREQUISITE_FAILED means a prerequisite was not met and the off value was returned
+	Reason_REASON_PREREQUISITE_FAILED Reason = 4
+)
+
+// Enum value maps for Reason.
+var (
+	Reason_name = map[int32]string{
+		0: "REASON_UNSPECIFIED",
+		1: "REASON_DEFAULT",
+		2: "REASON_RULE_MATCH",
+		3: "REASON_ROLLOUT",
+		4: "REASON_PREREQUISITE_FAILED",
+	}
+	Reason_value = map[string]int32{
+		"REASON_UNSPECIFIED":         0,
+		"REASON_DEFAULT":             1,
+		"REASON_RULE_MATCH":          2,
+		"REASON_ROLLOUT":             3,
+		"REASON_PREREQUISITE_FAILED": 4,
+	}
+)
+
+func (x Reason) Enum() *Reason {
+	p := new(Reason)
+	*p = x
+	return p
+}
+
+func (x Reason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Reason) Descriptor() protoreflect.EnumDescriptor {
+	return file_feature_proto_enumTypes[3].Descriptor()
+}
+
+func (Reason) Type() protoreflect.EnumType {
+	return &file_feature_proto_enumTypes[3]
+}
+
+func (x Reason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Reason.Descriptor instead.
+func (Reason) EnumDescriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{3}
+}
+
+// EventType describes what a WatchEvent represents
+type EventType int32
+
+const (
+	EventType_EVENT_TYPE_UNSPECIFIED EventType = 0
+	// EVENT_TYPE_SNAPSHOT is a key that existed when the watch started
+	EventType_EVENT_TYPE_SNAPSHOT EventType = 1
+	// EVENT_TYPE_SYNCED marks the end of the snapshot or replayed backlog
+	EventType_EVENT_TYPE_SYNCED EventType = 2
+	EventType_EVENT_TYPE_CREATE EventType = 3
+	EventType_EVENT_TYPE_UPDATE EventType = 4
+	EventType_EVENT_TYPE_DELETE EventType = 5
+)
+
+// Enum value maps for EventType.
+var (
+	EventType_name = map[int32]string{
+		0: "EVENT_TYPE_UNSPECIFIED",
+		1: "EVENT_TYPE_SNAPSHOT",
+		2: "EVENT_TYPE_SYNCED",
+		3: "EVENT_TYPE_CREATE",
+		4: "EVENT_TYPE_UPDATE",
+		5: "EVENT_TYPE_DELETE",
+	}
+	EventType_value = map[string]int32{
+		"EVENT_TYPE_UNSPECIFIED": 0,
+		"EVENT_TYPE_SNAPSHOT":    1,
+		"EVENT_TYPE_SYNCED":      2,
+		"EVENT_TYPE_CREATE":      3,
+		"EVENT_TYPE_UPDATE":      4,
+		"EVENT_TYPE_DELETE":      5,
+	}
+)
+
+func (x EventType) Enum() *EventType {
+	p := new(EventType)
+	*p = x
+	return p
+}
+
+func (x EventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_feature_proto_enumTypes[4].Descriptor()
+}
+
+func (EventType) Type() protoreflect.EnumType {
+	return &file_feature_proto_enumTypes[4]
+}
+
+func (x EventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventType.Descriptor instead.
+func (EventType) EnumDescriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{4}
+}
+
+// StaleReason tells why a flag is stale
+type StaleReason int32
+
+const (
+	StaleReason_STALE_REASON_UNSPECIFIED StaleReason = 0
+	// STALE_REASON_EXPIRED flags are past their expiry date
+	StaleReason_STALE_REASON_EXPIRED StaleReason = 1
+	// STALE_REASON_UNUSED flags were neither changed nor read for the configured period
+	StaleReason_STALE_REASON_UNUSED StaleReason = 2
+)
+
+// Enum value maps for StaleReason.
+var (
+	StaleReason_name = map[int32]string{
+		0: "STALE_REASON_UNSPECIFIED",
+		1: "STALE_REASON_EXPIRED",
+		2: "STALE_REASON_UNUSED",
+	}
+	StaleReason_value = map[string]int32{
+		"STALE_REASON_UNSPECIFIED": 0,
+		"STALE_REASON_EXPIRED":     1,
+		"STALE_REASON_UNUSED":      2,
+	}
+)
+
+func (x StaleReason) Enum() *StaleReason {
+	p := new(StaleReason)
+	*p = x
+	return p
+}
+
+func (x StaleReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (StaleReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_feature_proto_enumTypes[5].Descriptor()
+}
+
+func (StaleReason) Type() protoreflect.EnumType {
+	return &file_feature_proto_enumTypes[5]
+}
+
+func (x StaleReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use StaleReason.Descriptor instead.
+func (StaleReason) EnumDescriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{5}
+}
+
+type TokenScope int32
+
+const (
+	TokenScope_TOKEN_SCOPE_UNSPECIFIED TokenScope = 0
+	// TOKEN_SCOPE_READ allows the methods that only read flags
+	TokenScope_TOKEN_SCOPE_READ TokenScope = 1
+	// TOKEN_SCOPE_WRITE allows the methods that change flags, e.g. Set, Delete and Batch
+	TokenScope_TOKEN_SCOPE_WRITE TokenScope = 2
+	// TOKEN_SCOPE_RESTART allows restarting workloads
+	TokenScope_TOKEN_SCOPE_RESTART TokenScope = 3
+)
+
+// Enum value maps for TokenScope.
+var (
+	TokenScope_name = map[int32]string{
+		0: "TOKEN_SCOPE_UNSPECIFIED",
+		1: "TOKEN_SCOPE_READ",
+		2: "TOKEN_SCOPE_WRITE",
+		3: "TOKEN_SCOPE_RESTART",
+	}
+	TokenScope_value = map[string]int32{
+		"TOKEN_SCOPE_UNSPECIFIED": 0,
+		"TOKEN_SCOPE_READ":        1,
+		"TOKEN_SCOPE_WRITE":       2,
+		"TOKEN_SCOPE_RESTART":     3,
+	}
+)
+
+func (x TokenScope) Enum() *TokenScope {
+	p := new(TokenScope)
+	*p = x
+	return p
+}
+
+func (x TokenScope) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TokenScope) Descriptor() protoreflect.EnumDescriptor {
+	return file_feature_proto_enumTypes[6].Descriptor()
+}
+
+func (TokenScope) Type() protoreflect.EnumType {
+	return &file_feature_proto_enumTypes[6]
+}
+
+func (x TokenScope) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TokenScope.Descriptor instead.
+func (TokenScope) EnumDescriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{6}
+}
+
 type Key struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
@@ -110,29 +529,36 @@ func (x *Value) GetName() string {
 	return ""
 }
 
-type KeyValue struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
-	Editable      bool                   `protobuf:"varint,3,opt,name=editable,proto3" json:"editable,omitempty"`
+// Constraints restrict the values a typed flag accepts
+type Constraints struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// min and max bound integer and float values (inclusive)
+	Min *float64 `protobuf:"fixed64,1,opt,name=min,proto3,oneof" json:"min,omitempty"`
+	Max *float64 `protobuf:"fixed64,2,opt,name=max,proto3,oneof" json:"max,omitempty"`
+	// pattern is a regular expression a string value must match
+	Pattern string `protobuf:"bytes,3,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	// allowedValues lists the values of an enum, or restricts a string
+	AllowedValues []string `protobuf:"bytes,4,rep,name=allowedValues,proto3" json:"allowedValues,omitempty"`
+	// jsonSchema is a JSON schema a JSON value must conform to
+	JsonSchema    string `protobuf:"bytes,5,opt,name=jsonSchema,proto3" json:"jsonSchema,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *KeyValue) Reset() {
-	*x = KeyValue{}
+func (x *Constraints) Reset() {
+	*x = Constraints{}
 	mi := &file_feature_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *KeyValue) String() string {
+func (x *Constraints) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*KeyValue) ProtoMessage() {}
+func (*Constraints) ProtoMessage() {}
 
-func (x *KeyValue) ProtoReflect() protoreflect.Message {
+func (x *Constraints) ProtoReflect() protoreflect.Message {
 	mi := &file_feature_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -144,107 +570,3067 @@ func (x *KeyValue) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use KeyValue.ProtoReflect.Descriptor instead.
-func (*KeyValue) Descriptor() ([]byte, []int) {
+// Deprecated: Use Constraints.ProtoReflect.Descriptor instead.
+func (*Constraints) Descriptor() ([]byte, []int) {
 	return file_feature_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *KeyValue) GetKey() string {
+func (x *Constraints) GetMin() float64 {
+	if x != nil && x.Min != nil {
+		return *x.Min
+	}
+	return 0
+}
+
+func (x *Constraints) GetMax() float64 {
+	if x != nil && x.Max != nil {
+		return *x.Max
+	}
+	return 0
+}
+
+func (x *Constraints) GetPattern() string {
 	if x != nil {
-		return x.Key
+		return x.Pattern
 	}
 	return ""
 }
 
-func (x *KeyValue) GetValue() string {
+func (x *Constraints) GetAllowedValues() []string {
 	if x != nil {
-		return x.Value
+		return x.AllowedValues
+	}
+	return nil
+}
+
+func (x *Constraints) GetJsonSchema() string {
+	if x != nil {
+		return x.JsonSchema
 	}
 	return ""
 }
 
-func (x *KeyValue) GetEditable() bool {
+// Metadata documents a flag. On Set and PreSet description, owner and tags replace the
+// stored ones if metadata is present, the remaining fields are maintained by the service.
+type Metadata struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Description string                 `protobuf:"bytes,1,opt,name=description,proto3" json:"description,omitempty"`
+	Owner       string                 `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	Tags        []string               `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+	CreatedAt   *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
+	UpdatedAt   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=updatedAt,proto3" json:"updatedAt,omitempty"`
+	// lastModifiedBy is the principal of the last change, empty without authentication
+	LastModifiedBy string `protobuf:"bytes,6,opt,name=lastModifiedBy,proto3" json:"lastModifiedBy,omitempty"`
+	// expiresAt is the date after which a temporary flag should be removed, it is reported as stale then
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=expiresAt,proto3" json:"expiresAt,omitempty"`
+	Lifecycle     Lifecycle              `protobuf:"varint,8,opt,name=lifecycle,proto3,enum=feature.v1.Lifecycle" json:"lifecycle,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Metadata) Reset() {
+	*x = Metadata{}
+	mi := &file_feature_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Metadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Metadata) ProtoMessage() {}
+
+func (x *Metadata) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[3]
 	if x != nil {
-		return x.Editable
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return false
+	return mi.MessageOf(x)
 }
 
-var File_feature_proto protoreflect.FileDescriptor
+// Deprecated: Use Metadata.ProtoReflect.Descriptor instead.
+func (*Metadata) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{3}
+}
 
-const file_feature_proto_rawDesc = "" +
-	"\n" +
-	"\rfeature.proto\x12\n" +
-	"feature.v1\x1a\x1bgoogle/protobuf/empty.proto\"\x19\n" +
-	"\x03Key\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\"\x1b\n" +
-	"\x05Value\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\"N\n" +
-	"\bKeyValue\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value\x12\x1a\n" +
-	"\beditable\x18\x03 \x01(\bR\beditable2\x8e\x02\n" +
-	"\aFeature\x128\n" +
-	"\x06GetAll\x12\x16.google.protobuf.Empty\x1a\x14.feature.v1.KeyValue0\x01\x126\n" +
-	"\x06PreSet\x12\x14.feature.v1.KeyValue\x1a\x16.google.protobuf.Empty\x123\n" +
-	"\x03Set\x12\x14.feature.v1.KeyValue\x1a\x16.google.protobuf.Empty\x12)\n" +
-	"\x03Get\x12\x0f.feature.v1.Key\x1a\x11.feature.v1.Value\x121\n" +
-	"\x06Delete\x12\x0f.feature.v1.Key\x1a\x16.google.protobuf.EmptyBHZFgithub.com/dkrizic/feature/service/service/feature/featurev1;featurev1b\x06proto3"
+func (x *Metadata) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
 
-var (
-	file_feature_proto_rawDescOnce sync.Once
-	file_feature_proto_rawDescData []byte
-)
+func (x *Metadata) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
 
-func file_feature_proto_rawDescGZIP() []byte {
-	file_feature_proto_rawDescOnce.Do(func() {
-		file_feature_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_feature_proto_rawDesc), len(file_feature_proto_rawDesc)))
-	})
-	return file_feature_proto_rawDescData
+func (x *Metadata) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
 }
 
-var file_feature_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
-var file_feature_proto_goTypes = []any{
-	(*Key)(nil),           // 0: feature.v1.Key
-	(*Value)(nil),         // 1: feature.v1.Value
-	(*KeyValue)(nil),      // 2: feature.v1.KeyValue
-	(*emptypb.Empty)(nil), // 3: google.protobuf.Empty
+func (x *Metadata) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
 }
-var file_feature_proto_depIdxs = []int32{
-	3, // 0: feature.v1.Feature.GetAll:input_type -> google.protobuf.Empty
-	2, // 1: feature.v1.Feature.PreSet:input_type -> feature.v1.KeyValue
-	2, // 2: feature.v1.Feature.Set:input_type -> feature.v1.KeyValue
-	0, // 3: feature.v1.Feature.Get:input_type -> feature.v1.Key
-	0, // 4: feature.v1.Feature.Delete:input_type -> feature.v1.Key
-	2, // 5: feature.v1.Feature.GetAll:output_type -> feature.v1.KeyValue
-	3, // 6: feature.v1.Feature.PreSet:output_type -> google.protobuf.Empty
-	3, // 7: feature.v1.Feature.Set:output_type -> google.protobuf.Empty
-	1, // 8: feature.v1.Feature.Get:output_type -> feature.v1.Value
-	3, // 9: feature.v1.Feature.Delete:output_type -> google.protobuf.Empty
-	5, // [5:10] is the sub-list for method output_type
-	0, // [0:5] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+
+func (x *Metadata) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
 }
 
-func init() { file_feature_proto_init() }
-func file_feature_proto_init() {
-	if File_feature_proto != nil {
-		return
+func (x *Metadata) GetLastModifiedBy() string {
+	if x != nil {
+		return x.LastModifiedBy
+	}
+	return ""
+}
+
+func (x *Metadata) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *Metadata) GetLifecycle() Lifecycle {
+	if x != nil {
+		return x.Lifecycle
+	}
+	return Lifecycle_LIFECYCLE_UNSPECIFIED
+}
+
+// Condition tests one attribute. IN and NOT_IN take any number of values, the others exactly one.
+type Condition struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// attribute is userId, tenant, region or the name of an entry in attributes
+	Attribute     string   `protobuf:"bytes,1,opt,name=attribute,proto3" json:"attribute,omitempty"`
+	Operator      Operator `protobuf:"varint,2,opt,name=operator,proto3,enum=feature.v1.Operator" json:"operator,omitempty"`
+	Values        []string `protobuf:"bytes,3,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Condition) Reset() {
+	*x = Condition{}
+	mi := &file_feature_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Condition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Condition) ProtoMessage() {}
+
+func (x *Condition) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Condition.ProtoReflect.Descriptor instead.
+func (*Condition) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Condition) GetAttribute() string {
+	if x != nil {
+		return x.Attribute
+	}
+	return ""
+}
+
+func (x *Condition) GetOperator() Operator {
+	if x != nil {
+		return x.Operator
+	}
+	return Operator_OPERATOR_UNSPECIFIED
+}
+
+func (x *Condition) GetValues() []string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+// Variant serves value to a percentage of the callers
+type Variant struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Value string                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	// percentage between 0 and 100, with a resolution of 0.01
+	Percentage    float64 `protobuf:"fixed64,2,opt,name=percentage,proto3" json:"percentage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Variant) Reset() {
+	*x = Variant{}
+	mi := &file_feature_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Variant) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Variant) ProtoMessage() {}
+
+func (x *Variant) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Variant.ProtoReflect.Descriptor instead.
+func (*Variant) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Variant) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *Variant) GetPercentage() float64 {
+	if x != nil {
+		return x.Percentage
+	}
+	return 0
+}
+
+// Rollout assigns callers to buckets by hashing an attribute of the evaluation context together
+// with the flag key, so a caller always lands in the same bucket. The variants take the buckets in
+// order, callers in the remaining buckets and callers without the attribute get the fallback value.
+type Rollout struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// bucketBy is the attribute to hash, userId if empty
+	BucketBy string `protobuf:"bytes,1,opt,name=bucketBy,proto3" json:"bucketBy,omitempty"`
+	// the percentages of all variants add up to at most 100
+	Variants      []*Variant `protobuf:"bytes,2,rep,name=variants,proto3" json:"variants,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Rollout) Reset() {
+	*x = Rollout{}
+	mi := &file_feature_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rollout) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rollout) ProtoMessage() {}
+
+func (x *Rollout) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rollout.ProtoReflect.Descriptor instead.
+func (*Rollout) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Rollout) GetBucketBy() string {
+	if x != nil {
+		return x.BucketBy
+	}
+	return ""
+}
+
+func (x *Rollout) GetVariants() []*Variant {
+	if x != nil {
+		return x.Variants
+	}
+	return nil
+}
+
+// Rule serves value if all of its conditions match, or one of the variants of its rollout
+type Rule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Conditions    []*Condition           `protobuf:"bytes,2,rep,name=conditions,proto3" json:"conditions,omitempty"`
+	Value         string                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Rollout       *Rollout               `protobuf:"bytes,4,opt,name=rollout,proto3" json:"rollout,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Rule) Reset() {
+	*x = Rule{}
+	mi := &file_feature_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rule) ProtoMessage() {}
+
+func (x *Rule) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rule.ProtoReflect.Descriptor instead.
+func (*Rule) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Rule) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Rule) GetConditions() []*Condition {
+	if x != nil {
+		return x.Conditions
+	}
+	return nil
+}
+
+func (x *Rule) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *Rule) GetRollout() *Rollout {
+	if x != nil {
+		return x.Rollout
+	}
+	return nil
+}
+
+// Rules are the ordered targeting rules of a flag, the first matching rule wins
+type Rules struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Rules []*Rule                `protobuf:"bytes,2,rep,name=rules,proto3" json:"rules,omitempty"`
+	// redacted is set by GetRules if the flag is sensitive and the caller may not see the values
+	// of its rules, they are empty then
+	Redacted      bool `protobuf:"varint,3,opt,name=redacted,proto3" json:"redacted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Rules) Reset() {
+	*x = Rules{}
+	mi := &file_feature_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rules) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rules) ProtoMessage() {}
+
+func (x *Rules) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rules.ProtoReflect.Descriptor instead.
+func (*Rules) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Rules) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Rules) GetRules() []*Rule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *Rules) GetRedacted() bool {
+	if x != nil {
+		return x.Redacted
+	}
+	return false
+}
+
+// Prerequisite requires the flag key of the same set and environment to evaluate to value
+type Prerequisite struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Prerequisite) Reset() {
+	*x = Prerequisite{}
+	mi := &file_feature_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Prerequisite) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Prerequisite) ProtoMessage() {}
+
+func (x *Prerequisite) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Prerequisite.ProtoReflect.Descriptor instead.
+func (*Prerequisite) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Prerequisite) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Prerequisite) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+// Prerequisites must all be met before rules, rollout and value of a flag apply, otherwise the flag
+// evaluates to offValue
+type Prerequisites struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Prerequisites []*Prerequisite        `protobuf:"bytes,2,rep,name=prerequisites,proto3" json:"prerequisites,omitempty"`
+	// offValue is served if a prerequisite is not met, empty means false for boolean flags
+	OffValue      string `protobuf:"bytes,3,opt,name=offValue,proto3" json:"offValue,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Prerequisites) Reset() {
+	*x = Prerequisites{}
+	mi := &file_feature_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Prerequisites) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Prerequisites) ProtoMessage() {}
+
+func (x *Prerequisites) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Prerequisites.ProtoReflect.Descriptor instead.
+func (*Prerequisites) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Prerequisites) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Prerequisites) GetPrerequisites() []*Prerequisite {
+	if x != nil {
+		return x.Prerequisites
+	}
+	return nil
+}
+
+func (x *Prerequisites) GetOffValue() string {
+	if x != nil {
+		return x.OffValue
+	}
+	return ""
+}
+
+type KeyValue struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Key         string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value       string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Editable    bool                   `protobuf:"varint,3,opt,name=editable,proto3" json:"editable,omitempty"`
+	Type        ValueType              `protobuf:"varint,4,opt,name=type,proto3,enum=feature.v1.ValueType" json:"type,omitempty"`
+	Constraints *Constraints           `protobuf:"bytes,5,opt,name=constraints,proto3" json:"constraints,omitempty"`
+	Metadata    *Metadata              `protobuf:"bytes,6,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	// rules are reported by GetAll and ignored by Set and PreSet, use SetRules to change them
+	Rules []*Rule `protobuf:"bytes,7,rep,name=rules,proto3" json:"rules,omitempty"`
+	// rollout applies if no rule matches, it is reported by GetAll and ignored by Set and PreSet,
+	// use SetRollout to change it
+	Rollout *Rollout `protobuf:"bytes,8,opt,name=rollout,proto3" json:"rollout,omitempty"`
+	// revision changes with every write of the key. Passing the revision to Set makes the write
+	// conditional: it fails with FailedPrecondition if the key was changed in the meantime.
+	// Zero writes unconditionally.
+	Revision uint64 `protobuf:"varint,9,opt,name=revision,proto3" json:"revision,omitempty"`
+	// redacted is set if the flag is sensitive and the caller may not see its value. The value and
+	// the values of rules and rollout are empty then.
+	Redacted bool `protobuf:"varint,10,opt,name=redacted,proto3" json:"redacted,omitempty"`
+	// prerequisites and offValue are reported by GetAll and ignored by Set and PreSet, use
+	// SetPrerequisites to change them
+	Prerequisites []*Prerequisite `protobuf:"bytes,11,rep,name=prerequisites,proto3" json:"prerequisites,omitempty"`
+	OffValue      string          `protobuf:"bytes,12,opt,name=offValue,proto3" json:"offValue,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyValue) Reset() {
+	*x = KeyValue{}
+	mi := &file_feature_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyValue) ProtoMessage() {}
+
+func (x *KeyValue) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyValue.ProtoReflect.Descriptor instead.
+func (*KeyValue) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *KeyValue) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KeyValue) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *KeyValue) GetEditable() bool {
+	if x != nil {
+		return x.Editable
+	}
+	return false
+}
+
+func (x *KeyValue) GetType() ValueType {
+	if x != nil {
+		return x.Type
+	}
+	return ValueType_VALUE_TYPE_UNSPECIFIED
+}
+
+func (x *KeyValue) GetConstraints() *Constraints {
+	if x != nil {
+		return x.Constraints
+	}
+	return nil
+}
+
+func (x *KeyValue) GetMetadata() *Metadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *KeyValue) GetRules() []*Rule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *KeyValue) GetRollout() *Rollout {
+	if x != nil {
+		return x.Rollout
+	}
+	return nil
+}
+
+func (x *KeyValue) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *KeyValue) GetRedacted() bool {
+	if x != nil {
+		return x.Redacted
+	}
+	return false
+}
+
+func (x *KeyValue) GetPrerequisites() []*Prerequisite {
+	if x != nil {
+		return x.Prerequisites
+	}
+	return nil
+}
+
+func (x *KeyValue) GetOffValue() string {
+	if x != nil {
+		return x.OffValue
+	}
+	return ""
+}
+
+// SetRolloutRequest replaces the rollout of a flag, a rollout without variants removes it
+type SetRolloutRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Rollout       *Rollout               `protobuf:"bytes,2,opt,name=rollout,proto3" json:"rollout,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetRolloutRequest) Reset() {
+	*x = SetRolloutRequest{}
+	mi := &file_feature_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetRolloutRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetRolloutRequest) ProtoMessage() {}
+
+func (x *SetRolloutRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetRolloutRequest.ProtoReflect.Descriptor instead.
+func (*SetRolloutRequest) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SetRolloutRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SetRolloutRequest) GetRollout() *Rollout {
+	if x != nil {
+		return x.Rollout
+	}
+	return nil
+}
+
+// EvaluationContext describes the caller a flag is evaluated for
+type EvaluationContext struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=userId,proto3" json:"userId,omitempty"`
+	Tenant        string                 `protobuf:"bytes,2,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	Region        string                 `protobuf:"bytes,3,opt,name=region,proto3" json:"region,omitempty"`
+	Attributes    map[string]string      `protobuf:"bytes,4,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EvaluationContext) Reset() {
+	*x = EvaluationContext{}
+	mi := &file_feature_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EvaluationContext) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvaluationContext) ProtoMessage() {}
+
+func (x *EvaluationContext) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvaluationContext.ProtoReflect.Descriptor instead.
+func (*EvaluationContext) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *EvaluationContext) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *EvaluationContext) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *EvaluationContext) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *EvaluationContext) GetAttributes() map[string]string {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+type EvaluateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Context       *EvaluationContext     `protobuf:"bytes,2,opt,name=context,proto3" json:"context,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EvaluateRequest) Reset() {
+	*x = EvaluateRequest{}
+	mi := &file_feature_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EvaluateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvaluateRequest) ProtoMessage() {}
+
+func (x *EvaluateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvaluateRequest.ProtoReflect.Descriptor instead.
+func (*EvaluateRequest) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *EvaluateRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *EvaluateRequest) GetContext() *EvaluationContext {
+	if x != nil {
+		return x.Context
+	}
+	return nil
+}
+
+// Explanation tells how an evaluation arrived at its value, for debugging
+type Explanation struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// bucketBy is the attribute used for bucketing, empty if no rollout applied
+	BucketBy string `protobuf:"bytes,1,opt,name=bucketBy,proto3" json:"bucketBy,omitempty"`
+	// bucket is between 0 and 9999 (hundredths of a percent), -1 if the caller was not bucketed
+	Bucket int32 `protobuf:"varint,2,opt,name=bucket,proto3" json:"bucket,omitempty"`
+	// variantIndex is the position of the served variant, -1 if none was served
+	VariantIndex int32 `protobuf:"varint,3,opt,name=variantIndex,proto3" json:"variantIndex,omitempty"`
+	// message summarizes the evaluation in words
+	Message       string `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Explanation) Reset() {
+	*x = Explanation{}
+	mi := &file_feature_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Explanation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Explanation) ProtoMessage() {}
+
+func (x *Explanation) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Explanation.ProtoReflect.Descriptor instead.
+func (*Explanation) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Explanation) GetBucketBy() string {
+	if x != nil {
+		return x.BucketBy
+	}
+	return ""
+}
+
+func (x *Explanation) GetBucket() int32 {
+	if x != nil {
+		return x.Bucket
+	}
+	return 0
+}
+
+func (x *Explanation) GetVariantIndex() int32 {
+	if x != nil {
+		return x.VariantIndex
+	}
+	return 0
+}
+
+func (x *Explanation) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type EvaluateResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Key    string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value  string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Reason Reason                 `protobuf:"varint,3,opt,name=reason,proto3,enum=feature.v1.Reason" json:"reason,omitempty"`
+	// ruleIndex is the position of the matching rule, -1 if none matched
+	RuleIndex   int32        `protobuf:"varint,4,opt,name=ruleIndex,proto3" json:"ruleIndex,omitempty"`
+	RuleName    string       `protobuf:"bytes,5,opt,name=ruleName,proto3" json:"ruleName,omitempty"`
+	Explanation *Explanation `protobuf:"bytes,6,opt,name=explanation,proto3" json:"explanation,omitempty"`
+	// prerequisite is the key of the prerequisite that was not met, empty if all were met
+	Prerequisite  string `protobuf:"bytes,7,opt,name=prerequisite,proto3" json:"prerequisite,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EvaluateResponse) Reset() {
+	*x = EvaluateResponse{}
+	mi := &file_feature_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EvaluateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvaluateResponse) ProtoMessage() {}
+
+func (x *EvaluateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvaluateResponse.ProtoReflect.Descriptor instead.
+func (*EvaluateResponse) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *EvaluateResponse) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *EvaluateResponse) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *EvaluateResponse) GetReason() Reason {
+	if x != nil {
+		return x.Reason
+	}
+	return Reason_REASON_UNSPECIFIED
+}
+
+func (x *EvaluateResponse) GetRuleIndex() int32 {
+	if x != nil {
+		return x.RuleIndex
+	}
+	return 0
+}
+
+func (x *EvaluateResponse) GetRuleName() string {
+	if x != nil {
+		return x.RuleName
+	}
+	return ""
+}
+
+func (x *EvaluateResponse) GetExplanation() *Explanation {
+	if x != nil {
+		return x.Explanation
+	}
+	return nil
+}
+
+func (x *EvaluateResponse) GetPrerequisite() string {
+	if x != nil {
+		return x.Prerequisite
+	}
+	return ""
+}
+
+// WatchRequest starts a watch. A non-zero revision resumes after that revision
+// if it is still retained by the service, otherwise a fresh snapshot is sent.
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Revision      uint64                 `protobuf:"varint,1,opt,name=revision,proto3" json:"revision,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_feature_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *WatchRequest) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+// WatchEvent is a single change pushed to watchers
+type WatchEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          EventType              `protobuf:"varint,1,opt,name=type,proto3,enum=feature.v1.EventType" json:"type,omitempty"`
+	KeyValue      *KeyValue              `protobuf:"bytes,2,opt,name=keyValue,proto3" json:"keyValue,omitempty"`
+	Revision      uint64                 `protobuf:"varint,3,opt,name=revision,proto3" json:"revision,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEvent) Reset() {
+	*x = WatchEvent{}
+	mi := &file_feature_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEvent) ProtoMessage() {}
+
+func (x *WatchEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEvent.ProtoReflect.Descriptor instead.
+func (*WatchEvent) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *WatchEvent) GetType() EventType {
+	if x != nil {
+		return x.Type
+	}
+	return EventType_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *WatchEvent) GetKeyValue() *KeyValue {
+	if x != nil {
+		return x.KeyValue
+	}
+	return nil
+}
+
+func (x *WatchEvent) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+// HistoryEntry records one change of a key
+type HistoryEntry struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// revision numbers the changes of a key, starting at 1
+	Revision uint64 `protobuf:"varint,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	// type is EVENT_TYPE_CREATE, EVENT_TYPE_UPDATE or EVENT_TYPE_DELETE
+	Type     EventType `protobuf:"varint,3,opt,name=type,proto3,enum=feature.v1.EventType" json:"type,omitempty"`
+	OldValue string    `protobuf:"bytes,4,opt,name=oldValue,proto3" json:"oldValue,omitempty"`
+	NewValue string    `protobuf:"bytes,5,opt,name=newValue,proto3" json:"newValue,omitempty"`
+	// actor is the principal that made the change, empty without authentication
+	Actor     string                 `protobuf:"bytes,6,opt,name=actor,proto3" json:"actor,omitempty"`
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// source is the RPC that made the change, e.g. Set or Rollback
+	Source string `protobuf:"bytes,8,opt,name=source,proto3" json:"source,omitempty"`
+	// redacted is set if the flag is sensitive and the caller may not see its values, oldValue and
+	// newValue are empty then
+	Redacted      bool `protobuf:"varint,9,opt,name=redacted,proto3" json:"redacted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryEntry) Reset() {
+	*x = HistoryEntry{}
+	mi := &file_feature_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryEntry) ProtoMessage() {}
+
+func (x *HistoryEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryEntry.ProtoReflect.Descriptor instead.
+func (*HistoryEntry) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *HistoryEntry) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *HistoryEntry) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *HistoryEntry) GetType() EventType {
+	if x != nil {
+		return x.Type
+	}
+	return EventType_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *HistoryEntry) GetOldValue() string {
+	if x != nil {
+		return x.OldValue
+	}
+	return ""
+}
+
+func (x *HistoryEntry) GetNewValue() string {
+	if x != nil {
+		return x.NewValue
+	}
+	return ""
+}
+
+func (x *HistoryEntry) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *HistoryEntry) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *HistoryEntry) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *HistoryEntry) GetRedacted() bool {
+	if x != nil {
+		return x.Redacted
+	}
+	return false
+}
+
+type HistoryResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// entries are ordered by revision, oldest first
+	Entries       []*HistoryEntry `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryResponse) Reset() {
+	*x = HistoryResponse{}
+	mi := &file_feature_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryResponse) ProtoMessage() {}
+
+func (x *HistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryResponse.ProtoReflect.Descriptor instead.
+func (*HistoryResponse) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *HistoryResponse) GetEntries() []*HistoryEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+// RollbackRequest restores a key to the state after the change with the given revision
+type RollbackRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Revision      uint64                 `protobuf:"varint,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RollbackRequest) Reset() {
+	*x = RollbackRequest{}
+	mi := &file_feature_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RollbackRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RollbackRequest) ProtoMessage() {}
+
+func (x *RollbackRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RollbackRequest.ProtoReflect.Descriptor instead.
+func (*RollbackRequest) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *RollbackRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *RollbackRequest) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+// Operation is one change of a batch
+type Operation struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Operation:
+	//
+	//	*Operation_Set
+	//	*Operation_Delete
+	Operation     isOperation_Operation `protobuf_oneof:"operation"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Operation) Reset() {
+	*x = Operation{}
+	mi := &file_feature_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Operation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Operation) ProtoMessage() {}
+
+func (x *Operation) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Operation.ProtoReflect.Descriptor instead.
+func (*Operation) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *Operation) GetOperation() isOperation_Operation {
+	if x != nil {
+		return x.Operation
+	}
+	return nil
+}
+
+func (x *Operation) GetSet() *KeyValue {
+	if x != nil {
+		if x, ok := x.Operation.(*Operation_Set); ok {
+			return x.Set
+		}
+	}
+	return nil
+}
+
+func (x *Operation) GetDelete() *Key {
+	if x != nil {
+		if x, ok := x.Operation.(*Operation_Delete); ok {
+			return x.Delete
+		}
+	}
+	return nil
+}
+
+type isOperation_Operation interface {
+	isOperation_Operation()
+}
+
+type Operation_Set struct {
+	// set is checked and stored like a Set, including a conditional revision
+	Set *KeyValue `protobuf:"bytes,1,opt,name=set,proto3,oneof"`
+}
+
+type Operation_Delete struct {
+	Delete *Key `protobuf:"bytes,2,opt,name=delete,proto3,oneof"`
+}
+
+func (*Operation_Set) isOperation_Operation() {}
+
+func (*Operation_Delete) isOperation_Operation() {}
+
+// BatchRequest applies all operations or none, each key at most once
+type BatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Operations    []*Operation           `protobuf:"bytes,1,rep,name=operations,proto3" json:"operations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchRequest) Reset() {
+	*x = BatchRequest{}
+	mi := &file_feature_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchRequest) ProtoMessage() {}
+
+func (x *BatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchRequest.ProtoReflect.Descriptor instead.
+func (*BatchRequest) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *BatchRequest) GetOperations() []*Operation {
+	if x != nil {
+		return x.Operations
+	}
+	return nil
+}
+
+// FlagSet is a named set of flags with its own storage partition, e.g. its own ConfigMap. The
+// other RPCs work on the set named in the request metadata "feature-set", the default set if it
+// is missing or "default". The default set is configured by the flags of the service.
+type FlagSet struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// name is a lowercase DNS label of at most 40 characters
+	Name        string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description string `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	// editable lists the keys that may be changed, empty means all
+	Editable []string `protobuf:"bytes,3,rep,name=editable,proto3" json:"editable,omitempty"`
+	// principals may use the set, empty means everybody
+	Principals []string `protobuf:"bytes,4,rep,name=principals,proto3" json:"principals,omitempty"`
+	// restartType and restartName select the workload Workload.Restart restarts for the set
+	RestartType   string `protobuf:"bytes,5,opt,name=restartType,proto3" json:"restartType,omitempty"`
+	RestartName   string `protobuf:"bytes,6,opt,name=restartName,proto3" json:"restartName,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FlagSet) Reset() {
+	*x = FlagSet{}
+	mi := &file_feature_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FlagSet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlagSet) ProtoMessage() {}
+
+func (x *FlagSet) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlagSet.ProtoReflect.Descriptor instead.
+func (*FlagSet) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *FlagSet) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *FlagSet) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *FlagSet) GetEditable() []string {
+	if x != nil {
+		return x.Editable
+	}
+	return nil
+}
+
+func (x *FlagSet) GetPrincipals() []string {
+	if x != nil {
+		return x.Principals
+	}
+	return nil
+}
+
+func (x *FlagSet) GetRestartType() string {
+	if x != nil {
+		return x.RestartType
+	}
+	return ""
+}
+
+func (x *FlagSet) GetRestartName() string {
+	if x != nil {
+		return x.RestartName
+	}
+	return ""
+}
+
+type FlagSets struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// sets lists the default set first and the others by name
+	Sets          []*FlagSet `protobuf:"bytes,1,rep,name=sets,proto3" json:"sets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FlagSets) Reset() {
+	*x = FlagSets{}
+	mi := &file_feature_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FlagSets) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlagSets) ProtoMessage() {}
+
+func (x *FlagSets) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlagSets.ProtoReflect.Descriptor instead.
+func (*FlagSets) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *FlagSets) GetSets() []*FlagSet {
+	if x != nil {
+		return x.Sets
+	}
+	return nil
+}
+
+// Environments are the environments of the service, e.g. dev, staging and prod. Every flag set
+// holds separate flags per environment. The other RPCs work on the environment named in the
+// request metadata "feature-environment", the first one if it is missing.
+type Environments struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// names lists the environments in the configured order, empty if none are configured
+	Names         []string `protobuf:"bytes,1,rep,name=names,proto3" json:"names,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Environments) Reset() {
+	*x = Environments{}
+	mi := &file_feature_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Environments) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Environments) ProtoMessage() {}
+
+func (x *Environments) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Environments.ProtoReflect.Descriptor instead.
+func (*Environments) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *Environments) GetNames() []string {
+	if x != nil {
+		return x.Names
+	}
+	return nil
+}
+
+// PromoteRequest copies a flag of the selected set from one environment to another
+type PromoteRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	From  string                 `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To    string                 `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	// dryRun only reports the changes
+	DryRun bool `protobuf:"varint,4,opt,name=dryRun,proto3" json:"dryRun,omitempty"`
+	// revision makes the promotion conditional on the revision of the key in the target
+	// environment like Set, zero promotes unconditionally
+	Revision      uint64 `protobuf:"varint,5,opt,name=revision,proto3" json:"revision,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PromoteRequest) Reset() {
+	*x = PromoteRequest{}
+	mi := &file_feature_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PromoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PromoteRequest) ProtoMessage() {}
+
+func (x *PromoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PromoteRequest.ProtoReflect.Descriptor instead.
+func (*PromoteRequest) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *PromoteRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *PromoteRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *PromoteRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *PromoteRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *PromoteRequest) GetRevision() uint64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+type PromoteResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Source *KeyValue              `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	// target is the flag in the target environment before the promotion, missing if it is new
+	Target *KeyValue `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	// changes describe the differences, e.g. "value: red -> blue", empty if there are none
+	Changes []string `protobuf:"bytes,3,rep,name=changes,proto3" json:"changes,omitempty"`
+	// applied is set if the flag was written to the target environment
+	Applied       bool `protobuf:"varint,4,opt,name=applied,proto3" json:"applied,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PromoteResponse) Reset() {
+	*x = PromoteResponse{}
+	mi := &file_feature_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PromoteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PromoteResponse) ProtoMessage() {}
+
+func (x *PromoteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PromoteResponse.ProtoReflect.Descriptor instead.
+func (*PromoteResponse) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *PromoteResponse) GetSource() *KeyValue {
+	if x != nil {
+		return x.Source
+	}
+	return nil
+}
+
+func (x *PromoteResponse) GetTarget() *KeyValue {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+func (x *PromoteResponse) GetChanges() []string {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+func (x *PromoteResponse) GetApplied() bool {
+	if x != nil {
+		return x.Applied
+	}
+	return false
+}
+
+// Schedule is a change of a flag value planned for a later time
+type Schedule struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// id is assigned by ScheduleSet
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Key   string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Value string                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	At    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=at,proto3" json:"at,omitempty"`
+	// restart restarts the workload of the set after the change, see Workload.Restart
+	Restart bool `protobuf:"varint,5,opt,name=restart,proto3" json:"restart,omitempty"`
+	// environment is the environment the change is applied to
+	Environment string `protobuf:"bytes,6,opt,name=environment,proto3" json:"environment,omitempty"`
+	// createdBy is the principal that scheduled the change, it is applied on its behalf
+	CreatedBy string                 `protobuf:"bytes,7,opt,name=createdBy,proto3" json:"createdBy,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
+	// error is set if applying the change failed, it is not retried then
+	Error string `protobuf:"bytes,9,opt,name=error,proto3" json:"error,omitempty"`
+	// redacted is set if the flag is sensitive and the caller may not see the value, value is empty then
+	Redacted      bool `protobuf:"varint,10,opt,name=redacted,proto3" json:"redacted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Schedule) Reset() {
+	*x = Schedule{}
+	mi := &file_feature_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Schedule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Schedule) ProtoMessage() {}
+
+func (x *Schedule) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Schedule.ProtoReflect.Descriptor instead.
+func (*Schedule) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *Schedule) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Schedule) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Schedule) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *Schedule) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *Schedule) GetRestart() bool {
+	if x != nil {
+		return x.Restart
+	}
+	return false
+}
+
+func (x *Schedule) GetEnvironment() string {
+	if x != nil {
+		return x.Environment
+	}
+	return ""
+}
+
+func (x *Schedule) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+func (x *Schedule) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Schedule) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Schedule) GetRedacted() bool {
+	if x != nil {
+		return x.Redacted
+	}
+	return false
+}
+
+type ScheduleSetRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	// at must be in the future
+	At            *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=at,proto3" json:"at,omitempty"`
+	Restart       bool                   `protobuf:"varint,4,opt,name=restart,proto3" json:"restart,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScheduleSetRequest) Reset() {
+	*x = ScheduleSetRequest{}
+	mi := &file_feature_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScheduleSetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScheduleSetRequest) ProtoMessage() {}
+
+func (x *ScheduleSetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScheduleSetRequest.ProtoReflect.Descriptor instead.
+func (*ScheduleSetRequest) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ScheduleSetRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ScheduleSetRequest) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *ScheduleSetRequest) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+func (x *ScheduleSetRequest) GetRestart() bool {
+	if x != nil {
+		return x.Restart
+	}
+	return false
+}
+
+type Schedules struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Schedules     []*Schedule            `protobuf:"bytes,1,rep,name=schedules,proto3" json:"schedules,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Schedules) Reset() {
+	*x = Schedules{}
+	mi := &file_feature_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Schedules) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Schedules) ProtoMessage() {}
+
+func (x *Schedules) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Schedules.ProtoReflect.Descriptor instead.
+func (*Schedules) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *Schedules) GetSchedules() []*Schedule {
+	if x != nil {
+		return x.Schedules
+	}
+	return nil
+}
+
+// StaleFlag is a flag marked as stale by the lifecycle job
+type StaleFlag struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Key    string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Reason StaleReason            `protobuf:"varint,2,opt,name=reason,proto3,enum=feature.v1.StaleReason" json:"reason,omitempty"`
+	// since is the time the flag was marked as stale
+	Since *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=since,proto3" json:"since,omitempty"`
+	// lastRead is the last time the flag was read with Get, GetAll or Evaluate, unset if it was not read
+	// since tracking started
+	LastRead      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=lastRead,proto3" json:"lastRead,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=expiresAt,proto3" json:"expiresAt,omitempty"`
+	Owner         string                 `protobuf:"bytes,6,opt,name=owner,proto3" json:"owner,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StaleFlag) Reset() {
+	*x = StaleFlag{}
+	mi := &file_feature_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StaleFlag) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StaleFlag) ProtoMessage() {}
+
+func (x *StaleFlag) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StaleFlag.ProtoReflect.Descriptor instead.
+func (*StaleFlag) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *StaleFlag) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *StaleFlag) GetReason() StaleReason {
+	if x != nil {
+		return x.Reason
+	}
+	return StaleReason_STALE_REASON_UNSPECIFIED
+}
+
+func (x *StaleFlag) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *StaleFlag) GetLastRead() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastRead
+	}
+	return nil
+}
+
+func (x *StaleFlag) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *StaleFlag) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+type StaleFlags struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Flags         []*StaleFlag           `protobuf:"bytes,1,rep,name=flags,proto3" json:"flags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StaleFlags) Reset() {
+	*x = StaleFlags{}
+	mi := &file_feature_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StaleFlags) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StaleFlags) ProtoMessage() {}
+
+func (x *StaleFlags) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StaleFlags.ProtoReflect.Descriptor instead.
+func (*StaleFlags) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *StaleFlags) GetFlags() []*StaleFlag {
+	if x != nil {
+		return x.Flags
+	}
+	return nil
+}
+
+// Grant allows a method to the caller
+type Grant struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// method is the name of the RPC of the Feature or Workload service, e.g. Set or Restart
+	Method string `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"`
+	// keys are the patterns of the keys the method may change, e.g. CHECKOUT_*, empty means all
+	Keys          []string `protobuf:"bytes,2,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Grant) Reset() {
+	*x = Grant{}
+	mi := &file_feature_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Grant) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Grant) ProtoMessage() {}
+
+func (x *Grant) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Grant.ProtoReflect.Descriptor instead.
+func (*Grant) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *Grant) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *Grant) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+// Permissions are what the caller may do according to its roles
+type Permissions struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Principal string                 `protobuf:"bytes,1,opt,name=principal,proto3" json:"principal,omitempty"`
+	Roles     []string               `protobuf:"bytes,2,rep,name=roles,proto3" json:"roles,omitempty"`
+	// unrestricted is set if the caller may call every method on every key, e.g. without a policy
+	Unrestricted bool `protobuf:"varint,3,opt,name=unrestricted,proto3" json:"unrestricted,omitempty"`
+	// grants lists the methods the caller may call, unless unrestricted is set
+	Grants        []*Grant `protobuf:"bytes,4,rep,name=grants,proto3" json:"grants,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Permissions) Reset() {
+	*x = Permissions{}
+	mi := &file_feature_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Permissions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Permissions) ProtoMessage() {}
+
+func (x *Permissions) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Permissions.ProtoReflect.Descriptor instead.
+func (*Permissions) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *Permissions) GetPrincipal() string {
+	if x != nil {
+		return x.Principal
+	}
+	return ""
+}
+
+func (x *Permissions) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+func (x *Permissions) GetUnrestricted() bool {
+	if x != nil {
+		return x.Unrestricted
+	}
+	return false
+}
+
+func (x *Permissions) GetGrants() []*Grant {
+	if x != nil {
+		return x.Grants
+	}
+	return nil
+}
+
+// Token is an API token for machine clients, sent as authorization: Bearer <secret>
+type Token struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// id is assigned by CreateToken
+	Id     string       `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name   string       `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Scopes []TokenScope `protobuf:"varint,3,rep,packed,name=scopes,proto3,enum=feature.v1.TokenScope" json:"scopes,omitempty"`
+	// expiresAt is when the token stops working, unset means never
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=expiresAt,proto3" json:"expiresAt,omitempty"`
+	// createdBy is the principal that created the token
+	CreatedBy     string                 `protobuf:"bytes,5,opt,name=createdBy,proto3" json:"createdBy,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Token) Reset() {
+	*x = Token{}
+	mi := &file_feature_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Token) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Token) ProtoMessage() {}
+
+func (x *Token) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Token.ProtoReflect.Descriptor instead.
+func (*Token) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *Token) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Token) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Token) GetScopes() []TokenScope {
+	if x != nil {
+		return x.Scopes
+	}
+	return nil
+}
+
+func (x *Token) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *Token) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+func (x *Token) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type CreateTokenRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// name identifies the clients using the token, calls with it have the principal token:<name>
+	Name   string       `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Scopes []TokenScope `protobuf:"varint,2,rep,packed,name=scopes,proto3,enum=feature.v1.TokenScope" json:"scopes,omitempty"`
+	// expiresAt must be in the future if set
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=expiresAt,proto3" json:"expiresAt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTokenRequest) Reset() {
+	*x = CreateTokenRequest{}
+	mi := &file_feature_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTokenRequest) ProtoMessage() {}
+
+func (x *CreateTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTokenRequest.ProtoReflect.Descriptor instead.
+func (*CreateTokenRequest) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *CreateTokenRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateTokenRequest) GetScopes() []TokenScope {
+	if x != nil {
+		return x.Scopes
+	}
+	return nil
+}
+
+func (x *CreateTokenRequest) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+type CreateTokenResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Token *Token                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	// secret is the bearer token, it is only stored hashed and cannot be retrieved again
+	Secret        string `protobuf:"bytes,2,opt,name=secret,proto3" json:"secret,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTokenResponse) Reset() {
+	*x = CreateTokenResponse{}
+	mi := &file_feature_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTokenResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTokenResponse) ProtoMessage() {}
+
+func (x *CreateTokenResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTokenResponse.ProtoReflect.Descriptor instead.
+func (*CreateTokenResponse) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *CreateTokenResponse) GetToken() *Token {
+	if x != nil {
+		return x.Token
+	}
+	return nil
+}
+
+func (x *CreateTokenResponse) GetSecret() string {
+	if x != nil {
+		return x.Secret
+	}
+	return ""
+}
+
+type Tokens struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tokens        []*Token               `protobuf:"bytes,1,rep,name=tokens,proto3" json:"tokens,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tokens) Reset() {
+	*x = Tokens{}
+	mi := &file_feature_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tokens) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tokens) ProtoMessage() {}
+
+func (x *Tokens) ProtoReflect() protoreflect.Message {
+	mi := &file_feature_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tokens.ProtoReflect.Descriptor instead.
+func (*Tokens) Descriptor() ([]byte, []int) {
+	return file_feature_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *Tokens) GetTokens() []*Token {
+	if x != nil {
+		return x.Tokens
+	}
+	return nil
+}
+
+var File_feature_proto protoreflect.FileDescriptor
+
+const file_feature_proto_rawDesc = "" +
+	"\n" +
+	"\rfeature.proto\x12\n" +
+	"feature.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x19\n" +
+	"\x03Key\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\x1b\n" +
+	"\x05Value\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\xab\x01\n" +
+	"\vConstraints\x12\x15\n" +
+	"\x03min\x18\x01 \x01(\x01H\x00R\x03min\x88\x01\x01\x12\x15\n" +
+	"\x03max\x18\x02 \x01(\x01H\x01R\x03max\x88\x01\x01\x12\x18\n" +
+	"\apattern\x18\x03 \x01(\tR\apattern\x12$\n" +
+	"\rallowedValues\x18\x04 \x03(\tR\rallowedValues\x12\x1e\n" +
+	"\n" +
+	"jsonSchema\x18\x05 \x01(\tR\n" +
+	"jsonSchemaB\x06\n" +
+	"\x04_minB\x06\n" +
+	"\x04_max\"\xe1\x02\n" +
+	"\bMetadata\x12 \n" +
+	"\vdescription\x18\x01 \x01(\tR\vdescription\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\x12\x12\n" +
+	"\x04tags\x18\x03 \x03(\tR\x04tags\x128\n" +
+	"\tcreatedAt\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x128\n" +
+	"\tupdatedAt\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12&\n" +
+	"\x0elastModifiedBy\x18\x06 \x01(\tR\x0elastModifiedBy\x128\n" +
+	"\texpiresAt\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x123\n" +
+	"\tlifecycle\x18\b \x01(\x0e2\x15.feature.v1.LifecycleR\tlifecycle\"s\n" +
+	"\tCondition\x12\x1c\n" +
+	"\tattribute\x18\x01 \x01(\tR\tattribute\x120\n" +
+	"\boperator\x18\x02 \x01(\x0e2\x14.feature.v1.OperatorR\boperator\x12\x16\n" +
+	"\x06values\x18\x03 \x03(\tR\x06values\"?\n" +
+	"\aVariant\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\tR\x05value\x12\x1e\n" +
+	"\n" +
+	"percentage\x18\x02 \x01(\x01R\n" +
+	"percentage\"V\n" +
+	"\aRollout\x12\x1a\n" +
+	"\bbucketBy\x18\x01 \x01(\tR\bbucketBy\x12/\n" +
+	"\bvariants\x18\x02 \x03(\v2\x13.feature.v1.VariantR\bvariants\"\x96\x01\n" +
+	"\x04Rule\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x125\n" +
+	"\n" +
+	"conditions\x18\x02 \x03(\v2\x15.feature.v1.ConditionR\n" +
+	"conditions\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\x12-\n" +
+	"\arollout\x18\x04 \x01(\v2\x13.feature.v1.RolloutR\arollout\"]\n" +
+	"\x05Rules\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12&\n" +
+	"\x05rules\x18\x02 \x03(\v2\x10.feature.v1.RuleR\x05rules\x12\x1a\n" +
+	"\bredacted\x18\x03 \x01(\bR\bredacted\"6\n" +
+	"\fPrerequisite\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\"}\n" +
+	"\rPrerequisites\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12>\n" +
+	"\rprerequisites\x18\x02 \x03(\v2\x18.feature.v1.PrerequisiteR\rprerequisites\x12\x1a\n" +
+	"\boffValue\x18\x03 \x01(\tR\boffValue\"\xd1\x03\n" +
+	"\bKeyValue\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12\x1a\n" +
+	"\beditable\x18\x03 \x01(\bR\beditable\x12)\n" +
+	"\x04type\x18\x04 \x01(\x0e2\x15.feature.v1.ValueTypeR\x04type\x129\n" +
+	"\vconstraints\x18\x05 \x01(\v2\x17.feature.v1.ConstraintsR\vconstraints\x120\n" +
+	"\bmetadata\x18\x06 \x01(\v2\x14.feature.v1.MetadataR\bmetadata\x12&\n" +
+	"\x05rules\x18\a \x03(\v2\x10.feature.v1.RuleR\x05rules\x12-\n" +
+	"\arollout\x18\b \x01(\v2\x13.feature.v1.RolloutR\arollout\x12\x1a\n" +
+	"\brevision\x18\t \x01(\x04R\brevision\x12\x1a\n" +
+	"\bredacted\x18\n" +
+	" \x01(\bR\bredacted\x12>\n" +
+	"\rprerequisites\x18\v \x03(\v2\x18.feature.v1.PrerequisiteR\rprerequisites\x12\x1a\n" +
+	"\boffValue\x18\f \x01(\tR\boffValue\"T\n" +
+	"\x11SetRolloutRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12-\n" +
+	"\arollout\x18\x02 \x01(\v2\x13.feature.v1.RolloutR\arollout\"\xe9\x01\n" +
+	"\x11EvaluationContext\x12\x16\n" +
+	"\x06userId\x18\x01 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06tenant\x18\x02 \x01(\tR\x06tenant\x12\x16\n" +
+	"\x06region\x18\x03 \x01(\tR\x06region\x12M\n" +
+	"\n" +
+	"attributes\x18\x04 \x03(\v2-.feature.v1.EvaluationContext.AttributesEntryR\n" +
+	"attributes\x1a=\n" +
+	"\x0fAttributesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\\\n" +
+	"\x0fEvaluateRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x127\n" +
+	"\acontext\x18\x02 \x01(\v2\x1d.feature.v1.EvaluationContextR\acontext\"\x7f\n" +
+	"\vExplanation\x12\x1a\n" +
+	"\bbucketBy\x18\x01 \x01(\tR\bbucketBy\x12\x16\n" +
+	"\x06bucket\x18\x02 \x01(\x05R\x06bucket\x12\"\n" +
+	"\fvariantIndex\x18\x03 \x01(\x05R\fvariantIndex\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\"\xff\x01\n" +
+	"\x10EvaluateResponse\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12*\n" +
+	"\x06reason\x18\x03 \x01(\x0e2\x12.feature.v1.ReasonR\x06reason\x12\x1c\n" +
+	"\truleIndex\x18\x04 \x01(\x05R\truleIndex\x12\x1a\n" +
+	"\bruleName\x18\x05 \x01(\tR\bruleName\x129\n" +
+	"\vexplanation\x18\x06 \x01(\v2\x17.feature.v1.ExplanationR\vexplanation\x12\"\n" +
+	"\fprerequisite\x18\a \x01(\tR\fprerequisite\"*\n" +
+	"\fWatchRequest\x12\x1a\n" +
+	"\brevision\x18\x01 \x01(\x04R\brevision\"\x85\x01\n" +
+	"\n" +
+	"WatchEvent\x12)\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x15.feature.v1.EventTypeR\x04type\x120\n" +
+	"\bkeyValue\x18\x02 \x01(\v2\x14.feature.v1.KeyValueR\bkeyValue\x12\x1a\n" +
+	"\brevision\x18\x03 \x01(\x04R\brevision\"\xa3\x02\n" +
+	"\fHistoryEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x1a\n" +
+	"\brevision\x18\x02 \x01(\x04R\brevision\x12)\n" +
+	"\x04type\x18\x03 \x01(\x0e2\x15.feature.v1.EventTypeR\x04type\x12\x1a\n" +
+	"\boldValue\x18\x04 \x01(\tR\boldValue\x12\x1a\n" +
+	"\bnewValue\x18\x05 \x01(\tR\bnewValue\x12\x14\n" +
+	"\x05actor\x18\x06 \x01(\tR\x05actor\x128\n" +
+	"\ttimestamp\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x16\n" +
+	"\x06source\x18\b \x01(\tR\x06source\x12\x1a\n" +
+	"\bredacted\x18\t \x01(\bR\bredacted\"E\n" +
+	"\x0fHistoryResponse\x122\n" +
+	"\aentries\x18\x01 \x03(\v2\x18.feature.v1.HistoryEntryR\aentries\"?\n" +
+	"\x0fRollbackRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x1a\n" +
+	"\brevision\x18\x02 \x01(\x04R\brevision\"m\n" +
+	"\tOperation\x12(\n" +
+	"\x03set\x18\x01 \x01(\v2\x14.feature.v1.KeyValueH\x00R\x03set\x12)\n" +
+	"\x06delete\x18\x02 \x01(\v2\x0f.feature.v1.KeyH\x00R\x06deleteB\v\n" +
+	"\toperation\"E\n" +
+	"\fBatchRequest\x125\n" +
+	"\n" +
+	"operations\x18\x01 \x03(\v2\x15.feature.v1.OperationR\n" +
+	"operations\"\xbf\x01\n" +
+	"\aFlagSet\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x1a\n" +
+	"\beditable\x18\x03 \x03(\tR\beditable\x12\x1e\n" +
+	"\n" +
+	"principals\x18\x04 \x03(\tR\n" +
+	"principals\x12 \n" +
+	"\vrestartType\x18\x05 \x01(\tR\vrestartType\x12 \n" +
+	"\vrestartName\x18\x06 \x01(\tR\vrestartName\"3\n" +
+	"\bFlagSets\x12'\n" +
+	"\x04sets\x18\x01 \x03(\v2\x13.feature.v1.FlagSetR\x04sets\"$\n" +
+	"\fEnvironments\x12\x14\n" +
+	"\x05names\x18\x01 \x03(\tR\x05names\"z\n" +
+	"\x0ePromoteRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x03 \x01(\tR\x02to\x12\x16\n" +
+	"\x06dryRun\x18\x04 \x01(\bR\x06dryRun\x12\x1a\n" +
+	"\brevision\x18\x05 \x01(\x04R\brevision\"\xa1\x01\n" +
+	"\x0fPromoteResponse\x12,\n" +
+	"\x06source\x18\x01 \x01(\v2\x14.feature.v1.KeyValueR\x06source\x12,\n" +
+	"\x06target\x18\x02 \x01(\v2\x14.feature.v1.KeyValueR\x06target\x12\x18\n" +
+	"\achanges\x18\x03 \x03(\tR\achanges\x12\x18\n" +
+	"\aapplied\x18\x04 \x01(\bR\aapplied\"\xb4\x02\n" +
+	"\bSchedule\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\x12*\n" +
+	"\x02at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\x18\n" +
+	"\arestart\x18\x05 \x01(\bR\arestart\x12 \n" +
+	"\venvironment\x18\x06 \x01(\tR\venvironment\x12\x1c\n" +
+	"\tcreatedBy\x18\a \x01(\tR\tcreatedBy\x128\n" +
+	"\tcreatedAt\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x14\n" +
+	"\x05error\x18\t \x01(\tR\x05error\x12\x1a\n" +
+	"\bredacted\x18\n" +
+	" \x01(\bR\bredacted\"\x82\x01\n" +
+	"\x12ScheduleSetRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12*\n" +
+	"\x02at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02at\x12\x18\n" +
+	"\arestart\x18\x04 \x01(\bR\arestart\"?\n" +
+	"\tSchedules\x122\n" +
+	"\tschedules\x18\x01 \x03(\v2\x14.feature.v1.ScheduleR\tschedules\"\x88\x02\n" +
+	"\tStaleFlag\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12/\n" +
+	"\x06reason\x18\x02 \x01(\x0e2\x17.feature.v1.StaleReasonR\x06reason\x120\n" +
+	"\x05since\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x126\n" +
+	"\blastRead\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\blastRead\x128\n" +
+	"\texpiresAt\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x14\n" +
+	"\x05owner\x18\x06 \x01(\tR\x05owner\"9\n" +
+	"\n" +
+	"StaleFlags\x12+\n" +
+	"\x05flags\x18\x01 \x03(\v2\x15.feature.v1.StaleFlagR\x05flags\"3\n" +
+	"\x05Grant\x12\x16\n" +
+	"\x06method\x18\x01 \x01(\tR\x06method\x12\x12\n" +
+	"\x04keys\x18\x02 \x03(\tR\x04keys\"\x90\x01\n" +
+	"\vPermissions\x12\x1c\n" +
+	"\tprincipal\x18\x01 \x01(\tR\tprincipal\x12\x14\n" +
+	"\x05roles\x18\x02 \x03(\tR\x05roles\x12\"\n" +
+	"\funrestricted\x18\x03 \x01(\bR\funrestricted\x12)\n" +
+	"\x06grants\x18\x04 \x03(\v2\x11.feature.v1.GrantR\x06grants\"\xed\x01\n" +
+	"\x05Token\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12.\n" +
+	"\x06scopes\x18\x03 \x03(\x0e2\x16.feature.v1.TokenScopeR\x06scopes\x128\n" +
+	"\texpiresAt\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x1c\n" +
+	"\tcreatedBy\x18\x05 \x01(\tR\tcreatedBy\x128\n" +
+	"\tcreatedAt\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"\x92\x01\n" +
+	"\x12CreateTokenRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12.\n" +
+	"\x06scopes\x18\x02 \x03(\x0e2\x16.feature.v1.TokenScopeR\x06scopes\x128\n" +
+	"\texpiresAt\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"V\n" +
+	"\x13CreateTokenResponse\x12'\n" +
+	"\x05token\x18\x01 \x01(\v2\x11.feature.v1.TokenR\x05token\x12\x16\n" +
+	"\x06secret\x18\x02 \x01(\tR\x06secret\"3\n" +
+	"\x06Tokens\x12)\n" +
+	"\x06tokens\x18\x01 \x03(\v2\x11.feature.v1.TokenR\x06tokens*\xae\x01\n" +
+	"\tValueType\x12\x1a\n" +
+	"\x16VALUE_TYPE_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11VALUE_TYPE_STRING\x10\x01\x12\x16\n" +
+	"\x12VALUE_TYPE_BOOLEAN\x10\x02\x12\x16\n" +
+	"\x12VALUE_TYPE_INTEGER\x10\x03\x12\x14\n" +
+	"\x10VALUE_TYPE_FLOAT\x10\x04\x12\x13\n" +
+	"\x0fVALUE_TYPE_ENUM\x10\x05\x12\x13\n" +
+	"\x0fVALUE_TYPE_JSON\x10\x06*X\n" +
+	"\tLifecycle\x12\x19\n" +
+	"\x15LIFECYCLE_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13LIFECYCLE_TEMPORARY\x10\x01\x12\x17\n" +
+	"\x13LIFECYCLE_PERMANENT\x10\x02*\xb2\x02\n" +
+	"\bOperator\x12\x18\n" +
+	"\x14OPERATOR_UNSPECIFIED\x10\x00\x12\x13\n" +
+	"\x0fOPERATOR_EQUALS\x10\x01\x12\x17\n" +
+	"\x13OPERATOR_NOT_EQUALS\x10\x02\x12\x0f\n" +
+	"\vOPERATOR_IN\x10\x03\x12\x13\n" +
+	"\x0fOPERATOR_NOT_IN\x10\x04\x12\x12\n" +
+	"\x0eOPERATOR_REGEX\x10\x05\x12\x1a\n" +
+	"\x16OPERATOR_SEMVER_EQUALS\x10\x06\x12\x1d\n" +
+	"\x19OPERATOR_SEMVER_LESS_THAN\x10\a\x12!\n" +
+	"\x1dOPERATOR_SEMVER_LESS_OR_EQUAL\x10\b\x12 \n" +
+	"\x1cOPERATOR_SEMVER_GREATER_THAN\x10\t\x12$\n" +
+	" OPERATOR_SEMVER_GREATER_OR_EQUAL\x10\n" +
+	"*\x7f\n" +
+	"\x06Reason\x12\x16\n" +
+	"\x12REASON_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eREASON_DEFAULT\x10\x01\x12\x15\n" +
+	"\x11REASON_RULE_MATCH\x10\x02\x12\x12\n" +
+	"\x0eREASON_ROLLOUT\x10\x03\x12\x1e\n" +
+	"\x1aREASON_PREREQUISITE_FAILED\x10\x04*\x9c\x01\n" +
+	"\tEventType\x12\x1a\n" +
+	"\x16EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13EVENT_TYPE_SNAPSHOT\x10\x01\x12\x15\n" +
+	"\x11EVENT_TYPE_SYNCED\x10\x02\x12\x15\n" +
+	"\x11EVENT_TYPE_CREATE\x10\x03\x12\x15\n" +
+	"\x11EVENT_TYPE_UPDATE\x10\x04\x12\x15\n" +
+	"\x11EVENT_TYPE_DELETE\x10\x05*^\n" +
+	"\vStaleReason\x12\x1c\n" +
+	"\x18STALE_REASON_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14STALE_REASON_EXPIRED\x10\x01\x12\x17\n" +
+	"\x13STALE_REASON_UNUSED\x10\x02*o\n" +
+	"\n" +
+	"TokenScope\x12\x1b\n" +
+	"\x17TOKEN_SCOPE_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10TOKEN_SCOPE_READ\x10\x01\x12\x15\n" +
+	"\x11TOKEN_SCOPE_WRITE\x10\x02\x12\x17\n" +
+	"\x13TOKEN_SCOPE_RESTART\x10\x032\xfb\f\n" +
+	"\aFeature\x128\n" +
+	"\x06GetAll\x12\x16.google.protobuf.Empty\x1a\x14.feature.v1.KeyValue0\x01\x126\n" +
+	"\x06PreSet\x12\x14.feature.v1.KeyValue\x1a\x16.google.protobuf.Empty\x123\n" +
+	"\x03Set\x12\x14.feature.v1.KeyValue\x1a\x16.google.protobuf.Empty\x12)\n" +
+	"\x03Get\x12\x0f.feature.v1.Key\x1a\x11.feature.v1.Value\x121\n" +
+	"\x06Delete\x12\x0f.feature.v1.Key\x1a\x16.google.protobuf.Empty\x12;\n" +
+	"\x05Watch\x12\x18.feature.v1.WatchRequest\x1a\x16.feature.v1.WatchEvent0\x01\x12E\n" +
+	"\bEvaluate\x12\x1b.feature.v1.EvaluateRequest\x1a\x1c.feature.v1.EvaluateResponse\x12.\n" +
+	"\bGetRules\x12\x0f.feature.v1.Key\x1a\x11.feature.v1.Rules\x125\n" +
+	"\bSetRules\x12\x11.feature.v1.Rules\x1a\x16.google.protobuf.Empty\x12C\n" +
+	"\n" +
+	"SetRollout\x12\x1d.feature.v1.SetRolloutRequest\x1a\x16.google.protobuf.Empty\x127\n" +
+	"\aHistory\x12\x0f.feature.v1.Key\x1a\x1b.feature.v1.HistoryResponse\x12?\n" +
+	"\bRollback\x12\x1b.feature.v1.RollbackRequest\x1a\x16.google.protobuf.Empty\x129\n" +
+	"\x05Batch\x12\x18.feature.v1.BatchRequest\x1a\x16.google.protobuf.Empty\x128\n" +
+	"\bListSets\x12\x16.google.protobuf.Empty\x1a\x14.feature.v1.FlagSets\x128\n" +
+	"\tCreateSet\x12\x13.feature.v1.FlagSet\x1a\x16.google.protobuf.Empty\x128\n" +
+	"\tDeleteSet\x12\x13.feature.v1.FlagSet\x1a\x16.google.protobuf.Empty\x12D\n" +
+	"\x10ListEnvironments\x12\x16.google.protobuf.Empty\x1a\x18.feature.v1.Environments\x12B\n" +
+	"\aPromote\x12\x1a.feature.v1.PromoteRequest\x1a\x1b.feature.v1.PromoteResponse\x12C\n" +
+	"\vScheduleSet\x12\x1e.feature.v1.ScheduleSetRequest\x1a\x14.feature.v1.Schedule\x12>\n" +
+	"\rListSchedules\x12\x16.google.protobuf.Empty\x1a\x15.feature.v1.Schedules\x12>\n" +
+	"\x0eCancelSchedule\x12\x14.feature.v1.Schedule\x1a\x16.google.protobuf.Empty\x12;\n" +
+	"\tListStale\x12\x16.google.protobuf.Empty\x1a\x16.feature.v1.StaleFlags\x12E\n" +
+	"\x10SetPrerequisites\x12\x19.feature.v1.Prerequisites\x1a\x16.google.protobuf.Empty\x12A\n" +
+	"\x0eGetPermissions\x12\x16.google.protobuf.Empty\x1a\x17.feature.v1.Permissions\x12N\n" +
+	"\vCreateToken\x12\x1e.feature.v1.CreateTokenRequest\x1a\x1f.feature.v1.CreateTokenResponse\x128\n" +
+	"\n" +
+	"ListTokens\x12\x16.google.protobuf.Empty\x1a\x12.feature.v1.Tokens\x128\n" +
+	"\vRevokeToken\x12\x11.feature.v1.Token\x1a\x16.google.protobuf.EmptyBHZFgithub.com/dkrizic/feature/service/service/feature/featurev1;featurev1b\x06proto3"
+
+var (
+	file_feature_proto_rawDescOnce sync.Once
+	file_feature_proto_rawDescData []byte
+)
+
+func file_feature_proto_rawDescGZIP() []byte {
+	file_feature_proto_rawDescOnce.Do(func() {
+		file_feature_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_feature_proto_rawDesc), len(file_feature_proto_rawDesc)))
+	})
+	return file_feature_proto_rawDescData
+}
+
+var file_feature_proto_enumTypes = make([]protoimpl.EnumInfo, 7)
+var file_feature_proto_msgTypes = make([]protoimpl.MessageInfo, 41)
+var file_feature_proto_goTypes = []any{
+	(ValueType)(0),                // 0: feature.v1.ValueType
+	(Lifecycle)(0),                // 1: feature.v1.Lifecycle
+	(Operator)(0),                 // 2: feature.v1.Operator
+	(Reason)(0),                   // 3: feature.v1.Reason
+	(EventType)(0),                // 4: feature.v1.EventType
+	(StaleReason)(0),              // 5: feature.v1.StaleReason
+	(TokenScope)(0),               // 6: feature.v1.TokenScope
+	(*Key)(nil),                   // 7: feature.v1.Key
+	(*Value)(nil),                 // 8: feature.v1.Value
+	(*Constraints)(nil),           // 9: feature.v1.Constraints
+	(*Metadata)(nil),              // 10: feature.v1.Metadata
+	(*Condition)(nil),             // 11: feature.v1.Condition
+	(*Variant)(nil),               // 12: feature.v1.Variant
+	(*Rollout)(nil),               // 13: feature.v1.Rollout
+	(*Rule)(nil),                  // 14: feature.v1.Rule
+	(*Rules)(nil),                 // 15: feature.v1.Rules
+	(*Prerequisite)(nil),          // 16: feature.v1.Prerequisite
+	(*Prerequisites)(nil),         // 17: feature.v1.Prerequisites
+	(*KeyValue)(nil),              // 18: feature.v1.KeyValue
+	(*SetRolloutRequest)(nil),     // 19: feature.v1.SetRolloutRequest
+	(*EvaluationContext)(nil),     // 20: feature.v1.EvaluationContext
+	(*EvaluateRequest)(nil),       // 21: feature.v1.EvaluateRequest
+	(*Explanation)(nil),           // 22: feature.v1.Explanation
+	(*EvaluateResponse)(nil),      // 23: feature.v1.EvaluateResponse
+	(*WatchRequest)(nil),          // 24: feature.v1.WatchRequest
+	(*WatchEvent)(nil),            // 25: feature.v1.WatchEvent
+	(*HistoryEntry)(nil),          // 26: feature.v1.HistoryEntry
+	(*HistoryResponse)(nil),       // 27: feature.v1.HistoryResponse
+	(*RollbackRequest)(nil),       // 28: feature.v1.RollbackRequest
+	(*Operation)(nil),             // 29: feature.v1.Operation
+	(*BatchRequest)(nil),          // 30: feature.v1.BatchRequest
+	(*FlagSet)(nil),               // 31: feature.v1.FlagSet
+	(*FlagSets)(nil),              // 32: feature.v1.FlagSets
+	(*Environments)(nil),          // 33: feature.v1.Environments
+	(*PromoteRequest)(nil),        // 34: feature.v1.PromoteRequest
+	(*PromoteResponse)(nil),       // 35: feature.v1.PromoteResponse
+	(*Schedule)(nil),              // 36: feature.v1.Schedule
+	(*ScheduleSetRequest)(nil),    // 37: feature.v1.ScheduleSetRequest
+	(*Schedules)(nil),             // 38: feature.v1.Schedules
+	(*StaleFlag)(nil),             // 39: feature.v1.StaleFlag
+	(*StaleFlags)(nil),            // 40: feature.v1.StaleFlags
+	(*Grant)(nil),                 // 41: feature.v1.Grant
+	(*Permissions)(nil),           // 42: feature.v1.Permissions
+	(*Token)(nil),                 // 43: feature.v1.Token
+	(*CreateTokenRequest)(nil),    // 44: feature.v1.CreateTokenRequest
+	(*CreateTokenResponse)(nil),   // 45: feature.v1.CreateTokenResponse
+	(*Tokens)(nil),                // 46: feature.v1.Tokens
+	nil,                           // 47: feature.v1.EvaluationContext.AttributesEntry
+	(*timestamppb.Timestamp)(nil), // 48: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),         // 49: google.protobuf.Empty
+}
+var file_feature_proto_depIdxs = []int32{
+	48, // 0: feature.v1.Metadata.createdAt:type_name -> google.protobuf.Timestamp
+	48, // 1: feature.v1.Metadata.updatedAt:type_name -> google.protobuf.Timestamp
+	48, // 2: feature.v1.Metadata.expiresAt:type_name -> google.protobuf.Timestamp
+	1,  // 3: feature.v1.Metadata.lifecycle:type_name -> feature.v1.Lifecycle
+	2,  // 4: feature.v1.Condition.operator:type_name -> feature.v1.Operator
+	12, // 5: feature.v1.Rollout.variants:type_name -> feature.v1.Variant
+	11, // 6: feature.v1.Rule.conditions:type_name -> feature.v1.Condition
+	13, // 7: feature.v1.Rule.rollout:type_name -> feature.v1.Rollout
+	14, // 8: feature.v1.Rules.rules:type_name -> feature.v1.Rule
+	16, // 9: feature.v1.Prerequisites.prerequisites:type_name -> feature.v1.Prerequisite
+	0,  // 10: feature.v1.KeyValue.type:type_name -> feature.v1.ValueType
+	9,  // 11: feature.v1.KeyValue.constraints:type_name -> feature.v1.Constraints
+	10, // 12: feature.v1.KeyValue.metadata:type_name -> feature.v1.Metadata
+	14, // 13: feature.v1.KeyValue.rules:type_name -> feature.v1.Rule
+	13, // 14: feature.v1.KeyValue.rollout:type_name -> feature.v1.Rollout
+	16, // 15: feature.v1.KeyValue.prerequisites:type_name -> feature.v1.Prerequisite
+	13, // 16: feature.v1.SetRolloutRequest.rollout:type_name -> feature.v1.Rollout
+	47, // 17: feature.v1.EvaluationContext.attributes:type_name -> feature.v1.EvaluationContext.AttributesEntry
+	20, // 18: feature.v1.EvaluateRequest.context:type_name -> feature.v1.EvaluationContext
+	3,  // 19: feature.v1.EvaluateResponse.reason:type_name -> feature.v1.Reason
+	22, // 20: feature.v1.EvaluateResponse.explanation:type_name -> feature.v1.Explanation
+	4,  // 21: feature.v1.WatchEvent.type:type_name -> feature.v1.EventType
+	18, // 22: feature.v1.WatchEvent.keyValue:type_name -> feature.v1.KeyValue
+	4,  // 23: feature.v1.HistoryEntry.type:type_name -> feature.v1.EventType
+	48, // 24: feature.v1.HistoryEntry.timestamp:type_name -> google.protobuf.Timestamp
+	26, // 25: feature.v1.HistoryResponse.entries:type_name -> feature.v1.HistoryEntry
+	18, // 26: feature.v1.Operation.set:type_name -> feature.v1.KeyValue
+	7,  // 27: feature.v1.Operation.delete:type_name -> feature.v1.Key
+	29, // 28: feature.v1.BatchRequest.operations:type_name -> feature.v1.Operation
+	31, // 29: feature.v1.FlagSets.sets:type_name -> feature.v1.FlagSet
+	18, // 30: feature.v1.PromoteResponse.source:type_name -> feature.v1.KeyValue
+	18, // 31: feature.v1.PromoteResponse.target:type_name -> feature.v1.KeyValue
+	48, // 32: feature.v1.Schedule.at:type_name -> google.protobuf.Timestamp
+	48, // 33: feature.v1.Schedule.createdAt:type_name -> google.protobuf.Timestamp
+	48, // 34: feature.v1.ScheduleSetRequest.at:type_name -> google.protobuf.Timestamp
+	36, // 35: feature.v1.Schedules.schedules:type_name -> feature.v1.Schedule
+	5,  // 36: feature.v1.StaleFlag.reason:type_name -> feature.v1.StaleReason
+	48, // 37: feature.v1.StaleFlag.since:type_name -> google.protobuf.Timestamp
+	48, // 38: feature.v1.StaleFlag.lastRead:type_name -> google.protobuf.Timestamp
+	48, // 39: feature.v1.StaleFlag.expiresAt:type_name -> google.protobuf.Timestamp
+	39, // 40: feature.v1.StaleFlags.flags:type_name -> feature.v1.StaleFlag
+	41, // 41: feature.v1.Permissions.grants:type_name -> feature.v1.Grant
+	6,  // 42: feature.v1.Token.scopes:type_name -> feature.v1.TokenScope
+	48, // 43: feature.v1.Token.expiresAt:type_name -> google.protobuf.Timestamp
+	48, // 44: feature.v1.Token.createdAt:type_name -> google.protobuf.Timestamp
+	6,  // 45: feature.v1.CreateTokenRequest.scopes:type_name -> feature.v1.TokenScope
+	48, // 46: feature.v1.CreateTokenRequest.expiresAt:type_name -> google.protobuf.Timestamp
+	43, // 47: feature.v1.CreateTokenResponse.token:type_name -> feature.v1.Token
+	43, // 48: feature.v1.Tokens.tokens:type_name -> feature.v1.Token
+	49, // 49: feature.v1.Feature.GetAll:input_type -> google.protobuf.Empty
+	18, // 50: feature.v1.Feature.PreSet:input_type -> feature.v1.KeyValue
+	18, // 51: feature.v1.Feature.Set:input_type -> feature.v1.KeyValue
+	7,  // 52: feature.v1.Feature.Get:input_type -> feature.v1.Key
+	7,  // 53: feature.v1.Feature.Delete:input_type -> feature.v1.Key
+	24, // 54: feature.v1.Feature.Watch:input_type -> feature.v1.WatchRequest
+	21, // 55: feature.v1.Feature.Evaluate:input_type -> feature.v1.EvaluateRequest
+	7,  // 56: feature.v1.Feature.GetRules:input_type -> feature.v1.Key
+	15, // 57: feature.v1.Feature.SetRules:input_type -> feature.v1.Rules
+	19, // 58: feature.v1.Feature.SetRollout:input_type -> feature.v1.SetRolloutRequest
+	7,  // 59: feature.v1.Feature.History:input_type -> feature.v1.Key
+	28, // 60: feature.v1.Feature.Rollback:input_type -> feature.v1.RollbackRequest
+	30, // 61: feature.v1.Feature.Batch:input_type -> feature.v1.BatchRequest
+	49, // 62: feature.v1.Feature.ListSets:input_type -> google.protobuf.Empty
+	31, // 63: feature.v1.Feature.CreateSet:input_type -> feature.v1.FlagSet
+	31, // 64: feature.v1.Feature.DeleteSet:input_type -> feature.v1.FlagSet
+	49, // 65: feature.v1.Feature.ListEnvironments:input_type -> google.protobuf.Empty
+	34, // 66: feature.v1.Feature.Promote:input_type -> feature.v1.PromoteRequest
+	37, // 67: feature.v1.Feature.ScheduleSet:input_type -> feature.v1.ScheduleSetRequest
+	49, // 68: feature.v1.Feature.ListSchedules:input_type -> google.protobuf.Empty
+	36, // 69: feature.v1.Feature.CancelSchedule:input_type -> feature.v1.Schedule
+	49, // 70: feature.v1.Feature.ListStale:input_type -> google.protobuf.Empty
+	17, // 71: feature.v1.Feature.SetPrerequisites:input_type -> feature.v1.Prerequisites
+	49, // 72: feature.v1.Feature.GetPermissions:input_type -> google.protobuf.Empty
+	44, // 73: feature.v1.Feature.CreateToken:input_type -> feature.v1.CreateTokenRequest
+	49, // 74: feature.v1.Feature.ListTokens:input_type -> google.protobuf.Empty
+	43, // 75: feature.v1.Feature.RevokeToken:input_type -> feature.v1.Token
+	18, // 76: feature.v1.Feature.GetAll:output_type -> feature.v1.KeyValue
+	49, // 77: feature.v1.Feature.PreSet:output_type -> google.protobuf.Empty
+	49, // 78: feature.v1.Feature.Set:output_type -> google.protobuf.Empty
+	8,  // 79: feature.v1.Feature.Get:output_type -> feature.v1.Value
+	49, // 80: feature.v1.Feature.Delete:output_type -> google.protobuf.Empty
+	25, // 81: feature.v1.Feature.Watch:output_type -> feature.v1.WatchEvent
+	23, // 82: feature.v1.Feature.Evaluate:output_type -> feature.v1.EvaluateResponse
+	15, // 83: feature.v1.Feature.GetRules:output_type -> feature.v1.Rules
+	49, // 84: feature.v1.Feature.SetRules:output_type -> google.protobuf.Empty
+	49, // 85: feature.v1.Feature.SetRollout:output_type -> google.protobuf.Empty
+	27, // 86: feature.v1.Feature.History:output_type -> feature.v1.HistoryResponse
+	49, // 87: feature.v1.Feature.Rollback:output_type -> google.protobuf.Empty
+	49, // 88: feature.v1.Feature.Batch:output_type -> google.protobuf.Empty
+	32, // 89: feature.v1.Feature.ListSets:output_type -> feature.v1.FlagSets
+	49, // 90: feature.v1.Feature.CreateSet:output_type -> google.protobuf.Empty
+	49, // 91: feature.v1.Feature.DeleteSet:output_type -> google.protobuf.Empty
+	33, // 92: feature.v1.Feature.ListEnvironments:output_type -> feature.v1.Environments
+	35, // 93: feature.v1.Feature.Promote:output_type -> feature.v1.PromoteResponse
+	36, // 94: feature.v1.Feature.ScheduleSet:output_type -> feature.v1.Schedule
+	38, // 95: feature.v1.Feature.ListSchedules:output_type -> feature.v1.Schedules
+	49, // 96: feature.v1.Feature.CancelSchedule:output_type -> google.protobuf.Empty
+	40, // 97: feature.v1.Feature.ListStale:output_type -> feature.v1.StaleFlags
+	49, // 98: feature.v1.Feature.SetPrerequisites:output_type -> google.protobuf.Empty
+	42, // 99: feature.v1.Feature.GetPermissions:output_type -> feature.v1.Permissions
+	45, // 100: feature.v1.Feature.CreateToken:output_type -> feature.v1.CreateTokenResponse
+	46, // 101: feature.v1.Feature.ListTokens:output_type -> feature.v1.Tokens
+	49, // 102: feature.v1.Feature.RevokeToken:output_type -> google.protobuf.Empty
+	76, // [76:103] is the sub-list for method output_type
+	49, // [49:76] is the sub-list for method input_type
+	49, // [49:49] is the sub-list for extension type_name
+	49, // [49:49] is the sub-list for extension extendee
+	0,  // [0:49] is the sub-list for field type_name
+}
+
+func init() { file_feature_proto_init() }
+func file_feature_proto_init() {
+	if File_feature_proto != nil {
+		return
+	}
+	file_feature_proto_msgTypes[2].OneofWrappers = []any{}
+	file_feature_proto_msgTypes[22].OneofWrappers = []any{
+		(*Operation_Set)(nil),
+		(*Operation_Delete)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_feature_proto_rawDesc), len(file_feature_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   3,
+			NumEnums:      7,
+			NumMessages:   41,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_feature_proto_goTypes,
 		DependencyIndexes: file_feature_proto_depIdxs,
+		EnumInfos:         file_feature_proto_enumTypes,
 		MessageInfos:      file_feature_proto_msgTypes,
 	}.Build()
 	File_feature_proto = out.File
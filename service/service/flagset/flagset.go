@@ -0,0 +1,409 @@
+package flagset
+
+// manages the flag sets of the service. Every set has its own storage partition, Watch broadcaster,
+// editable keys, principals and restart target. The default set is configured with the command
+// line flags, the other sets are created at runtime and their definitions are kept in the storage
+// partition RegistryPartition. If environments are configured, every set has a partition per
+// environment, the first environment is the default one and uses the partition of the set.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/dkrizic/feature/service/notifier"
+	"github.com/dkrizic/feature/service/notifier/broadcast"
+	"github.com/dkrizic/feature/service/service/auth"
+	"github.com/dkrizic/feature/service/service/persistence"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// MetadataKey is the request metadata naming the flag set of a call, the default set if missing
+	MetadataKey = "feature-set"
+	// EnvironmentMetadataKey is the request metadata naming the environment of a call, the default
+	// environment if missing
+	EnvironmentMetadataKey = "feature-environment"
+	// DefaultName names the default set
+	DefaultName = "default"
+	// RegistryPartition is the storage partition holding the definitions of the sets
+	RegistryPartition = "sets"
+	// SchedulePartition is the storage partition holding the scheduled changes of all sets
+	SchedulePartition = "schedules"
+	// LifecyclePartition is the storage partition holding the read times and stale marks of all sets
+	LifecyclePartition = "lifecycle"
+	// TokenPartition is the storage partition holding the hashed API tokens
+	TokenPartition = "tokens"
+)
+
+var (
+	ErrNotFound         = errors.New("flag set not found")
+	ErrExists           = errors.New("flag set already exists")
+	ErrInvalid          = errors.New("invalid flag set")
+	ErrNotEmpty         = errors.New("flag set is not empty")
+	ErrDefault          = errors.New("the default flag set cannot be created or deleted")
+	ErrPermissionDenied = errors.New("not allowed to use the flag set")
+	ErrEnvironment      = errors.New("unknown environment")
+)
+
+// validName keeps set names usable in the names of ConfigMaps, Secrets, files and labels
+var validName = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,38}[a-z0-9])?$`)
+
+// reservedNames would clash with the partitions of other sets or their history
+var reservedNames = []string{DefaultName, RegistryPartition, SchedulePartition, LifecyclePartition, TokenPartition, "history"}
+
+// restartTypes are the workload types a set can restart, like --restart-type
+var restartTypes = []string{"deployment", "statefulset", "daemonset"}
+
+// Config describes a flag set
+type Config struct {
+	Name        string `json:"-"`
+	Description string `json:"description,omitempty"`
+	// Editable lists the keys that may be changed, empty means all, like --editable for the default set
+	Editable []string `json:"editable,omitempty"`
+	// Principals may use the set, empty means everybody
+	Principals []string `json:"principals,omitempty"`
+	// RestartType and RestartName select the workload Workload.Restart restarts for the set
+	RestartType string `json:"restartType,omitempty"`
+	RestartName string `json:"restartName,omitempty"`
+}
+
+// Allows reports whether the principal may use the set. Restricted sets require authentication.
+func (c Config) Allows(principal string) bool {
+	return len(c.Principals) == 0 || (principal != "" && slices.Contains(c.Principals, principal))
+}
+
+// Validate checks the name and restart target of a set to be created
+func (c Config) Validate() error {
+	if !validName.MatchString(c.Name) || slices.Contains(reservedNames, c.Name) || strings.HasSuffix(c.Name, "-history") {
+		return fmt.Errorf("%w: the name must be a lowercase DNS label of at most 40 characters, not ending in -history and none of %s",
+			ErrInvalid, strings.Join(reservedNames, ", "))
+	}
+	if c.RestartType != "" && !slices.Contains(restartTypes, c.RestartType) {
+		return fmt.Errorf("%w: restart type must be one of %s", ErrInvalid, strings.Join(restartTypes, ", "))
+	}
+	return nil
+}
+
+// ValidateEnvironments checks the configured environments, which are used in partition names
+func ValidateEnvironments(environments []string) error {
+	for i, environment := range environments {
+		if !validName.MatchString(environment) || len(environment) > 20 {
+			return fmt.Errorf("invalid environment %q: must be a lowercase DNS label of at most 20 characters", environment)
+		}
+		if slices.Contains(environments[:i], environment) {
+			return fmt.Errorf("duplicate environment %q", environment)
+		}
+	}
+	return nil
+}
+
+// Partition is the storage partition of a set in an environment
+type Partition struct {
+	// Name is empty for the default set in the default environment, the name of the set in the default
+	// environment and set.environment otherwise, with the default set named DefaultName. Set names
+	// contain no dots, so the names of sets and environments cannot clash.
+	Name string
+	// Set is empty for the default set
+	Set string
+	// Environment is empty without environments
+	Environment string
+}
+
+// Set is an opened flag set
+type Set struct {
+	Config
+	Partition   Partition
+	Persistence persistence.Persistence
+	Broadcaster *broadcast.Broadcaster
+	// revision is the revision of the definition in the registry
+	revision uint64
+}
+
+// Opener opens the persistence of a partition and sends the changes to the notifiers
+type Opener func(ctx context.Context, partition Partition, notifiers ...notifier.Notifier) persistence.Persistence
+
+type Manager struct {
+	registry     persistence.Persistence
+	open         Opener
+	defaults     Config
+	environments []string
+
+	mu sync.Mutex
+	// opened holds the opened sets by partition name
+	opened map[string]*Set
+}
+
+// NewManager creates the manager of the flag sets. The registry holds the definitions of the sets,
+// defaults is the configuration of the default set. environments may be empty, the first one is
+// the default environment.
+func NewManager(registry persistence.Persistence, defaults Config, environments []string, open Opener) *Manager {
+	defaults.Name = DefaultName
+	return &Manager{
+		registry:     registry,
+		open:         open,
+		defaults:     defaults,
+		environments: environments,
+		opened:       make(map[string]*Set),
+	}
+}
+
+// Environments returns the configured environments, the default one first
+func (m *Manager) Environments() []string {
+	return slices.Clone(m.environments)
+}
+
+// environment resolves the name of an environment, empty for the default one
+func (m *Manager) environment(name string) (string, error) {
+	if name == "" && len(m.environments) > 0 {
+		return m.environments[0], nil
+	}
+	if name == "" || slices.Contains(m.environments, name) {
+		return name, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrEnvironment, name)
+}
+
+// partition returns the partition of a set, empty for the default set, in a resolved environment
+func (m *Manager) partition(set, environment string) Partition {
+	p := Partition{Name: set, Set: set, Environment: environment}
+	if len(m.environments) > 0 && environment != m.environments[0] {
+		base := set
+		if base == "" {
+			base = DefaultName
+		}
+		p.Name = base + "." + environment
+	}
+	return p
+}
+
+// NameFromContext returns the name of the flag set in the request metadata, empty for the default set
+func NameFromContext(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	names := md.Get(MetadataKey)
+	if len(names) == 0 || names[0] == DefaultName {
+		return ""
+	}
+	return names[0]
+}
+
+// EnvironmentFromContext returns the environment in the request metadata, empty for the default one
+func EnvironmentFromContext(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if environments := md.Get(EnvironmentMetadataKey); len(environments) > 0 {
+		return environments[0]
+	}
+	return ""
+}
+
+// FromContext returns the flag set named in the request metadata in the environment of the
+// request if the principal may use it
+func (m *Manager) FromContext(ctx context.Context) (*Set, error) {
+	return m.InEnvironment(ctx, EnvironmentFromContext(ctx))
+}
+
+// InEnvironment returns the flag set named in the request metadata in the given environment, empty
+// for the default one, if the principal may use it
+func (m *Manager) InEnvironment(ctx context.Context, environment string) (*Set, error) {
+	set, err := m.Get(ctx, NameFromContext(ctx), environment)
+	if err != nil {
+		return nil, err
+	}
+	if principal := auth.PrincipalFromContext(ctx); !set.Allows(principal) {
+		slog.WarnContext(ctx, "Flag set denied", "set", set.Name, "principal", principal)
+		return nil, ErrPermissionDenied
+	}
+	return set, nil
+}
+
+// Get returns the flag set with the name, the default set if it is empty or DefaultName, in the
+// environment, the default one if it is empty. Sets are opened on first use. The definition is
+// read on every call, so sets changed by another instance of the service are seen.
+func (m *Manager) Get(ctx context.Context, name, environment string) (*Set, error) {
+	ctx, span := otel.Tracer("service/flagset").Start(ctx, "Get")
+	defer span.End()
+
+	environment, err := m.environment(environment)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" || name == DefaultName {
+		partition := m.partition("", environment)
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		set, ok := m.opened[partition.Name]
+		if !ok {
+			set = m.openSet(ctx, partition, m.defaults)
+			m.opened[partition.Name] = set
+		}
+		return set, nil
+	}
+
+	config, revision, err := m.definition(ctx, name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if errors.Is(err, ErrNotFound) {
+		// deleted, maybe by another instance
+		m.forget(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	partition := m.partition(name, environment)
+	set, ok := m.opened[partition.Name]
+	switch {
+	case !ok:
+		set = m.openSet(ctx, partition, config)
+	case set.revision != revision:
+		// changed, the partition stays the same
+		set = &Set{Config: config, Partition: partition, Persistence: set.Persistence, Broadcaster: set.Broadcaster}
+	default:
+		return set, nil
+	}
+	set.revision = revision
+	m.opened[partition.Name] = set
+	return set, nil
+}
+
+func (m *Manager) openSet(ctx context.Context, partition Partition, config Config) *Set {
+	slog.InfoContext(ctx, "Opening flag set", "set", config.Name, "environment", partition.Environment)
+	broadcaster := broadcast.NewBroadcaster(broadcast.DefaultHistorySize)
+	return &Set{
+		Config:      config,
+		Partition:   partition,
+		Persistence: m.open(ctx, partition, broadcaster),
+		Broadcaster: broadcaster,
+	}
+}
+
+// forget drops the opened partitions of a set, m.mu must be held
+func (m *Manager) forget(name string) {
+	for partition, set := range m.opened {
+		if set.Partition.Set == name {
+			delete(m.opened, partition)
+		}
+	}
+}
+
+// definition reads the definition of a set from the registry
+func (m *Manager) definition(ctx context.Context, name string) (Config, uint64, error) {
+	kv, err := m.registry.Get(ctx, name)
+	if errors.Is(err, persistence.ErrKeyNotFound) {
+		return Config{}, 0, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return Config{}, 0, err
+	}
+	var config Config
+	if err := json.Unmarshal([]byte(kv.Value), &config); err != nil {
+		return Config{}, 0, fmt.Errorf("flag set %s: %w", name, err)
+	}
+	config.Name = name
+	return config, kv.Revision, nil
+}
+
+// List returns the definitions of all sets, the default set first and the others by name
+func (m *Manager) List(ctx context.Context) ([]Config, error) {
+	ctx, span := otel.Tracer("service/flagset").Start(ctx, "List")
+	defer span.End()
+
+	all, err := m.registry.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	configs := make([]Config, 0, len(all)+1)
+	for _, kv := range all {
+		var config Config
+		if err := json.Unmarshal([]byte(kv.Value), &config); err != nil {
+			slog.WarnContext(ctx, "Ignoring malformed flag set", "set", kv.Key, "error", err)
+			continue
+		}
+		config.Name = kv.Key
+		configs = append(configs, config)
+	}
+	slices.SortFunc(configs, func(a, b Config) int { return strings.Compare(a.Name, b.Name) })
+	return append([]Config{m.defaults}, configs...), nil
+}
+
+// Create adds a set, its partition is created with the first flag
+func (m *Manager) Create(ctx context.Context, config Config) error {
+	ctx, span := otel.Tracer("service/flagset").Start(ctx, "Create")
+	defer span.End()
+
+	if config.Name == DefaultName {
+		return ErrDefault
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	exists := fmt.Errorf("%w: %s", ErrExists, config.Name)
+	if _, err := m.registry.Get(ctx, config.Name); err == nil {
+		return exists
+	} else if !errors.Is(err, persistence.ErrKeyNotFound) {
+		return err
+	}
+	// PreSet keeps a set created in the meantime, which is found by comparing the definition
+	if err := m.registry.PreSet(ctx, persistence.KeyValue{Key: config.Name, Value: string(raw)}); err != nil {
+		return err
+	}
+	kv, err := m.registry.Get(ctx, config.Name)
+	if err != nil {
+		return err
+	}
+	if kv.Value != string(raw) {
+		return exists
+	}
+	slog.InfoContext(ctx, "Flag set created", "set", config.Name)
+	return nil
+}
+
+// Delete removes a set. Only sets that are empty in all environments can be deleted, so no flags
+// are lost by accident.
+func (m *Manager) Delete(ctx context.Context, name string) error {
+	ctx, span := otel.Tracer("service/flagset").Start(ctx, "Delete")
+	defer span.End()
+
+	if name == "" || name == DefaultName {
+		return ErrDefault
+	}
+	environments := m.environments
+	if len(environments) == 0 {
+		environments = []string{""}
+	}
+	for _, environment := range environments {
+		set, err := m.Get(ctx, name, environment)
+		if err != nil {
+			return err
+		}
+		count, err := set.Persistence.Count(ctx)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			if environment != "" {
+				return fmt.Errorf("%w: %s has %d flags in %s, delete them first", ErrNotEmpty, name, count, environment)
+			}
+			return fmt.Errorf("%w: %s has %d flags, delete them first", ErrNotEmpty, name, count)
+		}
+	}
+	if err := m.registry.Delete(ctx, name); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.forget(name)
+	m.mu.Unlock()
+	slog.InfoContext(ctx, "Flag set deleted", "set", name)
+	return nil
+}
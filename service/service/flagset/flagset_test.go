@@ -0,0 +1,194 @@
+package flagset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dkrizic/feature/service/notifier"
+	"github.com/dkrizic/feature/service/service/auth"
+	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/dkrizic/feature/service/service/persistence/inmemory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+// newTestManager returns a manager keeping every partition in memory and the partitions opened so far
+func newTestManager(defaults Config, environments ...string) (*Manager, map[string]persistence.Persistence) {
+	partitions := make(map[string]persistence.Persistence)
+	open := func(ctx context.Context, partition Partition, notifiers ...notifier.Notifier) persistence.Persistence {
+		p := inmemory.NewInMemoryPersistence()
+		partitions[partition.Name] = p
+		return p
+	}
+	return NewManager(inmemory.NewInMemoryPersistence(), defaults, environments, open), partitions
+}
+
+func withSet(ctx context.Context, name string) context.Context {
+	return metadata.NewIncomingContext(ctx, metadata.Pairs(MetadataKey, name))
+}
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	m, partitions := newTestManager(Config{Editable: []string{"COLOR"}})
+
+	def, err := m.Get(ctx, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultName, def.Name)
+	assert.Equal(t, []string{"COLOR"}, def.Editable)
+	again, _ := m.Get(ctx, DefaultName, "")
+	assert.Same(t, def, again)
+
+	_, err = m.Get(ctx, "team-a", "")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, m.Create(ctx, Config{Name: "team-a", Description: "Team A", RestartType: "deployment", RestartName: "app-a"}))
+	require.NoError(t, m.Create(ctx, Config{Name: "batch"}))
+	assert.ErrorIs(t, m.Create(ctx, Config{Name: "team-a"}), ErrExists)
+	assert.ErrorIs(t, m.Create(ctx, Config{Name: DefaultName}), ErrDefault)
+
+	set, err := m.Get(ctx, "team-a", "")
+	require.NoError(t, err)
+	assert.Equal(t, "Team A", set.Description)
+	assert.Equal(t, "app-a", set.RestartName)
+	assert.NotNil(t, set.Broadcaster)
+	assert.Same(t, partitions["team-a"], set.Persistence)
+
+	configs, err := m.List(ctx)
+	require.NoError(t, err)
+	names := make([]string, len(configs))
+	for i, config := range configs {
+		names[i] = config.Name
+	}
+	assert.Equal(t, []string{DefaultName, "batch", "team-a"}, names)
+
+	// only empty sets can be deleted
+	require.NoError(t, set.Persistence.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	assert.ErrorIs(t, m.Delete(ctx, "team-a"), ErrNotEmpty)
+	require.NoError(t, set.Persistence.Delete(ctx, "COLOR"))
+	assert.NoError(t, m.Delete(ctx, "team-a"))
+	_, err = m.Get(ctx, "team-a", "")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, m.Delete(ctx, "team-a"), ErrNotFound)
+	assert.ErrorIs(t, m.Delete(ctx, DefaultName), ErrDefault)
+}
+
+func TestManager_ChangedDefinition(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestManager(Config{})
+	require.NoError(t, m.Create(ctx, Config{Name: "team-a"}))
+	before, err := m.Get(ctx, "team-a", "")
+	require.NoError(t, err)
+
+	// e.g. changed by another instance of the service
+	require.NoError(t, m.registry.Set(ctx, persistence.KeyValue{Key: "team-a", Value: `{"principals":["alice"]}`}))
+	after, err := m.Get(ctx, "team-a", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, after.Principals)
+	assert.Same(t, before.Persistence, after.Persistence)
+	assert.Same(t, before.Broadcaster, after.Broadcaster)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	for _, name := range []string{"team-a", "a", "prod2"} {
+		assert.NoError(t, Config{Name: name}.Validate(), name)
+	}
+	for _, name := range []string{"", "Team", "-a", "a-", "a_b", "default", "sets", "schedules", "lifecycle", "history", "team-history",
+		"a-very-long-name-for-a-flag-set-exceeding-forty"} {
+		assert.ErrorIs(t, Config{Name: name}.Validate(), ErrInvalid, name)
+	}
+	assert.ErrorIs(t, Config{Name: "a", RestartType: "pod"}.Validate(), ErrInvalid)
+}
+
+func TestConfig_Allows(t *testing.T) {
+	open := Config{}
+	assert.True(t, open.Allows(""))
+	assert.True(t, open.Allows("alice"))
+
+	restricted := Config{Principals: []string{"alice"}}
+	assert.True(t, restricted.Allows("alice"))
+	assert.False(t, restricted.Allows("bob"))
+	assert.False(t, restricted.Allows(""))
+}
+
+func TestNameFromContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "", NameFromContext(ctx))
+	assert.Equal(t, "", NameFromContext(withSet(ctx, DefaultName)))
+	assert.Equal(t, "team-a", NameFromContext(withSet(ctx, "team-a")))
+}
+
+func TestManager_FromContext(t *testing.T) {
+	ctx := context.Background()
+	m, _ := newTestManager(Config{})
+	require.NoError(t, m.Create(ctx, Config{Name: "team-a", Principals: []string{"alice"}}))
+
+	set, err := m.FromContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultName, set.Name)
+
+	_, err = m.FromContext(withSet(ctx, "team-a"))
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+	_, err = m.FromContext(auth.WithPrincipal(withSet(ctx, "team-a"), "bob"))
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+	set, err = m.FromContext(auth.WithPrincipal(withSet(ctx, "team-a"), "alice"))
+	require.NoError(t, err)
+	assert.Equal(t, "team-a", set.Name)
+
+	_, err = m.FromContext(withSet(ctx, "unknown"))
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestManager_Environments(t *testing.T) {
+	ctx := context.Background()
+	m, partitions := newTestManager(Config{}, "staging", "prod")
+	require.NoError(t, m.Create(ctx, Config{Name: "team-a"}))
+	assert.Equal(t, []string{"staging", "prod"}, m.Environments())
+
+	// the default environment keeps the partitions used without environments
+	for _, tc := range []struct{ set, environment, partition string }{
+		{"", "", ""},
+		{"", "staging", ""},
+		{"", "prod", "default.prod"},
+		{"team-a", "", "team-a"},
+		{"team-a", "prod", "team-a.prod"},
+	} {
+		set, err := m.Get(ctx, tc.set, tc.environment)
+		require.NoError(t, err)
+		assert.Equal(t, tc.partition, set.Partition.Name)
+		assert.Same(t, partitions[tc.partition], set.Persistence)
+	}
+	prod, _ := m.Get(ctx, "team-a", "prod")
+	assert.Equal(t, "prod", prod.Partition.Environment)
+	staging, _ := m.Get(ctx, "team-a", "")
+	assert.Equal(t, "staging", staging.Partition.Environment)
+	assert.NotSame(t, prod.Broadcaster, staging.Broadcaster)
+
+	_, err := m.Get(ctx, "", "dev")
+	assert.ErrorIs(t, err, ErrEnvironment)
+	set, err := m.FromContext(metadata.NewIncomingContext(ctx, metadata.Pairs(MetadataKey, "team-a", EnvironmentMetadataKey, "prod")))
+	require.NoError(t, err)
+	assert.Same(t, prod, set)
+
+	// a set is only deleted if it is empty in all environments
+	require.NoError(t, prod.Persistence.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	assert.ErrorIs(t, m.Delete(ctx, "team-a"), ErrNotEmpty)
+	require.NoError(t, prod.Persistence.Delete(ctx, "COLOR"))
+	assert.NoError(t, m.Delete(ctx, "team-a"))
+}
+
+func TestManager_NoEnvironments(t *testing.T) {
+	m, _ := newTestManager(Config{})
+	_, err := m.Get(context.Background(), "", "prod")
+	assert.ErrorIs(t, err, ErrEnvironment)
+	assert.Empty(t, m.Environments())
+}
+
+func TestValidateEnvironments(t *testing.T) {
+	assert.NoError(t, ValidateEnvironments(nil))
+	assert.NoError(t, ValidateEnvironments([]string{"dev", "staging", "prod"}))
+	assert.Error(t, ValidateEnvironments([]string{"Prod"}))
+	assert.Error(t, ValidateEnvironments([]string{"a.b"}))
+	assert.Error(t, ValidateEnvironments([]string{"dev", "dev"}))
+	assert.Error(t, ValidateEnvironments([]string{"an-environment-name-too-long"}))
+}
@@ -0,0 +1,367 @@
+package lifecycle
+
+// detects stale flags: flags past their expiry date and flags that were neither changed nor read for
+// a while. Reads are tracked in memory and written with the stale marks to the storage partition
+// flagset.LifecyclePartition by a background job, one record per partition of a set, so they
+// survive restarts of the service and are shared by its replicas.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dkrizic/feature/service/service/flagset"
+	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/dkrizic/feature/service/telemetry/localmetrics"
+	"go.opentelemetry.io/otel"
+)
+
+// Reason tells why a flag is stale
+type Reason string
+
+const (
+	// ReasonExpired flags are past their expiry date
+	ReasonExpired Reason = "expired"
+	// ReasonUnused flags were neither changed nor read for the configured period, permanent flags
+	// never are
+	ReasonUnused Reason = "unused"
+)
+
+// saveAttempts bounds the retries of a record written by another replica at the same time
+const saveAttempts = 3
+
+// Mark is a flag marked as stale by the job
+type Mark struct {
+	Reason Reason    `json:"reason"`
+	Since  time.Time `json:"since"`
+}
+
+// record is the stored state of a partition
+type record struct {
+	// Since is the start of the tracking, flags without reads or changes count as unused from then on
+	Since time.Time            `json:"since"`
+	Reads map[string]time.Time `json:"reads,omitempty"`
+	Stale map[string]Mark      `json:"stale,omitempty"`
+	// revision is the revision of the stored record, 0 if there is none yet
+	revision uint64
+}
+
+// Flag is a stale flag as returned by Monitor.Stale
+type Flag struct {
+	Key    string
+	Reason Reason
+	// Since is the time the flag was marked as stale
+	Since time.Time
+	// LastRead is zero if the flag was not read since the tracking started
+	LastRead  time.Time
+	ExpiresAt time.Time
+	Owner     string
+}
+
+// Monitor tracks the reads of the flags and marks stale flags
+type Monitor struct {
+	sets        *flagset.Manager
+	persistence persistence.Persistence
+	staleAfter  time.Duration
+	now         func() time.Time
+
+	mu sync.Mutex
+	// reads holds the reads not yet written, by partition name and key
+	reads map[string]map[string]time.Time
+}
+
+// NewMonitor creates the monitor of the sets of the manager. The records are kept in p. Flags are
+// unused after staleAfter without reads and changes, 0 only reports expired flags.
+func NewMonitor(sets *flagset.Manager, p persistence.Persistence, staleAfter time.Duration) *Monitor {
+	if err := localmetrics.New(); err != nil {
+		slog.Error("Failed to initialize local metrics", "error", err)
+	}
+	return &Monitor{
+		sets:        sets,
+		persistence: p,
+		staleAfter:  staleAfter,
+		now:         time.Now,
+		reads:       make(map[string]map[string]time.Time),
+	}
+}
+
+// Read records that keys of a partition were read
+func (m *Monitor) Read(partition string, keys ...string) {
+	now := m.now().UTC()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	reads, ok := m.reads[partition]
+	if !ok {
+		reads = make(map[string]time.Time)
+		m.reads[partition] = reads
+	}
+	for _, key := range keys {
+		reads[key] = now
+	}
+}
+
+// Run checks all sets every interval until the context is cancelled
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	slog.InfoContext(ctx, "Lifecycle job started", "interval", interval, "staleAfter", m.staleAfter)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		m.Check(ctx)
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "Lifecycle job stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check writes the reads and marks the stale flags of all sets in all environments and returns the
+// number of stale flags. Records of deleted sets are removed.
+func (m *Monitor) Check(ctx context.Context) int {
+	ctx, span := otel.Tracer("service/lifecycle").Start(ctx, "Check")
+	defer span.End()
+
+	configs, err := m.sets.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list flag sets", "error", err)
+		return 0
+	}
+	environments := m.sets.Environments()
+	if len(environments) == 0 {
+		environments = []string{""}
+	}
+	checked := make(map[string]bool)
+	stale := 0
+	for _, config := range configs {
+		for _, environment := range environments {
+			set, err := m.sets.Get(ctx, config.Name, environment)
+			if err != nil {
+				slog.WarnContext(ctx, "Failed to open flag set", "set", config.Name, "environment", environment, "error", err)
+				continue
+			}
+			checked[recordKey(set.Partition.Name)] = true
+			count, err := m.check(ctx, set)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to check flag set", "set", config.Name, "environment", environment, "error", err)
+				continue
+			}
+			stale += count
+		}
+	}
+	m.prune(ctx, checked)
+	localmetrics.StaleGauge().Record(ctx, int64(stale))
+	slog.InfoContext(ctx, "Lifecycle check completed", "stale", stale)
+	return stale
+}
+
+// check marks the stale flags of a partition and returns their number
+func (m *Monitor) check(ctx context.Context, set *flagset.Set) (int, error) {
+	flags, err := set.Persistence.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+	now := m.now().UTC()
+	stale := 0
+	err = m.update(ctx, set.Partition.Name, func(r *record) {
+		marks := make(map[string]Mark)
+		reads := make(map[string]time.Time)
+		for _, kv := range flags {
+			if read, ok := r.Reads[kv.Key]; ok {
+				reads[kv.Key] = read
+			}
+			reason := m.reason(kv, r, now)
+			if reason == "" {
+				continue
+			}
+			mark, ok := r.Stale[kv.Key]
+			if !ok || mark.Reason != reason {
+				slog.InfoContext(ctx, "Flag is stale", "set", set.Name, "environment", set.Partition.Environment, "key", kv.Key, "reason", reason)
+				mark = Mark{Reason: reason, Since: now}
+			}
+			marks[kv.Key] = mark
+		}
+		// deleted flags are forgotten
+		r.Reads = reads
+		r.Stale = marks
+		stale = len(marks)
+	})
+	return stale, err
+}
+
+// reason tells whether a flag is stale, empty if it is not
+func (m *Monitor) reason(kv persistence.KeyValue, r *record, now time.Time) Reason {
+	if !kv.Metadata.ExpiresAt.IsZero() && !now.Before(kv.Metadata.ExpiresAt) {
+		return ReasonExpired
+	}
+	if m.staleAfter <= 0 || kv.Metadata.Lifecycle == persistence.LifecyclePermanent {
+		return ""
+	}
+	used := r.Since
+	if kv.Metadata.UpdatedAt.After(used) {
+		used = kv.Metadata.UpdatedAt
+	}
+	if read := r.Reads[kv.Key]; read.After(used) {
+		used = read
+	}
+	if now.Sub(used) >= m.staleAfter {
+		return ReasonUnused
+	}
+	return ""
+}
+
+// Stale returns the flags of a set marked as stale by the job, sorted by key. A mark no longer
+// applies as soon as the flag is deleted, changed or read.
+func (m *Monitor) Stale(ctx context.Context, set *flagset.Set) ([]Flag, error) {
+	ctx, span := otel.Tracer("service/lifecycle").Start(ctx, "Stale")
+	defer span.End()
+
+	r, err := m.load(ctx, set.Partition.Name)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.Stale) == 0 {
+		return nil, nil
+	}
+	m.mu.Lock()
+	r.Reads = merge(r.Reads, m.reads[set.Partition.Name])
+	m.mu.Unlock()
+
+	flags, err := set.Persistence.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := m.now().UTC()
+	var result []Flag
+	for _, kv := range flags {
+		mark, ok := r.Stale[kv.Key]
+		if !ok || m.reason(kv, r, now) != mark.Reason {
+			continue
+		}
+		if mark.Reason == ReasonUnused && (kv.Metadata.UpdatedAt.After(mark.Since) || r.Reads[kv.Key].After(mark.Since)) {
+			continue
+		}
+		result = append(result, Flag{
+			Key:       kv.Key,
+			Reason:    mark.Reason,
+			Since:     mark.Since,
+			LastRead:  r.Reads[kv.Key],
+			ExpiresAt: kv.Metadata.ExpiresAt,
+			Owner:     kv.Metadata.Owner,
+		})
+	}
+	slices.SortFunc(result, func(a, b Flag) int { return strings.Compare(a.Key, b.Key) })
+	return result, nil
+}
+
+// update applies the pending reads and fn to the record of a partition and writes it. The record is
+// read again if another replica wrote it in the meantime.
+func (m *Monitor) update(ctx context.Context, partition string, fn func(r *record)) error {
+	m.mu.Lock()
+	pending := m.reads[partition]
+	delete(m.reads, partition)
+	m.mu.Unlock()
+
+	var err error
+	for range saveAttempts {
+		var r *record
+		r, err = m.load(ctx, partition)
+		if err != nil {
+			break
+		}
+		r.Reads = merge(r.Reads, pending)
+		fn(r)
+		err = m.save(ctx, partition, r)
+		if !errors.Is(err, persistence.ErrRevisionMismatch) {
+			break
+		}
+	}
+	if err != nil {
+		// keep the reads for the next check
+		m.mu.Lock()
+		m.reads[partition] = merge(m.reads[partition], pending)
+		m.mu.Unlock()
+	}
+	return err
+}
+
+// prune removes the records and pending reads of partitions that no longer exist
+func (m *Monitor) prune(ctx context.Context, checked map[string]bool) {
+	m.mu.Lock()
+	for partition := range m.reads {
+		if !checked[recordKey(partition)] {
+			delete(m.reads, partition)
+		}
+	}
+	m.mu.Unlock()
+
+	all, err := m.persistence.GetAll(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to list lifecycle records", "error", err)
+		return
+	}
+	for _, kv := range all {
+		if checked[kv.Key] {
+			continue
+		}
+		if err := m.persistence.Delete(ctx, kv.Key); err != nil && !errors.Is(err, persistence.ErrKeyNotFound) {
+			slog.WarnContext(ctx, "Failed to delete lifecycle record", "partition", kv.Key, "error", err)
+		}
+	}
+}
+
+func (m *Monitor) load(ctx context.Context, partition string) (*record, error) {
+	kv, err := m.persistence.Get(ctx, recordKey(partition))
+	if errors.Is(err, persistence.ErrKeyNotFound) {
+		return &record{Since: m.now().UTC()}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r record
+	if err := json.Unmarshal([]byte(kv.Value), &r); err != nil {
+		return nil, fmt.Errorf("lifecycle record %s: %w", kv.Key, err)
+	}
+	r.revision = kv.Revision
+	return &r, nil
+}
+
+func (m *Monitor) save(ctx context.Context, partition string, r *record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return m.persistence.Set(ctx, persistence.KeyValue{Key: recordKey(partition), Value: string(data), Revision: r.revision})
+}
+
+// recordKey is the key of the record of a partition, the default partition has no name
+func recordKey(partition string) string {
+	if partition == "" {
+		return flagset.DefaultName
+	}
+	return partition
+}
+
+// merge returns the later of the read times of both
+func merge(reads, more map[string]time.Time) map[string]time.Time {
+	if len(more) == 0 {
+		return reads
+	}
+	result := maps.Clone(reads)
+	if result == nil {
+		result = make(map[string]time.Time)
+	}
+	for key, read := range more {
+		if read.After(result[key]) {
+			result[key] = read
+		}
+	}
+	return result
+}
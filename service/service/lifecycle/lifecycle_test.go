@@ -0,0 +1,132 @@
+package lifecycle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dkrizic/feature/service/notifier"
+	"github.com/dkrizic/feature/service/service/flagset"
+	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/dkrizic/feature/service/service/persistence/inmemory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestManager() *flagset.Manager {
+	partitions := make(map[string]persistence.Persistence)
+	open := func(ctx context.Context, partition flagset.Partition, notifiers ...notifier.Notifier) persistence.Persistence {
+		if _, ok := partitions[partition.Name]; !ok {
+			partitions[partition.Name] = inmemory.NewInMemoryPersistence()
+		}
+		return partitions[partition.Name]
+	}
+	return flagset.NewManager(inmemory.NewInMemoryPersistence(), flagset.Config{}, nil, open)
+}
+
+func keys(flags []Flag) []string {
+	var result []string
+	for _, flag := range flags {
+		result = append(result, flag.Key+" "+string(flag.Reason))
+	}
+	return result
+}
+
+func TestMonitor_Stale(t *testing.T) {
+	ctx := context.Background()
+	sets := newTestManager()
+	set, err := sets.Get(ctx, "", "")
+	require.NoError(t, err)
+	start := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	now := start
+	m := NewMonitor(sets, inmemory.NewInMemoryPersistence(), 30*24*time.Hour)
+	m.now = func() time.Time { return now }
+
+	for _, kv := range []persistence.KeyValue{
+		{Key: "OLD", Value: "1", Metadata: persistence.Metadata{UpdatedAt: start}},
+		{Key: "READ", Value: "1", Metadata: persistence.Metadata{UpdatedAt: start}},
+		{Key: "KILL_SWITCH", Value: "1", Metadata: persistence.Metadata{UpdatedAt: start, Lifecycle: persistence.LifecyclePermanent}},
+		{Key: "RELEASE", Value: "1", Metadata: persistence.Metadata{UpdatedAt: start, ExpiresAt: start.AddDate(0, 0, 7), Owner: "alice"}},
+	} {
+		require.NoError(t, set.Persistence.Set(ctx, kv))
+	}
+
+	// tracking starts with the first check
+	assert.Equal(t, 0, m.Check(ctx))
+	now = start.AddDate(0, 0, 10)
+	assert.Equal(t, 1, m.Check(ctx))
+	now = start.AddDate(0, 0, 20)
+	m.Read("", "READ")
+	now = start.AddDate(0, 0, 31)
+	assert.Equal(t, 2, m.Check(ctx))
+
+	stale, err := m.Stale(ctx, set)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"OLD unused", "RELEASE expired"}, keys(stale))
+	assert.Equal(t, start.AddDate(0, 0, 10), stale[1].Since, "marked by the earlier check")
+	assert.Equal(t, "alice", stale[1].Owner)
+	assert.True(t, stale[0].LastRead.IsZero())
+
+	// a read or a change lifts the mark right away
+	m.Read("", "OLD")
+	require.NoError(t, set.Persistence.Set(ctx, persistence.KeyValue{Key: "RELEASE", Value: "1", Metadata: persistence.Metadata{UpdatedAt: now}}))
+	stale, err = m.Stale(ctx, set)
+	require.NoError(t, err)
+	assert.Empty(t, stale)
+
+	// READ was last read 30 days ago, RELEASE was changed 19 days ago
+	now = start.AddDate(0, 0, 50)
+	require.NoError(t, set.Persistence.Delete(ctx, "OLD"))
+	assert.Equal(t, 1, m.Check(ctx))
+	stale, err = m.Stale(ctx, set)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"READ unused"}, keys(stale))
+	assert.Equal(t, start.AddDate(0, 0, 20), stale[0].LastRead)
+}
+
+func TestMonitor_ExpiredOnly(t *testing.T) {
+	ctx := context.Background()
+	sets := newTestManager()
+	set, err := sets.Get(ctx, "", "")
+	require.NoError(t, err)
+	m := NewMonitor(sets, inmemory.NewInMemoryPersistence(), 0)
+	m.now = func() time.Time { return time.Now().AddDate(1, 0, 0) }
+
+	require.NoError(t, set.Persistence.Set(ctx, persistence.KeyValue{Key: "OLD", Value: "1"}))
+	require.NoError(t, set.Persistence.Set(ctx, persistence.KeyValue{Key: "RELEASE", Value: "1",
+		Metadata: persistence.Metadata{ExpiresAt: time.Now()}}))
+	assert.Equal(t, 1, m.Check(ctx))
+}
+
+func TestMonitor_SharedRecords(t *testing.T) {
+	ctx := context.Background()
+	sets := newTestManager()
+	require.NoError(t, sets.Create(ctx, flagset.Config{Name: "team-a"}))
+	set, err := sets.Get(ctx, "team-a", "")
+	require.NoError(t, err)
+	require.NoError(t, set.Persistence.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	records := inmemory.NewInMemoryPersistence()
+	start := time.Now().UTC()
+	now := start
+
+	// two replicas with their own reads
+	first := NewMonitor(sets, records, time.Hour)
+	second := NewMonitor(sets, records, time.Hour)
+	first.now = func() time.Time { return now }
+	second.now = func() time.Time { return now }
+	assert.Equal(t, 0, first.Check(ctx))
+	now = start.Add(50 * time.Minute)
+	second.Read("team-a", "COLOR")
+	assert.Equal(t, 0, second.Check(ctx))
+	now = start.Add(90 * time.Minute)
+	assert.Equal(t, 0, first.Check(ctx), "read on the other replica")
+
+	// the records of deleted sets are removed
+	require.NoError(t, set.Persistence.Delete(ctx, "COLOR"))
+	require.NoError(t, sets.Delete(ctx, "team-a"))
+	first.Check(ctx)
+	all, err := records.GetAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, flagset.DefaultName, all[0].Key)
+}
@@ -0,0 +1,195 @@
+package configmap
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"github.com/dkrizic/feature/service/notifier"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// The flags ConfigMap is watched by an informer and reads are served from the last version it
+// has seen. Writes go to the API server and wait until the informer has seen them, so a read
+// after a write returns what was written. Versions the service did not write itself are changes
+// made by someone else, e.g. with kubectl edit, and are sent to the notifiers.
+
+// cacheTimeout limits how long a write waits for the informer to see it
+const cacheTimeout = 5 * time.Second
+
+// start creates the client and starts the informer on first use. A failed start is tried again
+// on the next call.
+func (p *Persistence) start(ctx context.Context) (configMapClient, error) {
+	p.startMu.Lock()
+	defer p.startMu.Unlock()
+	if p.client != nil {
+		return p.client, nil
+	}
+
+	client, namespace, err := k8sClientFn(ctx, p.configMapName)
+	if err != nil {
+		return nil, err
+	}
+	slog.DebugContext(ctx, "Running in namespace", "namespace", *namespace)
+
+	selector := fields.OneTermEqualSelector("metadata.name", p.configMapName).String()
+	_, controller := cache.NewInformerWithOptions(cache.InformerOptions{
+		// the client decides whether the informer may stream the initial list with the watch
+		ListerWatcher: cache.ToListWatcherWithWatchListSemantics(&cache.ListWatch{
+			ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+				options.FieldSelector = selector
+				return client.List(ctx, options)
+			},
+			WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+				options.FieldSelector = selector
+				return client.Watch(ctx, options)
+			},
+		}, client),
+		ObjectType: &v1.ConfigMap{},
+		Handler: cache.ResourceEventHandlerDetailedFuncs{
+			AddFunc: func(obj any, isInInitialList bool) {
+				p.observe(nil, obj.(*v1.ConfigMap), isInInitialList)
+			},
+			UpdateFunc: func(oldObj, newObj any) {
+				p.observe(oldObj.(*v1.ConfigMap), newObj.(*v1.ConfigMap), false)
+			},
+			DeleteFunc: func(obj any) {
+				if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+					obj = tombstone.Obj
+				}
+				if configMap, ok := obj.(*v1.ConfigMap); ok {
+					p.observe(configMap, nil, false)
+				}
+			},
+		},
+	})
+
+	runCtx, stop := context.WithCancel(context.Background())
+	go controller.RunWithContext(runCtx)
+	if !cache.WaitForCacheSync(ctx.Done(), controller.HasSynced) {
+		stop()
+		return nil, errors.New("timed out waiting for the ConfigMap cache to sync")
+	}
+	slog.InfoContext(ctx, "ConfigMap cache synced", "configmap", p.configMapName, "namespace", *namespace)
+
+	p.client = client
+	p.stop = stop
+	return client, nil
+}
+
+// Close stops the informer
+func (p *Persistence) Close() {
+	p.startMu.Lock()
+	defer p.startMu.Unlock()
+	if p.stop != nil {
+		p.stop()
+	}
+}
+
+// cached returns the flags ConfigMap as last seen by the informer, an empty one if it does not
+// exist. It is shared and must not be changed.
+func (p *Persistence) cached(ctx context.Context) (*v1.ConfigMap, error) {
+	if _, err := p.start(ctx); err != nil {
+		return nil, err
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.configMap == nil {
+		return &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: p.configMapName}}, nil
+	}
+	return p.configMap, nil
+}
+
+// update writes the flags ConfigMap and waits until the informer has seen the new version
+func (p *Persistence) update(ctx context.Context, client configMapClient, configMap *v1.ConfigMap) error {
+	// holding writeMu keeps the informer from handling the new version before it is known as our own
+	p.writeMu.Lock()
+	updated, err := client.Update(ctx, configMap, metav1.UpdateOptions{})
+	if err != nil {
+		p.writeMu.Unlock()
+		return err
+	}
+	seen := make(chan struct{})
+	p.pending[updated.ResourceVersion] = seen
+	p.writeMu.Unlock()
+
+	select {
+	case <-seen:
+	case <-time.After(cacheTimeout):
+		slog.WarnContext(ctx, "ConfigMap cache did not see the update in time, reads may be stale", "configmap", p.configMapName, "resourceVersion", updated.ResourceVersion)
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+// observe is called by the informer with the previous and the new version of the flags ConfigMap,
+// either of them nil if the ConfigMap did not or does no longer exist. The version found on
+// start is initial and no change.
+func (p *Persistence) observe(previous, current *v1.ConfigMap, initial bool) {
+	p.writeMu.Lock()
+	var seen chan struct{}
+	if current != nil {
+		seen = p.pending[current.ResourceVersion]
+		delete(p.pending, current.ResourceVersion)
+	}
+	p.writeMu.Unlock()
+
+	p.mu.Lock()
+	p.configMap = current
+	p.mu.Unlock()
+
+	if seen != nil {
+		// our own write, the notifying persistence has sent its notifications
+		close(seen)
+		return
+	}
+	if initial {
+		return
+	}
+	p.notifyExternal(context.Background(), previous, current)
+}
+
+// notifyExternal sends a notification with the actor notifier.ActorExternal for every key that
+// was created, deleted or changed in value or attributes between the two versions
+func (p *Persistence) notifyExternal(ctx context.Context, previous, current *v1.ConfigMap) {
+	if previous == nil {
+		previous = &v1.ConfigMap{}
+	}
+	if current == nil {
+		current = &v1.ConfigMap{}
+	}
+	attrsBefore, attrsAfter := loadAttributes(ctx, previous), loadAttributes(ctx, current)
+
+	var notifications []notifier.Notification
+	for key, value := range current.Data {
+		old, existed := previous.Data[key]
+		switch {
+		case !existed:
+			notifications = append(notifications, notifier.CreateNotifucation(key, value))
+		case old != value || !reflect.DeepEqual(attrsBefore[key], attrsAfter[key]):
+			notifications = append(notifications, notifier.UpdateNotification(key, value))
+		}
+	}
+	for key := range previous.Data {
+		if _, exists := current.Data[key]; !exists {
+			notifications = append(notifications, notifier.DeleteNotification(key))
+		}
+	}
+
+	for _, notification := range notifications {
+		notification.Actor = notifier.ActorExternal
+		slog.InfoContext(ctx, "ConfigMap changed externally", "configmap", p.configMapName, "action", notification.Action.Type, "key", notification.Action.Key)
+		for _, n := range p.notifiers {
+			if err := n.Notify(ctx, notification); err != nil {
+				slog.WarnContext(ctx, "Failed to send notification", "key", notification.Action.Key, "error", err)
+			}
+		}
+	}
+}
@@ -2,10 +2,13 @@ package configmap
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
 	"net/http"
 	"os"
+	"sync"
 
+	"github.com/dkrizic/feature/service/notifier"
 	"github.com/dkrizic/feature/service/service/persistence"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
@@ -14,8 +17,10 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
 )
 
 // configMapClient is an interface for ConfigMap operations to allow testing
@@ -23,10 +28,49 @@ type configMapClient interface {
 	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.ConfigMap, error)
 	Create(ctx context.Context, configMap *v1.ConfigMap, opts metav1.CreateOptions) (*v1.ConfigMap, error)
 	Update(ctx context.Context, configMap *v1.ConfigMap, opts metav1.UpdateOptions) (*v1.ConfigMap, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*v1.ConfigMapList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+}
+
+// attributesAnnotation holds type, constraints, metadata, rules and rollout of the flags as a JSON object keyed by flag key.
+// They are kept out of the data section so the ConfigMap stays usable with envFrom.
+const attributesAnnotation = "feature.dkrizic.github.com/attributes"
+
+// revisionsAnnotation holds the revision of every flag as a JSON object keyed by flag key
+const revisionsAnnotation = "feature.dkrizic.github.com/revisions"
+
+// historySuffix is appended to the ConfigMap name to name the ConfigMap holding the history,
+// one JSON array of entries per key. It is kept apart so the flags ConfigMap stays small.
+const historySuffix = "-history"
+
+// attributes is everything stored for a key besides its value
+type attributes struct {
+	Type          persistence.ValueType      `json:"type,omitempty"`
+	Constraints   *persistence.Constraints   `json:"constraints,omitempty"`
+	Metadata      persistence.Metadata       `json:"metadata,omitzero"`
+	Rules         []persistence.Rule         `json:"rules,omitempty"`
+	Rollout       *persistence.Rollout       `json:"rollout,omitempty"`
+	Prerequisites []persistence.Prerequisite `json:"prerequisites,omitempty"`
+	OffValue      string                     `json:"offValue,omitempty"`
 }
 
 type Persistence struct {
 	configMapName string
+	// notifiers receive the changes made to the ConfigMap by others
+	notifiers []notifier.Notifier
+
+	// startMu guards client and stop, both set once the informer runs
+	startMu sync.Mutex
+	client  configMapClient
+	stop    context.CancelFunc
+
+	// mu guards configMap, the flags ConfigMap as last seen by the informer, nil if it does not exist
+	mu        sync.RWMutex
+	configMap *v1.ConfigMap
+
+	// writeMu guards pending, the resourceVersions of own updates the informer has not seen yet
+	writeMu sync.Mutex
+	pending map[string]chan struct{}
 }
 
 // Injectable function variables for testing
@@ -35,9 +79,13 @@ var (
 	ownNamespaceFn                                                                 = ownNamespace
 )
 
-func NewConfigMapPersistence(configMapName string) *Persistence {
+// NewConfigMapPersistence creates a persistence storing the flags in the named ConfigMap of the
+// own namespace. Changes made to the ConfigMap by others are sent to the notifiers.
+func NewConfigMapPersistence(configMapName string, notifiers ...notifier.Notifier) *Persistence {
 	return &Persistence{
 		configMapName: configMapName,
+		notifiers:     notifiers,
+		pending:       make(map[string]chan struct{}),
 	}
 }
 
@@ -45,67 +93,95 @@ func (p *Persistence) GetAll(ctx context.Context) ([]persistence.KeyValue, error
 	ctx, span := otel.Tracer("service/persistence/configmap").Start(ctx, "GetAll")
 	defer span.End()
 
-	configMap, err := p.createOrLoadConfigMap(ctx)
+	configMap, err := p.cached(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	attrs := loadAttributes(ctx, configMap)
+	revisions := loadRevisions(ctx, configMap)
 	var keyValues []persistence.KeyValue
 	for key, value := range configMap.Data {
-		keyValues = append(keyValues, persistence.KeyValue{
-			Key:   key,
-			Value: value,
-		})
+		keyValues = append(keyValues, keyValue(key, value, attrs[key], revisions[key]))
 	}
 	return keyValues, nil
 }
 
+// Reads are served from the informer cache, see cache.go. Writes load the ConfigMap from the API
+// server, change it and update it with the loaded resourceVersion. If someone else updated the
+// ConfigMap in between, the update conflicts and the write starts over.
+
 func (p *Persistence) PreSet(ctx context.Context, kv persistence.KeyValue) error {
 	ctx, span := otel.Tracer("service/persistence/configmap").Start(ctx, "PreSet")
 	defer span.End()
 
-	configMap, err := p.createOrLoadConfigMap(ctx)
-	if err != nil {
-		return err
-	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := p.createOrLoadConfigMap(ctx)
+		if err != nil {
+			return err
+		}
 
-	if configMap.Data == nil {
-		configMap.Data = make(map[string]string)
-	}
+		if configMap.Data == nil {
+			configMap.Data = make(map[string]string)
+		}
 
-	// Only set if key doesn't exist
-	if _, exists := configMap.Data[kv.Key]; exists {
-		// do not change if there is already a value
-		return nil
-	}
+		// Only set if key doesn't exist
+		if _, exists := configMap.Data[kv.Key]; exists {
+			// do not change if there is already a value
+			return nil
+		}
 
-	configMap.Data[kv.Key] = kv.Value
-	return p.saveConfigMap(ctx, *configMap)
+		configMap.Data[kv.Key] = kv.Value
+		if err := setAttributes(ctx, configMap, kv); err != nil {
+			return err
+		}
+		if err := setRevision(ctx, configMap, kv.Key, 1); err != nil {
+			return err
+		}
+		return p.saveConfigMap(ctx, *configMap)
+	})
 }
 
 func (p *Persistence) Set(ctx context.Context, kv persistence.KeyValue) error {
 	ctx, span := otel.Tracer("service/persistence/configmap").Start(ctx, "Set")
 	defer span.End()
 
-	configMap, err := p.createOrLoadConfigMap(ctx)
-	if err != nil {
-		return err
-	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := p.createOrLoadConfigMap(ctx)
+		if err != nil {
+			return err
+		}
 
-	if configMap.Data == nil {
-		configMap.Data = make(map[string]string)
-	}
-	configMap.Data[kv.Key] = kv.Value
+		if configMap.Data == nil {
+			configMap.Data = make(map[string]string)
+		}
 
-	err = p.saveConfigMap(ctx, *configMap)
-	return err
+		// the revision is checked against the freshly loaded ConfigMap on every attempt
+		var current uint64
+		if _, exists := configMap.Data[kv.Key]; exists {
+			current = max(loadRevisions(ctx, configMap)[kv.Key], 1)
+		}
+		revision, err := persistence.NextRevision(current, kv.Revision)
+		if err != nil {
+			return err
+		}
+
+		configMap.Data[kv.Key] = kv.Value
+		if err := setAttributes(ctx, configMap, kv); err != nil {
+			return err
+		}
+		if err := setRevision(ctx, configMap, kv.Key, revision); err != nil {
+			return err
+		}
+		return p.saveConfigMap(ctx, *configMap)
+	})
 }
 
 func (p *Persistence) Get(ctx context.Context, key string) (persistence.KeyValue, error) {
 	ctx, span := otel.Tracer("service/persistence/configmap").Start(ctx, "Get")
 	defer span.End()
 
-	configMap, err := p.createOrLoadConfigMap(ctx)
+	configMap, err := p.cached(ctx)
 	if err != nil {
 		return persistence.KeyValue{}, err
 	}
@@ -113,43 +189,196 @@ func (p *Persistence) Get(ctx context.Context, key string) (persistence.KeyValue
 	if !exists {
 		return persistence.KeyValue{}, persistence.ErrKeyNotFound
 	}
-	return persistence.KeyValue{
-		Key:   key,
-		Value: value,
-	}, nil
+	return keyValue(key, value, loadAttributes(ctx, configMap)[key], loadRevisions(ctx, configMap)[key]), nil
 }
 
 func (p *Persistence) Delete(ctx context.Context, key string) error {
 	ctx, span := otel.Tracer("service/persistence/configmap").Start(ctx, "Delete")
 	defer span.End()
 
-	configMap, err := p.createOrLoadConfigMap(ctx)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := p.createOrLoadConfigMap(ctx)
+		if err != nil {
+			return err
+		}
+		delete(configMap.Data, key)
+		if err := setAttributes(ctx, configMap, persistence.KeyValue{Key: key}); err != nil {
+			return err
+		}
+		if err := setRevision(ctx, configMap, key, 0); err != nil {
+			return err
+		}
+		return p.saveConfigMap(ctx, *configMap)
+	})
+}
+
+// Batch applies all operations to the loaded ConfigMap and saves it with a single update,
+// so either all of them become visible or none.
+func (p *Persistence) Batch(ctx context.Context, operations []persistence.Operation) error {
+	ctx, span := otel.Tracer("service/persistence/configmap").Start(ctx, "Batch")
+	defer span.End()
+	span.SetAttributes(attribute.Int("operations", len(operations)))
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := p.createOrLoadConfigMap(ctx)
+		if err != nil {
+			return err
+		}
+
+		if configMap.Data == nil {
+			configMap.Data = make(map[string]string)
+		}
+
+		for _, op := range operations {
+			kv := op.KeyValue
+			if op.Delete {
+				delete(configMap.Data, kv.Key)
+				if err := setAttributes(ctx, configMap, persistence.KeyValue{Key: kv.Key}); err != nil {
+					return err
+				}
+				if err := setRevision(ctx, configMap, kv.Key, 0); err != nil {
+					return err
+				}
+				continue
+			}
+
+			var current uint64
+			if _, exists := configMap.Data[kv.Key]; exists {
+				current = max(loadRevisions(ctx, configMap)[kv.Key], 1)
+			}
+			revision, err := persistence.NextRevision(current, kv.Revision)
+			if err != nil {
+				return err
+			}
+			configMap.Data[kv.Key] = kv.Value
+			if err := setAttributes(ctx, configMap, kv); err != nil {
+				return err
+			}
+			if err := setRevision(ctx, configMap, kv.Key, revision); err != nil {
+				return err
+			}
+		}
+		return p.saveConfigMap(ctx, *configMap)
+	})
+}
+
+func keyValue(key, value string, attrs attributes, revision uint64) persistence.KeyValue {
+	// keys written before revisions were tracked, or added to the ConfigMap directly, have been written once
+	if revision == 0 {
+		revision = 1
+	}
+	return persistence.KeyValue{
+		Key:           key,
+		Value:         value,
+		Type:          attrs.Type,
+		Constraints:   attrs.Constraints,
+		Metadata:      attrs.Metadata,
+		Rules:         attrs.Rules,
+		Rollout:       attrs.Rollout,
+		Prerequisites: attrs.Prerequisites,
+		OffValue:      attrs.OffValue,
+		Revision:      revision,
+	}
+}
+
+// loadAttributes decodes the attributes annotation, a malformed annotation is ignored
+func loadAttributes(ctx context.Context, configMap *v1.ConfigMap) map[string]attributes {
+	attrs := make(map[string]attributes)
+	raw, exists := configMap.Annotations[attributesAnnotation]
+	if !exists || raw == "" {
+		return attrs
+	}
+	if err := json.Unmarshal([]byte(raw), &attrs); err != nil {
+		slog.WarnContext(ctx, "Ignoring malformed attributes annotation", "configmap", configMap.Name, "error", err)
+		return make(map[string]attributes)
+	}
+	return attrs
+}
+
+// setAttributes stores the attributes of kv in the annotation, removing the entry if there are none
+func setAttributes(ctx context.Context, configMap *v1.ConfigMap, kv persistence.KeyValue) error {
+	attrs := loadAttributes(ctx, configMap)
+	if kv.Type == "" && kv.Constraints == nil && kv.Metadata.IsZero() && len(kv.Rules) == 0 && kv.Rollout == nil &&
+		len(kv.Prerequisites) == 0 && kv.OffValue == "" {
+		delete(attrs, kv.Key)
+	} else {
+		attrs[kv.Key] = attributes{Type: kv.Type, Constraints: kv.Constraints, Metadata: kv.Metadata, Rules: kv.Rules, Rollout: kv.Rollout,
+			Prerequisites: kv.Prerequisites, OffValue: kv.OffValue}
+	}
+
+	if len(attrs) == 0 {
+		delete(configMap.Annotations, attributesAnnotation)
+		return nil
+	}
+	raw, err := json.Marshal(attrs)
 	if err != nil {
 		return err
 	}
-	delete(configMap.Data, key)
+	if configMap.Annotations == nil {
+		configMap.Annotations = make(map[string]string)
+	}
+	configMap.Annotations[attributesAnnotation] = string(raw)
+	return nil
+}
 
-	err = p.saveConfigMap(ctx, *configMap)
-	return err
+// loadRevisions decodes the revisions annotation, a malformed annotation is ignored
+func loadRevisions(ctx context.Context, configMap *v1.ConfigMap) map[string]uint64 {
+	revisions := make(map[string]uint64)
+	raw, exists := configMap.Annotations[revisionsAnnotation]
+	if !exists || raw == "" {
+		return revisions
+	}
+	if err := json.Unmarshal([]byte(raw), &revisions); err != nil {
+		slog.WarnContext(ctx, "Ignoring malformed revisions annotation", "configmap", configMap.Name, "error", err)
+		return make(map[string]uint64)
+	}
+	return revisions
+}
+
+// setRevision stores the revision of a key in the annotation, removing the entry for revision 0
+func setRevision(ctx context.Context, configMap *v1.ConfigMap, key string, revision uint64) error {
+	revisions := loadRevisions(ctx, configMap)
+	if revision == 0 {
+		delete(revisions, key)
+	} else {
+		revisions[key] = revision
+	}
+
+	if len(revisions) == 0 {
+		delete(configMap.Annotations, revisionsAnnotation)
+		return nil
+	}
+	raw, err := json.Marshal(revisions)
+	if err != nil {
+		return err
+	}
+	if configMap.Annotations == nil {
+		configMap.Annotations = make(map[string]string)
+	}
+	configMap.Annotations[revisionsAnnotation] = string(raw)
+	return nil
 }
 
 func (p *Persistence) createOrLoadConfigMap(ctx context.Context) (*v1.ConfigMap, error) {
+	return p.createOrLoad(ctx, p.configMapName)
+}
+
+func (p *Persistence) createOrLoad(ctx context.Context, name string) (*v1.ConfigMap, error) {
 	ctx, span := otel.Tracer("service/persistence/configmap").Start(ctx, "createOrLoadConfigMap")
 	defer span.End()
 
-	configMapClient, namespace, err := k8sClientFn(ctx, p.configMapName)
+	configMapClient, err := p.start(ctx)
 	if err != nil {
 		return nil, err
 	}
-	slog.DebugContext(ctx, "Running in namespace", "namespace", *namespace)
 
-	configMap, err := configMapClient.Get(ctx, p.configMapName, metav1.GetOptions{})
+	configMap, err := configMapClient.Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// ConfigMap does not exist, create it
 			newConfigMap := &v1.ConfigMap{
 				ObjectMeta: metav1.ObjectMeta{
-					Name: p.configMapName,
+					Name: name,
 				},
 				Data: map[string]string{},
 			}
@@ -210,16 +439,16 @@ func (p *Persistence) saveConfigMap(ctx context.Context, configMap v1.ConfigMap)
 	ctx, span := otel.Tracer("service/persistence/configmap").Start(ctx, "saveConfigMap")
 	defer span.End()
 
-	configMapClient, _, err := k8sClientFn(ctx, p.configMapName)
+	configMapClient, err := p.start(ctx)
 	if err != nil {
 		return err
 	}
 
-	_, err = configMapClient.Update(ctx, &configMap, metav1.UpdateOptions{})
-	if err != nil {
-		return err
+	if configMap.Name == p.configMapName {
+		return p.update(ctx, configMapClient, &configMap)
 	}
-	return nil
+	_, err = configMapClient.Update(ctx, &configMap, metav1.UpdateOptions{})
+	return err
 }
 
 func ownNamespace(ctx context.Context) (namespace *string, err error) {
@@ -240,10 +469,58 @@ func (p *Persistence) Count(ctx context.Context) (int, error) {
 	ctx, span := otel.Tracer("service/persistence/configmap").Start(ctx, "Count")
 	defer span.End()
 
-	configMap, err := p.createOrLoadConfigMap(ctx)
+	configMap, err := p.cached(ctx)
 	if err != nil {
 		return 0, err
 	}
 	count := len(configMap.Data)
 	return count, nil
 }
+
+func (p *Persistence) AppendHistory(ctx context.Context, entry persistence.HistoryEntry) error {
+	ctx, span := otel.Tracer("service/persistence/configmap").Start(ctx, "AppendHistory")
+	defer span.End()
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := p.createOrLoad(ctx, p.configMapName+historySuffix)
+		if err != nil {
+			return err
+		}
+
+		entries := persistence.AppendEntry(loadHistory(ctx, configMap, entry.Key), entry)
+		raw, err := json.Marshal(entries)
+		if err != nil {
+			return err
+		}
+		if configMap.Data == nil {
+			configMap.Data = make(map[string]string)
+		}
+		configMap.Data[entry.Key] = string(raw)
+		return p.saveConfigMap(ctx, *configMap)
+	})
+}
+
+func (p *Persistence) History(ctx context.Context, key string) ([]persistence.HistoryEntry, error) {
+	ctx, span := otel.Tracer("service/persistence/configmap").Start(ctx, "History")
+	defer span.End()
+
+	configMap, err := p.createOrLoad(ctx, p.configMapName+historySuffix)
+	if err != nil {
+		return nil, err
+	}
+	return loadHistory(ctx, configMap, key), nil
+}
+
+// loadHistory decodes the history of a key, a malformed history is ignored
+func loadHistory(ctx context.Context, configMap *v1.ConfigMap, key string) []persistence.HistoryEntry {
+	raw, exists := configMap.Data[key]
+	if !exists || raw == "" {
+		return nil
+	}
+	var entries []persistence.HistoryEntry
+	if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+		slog.WarnContext(ctx, "Ignoring malformed history", "configmap", configMap.Name, "key", key, "error", err)
+		return nil
+	}
+	return entries
+}
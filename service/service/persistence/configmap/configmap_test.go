@@ -2,22 +2,47 @@ package configmap
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/dkrizic/feature/service/notifier"
 	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/dkrizic/feature/service/service/persistence/persistencetest"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
-// fakeConfigMapClient implements configMapClient interface for testing
+// fakeConfigMapClient implements configMapClient interface for testing. Like the API server it
+// rejects updates carrying an outdated resourceVersion, and its watches start at the
+// resourceVersion they are given.
 type fakeConfigMapClient struct {
+	mu         sync.Mutex
 	configMaps map[string]*v1.ConfigMap
+	// conflicts is the number of updates to reject as if someone else updated the ConfigMap first
+	conflicts int
+	updates   int
+	gets      int
+	// clients counts the clients handed out by k8sClientFn
+	clients int
+	// version is the last resourceVersion handed out, events are all changes so far
+	version  int
+	events   []watch.Event
+	watchers []*fakeWatcher
 }
 
 func (f *fakeConfigMapClient) Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.ConfigMap, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gets++
 	cm, exists := f.configMaps[name]
 	if !exists {
 		return nil, errors.NewNotFound(schema.GroupResource{Group: "", Resource: "configmaps"}, name)
@@ -27,23 +52,124 @@ func (f *fakeConfigMapClient) Get(ctx context.Context, name string, opts metav1.
 }
 
 func (f *fakeConfigMapClient) Create(ctx context.Context, configMap *v1.ConfigMap, opts metav1.CreateOptions) (*v1.ConfigMap, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 	if _, exists := f.configMaps[configMap.Name]; exists {
 		return nil, errors.NewAlreadyExists(schema.GroupResource{Group: "", Resource: "configmaps"}, configMap.Name)
 	}
-	// Store a copy
-	cmCopy := configMap.DeepCopy()
-	f.configMaps[configMap.Name] = cmCopy
-	return cmCopy, nil
+	return f.store(watch.Added, configMap), nil
 }
 
 func (f *fakeConfigMapClient) Update(ctx context.Context, configMap *v1.ConfigMap, opts metav1.UpdateOptions) (*v1.ConfigMap, error) {
-	if _, exists := f.configMaps[configMap.Name]; !exists {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stored, exists := f.configMaps[configMap.Name]
+	if !exists {
 		return nil, errors.NewNotFound(schema.GroupResource{Group: "", Resource: "configmaps"}, configMap.Name)
 	}
-	// Store a copy
+	f.updates++
+	if f.conflicts > 0 {
+		f.conflicts--
+		return nil, errors.NewConflict(schema.GroupResource{Group: "", Resource: "configmaps"}, configMap.Name, fmt.Errorf("the object has been modified"))
+	}
+	if configMap.ResourceVersion != "" && configMap.ResourceVersion != stored.ResourceVersion {
+		return nil, errors.NewConflict(schema.GroupResource{Group: "", Resource: "configmaps"}, configMap.Name, fmt.Errorf("the object has been modified"))
+	}
+	return f.store(watch.Modified, configMap), nil
+}
+
+// delete removes a ConfigMap like kubectl delete would
+func (f *fakeConfigMapClient) delete(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	configMap := f.configMaps[name].DeepCopy()
+	delete(f.configMaps, name)
+	f.version++
+	configMap.ResourceVersion = strconv.Itoa(f.version)
+	f.emit(watch.Event{Type: watch.Deleted, Object: configMap})
+}
+
+// store saves a copy with the next resourceVersion and sends it to the watchers, f.mu must be held
+func (f *fakeConfigMapClient) store(eventType watch.EventType, configMap *v1.ConfigMap) *v1.ConfigMap {
+	f.version++
 	cmCopy := configMap.DeepCopy()
+	cmCopy.ResourceVersion = strconv.Itoa(f.version)
 	f.configMaps[configMap.Name] = cmCopy
-	return cmCopy, nil
+	f.emit(watch.Event{Type: eventType, Object: cmCopy.DeepCopy()})
+	return cmCopy.DeepCopy()
+}
+
+func (f *fakeConfigMapClient) emit(event watch.Event) {
+	f.events = append(f.events, event)
+	for _, w := range f.watchers {
+		w.send(event)
+	}
+}
+
+func (f *fakeConfigMapClient) List(ctx context.Context, opts metav1.ListOptions) (*v1.ConfigMapList, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	selector, err := fields.ParseSelector(opts.FieldSelector)
+	if err != nil {
+		return nil, err
+	}
+	list := &v1.ConfigMapList{ListMeta: metav1.ListMeta{ResourceVersion: strconv.Itoa(f.version)}}
+	for name, cm := range f.configMaps {
+		if selector.Matches(fields.Set{"metadata.name": name}) {
+			list.Items = append(list.Items, *cm.DeepCopy())
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeConfigMapClient) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	selector, err := fields.ParseSelector(opts.FieldSelector)
+	if err != nil {
+		return nil, err
+	}
+	since, _ := strconv.Atoi(opts.ResourceVersion)
+	w := &fakeWatcher{client: f, selector: selector, result: make(chan watch.Event, 1000)}
+	for _, event := range f.events {
+		if version, _ := strconv.Atoi(event.Object.(*v1.ConfigMap).ResourceVersion); version > since {
+			w.send(event)
+		}
+	}
+	f.watchers = append(f.watchers, w)
+	return w, nil
+}
+
+// IsWatchListSemanticsUnSupported makes the informer list before it watches, the fake cannot
+// stream the initial list
+func (f *fakeConfigMapClient) IsWatchListSemanticsUnSupported() bool {
+	return true
+}
+
+// fakeWatcher receives the events of the ConfigMaps matching its selector
+type fakeWatcher struct {
+	client   *fakeConfigMapClient
+	selector fields.Selector
+	result   chan watch.Event
+}
+
+func (w *fakeWatcher) send(event watch.Event) {
+	if w.selector.Matches(fields.Set{"metadata.name": event.Object.(*v1.ConfigMap).Name}) {
+		w.result <- watch.Event{Type: event.Type, Object: event.Object.DeepCopyObject()}
+	}
+}
+
+func (w *fakeWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+func (w *fakeWatcher) Stop() {
+	w.client.mu.Lock()
+	defer w.client.mu.Unlock()
+	if i := slices.Index(w.client.watchers, w); i >= 0 {
+		w.client.watchers = slices.Delete(w.client.watchers, i, i+1)
+		close(w.result)
+	}
 }
 
 // setupFakeK8s sets up fake k8s client for tests and returns the fake client for seeding data
@@ -59,6 +185,9 @@ func setupFakeK8s(namespace string) *fakeConfigMapClient {
 	// Override the injectable functions
 	k8sClientFn = func(ctx context.Context, configMapName string) (configMapClient, *string, error) {
 		ns := namespace
+		fakeClient.mu.Lock()
+		fakeClient.clients++
+		fakeClient.mu.Unlock()
 		return fakeClient, &ns, nil
 	}
 
@@ -173,6 +302,161 @@ func TestConfigMapPersistence_Set(t *testing.T) {
 	assert.Equal(t, "newvalue", kv.Value)
 }
 
+func TestConfigMapPersistence_Set_Attributes(t *testing.T) {
+	fakeClient := setupFakeK8s("test-namespace")
+	ctx := context.Background()
+	p := NewConfigMapPersistence("test-configmap")
+
+	max := 10.0
+	err := p.Set(ctx, persistence.KeyValue{
+		Key:         "RETRIES",
+		Value:       "3",
+		Type:        persistence.TypeInteger,
+		Constraints: &persistence.Constraints{Max: &max},
+	})
+	assert.NoError(t, err)
+
+	// the value stays plain in the data section, the attributes go into the annotation
+	cm := fakeClient.configMaps["test-configmap"]
+	assert.Equal(t, "3", cm.Data["RETRIES"])
+	assert.JSONEq(t, `{"RETRIES":{"type":"integer","constraints":{"max":10}}}`, cm.Annotations[attributesAnnotation])
+
+	kv, err := p.Get(ctx, "RETRIES")
+	assert.NoError(t, err)
+	assert.Equal(t, persistence.TypeInteger, kv.Type)
+	assert.Equal(t, 10.0, *kv.Constraints.Max)
+
+	all, err := p.GetAll(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, all, 1)
+	assert.Equal(t, persistence.TypeInteger, all[0].Type)
+
+	// deleting the key removes its attributes
+	err = p.Delete(ctx, "RETRIES")
+	assert.NoError(t, err)
+	_, exists := fakeClient.configMaps["test-configmap"].Annotations[attributesAnnotation]
+	assert.False(t, exists)
+}
+
+func TestConfigMapPersistence_Set_Metadata(t *testing.T) {
+	fakeClient := setupFakeK8s("test-namespace")
+	ctx := context.Background()
+	p := NewConfigMapPersistence("test-configmap")
+
+	updated := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	err := p.Set(ctx, persistence.KeyValue{
+		Key:   "BOOKING",
+		Value: "true",
+		Metadata: persistence.Metadata{
+			Description:    "Enables the booking flow",
+			Tags:           []string{"checkout", "q3"},
+			UpdatedAt:      updated,
+			LastModifiedBy: "alice",
+		},
+	})
+	assert.NoError(t, err)
+
+	cm := fakeClient.configMaps["test-configmap"]
+	assert.Equal(t, map[string]string{"BOOKING": "true"}, cm.Data)
+	assert.JSONEq(t, `{"BOOKING":{"metadata":{"description":"Enables the booking flow","tags":["checkout","q3"],"updatedAt":"2025-06-01T12:00:00Z","lastModifiedBy":"alice"}}}`, cm.Annotations[attributesAnnotation])
+
+	kv, err := p.Get(ctx, "BOOKING")
+	assert.NoError(t, err)
+	assert.Equal(t, "Enables the booking flow", kv.Metadata.Description)
+	assert.Equal(t, []string{"checkout", "q3"}, kv.Metadata.Tags)
+	assert.Equal(t, updated, kv.Metadata.UpdatedAt)
+	assert.True(t, kv.Metadata.CreatedAt.IsZero())
+}
+
+func TestConfigMapPersistence_Set_Rules(t *testing.T) {
+	fakeClient := setupFakeK8s("test-namespace")
+	ctx := context.Background()
+	p := NewConfigMapPersistence("test-configmap")
+
+	rules := []persistence.Rule{{
+		Name:       "beta",
+		Value:      "new",
+		Conditions: []persistence.Condition{{Attribute: "tenant", Operator: persistence.OperatorIn, Values: []string{"acme", "globex"}}},
+	}}
+	err := p.Set(ctx, persistence.KeyValue{Key: "CHECKOUT", Value: "classic", Rules: rules})
+	assert.NoError(t, err)
+
+	cm := fakeClient.configMaps["test-configmap"]
+	assert.Equal(t, map[string]string{"CHECKOUT": "classic"}, cm.Data)
+	assert.JSONEq(t, `{"CHECKOUT":{"rules":[{"name":"beta","conditions":[{"attribute":"tenant","operator":"in","values":["acme","globex"]}],"value":"new"}]}}`, cm.Annotations[attributesAnnotation])
+
+	kv, err := p.Get(ctx, "CHECKOUT")
+	assert.NoError(t, err)
+	assert.Equal(t, rules, kv.Rules)
+
+	rollout := &persistence.Rollout{BucketBy: "tenant", Variants: []persistence.Variant{{Value: "new", Percentage: 12.5}}}
+	err = p.Set(ctx, persistence.KeyValue{Key: "CHECKOUT", Value: "classic", Rollout: rollout})
+	assert.NoError(t, err)
+	kv, err = p.Get(ctx, "CHECKOUT")
+	assert.NoError(t, err)
+	assert.Equal(t, rollout, kv.Rollout)
+
+	// removing the rules drops the attributes entry
+	err = p.Set(ctx, persistence.KeyValue{Key: "CHECKOUT", Value: "classic"})
+	assert.NoError(t, err)
+	assert.NotContains(t, fakeClient.configMaps["test-configmap"].Annotations[attributesAnnotation], "CHECKOUT")
+}
+
+func TestConfigMapPersistence_History(t *testing.T) {
+	fakeClient := setupFakeK8s("test-namespace")
+	ctx := context.Background()
+	p := NewConfigMapPersistence("test-configmap")
+
+	changed := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	err := p.AppendHistory(ctx, persistence.HistoryEntry{Key: "COLOR", Action: persistence.ActionCreate, NewValue: "red", Timestamp: changed, Source: "Set"})
+	assert.NoError(t, err)
+	err = p.AppendHistory(ctx, persistence.HistoryEntry{Key: "COLOR", Action: persistence.ActionUpdate, OldValue: "red", NewValue: "blue", Actor: "alice", Timestamp: changed, Source: "Set"})
+	assert.NoError(t, err)
+
+	// the history lives in its own ConfigMap, the flags ConfigMap is untouched
+	assert.NotContains(t, fakeClient.configMaps, "test-configmap")
+	history := fakeClient.configMaps["test-configmap-history"]
+	assert.Contains(t, history.Data["COLOR"], `"newValue":"blue"`)
+
+	entries, err := p.History(ctx, "COLOR")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, uint64(2), entries[1].Revision)
+	assert.Equal(t, "red", entries[1].OldValue)
+	assert.Equal(t, "alice", entries[1].Actor)
+	assert.Equal(t, changed, entries[1].Timestamp)
+
+	entries, err = p.History(ctx, "THEME")
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// a malformed history is ignored
+	history = fakeClient.configMaps["test-configmap-history"]
+	history.Data["THEME"] = "{"
+	entries, err = p.History(ctx, "THEME")
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestConfigMapPersistence_MalformedAttributes(t *testing.T) {
+	fakeClient := setupFakeK8s("test-namespace")
+	ctx := context.Background()
+
+	fakeClient.configMaps["test-configmap"] = &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-configmap",
+			Annotations: map[string]string{attributesAnnotation: "{not json"},
+		},
+		Data: map[string]string{"key1": "value1"},
+	}
+
+	p := NewConfigMapPersistence("test-configmap")
+	kv, err := p.Get(ctx, "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "value1", kv.Value)
+	assert.Empty(t, kv.Type)
+}
+
 func TestConfigMapPersistence_Get(t *testing.T) {
 	fakeClient := setupFakeK8s("test-namespace")
 	ctx := context.Background()
@@ -270,3 +554,254 @@ func TestConfigMapPersistence_Count(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 3, count)
 }
+
+func TestConfigMapPersistence_Revision(t *testing.T) {
+	setupFakeK8s("test-namespace")
+	p := NewConfigMapPersistence("test-configmap")
+	ctx := context.Background()
+
+	assert.NoError(t, p.PreSet(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	kv, err := p.Get(ctx, "COLOR")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), kv.Revision)
+
+	// a conditional write with the current revision succeeds and bumps it
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "blue", Revision: 1}))
+	kv, _ = p.Get(ctx, "COLOR")
+	assert.Equal(t, "blue", kv.Value)
+	assert.Equal(t, uint64(2), kv.Revision)
+
+	// a stale revision is rejected and nothing changes
+	err = p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "green", Revision: 1})
+	assert.ErrorIs(t, err, persistence.ErrRevisionMismatch)
+	kv, _ = p.Get(ctx, "COLOR")
+	assert.Equal(t, "blue", kv.Value)
+
+	// unconditional writes always succeed
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "green"}))
+	kv, _ = p.Get(ctx, "COLOR")
+	assert.Equal(t, uint64(3), kv.Revision)
+
+	// a recreated key starts over
+	assert.NoError(t, p.Delete(ctx, "COLOR"))
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	kv, _ = p.Get(ctx, "COLOR")
+	assert.Equal(t, uint64(1), kv.Revision)
+}
+
+func TestConfigMapPersistence_Revision_AddedOutOfBand(t *testing.T) {
+	fakeClient := setupFakeK8s("test-namespace")
+	fakeClient.configMaps["test-configmap"] = &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-configmap"},
+		Data:       map[string]string{"COLOR": "red"},
+	}
+	p := NewConfigMapPersistence("test-configmap")
+	ctx := context.Background()
+
+	kv, err := p.Get(ctx, "COLOR")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), kv.Revision)
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "blue", Revision: 1}))
+}
+
+// recordingNotifier keeps the notifications it receives
+type recordingNotifier struct {
+	mu            sync.Mutex
+	notifications []notifier.Notification
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, notification notifier.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifications = append(r.notifications, notification)
+	return nil
+}
+
+func (r *recordingNotifier) actions() []notifier.Action {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var actions []notifier.Action
+	for _, n := range r.notifications {
+		actions = append(actions, n.Action)
+	}
+	return actions
+}
+
+func (r *recordingNotifier) actors() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var actors []string
+	for _, n := range r.notifications {
+		actors = append(actors, n.Actor)
+	}
+	return actors
+}
+
+func TestConfigMapPersistence_Cache(t *testing.T) {
+	fakeClient := setupFakeK8s("test-namespace")
+	p := NewConfigMapPersistence("test-configmap")
+	t.Cleanup(p.Close)
+	ctx := context.Background()
+
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	gets := fakeClient.gets
+
+	// reads are served from the cache and see the write
+	for range 3 {
+		kv, err := p.Get(ctx, "COLOR")
+		assert.NoError(t, err)
+		assert.Equal(t, "red", kv.Value)
+		count, err := p.Count(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+		all, err := p.GetAll(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, all, 1)
+	}
+	assert.Equal(t, gets, fakeClient.gets)
+
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "blue", Revision: 1}))
+	kv, _ := p.Get(ctx, "COLOR")
+	assert.Equal(t, persistence.KeyValue{Key: "COLOR", Value: "blue", Revision: 2}, kv)
+	assert.Equal(t, 1, fakeClient.clients)
+}
+
+func TestConfigMapPersistence_ExternalChange(t *testing.T) {
+	fakeClient := setupFakeK8s("test-namespace")
+	recorder := &recordingNotifier{}
+	p := NewConfigMapPersistence("test-configmap", recorder)
+	t.Cleanup(p.Close)
+	ctx := context.Background()
+
+	// own writes are notified by the notifying persistence, not by the informer
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "SIZE", Value: "large"}))
+	assert.Empty(t, recorder.actions())
+
+	// kubectl edit
+	cm, _ := fakeClient.Get(ctx, "test-configmap", metav1.GetOptions{})
+	cm.Data = map[string]string{"COLOR": "blue", "THEME": "dark"}
+	_, err := fakeClient.Update(ctx, cm, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		kv, err := p.Get(ctx, "COLOR")
+		return err == nil && kv.Value == "blue"
+	}, time.Second, 10*time.Millisecond)
+	blue, dark := "blue", "dark"
+	assert.ElementsMatch(t, []notifier.Action{
+		{Type: notifier.ActionUpdate, Key: "COLOR", Value: &blue},
+		{Type: notifier.ActionCreate, Key: "THEME", Value: &dark},
+		{Type: notifier.ActionDelete, Key: "SIZE"},
+	}, recorder.actions())
+	assert.Equal(t, []string{notifier.ActorExternal, notifier.ActorExternal, notifier.ActorExternal}, recorder.actors())
+}
+
+func TestConfigMapPersistence_ExternalChange_Attributes(t *testing.T) {
+	fakeClient := setupFakeK8s("test-namespace")
+	recorder := &recordingNotifier{}
+	p := NewConfigMapPersistence("test-configmap", recorder)
+	t.Cleanup(p.Close)
+	ctx := context.Background()
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "ENABLED", Value: "true"}))
+
+	// changing only the type of a flag is an update
+	cm, _ := fakeClient.Get(ctx, "test-configmap", metav1.GetOptions{})
+	cm.Annotations = map[string]string{attributesAnnotation: `{"ENABLED":{"type":"boolean"}}`}
+	_, err := fakeClient.Update(ctx, cm, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		kv, _ := p.Get(ctx, "ENABLED")
+		return kv.Type == persistence.TypeBoolean
+	}, time.Second, 10*time.Millisecond)
+	enabled := "true"
+	assert.Equal(t, []notifier.Action{{Type: notifier.ActionUpdate, Key: "ENABLED", Value: &enabled}}, recorder.actions())
+}
+
+func TestConfigMapPersistence_ExternalDelete(t *testing.T) {
+	fakeClient := setupFakeK8s("test-namespace")
+	recorder := &recordingNotifier{}
+	p := NewConfigMapPersistence("test-configmap", recorder)
+	t.Cleanup(p.Close)
+	ctx := context.Background()
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "SIZE", Value: "large"}))
+
+	// deleting the ConfigMap deletes all flags
+	fakeClient.delete("test-configmap")
+	assert.Eventually(t, func() bool {
+		count, _ := p.Count(ctx)
+		return count == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.ElementsMatch(t, []notifier.Action{
+		{Type: notifier.ActionDelete, Key: "COLOR"},
+		{Type: notifier.ActionDelete, Key: "SIZE"},
+	}, recorder.actions())
+
+	// the next write creates it again
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "blue"}))
+	kv, err := p.Get(ctx, "COLOR")
+	assert.NoError(t, err)
+	assert.Equal(t, "blue", kv.Value)
+	assert.Len(t, recorder.actions(), 2)
+}
+
+func TestConfigMapPersistence_RetryOnConflict(t *testing.T) {
+	fakeClient := setupFakeK8s("test-namespace")
+	p := NewConfigMapPersistence("test-configmap")
+	ctx := context.Background()
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+
+	fakeClient.conflicts = 2
+	fakeClient.updates = 0
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "blue"}))
+	assert.Equal(t, 3, fakeClient.updates)
+	assert.Equal(t, "blue", fakeClient.configMaps["test-configmap"].Data["COLOR"])
+
+	// an update with a stale resourceVersion is a conflict, the retry loads the ConfigMap again
+	stale, err := p.createOrLoadConfigMap(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "THEME", Value: "dark"}))
+	err = p.saveConfigMap(ctx, *stale)
+	assert.True(t, errors.IsConflict(err))
+	assert.NoError(t, p.Delete(ctx, "THEME"))
+	assert.NotContains(t, fakeClient.configMaps["test-configmap"].Data, "THEME")
+}
+
+func TestConfigMapPersistence_Batch(t *testing.T) {
+	fakeClient := setupFakeK8s("test-namespace")
+	p := NewConfigMapPersistence("test-configmap")
+	ctx := context.Background()
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "SIZE", Value: "large"}))
+
+	// all operations are saved with a single update
+	fakeClient.updates = 0
+	assert.NoError(t, p.Batch(ctx, []persistence.Operation{
+		{KeyValue: persistence.KeyValue{Key: "COLOR", Value: "blue", Revision: 1}},
+		{KeyValue: persistence.KeyValue{Key: "THEME", Value: "dark", Type: persistence.TypeString}},
+		{KeyValue: persistence.KeyValue{Key: "SIZE"}, Delete: true},
+	}))
+	assert.Equal(t, 1, fakeClient.updates)
+	assert.Equal(t, map[string]string{"COLOR": "blue", "THEME": "dark"}, fakeClient.configMaps["test-configmap"].Data)
+	kv, _ := p.Get(ctx, "COLOR")
+	assert.Equal(t, uint64(2), kv.Revision)
+	kv, _ = p.Get(ctx, "THEME")
+	assert.Equal(t, persistence.TypeString, kv.Type)
+
+	// a stale revision fails the whole batch
+	err := p.Batch(ctx, []persistence.Operation{
+		{KeyValue: persistence.KeyValue{Key: "THEME", Value: "light"}},
+		{KeyValue: persistence.KeyValue{Key: "COLOR", Value: "green", Revision: 1}},
+	})
+	assert.ErrorIs(t, err, persistence.ErrRevisionMismatch)
+	assert.Equal(t, map[string]string{"COLOR": "blue", "THEME": "dark"}, fakeClient.configMaps["test-configmap"].Data)
+}
+
+func TestConformance(t *testing.T) {
+	persistencetest.Run(t, func(t *testing.T) persistence.Persistence {
+		setupFakeK8s("test-namespace")
+		return NewConfigMapPersistence("test-configmap")
+	}, persistencetest.Limitations{})
+}
@@ -0,0 +1,627 @@
+package crd
+
+// implements the persistence interface on top of FeatureFlag custom resources, one object per flag
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/dkrizic/feature/service/service/persistence"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	Group   = "feature.dkrizic.github.com"
+	Version = "v1alpha1"
+	Kind    = "FeatureFlag"
+)
+
+// Resource identifies the FeatureFlag custom resources
+var Resource = schema.GroupVersionResource{Group: Group, Version: Version, Resource: "featureflags"}
+
+// configMaps identifies the ConfigMaps, the history is kept in one
+var configMaps = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+// HistoryConfigMap is the ConfigMap holding the history of the default set, another set uses one
+// suffixed with its name. The history is kept apart from the FeatureFlags to outlive deleted flags.
+const HistoryConfigMap = "featureflag-history"
+
+// FeatureFlag is the custom resource holding one flag. The spec is what GitOps tools manage,
+// the status is maintained by the service.
+type FeatureFlag struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FeatureFlagSpec   `json:"spec"`
+	Status FeatureFlagStatus `json:"status,omitzero"`
+}
+
+type FeatureFlagSpec struct {
+	// Key is the flag key, the object name is derived from it as keys need not be valid names
+	Key           string                     `json:"key"`
+	Value         string                     `json:"value"`
+	Type          persistence.ValueType      `json:"type,omitempty"`
+	Constraints   *persistence.Constraints   `json:"constraints,omitempty"`
+	Metadata      persistence.Metadata       `json:"metadata,omitzero"`
+	Rules         []persistence.Rule         `json:"rules,omitempty"`
+	Rollout       *persistence.Rollout       `json:"rollout,omitempty"`
+	Prerequisites []persistence.Prerequisite `json:"prerequisites,omitempty"`
+	OffValue      string                     `json:"offValue,omitempty"`
+}
+
+type FeatureFlagStatus struct {
+	Revision uint64 `json:"revision,omitempty"`
+}
+
+// SetLabel holds the flag set of a FeatureFlag, flags without it belong to the default set
+const SetLabel = Group + "/set"
+
+type Persistence struct {
+	// flags are the FeatureFlags in the namespace of the service
+	flags dynamic.ResourceInterface
+	// history are the ConfigMaps in the namespace of the service
+	history dynamic.ResourceInterface
+	// flagSet is the flag set of the flags, empty for the default set
+	flagSet string
+}
+
+// NewCRDPersistence returns the persistence of the FeatureFlags of a flag set, empty for the
+// default set, in the namespace. The client is shared by the flag sets, see NewClient.
+func NewCRDPersistence(client dynamic.Interface, namespace, flagSet string) *Persistence {
+	return &Persistence{
+		flags:   client.Resource(Resource).Namespace(namespace),
+		history: client.Resource(configMaps).Namespace(namespace),
+		flagSet: flagSet,
+	}
+}
+
+var invalidNameCharacters = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// objectName derives the object name from a flag key. Keys that are no valid names, like
+// MY_FLAG, are hashed, see hashedName.
+func objectName(key string) string {
+	if len(validation.IsDNS1123Subdomain(key)) == 0 {
+		return key
+	}
+	return hashedName(key)
+}
+
+// hashedName lowercases and cleans up the key, and a hash of the key keeps the names apart from
+// each other
+func hashedName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := invalidNameCharacters.ReplaceAllString(strings.ToLower(key), "-")
+	name = strings.Trim(name, ".-")
+	if len(name) > 200 {
+		name = strings.TrimRight(name[:200], ".-")
+	}
+	if name == "" {
+		return "flag-" + hex.EncodeToString(sum[:4])
+	}
+	return name + "-" + hex.EncodeToString(sum[:4])
+}
+
+// objectName is the name of the object of a key, prefixed with the flag set unless it is the
+// default set. The name is always hashed then, otherwise the key flag of the set shop and the key
+// shop.flag of the default set would share the object shop.flag.
+func (p *Persistence) objectName(key string) string {
+	if p.flagSet == "" {
+		return objectName(key)
+	}
+	return p.flagSet + "." + hashedName(key)
+}
+
+// labels are the labels of the objects of the flag set
+func (p *Persistence) labels() map[string]string {
+	if p.flagSet == "" {
+		return nil
+	}
+	return map[string]string{SetLabel: p.flagSet}
+}
+
+// selector selects the objects of the flag set
+func (p *Persistence) selector() string {
+	if p.flagSet == "" {
+		return "!" + SetLabel
+	}
+	return SetLabel + "=" + p.flagSet
+}
+
+func (p *Persistence) newFeatureFlag(key string) *FeatureFlag {
+	return &FeatureFlag{
+		TypeMeta:   metav1.TypeMeta{APIVersion: Group + "/" + Version, Kind: Kind},
+		ObjectMeta: metav1.ObjectMeta{Name: p.objectName(key), Labels: p.labels()},
+		Spec:       FeatureFlagSpec{Key: key},
+	}
+}
+
+func (f *FeatureFlag) keyValue() persistence.KeyValue {
+	// objects created by other means, e.g. by a GitOps tool, have been written once
+	revision := max(f.Status.Revision, 1)
+	return persistence.KeyValue{
+		Key:           f.Spec.Key,
+		Value:         f.Spec.Value,
+		Type:          f.Spec.Type,
+		Constraints:   f.Spec.Constraints,
+		Metadata:      f.Spec.Metadata,
+		Rules:         f.Spec.Rules,
+		Rollout:       f.Spec.Rollout,
+		Prerequisites: f.Spec.Prerequisites,
+		OffValue:      f.Spec.OffValue,
+		Revision:      revision,
+	}
+}
+
+func (f *FeatureFlag) setKeyValue(kv persistence.KeyValue) {
+	f.Spec = FeatureFlagSpec{
+		Key:           kv.Key,
+		Value:         kv.Value,
+		Type:          kv.Type,
+		Constraints:   kv.Constraints,
+		Metadata:      kv.Metadata,
+		Rules:         kv.Rules,
+		Rollout:       kv.Rollout,
+		Prerequisites: kv.Prerequisites,
+		OffValue:      kv.OffValue,
+	}
+}
+
+func toObject(flag *FeatureFlag) (*unstructured.Unstructured, error) {
+	raw, err := json.Marshal(flag)
+	if err != nil {
+		return nil, err
+	}
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(raw); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func fromObject(obj *unstructured.Unstructured) (*FeatureFlag, error) {
+	raw, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	flag := &FeatureFlag{}
+	if err := json.Unmarshal(raw, flag); err != nil {
+		return nil, err
+	}
+	return flag, nil
+}
+
+func (p *Persistence) GetAll(ctx context.Context) ([]persistence.KeyValue, error) {
+	ctx, span := otel.Tracer("service/persistence/crd").Start(ctx, "GetAll")
+	defer span.End()
+
+	flags, err := p.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var keyValues []persistence.KeyValue
+	for _, flag := range flags {
+		keyValues = append(keyValues, flag.keyValue())
+	}
+	return keyValues, nil
+}
+
+// Writes load the object, change it and update it with the loaded resourceVersion. If someone
+// else updated the object in between, the update conflicts and the write starts over.
+
+func (p *Persistence) PreSet(ctx context.Context, kv persistence.KeyValue) error {
+	ctx, span := otel.Tracer("service/persistence/crd").Start(ctx, "PreSet")
+	defer span.End()
+
+	flag, err := p.load(ctx, kv.Key)
+	if err != nil || flag != nil {
+		// do not change if there is already a value
+		return err
+	}
+
+	flag = p.newFeatureFlag(kv.Key)
+	flag.setKeyValue(kv)
+	flag.Status.Revision = 1
+	_, err = p.save(ctx, flag, true)
+	if errors.IsConflict(err) {
+		// created by someone else in the meantime
+		return nil
+	}
+	return err
+}
+
+func (p *Persistence) Set(ctx context.Context, kv persistence.KeyValue) error {
+	ctx, span := otel.Tracer("service/persistence/crd").Start(ctx, "Set")
+	defer span.End()
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		flag, err := p.load(ctx, kv.Key)
+		if err != nil {
+			return err
+		}
+		_, err = p.set(ctx, flag, kv)
+		return err
+	})
+}
+
+// set writes kv over the loaded flag, nil if it does not exist, and returns the written flag
+func (p *Persistence) set(ctx context.Context, flag *FeatureFlag, kv persistence.KeyValue) (*FeatureFlag, error) {
+	// the revision is checked against the freshly loaded object on every attempt
+	var current uint64
+	create := flag == nil
+	if create {
+		flag = p.newFeatureFlag(kv.Key)
+	} else {
+		// the caller's flag stays as loaded, the spec is replaced as a whole
+		copied := *flag
+		flag = &copied
+		current = max(flag.Status.Revision, 1)
+	}
+	revision, err := persistence.NextRevision(current, kv.Revision)
+	if err != nil {
+		return nil, err
+	}
+
+	flag.setKeyValue(kv)
+	flag.Status.Revision = revision
+	return p.save(ctx, flag, create)
+}
+
+func (p *Persistence) Get(ctx context.Context, key string) (persistence.KeyValue, error) {
+	ctx, span := otel.Tracer("service/persistence/crd").Start(ctx, "Get")
+	defer span.End()
+
+	flag, err := p.load(ctx, key)
+	if err != nil {
+		return persistence.KeyValue{}, err
+	}
+	if flag == nil {
+		return persistence.KeyValue{}, persistence.ErrKeyNotFound
+	}
+	return flag.keyValue(), nil
+}
+
+func (p *Persistence) Delete(ctx context.Context, key string) error {
+	ctx, span := otel.Tracer("service/persistence/crd").Start(ctx, "Delete")
+	defer span.End()
+
+	return p.remove(ctx, key)
+}
+
+func (p *Persistence) remove(ctx context.Context, key string) error {
+	flag, err := p.load(ctx, key)
+	if err != nil || flag == nil {
+		return err
+	}
+	// the object may have been replaced since it was loaded
+	err = p.flags.Delete(ctx, flag.Name, metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &flag.UID}})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (p *Persistence) Count(ctx context.Context) (int, error) {
+	ctx, span := otel.Tracer("service/persistence/crd").Start(ctx, "Count")
+	defer span.End()
+
+	flags, err := p.list(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return len(flags), nil
+}
+
+func (p *Persistence) AppendHistory(ctx context.Context, entry persistence.HistoryEntry) error {
+	ctx, span := otel.Tracer("service/persistence/crd").Start(ctx, "AppendHistory")
+	defer span.End()
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := p.history.Get(ctx, p.historyName(), metav1.GetOptions{})
+		create := errors.IsNotFound(err)
+		if create {
+			obj = &unstructured.Unstructured{}
+			obj.SetAPIVersion("v1")
+			obj.SetKind("ConfigMap")
+			obj.SetName(p.historyName())
+			obj.SetLabels(p.labels())
+		} else if err != nil {
+			return err
+		}
+
+		data, _, _ := unstructured.NestedStringMap(obj.Object, "data")
+		if data == nil {
+			data = make(map[string]string)
+		}
+		entries := persistence.AppendEntry(loadHistory(ctx, data, entry.Key), entry)
+		raw, err := json.Marshal(entries)
+		if err != nil {
+			return err
+		}
+		data[historyKey(entry.Key)] = string(raw)
+		if err := unstructured.SetNestedStringMap(obj.Object, data, "data"); err != nil {
+			return err
+		}
+
+		if create {
+			_, err = p.history.Create(ctx, obj, metav1.CreateOptions{})
+			if errors.IsAlreadyExists(err) {
+				return errors.NewConflict(configMaps.GroupResource(), obj.GetName(), err)
+			}
+			return err
+		}
+		_, err = p.history.Update(ctx, obj, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func (p *Persistence) History(ctx context.Context, key string) ([]persistence.HistoryEntry, error) {
+	ctx, span := otel.Tracer("service/persistence/crd").Start(ctx, "History")
+	defer span.End()
+
+	obj, err := p.history.Get(ctx, p.historyName(), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, _, _ := unstructured.NestedStringMap(obj.Object, "data")
+	return loadHistory(ctx, data, key), nil
+}
+
+// historyName is the name of the history ConfigMap of the flag set
+func (p *Persistence) historyName() string {
+	if p.flagSet == "" {
+		return HistoryConfigMap
+	}
+	return HistoryConfigMap + "-" + p.flagSet
+}
+
+// historyKey is the key of the history of a flag in the data of the ConfigMap. Flag keys need
+// not be valid ConfigMap keys, the object names are.
+func historyKey(key string) string {
+	return objectName(key)
+}
+
+// loadHistory decodes the history of a key, a malformed history is ignored
+func loadHistory(ctx context.Context, data map[string]string, key string) []persistence.HistoryEntry {
+	raw := data[historyKey(key)]
+	if raw == "" {
+		return nil
+	}
+	var entries []persistence.HistoryEntry
+	if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+		slog.WarnContext(ctx, "Ignoring malformed history", "key", key, "error", err)
+		return nil
+	}
+	return entries
+}
+
+// Batch writes the objects one by one, as Kubernetes cannot change several objects together. All
+// revisions are checked before the first write, and if a write fails the objects already written
+// are restored, so a failed batch leaves no changes behind. Watchers may see a partial batch.
+func (p *Persistence) Batch(ctx context.Context, operations []persistence.Operation) error {
+	ctx, span := otel.Tracer("service/persistence/crd").Start(ctx, "Batch")
+	defer span.End()
+	span.SetAttributes(attribute.Int("operations", len(operations)))
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		// the state of every key before the batch, nil if it did not exist
+		before := make(map[string]*FeatureFlag)
+		current := make(map[string]*FeatureFlag)
+		for _, op := range operations {
+			key := op.KeyValue.Key
+			if _, loaded := before[key]; loaded {
+				continue
+			}
+			flag, err := p.load(ctx, key)
+			if err != nil {
+				return err
+			}
+			before[key] = flag
+			current[key] = flag
+		}
+
+		// check the revisions against the state each operation will see
+		revisions := make(map[string]uint64)
+		for key, flag := range current {
+			if flag != nil {
+				revisions[key] = max(flag.Status.Revision, 1)
+			}
+		}
+		for _, op := range operations {
+			key := op.KeyValue.Key
+			if op.Delete {
+				delete(revisions, key)
+				continue
+			}
+			revision, err := persistence.NextRevision(revisions[key], op.KeyValue.Revision)
+			if err != nil {
+				return err
+			}
+			revisions[key] = revision
+		}
+
+		var written []string
+		for _, op := range operations {
+			key := op.KeyValue.Key
+			var err error
+			if op.Delete {
+				err = p.remove(ctx, key)
+				current[key] = nil
+			} else {
+				current[key], err = p.set(ctx, current[key], op.KeyValue)
+			}
+			if err != nil {
+				p.restore(ctx, written, before)
+				return err
+			}
+			written = append(written, key)
+		}
+		return nil
+	})
+}
+
+// restore writes the keys back to their state before a failed batch, failures are only logged
+func (p *Persistence) restore(ctx context.Context, keys []string, before map[string]*FeatureFlag) {
+	for _, key := range keys {
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			flag, err := p.load(ctx, key)
+			if err != nil {
+				return err
+			}
+			previous := before[key]
+			switch {
+			case previous == nil:
+				return p.remove(ctx, key)
+			case flag == nil:
+				restored := p.newFeatureFlag(key)
+				restored.Spec = previous.Spec
+				restored.Status = previous.Status
+				_, err = p.save(ctx, restored, true)
+				return err
+			default:
+				flag.Spec = previous.Spec
+				flag.Status = previous.Status
+				_, err = p.save(ctx, flag, false)
+				return err
+			}
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to restore flag after failed batch", "key", key, "error", err)
+		}
+	}
+}
+
+func (p *Persistence) list(ctx context.Context) ([]*FeatureFlag, error) {
+	list, err := p.flags.List(ctx, metav1.ListOptions{LabelSelector: p.selector()})
+	if err != nil {
+		return nil, err
+	}
+	var flags []*FeatureFlag
+	for i := range list.Items {
+		flag, err := fromObject(&list.Items[i])
+		if err != nil || flag.Spec.Key == "" {
+			slog.WarnContext(ctx, "Ignoring malformed FeatureFlag", "name", list.Items[i].GetName(), "error", err)
+			continue
+		}
+		flags = append(flags, flag)
+	}
+	return flags, nil
+}
+
+// load returns the flag of a key, nil if it does not exist. An object of the name holding another
+// key or belonging to another flag set does not count.
+func (p *Persistence) load(ctx context.Context, key string) (*FeatureFlag, error) {
+	obj, err := p.flags.Get(ctx, p.objectName(key), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	flag, err := fromObject(obj)
+	if err != nil {
+		return nil, err
+	}
+	if flag.Spec.Key != key || flag.Labels[SetLabel] != p.flagSet {
+		slog.WarnContext(ctx, "Ignoring FeatureFlag of another key", "name", flag.Name, "key", key, "set", p.flagSet, "objectKey", flag.Spec.Key, "objectSet", flag.Labels[SetLabel])
+		return nil, nil
+	}
+	return flag, nil
+}
+
+// save creates or updates the flag and returns the stored flag. A flag created by someone else
+// in the meantime is reported as a conflict, so that the write starts over.
+func (p *Persistence) save(ctx context.Context, flag *FeatureFlag, create bool) (*FeatureFlag, error) {
+	ctx, span := otel.Tracer("service/persistence/crd").Start(ctx, "save")
+	defer span.End()
+
+	obj, err := toObject(flag)
+	if err != nil {
+		return nil, err
+	}
+	if create {
+		obj, err = p.flags.Create(ctx, obj, metav1.CreateOptions{})
+		if errors.IsAlreadyExists(err) {
+			return nil, errors.NewConflict(Resource.GroupResource(), flag.Name, err)
+		}
+	} else {
+		obj, err = p.flags.Update(ctx, obj, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fromObject(obj)
+}
+
+// NewClient creates a dynamic client with the in-cluster configuration and returns it with the
+// namespace of the service
+func NewClient(ctx context.Context) (dynamic.Interface, string, error) {
+	ctx, span := otel.Tracer("service/persistence/crd").Start(ctx, "NewClient")
+	defer span.End()
+
+	rc, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	namespace, err := ownNamespace(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Instrument the transport with otelhttp and set peer.service to "kubernetes"
+	rc.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		return otelhttp.NewTransport(rt,
+			otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
+				return r.Method + " " + r.URL.String()
+			}),
+			otelhttp.WithSpanOptions(
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("peer.service", "kubernetes"),
+					attribute.String("namespace", *namespace),
+				),
+			),
+		)
+	}
+
+	client, err := dynamic.NewForConfig(rc)
+	if err != nil {
+		return nil, "", err
+	}
+	return client, *namespace, nil
+}
+
+func ownNamespace(ctx context.Context) (namespace *string, err error) {
+	ctx, span := otel.Tracer("service/persistence/crd").Start(ctx, "ownNamespace")
+	defer span.End()
+
+	data, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+	if err != nil {
+		return nil, err
+	}
+	ns := string(data)
+
+	span.SetAttributes(attribute.String("namespace", ns))
+	return &ns, nil
+}
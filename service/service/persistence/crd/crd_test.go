@@ -0,0 +1,301 @@
+package crd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/dkrizic/feature/service/service/persistence/persistencetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// setupFakeClient returns a fake dynamic client for NewCRDPersistence, seeded with the given objects
+func setupFakeClient(t *testing.T, objects ...runtime.Object) *fake.FakeDynamicClient {
+	t.Helper()
+	return fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{Resource: Kind + "List"}, objects...)
+}
+
+func object(t *testing.T, flag *FeatureFlag) *unstructured.Unstructured {
+	t.Helper()
+	flag.Namespace = "test-namespace"
+	obj, err := toObject(flag)
+	require.NoError(t, err)
+	return obj
+}
+
+func TestObjectName(t *testing.T) {
+	assert.Equal(t, "checkout", objectName("checkout"))
+	assert.Equal(t, "new.checkout-flow", objectName("new.checkout-flow"))
+
+	// keys that are no valid names are cleaned up and get a hash
+	name := objectName("MY_FLAG")
+	assert.Regexp(t, `^my-flag-[0-9a-f]{8}$`, name)
+	assert.NotEqual(t, name, objectName("my_flag"))
+	assert.Regexp(t, `^flag-[0-9a-f]{8}$`, objectName("__"))
+	for _, key := range []string{"MY_FLAG", "__", "Ä", string(make([]byte, 300))} {
+		assert.Empty(t, validation.IsDNS1123Subdomain(objectName(key)), key)
+	}
+}
+
+func TestCRDPersistence(t *testing.T) {
+	client := setupFakeClient(t)
+	p := NewCRDPersistence(client, "test-namespace", "")
+	ctx := context.Background()
+
+	_, err := p.Get(ctx, "COLOR")
+	assert.ErrorIs(t, err, persistence.ErrKeyNotFound)
+
+	assert.NoError(t, p.PreSet(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	// PreSet does not change an existing flag
+	assert.NoError(t, p.PreSet(ctx, persistence.KeyValue{Key: "COLOR", Value: "green"}))
+	max := 5.0
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{
+		Key:         "RETRIES",
+		Value:       "3",
+		Type:        persistence.TypeInteger,
+		Constraints: &persistence.Constraints{Max: &max},
+		Metadata:    persistence.Metadata{Owner: "team-checkout", Tags: []string{"checkout"}},
+		Rules: []persistence.Rule{{Name: "beta", Value: "5", Conditions: []persistence.Condition{
+			{Attribute: "tenant", Operator: persistence.OperatorIn, Values: []string{"acme"}},
+		}}},
+	}))
+
+	kv, err := p.Get(ctx, "COLOR")
+	assert.NoError(t, err)
+	assert.Equal(t, persistence.KeyValue{Key: "COLOR", Value: "red", Revision: 1}, kv)
+
+	kv, err = p.Get(ctx, "RETRIES")
+	assert.NoError(t, err)
+	assert.Equal(t, persistence.TypeInteger, kv.Type)
+	assert.Equal(t, &max, kv.Constraints.Max)
+	assert.Equal(t, "team-checkout", kv.Metadata.Owner)
+	assert.Equal(t, []string{"acme"}, kv.Rules[0].Conditions[0].Values)
+
+	// one object per flag with the key and value in the spec
+	obj, err := client.Resource(Resource).Namespace("test-namespace").Get(ctx, objectName("RETRIES"), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, Kind, obj.GetKind())
+	value, _, _ := unstructured.NestedString(obj.Object, "spec", "value")
+	assert.Equal(t, "3", value)
+
+	all, err := p.GetAll(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, all, 2)
+	count, err := p.Count(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.NoError(t, p.Delete(ctx, "COLOR"))
+	// deleting a missing flag is no error
+	assert.NoError(t, p.Delete(ctx, "COLOR"))
+	count, _ = p.Count(ctx)
+	assert.Equal(t, 1, count)
+}
+
+func TestCRDPersistence_CreatedByOthers(t *testing.T) {
+	// a flag as a GitOps tool would create it, without status
+	client := setupFakeClient(t, object(t, &FeatureFlag{
+		TypeMeta:   metav1.TypeMeta{APIVersion: Group + "/" + Version, Kind: Kind},
+		ObjectMeta: metav1.ObjectMeta{Name: "booking"},
+		Spec:       FeatureFlagSpec{Key: "BOOKING", Value: "true", Type: persistence.TypeBoolean},
+	}))
+	p := NewCRDPersistence(client, "test-namespace", "")
+	ctx := context.Background()
+
+	all, err := p.GetAll(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []persistence.KeyValue{{Key: "BOOKING", Value: "true", Type: persistence.TypeBoolean, Revision: 1}}, all)
+}
+
+func TestCRDPersistence_FlagSet(t *testing.T) {
+	client := setupFakeClient(t)
+	defaultSet, shop := NewCRDPersistence(client, "test-namespace", ""), NewCRDPersistence(client, "test-namespace", "shop")
+	ctx := context.Background()
+
+	assert.NoError(t, defaultSet.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	assert.NoError(t, shop.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "blue"}))
+
+	kv, _ := defaultSet.Get(ctx, "COLOR")
+	assert.Equal(t, "red", kv.Value)
+	all, err := shop.GetAll(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []persistence.KeyValue{{Key: "COLOR", Value: "blue", Revision: 1}}, all)
+
+	// the objects of a set are labeled and named after it
+	obj, err := client.Resource(Resource).Namespace("test-namespace").Get(ctx, "shop."+hashedName("COLOR"), metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "shop", obj.GetLabels()[SetLabel])
+
+	assert.NoError(t, shop.Delete(ctx, "COLOR"))
+	count, _ := defaultSet.Count(ctx)
+	assert.Equal(t, 1, count)
+}
+
+func TestCRDPersistence_ForeignObject(t *testing.T) {
+	client := setupFakeClient(t)
+	defaultSet, shop := NewCRDPersistence(client, "test-namespace", ""), NewCRDPersistence(client, "test-namespace", "shop")
+	ctx := context.Background()
+
+	// a key of the default set named like the object of a key of the set shop
+	foreign := shop.objectName("flag")
+	require.NoError(t, defaultSet.Set(ctx, persistence.KeyValue{Key: foreign, Value: "default"}))
+
+	_, err := shop.Get(ctx, "flag")
+	assert.ErrorIs(t, err, persistence.ErrKeyNotFound)
+	assert.Error(t, shop.Set(ctx, persistence.KeyValue{Key: "flag", Value: "shop"}), "the object is not overwritten")
+	assert.NoError(t, shop.Delete(ctx, "flag"))
+	kv, err := defaultSet.Get(ctx, foreign)
+	assert.NoError(t, err)
+	assert.Equal(t, "default", kv.Value)
+}
+
+func TestCRDPersistence_Revision(t *testing.T) {
+	client := setupFakeClient(t)
+	p := NewCRDPersistence(client, "test-namespace", "")
+	ctx := context.Background()
+
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "blue", Revision: 1}))
+	err := p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "green", Revision: 1})
+	assert.ErrorIs(t, err, persistence.ErrRevisionMismatch)
+
+	kv, _ := p.Get(ctx, "COLOR")
+	assert.Equal(t, "blue", kv.Value)
+	assert.Equal(t, uint64(2), kv.Revision)
+
+	// a conditional write of a missing flag fails
+	err = p.Set(ctx, persistence.KeyValue{Key: "THEME", Value: "dark", Revision: 1})
+	assert.ErrorIs(t, err, persistence.ErrRevisionMismatch)
+}
+
+func TestCRDPersistence_RetryOnConflict(t *testing.T) {
+	client := setupFakeClient(t)
+	p := NewCRDPersistence(client, "test-namespace", "")
+	ctx := context.Background()
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+
+	conflicts := 2
+	client.PrependReactor("update", "featureflags", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if conflicts == 0 {
+			return false, nil, nil
+		}
+		conflicts--
+		return true, nil, errors.NewConflict(Resource.GroupResource(), objectName("COLOR"), fmt.Errorf("the object has been modified"))
+	})
+
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "blue"}))
+	assert.Equal(t, 0, conflicts)
+	kv, _ := p.Get(ctx, "COLOR")
+	assert.Equal(t, "blue", kv.Value)
+}
+
+func TestCRDPersistence_History(t *testing.T) {
+	client := setupFakeClient(t)
+	p := NewCRDPersistence(client, "test-namespace", "")
+	ctx := context.Background()
+
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	assert.NoError(t, p.AppendHistory(ctx, persistence.HistoryEntry{Key: "COLOR", Action: persistence.ActionCreate, NewValue: "red"}))
+	assert.NoError(t, p.AppendHistory(ctx, persistence.HistoryEntry{Key: "COLOR", Action: persistence.ActionUpdate, OldValue: "red", NewValue: "blue"}))
+
+	entries, err := p.History(ctx, "COLOR")
+	assert.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, uint64(2), entries[1].Revision)
+	assert.Equal(t, "blue", entries[1].NewValue)
+
+	// the history is kept in a ConfigMap and does not count as a write of the flag
+	kv, _ := p.Get(ctx, "COLOR")
+	assert.Equal(t, uint64(1), kv.Revision)
+	_, err = client.Resource(configMaps).Namespace("test-namespace").Get(ctx, HistoryConfigMap, metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	// it outlives the flag
+	assert.NoError(t, p.Delete(ctx, "COLOR"))
+	assert.NoError(t, p.AppendHistory(ctx, persistence.HistoryEntry{Key: "COLOR", Action: persistence.ActionDelete, OldValue: "blue"}))
+	entries, err = p.History(ctx, "COLOR")
+	assert.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, persistence.ActionDelete, entries[2].Action)
+
+	// every flag set has a history of its own
+	shop := NewCRDPersistence(client, "test-namespace", "shop")
+	entries, err = shop.History(ctx, "COLOR")
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestCRDPersistence_Batch(t *testing.T) {
+	client := setupFakeClient(t)
+	p := NewCRDPersistence(client, "test-namespace", "")
+	ctx := context.Background()
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "SIZE", Value: "large"}))
+
+	assert.NoError(t, p.Batch(ctx, []persistence.Operation{
+		{KeyValue: persistence.KeyValue{Key: "COLOR", Value: "blue", Revision: 1}},
+		{KeyValue: persistence.KeyValue{Key: "THEME", Value: "dark"}},
+		{KeyValue: persistence.KeyValue{Key: "SIZE"}, Delete: true},
+	}))
+	all, _ := p.GetAll(ctx)
+	assert.ElementsMatch(t, []persistence.KeyValue{
+		{Key: "COLOR", Value: "blue", Revision: 2},
+		{Key: "THEME", Value: "dark", Revision: 1},
+	}, all)
+
+	// a stale revision is detected before anything is written
+	err := p.Batch(ctx, []persistence.Operation{
+		{KeyValue: persistence.KeyValue{Key: "THEME", Value: "light"}},
+		{KeyValue: persistence.KeyValue{Key: "COLOR", Value: "green", Revision: 1}},
+	})
+	assert.ErrorIs(t, err, persistence.ErrRevisionMismatch)
+	kv, _ := p.Get(ctx, "THEME")
+	assert.Equal(t, "dark", kv.Value)
+
+	// a failing write restores what the batch has written so far
+	client.PrependReactor("create", "featureflags", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		obj := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		if obj.GetName() != objectName("SIZE") {
+			return false, nil, nil
+		}
+		return true, nil, errors.NewForbidden(Resource.GroupResource(), obj.GetName(), fmt.Errorf("quota exceeded"))
+	})
+	err = p.Batch(ctx, []persistence.Operation{
+		{KeyValue: persistence.KeyValue{Key: "COLOR", Value: "green"}},
+		{KeyValue: persistence.KeyValue{Key: "THEME"}, Delete: true},
+		{KeyValue: persistence.KeyValue{Key: "SIZE", Value: "small"}},
+	})
+	assert.True(t, errors.IsForbidden(err))
+	kv, _ = p.Get(ctx, "COLOR")
+	assert.Equal(t, persistence.KeyValue{Key: "COLOR", Value: "blue", Revision: 2}, kv)
+	kv, err = p.Get(ctx, "THEME")
+	assert.NoError(t, err)
+	assert.Equal(t, "dark", kv.Value)
+}
+
+func TestConformance(t *testing.T) {
+	persistencetest.Run(t, func(t *testing.T) persistence.Persistence {
+		return NewCRDPersistence(setupFakeClient(t), "test-namespace", "")
+	}, persistencetest.Limitations{
+		// the fake dynamic client does not check the resourceVersion, the API server does
+		UndetectedConflicts: true,
+	})
+}
+
+func TestConformance_Partitions(t *testing.T) {
+	persistencetest.RunPartitions(t, "shop", func(t *testing.T) (persistence.Persistence, persistence.Persistence) {
+		client := setupFakeClient(t)
+		return NewCRDPersistence(client, "test-namespace", ""), NewCRDPersistence(client, "test-namespace", "shop")
+	})
+}
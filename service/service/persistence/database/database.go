@@ -0,0 +1,409 @@
+package database
+
+// implements the persistence interface on top of a SQL database, SQLite or PostgreSQL
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/dkrizic/feature/service/service/persistence"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// attempts limits how often a write is retried after a concurrent write of another instance
+const attempts = 5
+
+// errConflict reports that another instance changed a row between reading and writing it
+var errConflict = errors.New("concurrent write")
+
+type Persistence struct {
+	db      *sql.DB
+	dialect dialect
+	// flagSet is the partition of the tables holding the flags, '' for the default set
+	flagSet string
+}
+
+// NewDatabasePersistence connects to the database and brings its schema up to date. The dsn is
+// passed to the driver, e.g. a file name for SQLite or a connection URL for PostgreSQL.
+func NewDatabasePersistence(ctx context.Context, dialectName, dsn string) (*Persistence, error) {
+	d, err := dialectByName(dialectName)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(d.driver, d.withParams(dsn))
+	if err != nil {
+		return nil, err
+	}
+	if d.connections > 0 {
+		db.SetMaxOpenConns(d.connections)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := migrate(ctx, db, d); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Persistence{db: db, dialect: d}, nil
+}
+
+// Partition returns the persistence of a flag set, sharing the connections with p
+func (p *Persistence) Partition(flagSet string) *Persistence {
+	return &Persistence{db: p.db, dialect: p.dialect, flagSet: flagSet}
+}
+
+// Close closes the connections to the database, also those of the partitions
+func (p *Persistence) Close() error {
+	return p.db.Close()
+}
+
+const selectFlags = `SELECT f.key, f.value, f.type, f.constraints, f.rules, f.rollout, f.prerequisites, f.off_value, f.revision,
+    m.description, m.owner, m.tags, m.created_at, m.updated_at, m.last_modified_by, m.expires_at, m.lifecycle
+FROM flags f LEFT JOIN flag_metadata m ON m.flag_set = f.flag_set AND m.key = f.key
+WHERE f.flag_set = ?`
+
+func (p *Persistence) GetAll(ctx context.Context) ([]persistence.KeyValue, error) {
+	ctx, span := otel.Tracer("service/persistence/database").Start(ctx, "GetAll")
+	defer span.End()
+
+	rows, err := p.db.QueryContext(ctx, p.dialect.rebind(selectFlags), p.flagSet)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []persistence.KeyValue
+	for rows.Next() {
+		kv, err := scanFlag(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, kv)
+	}
+	return result, rows.Err()
+}
+
+// set the value only if it does not exist
+func (p *Persistence) PreSet(ctx context.Context, kv persistence.KeyValue) error {
+	ctx, span := otel.Tracer("service/persistence/database").Start(ctx, "PreSet")
+	defer span.End()
+
+	return p.transaction(ctx, func(tx *sql.Tx) error {
+		revision, err := p.revision(ctx, tx, kv.Key)
+		if err != nil {
+			return err
+		}
+		if revision != 0 {
+			slog.InfoContext(ctx, "Key already exists, not presetting", "key", kv.Key, "value", kv.Value)
+			return nil
+		}
+		slog.DebugContext(ctx, "PreSetting", "key", kv.Key, "value", kv.Value)
+		kv.Revision = 1
+		return p.write(ctx, tx, kv, 0)
+	})
+}
+
+func (p *Persistence) Set(ctx context.Context, kv persistence.KeyValue) error {
+	ctx, span := otel.Tracer("service/persistence/database").Start(ctx, "Set")
+	defer span.End()
+
+	return p.transaction(ctx, func(tx *sql.Tx) error {
+		return p.set(ctx, tx, kv)
+	})
+}
+
+// set checks the revision of kv against the stored one and writes it
+func (p *Persistence) set(ctx context.Context, tx *sql.Tx, kv persistence.KeyValue) error {
+	current, err := p.revision(ctx, tx, kv.Key)
+	if err != nil {
+		return err
+	}
+	revision, err := persistence.NextRevision(current, kv.Revision)
+	if err != nil {
+		return err
+	}
+	kv.Revision = revision
+	return p.write(ctx, tx, kv, current)
+}
+
+// Batch applies all operations in one transaction
+func (p *Persistence) Batch(ctx context.Context, operations []persistence.Operation) error {
+	ctx, span := otel.Tracer("service/persistence/database").Start(ctx, "Batch")
+	defer span.End()
+	span.SetAttributes(attribute.Int("operations", len(operations)))
+
+	return p.transaction(ctx, func(tx *sql.Tx) error {
+		for _, op := range operations {
+			var err error
+			if op.Delete {
+				err = p.remove(ctx, tx, op.KeyValue.Key)
+			} else {
+				err = p.set(ctx, tx, op.KeyValue)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (p *Persistence) Get(ctx context.Context, key string) (persistence.KeyValue, error) {
+	ctx, span := otel.Tracer("service/persistence/database").Start(ctx, "Get")
+	defer span.End()
+
+	kv, err := scanFlag(p.db.QueryRowContext(ctx, p.dialect.rebind(selectFlags+" AND f.key = ?"), p.flagSet, key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return persistence.KeyValue{}, persistence.ErrKeyNotFound
+	}
+	return kv, err
+}
+
+func (p *Persistence) Delete(ctx context.Context, key string) error {
+	ctx, span := otel.Tracer("service/persistence/database").Start(ctx, "Delete")
+	defer span.End()
+
+	return p.transaction(ctx, func(tx *sql.Tx) error {
+		return p.remove(ctx, tx, key)
+	})
+}
+
+func (p *Persistence) remove(ctx context.Context, tx *sql.Tx, key string) error {
+	if _, err := tx.ExecContext(ctx, p.dialect.rebind("DELETE FROM flag_metadata WHERE flag_set = ? AND key = ?"), p.flagSet, key); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, p.dialect.rebind("DELETE FROM flags WHERE flag_set = ? AND key = ?"), p.flagSet, key)
+	return err
+}
+
+func (p *Persistence) Count(ctx context.Context) (int, error) {
+	ctx, span := otel.Tracer("service/persistence/database").Start(ctx, "Count")
+	defer span.End()
+
+	var count int
+	err := p.db.QueryRowContext(ctx, p.dialect.rebind("SELECT COUNT(*) FROM flags WHERE flag_set = ?"), p.flagSet).Scan(&count)
+	return count, err
+}
+
+func (p *Persistence) AppendHistory(ctx context.Context, entry persistence.HistoryEntry) error {
+	ctx, span := otel.Tracer("service/persistence/database").Start(ctx, "AppendHistory")
+	defer span.End()
+
+	return p.transaction(ctx, func(tx *sql.Tx) error {
+		var last uint64
+		err := tx.QueryRowContext(ctx, p.dialect.rebind("SELECT COALESCE(MAX(revision), 0) FROM history WHERE flag_set = ? AND key = ?"), p.flagSet, entry.Key).Scan(&last)
+		if err != nil {
+			return err
+		}
+		entry.Revision = last + 1
+		// the primary key rejects the entry if another instance appended the same revision
+		result, err := tx.ExecContext(ctx, p.dialect.rebind(`INSERT INTO history (flag_set, key, revision, action, old_value, new_value, actor, timestamp, source)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT (flag_set, key, revision) DO NOTHING`),
+			p.flagSet, entry.Key, entry.Revision, string(entry.Action), entry.OldValue, entry.NewValue, entry.Actor, entry.Timestamp.UTC(), entry.Source)
+		if err := conflict(result, err); err != nil {
+			return err
+		}
+		if entry.Revision > persistence.HistoryLimit {
+			_, err = tx.ExecContext(ctx, p.dialect.rebind("DELETE FROM history WHERE flag_set = ? AND key = ? AND revision <= ?"),
+				p.flagSet, entry.Key, entry.Revision-persistence.HistoryLimit)
+		}
+		return err
+	})
+}
+
+func (p *Persistence) History(ctx context.Context, key string) ([]persistence.HistoryEntry, error) {
+	ctx, span := otel.Tracer("service/persistence/database").Start(ctx, "History")
+	defer span.End()
+
+	rows, err := p.db.QueryContext(ctx, p.dialect.rebind(`SELECT key, revision, action, old_value, new_value, actor, timestamp, source
+FROM history WHERE flag_set = ? AND key = ? ORDER BY revision`), p.flagSet, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []persistence.HistoryEntry
+	for rows.Next() {
+		var entry persistence.HistoryEntry
+		var action string
+		if err := rows.Scan(&entry.Key, &entry.Revision, &action, &entry.OldValue, &entry.NewValue, &entry.Actor, &entry.Timestamp, &entry.Source); err != nil {
+			return nil, err
+		}
+		entry.Action = persistence.Action(action)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// transaction runs fn in a transaction and commits it if fn succeeds. Writes are checked against
+// the revision read before, so a write of another instance in between is detected, and fn is
+// run again on the new state.
+func (p *Persistence) transaction(ctx context.Context, fn func(*sql.Tx) error) error {
+	var err error
+	for range attempts {
+		err = p.attempt(ctx, fn)
+		if !errors.Is(err, errConflict) {
+			return err
+		}
+		slog.DebugContext(ctx, "Concurrent write, retrying")
+	}
+	return err
+}
+
+func (p *Persistence) attempt(ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// revision returns the stored revision of a key, 0 if it does not exist
+func (p *Persistence) revision(ctx context.Context, tx *sql.Tx, key string) (uint64, error) {
+	var revision uint64
+	err := tx.QueryRowContext(ctx, p.dialect.rebind("SELECT revision FROM flags WHERE flag_set = ? AND key = ?"), p.flagSet, key).Scan(&revision)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return revision, err
+}
+
+// write stores kv with its metadata if the stored revision is still current, 0 if the key did not exist
+func (p *Persistence) write(ctx context.Context, tx *sql.Tx, kv persistence.KeyValue, current uint64) error {
+	constraints, err := marshal(kv.Constraints, kv.Constraints == nil)
+	if err != nil {
+		return err
+	}
+	rules, err := marshal(kv.Rules, len(kv.Rules) == 0)
+	if err != nil {
+		return err
+	}
+	rollout, err := marshal(kv.Rollout, kv.Rollout == nil)
+	if err != nil {
+		return err
+	}
+	prerequisites, err := marshal(kv.Prerequisites, len(kv.Prerequisites) == 0)
+	if err != nil {
+		return err
+	}
+
+	var result sql.Result
+	if current == 0 {
+		result, err = tx.ExecContext(ctx, p.dialect.rebind(`INSERT INTO flags (flag_set, key, value, type, constraints, rules, rollout, prerequisites, off_value, revision)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT (flag_set, key) DO NOTHING`),
+			p.flagSet, kv.Key, kv.Value, string(kv.Type), constraints, rules, rollout, prerequisites, kv.OffValue, kv.Revision)
+	} else {
+		result, err = tx.ExecContext(ctx, p.dialect.rebind(`UPDATE flags SET value = ?, type = ?, constraints = ?, rules = ?, rollout = ?, prerequisites = ?, off_value = ?, revision = ?
+WHERE flag_set = ? AND key = ? AND revision = ?`),
+			kv.Value, string(kv.Type), constraints, rules, rollout, prerequisites, kv.OffValue, kv.Revision, p.flagSet, kv.Key, current)
+	}
+	if err := conflict(result, err); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, p.dialect.rebind("DELETE FROM flag_metadata WHERE flag_set = ? AND key = ?"), p.flagSet, kv.Key); err != nil {
+		return err
+	}
+	metadata := kv.Metadata
+	if metadata.IsZero() {
+		return nil
+	}
+	tags, err := marshal(metadata.Tags, len(metadata.Tags) == 0)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, p.dialect.rebind(`INSERT INTO flag_metadata (flag_set, key, description, owner, tags, created_at, updated_at, last_modified_by, expires_at, lifecycle)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		p.flagSet, kv.Key, metadata.Description, metadata.Owner, tags, nullTime(metadata.CreatedAt), nullTime(metadata.UpdatedAt), metadata.LastModifiedBy,
+		nullTime(metadata.ExpiresAt), string(metadata.Lifecycle))
+	return err
+}
+
+// conflict turns a write that changed no row into errConflict
+func conflict(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errConflict
+	}
+	return nil
+}
+
+// scanner is implemented by sql.Row and sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanFlag(row scanner) (persistence.KeyValue, error) {
+	var kv persistence.KeyValue
+	var valueType string
+	var constraints, rules, rollout, prerequisites, description, owner, tags, lastModifiedBy, lifecycle sql.NullString
+	var createdAt, updatedAt, expiresAt sql.NullTime
+	err := row.Scan(&kv.Key, &kv.Value, &valueType, &constraints, &rules, &rollout, &prerequisites, &kv.OffValue, &kv.Revision,
+		&description, &owner, &tags, &createdAt, &updatedAt, &lastModifiedBy, &expiresAt, &lifecycle)
+	if err != nil {
+		return persistence.KeyValue{}, err
+	}
+	kv.Type = persistence.ValueType(valueType)
+	kv.Metadata = persistence.Metadata{
+		Description:    description.String,
+		Owner:          owner.String,
+		LastModifiedBy: lastModifiedBy.String,
+		Lifecycle:      persistence.Lifecycle(lifecycle.String),
+	}
+	if createdAt.Valid {
+		kv.Metadata.CreatedAt = createdAt.Time.UTC()
+	}
+	if updatedAt.Valid {
+		kv.Metadata.UpdatedAt = updatedAt.Time.UTC()
+	}
+	if expiresAt.Valid {
+		kv.Metadata.ExpiresAt = expiresAt.Time.UTC()
+	}
+	for _, column := range []struct {
+		value  sql.NullString
+		target any
+	}{{constraints, &kv.Constraints}, {rules, &kv.Rules}, {rollout, &kv.Rollout}, {prerequisites, &kv.Prerequisites}, {tags, &kv.Metadata.Tags}} {
+		if !column.value.Valid {
+			continue
+		}
+		if err := json.Unmarshal([]byte(column.value.String), column.target); err != nil {
+			return persistence.KeyValue{}, err
+		}
+	}
+	return kv, nil
+}
+
+// marshal encodes v as JSON, or NULL if it is empty
+func marshal(v any, empty bool) (sql.NullString, error) {
+	if empty {
+		return sql.NullString{}, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(raw), Valid: true}, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	if t.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
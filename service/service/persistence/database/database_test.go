@@ -0,0 +1,265 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/dkrizic/feature/service/service/persistence/persistencetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPersistence(t *testing.T) *Persistence {
+	t.Helper()
+	p, err := NewDatabasePersistence(context.Background(), DialectSQLite, filepath.Join(t.TempDir(), "features.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func TestRebind(t *testing.T) {
+	query := "UPDATE flags SET value = ? WHERE key = ? AND revision = ?"
+	assert.Equal(t, query, dialects[DialectSQLite].rebind(query))
+	assert.Equal(t, "UPDATE flags SET value = $1 WHERE key = $2 AND revision = $3", dialects[DialectPostgres].rebind(query))
+}
+
+func TestWithParams(t *testing.T) {
+	sqlite := dialects[DialectSQLite]
+	assert.Equal(t, "features.db?_txlock=immediate", sqlite.withParams("features.db"))
+	assert.Equal(t, "features.db?_pragma=busy_timeout(5000)&_txlock=immediate", sqlite.withParams("features.db?_pragma=busy_timeout(5000)"))
+	assert.Equal(t, "features.db?_txlock=exclusive", sqlite.withParams("features.db?_txlock=exclusive"))
+	assert.Equal(t, "postgres://localhost/features", dialects[DialectPostgres].withParams("postgres://localhost/features"))
+}
+
+func TestMigrations(t *testing.T) {
+	for name, d := range dialects {
+		t.Run(name, func(t *testing.T) {
+			migrations, err := loadMigrations(d)
+			require.NoError(t, err)
+			require.NotEmpty(t, migrations)
+			for i, m := range migrations {
+				assert.Equal(t, i+1, m.version, "versions must be consecutive")
+				assert.NotEmpty(t, m.statements)
+			}
+		})
+	}
+	// every dialect has the same migrations
+	sqlite, _ := loadMigrations(dialects[DialectSQLite])
+	postgres, _ := loadMigrations(dialects[DialectPostgres])
+	require.Equal(t, len(sqlite), len(postgres))
+	for i := range sqlite {
+		assert.Equal(t, sqlite[i].name, postgres[i].name)
+	}
+}
+
+func TestNewDatabasePersistence(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "features.db")
+	p, err := NewDatabasePersistence(ctx, DialectSQLite, path)
+	require.NoError(t, err)
+	require.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	p.Close()
+
+	// migrating an up to date database changes nothing
+	p, err = NewDatabasePersistence(ctx, DialectSQLite, path)
+	require.NoError(t, err)
+	defer p.Close()
+	var applied int
+	require.NoError(t, p.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations").Scan(&applied))
+	migrations, _ := loadMigrations(dialects[DialectSQLite])
+	assert.Equal(t, len(migrations), applied)
+	kv, err := p.Get(ctx, "COLOR")
+	assert.NoError(t, err)
+	assert.Equal(t, "red", kv.Value)
+
+	_, err = NewDatabasePersistence(ctx, "oracle", "")
+	assert.ErrorContains(t, err, "unsupported database dialect")
+}
+
+func TestDatabasePersistence(t *testing.T) {
+	p := newTestPersistence(t)
+	ctx := context.Background()
+
+	_, err := p.Get(ctx, "COLOR")
+	assert.ErrorIs(t, err, persistence.ErrKeyNotFound)
+
+	assert.NoError(t, p.PreSet(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	assert.NoError(t, p.PreSet(ctx, persistence.KeyValue{Key: "COLOR", Value: "green"}))
+	max := 5.0
+	updated := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	retries := persistence.KeyValue{
+		Key:         "RETRIES",
+		Value:       "3",
+		Type:        persistence.TypeInteger,
+		Constraints: &persistence.Constraints{Max: &max},
+		Metadata: persistence.Metadata{Owner: "team-checkout", Tags: []string{"checkout"}, UpdatedAt: updated, LastModifiedBy: "admin",
+			ExpiresAt: updated.AddDate(0, 3, 0), Lifecycle: persistence.LifecycleTemporary},
+		Rules: []persistence.Rule{{Name: "beta", Value: "5", Conditions: []persistence.Condition{
+			{Attribute: "tenant", Operator: persistence.OperatorIn, Values: []string{"acme"}},
+		}}},
+		Rollout: &persistence.Rollout{Variants: []persistence.Variant{{Value: "4", Percentage: 10}}},
+	}
+	assert.NoError(t, p.Set(ctx, retries))
+
+	kv, err := p.Get(ctx, "COLOR")
+	assert.NoError(t, err)
+	assert.Equal(t, persistence.KeyValue{Key: "COLOR", Value: "red", Revision: 1}, kv)
+
+	kv, err = p.Get(ctx, "RETRIES")
+	assert.NoError(t, err)
+	retries.Revision = 1
+	assert.Equal(t, retries, kv)
+
+	// writing without metadata removes it
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "RETRIES", Value: "4"}))
+	kv, _ = p.Get(ctx, "RETRIES")
+	assert.Equal(t, persistence.KeyValue{Key: "RETRIES", Value: "4", Revision: 2}, kv)
+
+	all, err := p.GetAll(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, all, 2)
+	count, err := p.Count(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.NoError(t, p.Delete(ctx, "COLOR"))
+	assert.NoError(t, p.Delete(ctx, "COLOR"))
+	count, _ = p.Count(ctx)
+	assert.Equal(t, 1, count)
+}
+
+func TestDatabasePersistence_Revision(t *testing.T) {
+	p := newTestPersistence(t)
+	ctx := context.Background()
+
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "blue", Revision: 1}))
+	err := p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "green", Revision: 1})
+	assert.ErrorIs(t, err, persistence.ErrRevisionMismatch)
+	err = p.Set(ctx, persistence.KeyValue{Key: "THEME", Value: "dark", Revision: 1})
+	assert.ErrorIs(t, err, persistence.ErrRevisionMismatch)
+
+	kv, _ := p.Get(ctx, "COLOR")
+	assert.Equal(t, persistence.KeyValue{Key: "COLOR", Value: "blue", Revision: 2}, kv)
+}
+
+func TestDatabasePersistence_ConcurrentInstances(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "features.db") + "?_pragma=busy_timeout(5000)"
+	var instances []*Persistence
+	for range 2 {
+		p, err := NewDatabasePersistence(ctx, DialectSQLite, path)
+		require.NoError(t, err)
+		defer p.Close()
+		instances = append(instances, p)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Go(func() {
+			p := instances[i%len(instances)]
+			assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COUNTER", Value: fmt.Sprint(i)}))
+			assert.NoError(t, p.AppendHistory(ctx, persistence.HistoryEntry{Key: "COUNTER", Action: persistence.ActionUpdate, Timestamp: time.Now()}))
+		})
+	}
+	wg.Wait()
+
+	// every write got its own revision
+	kv, _ := instances[0].Get(ctx, "COUNTER")
+	assert.Equal(t, uint64(20), kv.Revision)
+	entries, _ := instances[1].History(ctx, "COUNTER")
+	assert.Len(t, entries, 20)
+}
+
+func TestDatabasePersistence_Batch(t *testing.T) {
+	p := newTestPersistence(t)
+	ctx := context.Background()
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "SIZE", Value: "large", Metadata: persistence.Metadata{Owner: "shop"}}))
+
+	assert.NoError(t, p.Batch(ctx, []persistence.Operation{
+		{KeyValue: persistence.KeyValue{Key: "COLOR", Value: "blue", Revision: 1}},
+		{KeyValue: persistence.KeyValue{Key: "THEME", Value: "dark"}},
+		{KeyValue: persistence.KeyValue{Key: "SIZE"}, Delete: true},
+	}))
+	all, _ := p.GetAll(ctx)
+	assert.ElementsMatch(t, []persistence.KeyValue{
+		{Key: "COLOR", Value: "blue", Revision: 2},
+		{Key: "THEME", Value: "dark", Revision: 1},
+	}, all)
+
+	// a failing operation rolls back the whole batch
+	err := p.Batch(ctx, []persistence.Operation{
+		{KeyValue: persistence.KeyValue{Key: "THEME", Value: "light"}},
+		{KeyValue: persistence.KeyValue{Key: "COLOR", Value: "green", Revision: 1}},
+	})
+	assert.ErrorIs(t, err, persistence.ErrRevisionMismatch)
+	kv, _ := p.Get(ctx, "THEME")
+	assert.Equal(t, "dark", kv.Value)
+}
+
+func TestDatabasePersistence_History(t *testing.T) {
+	p := newTestPersistence(t)
+	ctx := context.Background()
+	timestamp := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := range persistence.HistoryLimit + 2 {
+		assert.NoError(t, p.AppendHistory(ctx, persistence.HistoryEntry{
+			Key: "COLOR", Action: persistence.ActionUpdate, NewValue: fmt.Sprint(i), Actor: "admin", Timestamp: timestamp, Source: "Set",
+		}))
+	}
+
+	entries, err := p.History(ctx, "COLOR")
+	assert.NoError(t, err)
+	require.Len(t, entries, persistence.HistoryLimit)
+	assert.Equal(t, uint64(3), entries[0].Revision)
+	assert.Equal(t, persistence.HistoryEntry{
+		Key: "COLOR", Revision: persistence.HistoryLimit + 2, Action: persistence.ActionUpdate, NewValue: fmt.Sprint(persistence.HistoryLimit + 1),
+		Actor: "admin", Timestamp: timestamp, Source: "Set",
+	}, entries[len(entries)-1])
+
+	entries, err = p.History(ctx, "THEME")
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestDatabasePersistence_Partition(t *testing.T) {
+	p := newTestPersistence(t)
+	shop := p.Partition("shop")
+	ctx := context.Background()
+
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red", Metadata: persistence.Metadata{Owner: "web"}}))
+	assert.NoError(t, shop.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "blue"}))
+	assert.NoError(t, shop.AppendHistory(ctx, persistence.HistoryEntry{Key: "COLOR", Action: persistence.ActionCreate, NewValue: "blue", Timestamp: time.Now()}))
+
+	kv, _ := p.Get(ctx, "COLOR")
+	assert.Equal(t, persistence.KeyValue{Key: "COLOR", Value: "red", Metadata: persistence.Metadata{Owner: "web"}, Revision: 1}, kv)
+	kv, _ = shop.Get(ctx, "COLOR")
+	assert.Equal(t, persistence.KeyValue{Key: "COLOR", Value: "blue", Revision: 1}, kv)
+	entries, _ := p.History(ctx, "COLOR")
+	assert.Empty(t, entries)
+
+	assert.NoError(t, shop.Delete(ctx, "COLOR"))
+	count, _ := shop.Count(ctx)
+	assert.Equal(t, 0, count)
+	count, _ = p.Count(ctx)
+	assert.Equal(t, 1, count)
+}
+
+func TestConformance(t *testing.T) {
+	persistencetest.Run(t, func(t *testing.T) persistence.Persistence {
+		return newTestPersistence(t)
+	}, persistencetest.Limitations{})
+}
+
+func TestConformance_Partitions(t *testing.T) {
+	persistencetest.RunPartitions(t, "shop", func(t *testing.T) (persistence.Persistence, persistence.Persistence) {
+		p := newTestPersistence(t)
+		return p, p.Partition("shop")
+	})
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	// database drivers
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	DialectSQLite   = "sqlite"
+	DialectPostgres = "postgres"
+)
+
+// dialect holds what differs between the supported databases. The queries are written with ?
+// placeholders and the upsert syntax both databases understand.
+type dialect struct {
+	name string
+	// driver is the name of the database/sql driver
+	driver string
+	// numbered placeholders ($1, $2, ...) instead of ?
+	numbered bool
+	// lock is run at the start of the migration transaction so that instances starting at the
+	// same time do not migrate twice, empty if the database locks itself on the first write
+	lock string
+	// connections limits the open connections, 0 for no limit. SQLite allows a single writer,
+	// and an in-memory database only exists within its connection.
+	connections int
+	// params are added to the DSN unless it sets them itself
+	params map[string]string
+}
+
+var dialects = map[string]dialect{
+	DialectSQLite: {
+		name:        DialectSQLite,
+		driver:      "sqlite",
+		connections: 1,
+		// transactions are deferred by default and take the write lock on their first write.
+		// Another instance holding the lock then fails the upgrade with SQLITE_BUSY right away,
+		// the busy timeout only applies to locks taken when the transaction begins.
+		params: map[string]string{"_txlock": "immediate"},
+	},
+	DialectPostgres: {
+		name:     DialectPostgres,
+		driver:   "pgx",
+		numbered: true,
+		// an arbitrary key, the same for all instances
+		lock: "SELECT pg_advisory_xact_lock(7316046240118063474)",
+	},
+}
+
+func dialectByName(name string) (dialect, error) {
+	d, ok := dialects[name]
+	if !ok {
+		return dialect{}, fmt.Errorf("unsupported database dialect '%s', use %s or %s", name, DialectSQLite, DialectPostgres)
+	}
+	return d, nil
+}
+
+// withParams adds the params of the dialect to the DSN that it does not set itself
+func (d dialect) withParams(dsn string) string {
+	keys := make([]string, 0, len(d.params))
+	for key := range d.params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		_, query, _ := strings.Cut(dsn, "?")
+		if values, err := url.ParseQuery(query); err == nil && values.Has(key) {
+			continue
+		}
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		dsn += separator + key + "=" + url.QueryEscape(d.params[key])
+	}
+	return dsn
+}
+
+// rebind replaces the ? placeholders of the query with those of the dialect
+func (d dialect) rebind(query string) string {
+	if !d.numbered {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
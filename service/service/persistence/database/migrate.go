@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrations holds the schema of every dialect as numbered SQL files, e.g. 0002_add_column.sql.
+// Applied files must never change, a schema change is a new file for every dialect.
+//
+//go:embed migrations
+var migrations embed.FS
+
+type migration struct {
+	version    int
+	name       string
+	statements []string
+}
+
+// loadMigrations returns the migrations of the dialect ordered by version
+func loadMigrations(d dialect) ([]migration, error) {
+	dir := path.Join("migrations", d.name)
+	entries, err := fs.ReadDir(migrations, dir)
+	if err != nil {
+		return nil, err
+	}
+	var result []migration
+	for _, entry := range entries {
+		name := entry.Name()
+		prefix, _, found := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if !found || err != nil || !strings.HasSuffix(name, ".sql") {
+			return nil, fmt.Errorf("invalid migration name '%s'", name)
+		}
+		raw, err := fs.ReadFile(migrations, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		var statements []string
+		for statement := range strings.SplitSeq(string(raw), ";") {
+			if statement = strings.TrimSpace(statement); statement != "" {
+				statements = append(statements, statement)
+			}
+		}
+		result = append(result, migration{version: version, name: name, statements: statements})
+	}
+	slices.SortFunc(result, func(a, b migration) int { return a.version - b.version })
+	return result, nil
+}
+
+// migrate applies the migrations the database has not seen yet, all in one transaction
+func migrate(ctx context.Context, db *sql.DB, d dialect) error {
+	pending, err := loadMigrations(d)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if d.lock != "" {
+		if _, err := tx.ExecContext(ctx, d.lock); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMP NOT NULL
+)`); err != nil {
+		return err
+	}
+	var current int
+	if err := tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current); err != nil {
+		return err
+	}
+
+	for _, m := range pending {
+		if m.version <= current {
+			continue
+		}
+		slog.InfoContext(ctx, "Applying database migration", "version", m.version, "name", m.name)
+		for _, statement := range m.statements {
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("migration %s: %w", m.name, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, d.rebind("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)"),
+			m.version, m.name, time.Now().UTC()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
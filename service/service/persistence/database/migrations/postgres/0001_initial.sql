@@ -0,0 +1,34 @@
+-- flags holds the value of every key, constraints, rules and rollout are JSONB documents
+CREATE TABLE flags (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL,
+    type TEXT NOT NULL DEFAULT '',
+    constraints JSONB,
+    rules JSONB,
+    rollout JSONB,
+    revision BIGINT NOT NULL
+);
+
+-- flag_metadata holds the descriptive metadata of a key, tags are a JSONB array
+CREATE TABLE flag_metadata (
+    key TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    owner TEXT NOT NULL DEFAULT '',
+    tags JSONB,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    last_modified_by TEXT NOT NULL DEFAULT ''
+);
+
+-- history keeps the changes of a key, also after the key was deleted
+CREATE TABLE history (
+    key TEXT NOT NULL,
+    revision BIGINT NOT NULL,
+    action TEXT NOT NULL,
+    old_value TEXT NOT NULL DEFAULT '',
+    new_value TEXT NOT NULL DEFAULT '',
+    actor TEXT NOT NULL DEFAULT '',
+    timestamp TIMESTAMPTZ NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (key, revision)
+);
@@ -0,0 +1,12 @@
+-- flag_set partitions the tables by flag set, the default set is ''
+ALTER TABLE flags ADD COLUMN flag_set TEXT NOT NULL DEFAULT '';
+ALTER TABLE flags DROP CONSTRAINT flags_pkey;
+ALTER TABLE flags ADD PRIMARY KEY (flag_set, key);
+
+ALTER TABLE flag_metadata ADD COLUMN flag_set TEXT NOT NULL DEFAULT '';
+ALTER TABLE flag_metadata DROP CONSTRAINT flag_metadata_pkey;
+ALTER TABLE flag_metadata ADD PRIMARY KEY (flag_set, key);
+
+ALTER TABLE history ADD COLUMN flag_set TEXT NOT NULL DEFAULT '';
+ALTER TABLE history DROP CONSTRAINT history_pkey;
+ALTER TABLE history ADD PRIMARY KEY (flag_set, key, revision);
@@ -0,0 +1,3 @@
+-- prerequisites hold the JSON of the flags a flag depends on, off_value is served if one is not met
+ALTER TABLE flags ADD COLUMN prerequisites TEXT;
+ALTER TABLE flags ADD COLUMN off_value TEXT NOT NULL DEFAULT '';
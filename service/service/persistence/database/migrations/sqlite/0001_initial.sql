@@ -0,0 +1,34 @@
+-- flags holds the value of every key, constraints, rules and rollout are JSON documents
+CREATE TABLE flags (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL,
+    type TEXT NOT NULL DEFAULT '',
+    constraints TEXT,
+    rules TEXT,
+    rollout TEXT,
+    revision INTEGER NOT NULL
+);
+
+-- flag_metadata holds the descriptive metadata of a key, tags are a JSON array
+CREATE TABLE flag_metadata (
+    key TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    owner TEXT NOT NULL DEFAULT '',
+    tags TEXT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    last_modified_by TEXT NOT NULL DEFAULT ''
+);
+
+-- history keeps the changes of a key, also after the key was deleted
+CREATE TABLE history (
+    key TEXT NOT NULL,
+    revision INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    old_value TEXT NOT NULL DEFAULT '',
+    new_value TEXT NOT NULL DEFAULT '',
+    actor TEXT NOT NULL DEFAULT '',
+    timestamp TIMESTAMP NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (key, revision)
+);
@@ -0,0 +1,50 @@
+-- flag_set partitions the tables by flag set, the default set is ''. SQLite cannot change a
+-- primary key, so the tables are rebuilt.
+CREATE TABLE flags_new (
+    flag_set TEXT NOT NULL DEFAULT '',
+    key TEXT NOT NULL,
+    value TEXT NOT NULL,
+    type TEXT NOT NULL DEFAULT '',
+    constraints TEXT,
+    rules TEXT,
+    rollout TEXT,
+    revision INTEGER NOT NULL,
+    PRIMARY KEY (flag_set, key)
+);
+INSERT INTO flags_new (key, value, type, constraints, rules, rollout, revision)
+SELECT key, value, type, constraints, rules, rollout, revision FROM flags;
+DROP TABLE flags;
+ALTER TABLE flags_new RENAME TO flags;
+
+CREATE TABLE flag_metadata_new (
+    flag_set TEXT NOT NULL DEFAULT '',
+    key TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    owner TEXT NOT NULL DEFAULT '',
+    tags TEXT,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    last_modified_by TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (flag_set, key)
+);
+INSERT INTO flag_metadata_new (key, description, owner, tags, created_at, updated_at, last_modified_by)
+SELECT key, description, owner, tags, created_at, updated_at, last_modified_by FROM flag_metadata;
+DROP TABLE flag_metadata;
+ALTER TABLE flag_metadata_new RENAME TO flag_metadata;
+
+CREATE TABLE history_new (
+    flag_set TEXT NOT NULL DEFAULT '',
+    key TEXT NOT NULL,
+    revision INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    old_value TEXT NOT NULL DEFAULT '',
+    new_value TEXT NOT NULL DEFAULT '',
+    actor TEXT NOT NULL DEFAULT '',
+    timestamp TIMESTAMP NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (flag_set, key, revision)
+);
+INSERT INTO history_new (key, revision, action, old_value, new_value, actor, timestamp, source)
+SELECT key, revision, action, old_value, new_value, actor, timestamp, source FROM history;
+DROP TABLE history;
+ALTER TABLE history_new RENAME TO history;
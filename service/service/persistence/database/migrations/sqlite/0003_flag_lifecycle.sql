@@ -0,0 +1,3 @@
+-- expires_at and lifecycle let flags be detected as stale
+ALTER TABLE flag_metadata ADD COLUMN expires_at TIMESTAMP;
+ALTER TABLE flag_metadata ADD COLUMN lifecycle TEXT NOT NULL DEFAULT '';
@@ -2,41 +2,140 @@ package factory
 
 import (
 	"errors"
+	"path/filepath"
+	"strings"
 
 	"github.com/dkrizic/feature/service/constant"
+	"github.com/dkrizic/feature/service/notifier"
 	nf "github.com/dkrizic/feature/service/notifier/factory"
+	"github.com/dkrizic/feature/service/service/flagset"
 	"github.com/dkrizic/feature/service/service/persistence"
 	"github.com/dkrizic/feature/service/service/persistence/configmap"
+	"github.com/dkrizic/feature/service/service/persistence/crd"
+	"github.com/dkrizic/feature/service/service/persistence/database"
+	"github.com/dkrizic/feature/service/service/persistence/file"
 	"github.com/dkrizic/feature/service/service/persistence/inmemory"
 	"github.com/dkrizic/feature/service/service/persistence/notifying"
+	"github.com/dkrizic/feature/service/service/persistence/recording"
+	"github.com/dkrizic/feature/service/service/persistence/secret"
 	"github.com/urfave/cli/v3"
+	"k8s.io/client-go/dynamic"
 
 	"context"
 	"log/slog"
 )
 
-func NewPersistence(ctx context.Context, cmd *cli.Command) (persistence.Persistence, error) {
+// Storage is the configured storage. Every flag set has a partition of its own: the default set
+// uses the configured ConfigMap, Secret or file, another set one named after it, e.g.
+// feature-flags-shop for the set shop. Every further environment adds a partition per set, e.g.
+// feature-flags-shop.prod. FeatureFlags and database rows carry the partition name.
+type Storage struct {
+	storageType string
+	cmd         *cli.Command
+	notifier    notifier.Notifier
+	// db is shared by the partitions of the sql storage
+	db *database.Persistence
+	// kubernetes is shared by the partitions of the crd storage, in the namespace of the service
+	kubernetes dynamic.Interface
+	namespace  string
+}
+
+// Injectable function variables for testing
+var crdClientFn = crd.NewClient
+
+// NewStorage creates the configured storage and notifier
+func NewStorage(ctx context.Context, cmd *cli.Command) (*Storage, error) {
 	stype := cmd.String(constant.StorageType)
 
-	notifier, err := nf.NewNotifier(ctx, cmd)
+	n, err := nf.NewNotifier(ctx, cmd)
 	if err != nil {
 		return nil, err
 	}
+	s := &Storage{storageType: stype, cmd: cmd, notifier: n}
 
 	switch stype {
 	case constant.StorageTypeInMemory:
 		slog.InfoContext(ctx, "In-memory storage selected")
-		return notifying.NewNotifyingPersistence(
-			inmemory.NewInMemoryPersistence(), notifier,
-		), nil
 	case constant.StorageTypeConfigMap:
 		slog.InfoContext(ctx, "ConfigMap storage selected")
-		cmName := cmd.String(constant.ConfigMapName)
-		return notifying.NewNotifyingPersistence(
-			configmap.NewConfigMapPersistence(cmName), notifier,
-		), nil
+	case constant.StorageTypeCRD:
+		slog.InfoContext(ctx, "CRD storage selected")
+		s.kubernetes, s.namespace, err = crdClientFn(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to create Kubernetes client", "error", err)
+			return nil, err
+		}
+	case constant.StorageTypeFile:
+		slog.InfoContext(ctx, "File storage selected", "path", cmd.String(constant.StorageFile))
+	case constant.StorageTypeSQL:
+		dialect := cmd.String(constant.SQLDialect)
+		slog.InfoContext(ctx, "SQL storage selected", "dialect", dialect)
+		s.db, err = database.NewDatabasePersistence(ctx, dialect, cmd.String(constant.SQLDSN))
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to open database", "dialect", dialect, "error", err)
+			return nil, err
+		}
+	case constant.StorageTypeSecret:
+		slog.InfoContext(ctx, "Secret storage selected", "secret", cmd.String(constant.SecretName))
 	default:
 		slog.ErrorContext(ctx, "Invalid storage type", "type", stype)
 		return nil, errors.New("Invalid storage type")
 	}
+	return s, nil
+}
+
+// Open returns the persistence of a flag set in an environment. Changes are recorded in the
+// history of the backend and sent to the configured notifier and to any additional notifiers
+// (e.g. the Watch broadcaster).
+func (s *Storage) Open(ctx context.Context, p flagset.Partition, additional ...notifier.Notifier) persistence.Persistence {
+	notifiers := append([]notifier.Notifier{notifier.WithSet(s.notifier, p.Set, p.Environment)}, additional...)
+	return notifying.NewNotifyingPersistence(recording.NewRecordingPersistence(s.Backend(ctx, p.Name, notifiers...)), notifiers...)
+}
+
+// Backend returns the partition of a flag set without history and notifications. The notifiers
+// receive the changes made to the storage by others, if the backend detects them.
+func (s *Storage) Backend(ctx context.Context, set string, notifiers ...notifier.Notifier) persistence.Persistence {
+	slog.DebugContext(ctx, "Opening storage partition", "type", s.storageType, "set", set)
+	switch s.storageType {
+	case constant.StorageTypeConfigMap:
+		return configmap.NewConfigMapPersistence(partition(s.cmd.String(constant.ConfigMapName), set), notifiers...)
+	case constant.StorageTypeCRD:
+		return crd.NewCRDPersistence(s.kubernetes, s.namespace, set)
+	case constant.StorageTypeFile:
+		return file.NewFilePersistence(partitionFile(s.cmd.String(constant.StorageFile), set), s.cmd.Bool(constant.StorageFileReload))
+	case constant.StorageTypeSQL:
+		return s.db.Partition(set)
+	case constant.StorageTypeSecret:
+		return secret.NewSecretPersistence(partition(s.cmd.String(constant.SecretName), set))
+	default:
+		return inmemory.NewInMemoryPersistence()
+	}
+}
+
+// partition returns the name of the storage object of a flag set
+func partition(name, set string) string {
+	if set == "" {
+		return name
+	}
+	return name + "-" + set
+}
+
+// partitionFile returns the file of a flag set, keeping the extension that selects the format
+func partitionFile(path, set string) string {
+	if set == "" {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + set + ext
+}
+
+// NewPersistence creates the configured persistence of the default flag set. Changes are recorded
+// in the history of the backend and sent to the configured notifier and to any additional
+// notifiers (e.g. the Watch broadcaster).
+func NewPersistence(ctx context.Context, cmd *cli.Command, additional ...notifier.Notifier) (persistence.Persistence, error) {
+	s, err := NewStorage(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return s.Open(ctx, flagset.Partition{}, additional...), nil
 }
@@ -2,13 +2,18 @@ package factory
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 
 	"github.com/dkrizic/feature/service/constant"
+	"github.com/dkrizic/feature/service/service/flagset"
 	"github.com/dkrizic/feature/service/service/persistence"
 	"github.com/dkrizic/feature/service/service/persistence/notifying"
 	"github.com/stretchr/testify/assert"
 	"github.com/urfave/cli/v3"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/fake"
 )
 
 func newTestCommand(storageType, configMapName string) *cli.Command {
@@ -46,6 +51,81 @@ func TestNewPersistence_ConfigMap(t *testing.T) {
 	assert.True(t, ok, "expected NotifyingPersistence wrapper for configmap storage")
 }
 
+func TestNewPersistence_CRD(t *testing.T) {
+	original := crdClientFn
+	crdClientFn = func(ctx context.Context) (dynamic.Interface, string, error) {
+		return fake.NewSimpleDynamicClient(runtime.NewScheme()), "test-namespace", nil
+	}
+	t.Cleanup(func() { crdClientFn = original })
+	ctx := context.Background()
+	cmd := newTestCommand(constant.StorageTypeCRD, "")
+
+	p, err := NewPersistence(ctx, cmd)
+	assert.NoError(t, err)
+	assert.NotNil(t, p)
+
+	// The factory wraps the underlying persistence with notifying.NotifyingPersistence
+	_, ok := p.(*notifying.NotifyingPersistence)
+	assert.True(t, ok, "expected NotifyingPersistence wrapper for crd storage")
+}
+
+func TestNewPersistence_Secret(t *testing.T) {
+	ctx := context.Background()
+	cmd := newTestCommand(constant.StorageTypeSecret, "")
+	cmd.Flags = append(cmd.Flags, &cli.StringFlag{Name: constant.SecretName, Value: "feature-secrets"})
+
+	p, err := NewPersistence(ctx, cmd)
+	assert.NoError(t, err)
+	assert.NotNil(t, p)
+
+	// The factory wraps the underlying persistence with notifying.NotifyingPersistence
+	_, ok := p.(*notifying.NotifyingPersistence)
+	assert.True(t, ok, "expected NotifyingPersistence wrapper for secret storage")
+}
+
+func TestNewPersistence_File(t *testing.T) {
+	ctx := context.Background()
+	cmd := newTestCommand(constant.StorageTypeFile, "")
+	cmd.Flags = append(cmd.Flags,
+		&cli.StringFlag{Name: constant.StorageFile, Value: filepath.Join(t.TempDir(), "features.json")},
+		&cli.BoolFlag{Name: constant.StorageFileReload, Value: true},
+	)
+
+	p, err := NewPersistence(ctx, cmd)
+	assert.NoError(t, err)
+	assert.NotNil(t, p)
+
+	// The factory wraps the underlying persistence with notifying.NotifyingPersistence
+	_, ok := p.(*notifying.NotifyingPersistence)
+	assert.True(t, ok, "expected NotifyingPersistence wrapper for file storage")
+}
+
+func TestNewPersistence_SQL(t *testing.T) {
+	ctx := context.Background()
+	cmd := newTestCommand(constant.StorageTypeSQL, "")
+	cmd.Flags = append(cmd.Flags,
+		&cli.StringFlag{Name: constant.SQLDialect, Value: constant.SQLDialectSQLite},
+		&cli.StringFlag{Name: constant.SQLDSN, Value: filepath.Join(t.TempDir(), "features.db")},
+	)
+
+	p, err := NewPersistence(ctx, cmd)
+	assert.NoError(t, err)
+	assert.NotNil(t, p)
+
+	// The factory wraps the underlying persistence with notifying.NotifyingPersistence
+	_, ok := p.(*notifying.NotifyingPersistence)
+	assert.True(t, ok, "expected NotifyingPersistence wrapper for sql storage")
+
+	// an unreachable database fails at startup
+	cmd = newTestCommand(constant.StorageTypeSQL, "")
+	cmd.Flags = append(cmd.Flags,
+		&cli.StringFlag{Name: constant.SQLDialect, Value: constant.SQLDialectSQLite},
+		&cli.StringFlag{Name: constant.SQLDSN, Value: filepath.Join(t.TempDir(), "missing", "features.db")},
+	)
+	_, err = NewPersistence(ctx, cmd)
+	assert.Error(t, err)
+}
+
 func TestNewPersistence_InvalidType(t *testing.T) {
 	ctx := context.Background()
 	cmd := newTestCommand("invalid", "")
@@ -55,6 +135,30 @@ func TestNewPersistence_InvalidType(t *testing.T) {
 	assert.Nil(t, p)
 }
 
+func TestPartition(t *testing.T) {
+	assert.Equal(t, "feature-flags", partition("feature-flags", ""))
+	assert.Equal(t, "feature-flags-shop", partition("feature-flags", "shop"))
+	assert.Equal(t, "/data/features.yaml", partitionFile("/data/features.yaml", ""))
+	assert.Equal(t, "/data/features-shop.yaml", partitionFile("/data/features.yaml", "shop"))
+	assert.Equal(t, "/data/features-shop", partitionFile("/data/features", "shop"))
+}
+
+func TestStorage_SQLPartitions(t *testing.T) {
+	ctx := context.Background()
+	cmd := newTestCommand(constant.StorageTypeSQL, "")
+	cmd.Flags = append(cmd.Flags,
+		&cli.StringFlag{Name: constant.SQLDialect, Value: constant.SQLDialectSQLite},
+		&cli.StringFlag{Name: constant.SQLDSN, Value: filepath.Join(t.TempDir(), "features.db")},
+	)
+	s, err := NewStorage(ctx, cmd)
+	assert.NoError(t, err)
+
+	// the sets share the database but not the flags
+	assert.NoError(t, s.Open(ctx, flagset.Partition{}).Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	_, err = s.Open(ctx, flagset.Partition{Name: "shop", Set: "shop"}).Get(ctx, "COLOR")
+	assert.ErrorIs(t, err, persistence.ErrKeyNotFound)
+}
+
 // Ensure factory returns an implementation that fulfills the Persistence interface
 func TestNewPersistence_ImplementsInterface(t *testing.T) {
 	ctx := context.Background()
@@ -0,0 +1,400 @@
+package file
+
+// implements the persistence interface on top of a local JSON or YAML file, for deployments outside of Kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dkrizic/feature/service/service/persistence"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"sigs.k8s.io/yaml"
+)
+
+// lockSuffix is appended to the path of the file to name the lock file. The data file itself
+// cannot be locked as it is replaced on every write.
+const lockSuffix = ".lock"
+
+// flag is a key as it is stored in the file
+type flag struct {
+	Key           string                     `json:"key"`
+	Value         string                     `json:"value"`
+	Type          persistence.ValueType      `json:"type,omitempty"`
+	Constraints   *persistence.Constraints   `json:"constraints,omitempty"`
+	Metadata      persistence.Metadata       `json:"metadata,omitzero"`
+	Rules         []persistence.Rule         `json:"rules,omitempty"`
+	Rollout       *persistence.Rollout       `json:"rollout,omitempty"`
+	Prerequisites []persistence.Prerequisite `json:"prerequisites,omitempty"`
+	OffValue      string                     `json:"offValue,omitempty"`
+	Revision      uint64                     `json:"revision,omitempty"`
+}
+
+// document is the content of the file, the flags are sorted by key to keep diffs small
+type document struct {
+	Flags   []flag                                `json:"flags"`
+	History map[string][]persistence.HistoryEntry `json:"history,omitempty"`
+}
+
+// state identifies a version of the file, so that changes made by others are noticed
+type state struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+type Persistence struct {
+	path   string
+	reload bool
+
+	mu      sync.Mutex
+	loaded  bool
+	state   state
+	data    map[string]persistence.KeyValue
+	history map[string][]persistence.HistoryEntry
+}
+
+// NewFilePersistence stores the flags in the file at path, as YAML if it ends in .yaml or .yml and
+// as JSON otherwise. A missing file is created on the first write. With reload, changes made to
+// the file by others, e.g. an editor or a second instance, are picked up before every access;
+// without it the file is read once and the service assumes it is the only one writing it.
+func NewFilePersistence(path string, reload bool) *Persistence {
+	return &Persistence{
+		path:   path,
+		reload: reload,
+	}
+}
+
+func (p *Persistence) GetAll(ctx context.Context) ([]persistence.KeyValue, error) {
+	ctx, span := otel.Tracer("service/persistence/file").Start(ctx, "GetAll")
+	defer span.End()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.refresh(ctx); err != nil {
+		return nil, err
+	}
+	var result []persistence.KeyValue
+	for _, kv := range p.data {
+		result = append(result, kv)
+	}
+	return result, nil
+}
+
+// set the value only if it does not exist
+func (p *Persistence) PreSet(ctx context.Context, kv persistence.KeyValue) error {
+	ctx, span := otel.Tracer("service/persistence/file").Start(ctx, "PreSet")
+	defer span.End()
+
+	return p.update(ctx, func(data map[string]persistence.KeyValue, _ map[string][]persistence.HistoryEntry) error {
+		if old, exist := data[kv.Key]; exist {
+			slog.InfoContext(ctx, "Key already exists, not presetting", "key", kv.Key, "value", kv.Value, "oldvalue", old.Value)
+			return errUnchanged
+		}
+		slog.DebugContext(ctx, "PreSetting", "key", kv.Key, "value", kv.Value)
+		kv.Revision = 1
+		data[kv.Key] = kv
+		return nil
+	})
+}
+
+func (p *Persistence) Set(ctx context.Context, kv persistence.KeyValue) error {
+	ctx, span := otel.Tracer("service/persistence/file").Start(ctx, "Set")
+	defer span.End()
+
+	return p.update(ctx, func(data map[string]persistence.KeyValue, _ map[string][]persistence.HistoryEntry) error {
+		return set(data, kv)
+	})
+}
+
+func set(data map[string]persistence.KeyValue, kv persistence.KeyValue) error {
+	revision, err := persistence.NextRevision(data[kv.Key].Revision, kv.Revision)
+	if err != nil {
+		return err
+	}
+	kv.Revision = revision
+	data[kv.Key] = kv
+	return nil
+}
+
+// Batch writes all operations with a single replacement of the file
+func (p *Persistence) Batch(ctx context.Context, operations []persistence.Operation) error {
+	ctx, span := otel.Tracer("service/persistence/file").Start(ctx, "Batch")
+	defer span.End()
+	span.SetAttributes(attribute.Int("operations", len(operations)))
+
+	return p.update(ctx, func(data map[string]persistence.KeyValue, _ map[string][]persistence.HistoryEntry) error {
+		for _, op := range operations {
+			if op.Delete {
+				delete(data, op.KeyValue.Key)
+				continue
+			}
+			if err := set(data, op.KeyValue); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (p *Persistence) Get(ctx context.Context, key string) (persistence.KeyValue, error) {
+	ctx, span := otel.Tracer("service/persistence/file").Start(ctx, "Get")
+	defer span.End()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.refresh(ctx); err != nil {
+		return persistence.KeyValue{}, err
+	}
+	kv, exist := p.data[key]
+	if !exist {
+		return persistence.KeyValue{}, persistence.ErrKeyNotFound
+	}
+	return kv, nil
+}
+
+func (p *Persistence) Delete(ctx context.Context, key string) error {
+	ctx, span := otel.Tracer("service/persistence/file").Start(ctx, "Delete")
+	defer span.End()
+
+	return p.update(ctx, func(data map[string]persistence.KeyValue, _ map[string][]persistence.HistoryEntry) error {
+		if _, exist := data[key]; !exist {
+			return errUnchanged
+		}
+		delete(data, key)
+		return nil
+	})
+}
+
+func (p *Persistence) Count(ctx context.Context) (int, error) {
+	ctx, span := otel.Tracer("service/persistence/file").Start(ctx, "Count")
+	defer span.End()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.refresh(ctx); err != nil {
+		return 0, err
+	}
+	return len(p.data), nil
+}
+
+func (p *Persistence) AppendHistory(ctx context.Context, entry persistence.HistoryEntry) error {
+	ctx, span := otel.Tracer("service/persistence/file").Start(ctx, "AppendHistory")
+	defer span.End()
+
+	return p.update(ctx, func(_ map[string]persistence.KeyValue, history map[string][]persistence.HistoryEntry) error {
+		history[entry.Key] = persistence.AppendEntry(history[entry.Key], entry)
+		return nil
+	})
+}
+
+func (p *Persistence) History(ctx context.Context, key string) ([]persistence.HistoryEntry, error) {
+	ctx, span := otel.Tracer("service/persistence/file").Start(ctx, "History")
+	defer span.End()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return slices.Clone(p.history[key]), nil
+}
+
+// errUnchanged ends an update without writing the file
+var errUnchanged = errors.New("unchanged")
+
+// update applies change to copies of the data and history and replaces the file with the result.
+// The lock file keeps other processes from writing in between reading and replacing the file.
+func (p *Persistence) update(ctx context.Context, change func(map[string]persistence.KeyValue, map[string][]persistence.HistoryEntry) error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	unlock, err := lock(p.path + lockSuffix)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := p.refresh(ctx); err != nil {
+		return err
+	}
+	data := maps.Clone(p.data)
+	history := maps.Clone(p.history)
+	err = change(data, history)
+	if errors.Is(err, errUnchanged) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	written, err := p.write(data, history)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write file", "path", p.path, "error", err)
+		return err
+	}
+	p.state = written
+	p.data = data
+	p.history = history
+	return nil
+}
+
+// refresh reads the file on first use and, with reload, whenever it was changed by others
+func (p *Persistence) refresh(ctx context.Context) error {
+	if p.loaded && !p.reload {
+		return nil
+	}
+	current, err := stat(p.path)
+	if err != nil {
+		return err
+	}
+	if p.loaded && current == p.state {
+		return nil
+	}
+	if p.loaded {
+		slog.InfoContext(ctx, "File changed on disk, reloading", "path", p.path)
+	}
+
+	data, history, err := p.read()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read file", "path", p.path, "error", err)
+		return err
+	}
+	p.data = data
+	p.history = history
+	p.state = current
+	p.loaded = true
+	return nil
+}
+
+// read parses the file, a missing file holds no flags
+func (p *Persistence) read() (map[string]persistence.KeyValue, map[string][]persistence.HistoryEntry, error) {
+	data := make(map[string]persistence.KeyValue)
+	history := make(map[string][]persistence.HistoryEntry)
+
+	raw, err := os.ReadFile(p.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return data, history, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.isYAML() {
+		raw, err = yaml.YAMLToJSON(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	var doc document
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &doc); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, f := range doc.Flags {
+		if f.Key == "" {
+			continue
+		}
+		data[f.Key] = persistence.KeyValue{
+			Key:           f.Key,
+			Value:         f.Value,
+			Type:          f.Type,
+			Constraints:   f.Constraints,
+			Metadata:      f.Metadata,
+			Rules:         f.Rules,
+			Rollout:       f.Rollout,
+			Prerequisites: f.Prerequisites,
+			OffValue:      f.OffValue,
+			// flags added to the file by hand have been written once
+			Revision: max(f.Revision, 1),
+		}
+	}
+	maps.Copy(history, doc.History)
+	return data, history, nil
+}
+
+// write replaces the file atomically: the content goes to a temporary file in the same directory,
+// which is synced to disk and renamed over the file. It returns the state of the written file.
+func (p *Persistence) write(data map[string]persistence.KeyValue, history map[string][]persistence.HistoryEntry) (state, error) {
+	doc := document{Flags: []flag{}, History: history}
+	for _, key := range slices.Sorted(maps.Keys(data)) {
+		kv := data[key]
+		doc.Flags = append(doc.Flags, flag{
+			Key:           kv.Key,
+			Value:         kv.Value,
+			Type:          kv.Type,
+			Constraints:   kv.Constraints,
+			Metadata:      kv.Metadata,
+			Rules:         kv.Rules,
+			Rollout:       kv.Rollout,
+			Prerequisites: kv.Prerequisites,
+			OffValue:      kv.OffValue,
+			Revision:      kv.Revision,
+		})
+	}
+	raw, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return state{}, err
+	}
+	if p.isYAML() {
+		raw, err = yaml.JSONToYAML(raw)
+		if err != nil {
+			return state{}, err
+		}
+	}
+
+	dir := filepath.Dir(p.path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(p.path)+".tmp-*")
+	if err != nil {
+		return state{}, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return state{}, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return state{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return state{}, err
+	}
+	if err := os.Rename(tmp.Name(), p.path); err != nil {
+		return state{}, err
+	}
+	// sync the directory so that the rename survives a crash
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+
+	return stat(p.path)
+}
+
+func (p *Persistence) isYAML() bool {
+	ext := strings.ToLower(filepath.Ext(p.path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+func stat(path string) (state, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state{}, nil
+	}
+	if err != nil {
+		return state{}, err
+	}
+	return state{exists: true, size: info.Size(), modTime: info.ModTime()}, nil
+}
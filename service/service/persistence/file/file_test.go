@@ -0,0 +1,201 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/dkrizic/feature/service/service/persistence/persistencetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "features.json")
+	p := NewFilePersistence(path, true)
+	ctx := context.Background()
+
+	// a missing file holds no flags and is not created by reading
+	count, err := p.Count(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.NoFileExists(t, path)
+	_, err = p.Get(ctx, "COLOR")
+	assert.ErrorIs(t, err, persistence.ErrKeyNotFound)
+
+	assert.NoError(t, p.PreSet(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	assert.NoError(t, p.PreSet(ctx, persistence.KeyValue{Key: "COLOR", Value: "green"}))
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{
+		Key:      "RETRIES",
+		Value:    "3",
+		Type:     persistence.TypeInteger,
+		Metadata: persistence.Metadata{Owner: "team-checkout"},
+	}))
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "RETRIES", Value: "4", Type: persistence.TypeInteger, Revision: 1}))
+	err = p.Set(ctx, persistence.KeyValue{Key: "RETRIES", Value: "5", Revision: 1})
+	assert.ErrorIs(t, err, persistence.ErrRevisionMismatch)
+
+	kv, err := p.Get(ctx, "COLOR")
+	assert.NoError(t, err)
+	assert.Equal(t, persistence.KeyValue{Key: "COLOR", Value: "red", Revision: 1}, kv)
+
+	// everything survives a restart
+	restarted := NewFilePersistence(path, false)
+	kv, err = restarted.Get(ctx, "RETRIES")
+	assert.NoError(t, err)
+	assert.Equal(t, persistence.KeyValue{Key: "RETRIES", Value: "4", Type: persistence.TypeInteger, Revision: 2}, kv)
+	all, err := restarted.GetAll(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	assert.NoError(t, p.Delete(ctx, "COLOR"))
+	assert.NoError(t, p.Delete(ctx, "COLOR"))
+	count, _ = p.Count(ctx)
+	assert.Equal(t, 1, count)
+
+	// no temporary files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{"features.json", "features.json.lock"}, names)
+}
+
+func TestFilePersistence_YAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "features.yaml")
+	// a file as it could be written by hand, without revisions
+	require.NoError(t, os.WriteFile(path, []byte(`flags:
+  - key: BOOKING
+    value: "true"
+    type: boolean
+  - key: THEME
+    value: dark
+`), 0o644))
+	p := NewFilePersistence(path, true)
+	ctx := context.Background()
+
+	kv, err := p.Get(ctx, "BOOKING")
+	assert.NoError(t, err)
+	assert.Equal(t, persistence.KeyValue{Key: "BOOKING", Value: "true", Type: persistence.TypeBoolean, Revision: 1}, kv)
+
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "THEME", Value: "light", Revision: 1}))
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "- key: THEME\n  revision: 2\n  value: light\n")
+}
+
+func TestFilePersistence_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "features.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"flags": [`), 0o644))
+	p := NewFilePersistence(path, true)
+	ctx := context.Background()
+
+	_, err := p.GetAll(ctx)
+	assert.Error(t, err)
+	// the broken file is not overwritten
+	assert.Error(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	raw, _ := os.ReadFile(path)
+	assert.Equal(t, `{"flags": [`, string(raw))
+}
+
+func TestFilePersistence_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "features.json")
+	ctx := context.Background()
+	reloading := NewFilePersistence(path, true)
+	static := NewFilePersistence(path, false)
+	assert.NoError(t, reloading.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	kv, _ := static.Get(ctx, "COLOR")
+	assert.Equal(t, "red", kv.Value)
+
+	// an external edit is picked up with reload only
+	require.NoError(t, os.WriteFile(path, []byte(`{"flags": [{"key": "COLOR", "value": "purple", "revision": 7}]}`), 0o644))
+	kv, _ = reloading.Get(ctx, "COLOR")
+	assert.Equal(t, persistence.KeyValue{Key: "COLOR", Value: "purple", Revision: 7}, kv)
+	kv, _ = static.Get(ctx, "COLOR")
+	assert.Equal(t, "red", kv.Value)
+
+	// a write is based on the edited file
+	assert.NoError(t, reloading.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "blue", Revision: 7}))
+	kv, _ = reloading.Get(ctx, "COLOR")
+	assert.Equal(t, uint64(8), kv.Revision)
+}
+
+func TestFilePersistence_ConcurrentInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "features.json")
+	ctx := context.Background()
+	instances := []*Persistence{NewFilePersistence(path, true), NewFilePersistence(path, true)}
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Go(func() {
+			p := instances[i%len(instances)]
+			assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: fmt.Sprintf("KEY_%d", i), Value: "on"}))
+		})
+	}
+	wg.Wait()
+
+	// no write of one instance got lost by the other
+	count, err := NewFilePersistence(path, false).Count(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 20, count)
+}
+
+func TestFilePersistence_Batch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "features.json")
+	p := NewFilePersistence(path, true)
+	ctx := context.Background()
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "SIZE", Value: "large"}))
+
+	assert.NoError(t, p.Batch(ctx, []persistence.Operation{
+		{KeyValue: persistence.KeyValue{Key: "COLOR", Value: "blue", Revision: 1}},
+		{KeyValue: persistence.KeyValue{Key: "THEME", Value: "dark"}},
+		{KeyValue: persistence.KeyValue{Key: "SIZE"}, Delete: true},
+	}))
+	all, _ := NewFilePersistence(path, false).GetAll(ctx)
+	assert.ElementsMatch(t, []persistence.KeyValue{
+		{Key: "COLOR", Value: "blue", Revision: 2},
+		{Key: "THEME", Value: "dark", Revision: 1},
+	}, all)
+
+	// a failing operation leaves the file untouched
+	err := p.Batch(ctx, []persistence.Operation{
+		{KeyValue: persistence.KeyValue{Key: "THEME", Value: "light"}},
+		{KeyValue: persistence.KeyValue{Key: "COLOR", Value: "green", Revision: 1}},
+	})
+	assert.ErrorIs(t, err, persistence.ErrRevisionMismatch)
+	kv, _ := NewFilePersistence(path, false).Get(ctx, "THEME")
+	assert.Equal(t, "dark", kv.Value)
+}
+
+func TestFilePersistence_History(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "features.json")
+	p := NewFilePersistence(path, true)
+	ctx := context.Background()
+
+	assert.NoError(t, p.AppendHistory(ctx, persistence.HistoryEntry{Key: "COLOR", Action: persistence.ActionCreate, NewValue: "red"}))
+	assert.NoError(t, p.AppendHistory(ctx, persistence.HistoryEntry{Key: "COLOR", Action: persistence.ActionDelete, OldValue: "red"}))
+
+	// the history survives a restart and the delete of the key
+	entries, err := NewFilePersistence(path, false).History(ctx, "COLOR")
+	assert.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, uint64(2), entries[1].Revision)
+	assert.Equal(t, persistence.ActionDelete, entries[1].Action)
+
+	entries, err = p.History(ctx, "THEME")
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestConformance(t *testing.T) {
+	persistencetest.Run(t, func(t *testing.T) persistence.Persistence {
+		return NewFilePersistence(filepath.Join(t.TempDir(), "features.json"), true)
+	}, persistencetest.Limitations{})
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package file
+
+// lock does not lock across processes on platforms without flock, writes of the service itself
+// are still serialized by the mutex of the persistence
+func lock(path string) (func(), error) {
+	return func() {}, nil
+}
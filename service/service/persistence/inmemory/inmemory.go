@@ -3,18 +3,24 @@ package inmemory
 import (
 	"context"
 	"log/slog"
+	"maps"
+	"sync"
 
 	"github.com/dkrizic/feature/service/service/persistence"
 	"go.opentelemetry.io/otel"
 )
 
 type Persistence struct {
-	data map[string]string
+	// mu guards data and history, gRPC serves requests concurrently
+	mu      sync.RWMutex
+	data    map[string]persistence.KeyValue
+	history map[string][]persistence.HistoryEntry
 }
 
 func NewInMemoryPersistence() *Persistence {
 	return &Persistence{
-		data: make(map[string]string),
+		data:    make(map[string]persistence.KeyValue),
+		history: make(map[string][]persistence.HistoryEntry),
 	}
 }
 
@@ -22,9 +28,11 @@ func (p *Persistence) GetAll(ctx context.Context) ([]persistence.KeyValue, error
 	ctx, span := otel.Tracer("service/persistence/inmemory").Start(ctx, "GetAll")
 	defer span.End()
 
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	var result []persistence.KeyValue
-	for k, v := range p.data {
-		result = append(result, persistence.KeyValue{Key: k, Value: v})
+	for _, kv := range p.data {
+		result = append(result, kv)
 	}
 	return result, nil
 }
@@ -34,12 +42,15 @@ func (p *Persistence) PreSet(ctx context.Context, kv persistence.KeyValue) error
 	ctx, span := otel.Tracer("service/persistence/inmemory").Start(ctx, "PreSet")
 	defer span.End()
 
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	oldvalue, exist := p.data[kv.Key]
 	if !exist {
 		slog.DebugContext(ctx, "PreSetting", "key", kv.Key, "value", kv.Value)
-		p.data[kv.Key] = kv.Value
+		kv.Revision = 1
+		p.data[kv.Key] = kv
 	} else {
-		slog.InfoContext(ctx, "Key already exists, not presetting", "key", kv.Key, "value", kv.Value, "oldvalue", oldvalue)
+		slog.InfoContext(ctx, "Key already exists, not presetting", "key", kv.Key, "value", kv.Value, "oldvalue", oldvalue.Value)
 	}
 	return nil
 
@@ -49,8 +60,41 @@ func (p *Persistence) Set(ctx context.Context, kv persistence.KeyValue) error {
 	ctx, span := otel.Tracer("service/persistence/inmemory").Start(ctx, "Set")
 	defer span.End()
 
-	p.data[kv.Key] = kv.Value
-	slog.DebugContext(ctx, "Setting", "key", kv.Key, "value", kv.Value)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	revision, err := persistence.NextRevision(p.data[kv.Key].Revision, kv.Revision)
+	if err != nil {
+		return err
+	}
+	kv.Revision = revision
+	p.data[kv.Key] = kv
+	slog.DebugContext(ctx, "Setting", "key", kv.Key, "value", kv.Value, "revision", revision)
+	return nil
+}
+
+func (p *Persistence) Batch(ctx context.Context, operations []persistence.Operation) error {
+	ctx, span := otel.Tracer("service/persistence/inmemory").Start(ctx, "Batch")
+	defer span.End()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// apply to a copy so that a failing operation leaves the data untouched
+	data := maps.Clone(p.data)
+	for _, op := range operations {
+		if op.Delete {
+			delete(data, op.KeyValue.Key)
+			continue
+		}
+		kv := op.KeyValue
+		revision, err := persistence.NextRevision(data[kv.Key].Revision, kv.Revision)
+		if err != nil {
+			return err
+		}
+		kv.Revision = revision
+		data[kv.Key] = kv
+	}
+	p.data = data
+	slog.DebugContext(ctx, "Applying batch", "operations", len(operations))
 	return nil
 }
 
@@ -58,7 +102,12 @@ func (p *Persistence) Get(ctx context.Context, key string) (persistence.KeyValue
 	ctx, span := otel.Tracer("service/persistence/inmemory").Start(ctx, "Get")
 	defer span.End()
 
-	result := persistence.KeyValue{Key: key, Value: p.data[key]}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	result, exist := p.data[key]
+	if !exist {
+		return persistence.KeyValue{}, persistence.ErrKeyNotFound
+	}
 	slog.DebugContext(ctx, "Getting", "key", key, "value", result.Value)
 	return result, nil
 }
@@ -67,6 +116,8 @@ func (p *Persistence) Delete(ctx context.Context, key string) error {
 	ctx, span := otel.Tracer("service/persistence/inmemory").Start(ctx, "Delete")
 	defer span.End()
 
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	delete(p.data, key)
 	slog.DebugContext(ctx, "Deleting", "key", key)
 	return nil
@@ -76,7 +127,31 @@ func (p *Persistence) Count(ctx context.Context) (int, error) {
 	ctx, span := otel.Tracer("service/persistence/inmemory").Start(ctx, "Count")
 	defer span.End()
 
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	count := len(p.data)
 	slog.DebugContext(ctx, "Counting", "count", count)
 	return count, nil
 }
+
+func (p *Persistence) AppendHistory(ctx context.Context, entry persistence.HistoryEntry) error {
+	ctx, span := otel.Tracer("service/persistence/inmemory").Start(ctx, "AppendHistory")
+	defer span.End()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.history[entry.Key] = persistence.AppendEntry(p.history[entry.Key], entry)
+	slog.DebugContext(ctx, "Appending history", "key", entry.Key, "action", entry.Action)
+	return nil
+}
+
+func (p *Persistence) History(ctx context.Context, key string) ([]persistence.HistoryEntry, error) {
+	ctx, span := otel.Tracer("service/persistence/inmemory").Start(ctx, "History")
+	defer span.End()
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	entries := p.history[key]
+	slog.DebugContext(ctx, "Getting history", "key", key, "entries", len(entries))
+	return append([]persistence.HistoryEntry(nil), entries...), nil
+}
@@ -4,9 +4,11 @@ package inmemory
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/dkrizic/feature/service/service/persistence/persistencetest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -53,3 +55,104 @@ func TestInMemoryPersistence(t *testing.T) {
 		assert.Equal(t, expected[kv.Key], kv.Value)
 	}
 }
+
+func TestInMemoryPersistence_KeepsType(t *testing.T) {
+	ctx := context.Background()
+	p := NewInMemoryPersistence()
+
+	err := p.Set(ctx, persistence.KeyValue{
+		Key:         "MODE",
+		Value:       "fast",
+		Type:        persistence.TypeEnum,
+		Constraints: &persistence.Constraints{AllowedValues: []string{"fast", "slow"}},
+	})
+	assert.NoError(t, err)
+
+	kv, err := p.Get(ctx, "MODE")
+	assert.NoError(t, err)
+	assert.Equal(t, persistence.TypeEnum, kv.Type)
+	assert.Equal(t, []string{"fast", "slow"}, kv.Constraints.AllowedValues)
+}
+
+func TestInMemoryPersistence_Revision(t *testing.T) {
+	ctx := context.Background()
+	p := NewInMemoryPersistence()
+
+	assert.NoError(t, p.PreSet(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	kv, _ := p.Get(ctx, "COLOR")
+	assert.Equal(t, uint64(1), kv.Revision)
+
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "blue", Revision: 1}))
+	err := p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "green", Revision: 1})
+	assert.ErrorIs(t, err, persistence.ErrRevisionMismatch)
+
+	kv, _ = p.Get(ctx, "COLOR")
+	assert.Equal(t, "blue", kv.Value)
+	assert.Equal(t, uint64(2), kv.Revision)
+
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "green"}))
+	kv, _ = p.Get(ctx, "COLOR")
+	assert.Equal(t, uint64(3), kv.Revision)
+}
+
+func TestInMemoryPersistence_History(t *testing.T) {
+	ctx := context.Background()
+	p := NewInMemoryPersistence()
+
+	for i := range persistence.HistoryLimit + 5 {
+		err := p.AppendHistory(ctx, persistence.HistoryEntry{Key: "key1", Action: persistence.ActionUpdate, NewValue: fmt.Sprint(i)})
+		assert.NoError(t, err)
+	}
+	err := p.AppendHistory(ctx, persistence.HistoryEntry{Key: "key2", Action: persistence.ActionCreate})
+	assert.NoError(t, err)
+
+	// the oldest entries are dropped, revisions keep counting
+	entries, err := p.History(ctx, "key1")
+	assert.NoError(t, err)
+	assert.Len(t, entries, persistence.HistoryLimit)
+	assert.Equal(t, uint64(6), entries[0].Revision)
+	assert.Equal(t, uint64(persistence.HistoryLimit+5), entries[len(entries)-1].Revision)
+
+	entries, err = p.History(ctx, "key2")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, uint64(1), entries[0].Revision)
+
+	entries, err = p.History(ctx, "unknown")
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestInMemoryPersistence_Batch(t *testing.T) {
+	ctx := context.Background()
+	p := NewInMemoryPersistence()
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "SIZE", Value: "large"}))
+
+	assert.NoError(t, p.Batch(ctx, []persistence.Operation{
+		{KeyValue: persistence.KeyValue{Key: "COLOR", Value: "blue", Revision: 1}},
+		{KeyValue: persistence.KeyValue{Key: "SIZE"}, Delete: true},
+	}))
+	kv, _ := p.Get(ctx, "COLOR")
+	assert.Equal(t, "blue", kv.Value)
+	assert.Equal(t, uint64(2), kv.Revision)
+	count, _ := p.Count(ctx)
+	assert.Equal(t, 1, count)
+
+	// nothing is applied if an operation fails
+	err := p.Batch(ctx, []persistence.Operation{
+		{KeyValue: persistence.KeyValue{Key: "THEME", Value: "dark"}},
+		{KeyValue: persistence.KeyValue{Key: "COLOR", Value: "green", Revision: 1}},
+	})
+	assert.ErrorIs(t, err, persistence.ErrRevisionMismatch)
+	kv, _ = p.Get(ctx, "COLOR")
+	assert.Equal(t, "blue", kv.Value)
+	count, _ = p.Count(ctx)
+	assert.Equal(t, 1, count)
+}
+
+func TestConformance(t *testing.T) {
+	persistencetest.Run(t, func(t *testing.T) persistence.Persistence {
+		return NewInMemoryPersistence()
+	}, persistencetest.Limitations{})
+}
@@ -4,20 +4,22 @@ package notifying
 
 import (
 	"context"
+	"errors"
 
 	"github.com/dkrizic/feature/service/notifier"
+	"github.com/dkrizic/feature/service/service/auth"
 	"github.com/dkrizic/feature/service/service/persistence"
 )
 
 type NotifyingPersistence struct {
-	wrapped  persistence.Persistence
-	notifier notifier.Notifier
+	wrapped   persistence.Persistence
+	notifiers []notifier.Notifier
 }
 
-func NewNotifyingPersistence(wrapped persistence.Persistence, notifier notifier.Notifier) *NotifyingPersistence {
+func NewNotifyingPersistence(wrapped persistence.Persistence, notifiers ...notifier.Notifier) *NotifyingPersistence {
 	return &NotifyingPersistence{
-		wrapped:  wrapped,
-		notifier: notifier,
+		wrapped:   wrapped,
+		notifiers: notifiers,
 	}
 }
 
@@ -26,17 +28,38 @@ func (p *NotifyingPersistence) GetAll(ctx context.Context) ([]persistence.KeyVal
 }
 
 func (p *NotifyingPersistence) PreSet(ctx context.Context, kv persistence.KeyValue) error {
-	return p.wrapped.PreSet(ctx, kv)
+	existed := p.exists(ctx, kv.Key)
+	err := p.wrapped.PreSet(ctx, kv)
+	if err != nil {
+		return err
+	}
+	if existed {
+		// PreSet does not change existing keys
+		return nil
+	}
+
+	notification := notifier.CreateNotifucation(kv.Key, kv.Value)
+	if p.sensitive(ctx, kv.Key) {
+		notification.Action = notification.Action.Redact()
+	}
+	return p.notify(ctx, notification)
 }
 
 func (p *NotifyingPersistence) Set(ctx context.Context, kv persistence.KeyValue) error {
+	existed := p.exists(ctx, kv.Key)
 	err := p.wrapped.Set(ctx, kv)
 	if err != nil {
 		return err
 	}
 
 	notification := notifier.UpdateNotification(kv.Key, kv.Value)
-	return p.notifier.Notify(ctx, notification)
+	if !existed {
+		notification = notifier.CreateNotifucation(kv.Key, kv.Value)
+	}
+	if p.sensitive(ctx, kv.Key) {
+		notification.Action = notification.Action.Redact()
+	}
+	return p.notify(ctx, notification)
 }
 
 func (p *NotifyingPersistence) Delete(ctx context.Context, key string) error {
@@ -46,7 +69,44 @@ func (p *NotifyingPersistence) Delete(ctx context.Context, key string) error {
 	}
 
 	notification := notifier.DeleteNotification(key)
-	return p.notifier.Notify(ctx, notification)
+	return p.notify(ctx, notification)
+}
+
+// Batch sends a single notification grouping all changes, so that listeners see the batch as one
+func (p *NotifyingPersistence) Batch(ctx context.Context, operations []persistence.Operation) error {
+	existed := make(map[string]bool)
+	for _, op := range operations {
+		if _, ok := existed[op.KeyValue.Key]; !ok {
+			existed[op.KeyValue.Key] = p.exists(ctx, op.KeyValue.Key)
+		}
+	}
+	err := p.wrapped.Batch(ctx, operations)
+	if err != nil {
+		return err
+	}
+
+	actions := make([]notifier.Action, 0, len(operations))
+	for _, op := range operations {
+		key := op.KeyValue.Key
+		switch {
+		case op.Delete:
+			actions = append(actions, notifier.Action{Type: notifier.ActionDelete, Key: key})
+			existed[key] = false
+		case existed[key]:
+			value := op.KeyValue.Value
+			actions = append(actions, notifier.Action{Type: notifier.ActionUpdate, Key: key, Value: &value})
+		default:
+			value := op.KeyValue.Value
+			actions = append(actions, notifier.Action{Type: notifier.ActionCreate, Key: key, Value: &value})
+			existed[key] = true
+		}
+	}
+	for i, action := range actions {
+		if action.Type != notifier.ActionDelete && p.sensitive(ctx, action.Key) {
+			actions[i] = action.Redact()
+		}
+	}
+	return p.notify(ctx, notifier.BatchNotification(actions))
 }
 
 func (p *NotifyingPersistence) Get(ctx context.Context, key string) (persistence.KeyValue, error) {
@@ -56,3 +116,41 @@ func (p *NotifyingPersistence) Get(ctx context.Context, key string) (persistence
 func (p *NotifyingPersistence) Count(ctx context.Context) (int, error) {
 	return p.wrapped.Count(ctx)
 }
+
+func (p *NotifyingPersistence) AppendHistory(ctx context.Context, entry persistence.HistoryEntry) error {
+	return p.wrapped.AppendHistory(ctx, entry)
+}
+
+func (p *NotifyingPersistence) History(ctx context.Context, key string) ([]persistence.HistoryEntry, error) {
+	return p.wrapped.History(ctx, key)
+}
+
+// exists reports whether the key is stored. A backend returning an empty key value without an
+// error for a missing key is not mistaken for an existing key.
+func (p *NotifyingPersistence) exists(ctx context.Context, key string) bool {
+	kv, err := p.wrapped.Get(ctx, key)
+	if err != nil {
+		return !errors.Is(err, persistence.ErrKeyNotFound)
+	}
+	return kv.Key == key
+}
+
+// sensitive reports whether the wrapped persistence keeps the value of the key confidential, its
+// value is then left out of the notification
+func (p *NotifyingPersistence) sensitive(ctx context.Context, key string) bool {
+	kv, err := p.wrapped.Get(ctx, key)
+	return err == nil && kv.Sensitive
+}
+
+// notify sends the notification to all notifiers, even if some of them fail
+func (p *NotifyingPersistence) notify(ctx context.Context, notification notifier.Notification) error {
+	notification.Actor = auth.PrincipalFromContext(ctx)
+
+	var errs []error
+	for _, n := range p.notifiers {
+		if err := n.Notify(ctx, notification); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
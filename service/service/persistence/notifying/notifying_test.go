@@ -0,0 +1,61 @@
+package notifying
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dkrizic/feature/service/notifier"
+	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/dkrizic/feature/service/service/persistence/inmemory"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingNotifier keeps the actions of the notifications it receives
+type recordingNotifier struct {
+	actions []notifier.Action
+}
+
+func (r *recordingNotifier) Notify(_ context.Context, notification notifier.Notification) error {
+	r.actions = append(r.actions, notification.Action)
+	return nil
+}
+
+// missingAsEmpty returns an empty key value without an error for missing keys
+type missingAsEmpty struct {
+	persistence.Persistence
+}
+
+func (p missingAsEmpty) Get(ctx context.Context, key string) (persistence.KeyValue, error) {
+	kv, err := p.Persistence.Get(ctx, key)
+	if errors.Is(err, persistence.ErrKeyNotFound) {
+		return persistence.KeyValue{}, nil
+	}
+	return kv, err
+}
+
+func TestNotifyingPersistence_CreateAndUpdate(t *testing.T) {
+	backends := map[string]persistence.Persistence{
+		"inmemory":         inmemory.NewInMemoryPersistence(),
+		"missing as empty": missingAsEmpty{inmemory.NewInMemoryPersistence()},
+	}
+	for name, backend := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			recorder := &recordingNotifier{}
+			p := NewNotifyingPersistence(backend, recorder)
+
+			assert.NoError(t, p.PreSet(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+			assert.NoError(t, p.PreSet(ctx, persistence.KeyValue{Key: "COLOR", Value: "blue"}))
+			assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "green"}))
+			assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "THEME", Value: "dark"}))
+
+			red, green, dark := "red", "green", "dark"
+			assert.Equal(t, []notifier.Action{
+				{Type: notifier.ActionCreate, Key: "COLOR", Value: &red},
+				{Type: notifier.ActionUpdate, Key: "COLOR", Value: &green},
+				{Type: notifier.ActionCreate, Key: "THEME", Value: &dark},
+			}, recorder.actions)
+		})
+	}
+}
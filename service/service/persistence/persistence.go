@@ -1,24 +1,212 @@
 package persistence
 
-import "context"
+import (
+	"context"
+	"time"
+)
+
+// ValueType is the declared type of a flag value
+type ValueType string
+
+const (
+	TypeString  ValueType = "string"
+	TypeBoolean ValueType = "boolean"
+	TypeInteger ValueType = "integer"
+	TypeFloat   ValueType = "float"
+	TypeEnum    ValueType = "enum"
+	TypeJSON    ValueType = "json"
+)
+
+// Constraints restrict the values a typed flag accepts
+type Constraints struct {
+	Min           *float64 `json:"min,omitempty"`
+	Max           *float64 `json:"max,omitempty"`
+	Pattern       string   `json:"pattern,omitempty"`
+	AllowedValues []string `json:"allowedValues,omitempty"`
+	JSONSchema    string   `json:"jsonSchema,omitempty"`
+}
+
+// Lifecycle tells whether a flag is meant to be removed again, flags without one are treated as
+// temporary
+type Lifecycle string
+
+const (
+	LifecycleTemporary Lifecycle = "temporary"
+	LifecyclePermanent Lifecycle = "permanent"
+)
+
+// Metadata documents a flag
+type Metadata struct {
+	Description    string    `json:"description,omitempty"`
+	Owner          string    `json:"owner,omitempty"`
+	Tags           []string  `json:"tags,omitempty"`
+	CreatedAt      time.Time `json:"createdAt,omitzero"`
+	UpdatedAt      time.Time `json:"updatedAt,omitzero"`
+	LastModifiedBy string    `json:"lastModifiedBy,omitempty"`
+	// ExpiresAt is when the flag should be gone, a flag past it is stale
+	ExpiresAt time.Time `json:"expiresAt,omitzero"`
+	Lifecycle Lifecycle `json:"lifecycle,omitempty"`
+}
+
+func (m Metadata) IsZero() bool {
+	return m.Description == "" && m.Owner == "" && len(m.Tags) == 0 &&
+		m.CreatedAt.IsZero() && m.UpdatedAt.IsZero() && m.LastModifiedBy == "" &&
+		m.ExpiresAt.IsZero() && m.Lifecycle == ""
+}
+
+// Operator compares an attribute of the evaluation context with the values of a condition
+type Operator string
+
+const (
+	OperatorEquals               Operator = "equals"
+	OperatorNotEquals            Operator = "not_equals"
+	OperatorIn                   Operator = "in"
+	OperatorNotIn                Operator = "not_in"
+	OperatorRegex                Operator = "regex"
+	OperatorSemverEquals         Operator = "semver_equals"
+	OperatorSemverLessThan       Operator = "semver_less_than"
+	OperatorSemverLessOrEqual    Operator = "semver_less_or_equal"
+	OperatorSemverGreaterThan    Operator = "semver_greater_than"
+	OperatorSemverGreaterOrEqual Operator = "semver_greater_or_equal"
+)
+
+// Condition tests one attribute of the evaluation context
+type Condition struct {
+	Attribute string   `json:"attribute"`
+	Operator  Operator `json:"operator"`
+	Values    []string `json:"values"`
+}
+
+// Variant serves Value to Percentage (0 to 100) of the callers
+type Variant struct {
+	Value      string  `json:"value"`
+	Percentage float64 `json:"percentage"`
+}
+
+// Rollout splits callers into sticky buckets by hashing the BucketBy attribute with the flag key
+type Rollout struct {
+	BucketBy string    `json:"bucketBy,omitempty"`
+	Variants []Variant `json:"variants"`
+}
+
+// Rule serves Value if all of its conditions match, or a variant of its Rollout
+type Rule struct {
+	Name       string      `json:"name,omitempty"`
+	Conditions []Condition `json:"conditions"`
+	Value      string      `json:"value"`
+	Rollout    *Rollout    `json:"rollout,omitempty"`
+}
+
+// Prerequisite requires the flag Key of the same set and environment to evaluate to Value
+type Prerequisite struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
 
 type KeyValue struct {
 	Key   string
 	Value string
+	// Type, Constraints and Metadata are stored alongside the value, an empty Type is an untyped string
+	Type        ValueType
+	Constraints *Constraints
+	Metadata    Metadata
+	// Rules are the ordered targeting rules, the first matching rule wins over Value
+	Rules []Rule
+	// Rollout serves variants of Value to a percentage of the callers no rule matched
+	Rollout *Rollout
+	// Prerequisites must all be met before rules, rollout and Value apply, otherwise the flag
+	// evaluates to OffValue
+	Prerequisites []Prerequisite
+	// OffValue is served if a prerequisite is not met, empty means false for boolean flags
+	OffValue string
+	// Revision counts the writes of the key and is assigned by the backend. A non-zero Revision
+	// makes Set conditional, it fails with ErrRevisionMismatch unless the stored revision matches.
+	Revision uint64
+	// Sensitive is reported by backends that keep their values confidential, like the Secret
+	// backend, it is not stored. The values of sensitive keys are only shown to callers allowed
+	// to reveal them.
+	Sensitive bool
+}
+
+// Action is the kind of change a history entry records
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// HistoryLimit is the number of entries a backend keeps per key, older entries are dropped
+const HistoryLimit = 100
+
+// HistoryEntry records one change of a key
+type HistoryEntry struct {
+	Key string `json:"key"`
+	// Revision numbers the changes of a key starting at 1, it is assigned by the backend
+	Revision  uint64    `json:"revision"`
+	Action    Action    `json:"action"`
+	OldValue  string    `json:"oldValue,omitempty"`
+	NewValue  string    `json:"newValue,omitempty"`
+	Actor     string    `json:"actor,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	// Source is the RPC (or the operation outside of an RPC) that made the change
+	Source string `json:"source"`
+	// Sensitive is reported like KeyValue.Sensitive, for the values of the entry
+	Sensitive bool `json:"-"`
+}
+
+// AppendEntry appends an entry with the next revision and drops the oldest entries beyond HistoryLimit
+func AppendEntry(entries []HistoryEntry, entry HistoryEntry) []HistoryEntry {
+	entry.Revision = 1
+	if len(entries) > 0 {
+		entry.Revision = entries[len(entries)-1].Revision + 1
+	}
+	entries = append(entries, entry)
+	if len(entries) > HistoryLimit {
+		entries = entries[len(entries)-HistoryLimit:]
+	}
+	return entries
+}
+
+// NextRevision checks a write against the current revision of a key, 0 if the key does not exist,
+// and returns the revision to store. A requested revision of 0 writes unconditionally.
+func NextRevision(current, requested uint64) (uint64, error) {
+	if requested != 0 && requested != current {
+		return 0, ErrRevisionMismatch
+	}
+	return current + 1, nil
 }
 
+// Operation is one change of a batch, it deletes KeyValue.Key if Delete is set and sets KeyValue otherwise
+type Operation struct {
+	KeyValue KeyValue
+	Delete   bool
+}
+
+// Persistence stores the key values. Implementations are used from concurrent requests and must be
+// safe for concurrent use, the persistencetest package checks the behaviour they have in common.
 type Persistence interface {
 	GetAll(context.Context) ([]KeyValue, error)
 	PreSet(context.Context, KeyValue) error
 	Set(context.Context, KeyValue) error
+	// Get returns ErrKeyNotFound for a missing key
 	Get(context.Context, string) (KeyValue, error)
+	// Delete removes a key, deleting a missing key is not an error
 	Delete(context.Context, string) error
 	Count(context.Context) (int, error)
+	// AppendHistory records a change with the next revision of its key
+	AppendHistory(context.Context, HistoryEntry) error
+	// History returns the recorded changes of a key, oldest first, also after the key was deleted
+	History(context.Context, string) ([]HistoryEntry, error)
+	// Batch applies all operations in order or, if any of them fails, none of them
+	Batch(context.Context, []Operation) error
 }
 
 // errors
 var (
-	ErrKeyNotFound = &KeyNotFoundError{}
+	ErrKeyNotFound      = &KeyNotFoundError{}
+	ErrRevisionMismatch = &RevisionMismatchError{}
 )
 
 type KeyNotFoundError struct{}
@@ -26,3 +214,9 @@ type KeyNotFoundError struct{}
 func (e *KeyNotFoundError) Error() string {
 	return "key not found"
 }
+
+type RevisionMismatchError struct{}
+
+func (e *RevisionMismatchError) Error() string {
+	return "revision does not match"
+}
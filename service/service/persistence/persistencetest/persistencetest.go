@@ -0,0 +1,400 @@
+// Package persistencetest holds the conformance tests every persistence.Persistence implementation
+// has to pass. Backends run them from their own tests, e.g.
+//
+//	func TestConformance(t *testing.T) {
+//		persistencetest.Run(t, func(t *testing.T) persistence.Persistence { return New() }, persistencetest.Limitations{})
+//	}
+//
+// The tests include concurrent calls, run them with the race detector.
+package persistencetest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Limitations lists the documented deviations of a backend from the interface, the zero value
+// expects none
+type Limitations struct {
+	// UndetectedConflicts is set if the test setup cannot detect concurrent writes of the same
+	// key, e.g. a fake Kubernetes client that ignores the resourceVersion
+	UndetectedConflicts bool
+	// Sensitive is set if the backend reports all its key values and history entries as sensitive
+	Sensitive bool
+}
+
+// Run runs the conformance tests, newPersistence returns an empty persistence for every test
+func Run(t *testing.T, newPersistence func(t *testing.T) persistence.Persistence, limitations Limitations) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, p persistence.Persistence, limitations Limitations)
+	}{
+		{"Empty", testEmpty},
+		{"SetAndGet", testSetAndGet},
+		{"PreSet", testPreSet},
+		{"Revision", testRevision},
+		{"Delete", testDelete},
+		{"Batch", testBatch},
+		{"History", testHistory},
+		{"ConcurrentKeys", testConcurrentKeys},
+		{"ConcurrentWrites", testConcurrentWrites},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPersistence(t)
+			if limitations.Sensitive {
+				p = &sensitive{Persistence: p, t: t}
+			}
+			tt.test(t, p, limitations)
+		})
+	}
+}
+
+// RunPartitions checks that two flag sets sharing a backend keep their keys apart, also keys that
+// could map to the same storage name, like the key flag of the set and the key <set>.flag of the
+// default set. newPartitions returns the default set and the set with the name.
+func RunPartitions(t *testing.T, set string, newPartitions func(t *testing.T) (persistence.Persistence, persistence.Persistence)) {
+	ctx := context.Background()
+	defaultSet, other := newPartitions(t)
+	colliding := set + ".flag"
+
+	require.NoError(t, defaultSet.Set(ctx, persistence.KeyValue{Key: colliding, Value: "default"}))
+	require.NoError(t, other.Set(ctx, persistence.KeyValue{Key: "flag", Value: set}))
+	require.NoError(t, defaultSet.AppendHistory(ctx, persistence.HistoryEntry{Key: colliding, Action: persistence.ActionCreate, NewValue: "default"}))
+	require.NoError(t, other.AppendHistory(ctx, persistence.HistoryEntry{Key: "flag", Action: persistence.ActionCreate, NewValue: set}))
+
+	kv, err := defaultSet.Get(ctx, colliding)
+	assert.NoError(t, err)
+	assert.Equal(t, persistence.KeyValue{Key: colliding, Value: "default", Revision: 1}, kv)
+	kv, err = other.Get(ctx, "flag")
+	assert.NoError(t, err)
+	assert.Equal(t, persistence.KeyValue{Key: "flag", Value: set, Revision: 1}, kv)
+	_, err = defaultSet.Get(ctx, "flag")
+	assert.ErrorIs(t, err, persistence.ErrKeyNotFound)
+	_, err = other.Get(ctx, colliding)
+	assert.ErrorIs(t, err, persistence.ErrKeyNotFound)
+
+	all, err := defaultSet.GetAll(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []persistence.KeyValue{{Key: colliding, Value: "default", Revision: 1}}, all)
+	all, err = other.GetAll(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []persistence.KeyValue{{Key: "flag", Value: set, Revision: 1}}, all)
+
+	entries, err := defaultSet.History(ctx, colliding)
+	assert.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "default", entries[0].NewValue)
+
+	// deleting the key of one set leaves the other alone
+	require.NoError(t, other.Delete(ctx, "flag"))
+	kv, err = defaultSet.Get(ctx, colliding)
+	assert.NoError(t, err)
+	assert.Equal(t, "default", kv.Value)
+	entries, err = other.History(ctx, "flag")
+	assert.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, set, entries[0].NewValue)
+}
+
+// sensitive checks that a backend reports everything as sensitive and clears the marker, so the
+// tests compare the stored data only
+type sensitive struct {
+	persistence.Persistence
+	t *testing.T
+}
+
+func (s *sensitive) GetAll(ctx context.Context) ([]persistence.KeyValue, error) {
+	values, err := s.Persistence.GetAll(ctx)
+	for i := range values {
+		assert.True(s.t, values[i].Sensitive, "key value %s is not sensitive", values[i].Key)
+		values[i].Sensitive = false
+	}
+	return values, err
+}
+
+func (s *sensitive) Get(ctx context.Context, key string) (persistence.KeyValue, error) {
+	kv, err := s.Persistence.Get(ctx, key)
+	if err == nil {
+		assert.True(s.t, kv.Sensitive, "key value %s is not sensitive", key)
+		kv.Sensitive = false
+	}
+	return kv, err
+}
+
+func (s *sensitive) History(ctx context.Context, key string) ([]persistence.HistoryEntry, error) {
+	entries, err := s.Persistence.History(ctx, key)
+	for i := range entries {
+		assert.True(s.t, entries[i].Sensitive, "history entry %d of %s is not sensitive", entries[i].Revision, key)
+		entries[i].Sensitive = false
+	}
+	return entries, err
+}
+
+func testEmpty(t *testing.T, p persistence.Persistence, _ Limitations) {
+	ctx := context.Background()
+
+	all, err := p.GetAll(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, all)
+	count, err := p.Count(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+	_, err = p.Get(ctx, "COLOR")
+	assert.ErrorIs(t, err, persistence.ErrKeyNotFound)
+	entries, err := p.History(ctx, "COLOR")
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+	// deleting a missing key is no error
+	assert.NoError(t, p.Delete(ctx, "COLOR"))
+}
+
+func testSetAndGet(t *testing.T, p persistence.Persistence, _ Limitations) {
+	ctx := context.Background()
+	max := 10.0
+	kv := persistence.KeyValue{
+		Key:         "RETRIES",
+		Value:       "3",
+		Type:        persistence.TypeInteger,
+		Constraints: &persistence.Constraints{Max: &max},
+		Metadata: persistence.Metadata{
+			Description:    "Retries of the checkout",
+			Owner:          "team-checkout",
+			Tags:           []string{"checkout", "payment"},
+			CreatedAt:      time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+			UpdatedAt:      time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC),
+			LastModifiedBy: "admin",
+		},
+		Rules: []persistence.Rule{{Name: "beta", Value: "5", Conditions: []persistence.Condition{
+			{Attribute: "tenant", Operator: persistence.OperatorIn, Values: []string{"acme", "globex"}},
+		}}},
+		Rollout:       &persistence.Rollout{BucketBy: "userId", Variants: []persistence.Variant{{Value: "4", Percentage: 25}}},
+		Prerequisites: []persistence.Prerequisite{{Key: "CHECKOUT", Value: "true"}},
+		OffValue:      "0",
+	}
+	require.NoError(t, p.Set(ctx, kv))
+	require.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+
+	// everything stored for a key comes back
+	kv.Revision = 1
+	got, err := p.Get(ctx, "RETRIES")
+	assert.NoError(t, err)
+	assert.Equal(t, kv, got)
+
+	all, err := p.GetAll(ctx)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []persistence.KeyValue{kv, {Key: "COLOR", Value: "red", Revision: 1}}, all)
+	count, err := p.Count(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// a write replaces the attributes of the key
+	require.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "RETRIES", Value: "4"}))
+	got, err = p.Get(ctx, "RETRIES")
+	assert.NoError(t, err)
+	assert.Equal(t, persistence.KeyValue{Key: "RETRIES", Value: "4", Revision: 2}, got)
+}
+
+func testPreSet(t *testing.T, p persistence.Persistence, _ Limitations) {
+	ctx := context.Background()
+
+	require.NoError(t, p.PreSet(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	// PreSet does not change an existing key
+	require.NoError(t, p.PreSet(ctx, persistence.KeyValue{Key: "COLOR", Value: "green"}))
+
+	got, err := p.Get(ctx, "COLOR")
+	assert.NoError(t, err)
+	assert.Equal(t, persistence.KeyValue{Key: "COLOR", Value: "red", Revision: 1}, got)
+}
+
+func testRevision(t *testing.T, p persistence.Persistence, _ Limitations) {
+	ctx := context.Background()
+
+	require.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	require.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "blue", Revision: 1}))
+	err := p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "green", Revision: 1})
+	assert.ErrorIs(t, err, persistence.ErrRevisionMismatch)
+	// a conditional write of a missing key fails
+	err = p.Set(ctx, persistence.KeyValue{Key: "THEME", Value: "dark", Revision: 1})
+	assert.ErrorIs(t, err, persistence.ErrRevisionMismatch)
+
+	got, err := p.Get(ctx, "COLOR")
+	assert.NoError(t, err)
+	assert.Equal(t, persistence.KeyValue{Key: "COLOR", Value: "blue", Revision: 2}, got)
+	_, err = p.Get(ctx, "THEME")
+	assert.ErrorIs(t, err, persistence.ErrKeyNotFound)
+}
+
+func testDelete(t *testing.T, p persistence.Persistence, _ Limitations) {
+	ctx := context.Background()
+
+	require.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red", Metadata: persistence.Metadata{Owner: "shop"}}))
+	require.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "blue"}))
+	require.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "THEME", Value: "dark"}))
+	require.NoError(t, p.Delete(ctx, "COLOR"))
+
+	_, err := p.Get(ctx, "COLOR")
+	assert.ErrorIs(t, err, persistence.ErrKeyNotFound)
+	all, err := p.GetAll(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []persistence.KeyValue{{Key: "THEME", Value: "dark", Revision: 1}}, all)
+	count, err := p.Count(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// a deleted key starts over
+	require.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "green"}))
+	got, err := p.Get(ctx, "COLOR")
+	assert.NoError(t, err)
+	assert.Equal(t, persistence.KeyValue{Key: "COLOR", Value: "green", Revision: 1}, got)
+}
+
+func testBatch(t *testing.T, p persistence.Persistence, _ Limitations) {
+	ctx := context.Background()
+	require.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	require.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "SIZE", Value: "large"}))
+
+	require.NoError(t, p.Batch(ctx, []persistence.Operation{
+		{KeyValue: persistence.KeyValue{Key: "COLOR", Value: "blue", Revision: 1}},
+		{KeyValue: persistence.KeyValue{Key: "THEME", Value: "dark", Type: persistence.TypeString}},
+		{KeyValue: persistence.KeyValue{Key: "SIZE"}, Delete: true},
+	}))
+	all, err := p.GetAll(ctx)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []persistence.KeyValue{
+		{Key: "COLOR", Value: "blue", Revision: 2},
+		{Key: "THEME", Value: "dark", Type: persistence.TypeString, Revision: 1},
+	}, all)
+
+	// a failing operation leaves everything as it was
+	err = p.Batch(ctx, []persistence.Operation{
+		{KeyValue: persistence.KeyValue{Key: "THEME", Value: "light"}},
+		{KeyValue: persistence.KeyValue{Key: "COLOR"}, Delete: true},
+		{KeyValue: persistence.KeyValue{Key: "SIZE", Value: "small", Revision: 3}},
+	})
+	assert.ErrorIs(t, err, persistence.ErrRevisionMismatch)
+	after, err := p.GetAll(ctx)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, all, after)
+}
+
+func testHistory(t *testing.T, p persistence.Persistence, _ Limitations) {
+	ctx := context.Background()
+	timestamp := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+
+	for i := range persistence.HistoryLimit + 2 {
+		require.NoError(t, p.AppendHistory(ctx, persistence.HistoryEntry{
+			Key: "COLOR", Action: persistence.ActionUpdate, OldValue: fmt.Sprint(i), NewValue: fmt.Sprint(i + 1),
+			Actor: "admin", Timestamp: timestamp, Source: "Set",
+		}))
+	}
+	require.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "THEME", Value: "dark"}))
+	require.NoError(t, p.AppendHistory(ctx, persistence.HistoryEntry{Key: "THEME", Action: persistence.ActionCreate, NewValue: "dark", Timestamp: timestamp}))
+
+	// the oldest entries are dropped beyond the limit, the revisions go on
+	entries, err := p.History(ctx, "COLOR")
+	assert.NoError(t, err)
+	require.Len(t, entries, persistence.HistoryLimit)
+	assert.Equal(t, uint64(3), entries[0].Revision)
+	assert.Equal(t, persistence.HistoryEntry{
+		Key: "COLOR", Revision: persistence.HistoryLimit + 2, Action: persistence.ActionUpdate,
+		OldValue: fmt.Sprint(persistence.HistoryLimit + 1), NewValue: fmt.Sprint(persistence.HistoryLimit + 2),
+		Actor: "admin", Timestamp: timestamp, Source: "Set",
+	}, entries[len(entries)-1])
+
+	// every key has its own history
+	entries, err = p.History(ctx, "THEME")
+	assert.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, uint64(1), entries[0].Revision)
+
+	require.NoError(t, p.Delete(ctx, "THEME"))
+	require.NoError(t, p.AppendHistory(ctx, persistence.HistoryEntry{Key: "THEME", Action: persistence.ActionDelete, OldValue: "dark", Timestamp: timestamp}))
+	entries, err = p.History(ctx, "THEME")
+	assert.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, persistence.ActionDelete, entries[1].Action)
+}
+
+// testConcurrentKeys changes different keys at the same time while others read
+func testConcurrentKeys(t *testing.T, p persistence.Persistence, _ Limitations) {
+	ctx := context.Background()
+	const writers = 8
+
+	var wg sync.WaitGroup
+	for i := range writers {
+		key := fmt.Sprintf("KEY_%d", i)
+		wg.Go(func() {
+			assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: key, Value: "1"}))
+			assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: key, Value: "2", Revision: 1}))
+			assert.NoError(t, p.Delete(ctx, key))
+			assert.NoError(t, p.PreSet(ctx, persistence.KeyValue{Key: key, Value: "3"}))
+			assert.NoError(t, p.AppendHistory(ctx, persistence.HistoryEntry{Key: key, Action: persistence.ActionCreate, NewValue: "3", Timestamp: time.Now().UTC()}))
+		})
+		wg.Go(func() {
+			for range 5 {
+				_, err := p.GetAll(ctx)
+				assert.NoError(t, err)
+				_, err = p.Count(ctx)
+				assert.NoError(t, err)
+				_, err = p.History(ctx, key)
+				assert.NoError(t, err)
+			}
+		})
+	}
+	wg.Wait()
+
+	for i := range writers {
+		key := fmt.Sprintf("KEY_%d", i)
+		got, err := p.Get(ctx, key)
+		assert.NoError(t, err)
+		assert.Equal(t, persistence.KeyValue{Key: key, Value: "3", Revision: 1}, got)
+		entries, err := p.History(ctx, key)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+	}
+	count, err := p.Count(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, writers, count)
+}
+
+// testConcurrentWrites writes the same key at the same time, no write may get lost
+func testConcurrentWrites(t *testing.T, p persistence.Persistence, limitations Limitations) {
+	ctx := context.Background()
+	const writers = 4
+	const writes = 5
+
+	var mu sync.Mutex
+	succeeded := 0
+	var wg sync.WaitGroup
+	for i := range writers {
+		wg.Go(func() {
+			for j := range writes {
+				// a backend may give up on a heavily contended key, but must not lose a write it reported
+				if err := p.Set(ctx, persistence.KeyValue{Key: "COUNTER", Value: fmt.Sprintf("%d-%d", i, j)}); err == nil {
+					mu.Lock()
+					succeeded++
+					mu.Unlock()
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	require.Positive(t, succeeded)
+	got, err := p.Get(ctx, "COUNTER")
+	require.NoError(t, err)
+	if limitations.UndetectedConflicts {
+		assert.LessOrEqual(t, got.Revision, uint64(succeeded))
+		return
+	}
+	assert.Equal(t, uint64(succeeded), got.Revision)
+}
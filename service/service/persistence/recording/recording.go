@@ -0,0 +1,171 @@
+package recording
+
+// implements the persistence interface and wraps another persistence to record every change in its history
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/dkrizic/feature/service/service/auth"
+	"github.com/dkrizic/feature/service/service/persistence"
+	"google.golang.org/grpc"
+)
+
+type RecordingPersistence struct {
+	wrapped persistence.Persistence
+}
+
+func NewRecordingPersistence(wrapped persistence.Persistence) *RecordingPersistence {
+	return &RecordingPersistence{
+		wrapped: wrapped,
+	}
+}
+
+func (p *RecordingPersistence) GetAll(ctx context.Context) ([]persistence.KeyValue, error) {
+	return p.wrapped.GetAll(ctx)
+}
+
+func (p *RecordingPersistence) PreSet(ctx context.Context, kv persistence.KeyValue) error {
+	_, existed := p.lookup(ctx, kv.Key)
+	err := p.wrapped.PreSet(ctx, kv)
+	if err != nil {
+		return err
+	}
+	if existed {
+		// PreSet does not change existing keys
+		return nil
+	}
+
+	p.record(ctx, "PreSet", persistence.HistoryEntry{Key: kv.Key, Action: persistence.ActionCreate, NewValue: kv.Value})
+	return nil
+}
+
+func (p *RecordingPersistence) Set(ctx context.Context, kv persistence.KeyValue) error {
+	old, existed := p.lookup(ctx, kv.Key)
+	err := p.wrapped.Set(ctx, kv)
+	if err != nil {
+		return err
+	}
+
+	entry := persistence.HistoryEntry{Key: kv.Key, Action: persistence.ActionCreate, NewValue: kv.Value}
+	if existed {
+		entry.Action = persistence.ActionUpdate
+		entry.OldValue = old.Value
+	}
+	p.record(ctx, "Set", entry)
+	return nil
+}
+
+func (p *RecordingPersistence) Delete(ctx context.Context, key string) error {
+	old, existed := p.lookup(ctx, key)
+	err := p.wrapped.Delete(ctx, key)
+	if err != nil {
+		return err
+	}
+	if !existed {
+		return nil
+	}
+
+	p.record(ctx, "Delete", persistence.HistoryEntry{Key: key, Action: persistence.ActionDelete, OldValue: old.Value})
+	return nil
+}
+
+// Batch records an entry for every operation that changed a key, like the single operations would
+func (p *RecordingPersistence) Batch(ctx context.Context, operations []persistence.Operation) error {
+	before := make(map[string]persistence.KeyValue)
+	if all, err := p.wrapped.GetAll(ctx); err == nil {
+		for _, kv := range all {
+			before[kv.Key] = kv
+		}
+	}
+	err := p.wrapped.Batch(ctx, operations)
+	if err != nil {
+		return err
+	}
+
+	for _, op := range operations {
+		key := op.KeyValue.Key
+		old, existed := before[key]
+		if op.Delete {
+			if existed {
+				p.record(ctx, "Batch", persistence.HistoryEntry{Key: key, Action: persistence.ActionDelete, OldValue: old.Value})
+			}
+			delete(before, key)
+			continue
+		}
+
+		entry := persistence.HistoryEntry{Key: key, Action: persistence.ActionCreate, NewValue: op.KeyValue.Value}
+		if existed {
+			entry.Action = persistence.ActionUpdate
+			entry.OldValue = old.Value
+		}
+		p.record(ctx, "Batch", entry)
+		before[key] = op.KeyValue
+	}
+	return nil
+}
+
+func (p *RecordingPersistence) Get(ctx context.Context, key string) (persistence.KeyValue, error) {
+	return p.wrapped.Get(ctx, key)
+}
+
+func (p *RecordingPersistence) Count(ctx context.Context) (int, error) {
+	return p.wrapped.Count(ctx)
+}
+
+func (p *RecordingPersistence) AppendHistory(ctx context.Context, entry persistence.HistoryEntry) error {
+	return p.wrapped.AppendHistory(ctx, entry)
+}
+
+func (p *RecordingPersistence) History(ctx context.Context, key string) ([]persistence.HistoryEntry, error) {
+	return p.wrapped.History(ctx, key)
+}
+
+// lookup finds the current state of a key, a missing key is reported as not existing
+func (p *RecordingPersistence) lookup(ctx context.Context, key string) (persistence.KeyValue, bool) {
+	kv, err := p.wrapped.Get(ctx, key)
+	if errors.Is(err, persistence.ErrKeyNotFound) {
+		return persistence.KeyValue{}, false
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to look up key for history", "key", key, "error", err)
+		return persistence.KeyValue{}, false
+	}
+	return kv, true
+}
+
+// record appends the entry to the history. The change itself has already been made, so a
+// failure is only logged.
+func (p *RecordingPersistence) record(ctx context.Context, operation string, entry persistence.HistoryEntry) {
+	entry.Actor = auth.PrincipalFromContext(ctx)
+	entry.Timestamp = time.Now().UTC()
+	entry.Source = source(ctx, operation)
+
+	if err := p.wrapped.AppendHistory(ctx, entry); err != nil {
+		slog.ErrorContext(ctx, "Failed to record history", "key", entry.Key, "action", entry.Action, "error", err)
+	}
+}
+
+type sourceKey struct{}
+
+// WithSource returns a context whose changes are recorded with the given source instead of the
+// RPC, e.g. Schedule for changes applied by the scheduler
+func WithSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+// source returns the source set by WithSource, the name of the RPC being served, e.g. SetRules,
+// or the operation outside of an RPC
+func source(ctx context.Context, operation string) string {
+	if source, ok := ctx.Value(sourceKey{}).(string); ok {
+		return source
+	}
+	method, ok := grpc.Method(ctx)
+	if !ok {
+		return operation
+	}
+	return method[strings.LastIndex(method, "/")+1:]
+}
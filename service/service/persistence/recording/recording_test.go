@@ -0,0 +1,99 @@
+package recording
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dkrizic/feature/service/service/auth"
+	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/dkrizic/feature/service/service/persistence/inmemory"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// fakeTransportStream makes grpc.Method report an RPC like the gRPC server does
+type fakeTransportStream struct {
+	method string
+}
+
+func (f *fakeTransportStream) Method() string                  { return f.method }
+func (f *fakeTransportStream) SetHeader(md metadata.MD) error  { return nil }
+func (f *fakeTransportStream) SendHeader(md metadata.MD) error { return nil }
+func (f *fakeTransportStream) SetTrailer(md metadata.MD) error { return nil }
+
+func rpcContext(method string) context.Context {
+	ctx := auth.WithPrincipal(context.Background(), "alice")
+	return grpc.NewContextWithServerTransportStream(ctx, &fakeTransportStream{method: method})
+}
+
+func TestRecordingPersistence(t *testing.T) {
+	ctx := context.Background()
+	p := NewRecordingPersistence(inmemory.NewInMemoryPersistence())
+
+	assert.NoError(t, p.PreSet(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+	// PreSet of an existing key changes nothing and is not recorded
+	assert.NoError(t, p.PreSet(ctx, persistence.KeyValue{Key: "COLOR", Value: "green"}))
+	assert.NoError(t, p.Set(rpcContext("/feature.v1.Feature/Set"), persistence.KeyValue{Key: "COLOR", Value: "blue"}))
+	assert.NoError(t, p.Set(rpcContext("/feature.v1.Feature/SetRules"), persistence.KeyValue{Key: "COLOR", Value: "blue"}))
+	assert.NoError(t, p.Delete(rpcContext("/feature.v1.Feature/Delete"), "COLOR"))
+	// deleting a missing key is not recorded
+	assert.NoError(t, p.Delete(ctx, "COLOR"))
+	assert.NoError(t, p.Set(WithSource(rpcContext("/feature.v1.Feature/Set"), "Schedule"), persistence.KeyValue{Key: "COLOR", Value: "green"}))
+
+	entries, err := p.History(ctx, "COLOR")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 5)
+
+	assert.Equal(t, persistence.ActionCreate, entries[0].Action)
+	assert.Equal(t, "red", entries[0].NewValue)
+	assert.Equal(t, "PreSet", entries[0].Source)
+	assert.Empty(t, entries[0].Actor)
+	assert.False(t, entries[0].Timestamp.IsZero())
+
+	assert.Equal(t, persistence.HistoryEntry{
+		Key:       "COLOR",
+		Revision:  2,
+		Action:    persistence.ActionUpdate,
+		OldValue:  "red",
+		NewValue:  "blue",
+		Actor:     "alice",
+		Timestamp: entries[1].Timestamp,
+		Source:    "Set",
+	}, entries[1])
+	assert.Equal(t, "SetRules", entries[2].Source)
+
+	assert.Equal(t, persistence.ActionDelete, entries[3].Action)
+	assert.Equal(t, "blue", entries[3].OldValue)
+	assert.Equal(t, uint64(4), entries[3].Revision)
+	assert.Equal(t, "Schedule", entries[4].Source)
+}
+
+func TestRecordingPersistence_Batch(t *testing.T) {
+	ctx := context.Background()
+	p := NewRecordingPersistence(inmemory.NewInMemoryPersistence())
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "COLOR", Value: "red"}))
+
+	assert.NoError(t, p.Batch(rpcContext("/feature.v1.Feature/Batch"), []persistence.Operation{
+		{KeyValue: persistence.KeyValue{Key: "COLOR", Value: "blue"}},
+		{KeyValue: persistence.KeyValue{Key: "SIZE", Value: "large"}},
+		{KeyValue: persistence.KeyValue{Key: "THEME"}, Delete: true},
+	}))
+
+	entries, err := p.History(ctx, "COLOR")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, persistence.ActionUpdate, entries[1].Action)
+	assert.Equal(t, "red", entries[1].OldValue)
+	assert.Equal(t, "blue", entries[1].NewValue)
+	assert.Equal(t, "Batch", entries[1].Source)
+	assert.Equal(t, "alice", entries[1].Actor)
+
+	entries, _ = p.History(ctx, "SIZE")
+	assert.Len(t, entries, 1)
+	assert.Equal(t, persistence.ActionCreate, entries[0].Action)
+
+	// deleting a missing key is not recorded
+	entries, _ = p.History(ctx, "THEME")
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,478 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/dkrizic/feature/service/service/persistence"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+)
+
+// secretClient is an interface for Secret operations to allow testing
+type secretClient interface {
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.Secret, error)
+	Create(ctx context.Context, secret *v1.Secret, opts metav1.CreateOptions) (*v1.Secret, error)
+	Update(ctx context.Context, secret *v1.Secret, opts metav1.UpdateOptions) (*v1.Secret, error)
+}
+
+// attributesKey holds type, constraints, metadata, rules and rollout of the flags as a JSON object
+// keyed by flag key. Rules and rollouts serve values too, so unlike with the ConfigMap backend they
+// are kept in the data of the Secret. The leading dot is no valid environment variable name, so
+// envFrom skips it.
+const attributesKey = ".attributes"
+
+// revisionsAnnotation holds the revision of every flag as a JSON object keyed by flag key
+const revisionsAnnotation = "feature.dkrizic.github.com/revisions"
+
+// historySuffix is appended to the Secret name to name the Secret holding the history, one JSON
+// array of entries per key. The history holds old values, so it is a Secret as well.
+const historySuffix = "-history"
+
+// errUnchanged ends a modification without updating the Secret
+var errUnchanged = errors.New("unchanged")
+
+// attributes is everything stored for a key besides its value
+type attributes struct {
+	Type          persistence.ValueType      `json:"type,omitempty"`
+	Constraints   *persistence.Constraints   `json:"constraints,omitempty"`
+	Metadata      persistence.Metadata       `json:"metadata,omitzero"`
+	Rules         []persistence.Rule         `json:"rules,omitempty"`
+	Rollout       *persistence.Rollout       `json:"rollout,omitempty"`
+	Prerequisites []persistence.Prerequisite `json:"prerequisites,omitempty"`
+	OffValue      string                     `json:"offValue,omitempty"`
+}
+
+// Persistence stores the flags in a Secret of the own namespace, one data entry per flag. All
+// values it returns are sensitive.
+type Persistence struct {
+	secretName string
+
+	// mu guards client, it is created on first use
+	mu     sync.Mutex
+	client secretClient
+}
+
+// Injectable function variables for testing
+var (
+	k8sClientFn    func(context.Context, string) (secretClient, *string, error) = k8sClient
+	ownNamespaceFn                                                              = ownNamespace
+)
+
+func NewSecretPersistence(secretName string) *Persistence {
+	return &Persistence{
+		secretName: secretName,
+	}
+}
+
+func (p *Persistence) GetAll(ctx context.Context) ([]persistence.KeyValue, error) {
+	ctx, span := otel.Tracer("service/persistence/secret").Start(ctx, "GetAll")
+	defer span.End()
+
+	secret, err := p.load(ctx, p.secretName)
+	if err != nil {
+		return nil, err
+	}
+	attrs := loadAttributes(ctx, secret)
+	revisions := loadRevisions(ctx, secret)
+	var keyValues []persistence.KeyValue
+	for key, value := range secret.Data {
+		if key == attributesKey {
+			continue
+		}
+		keyValues = append(keyValues, keyValue(key, value, attrs[key], revisions[key]))
+	}
+	return keyValues, nil
+}
+
+func (p *Persistence) PreSet(ctx context.Context, kv persistence.KeyValue) error {
+	ctx, span := otel.Tracer("service/persistence/secret").Start(ctx, "PreSet")
+	defer span.End()
+
+	return p.modify(ctx, p.secretName, func(secret *v1.Secret) error {
+		if _, exists := secret.Data[kv.Key]; exists {
+			// do not change if there is already a value
+			return errUnchanged
+		}
+		kv.Revision = 0
+		return set(ctx, secret, kv)
+	})
+}
+
+func (p *Persistence) Set(ctx context.Context, kv persistence.KeyValue) error {
+	ctx, span := otel.Tracer("service/persistence/secret").Start(ctx, "Set")
+	defer span.End()
+
+	return p.modify(ctx, p.secretName, func(secret *v1.Secret) error {
+		return set(ctx, secret, kv)
+	})
+}
+
+func (p *Persistence) Get(ctx context.Context, key string) (persistence.KeyValue, error) {
+	ctx, span := otel.Tracer("service/persistence/secret").Start(ctx, "Get")
+	defer span.End()
+
+	secret, err := p.load(ctx, p.secretName)
+	if err != nil {
+		return persistence.KeyValue{}, err
+	}
+	value, exists := secret.Data[key]
+	if !exists || key == attributesKey {
+		return persistence.KeyValue{}, persistence.ErrKeyNotFound
+	}
+	return keyValue(key, value, loadAttributes(ctx, secret)[key], loadRevisions(ctx, secret)[key]), nil
+}
+
+func (p *Persistence) Delete(ctx context.Context, key string) error {
+	ctx, span := otel.Tracer("service/persistence/secret").Start(ctx, "Delete")
+	defer span.End()
+
+	return p.modify(ctx, p.secretName, func(secret *v1.Secret) error {
+		if _, exists := secret.Data[key]; !exists || key == attributesKey {
+			return errUnchanged
+		}
+		return remove(ctx, secret, key)
+	})
+}
+
+// Batch applies all operations to the loaded Secret and saves it with a single update, so either
+// all of them become visible or none.
+func (p *Persistence) Batch(ctx context.Context, operations []persistence.Operation) error {
+	ctx, span := otel.Tracer("service/persistence/secret").Start(ctx, "Batch")
+	defer span.End()
+	span.SetAttributes(attribute.Int("operations", len(operations)))
+
+	return p.modify(ctx, p.secretName, func(secret *v1.Secret) error {
+		for _, op := range operations {
+			if op.Delete {
+				if err := remove(ctx, secret, op.KeyValue.Key); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := set(ctx, secret, op.KeyValue); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (p *Persistence) Count(ctx context.Context) (int, error) {
+	ctx, span := otel.Tracer("service/persistence/secret").Start(ctx, "Count")
+	defer span.End()
+
+	secret, err := p.load(ctx, p.secretName)
+	if err != nil {
+		return 0, err
+	}
+	count := len(secret.Data)
+	if _, exists := secret.Data[attributesKey]; exists {
+		count--
+	}
+	return count, nil
+}
+
+func (p *Persistence) AppendHistory(ctx context.Context, entry persistence.HistoryEntry) error {
+	ctx, span := otel.Tracer("service/persistence/secret").Start(ctx, "AppendHistory")
+	defer span.End()
+
+	return p.modify(ctx, p.secretName+historySuffix, func(secret *v1.Secret) error {
+		entries := persistence.AppendEntry(loadHistory(ctx, secret, entry.Key), entry)
+		raw, err := json.Marshal(entries)
+		if err != nil {
+			return err
+		}
+		secret.Data[entry.Key] = raw
+		return nil
+	})
+}
+
+func (p *Persistence) History(ctx context.Context, key string) ([]persistence.HistoryEntry, error) {
+	ctx, span := otel.Tracer("service/persistence/secret").Start(ctx, "History")
+	defer span.End()
+
+	secret, err := p.load(ctx, p.secretName+historySuffix)
+	if err != nil {
+		return nil, err
+	}
+	entries := loadHistory(ctx, secret, key)
+	for i := range entries {
+		entries[i].Sensitive = true
+	}
+	return entries, nil
+}
+
+// set stores kv in the secret, checking a non-zero revision against the stored one
+func set(ctx context.Context, secret *v1.Secret, kv persistence.KeyValue) error {
+	if kv.Key == attributesKey {
+		return fmt.Errorf("'%s' is reserved", attributesKey)
+	}
+	var current uint64
+	if _, exists := secret.Data[kv.Key]; exists {
+		current = max(loadRevisions(ctx, secret)[kv.Key], 1)
+	}
+	revision, err := persistence.NextRevision(current, kv.Revision)
+	if err != nil {
+		return err
+	}
+
+	secret.Data[kv.Key] = []byte(kv.Value)
+	if err := setAttributes(ctx, secret, kv); err != nil {
+		return err
+	}
+	return setRevision(ctx, secret, kv.Key, revision)
+}
+
+// remove deletes a key with its attributes and revision from the secret
+func remove(ctx context.Context, secret *v1.Secret, key string) error {
+	delete(secret.Data, key)
+	if err := setAttributes(ctx, secret, persistence.KeyValue{Key: key}); err != nil {
+		return err
+	}
+	return setRevision(ctx, secret, key, 0)
+}
+
+func keyValue(key string, value []byte, attrs attributes, revision uint64) persistence.KeyValue {
+	// keys added to the Secret directly have been written once
+	if revision == 0 {
+		revision = 1
+	}
+	return persistence.KeyValue{
+		Key:           key,
+		Value:         string(value),
+		Type:          attrs.Type,
+		Constraints:   attrs.Constraints,
+		Metadata:      attrs.Metadata,
+		Rules:         attrs.Rules,
+		Rollout:       attrs.Rollout,
+		Prerequisites: attrs.Prerequisites,
+		OffValue:      attrs.OffValue,
+		Revision:      revision,
+		Sensitive:     true,
+	}
+}
+
+// loadAttributes decodes the attributes entry, a malformed entry is ignored
+func loadAttributes(ctx context.Context, secret *v1.Secret) map[string]attributes {
+	attrs := make(map[string]attributes)
+	raw, exists := secret.Data[attributesKey]
+	if !exists || len(raw) == 0 {
+		return attrs
+	}
+	if err := json.Unmarshal(raw, &attrs); err != nil {
+		slog.WarnContext(ctx, "Ignoring malformed attributes", "secret", secret.Name, "error", err)
+		return make(map[string]attributes)
+	}
+	return attrs
+}
+
+// setAttributes stores the attributes of kv, removing the entry if there are none
+func setAttributes(ctx context.Context, secret *v1.Secret, kv persistence.KeyValue) error {
+	attrs := loadAttributes(ctx, secret)
+	if kv.Type == "" && kv.Constraints == nil && kv.Metadata.IsZero() && len(kv.Rules) == 0 && kv.Rollout == nil &&
+		len(kv.Prerequisites) == 0 && kv.OffValue == "" {
+		delete(attrs, kv.Key)
+	} else {
+		attrs[kv.Key] = attributes{Type: kv.Type, Constraints: kv.Constraints, Metadata: kv.Metadata, Rules: kv.Rules, Rollout: kv.Rollout,
+			Prerequisites: kv.Prerequisites, OffValue: kv.OffValue}
+	}
+
+	if len(attrs) == 0 {
+		delete(secret.Data, attributesKey)
+		return nil
+	}
+	raw, err := json.Marshal(attrs)
+	if err != nil {
+		return err
+	}
+	secret.Data[attributesKey] = raw
+	return nil
+}
+
+// loadRevisions decodes the revisions annotation, a malformed annotation is ignored
+func loadRevisions(ctx context.Context, secret *v1.Secret) map[string]uint64 {
+	revisions := make(map[string]uint64)
+	raw, exists := secret.Annotations[revisionsAnnotation]
+	if !exists || raw == "" {
+		return revisions
+	}
+	if err := json.Unmarshal([]byte(raw), &revisions); err != nil {
+		slog.WarnContext(ctx, "Ignoring malformed revisions annotation", "secret", secret.Name, "error", err)
+		return make(map[string]uint64)
+	}
+	return revisions
+}
+
+// setRevision stores the revision of a key in the annotation, removing the entry for revision 0
+func setRevision(ctx context.Context, secret *v1.Secret, key string, revision uint64) error {
+	revisions := loadRevisions(ctx, secret)
+	if revision == 0 {
+		delete(revisions, key)
+	} else {
+		revisions[key] = revision
+	}
+
+	if len(revisions) == 0 {
+		delete(secret.Annotations, revisionsAnnotation)
+		return nil
+	}
+	raw, err := json.Marshal(revisions)
+	if err != nil {
+		return err
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	secret.Annotations[revisionsAnnotation] = string(raw)
+	return nil
+}
+
+// loadHistory decodes the history of a key, a malformed history is ignored
+func loadHistory(ctx context.Context, secret *v1.Secret, key string) []persistence.HistoryEntry {
+	raw, exists := secret.Data[key]
+	if !exists || len(raw) == 0 {
+		return nil
+	}
+	var entries []persistence.HistoryEntry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		slog.WarnContext(ctx, "Ignoring malformed history", "secret", secret.Name, "key", key, "error", err)
+		return nil
+	}
+	return entries
+}
+
+// modify loads the named Secret, changes it and updates it with the loaded resourceVersion. If
+// someone else updated the Secret in between, the update conflicts and the change starts over.
+func (p *Persistence) modify(ctx context.Context, name string, change func(*v1.Secret) error) error {
+	client, err := p.secretClient(ctx)
+	if err != nil {
+		return err
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := p.createOrLoad(ctx, client, name)
+		if err != nil {
+			return err
+		}
+		if secret.Data == nil {
+			secret.Data = make(map[string][]byte)
+		}
+		if err := change(secret); err != nil {
+			if errors.Is(err, errUnchanged) {
+				return nil
+			}
+			return err
+		}
+		_, err = client.Update(ctx, secret, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// load returns the named Secret, an empty one if it does not exist yet
+func (p *Persistence) load(ctx context.Context, name string) (*v1.Secret, error) {
+	client, err := p.secretClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := client.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name}}, nil
+	}
+	return secret, err
+}
+
+func (p *Persistence) createOrLoad(ctx context.Context, client secretClient, name string) (*v1.Secret, error) {
+	ctx, span := otel.Tracer("service/persistence/secret").Start(ctx, "createOrLoadSecret")
+	defer span.End()
+
+	secret, err := client.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return client.Create(ctx, &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Type:       v1.SecretTypeOpaque,
+		}, metav1.CreateOptions{})
+	}
+	return secret, err
+}
+
+// secretClient creates the client on first use, a failure is tried again on the next call
+func (p *Persistence) secretClient(ctx context.Context) (secretClient, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client != nil {
+		return p.client, nil
+	}
+	client, namespace, err := k8sClientFn(ctx, p.secretName)
+	if err != nil {
+		return nil, err
+	}
+	slog.DebugContext(ctx, "Running in namespace", "namespace", *namespace)
+	p.client = client
+	return client, nil
+}
+
+func k8sClient(ctx context.Context, secretName string) (client secretClient, namespace *string, err error) {
+	ctx, span := otel.Tracer("service/persistence/secret").Start(ctx, "k8sClient")
+	defer span.End()
+
+	rc, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	namespace, err = ownNamespaceFn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Instrument the transport with otelhttp and set peer.service to "kubernetes"
+	rc.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		return otelhttp.NewTransport(rt,
+			otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
+				return r.Method + " " + r.URL.String()
+			}),
+			otelhttp.WithSpanOptions(
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("peer.service", "kubernetes"),
+					attribute.String("namespace", *namespace),
+					attribute.String("secret", secretName),
+				),
+			),
+		)
+	}
+
+	clientset, err := kubernetes.NewForConfig(rc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return clientset.CoreV1().Secrets(*namespace), namespace, nil
+}
+
+func ownNamespace(ctx context.Context) (namespace *string, err error) {
+	ctx, span := otel.Tracer("service/persistence/secret").Start(ctx, "ownNamespace")
+	defer span.End()
+
+	data, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+	if err != nil {
+		return nil, err
+	}
+	ns := string(data)
+
+	span.SetAttributes(attribute.String("namespace", ns))
+	return &ns, nil
+}
@@ -0,0 +1,171 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/dkrizic/feature/service/service/persistence/persistencetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// fakeSecretClient implements secretClient for testing. Like the API server it rejects updates
+// carrying an outdated resourceVersion.
+type fakeSecretClient struct {
+	mu      sync.Mutex
+	secrets map[string]*v1.Secret
+	// conflicts is the number of updates to reject as if someone else updated the Secret first
+	conflicts int
+	updates   int
+}
+
+var secretsResource = schema.GroupResource{Resource: "secrets"}
+
+func (f *fakeSecretClient) Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.Secret, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	secret, exists := f.secrets[name]
+	if !exists {
+		return nil, errors.NewNotFound(secretsResource, name)
+	}
+	return secret.DeepCopy(), nil
+}
+
+func (f *fakeSecretClient) Create(ctx context.Context, secret *v1.Secret, opts metav1.CreateOptions) (*v1.Secret, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, exists := f.secrets[secret.Name]; exists {
+		return nil, errors.NewAlreadyExists(secretsResource, secret.Name)
+	}
+	stored := secret.DeepCopy()
+	stored.ResourceVersion = "1"
+	f.secrets[secret.Name] = stored
+	return stored.DeepCopy(), nil
+}
+
+func (f *fakeSecretClient) Update(ctx context.Context, secret *v1.Secret, opts metav1.UpdateOptions) (*v1.Secret, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stored, exists := f.secrets[secret.Name]
+	if !exists {
+		return nil, errors.NewNotFound(secretsResource, secret.Name)
+	}
+	f.updates++
+	if f.conflicts > 0 {
+		f.conflicts--
+		return nil, errors.NewConflict(secretsResource, secret.Name, fmt.Errorf("the object has been modified"))
+	}
+	if secret.ResourceVersion != stored.ResourceVersion {
+		return nil, errors.NewConflict(secretsResource, secret.Name, fmt.Errorf("the object has been modified"))
+	}
+	updated := secret.DeepCopy()
+	version, _ := strconv.Atoi(stored.ResourceVersion)
+	updated.ResourceVersion = strconv.Itoa(version + 1)
+	f.secrets[secret.Name] = updated
+	return updated.DeepCopy(), nil
+}
+
+// setupFakeK8s replaces the Kubernetes client with a fake and returns it for seeding data
+func setupFakeK8s(t *testing.T) *fakeSecretClient {
+	fakeClient := &fakeSecretClient{secrets: make(map[string]*v1.Secret)}
+	originalK8sClientFn := k8sClientFn
+	k8sClientFn = func(ctx context.Context, secretName string) (secretClient, *string, error) {
+		ns := "test-namespace"
+		return fakeClient, &ns, nil
+	}
+	t.Cleanup(func() { k8sClientFn = originalK8sClientFn })
+	return fakeClient
+}
+
+func TestSecretPersistence(t *testing.T) {
+	fakeClient := setupFakeK8s(t)
+	p := NewSecretPersistence("feature-secrets")
+	ctx := context.Background()
+
+	_, err := p.Get(ctx, "API_KEY")
+	assert.ErrorIs(t, err, persistence.ErrKeyNotFound)
+
+	owner := persistence.Metadata{Owner: "team-payments"}
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "API_KEY", Value: "s3cr3t", Metadata: owner}))
+	assert.NoError(t, p.PreSet(ctx, persistence.KeyValue{Key: "API_KEY", Value: "ignored"}))
+	assert.NoError(t, p.PreSet(ctx, persistence.KeyValue{Key: "ENDPOINT", Value: "https://payments.example.com"}))
+
+	kv, err := p.Get(ctx, "API_KEY")
+	assert.NoError(t, err)
+	assert.Equal(t, persistence.KeyValue{Key: "API_KEY", Value: "s3cr3t", Metadata: owner, Revision: 1, Sensitive: true}, kv)
+
+	// the values are data entries, so workloads can use the Secret directly
+	secret := fakeClient.secrets["feature-secrets"]
+	assert.Equal(t, v1.SecretTypeOpaque, secret.Type)
+	assert.Equal(t, []byte("s3cr3t"), secret.Data["API_KEY"])
+	assert.Equal(t, []byte("https://payments.example.com"), secret.Data["ENDPOINT"])
+	assert.JSONEq(t, `{"API_KEY":{"metadata":{"owner":"team-payments"}}}`, string(secret.Data[attributesKey]))
+
+	all, err := p.GetAll(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, all, 2)
+	count, err := p.Count(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.NoError(t, p.Delete(ctx, "API_KEY"))
+	assert.NotContains(t, fakeClient.secrets["feature-secrets"].Data, attributesKey)
+	count, _ = p.Count(ctx)
+	assert.Equal(t, 1, count)
+}
+
+func TestSecretPersistence_ReservedKey(t *testing.T) {
+	setupFakeK8s(t)
+	p := NewSecretPersistence("feature-secrets")
+	ctx := context.Background()
+
+	assert.ErrorContains(t, p.Set(ctx, persistence.KeyValue{Key: attributesKey, Value: "{}"}), "reserved")
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "API_KEY", Value: "s3cr3t", Type: persistence.TypeString}))
+	_, err := p.Get(ctx, attributesKey)
+	assert.ErrorIs(t, err, persistence.ErrKeyNotFound)
+}
+
+func TestSecretPersistence_History(t *testing.T) {
+	fakeClient := setupFakeK8s(t)
+	p := NewSecretPersistence("feature-secrets")
+	ctx := context.Background()
+
+	assert.NoError(t, p.AppendHistory(ctx, persistence.HistoryEntry{Key: "API_KEY", Action: persistence.ActionUpdate, OldValue: "old", NewValue: "new"}))
+	entries, err := p.History(ctx, "API_KEY")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.True(t, entries[0].Sensitive)
+	assert.Equal(t, "new", entries[0].NewValue)
+
+	// old values are confidential too
+	assert.Contains(t, string(fakeClient.secrets["feature-secrets-history"].Data["API_KEY"]), `"oldValue":"old"`)
+}
+
+func TestSecretPersistence_RetryOnConflict(t *testing.T) {
+	fakeClient := setupFakeK8s(t)
+	p := NewSecretPersistence("feature-secrets")
+	ctx := context.Background()
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "API_KEY", Value: "s3cr3t"}))
+
+	fakeClient.conflicts = 2
+	updates := fakeClient.updates
+	assert.NoError(t, p.Set(ctx, persistence.KeyValue{Key: "API_KEY", Value: "rotated", Revision: 1}))
+	assert.Equal(t, updates+3, fakeClient.updates)
+	kv, _ := p.Get(ctx, "API_KEY")
+	assert.Equal(t, uint64(2), kv.Revision)
+}
+
+func TestConformance(t *testing.T) {
+	persistencetest.Run(t, func(t *testing.T) persistence.Persistence {
+		setupFakeK8s(t)
+		return NewSecretPersistence("feature-secrets")
+	}, persistencetest.Limitations{Sensitive: true})
+}
@@ -0,0 +1,268 @@
+package scheduler
+
+// applies flag changes planned for a later time. The schedules of all flag sets are kept in the
+// storage partition flagset.SchedulePartition, so they survive restarts of the service and are
+// shared by its replicas. A replica claims a due schedule with a conditional write before applying
+// it and deletes it afterwards.
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/dkrizic/feature/service/service/auth"
+	"github.com/dkrizic/feature/service/service/feature/v1"
+	"github.com/dkrizic/feature/service/service/flagset"
+	"github.com/dkrizic/feature/service/service/persistence"
+	"github.com/dkrizic/feature/service/service/persistence/recording"
+	workloadv1 "github.com/dkrizic/feature/service/service/workload/v1"
+	"github.com/dkrizic/feature/service/telemetry/localmetrics"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	// Source is recorded in the history of the flags changed by the scheduler
+	Source = "Schedule"
+	// claimTimeout releases the claims of replicas that stopped while applying a schedule
+	claimTimeout = time.Minute
+)
+
+var ErrNotFound = errors.New("schedule not found")
+
+// Schedule is a value to set at a later time. It is applied on behalf of its creator, in the set
+// and environment it was created in.
+type Schedule struct {
+	ID          string    `json:"-"`
+	Set         string    `json:"set,omitempty"`
+	Environment string    `json:"environment,omitempty"`
+	Key         string    `json:"key"`
+	Value       string    `json:"value"`
+	At          time.Time `json:"at"`
+	Restart     bool      `json:"restart,omitempty"`
+	CreatedBy   string    `json:"createdBy,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	// Error is the reason applying the schedule failed, failed schedules are kept but not retried
+	Error string `json:"error,omitempty"`
+	// ClaimedAt is set by the replica applying the schedule
+	ClaimedAt time.Time `json:"claimedAt,omitzero"`
+	// Sensitive is reported by the storage like persistence.KeyValue.Sensitive
+	Sensitive bool `json:"-"`
+	// Revision is the revision of the stored schedule
+	Revision uint64 `json:"-"`
+}
+
+// Context returns the context the schedule is applied in: the metadata of a request to its set and
+// environment by its creator
+func (s Schedule) Context(ctx context.Context) context.Context {
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(flagset.MetadataKey, s.Set, flagset.EnvironmentMetadataKey, s.Environment))
+	return recording.WithSource(auth.WithPrincipal(ctx, s.CreatedBy), Source)
+}
+
+// Store keeps the schedules as JSON in a storage partition, keyed by their ID
+type Store struct {
+	persistence persistence.Persistence
+}
+
+func NewStore(p persistence.Persistence) *Store {
+	return &Store{persistence: p}
+}
+
+// Create stores a new schedule and returns it with its ID
+func (s *Store) Create(ctx context.Context, schedule Schedule) (Schedule, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return Schedule{}, err
+	}
+	schedule.ID = hex.EncodeToString(id)
+	if err := s.save(ctx, schedule); err != nil {
+		return Schedule{}, err
+	}
+	return schedule, nil
+}
+
+// List returns all schedules, the earliest first
+func (s *Store) List(ctx context.Context) ([]Schedule, error) {
+	kvs, err := s.persistence.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	schedules := make([]Schedule, 0, len(kvs))
+	for _, kv := range kvs {
+		schedule, err := decode(kv)
+		if err != nil {
+			slog.WarnContext(ctx, "Ignoring invalid schedule", "id", kv.Key, "error", err)
+			continue
+		}
+		schedules = append(schedules, schedule)
+	}
+	slices.SortFunc(schedules, func(a, b Schedule) int {
+		return a.At.Compare(b.At)
+	})
+	return schedules, nil
+}
+
+// Get returns the schedule with the ID or ErrNotFound
+func (s *Store) Get(ctx context.Context, id string) (Schedule, error) {
+	kv, err := s.persistence.Get(ctx, id)
+	if errors.Is(err, persistence.ErrKeyNotFound) {
+		return Schedule{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return Schedule{}, err
+	}
+	return decode(kv)
+}
+
+// Delete removes the schedule with the ID, it returns ErrNotFound if it is already gone
+func (s *Store) Delete(ctx context.Context, id string) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	return s.persistence.Delete(ctx, id)
+}
+
+func (s *Store) save(ctx context.Context, schedule Schedule) error {
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return err
+	}
+	return s.persistence.Set(ctx, persistence.KeyValue{Key: schedule.ID, Value: string(data), Revision: schedule.Revision})
+}
+
+func decode(kv persistence.KeyValue) (Schedule, error) {
+	var schedule Schedule
+	if err := json.Unmarshal([]byte(kv.Value), &schedule); err != nil {
+		return Schedule{}, err
+	}
+	schedule.ID = kv.Key
+	schedule.Sensitive = kv.Sensitive
+	schedule.Revision = kv.Revision
+	return schedule, nil
+}
+
+// Target sets the flags, the feature.Router
+type Target interface {
+	Set(context.Context, *featurev1.KeyValue) (*emptypb.Empty, error)
+}
+
+// Restarter restarts the workload of a flag set, the workload.WorkloadService
+type Restarter interface {
+	Restart(context.Context, *workloadv1.SimpleRestartRequest) (*workloadv1.RestartResponse, error)
+}
+
+// Scheduler applies the due schedules of the store
+type Scheduler struct {
+	store     *Store
+	target    Target
+	restarter Restarter
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewScheduler creates the scheduler. The restarter is optional; without it schedules asking for a
+// restart fail after the value was set.
+func NewScheduler(store *Store, target Target, restarter Restarter) *Scheduler {
+	if err := localmetrics.New(); err != nil {
+		slog.Error("Failed to initialize local metrics", "error", err)
+	}
+	return &Scheduler{
+		store:     store,
+		target:    target,
+		restarter: restarter,
+		now:       time.Now,
+	}
+}
+
+// Run applies the due schedules every interval until the context is done. Schedules that became
+// due while no replica was running are applied late.
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	slog.InfoContext(ctx, "Scheduler started", "interval", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "Scheduler stopped")
+			return
+		case <-ticker.C:
+			s.RunDue(ctx)
+		}
+	}
+}
+
+// RunDue applies the schedules that are due and returns how many were applied successfully
+func (s *Scheduler) RunDue(ctx context.Context) int {
+	ctx, span := otel.Tracer("service/scheduler").Start(ctx, "RunDue")
+	defer span.End()
+
+	schedules, err := s.store.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list schedules", "error", err)
+		return 0
+	}
+	applied := 0
+	now := s.now()
+	for _, schedule := range schedules {
+		if schedule.At.After(now) {
+			break
+		}
+		if schedule.Error != "" || now.Sub(schedule.ClaimedAt) < claimTimeout {
+			continue
+		}
+		// the write fails if another replica claimed the schedule first
+		schedule.ClaimedAt = now
+		if err := s.store.save(ctx, schedule); err != nil {
+			if !errors.Is(err, persistence.ErrRevisionMismatch) {
+				slog.ErrorContext(ctx, "Failed to claim schedule", "id", schedule.ID, "error", err)
+			}
+			continue
+		}
+		schedule.Revision = 0
+		if err := s.apply(ctx, schedule); err != nil {
+			slog.ErrorContext(ctx, "Failed to apply schedule", "id", schedule.ID, "set", schedule.Set, "environment", schedule.Environment,
+				"key", schedule.Key, "error", err)
+			schedule.Error = err.Error()
+			if err := s.store.save(ctx, schedule); err != nil {
+				slog.ErrorContext(ctx, "Failed to keep failed schedule", "id", schedule.ID, "error", err)
+			}
+			continue
+		}
+		if err := s.store.persistence.Delete(ctx, schedule.ID); err != nil {
+			slog.ErrorContext(ctx, "Failed to remove applied schedule", "id", schedule.ID, "error", err)
+		}
+		slog.InfoContext(ctx, "Schedule applied", "id", schedule.ID, "set", schedule.Set, "environment", schedule.Environment,
+			"key", schedule.Key, "restart", schedule.Restart)
+		localmetrics.ScheduleCounter().Add(ctx, 1)
+		applied++
+	}
+	return applied
+}
+
+func (s *Scheduler) apply(ctx context.Context, schedule Schedule) error {
+	ctx = schedule.Context(ctx)
+	if _, err := s.target.Set(ctx, &featurev1.KeyValue{Key: schedule.Key, Value: schedule.Value}); err != nil {
+		return err
+	}
+	if !schedule.Restart {
+		return nil
+	}
+	if s.restarter == nil {
+		return errors.New("value set, but the restart is not available")
+	}
+	response, err := s.restarter.Restart(ctx, &workloadv1.SimpleRestartRequest{})
+	if err != nil {
+		return fmt.Errorf("value set, but the restart failed: %w", err)
+	}
+	if !response.Success {
+		return fmt.Errorf("value set, but the restart failed: %s", response.Message)
+	}
+	return nil
+}
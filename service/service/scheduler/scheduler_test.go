@@ -0,0 +1,160 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dkrizic/feature/service/service/auth"
+	"github.com/dkrizic/feature/service/service/feature/v1"
+	"github.com/dkrizic/feature/service/service/flagset"
+	"github.com/dkrizic/feature/service/service/persistence/inmemory"
+	workloadv1 "github.com/dkrizic/feature/service/service/workload/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// fakeTarget records the values set and the set and principal of every call
+type fakeTarget struct {
+	calls []string
+	err   error
+}
+
+func (f *fakeTarget) Set(ctx context.Context, kv *featurev1.KeyValue) (*emptypb.Empty, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.calls = append(f.calls, flagset.NameFromContext(ctx)+"/"+flagset.EnvironmentFromContext(ctx)+" "+
+		auth.PrincipalFromContext(ctx)+" "+kv.Key+"="+kv.Value)
+	return &emptypb.Empty{}, nil
+}
+
+type fakeRestarter struct {
+	restarts int
+	success  bool
+}
+
+func (f *fakeRestarter) Restart(ctx context.Context, req *workloadv1.SimpleRestartRequest) (*workloadv1.RestartResponse, error) {
+	f.restarts++
+	return &workloadv1.RestartResponse{Success: f.success, Message: "not enabled"}, nil
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(inmemory.NewInMemoryPersistence())
+	now := time.Now().UTC()
+
+	later, err := store.Create(ctx, Schedule{Key: "COLOR", Value: "blue", At: now.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.NotEmpty(t, later.ID)
+	sooner, err := store.Create(ctx, Schedule{Key: "COLOR", Value: "red", At: now.Add(time.Minute), Set: "team-a"})
+	require.NoError(t, err)
+	assert.NotEqual(t, later.ID, sooner.ID)
+
+	schedules, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, schedules, 2)
+	assert.Equal(t, sooner.ID, schedules[0].ID)
+	assert.Equal(t, "team-a", schedules[0].Set)
+	assert.True(t, later.At.Equal(schedules[1].At))
+
+	got, err := store.Get(ctx, later.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "blue", got.Value)
+
+	require.NoError(t, store.Delete(ctx, later.ID))
+	assert.ErrorIs(t, store.Delete(ctx, later.ID), ErrNotFound)
+	_, err = store.Get(ctx, later.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestScheduler_RunDue(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(inmemory.NewInMemoryPersistence())
+	target := &fakeTarget{}
+	s := NewScheduler(store, target, nil)
+	now := time.Now().UTC()
+	s.now = func() time.Time { return now }
+
+	_, err := store.Create(ctx, Schedule{Key: "COLOR", Value: "red", At: now.Add(-time.Minute), Set: "team-a", Environment: "prod", CreatedBy: "alice"})
+	require.NoError(t, err)
+	pending, err := store.Create(ctx, Schedule{Key: "COLOR", Value: "blue", At: now.Add(time.Hour)})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, s.RunDue(ctx))
+	assert.Equal(t, []string{"team-a/prod alice COLOR=red"}, target.calls)
+	schedules, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, schedules, 1)
+	assert.Equal(t, pending.ID, schedules[0].ID)
+
+	// nothing is due any more
+	assert.Equal(t, 0, s.RunDue(ctx))
+
+	now = now.Add(2 * time.Hour)
+	assert.Equal(t, 1, s.RunDue(ctx))
+	assert.Equal(t, "/  COLOR=blue", target.calls[1])
+}
+
+func TestScheduler_Failures(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(inmemory.NewInMemoryPersistence())
+	target := &fakeTarget{err: errors.New("field 'COLOR' is not editable")}
+	s := NewScheduler(store, target, nil)
+	now := time.Now().UTC()
+	s.now = func() time.Time { return now }
+
+	failing, err := store.Create(ctx, Schedule{Key: "COLOR", Value: "red", At: now})
+	require.NoError(t, err)
+	assert.Equal(t, 0, s.RunDue(ctx))
+	failed, err := store.Get(ctx, failing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "field 'COLOR' is not editable", failed.Error)
+
+	// failed schedules are not retried
+	target.err = nil
+	assert.Equal(t, 0, s.RunDue(ctx))
+	assert.Empty(t, target.calls)
+	require.NoError(t, store.Delete(ctx, failing.ID))
+
+	// the value is set even if the restart fails
+	restarter := &fakeRestarter{}
+	s.restarter = restarter
+	restarting, err := store.Create(ctx, Schedule{Key: "COLOR", Value: "blue", At: now, Restart: true})
+	require.NoError(t, err)
+	assert.Equal(t, 0, s.RunDue(ctx))
+	assert.Equal(t, 1, restarter.restarts)
+	assert.Len(t, target.calls, 1)
+	failed, err = store.Get(ctx, restarting.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "value set, but the restart failed: not enabled", failed.Error)
+	require.NoError(t, store.Delete(ctx, restarting.ID))
+
+	restarter.success = true
+	_, err = store.Create(ctx, Schedule{Key: "COLOR", Value: "green", At: now, Restart: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, s.RunDue(ctx))
+	assert.Equal(t, 2, restarter.restarts)
+}
+
+func TestScheduler_Claimed(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(inmemory.NewInMemoryPersistence())
+	target := &fakeTarget{}
+	s := NewScheduler(store, target, nil)
+	now := time.Now().UTC()
+	s.now = func() time.Time { return now }
+
+	// another replica is applying the schedule
+	_, err := store.Create(ctx, Schedule{Key: "COLOR", Value: "red", At: now.Add(-time.Minute), ClaimedAt: now})
+	require.NoError(t, err)
+	assert.Equal(t, 0, s.RunDue(ctx))
+	assert.Empty(t, target.calls)
+
+	// the claim is released if the replica stopped
+	now = now.Add(claimTimeout)
+	assert.Equal(t, 1, s.RunDue(ctx))
+	assert.Len(t, target.calls, 1)
+}
@@ -11,10 +11,15 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/dkrizic/feature/service/audit"
+	auditfactory "github.com/dkrizic/feature/service/audit/factory"
 	"github.com/dkrizic/feature/service/constant"
 	"github.com/dkrizic/feature/service/service/auth"
+	"github.com/dkrizic/feature/service/service/flagset"
+	"github.com/dkrizic/feature/service/service/lifecycle"
 	"github.com/dkrizic/feature/service/service/persistence"
 	"github.com/dkrizic/feature/service/service/persistence/factory"
+	"github.com/dkrizic/feature/service/service/scheduler"
 	"github.com/dkrizic/feature/service/telemetry"
 	"github.com/urfave/cli/v3"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
@@ -36,6 +41,17 @@ import (
 
 var otelShutdown func(ctx context.Context) error = nil
 
+// parseList splits a comma-separated list, ignoring empty entries
+func parseList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 func Before(ctx context.Context, cmd *cli.Command) (context.Context, error) {
 	slog.Info("Starting service", "version", metaversion.Version)
 
@@ -84,7 +100,45 @@ func Service(ctx context.Context, cmd *cli.Command) error {
 	slog.InfoContext(ctx, "Configuration", "port", port)
 
 	// configure persistence based on storage type
-	pers, err := factory.NewPersistence(ctx, cmd)
+	storage, err := factory.NewStorage(ctx, cmd)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create persistence", "error", err)
+		return fmt.Errorf("failed to create persistence: %w", err)
+	}
+
+	environments := parseList(cmd.String(constant.Environments))
+	if err := flagset.ValidateEnvironments(environments); err != nil {
+		slog.ErrorContext(ctx, "Invalid environments", "environments", environments, "error", err)
+		return fmt.Errorf("invalid environments: %w", err)
+	}
+	slog.InfoContext(ctx, "Configuration", "environments", environments)
+
+	scheduleInterval := cmd.Duration(constant.ScheduleInterval)
+	if scheduleInterval <= 0 {
+		slog.ErrorContext(ctx, "Invalid schedule interval", "interval", scheduleInterval)
+		return fmt.Errorf("invalid schedule interval: %s", scheduleInterval)
+	}
+	staleAfter := cmd.Duration(constant.StaleAfter)
+	staleInterval := cmd.Duration(constant.StaleInterval)
+	if staleAfter < 0 || staleInterval <= 0 {
+		slog.ErrorContext(ctx, "Invalid stale flag detection", "staleAfter", staleAfter, "interval", staleInterval)
+		return fmt.Errorf("invalid stale flag detection: stale after %s, interval %s", staleAfter, staleInterval)
+	}
+
+	// the default flag set is configured by the flags, the other sets are kept in the storage.
+	// Every set has a partition per environment, and the changes of every partition are broadcast
+	// to its Watch subscribers in addition to the configured notifier.
+	sets := flagset.NewManager(storage.Backend(ctx, flagset.RegistryPartition), flagset.Config{
+		Editable:    parseList(cmd.String(constant.Editable)),
+		RestartType: cmd.String(constant.RestartType),
+		RestartName: cmd.String(constant.RestartName),
+	}, environments, storage.Open)
+	defaultSet, err := sets.Get(ctx, "", "")
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to open the default flag set", "error", err)
+		return fmt.Errorf("failed to open the default flag set: %w", err)
+	}
+	pers := defaultSet.Persistence
 
 	// check if there is a preset
 	preset := cmd.StringSlice(constant.PreSet)
@@ -117,16 +171,47 @@ func Service(ctx context.Context, cmd *cli.Command) error {
 	authUsername := cmd.String(constant.AuthenticationUsername)
 	authPassword := cmd.String(constant.AuthenticationPassword)
 
+	var usersSource auth.Source
+	if path := cmd.String(constant.AuthenticationUsersFile); path != "" {
+		usersSource = auth.NewFileSource(path)
+	} else if name := cmd.String(constant.AuthenticationUsersSecret); name != "" {
+		usersSource = auth.NewSecretSource(name, cmd.String(constant.AuthenticationUsersKey))
+	}
+	lockout := auth.NewLockout(cmd.Int(constant.AuthenticationMaxFailures), cmd.Duration(constant.AuthenticationLockout))
+	users, err := auth.NewUsers(authUsername, authPassword, usersSource, lockout)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create users", "error", err)
+		return fmt.Errorf("failed to create users: %w", err)
+	}
+
 	if authEnabled {
-		if authPassword == "" {
-			slog.ErrorContext(ctx, "Authentication password cannot be empty when authentication is enabled")
-			return fmt.Errorf("authentication password cannot be empty when authentication is enabled")
+		if authPassword == "" && usersSource == nil {
+			slog.ErrorContext(ctx, "Authentication password cannot be empty when authentication is enabled without users")
+			return fmt.Errorf("authentication password cannot be empty when authentication is enabled without users")
 		}
-		slog.InfoContext(ctx, "Authentication enabled", "username", authUsername)
+		if err := users.Reload(ctx); err != nil {
+			slog.ErrorContext(ctx, "Failed to load users", "error", err)
+			return fmt.Errorf("failed to load users: %w", err)
+		}
+		slog.InfoContext(ctx, "Authentication enabled", "users", users.Len(), "maxFailures", cmd.Int(constant.AuthenticationMaxFailures), "lockout", cmd.Duration(constant.AuthenticationLockout))
 	} else {
 		slog.InfoContext(ctx, "Authentication disabled")
 	}
 
+	// roles need the principal, without authentication everything is allowed
+	var authorizer *auth.Authorizer
+	if policy := cmd.String(constant.AuthorizationPolicy); policy != "" {
+		if !authEnabled {
+			slog.WarnContext(ctx, "Authorization policy ignored, authentication is disabled", "policy", policy)
+		} else {
+			authorizer = auth.NewAuthorizer(auth.NewFileSource(policy))
+			if err := authorizer.Reload(ctx); err != nil {
+				slog.ErrorContext(ctx, "Failed to load authorization policy", "error", err)
+				return fmt.Errorf("failed to load authorization policy: %w", err)
+			}
+		}
+	}
+
 	// Create interceptor chain
 	otelInterceptor := otelgrpc.NewServerHandler(otelgrpc.WithFilter(
 		func(stats *stats.RPCTagInfo) bool {
@@ -138,13 +223,35 @@ func Service(ctx context.Context, cmd *cli.Command) error {
 		},
 	))
 
-	authInterceptor := auth.SelectiveInterceptor(authEnabled, authUsername, authPassword)
-	authStreamInterceptor := auth.SelectiveStreamInterceptor(authEnabled, authUsername, authPassword)
+	// API tokens are an alternative to the credentials of users, so they need authentication
+	var tokens *auth.Tokens
+	if authEnabled {
+		tokens = auth.NewTokens(storage.Backend(ctx, flagset.TokenPartition))
+	}
+
+	authInterceptor := auth.SelectiveInterceptor(authEnabled, users, authorizer, tokens)
+	authStreamInterceptor := auth.SelectiveStreamInterceptor(authEnabled, users, authorizer, tokens)
+
+	unaryInterceptors := []grpc.UnaryServerInterceptor{authInterceptor}
+
+	// the audit interceptor runs after authentication to record the principal, the calls that
+	// authentication or authorization reject are recorded before it
+	if cmd.Bool(constant.AuditEnabled) {
+		sink, err := auditfactory.NewSink(ctx, cmd)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to create audit sink", "error", err)
+			return fmt.Errorf("failed to create audit sink: %w", err)
+		}
+		unaryInterceptors = []grpc.UnaryServerInterceptor{audit.RejectionInterceptor(sink), authInterceptor, audit.UnaryInterceptor(sink, sets)}
+		slog.InfoContext(ctx, "Audit enabled", "type", cmd.String(constant.AuditType))
+	} else {
+		slog.InfoContext(ctx, "Audit disabled")
+	}
 
 	// Create gRPC server with chained interceptors
 	grpcServer := grpc.NewServer(
 		grpc.StatsHandler(otelInterceptor),
-		grpc.ChainUnaryInterceptor(authInterceptor),
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
 		grpc.ChainStreamInterceptor(authStreamInterceptor),
 	)
 
@@ -159,16 +266,11 @@ func Service(ctx context.Context, cmd *cli.Command) error {
 	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
 	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
 
-	// Get editable fields configuration
-	editableFields := cmd.String(constant.Editable)
-
-	// feature
-	featureService, err := feature.NewFeatureService(pers, editableFields)
-	if err != nil {
-		slog.Error("Failed to create feature service", "error", err)
-		return fmt.Errorf("failed to create feature service: %w", err)
-	}
-	featurev1.RegisterFeatureServer(grpcServer, featureService)
+	// feature, every call is served by the flag set named in its metadata
+	schedules := scheduler.NewStore(storage.Backend(ctx, flagset.SchedulePartition))
+	monitor := lifecycle.NewMonitor(sets, storage.Backend(ctx, flagset.LifecyclePartition), staleAfter)
+	router := feature.NewRouter(sets, schedules, monitor, tokens, cmd.String(constant.Reveal))
+	featurev1.RegisterFeatureServer(grpcServer, router)
 
 	// workload
 	// Get the namespace from the environment, default to "default"
@@ -195,7 +297,7 @@ func Service(ctx context.Context, cmd *cli.Command) error {
 		restartType = workloadv1.WorkloadType_WORKLOAD_TYPE_DEPLOYMENT
 	}
 
-	workloadService, err := workload.NewWorkloadService(namespace, restartEnabled, restartType, restartName)
+	workloadService, err := workload.NewWorkloadService(namespace, restartEnabled, restartType, restartName, sets)
 	if err != nil {
 		slog.WarnContext(ctx, "Failed to create workload service (workload restart feature will be disabled)", "error", err)
 	} else {
@@ -203,6 +305,20 @@ func Service(ctx context.Context, cmd *cli.Command) error {
 		slog.InfoContext(ctx, "Workload service enabled", "namespace", namespace, "restartEnabled", restartEnabled, "restartType", restartTypeStr, "restartName", restartName)
 	}
 
+	// scheduled changes are applied through the router like the calls of their creators
+	var restarter scheduler.Restarter
+	if workloadService != nil {
+		restarter = workloadService
+	}
+	schedulerCtx, stopScheduler := context.WithCancel(ctx)
+	defer stopScheduler()
+	go scheduler.NewScheduler(schedules, router, restarter).Run(schedulerCtx, scheduleInterval)
+	go monitor.Run(schedulerCtx, staleInterval)
+	if authEnabled {
+		go users.Run(schedulerCtx, cmd.Duration(constant.AuthenticationUsersReload))
+		go authorizer.Run(schedulerCtx, cmd.Duration(constant.AuthenticationUsersReload))
+	}
+
 	cancelChan := make(chan os.Signal, 1)
 	// catch SIGETRM or SIGINTERRUPT
 	signal.Notify(cancelChan, syscall.SIGTERM, syscall.SIGINT)
@@ -2,11 +2,15 @@ package workload
 
 import (
 "context"
+"errors"
 "fmt"
 "log/slog"
 "time"
 
+"github.com/dkrizic/feature/service/service/flagset"
 workloadv1 "github.com/dkrizic/feature/service/service/workload/v1"
+"google.golang.org/grpc/codes"
+"google.golang.org/grpc/status"
 metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 "k8s.io/client-go/kubernetes"
 "k8s.io/client-go/rest"
@@ -20,10 +24,13 @@ type WorkloadService struct {
 	restartEnabled bool
 	restartType    workloadv1.WorkloadType
 	restartName    string
+	// sets provides the restart targets of the flag sets, the configured workload is the one of the default set
+	sets *flagset.Manager
 }
 
-// NewWorkloadService creates a new workload service
-func NewWorkloadService(namespace string, restartEnabled bool, restartType workloadv1.WorkloadType, restartName string) (*WorkloadService, error) {
+// NewWorkloadService creates a new workload service. Info and Restart use the restart target of the flag
+// set of the call, sets may be nil if there is only the default set.
+func NewWorkloadService(namespace string, restartEnabled bool, restartType workloadv1.WorkloadType, restartName string, sets *flagset.Manager) (*WorkloadService, error) {
 	// Create in-cluster config
 	config, err := rest.InClusterConfig()
 	if err != nil {
@@ -42,9 +49,40 @@ func NewWorkloadService(namespace string, restartEnabled bool, restartType workl
 		restartEnabled: restartEnabled,
 		restartType:    restartType,
 		restartName:    restartName,
+		sets:           sets,
 	}, nil
 }
 
+// workloadType converts the restart type of a flag set, like --restart-type
+func workloadType(restartType string) workloadv1.WorkloadType {
+	switch restartType {
+	case "statefulset":
+		return workloadv1.WorkloadType_WORKLOAD_TYPE_STATEFULSET
+	case "daemonset":
+		return workloadv1.WorkloadType_WORKLOAD_TYPE_DAEMONSET
+	default:
+		return workloadv1.WorkloadType_WORKLOAD_TYPE_DEPLOYMENT
+	}
+}
+
+// target returns the restart target of the flag set of the call, sets other than the default set
+// have none unless they configure one
+func (s *WorkloadService) target(ctx context.Context) (workloadv1.WorkloadType, string, error) {
+	if s.sets == nil || flagset.NameFromContext(ctx) == "" {
+		return s.restartType, s.restartName, nil
+	}
+	set, err := s.sets.FromContext(ctx)
+	switch {
+	case errors.Is(err, flagset.ErrNotFound):
+		return 0, "", status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, flagset.ErrPermissionDenied):
+		return 0, "", status.Error(codes.PermissionDenied, err.Error())
+	case err != nil:
+		return 0, "", err
+	}
+	return workloadType(set.RestartType), set.RestartName, nil
+}
+
 // RestartWorkload performs a rollout restart on the specified workload
 func (s *WorkloadService) RestartWorkload(ctx context.Context, req *workloadv1.RestartRequest) (*workloadv1.RestartResponse, error) {
 slog.InfoContext(ctx, "Received restart request", "type", req.Type.String(), "name", req.Name, "namespace", req.Namespace)
@@ -180,10 +218,18 @@ daemonSet.Spec.Template.Annotations = make(map[string]string)
 func (s *WorkloadService) Info(ctx context.Context, req *workloadv1.InfoRequest) (*workloadv1.ServiceInfo, error) {
 	slog.InfoContext(ctx, "Received info request")
 
+	restartType, restartName, err := s.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+	enabled := s.restartEnabled
+	if flagset.NameFromContext(ctx) != "" {
+		enabled = enabled && restartName != ""
+	}
 	return &workloadv1.ServiceInfo{
-		Enabled: s.restartEnabled,
-		Type:    s.restartType,
-		Name:    s.restartName,
+		Enabled: enabled,
+		Type:    restartType,
+		Name:    restartName,
 	}, nil
 }
 
@@ -198,7 +244,11 @@ func (s *WorkloadService) Restart(ctx context.Context, req *workloadv1.SimpleRes
 		}, nil
 	}
 
-	if s.restartName == "" {
+	restartType, restartName, err := s.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if restartName == "" {
 		return &workloadv1.RestartResponse{
 			Success: false,
 			Message: "Restart name is not configured",
@@ -207,8 +257,8 @@ func (s *WorkloadService) Restart(ctx context.Context, req *workloadv1.SimpleRes
 
 	// Call RestartWorkload with the configured values
 	return s.RestartWorkload(ctx, &workloadv1.RestartRequest{
-		Type:      s.restartType,
-		Name:      s.restartName,
+		Type:      restartType,
+		Name:      restartName,
 		Namespace: "", // Empty namespace uses the service's configured namespace
 	})
 }
@@ -1,15 +1,23 @@
 package workload
 
 import (
+"context"
 "testing"
 
+"github.com/dkrizic/feature/service/notifier"
+"github.com/dkrizic/feature/service/service/flagset"
+"github.com/dkrizic/feature/service/service/persistence"
+"github.com/dkrizic/feature/service/service/persistence/inmemory"
 workloadv1 "github.com/dkrizic/feature/service/service/workload/v1"
+"google.golang.org/grpc/codes"
+"google.golang.org/grpc/metadata"
+"google.golang.org/grpc/status"
 )
 
 // TestWorkloadServiceCreation tests that we can create a workload service
 func TestWorkloadServiceCreation(t *testing.T) {
 	// When running outside of a Kubernetes cluster, this should fail
-	_, err := NewWorkloadService("default", false, workloadv1.WorkloadType_WORKLOAD_TYPE_DEPLOYMENT, "test")
+	_, err := NewWorkloadService("default", false, workloadv1.WorkloadType_WORKLOAD_TYPE_DEPLOYMENT, "test", nil)
 	if err == nil {
 		t.Skip("Skipping test - running inside Kubernetes cluster")
 	}
@@ -40,3 +48,53 @@ t.Errorf("Expected %s, got %s", tt.expected, tt.wType.String())
 })
 }
 }
+
+// TestInfoPerFlagSet tests that Info reports the restart target of the flag set of the call
+func TestInfoPerFlagSet(t *testing.T) {
+	ctx := context.Background()
+	sets := flagset.NewManager(inmemory.NewInMemoryPersistence(), flagset.Config{}, nil,
+		func(ctx context.Context, partition flagset.Partition, notifiers ...notifier.Notifier) persistence.Persistence {
+			return inmemory.NewInMemoryPersistence()
+		})
+	if err := sets.Create(ctx, flagset.Config{Name: "team-a", RestartType: "statefulset", RestartName: "app-a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sets.Create(ctx, flagset.Config{Name: "team-b"}); err != nil {
+		t.Fatal(err)
+	}
+	s := &WorkloadService{restartEnabled: true, restartType: workloadv1.WorkloadType_WORKLOAD_TYPE_DEPLOYMENT, restartName: "app", sets: sets}
+	inSet := func(name string) context.Context {
+		return metadata.NewIncomingContext(ctx, metadata.Pairs(flagset.MetadataKey, name))
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		enabled bool
+		wType   workloadv1.WorkloadType
+		target  string
+	}{
+		{"Default", ctx, true, workloadv1.WorkloadType_WORKLOAD_TYPE_DEPLOYMENT, "app"},
+		{"Configured", inSet("team-a"), true, workloadv1.WorkloadType_WORKLOAD_TYPE_STATEFULSET, "app-a"},
+		{"NotConfigured", inSet("team-b"), false, workloadv1.WorkloadType_WORKLOAD_TYPE_DEPLOYMENT, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := s.Info(tt.ctx, &workloadv1.InfoRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Enabled != tt.enabled || info.Type != tt.wType || info.Name != tt.target {
+				t.Errorf("Expected %v %s %s, got %v %s %s", tt.enabled, tt.wType, tt.target, info.Enabled, info.Type, info.Name)
+			}
+		})
+	}
+
+	response, err := s.Restart(inSet("team-b"), &workloadv1.SimpleRestartRequest{})
+	if err != nil || response.Success {
+		t.Errorf("Expected restart of a set without target to fail, got %v %v", response, err)
+	}
+	if _, err := s.Info(inSet("unknown"), &workloadv1.InfoRequest{}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+}
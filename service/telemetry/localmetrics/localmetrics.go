@@ -12,6 +12,17 @@ var (
 	setCounter    metric.Int64Counter
 	presetCounter metric.Int64Counter
 	deleteCounter metric.Int64Counter
+	watchCounter  metric.Int64Counter
+	evalCounter   metric.Int64Counter
+	rulesCounter  metric.Int64Counter
+	rolloutCount  metric.Int64Counter
+	historyCount  metric.Int64Counter
+	rollbackCount metric.Int64Counter
+	batchCount    metric.Int64Counter
+	promoteCount  metric.Int64Counter
+	scheduleCount metric.Int64Counter
+	staleGauge    metric.Int64Gauge
+	prereqCount   metric.Int64Counter
 )
 
 func New() error {
@@ -61,6 +72,85 @@ func New() error {
 		return err
 	}
 
+	// counter for started watches
+	watchCounter, err = otel.Meter("telemetry/localmetrics").Int64Counter("feature.watch.count",
+		metric.WithDescription("Number of Watch requests"),
+		metric.WithUnit("count"))
+	if err != nil {
+		return err
+	}
+
+	// counter for evaluations
+	evalCounter, err = otel.Meter("telemetry/localmetrics").Int64Counter("feature.evaluate.count",
+		metric.WithDescription("Number of Evaluate requests"),
+		metric.WithUnit("count"))
+	if err != nil {
+		return err
+	}
+
+	// counter for rule changes
+	rulesCounter, err = otel.Meter("telemetry/localmetrics").Int64Counter("feature.setrules.count",
+		metric.WithDescription("Number of SetRules requests"),
+		metric.WithUnit("count"))
+	if err != nil {
+		return err
+	}
+
+	// counter for rollout changes
+	rolloutCount, err = otel.Meter("telemetry/localmetrics").Int64Counter("feature.setrollout.count",
+		metric.WithDescription("Number of SetRollout requests"),
+		metric.WithUnit("count"))
+	if err != nil {
+		return err
+	}
+
+	// counters for history reads and rollbacks
+	historyCount, err = otel.Meter("telemetry/localmetrics").Int64Counter("feature.history.count",
+		metric.WithDescription("Number of History requests"),
+		metric.WithUnit("count"))
+	if err != nil {
+		return err
+	}
+	rollbackCount, err = otel.Meter("telemetry/localmetrics").Int64Counter("feature.rollback.count",
+		metric.WithDescription("Number of Rollback requests"),
+		metric.WithUnit("count"))
+	if err != nil {
+		return err
+	}
+	batchCount, err = otel.Meter("telemetry/localmetrics").Int64Counter("feature.batch.count",
+		metric.WithDescription("Number of Batch requests"),
+		metric.WithUnit("count"))
+	if err != nil {
+		return err
+	}
+	promoteCount, err = otel.Meter("telemetry/localmetrics").Int64Counter("feature.promote.count",
+		metric.WithDescription("Number of applied promotions"),
+		metric.WithUnit("count"))
+	if err != nil {
+		return err
+	}
+	scheduleCount, err = otel.Meter("telemetry/localmetrics").Int64Counter("feature.schedule.count",
+		metric.WithDescription("Number of applied scheduled changes"),
+		metric.WithUnit("count"))
+	if err != nil {
+		return err
+	}
+
+	staleGauge, err = otel.Meter("telemetry/localmetrics").Int64Gauge("feature.stale.gauge",
+		metric.WithDescription("Number of stale features"),
+		metric.WithUnit("count"))
+	if err != nil {
+		return err
+	}
+
+	// counter for prerequisite changes
+	prereqCount, err = otel.Meter("telemetry/localmetrics").Int64Counter("feature.setprerequisites.count",
+		metric.WithDescription("Number of SetPrerequisites requests"),
+		metric.WithUnit("count"))
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -87,3 +177,47 @@ func PresetCounter() metric.Int64Counter {
 func DeleteCounter() metric.Int64Counter {
 	return deleteCounter
 }
+
+func WatchCounter() metric.Int64Counter {
+	return watchCounter
+}
+
+func EvaluateCounter() metric.Int64Counter {
+	return evalCounter
+}
+
+func SetRulesCounter() metric.Int64Counter {
+	return rulesCounter
+}
+
+func SetRolloutCounter() metric.Int64Counter {
+	return rolloutCount
+}
+
+func HistoryCounter() metric.Int64Counter {
+	return historyCount
+}
+
+func RollbackCounter() metric.Int64Counter {
+	return rollbackCount
+}
+
+func BatchCounter() metric.Int64Counter {
+	return batchCount
+}
+
+func PromoteCounter() metric.Int64Counter {
+	return promoteCount
+}
+
+func ScheduleCounter() metric.Int64Counter {
+	return scheduleCount
+}
+
+func StaleGauge() metric.Int64Gauge {
+	return staleGauge
+}
+
+func SetPrerequisitesCounter() metric.Int64Counter {
+	return prereqCount
+}
@@ -20,4 +20,10 @@ const (
 	Username                 = "username"
 	Password                 = "password"
 	SessionCookieName        = "feature-ui-session"
+	FlagSetCookieName        = "feature-ui-set"
+	EnvironmentCookieName    = "feature-ui-environment"
+	// FlagSetMetadata is the request metadata selecting the flag set of a call
+	FlagSetMetadata = "feature-set"
+	// EnvironmentMetadata is the request metadata selecting the environment of a call
+	EnvironmentMetadata = "feature-environment"
 )
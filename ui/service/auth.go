@@ -1,16 +1,18 @@
 package service
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
-	"io"
 	"log/slog"
 	"net/http"
 
 	"github.com/dkrizic/feature/ui/constant"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/codes"
+	grpccodes "google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -103,26 +105,22 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 		username := r.FormValue("username")
 		password := r.FormValue("password")
 
-		// Validate credentials by calling backend GetAll
+		// Validate credentials by calling the backend with them
 		// Use request context for proper tracing and cancellation
-		auth := &basicAuthCreds{
-			username: username,
-			password: password,
-		}
-		md, err := auth.GetRequestMetadata(ctx)
-		if err != nil {
-			slog.ErrorContext(ctx, "Failed to generate auth metadata", "error", err)
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			span.SetStatus(codes.Error, err.Error())
-			return
-		}
-		authCtx := metadata.AppendToOutgoingContext(ctx, "authorization", md["authorization"])
-
-		// Try to call GetAll with provided credentials
-		// Note: This validates credentials by attempting a backend call.
-		// The backend will log authentication attempts.
-		stream, err := s.featureClient.GetAll(authCtx, &emptypb.Empty{})
-		if err != nil {
+		if err := s.checkCredentials(ctx, username, password); err != nil {
+			var message string
+			switch status.Code(err) {
+			case grpccodes.Unauthenticated:
+				message = "Invalid username or password"
+			case grpccodes.ResourceExhausted:
+				message = "Too many failed attempts, try again later"
+			default:
+				// not a rejection of the credentials, e.g. the backend is unreachable
+				slog.ErrorContext(ctx, "Failed to check credentials", "username", username, "error", err)
+				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				span.SetStatus(codes.Error, err.Error())
+				return
+			}
 			// Backend validation failed - backend will log this
 			slog.WarnContext(ctx, "Backend authentication failed", "username", username, "error", err)
 			data := struct {
@@ -130,7 +128,7 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 				Error   string
 			}{
 				Subpath: s.subpath,
-				Error:   "Invalid username or password",
+				Error:   message,
 			}
 			if err := s.templates.ExecuteTemplate(w, "login.gohtml", data); err != nil {
 				slog.ErrorContext(ctx, "Failed to render login template", "error", err)
@@ -140,20 +138,6 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// Drain the stream (we don't need the data, just validation)
-		// Note: This is necessary because GetAll returns a stream. In production,
-		// consider using a dedicated authentication endpoint for efficiency.
-		for {
-			_, err := stream.Recv()
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				slog.WarnContext(ctx, "Error reading stream during auth", "error", err)
-				break
-			}
-		}
-
 		// Authentication successful - create session
 		sessionID, err := generateSessionID()
 		if err != nil {
@@ -209,6 +193,27 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// checkCredentials validates the credentials by calling the backend GetPermissions with them,
+// which every authenticated user may call whatever their roles. A PermissionDenied still means
+// the backend accepted the credentials, the roles only limit what the UI shows.
+func (s *Server) checkCredentials(ctx context.Context, username, password string) error {
+	auth := &basicAuthCreds{
+		username: username,
+		password: password,
+	}
+	md, err := auth.GetRequestMetadata(ctx)
+	if err != nil {
+		return err
+	}
+	authCtx := metadata.AppendToOutgoingContext(ctx, "authorization", md["authorization"])
+
+	_, err = s.featureClient.GetPermissions(authCtx, &emptypb.Empty{})
+	if status.Code(err) == grpccodes.PermissionDenied {
+		return nil
+	}
+	return err
+}
+
 // handleLogout logs out the user
 func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
 	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "handleLogout")
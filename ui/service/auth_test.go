@@ -0,0 +1,86 @@
+package service
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	featurev1 "github.com/dkrizic/feature/ui/repository/feature/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHandleLogin(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedError  string
+		expectedStatus int
+	}{
+		{
+			name: "valid credentials",
+			err:  nil,
+		},
+		{
+			name: "roles without the method",
+			err:  status.Error(codes.PermissionDenied, "permission denied"),
+		},
+		{
+			name:          "invalid credentials",
+			err:           status.Error(codes.Unauthenticated, "invalid credentials"),
+			expectedError: "Invalid username or password",
+		},
+		{
+			name:          "locked out",
+			err:           status.Error(codes.ResourceExhausted, "too many failed attempts, try again later"),
+			expectedError: "Too many failed attempts, try again later",
+		},
+		{
+			name:           "backend unavailable",
+			err:            status.Error(codes.Unavailable, "connection refused"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockFeatureClient := &MockFeatureClient{
+				permissions:    &featurev1.Permissions{Grants: []*featurev1.Grant{{Method: "Restart"}}},
+				permissionsErr: tt.err,
+			}
+
+			server := &Server{
+				templates:             template.Must(template.New("login.gohtml").Parse(`Error: {{.Error}}`)),
+				featureClient:         mockFeatureClient,
+				authEnabled:           true,
+				authenticatedSessions: make(map[string]*sessionCredentials),
+			}
+
+			form := url.Values{"username": {"alice"}, "password": {"wonderland"}}
+			req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+
+			server.handleLogin(w, req)
+
+			if tt.expectedStatus != 0 {
+				assert.Equal(t, tt.expectedStatus, w.Code)
+				assert.NotContains(t, w.Body.String(), "Invalid username or password")
+				assert.Empty(t, server.authenticatedSessions)
+				return
+			}
+			if tt.expectedError == "" {
+				assert.Equal(t, http.StatusSeeOther, w.Code)
+				assert.Len(t, server.authenticatedSessions, 1)
+				return
+			}
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedError)
+			assert.Empty(t, server.authenticatedSessions)
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dkrizic/feature/ui/constant"
+	featurev1 "github.com/dkrizic/feature/ui/repository/feature/v1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// MatrixCell is the state of a feature in one environment
+type MatrixCell struct {
+	Environment string
+	Value       string
+	Present     bool
+	Redacted    bool
+	// PromoteTo is the next environment the feature can be promoted to, empty for the last one
+	PromoteTo string
+}
+
+// MatrixRow is a feature across all environments
+type MatrixRow struct {
+	Key   string
+	Cells []MatrixCell
+	// Differs is set if the feature is missing or has another value in one of the environments
+	Differs bool
+}
+
+// environment returns the environment selected in the browser, empty for the default one
+func (s *Server) environment(r *http.Request) string {
+	cookie, err := r.Cookie(constant.EnvironmentCookieName)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// environments returns the environments of the backend, none if it has none or does not support them
+func (s *Server) environments(r *http.Request) []string {
+	ctx := r.Context()
+	result, err := s.featureClient.ListEnvironments(s.getAuthenticatedContext(ctx, r), &emptypb.Empty{})
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to list environments", "error", err)
+		return nil
+	}
+	return result.Names
+}
+
+// handleEnvironmentsList renders the environment selector. It stays empty if the backend has no
+// environments.
+func (s *Server) handleEnvironmentsList(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "handleEnvironmentsList")
+	defer span.End()
+
+	environments := s.environments(r.WithContext(ctx))
+	if len(environments) == 0 {
+		return
+	}
+
+	selected := s.environment(r)
+	if !slices.Contains(environments, selected) {
+		selected = environments[0]
+	}
+	data := struct {
+		Environments []string
+		Selected     string
+		Subpath      string
+	}{
+		Environments: environments,
+		Selected:     selected,
+		Subpath:      s.subpath,
+	}
+
+	if err := s.templates.ExecuteTemplate(w, "environments.gohtml", data); err != nil {
+		slog.ErrorContext(ctx, "Failed to render environments template", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+}
+
+// handleEnvironmentSelect remembers the selected environment in a cookie and reloads the page
+func (s *Server) handleEnvironmentSelect(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "handleEnvironmentSelect")
+	defer span.End()
+
+	environment := r.FormValue("environment")
+	slog.InfoContext(ctx, "Selecting environment", "environment", environment)
+
+	cookie := &http.Cookie{
+		Name:     constant.EnvironmentCookieName,
+		Value:    environment,
+		Path:     s.subpath + "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	}
+	if environment == "" {
+		cookie.MaxAge = -1
+	}
+	http.SetCookie(w, cookie)
+
+	// the whole page shows the selected environment, including the watch
+	http.Redirect(w, r, s.subpath+"/", http.StatusSeeOther)
+}
+
+// handleEnvironmentMatrix renders the features of the selected flag set side by side in all
+// environments. It stays empty if the backend has no environments.
+func (s *Server) handleEnvironmentMatrix(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "handleEnvironmentMatrix")
+	defer span.End()
+
+	environments := s.environments(r.WithContext(ctx))
+	if len(environments) == 0 {
+		return
+	}
+
+	rows := make(map[string]*MatrixRow)
+	for i, environment := range environments {
+		stream, err := s.featureClient.GetAll(s.contextIn(ctx, r, environment), &emptypb.Empty{})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to call GetAll", "environment", environment, "error", err)
+			http.Error(w, "Failed to fetch features", http.StatusInternalServerError)
+			span.SetStatus(codes.Error, err.Error())
+			return
+		}
+		for {
+			kv, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to receive from stream", "environment", environment, "error", err)
+				http.Error(w, "Failed to fetch features", http.StatusInternalServerError)
+				span.SetStatus(codes.Error, err.Error())
+				return
+			}
+			row, ok := rows[kv.Key]
+			if !ok {
+				row = &MatrixRow{Key: kv.Key, Cells: make([]MatrixCell, len(environments))}
+				for j := range row.Cells {
+					row.Cells[j].Environment = environments[j]
+				}
+				rows[kv.Key] = row
+			}
+			row.Cells[i] = MatrixCell{Environment: environment, Value: kv.Value, Present: true, Redacted: kv.Redacted}
+		}
+	}
+
+	data := struct {
+		Environments []string
+		Rows         []MatrixRow
+		Subpath      string
+	}{
+		Environments: environments,
+		Subpath:      s.subpath,
+	}
+	permissions := s.permissions(ctx, r)
+	for _, row := range rows {
+		for i, cell := range row.Cells {
+			if cell.Present != row.Cells[0].Present || cell.Value != row.Cells[0].Value || cell.Redacted != row.Cells[0].Redacted {
+				row.Differs = true
+			}
+			if cell.Present && i+1 < len(environments) && permissions.CanChange("Promote", row.Key) {
+				row.Cells[i].PromoteTo = environments[i+1]
+			}
+		}
+		data.Rows = append(data.Rows, *row)
+	}
+	slices.SortFunc(data.Rows, func(a, b MatrixRow) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	if err := s.templates.ExecuteTemplate(w, "matrix.gohtml", data); err != nil {
+		slog.ErrorContext(ctx, "Failed to render matrix template", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+}
+
+// handleEnvironmentPromote previews the promotion of a feature with dry_run=true, or promotes it
+// and re-renders the matrix.
+func (s *Server) handleEnvironmentPromote(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "handleEnvironmentPromote")
+	defer span.End()
+
+	// Parse form data
+	if err := r.ParseForm(); err != nil {
+		slog.ErrorContext(ctx, "Failed to parse form", "error", err)
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	req := &featurev1.PromoteRequest{
+		Key:    r.FormValue("key"),
+		From:   r.FormValue("from"),
+		To:     r.FormValue("to"),
+		DryRun: r.FormValue("dry_run") == "true",
+	}
+	if req.Key == "" || req.From == "" || req.To == "" {
+		slog.ErrorContext(ctx, "Missing key, from or to parameter")
+		http.Error(w, "Missing key, from or to parameter", http.StatusBadRequest)
+		span.SetStatus(codes.Error, "Missing key, from or to parameter")
+		return
+	}
+	if revision := r.FormValue("revision"); revision != "" {
+		var err error
+		req.Revision, err = strconv.ParseUint(revision, 10, 64)
+		if err != nil {
+			slog.ErrorContext(ctx, "Invalid revision", "revision", revision, "error", err)
+			http.Error(w, "Invalid revision", http.StatusBadRequest)
+			span.SetStatus(codes.Error, err.Error())
+			return
+		}
+	}
+
+	result, err := s.featureClient.Promote(s.getAuthenticatedContext(ctx, r), req)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to promote feature", "key", req.Key, "from", req.From, "to", req.To, "error", err)
+		switch status.Code(err) {
+		case grpccodes.PermissionDenied:
+			http.Error(w, status.Convert(err).Message(), http.StatusForbidden)
+		case grpccodes.NotFound:
+			http.Error(w, status.Convert(err).Message(), http.StatusNotFound)
+		default:
+			writeSetError(w, err, "Failed to promote feature")
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	if !req.DryRun {
+		slog.InfoContext(ctx, "Feature promoted", "key", req.Key, "from", req.From, "to", req.To, "applied", result.Applied)
+		s.handleEnvironmentMatrix(w, r)
+		return
+	}
+
+	data := struct {
+		Key      string
+		From     string
+		To       string
+		Changes  []string
+		Revision uint64
+		Subpath  string
+	}{
+		Key:      req.Key,
+		From:     req.From,
+		To:       req.To,
+		Changes:  result.Changes,
+		Revision: result.GetTarget().GetRevision(),
+		Subpath:  s.subpath,
+	}
+	if err := s.templates.ExecuteTemplate(w, "promote.gohtml", data); err != nil {
+		slog.ErrorContext(ctx, "Failed to render promote template", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	featurev1 "github.com/dkrizic/feature/ui/repository/feature/v1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// layout of the dependency graph in SVG user units
+const (
+	graphNodeWidth  = 180
+	graphNodeHeight = 32
+	graphColumnGap  = 100
+	graphRowGap     = 20
+	graphMargin     = 10
+)
+
+// GraphNode is a feature in the dependency graph, prerequisites are placed left of the features
+// that require them
+type GraphNode struct {
+	Key      string
+	Value    string
+	Redacted bool
+	// Missing is set for prerequisites that no longer exist
+	Missing bool
+	X       int
+	Y       int
+}
+
+// GraphEdge connects a prerequisite to a feature that requires it
+type GraphEdge struct {
+	From  string
+	To    string
+	Value string
+	// Unmet is set if the stored value of the prerequisite is not the expected one, rules and
+	// rollouts of the prerequisite may still meet it for some callers
+	Unmet bool
+	// Path is the SVG path of the edge, LabelX and LabelY the position of the expected value
+	Path   string
+	LabelX int
+	LabelY int
+}
+
+// Graph is the dependency graph of the features of a flag set
+type Graph struct {
+	Nodes  []GraphNode
+	Edges  []GraphEdge
+	Width  int
+	Height int
+	// NodeWidth and NodeHeight are the size of a node
+	NodeWidth  int
+	NodeHeight int
+}
+
+// buildGraph lays out the features that have or are prerequisites in columns by their depth, the
+// length of the longest chain of prerequisites below them
+func buildGraph(features []*featurev1.KeyValue) Graph {
+	byKey := make(map[string]*featurev1.KeyValue, len(features))
+	involved := make(map[string]bool)
+	for _, kv := range features {
+		byKey[kv.Key] = kv
+		for _, p := range kv.Prerequisites {
+			involved[kv.Key] = true
+			involved[p.Key] = true
+		}
+	}
+
+	depths := make(map[string]int)
+	var depth func(key string, visiting map[string]bool) int
+	depth = func(key string, visiting map[string]bool) int {
+		if d, ok := depths[key]; ok {
+			return d
+		}
+		if visiting[key] {
+			// the backend rejects cycles, but do not loop forever on stored ones
+			return 0
+		}
+		visiting[key] = true
+		d := 0
+		for _, p := range byKey[key].GetPrerequisites() {
+			d = max(d, depth(p.Key, visiting)+1)
+		}
+		depths[key] = d
+		return d
+	}
+	var columns [][]string
+	for key := range involved {
+		d := depth(key, map[string]bool{})
+		for len(columns) <= d {
+			columns = append(columns, nil)
+		}
+		columns[d] = append(columns[d], key)
+	}
+
+	graph := Graph{NodeWidth: graphNodeWidth, NodeHeight: graphNodeHeight}
+	position := make(map[string]GraphNode)
+	rows := 0
+	for d, keys := range columns {
+		slices.Sort(keys)
+		for i, key := range keys {
+			node := GraphNode{
+				Key: key,
+				X:   graphMargin + d*(graphNodeWidth+graphColumnGap),
+				Y:   graphMargin + i*(graphNodeHeight+graphRowGap),
+			}
+			if kv, ok := byKey[key]; ok {
+				node.Value = kv.Value
+				node.Redacted = kv.Redacted
+			} else {
+				node.Missing = true
+			}
+			position[key] = node
+			graph.Nodes = append(graph.Nodes, node)
+		}
+		rows = max(rows, len(keys))
+	}
+	graph.Width = 2*graphMargin + len(columns)*graphNodeWidth + max(len(columns)-1, 0)*graphColumnGap
+	graph.Height = 2*graphMargin + rows*graphNodeHeight + max(rows-1, 0)*graphRowGap
+
+	for _, node := range graph.Nodes {
+		kv, ok := byKey[node.Key]
+		if !ok {
+			continue
+		}
+		for _, p := range kv.Prerequisites {
+			from := position[p.Key]
+			x1, y1 := from.X+graphNodeWidth, from.Y+graphNodeHeight/2
+			x2, y2 := node.X, node.Y+graphNodeHeight/2
+			prerequisite, exists := byKey[p.Key]
+			graph.Edges = append(graph.Edges, GraphEdge{
+				From:   p.Key,
+				To:     node.Key,
+				Value:  p.Value,
+				Unmet:  !exists || (!prerequisite.Redacted && prerequisite.Value != p.Value),
+				Path:   edgePath(x1, y1, x2, y2),
+				LabelX: (x1 + x2) / 2,
+				LabelY: (y1+y2)/2 - 4,
+			})
+		}
+	}
+	return graph
+}
+
+// edgePath is a horizontal S-curve from (x1, y1) to (x2, y2)
+func edgePath(x1, y1, x2, y2 int) string {
+	mid := (x1 + x2) / 2
+	return fmt.Sprintf("M %d %d C %d %d, %d %d, %d %d", x1, y1, mid, y1, mid, y2, x2, y2)
+}
+
+// handleFeatureGraph renders the prerequisites of the features of the selected flag set and
+// environment as a graph. It stays empty if no feature has prerequisites.
+func (s *Server) handleFeatureGraph(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "handleFeatureGraph")
+	defer span.End()
+
+	stream, err := s.featureClient.GetAll(s.getAuthenticatedContext(ctx, r), &emptypb.Empty{})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to call GetAll", "error", err)
+		http.Error(w, "Failed to fetch features", http.StatusInternalServerError)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	var features []*featurev1.KeyValue
+	for {
+		kv, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to receive from stream", "error", err)
+			http.Error(w, "Failed to fetch features", http.StatusInternalServerError)
+			span.SetStatus(codes.Error, err.Error())
+			return
+		}
+		features = append(features, kv)
+	}
+
+	graph := buildGraph(features)
+	if len(graph.Edges) == 0 {
+		return
+	}
+	if err := s.templates.ExecuteTemplate(w, "graph.gohtml", graph); err != nil {
+		slog.ErrorContext(ctx, "Failed to render graph template", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+}
@@ -2,11 +2,16 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	featurev1 "github.com/dkrizic/feature/ui/repository/feature/v1"
@@ -15,7 +20,10 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/codes"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // Feature represents a feature flag with a key and value.
@@ -23,6 +31,253 @@ type Feature struct {
 	Key      string
 	Value    string
 	Editable bool
+	// Redacted is set for sensitive features whose values the user may not see, Value is empty
+	Redacted bool
+	// Type selects the editor: string, boolean, integer, float, enum or json
+	Type          string
+	AllowedValues []string
+	Min           *float64
+	Max           *float64
+	// Metadata documenting the feature
+	Description    string
+	Owner          string
+	Tags           []string
+	UpdatedAt      time.Time
+	LastModifiedBy string
+	ExpiresAt      time.Time
+	// Lifecycle is temporary, permanent or empty
+	Lifecycle string
+	// Stale is the reason the feature is stale, expired or unused, empty if it is not
+	Stale string
+	// Rules is the JSON of the targeting rules as shown in the rules editor
+	Rules     string
+	RuleCount int
+	// BucketBy and Variants describe the percentage rollout, Variants as "value=percentage, ..."
+	BucketBy string
+	Variants string
+	// Prerequisites as "KEY=value, ..." and the value served if one is not met
+	Prerequisites string
+	OffValue      string
+	// Deletable is set if the roles of the user allow deleting the feature
+	Deletable bool
+	// Revision is sent back on update so that changes made by someone else in the meantime are detected
+	Revision uint64
+}
+
+// HistoryEntry is one change of a feature as shown in the history panel
+type HistoryEntry struct {
+	Revision uint64
+	// Action is create, update or delete
+	Action    string
+	OldValue  string
+	NewValue  string
+	Actor     string
+	Timestamp time.Time
+	Source    string
+	// Redacted is set when the values of a sensitive feature are withheld
+	Redacted bool
+}
+
+// featureFilter selects features by key and metadata, empty fields match everything
+type featureFilter struct {
+	Search string
+	Owner  string
+	Tag    string
+}
+
+// parseFeatureFilter reads the filter from the request. The fields are prefixed so they do not
+// clash with the create form, which submits them along with its own fields.
+func parseFeatureFilter(r *http.Request) featureFilter {
+	return featureFilter{
+		Search: strings.TrimSpace(r.FormValue("filter-search")),
+		Owner:  strings.TrimSpace(r.FormValue("filter-owner")),
+		Tag:    strings.TrimSpace(r.FormValue("filter-tag")),
+	}
+}
+
+func (f featureFilter) matches(feature Feature) bool {
+	if f.Owner != "" && !strings.EqualFold(f.Owner, feature.Owner) {
+		return false
+	}
+	if f.Tag != "" && !slices.Contains(feature.Tags, f.Tag) {
+		return false
+	}
+	if f.Search != "" {
+		search := strings.ToLower(f.Search)
+		fields := append([]string{feature.Key, feature.Description, feature.Owner}, feature.Tags...)
+		return slices.ContainsFunc(fields, func(field string) bool {
+			return strings.Contains(strings.ToLower(field), search)
+		})
+	}
+	return true
+}
+
+// valueTypes are the types offered when creating a feature
+var valueTypes = []string{"string", "boolean", "integer", "float", "enum", "json"}
+
+// typeName returns the lower-case name of a value type, e.g. "boolean"
+func typeName(t featurev1.ValueType) string {
+	if t == featurev1.ValueType_VALUE_TYPE_UNSPECIFIED {
+		return "string"
+	}
+	return strings.ToLower(strings.TrimPrefix(t.String(), "VALUE_TYPE_"))
+}
+
+// splitList splits a comma-separated form value and drops empty entries
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// ruleJSON is the editable form of a targeting rule, operators use their lower-case names
+type ruleJSON struct {
+	Name       string          `json:"name,omitempty"`
+	Conditions []conditionJSON `json:"conditions"`
+	Value      string          `json:"value"`
+	Rollout    *rolloutJSON    `json:"rollout,omitempty"`
+}
+
+type rolloutJSON struct {
+	BucketBy string        `json:"bucketBy,omitempty"`
+	Variants []variantJSON `json:"variants"`
+}
+
+type variantJSON struct {
+	Value      string  `json:"value"`
+	Percentage float64 `json:"percentage"`
+}
+
+type conditionJSON struct {
+	Attribute string   `json:"attribute"`
+	Operator  string   `json:"operator"`
+	Values    []string `json:"values"`
+}
+
+// rulesToJSON renders the rules for the rules editor, an empty list if there are none
+func rulesToJSON(rules []*featurev1.Rule) string {
+	editable := make([]ruleJSON, 0, len(rules))
+	for _, rule := range rules {
+		r := ruleJSON{Name: rule.Name, Value: rule.Value, Conditions: []conditionJSON{}}
+		if rollout := rule.GetRollout(); len(rollout.GetVariants()) > 0 {
+			r.Rollout = &rolloutJSON{BucketBy: rollout.BucketBy}
+			for _, v := range rollout.Variants {
+				r.Rollout.Variants = append(r.Rollout.Variants, variantJSON{Value: v.Value, Percentage: v.Percentage})
+			}
+		}
+		for _, c := range rule.Conditions {
+			r.Conditions = append(r.Conditions, conditionJSON{
+				Attribute: c.Attribute,
+				Operator:  strings.ToLower(strings.TrimPrefix(c.Operator.String(), "OPERATOR_")),
+				Values:    c.Values,
+			})
+		}
+		editable = append(editable, r)
+	}
+	data, _ := json.MarshalIndent(editable, "", "  ")
+	return string(data)
+}
+
+// parseRules reads the rules submitted by the rules editor
+func parseRules(s string) ([]*featurev1.Rule, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var editable []ruleJSON
+	if err := json.Unmarshal([]byte(s), &editable); err != nil {
+		return nil, fmt.Errorf("invalid rules: %w", err)
+	}
+	rules := make([]*featurev1.Rule, 0, len(editable))
+	for _, r := range editable {
+		rule := &featurev1.Rule{Name: r.Name, Value: r.Value}
+		if r.Rollout != nil {
+			rule.Rollout = &featurev1.Rollout{BucketBy: r.Rollout.BucketBy}
+			for _, v := range r.Rollout.Variants {
+				rule.Rollout.Variants = append(rule.Rollout.Variants, &featurev1.Variant{Value: v.Value, Percentage: v.Percentage})
+			}
+		}
+		for _, c := range r.Conditions {
+			operator, ok := featurev1.Operator_value["OPERATOR_"+strings.ToUpper(c.Operator)]
+			if !ok || operator == 0 {
+				return nil, fmt.Errorf("invalid rules: unknown operator %q", c.Operator)
+			}
+			rule.Conditions = append(rule.Conditions, &featurev1.Condition{
+				Attribute: c.Attribute,
+				Operator:  featurev1.Operator(operator),
+				Values:    c.Values,
+			})
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// formatVariants renders the variants of a rollout as "value=percentage, ..."
+func formatVariants(variants []*featurev1.Variant) string {
+	formatted := make([]string, 0, len(variants))
+	for _, v := range variants {
+		formatted = append(formatted, v.Value+"="+strconv.FormatFloat(v.Percentage, 'f', -1, 64))
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// parseVariants reads comma-separated value=percentage pairs, the percentage may end with %
+func parseVariants(s string) ([]*featurev1.Variant, error) {
+	var variants []*featurev1.Variant
+	for _, pair := range splitList(s) {
+		i := strings.LastIndex(pair, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid variant %q, expected value=percentage", pair)
+		}
+		percentage, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(pair[i+1:], "%")), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid percentage in variant %q", pair)
+		}
+		variants = append(variants, &featurev1.Variant{Value: strings.TrimSpace(pair[:i]), Percentage: percentage})
+	}
+	return variants, nil
+}
+
+// formatPrerequisites renders prerequisites as "KEY=value, ..."
+func formatPrerequisites(prerequisites []*featurev1.Prerequisite) string {
+	formatted := make([]string, 0, len(prerequisites))
+	for _, p := range prerequisites {
+		formatted = append(formatted, p.Key+"="+p.Value)
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// parsePrerequisites reads comma-separated KEY=value pairs
+func parsePrerequisites(s string) ([]*featurev1.Prerequisite, error) {
+	var prerequisites []*featurev1.Prerequisite
+	for _, pair := range splitList(s) {
+		key, value, ok := strings.Cut(pair, "=")
+		if key = strings.TrimSpace(key); !ok || key == "" {
+			return nil, fmt.Errorf("invalid prerequisite %q, expected KEY=value", pair)
+		}
+		prerequisites = append(prerequisites, &featurev1.Prerequisite{Key: key, Value: strings.TrimSpace(value)})
+	}
+	return prerequisites, nil
+}
+
+// writeSetError reports a failed Set, validation errors are shown to the user as they are
+func writeSetError(w http.ResponseWriter, err error, fallback string) {
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case grpccodes.InvalidArgument:
+			http.Error(w, st.Message(), http.StatusBadRequest)
+			return
+		case grpccodes.FailedPrecondition:
+			// the feature was changed by someone else since the page was loaded
+			http.Error(w, st.Message(), http.StatusConflict)
+			return
+		}
+	}
+	http.Error(w, fallback, http.StatusInternalServerError)
 }
 
 // registerHandlers registers all HTTP handlers on the provided mux.
@@ -40,7 +295,27 @@ func (s *Server) registerHandlers(mux *http.ServeMux) {
 	mux.HandleFunc("GET "+prefix+"/features/list", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleFeaturesList), "handleFeaturesList").ServeHTTP))
 	mux.HandleFunc("POST "+prefix+"/features/create", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleFeatureCreate), "handleFeatureCreate").ServeHTTP))
 	mux.HandleFunc("POST "+prefix+"/features/update", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleFeatureUpdate), "handleFeatureUpdate").ServeHTTP))
+	mux.HandleFunc("POST "+prefix+"/features/rules", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleFeatureRules), "handleFeatureRules").ServeHTTP))
+	mux.HandleFunc("POST "+prefix+"/features/rollout", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleFeatureRollout), "handleFeatureRollout").ServeHTTP))
+	mux.HandleFunc("POST "+prefix+"/features/prerequisites", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleFeaturePrerequisites), "handleFeaturePrerequisites").ServeHTTP))
+	mux.HandleFunc("GET "+prefix+"/features/graph", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleFeatureGraph), "handleFeatureGraph").ServeHTTP))
+	mux.HandleFunc("GET "+prefix+"/features/history", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleFeatureHistory), "handleFeatureHistory").ServeHTTP))
+	mux.HandleFunc("POST "+prefix+"/features/rollback", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleFeatureRollback), "handleFeatureRollback").ServeHTTP))
+	mux.HandleFunc("POST "+prefix+"/features/batch", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleFeatureBatch), "handleFeatureBatch").ServeHTTP))
 	mux.HandleFunc("POST "+prefix+"/features/delete", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleFeatureDelete), "handleFeatureDelete").ServeHTTP))
+	mux.HandleFunc("GET "+prefix+"/features/watch", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleFeatureWatch), "handleFeatureWatch").ServeHTTP))
+	mux.HandleFunc("GET "+prefix+"/sets/list", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleSetsList), "handleSetsList").ServeHTTP))
+	mux.HandleFunc("POST "+prefix+"/sets/select", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleSetSelect), "handleSetSelect").ServeHTTP))
+	mux.HandleFunc("GET "+prefix+"/environments/list", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleEnvironmentsList), "handleEnvironmentsList").ServeHTTP))
+	mux.HandleFunc("POST "+prefix+"/environments/select", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleEnvironmentSelect), "handleEnvironmentSelect").ServeHTTP))
+	mux.HandleFunc("GET "+prefix+"/environments/matrix", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleEnvironmentMatrix), "handleEnvironmentMatrix").ServeHTTP))
+	mux.HandleFunc("POST "+prefix+"/environments/promote", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleEnvironmentPromote), "handleEnvironmentPromote").ServeHTTP))
+	mux.HandleFunc("GET "+prefix+"/schedules/list", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleSchedulesList), "handleSchedulesList").ServeHTTP))
+	mux.HandleFunc("POST "+prefix+"/schedules/create", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleScheduleCreate), "handleScheduleCreate").ServeHTTP))
+	mux.HandleFunc("POST "+prefix+"/schedules/cancel", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleScheduleCancel), "handleScheduleCancel").ServeHTTP))
+	mux.HandleFunc("GET "+prefix+"/tokens/list", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleTokensList), "handleTokensList").ServeHTTP))
+	mux.HandleFunc("POST "+prefix+"/tokens/create", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleTokenCreate), "handleTokenCreate").ServeHTTP))
+	mux.HandleFunc("POST "+prefix+"/tokens/revoke", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleTokenRevoke), "handleTokenRevoke").ServeHTTP))
 	mux.HandleFunc("POST "+prefix+"/restart", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleRestart), "handleRestart").ServeHTTP))
 	mux.HandleFunc("GET "+prefix+"/version", s.requireAuth(otelhttp.NewHandler(http.HandlerFunc(s.handleVersion), "handleVersion").ServeHTTP))
 	
@@ -61,6 +336,7 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 		RestartName    string
 		RestartType    string
 		AuthEnabled    bool
+		CanRestart     bool
 	}{
 		UIVersion:      s.uiVersion,
 		BackendVersion: s.backendVersion,
@@ -70,6 +346,22 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 		RestartType:    s.restartType,
 		AuthEnabled:    s.authEnabled,
 	}
+	if s.restartEnabled {
+		data.CanRestart = s.permissions(ctx, r).Can("Restart")
+	}
+
+	// another flag set than the default one has its own restart target
+	if s.flagSet(r) != "" {
+		data.RestartEnabled = false
+		info, err := s.workloadClient.Info(s.getAuthenticatedContext(ctx, r), &workloadv1.InfoRequest{})
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to fetch service info of the flag set", "set", s.flagSet(r), "error", err)
+		} else {
+			data.RestartEnabled = info.Enabled
+			data.RestartName = info.Name
+			data.RestartType = info.Type.String()
+		}
+	}
 
 	if err := s.templates.ExecuteTemplate(w, "index.gohtml", data); err != nil {
 		slog.ErrorContext(ctx, "Failed to render index template", "error", err)
@@ -98,8 +390,13 @@ func (s *Server) handleFeaturesList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Collect all features from the stream
+	permissions := s.permissions(ctx, r)
+
+	// Collect all features from the stream that pass the filter
+	filter := parseFeatureFilter(r)
 	var features []Feature
+	// Restrictions are active if at least one field is not editable, filtered or not
+	restrictionsActive := false
 
 	for {
 		kv, err := stream.Recv()
@@ -114,11 +411,65 @@ func (s *Server) handleFeaturesList(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		features = append(features, Feature{
+		feature := Feature{
 			Key:      kv.Key,
 			Value:    kv.Value,
 			Editable: kv.Editable,
-		})
+			Redacted: kv.Redacted,
+			Type:     typeName(kv.Type),
+			Revision: kv.Revision,
+		}
+		if c := kv.GetConstraints(); c != nil {
+			feature.AllowedValues = c.AllowedValues
+			feature.Min = c.Min
+			feature.Max = c.Max
+		}
+		if m := kv.GetMetadata(); m != nil {
+			feature.Description = m.Description
+			feature.Owner = m.Owner
+			feature.Tags = m.Tags
+			feature.LastModifiedBy = m.LastModifiedBy
+			if m.UpdatedAt != nil {
+				feature.UpdatedAt = m.UpdatedAt.AsTime()
+			}
+			if m.ExpiresAt != nil {
+				feature.ExpiresAt = m.ExpiresAt.AsTime()
+			}
+			if m.Lifecycle != featurev1.Lifecycle_LIFECYCLE_UNSPECIFIED {
+				feature.Lifecycle = strings.ToLower(strings.TrimPrefix(m.Lifecycle.String(), "LIFECYCLE_"))
+			}
+		}
+		feature.Rules = rulesToJSON(kv.Rules)
+		feature.RuleCount = len(kv.Rules)
+		if rollout := kv.GetRollout(); rollout != nil {
+			feature.BucketBy = rollout.BucketBy
+			feature.Variants = formatVariants(rollout.Variants)
+		}
+		feature.Prerequisites = formatPrerequisites(kv.Prerequisites)
+		feature.OffValue = kv.OffValue
+		if !feature.Editable {
+			restrictionsActive = true
+		}
+		// the roles of the user restrict the features further, but not creating and deleting of others
+		feature.Editable = feature.Editable && permissions.CanChange("Set", kv.Key)
+		feature.Deletable = permissions.CanChange("Delete", kv.Key)
+		if !filter.matches(feature) {
+			continue
+		}
+		features = append(features, feature)
+	}
+
+	// Stale features are highlighted, the list is shown without if they are unavailable
+	stale, err := s.featureClient.ListStale(authCtx, &emptypb.Empty{})
+	if err != nil && status.Code(err) != grpccodes.Unimplemented {
+		slog.WarnContext(ctx, "Failed to list stale features", "error", err)
+	}
+	reasons := make(map[string]string)
+	for _, flag := range stale.GetFlags() {
+		reasons[flag.Key] = strings.ToLower(strings.TrimPrefix(flag.Reason.String(), "STALE_REASON_"))
+	}
+	for i := range features {
+		features[i].Stale = reasons[features[i].Key]
 	}
 
 	// Sort features alphabetically by key
@@ -126,23 +477,20 @@ func (s *Server) handleFeaturesList(w http.ResponseWriter, r *http.Request) {
 		return features[i].Key < features[j].Key
 	})
 
-	// Check if restrictions are active (at least one field is not editable)
-	restrictionsActive := false
-	for _, f := range features {
-		if !f.Editable {
-			restrictionsActive = true
-			break
-		}
-	}
-
 	data := struct {
 		Features           []Feature
 		Subpath            string
 		RestrictionsActive bool
+		ValueTypes         []string
+		Filtered           bool
+		CanCreate          bool
 	}{
 		Features:           features,
 		Subpath:            s.subpath,
 		RestrictionsActive: restrictionsActive,
+		ValueTypes:         valueTypes,
+		Filtered:           filter != featureFilter{},
+		CanCreate:          permissions.Can("Set"),
 	}
 
 	if err := s.templates.ExecuteTemplate(w, "features_list.gohtml", data); err != nil {
@@ -177,15 +525,51 @@ func (s *Server) handleFeatureCreate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	value := r.FormValue("value")
+	kv := &featurev1.KeyValue{Key: key, Value: value}
+
+	// An optional type, enums also need their allowed values
+	if valueType := r.FormValue("type"); valueType != "" {
+		t, ok := featurev1.ValueType_value["VALUE_TYPE_"+strings.ToUpper(valueType)]
+		if !ok {
+			slog.ErrorContext(ctx, "Invalid type parameter", "type", valueType)
+			http.Error(w, "Invalid type parameter", http.StatusBadRequest)
+			span.SetStatus(codes.Error, "Invalid type parameter")
+			return
+		}
+		kv.Type = featurev1.ValueType(t)
+	}
+	if values := splitList(r.FormValue("allowed")); len(values) > 0 {
+		kv.Constraints = &featurev1.Constraints{AllowedValues: values}
+	}
+
+	// Optional metadata documenting the feature
+	description := strings.TrimSpace(r.FormValue("description"))
+	owner := strings.TrimSpace(r.FormValue("owner"))
+	tags := splitList(r.FormValue("tags"))
+	lifecycle := featurev1.Lifecycle(featurev1.Lifecycle_value["LIFECYCLE_"+strings.ToUpper(r.FormValue("lifecycle"))])
+	var expiresAt *timestamppb.Timestamp
+	if expires := r.FormValue("expires"); expires != "" {
+		t, err := time.Parse(time.DateOnly, expires)
+		if err != nil {
+			slog.ErrorContext(ctx, "Invalid expires parameter", "expires", expires)
+			http.Error(w, "Invalid expires parameter", http.StatusBadRequest)
+			span.SetStatus(codes.Error, "Invalid expires parameter")
+			return
+		}
+		expiresAt = timestamppb.New(t)
+	}
+	if description != "" || owner != "" || len(tags) > 0 || expiresAt != nil || lifecycle != featurev1.Lifecycle_LIFECYCLE_UNSPECIFIED {
+		kv.Metadata = &featurev1.Metadata{Description: description, Owner: owner, Tags: tags, ExpiresAt: expiresAt, Lifecycle: lifecycle}
+	}
 
 	// Get authenticated context with credentials from session
 	authCtx := s.getAuthenticatedContext(ctx, r)
 
 	// Call the gRPC backend to set (upsert)
-	_, err := s.featureClient.Set(authCtx, &featurev1.KeyValue{Key: key, Value: value})
+	_, err := s.featureClient.Set(authCtx, kv)
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to create feature", "key", key, "error", err)
-		http.Error(w, "Failed to create feature", http.StatusInternalServerError)
+		writeSetError(w, err, "Failed to create feature")
 		span.SetStatus(codes.Error, err.Error())
 		return
 	}
@@ -219,14 +603,27 @@ func (s *Server) handleFeatureUpdate(w http.ResponseWriter, r *http.Request) {
 
 	value := r.FormValue("value")
 
+	// The revision the page was rendered with, an empty revision overwrites unconditionally
+	var revision uint64
+	if raw := r.FormValue("revision"); raw != "" {
+		var err error
+		revision, err = strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			slog.ErrorContext(ctx, "Invalid revision parameter", "revision", raw)
+			http.Error(w, "Invalid revision parameter", http.StatusBadRequest)
+			span.SetStatus(codes.Error, "Invalid revision parameter")
+			return
+		}
+	}
+
 	// Get authenticated context with credentials from session
 	authCtx := s.getAuthenticatedContext(ctx, r)
 
 	// Call the gRPC backend to set (update)
-	_, err := s.featureClient.Set(authCtx, &featurev1.KeyValue{Key: key, Value: value})
+	_, err := s.featureClient.Set(authCtx, &featurev1.KeyValue{Key: key, Value: value, Revision: revision})
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to update feature", "key", key, "error", err)
-		http.Error(w, "Failed to update feature", http.StatusInternalServerError)
+		writeSetError(w, err, "Failed to update feature")
 		span.SetStatus(codes.Error, err.Error())
 		return
 	}
@@ -237,6 +634,259 @@ func (s *Server) handleFeatureUpdate(w http.ResponseWriter, r *http.Request) {
 	s.handleFeaturesList(w, r)
 }
 
+// handleFeatureRules replaces the targeting rules of a feature and re-renders the list.
+func (s *Server) handleFeatureRules(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "handleFeatureRules")
+	defer span.End()
+
+	// Parse form data
+	if err := r.ParseForm(); err != nil {
+		slog.ErrorContext(ctx, "Failed to parse form", "error", err)
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	key := r.FormValue("key")
+	if key == "" {
+		slog.ErrorContext(ctx, "Missing key parameter")
+		http.Error(w, "Missing key parameter", http.StatusBadRequest)
+		span.SetStatus(codes.Error, "Missing key parameter")
+		return
+	}
+
+	rules, err := parseRules(r.FormValue("rules"))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse rules", "key", key, "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	// Get authenticated context with credentials from session
+	authCtx := s.getAuthenticatedContext(ctx, r)
+
+	// Call the gRPC backend to replace the rules
+	_, err = s.featureClient.SetRules(authCtx, &featurev1.Rules{Key: key, Rules: rules})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to set rules", "key", key, "error", err)
+		writeSetError(w, err, "Failed to set rules")
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	slog.InfoContext(ctx, "Rules updated", "key", key, "rules", len(rules))
+
+	// Re-render the feature list by calling the list handler
+	s.handleFeaturesList(w, r)
+}
+
+// handleFeatureRollout replaces the percentage rollout of a feature and re-renders the list.
+func (s *Server) handleFeatureRollout(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "handleFeatureRollout")
+	defer span.End()
+
+	// Parse form data
+	if err := r.ParseForm(); err != nil {
+		slog.ErrorContext(ctx, "Failed to parse form", "error", err)
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	key := r.FormValue("key")
+	if key == "" {
+		slog.ErrorContext(ctx, "Missing key parameter")
+		http.Error(w, "Missing key parameter", http.StatusBadRequest)
+		span.SetStatus(codes.Error, "Missing key parameter")
+		return
+	}
+
+	variants, err := parseVariants(r.FormValue("variants"))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse variants", "key", key, "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	// Get authenticated context with credentials from session
+	authCtx := s.getAuthenticatedContext(ctx, r)
+
+	// Call the gRPC backend, no variants remove the rollout
+	_, err = s.featureClient.SetRollout(authCtx, &featurev1.SetRolloutRequest{
+		Key: key,
+		Rollout: &featurev1.Rollout{
+			BucketBy: strings.TrimSpace(r.FormValue("bucketBy")),
+			Variants: variants,
+		},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to set rollout", "key", key, "error", err)
+		writeSetError(w, err, "Failed to set rollout")
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	slog.InfoContext(ctx, "Rollout updated", "key", key, "variants", len(variants))
+
+	// Re-render the feature list by calling the list handler
+	s.handleFeaturesList(w, r)
+}
+
+// handleFeaturePrerequisites replaces the prerequisites and the off value of a feature and
+// re-renders the list.
+func (s *Server) handleFeaturePrerequisites(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "handleFeaturePrerequisites")
+	defer span.End()
+
+	// Parse form data
+	if err := r.ParseForm(); err != nil {
+		slog.ErrorContext(ctx, "Failed to parse form", "error", err)
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	key := r.FormValue("key")
+	if key == "" {
+		slog.ErrorContext(ctx, "Missing key parameter")
+		http.Error(w, "Missing key parameter", http.StatusBadRequest)
+		span.SetStatus(codes.Error, "Missing key parameter")
+		return
+	}
+
+	prerequisites, err := parsePrerequisites(r.FormValue("prerequisites"))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse prerequisites", "key", key, "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	// Get authenticated context with credentials from session
+	authCtx := s.getAuthenticatedContext(ctx, r)
+
+	// Call the gRPC backend, no prerequisites remove them
+	_, err = s.featureClient.SetPrerequisites(authCtx, &featurev1.Prerequisites{
+		Key:           key,
+		Prerequisites: prerequisites,
+		OffValue:      strings.TrimSpace(r.FormValue("offValue")),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to set prerequisites", "key", key, "error", err)
+		if status.Code(err) == grpccodes.PermissionDenied {
+			http.Error(w, status.Convert(err).Message(), http.StatusForbidden)
+		} else {
+			writeSetError(w, err, "Failed to set prerequisites")
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	slog.InfoContext(ctx, "Prerequisites updated", "key", key, "prerequisites", len(prerequisites))
+
+	// Re-render the feature list by calling the list handler
+	s.handleFeaturesList(w, r)
+}
+
+// handleFeatureHistory renders the change history of a feature, newest first.
+func (s *Server) handleFeatureHistory(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "handleFeatureHistory")
+	defer span.End()
+
+	key := r.FormValue("key")
+	if key == "" {
+		slog.ErrorContext(ctx, "Missing key parameter")
+		http.Error(w, "Missing key parameter", http.StatusBadRequest)
+		span.SetStatus(codes.Error, "Missing key parameter")
+		return
+	}
+
+	// Get authenticated context with credentials from session
+	authCtx := s.getAuthenticatedContext(ctx, r)
+
+	result, err := s.featureClient.History(authCtx, &featurev1.Key{Name: key})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to fetch history", "key", key, "error", err)
+		http.Error(w, "Failed to fetch history", http.StatusInternalServerError)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	entries := make([]HistoryEntry, 0, len(result.Entries))
+	for _, entry := range slices.Backward(result.Entries) {
+		entries = append(entries, HistoryEntry{
+			Revision:  entry.Revision,
+			Action:    strings.ToLower(strings.TrimPrefix(entry.Type.String(), "EVENT_TYPE_")),
+			OldValue:  entry.OldValue,
+			NewValue:  entry.NewValue,
+			Actor:     entry.Actor,
+			Timestamp: entry.Timestamp.AsTime(),
+			Source:    entry.Source,
+			Redacted:  entry.Redacted,
+		})
+	}
+
+	data := struct {
+		Key      string
+		Entries  []HistoryEntry
+		Editable bool
+		Subpath  string
+	}{
+		Key:      key,
+		Entries:  entries,
+		Editable: r.FormValue("editable") == "true" && s.permissions(ctx, r).CanChange("Rollback", key),
+		Subpath:  s.subpath,
+	}
+
+	if err := s.templates.ExecuteTemplate(w, "history.gohtml", data); err != nil {
+		slog.ErrorContext(ctx, "Failed to render history template", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+}
+
+// handleFeatureRollback restores a feature to a revision of its history and re-renders the list.
+func (s *Server) handleFeatureRollback(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "handleFeatureRollback")
+	defer span.End()
+
+	// Parse form data
+	if err := r.ParseForm(); err != nil {
+		slog.ErrorContext(ctx, "Failed to parse form", "error", err)
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	key := r.FormValue("key")
+	revision, err := strconv.ParseUint(r.FormValue("revision"), 10, 64)
+	if key == "" || err != nil {
+		slog.ErrorContext(ctx, "Missing key or revision parameter")
+		http.Error(w, "Missing key or revision parameter", http.StatusBadRequest)
+		span.SetStatus(codes.Error, "Missing key or revision parameter")
+		return
+	}
+
+	// Get authenticated context with credentials from session
+	authCtx := s.getAuthenticatedContext(ctx, r)
+
+	_, err = s.featureClient.Rollback(authCtx, &featurev1.RollbackRequest{Key: key, Revision: revision})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to roll back feature", "key", key, "revision", revision, "error", err)
+		writeSetError(w, err, "Failed to roll back feature")
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	slog.InfoContext(ctx, "Feature rolled back", "key", key, "revision", revision)
+
+	// Re-render the feature list by calling the list handler
+	s.handleFeaturesList(w, r)
+}
+
 // handleFeatureDelete deletes a feature and re-renders the list.
 func (s *Server) handleFeatureDelete(w http.ResponseWriter, r *http.Request) {
 	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "handleFeatureDelete")
@@ -265,7 +915,8 @@ func (s *Server) handleFeatureDelete(w http.ResponseWriter, r *http.Request) {
 	_, err := s.featureClient.Delete(authCtx, &featurev1.Key{Name: key})
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to delete feature", "key", key, "error", err)
-		http.Error(w, "Failed to delete feature", http.StatusInternalServerError)
+		// features other features depend on cannot be deleted, the backend tells which
+		writeSetError(w, err, "Failed to delete feature")
 		span.SetStatus(codes.Error, err.Error())
 		return
 	}
@@ -276,6 +927,155 @@ func (s *Server) handleFeatureDelete(w http.ResponseWriter, r *http.Request) {
 	s.handleFeaturesList(w, r)
 }
 
+// batchOperations builds the operations of the multi-edit form. Every row submits its key along
+// with value.<key>, original.<key> and revision.<key>, and delete lists the keys to delete.
+// Rows whose value was not changed are left out.
+func batchOperations(form url.Values) ([]*featurev1.Operation, error) {
+	var operations []*featurev1.Operation
+	for _, key := range form["key"] {
+		if slices.Contains(form["delete"], key) {
+			operations = append(operations, &featurev1.Operation{Operation: &featurev1.Operation_Delete{Delete: &featurev1.Key{Name: key}}})
+			continue
+		}
+		value := form.Get("value." + key)
+		if value == form.Get("original."+key) {
+			continue
+		}
+		var revision uint64
+		if raw := form.Get("revision." + key); raw != "" {
+			var err error
+			revision, err = strconv.ParseUint(raw, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid revision of %s", key)
+			}
+		}
+		operations = append(operations, &featurev1.Operation{Operation: &featurev1.Operation_Set{Set: &featurev1.KeyValue{Key: key, Value: value, Revision: revision}}})
+	}
+	return operations, nil
+}
+
+// handleFeatureBatch applies all changes of the multi-edit form at once and re-renders the list.
+func (s *Server) handleFeatureBatch(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "handleFeatureBatch")
+	defer span.End()
+
+	// Parse form data
+	if err := r.ParseForm(); err != nil {
+		slog.ErrorContext(ctx, "Failed to parse form", "error", err)
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	operations, err := batchOperations(r.PostForm)
+	if err != nil {
+		slog.ErrorContext(ctx, "Invalid batch", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	if len(operations) == 0 {
+		slog.ErrorContext(ctx, "Nothing to change")
+		http.Error(w, "Nothing to change", http.StatusBadRequest)
+		span.SetStatus(codes.Error, "Nothing to change")
+		return
+	}
+
+	// Get authenticated context with credentials from session
+	authCtx := s.getAuthenticatedContext(ctx, r)
+
+	// Call the gRPC backend to apply all changes, either all of them succeed or none
+	_, err = s.featureClient.Batch(authCtx, &featurev1.BatchRequest{Operations: operations})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to apply changes", "operations", len(operations), "error", err)
+		writeSetError(w, err, "Failed to apply changes")
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	slog.InfoContext(ctx, "Changes applied", "operations", len(operations))
+
+	// Re-render the feature list by calling the list handler
+	s.handleFeaturesList(w, r)
+}
+
+// watchEvent is the JSON payload of a server-sent change event.
+type watchEvent struct {
+	Type     string `json:"type"`
+	Key      string `json:"key,omitempty"`
+	Value    string `json:"value,omitempty"`
+	Revision uint64 `json:"revision"`
+}
+
+// handleFeatureWatch streams feature changes from the backend Watch RPC to the browser
+// as server-sent events. The revision is used as event id, so a reconnecting EventSource
+// resumes via the Last-Event-ID header without missing changes.
+func (s *Server) handleFeatureWatch(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "handleFeatureWatch")
+	defer span.End()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		slog.ErrorContext(ctx, "Streaming not supported by response writer")
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		span.SetStatus(codes.Error, "Streaming not supported")
+		return
+	}
+
+	var revision uint64
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		revision, _ = strconv.ParseUint(lastEventID, 10, 64)
+	}
+
+	// Get authenticated context with credentials from session
+	authCtx := s.getAuthenticatedContext(ctx, r)
+
+	stream, err := s.featureClient.Watch(authCtx, &featurev1.WatchRequest{Revision: revision})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to call Watch", "error", err)
+		http.Error(w, "Failed to watch features", http.StatusInternalServerError)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	slog.InfoContext(ctx, "Watching features", "revision", revision)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			if err != io.EOF && ctx.Err() == nil {
+				slog.WarnContext(ctx, "Watch stream ended", "error", err)
+			}
+			return
+		}
+
+		// the page renders the initial list itself, only changes are forwarded
+		if event.Type == featurev1.EventType_EVENT_TYPE_SNAPSHOT {
+			continue
+		}
+
+		payload, err := json.Marshal(watchEvent{
+			Type:     strings.ToLower(strings.TrimPrefix(event.Type.String(), "EVENT_TYPE_")),
+			Key:      event.KeyValue.GetKey(),
+			Value:    event.KeyValue.GetValue(),
+			Revision: event.Revision,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to encode watch event", "error", err)
+			return
+		}
+
+		fmt.Fprintf(w, "id: %d\nevent: change\ndata: %s\n\n", event.Revision, payload)
+		flusher.Flush()
+	}
+}
+
 // handleHealth is a simple health check endpoint.
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
@@ -396,8 +1196,8 @@ func (s *Server) handleRestart(w http.ResponseWriter, r *http.Request) {
 
 	slog.InfoContext(ctx, "Handling restart request for configured service")
 
-	// Validate that restart is enabled
-	if !s.restartEnabled {
+	// Validate that restart is enabled, the backend checks the target of another flag set
+	if !s.restartEnabled && s.flagSet(r) == "" {
 		slog.WarnContext(ctx, "Restart feature is not enabled")
 		http.Error(w, "Restart feature is not enabled", http.StatusForbidden)
 		span.SetStatus(codes.Error, "Restart feature is not enabled")
@@ -8,20 +8,30 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"strings"
+	"slices"
 	"testing"
+	"time"
 
+	"github.com/dkrizic/feature/ui/constant"
 	featurev1 "github.com/dkrizic/feature/ui/repository/feature/v1"
 	metav1 "github.com/dkrizic/feature/ui/repository/meta/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // MockFeatureClient is a mock for FeatureClient
 type MockFeatureClient struct {
 	mock.Mock
+	// permissions are returned by GetPermissions, without them the backend has no roles
+	permissions *featurev1.Permissions
+	// permissionsErr is returned by GetPermissions instead of the permissions, e.g. to reject credentials
+	permissionsErr error
 }
 
 func (m *MockFeatureClient) GetAll(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[featurev1.KeyValue], error) {
@@ -64,6 +74,184 @@ func (m *MockFeatureClient) Delete(ctx context.Context, in *featurev1.Key, opts
 	return args.Get(0).(*emptypb.Empty), args.Error(1)
 }
 
+func (m *MockFeatureClient) Watch(ctx context.Context, in *featurev1.WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[featurev1.WatchEvent], error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(grpc.ServerStreamingClient[featurev1.WatchEvent]), args.Error(1)
+}
+
+func (m *MockFeatureClient) Evaluate(ctx context.Context, in *featurev1.EvaluateRequest, opts ...grpc.CallOption) (*featurev1.EvaluateResponse, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*featurev1.EvaluateResponse), args.Error(1)
+}
+
+func (m *MockFeatureClient) GetRules(ctx context.Context, in *featurev1.Key, opts ...grpc.CallOption) (*featurev1.Rules, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*featurev1.Rules), args.Error(1)
+}
+
+func (m *MockFeatureClient) SetRules(ctx context.Context, in *featurev1.Rules, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*emptypb.Empty), args.Error(1)
+}
+
+func (m *MockFeatureClient) SetRollout(ctx context.Context, in *featurev1.SetRolloutRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*emptypb.Empty), args.Error(1)
+}
+
+func (m *MockFeatureClient) History(ctx context.Context, in *featurev1.Key, opts ...grpc.CallOption) (*featurev1.HistoryResponse, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*featurev1.HistoryResponse), args.Error(1)
+}
+
+func (m *MockFeatureClient) Rollback(ctx context.Context, in *featurev1.RollbackRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*emptypb.Empty), args.Error(1)
+}
+
+func (m *MockFeatureClient) Batch(ctx context.Context, in *featurev1.BatchRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*emptypb.Empty), args.Error(1)
+}
+
+func (m *MockFeatureClient) ListSets(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*featurev1.FlagSets, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*featurev1.FlagSets), args.Error(1)
+}
+
+func (m *MockFeatureClient) CreateSet(ctx context.Context, in *featurev1.FlagSet, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*emptypb.Empty), args.Error(1)
+}
+
+func (m *MockFeatureClient) DeleteSet(ctx context.Context, in *featurev1.FlagSet, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*emptypb.Empty), args.Error(1)
+}
+
+func (m *MockFeatureClient) ListEnvironments(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*featurev1.Environments, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*featurev1.Environments), args.Error(1)
+}
+
+func (m *MockFeatureClient) Promote(ctx context.Context, in *featurev1.PromoteRequest, opts ...grpc.CallOption) (*featurev1.PromoteResponse, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*featurev1.PromoteResponse), args.Error(1)
+}
+
+func (m *MockFeatureClient) ScheduleSet(ctx context.Context, in *featurev1.ScheduleSetRequest, opts ...grpc.CallOption) (*featurev1.Schedule, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*featurev1.Schedule), args.Error(1)
+}
+
+func (m *MockFeatureClient) ListSchedules(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*featurev1.Schedules, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*featurev1.Schedules), args.Error(1)
+}
+
+func (m *MockFeatureClient) ListStale(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*featurev1.StaleFlags, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*featurev1.StaleFlags), args.Error(1)
+}
+
+func (m *MockFeatureClient) SetPrerequisites(ctx context.Context, in *featurev1.Prerequisites, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*emptypb.Empty), args.Error(1)
+}
+
+func (m *MockFeatureClient) GetPermissions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*featurev1.Permissions, error) {
+	if m.permissionsErr != nil {
+		return nil, m.permissionsErr
+	}
+	if m.permissions == nil {
+		return nil, status.Error(codes.Unimplemented, "unknown method GetPermissions")
+	}
+	return m.permissions, nil
+}
+
+func (m *MockFeatureClient) CancelSchedule(ctx context.Context, in *featurev1.Schedule, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*emptypb.Empty), args.Error(1)
+}
+
+func (m *MockFeatureClient) CreateToken(ctx context.Context, in *featurev1.CreateTokenRequest, opts ...grpc.CallOption) (*featurev1.CreateTokenResponse, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*featurev1.CreateTokenResponse), args.Error(1)
+}
+
+func (m *MockFeatureClient) ListTokens(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*featurev1.Tokens, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*featurev1.Tokens), args.Error(1)
+}
+
+func (m *MockFeatureClient) RevokeToken(ctx context.Context, in *featurev1.Token, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	args := m.Called(ctx, in)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*emptypb.Empty), args.Error(1)
+}
+
 // MockMetaClient is a mock for MetaClient
 type MockMetaClient struct {
 	mock.Mock
@@ -117,6 +305,22 @@ func (m *MockStreamClient) RecvMsg(msg interface{}) error {
 	return nil
 }
 
+// MockWatchStreamClient is a mock for the Watch stream
+type MockWatchStreamClient struct {
+	MockStreamClient
+	events []*featurev1.WatchEvent
+	index  int
+}
+
+func (m *MockWatchStreamClient) Recv() (*featurev1.WatchEvent, error) {
+	if m.index >= len(m.events) {
+		return nil, io.EOF
+	}
+	event := m.events[m.index]
+	m.index++
+	return event, nil
+}
+
 func TestHandleHealth(t *testing.T) {
 	server := &Server{}
 
@@ -223,6 +427,7 @@ func TestHandleFeaturesList(t *testing.T) {
 					index: 0,
 				}
 				mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(mockStream, nil)
+				mockFeatureClient.On("ListStale", mock.Anything, mock.Anything).Return(&featurev1.StaleFlags{}, nil).Maybe()
 			} else {
 				mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(nil, tt.mockError)
 			}
@@ -271,6 +476,7 @@ func TestHandleFeaturesList_AlphabeticalSorting(t *testing.T) {
 		index: 0,
 	}
 	mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(mockStream, nil)
+	mockFeatureClient.On("ListStale", mock.Anything, mock.Anything).Return(&featurev1.StaleFlags{}, nil).Maybe()
 
 	// Create a template that outputs the keys in order
 	tmpl := template.Must(template.New("features_list.gohtml").Parse(`{{range .Features}}{{.Key}},{{end}}`))
@@ -346,6 +552,7 @@ func TestHandleFeatureDelete(t *testing.T) {
 					index: 0,
 				}
 				mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(mockStream, nil)
+				mockFeatureClient.On("ListStale", mock.Anything, mock.Anything).Return(&featurev1.StaleFlags{}, nil).Maybe()
 			}
 
 			// Create a minimal template for testing
@@ -377,124 +584,805 @@ func TestHandleFeatureDelete(t *testing.T) {
 	}
 }
 
-func TestHandleVersion(t *testing.T) {
-	tests := []struct {
-		name           string
-		mockVersion    string
-		mockError      error
-		expectedStatus int
-		expectedBody   string
-	}{
-		{
-			name:           "successful version fetch",
-			mockVersion:    "v1.2.3",
-			mockError:      nil,
-			expectedStatus: http.StatusOK,
-			expectedBody:   "v1.2.3",
-		},
-		{
-			name:           "error fetching version",
-			mockVersion:    "",
-			mockError:      io.EOF,
-			expectedStatus: http.StatusOK,
-			expectedBody:   "",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			mockMetaClient := new(MockMetaClient)
+func TestHandleFeatureCreate_Typed(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("Set", mock.Anything, &featurev1.KeyValue{
+		Key:         "MODE",
+		Value:       "fast",
+		Type:        featurev1.ValueType_VALUE_TYPE_ENUM,
+		Constraints: &featurev1.Constraints{AllowedValues: []string{"fast", "slow"}},
+	}).Return(&emptypb.Empty{}, nil)
+	mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(&MockStreamClient{}, nil)
+	mockFeatureClient.On("ListStale", mock.Anything, mock.Anything).Return(&featurev1.StaleFlags{}, nil).Maybe()
 
-			if tt.mockError == nil {
-				mockMetaClient.On("Meta", mock.Anything, mock.Anything).Return(&metav1.MetaResponse{Version: tt.mockVersion}, nil)
-			} else {
-				mockMetaClient.On("Meta", mock.Anything, mock.Anything).Return(nil, tt.mockError)
-			}
+	server := &Server{
+		templates:     template.Must(template.New("features_list.gohtml").Parse(`Features: {{len .Features}}`)),
+		featureClient: mockFeatureClient,
+	}
 
-			server := &Server{
-				metaClient: mockMetaClient,
-			}
+	form := url.Values{"key": {"MODE"}, "value": {"fast"}, "type": {"enum"}, "allowed": {"fast, slow"}}
+	req := httptest.NewRequest(http.MethodPost, "/features/create", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
 
-			req := httptest.NewRequest(http.MethodGet, "/version", nil)
-			w := httptest.NewRecorder()
+	server.handleFeatureCreate(w, req)
 
-			server.handleVersion(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockFeatureClient.AssertExpectations(t)
+}
 
-			resp := w.Result()
-			defer resp.Body.Close()
+func TestHandleFeatureUpdate_InvalidValue(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("Set", mock.Anything, mock.Anything).
+		Return(nil, status.Error(codes.InvalidArgument, "invalid value for 'BOOKING': value \"ture\" is not a boolean"))
 
-			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+	server := &Server{
+		templates:     template.Must(template.New("features_list.gohtml").Parse(`Features: {{len .Features}}`)),
+		featureClient: mockFeatureClient,
+	}
 
-			body, err := io.ReadAll(resp.Body)
-			assert.NoError(t, err)
-			assert.Equal(t, tt.expectedBody, string(body))
+	form := url.Values{"key": {"BOOKING"}, "value": {"ture"}}
+	req := httptest.NewRequest(http.MethodPost, "/features/update", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
 
-			if tt.expectedBody != "" {
-				assert.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
-			}
+	server.handleFeatureUpdate(w, req)
 
-			mockMetaClient.AssertExpectations(t)
-		})
-	}
+	// validation errors are passed on so the toast can show them
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "is not a boolean")
 }
 
-func TestRegisterHandlers(t *testing.T) {
-	// Create minimal templates for testing
-	tmpl := template.Must(template.New("index.gohtml").Parse(`Test`))
-	template.Must(tmpl.New("features_list.gohtml").Parse(`Test`))
-
+func TestHandleFeatureUpdate_Conflict(t *testing.T) {
 	mockFeatureClient := new(MockFeatureClient)
-	mockMetaClient := new(MockMetaClient)
+	mockFeatureClient.On("Set", mock.Anything, &featurev1.KeyValue{Key: "COLOR", Value: "blue", Revision: 4}).
+		Return(nil, status.Error(codes.FailedPrecondition, "'COLOR' was changed by someone else, revision 4 does not match 5"))
 
 	server := &Server{
-		templates:     tmpl,
+		templates:     template.Must(template.New("features_list.gohtml").Parse(`Features: {{len .Features}}`)),
 		featureClient: mockFeatureClient,
-		metaClient:    mockMetaClient,
 	}
-	mux := http.NewServeMux()
 
-	server.registerHandlers(mux)
+	form := url.Values{"key": {"COLOR"}, "value": {"blue"}, "revision": {"4"}}
+	req := httptest.NewRequest(http.MethodPost, "/features/update", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
 
-	// Test that routes are registered by making test requests
-	testRoutes := []struct {
-		method         string
-		path           string
-		skipBadRequest bool
-	}{
-		{http.MethodGet, "/", false},
-		{http.MethodGet, "/healthz", false},
-		// Skip these as they require mock setup
-		// {http.MethodGet, "/features/list", true},
-		// {http.MethodPost, "/features/delete", true},
-	}
+	server.handleFeatureUpdate(w, req)
 
-	for _, route := range testRoutes {
-		t.Run(route.method+" "+route.path, func(t *testing.T) {
-			req := httptest.NewRequest(route.method, route.path, nil)
-			w := httptest.NewRecorder()
+	// the page asks the user instead of overwriting
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "changed by someone else")
+	mockFeatureClient.AssertExpectations(t)
 
-			mux.ServeHTTP(w, req)
+	// a malformed revision is rejected
+	form = url.Values{"key": {"COLOR"}, "value": {"blue"}, "revision": {"latest"}}
+	req = httptest.NewRequest(http.MethodPost, "/features/update", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
 
-			// Should not return 404 if route is registered
-			assert.NotEqual(t, http.StatusNotFound, w.Code, "Route should be registered")
-		})
-	}
+	server.handleFeatureUpdate(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
-// TestSubpathSupport verifies that routes work correctly with a subpath prefix.
-func TestSubpathSupport(t *testing.T) {
-	tests := []struct {
-		name     string
-		subpath  string
-		testPath string
-		expected int
-	}{
-		{
-			name:     "no subpath - root",
-			subpath:  "",
-			testPath: "/",
-			expected: http.StatusOK,
-		},
+func TestHandleFeaturesList_Types(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	max := 5.0
+	mockStream := &MockStreamClient{
+		items: []*featurev1.KeyValue{
+			{Key: "BOOKING", Value: "true", Editable: true, Type: featurev1.ValueType_VALUE_TYPE_BOOLEAN},
+			{Key: "LIMIT", Value: "3", Editable: true, Type: featurev1.ValueType_VALUE_TYPE_INTEGER, Constraints: &featurev1.Constraints{Max: &max}},
+			{Key: "MODE", Value: "slow", Editable: true, Type: featurev1.ValueType_VALUE_TYPE_ENUM, Constraints: &featurev1.Constraints{AllowedValues: []string{"fast", "slow"}}},
+			{Key: "NAME", Value: "x", Editable: true, Revision: 7},
+		},
+	}
+	mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(mockStream, nil)
+	mockFeatureClient.On("ListStale", mock.Anything, mock.Anything).Return(&featurev1.StaleFlags{}, nil).Maybe()
+
+	server := &Server{
+		templates:     ParseTemplates(context.Background()),
+		featureClient: mockFeatureClient,
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/features/list", nil)
+	w := httptest.NewRecorder()
+
+	server.handleFeaturesList(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `type="checkbox" role="switch" name="value" value="true" aria-label="Toggle BOOKING" checked`)
+	assert.Contains(t, body, `type="number" name="value" value="3" step="1"`)
+	assert.Contains(t, body, `max="5"`)
+	assert.Contains(t, body, `<option value="slow" selected>slow</option>`)
+	assert.Contains(t, body, `type="text" name="value" value="x"`)
+	assert.Contains(t, body, `<input type="hidden" name="revision" value="7">`)
+}
+
+func TestHandleFeaturesList_MultiEdit(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockStream := &MockStreamClient{
+		items: []*featurev1.KeyValue{
+			{Key: "BOOKING", Value: "true", Editable: true, Type: featurev1.ValueType_VALUE_TYPE_BOOLEAN},
+			{Key: "NAME", Value: "x", Editable: true, Revision: 7},
+			{Key: "LOCKED", Value: "y", Editable: false},
+		},
+	}
+	mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(mockStream, nil)
+	mockFeatureClient.On("ListStale", mock.Anything, mock.Anything).Return(&featurev1.StaleFlags{}, nil).Maybe()
+
+	server := &Server{
+		templates:     ParseTemplates(context.Background()),
+		featureClient: mockFeatureClient,
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/features/list", nil)
+	w := httptest.NewRecorder()
+
+	server.handleFeaturesList(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `hx-post="/features/batch"`)
+	assert.Contains(t, body, `<select name="value.BOOKING"`)
+	assert.Contains(t, body, `<input type="hidden" name="revision.NAME" value="7">`)
+	assert.Contains(t, body, `<input type="hidden" name="original.NAME" value="x">`)
+	// read-only features are not part of the multi-edit form
+	assert.NotContains(t, body, `name="value.LOCKED"`)
+}
+
+func TestHandleFeaturesList_Redacted(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockStream := &MockStreamClient{
+		items: []*featurev1.KeyValue{
+			{Key: "API_KEY", Editable: true, Redacted: true, Rules: []*featurev1.Rule{{Name: "beta"}}},
+			{Key: "ENABLED", Editable: true, Redacted: true, Type: featurev1.ValueType_VALUE_TYPE_BOOLEAN},
+		},
+	}
+	mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(mockStream, nil)
+	mockFeatureClient.On("ListStale", mock.Anything, mock.Anything).Return(&featurev1.StaleFlags{}, nil).Maybe()
+
+	server := &Server{
+		templates:     ParseTemplates(context.Background()),
+		featureClient: mockFeatureClient,
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/features/list", nil)
+	w := httptest.NewRecorder()
+
+	server.handleFeaturesList(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	// hidden values are not prefilled, a new value has to be entered
+	assert.Contains(t, body, `<input type="password" name="value" value="" required`)
+	assert.Contains(t, body, `<input type="password" name="value.ENABLED" value=""`)
+	assert.NotContains(t, body, `aria-label="Toggle ENABLED"`)
+	// saving the rules without their values would overwrite them
+	assert.NotContains(t, body, `aria-label="Targeting rules for API_KEY"`)
+	assert.Contains(t, body, "values hidden")
+}
+
+func TestHandleFeaturesList_Filter(t *testing.T) {
+	items := []*featurev1.KeyValue{
+		{Key: "BOOKING", Value: "true", Metadata: &featurev1.Metadata{Description: "New booking flow", Owner: "team-checkout", Tags: []string{"checkout"}}},
+		{Key: "SEARCH_V2", Value: "false", Metadata: &featurev1.Metadata{Owner: "team-search", Tags: []string{"search", "q3"}}},
+		{Key: "COLOR", Value: "red"},
+	}
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{"no filter", "", "BOOKING,COLOR,SEARCH_V2,"},
+		{"search in description", "filter-search=booking+FLOW", "BOOKING,"},
+		{"search in key", "filter-search=col", "COLOR,"},
+		{"owner", "filter-owner=Team-Search", "SEARCH_V2,"},
+		{"tag", "filter-tag=checkout", "BOOKING,"},
+		{"combined", "filter-owner=team-search&filter-tag=checkout", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockFeatureClient := new(MockFeatureClient)
+			mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(&MockStreamClient{items: items}, nil)
+			mockFeatureClient.On("ListStale", mock.Anything, mock.Anything).Return(&featurev1.StaleFlags{}, nil).Maybe()
+
+			server := &Server{
+				templates:     template.Must(template.New("features_list.gohtml").Parse(`{{range .Features}}{{.Key}},{{end}}`)),
+				featureClient: mockFeatureClient,
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/features/list?"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			server.handleFeaturesList(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expected, w.Body.String())
+		})
+	}
+}
+
+func TestHandleFeaturesList_Metadata(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockStream := &MockStreamClient{
+		items: []*featurev1.KeyValue{{
+			Key:      "BOOKING",
+			Value:    "true",
+			Editable: true,
+			Metadata: &featurev1.Metadata{
+				Description:    "New booking flow",
+				Owner:          "team-checkout",
+				Tags:           []string{"checkout"},
+				UpdatedAt:      timestamppb.New(time.Date(2025, 6, 1, 12, 30, 0, 0, time.UTC)),
+				LastModifiedBy: "alice",
+			},
+		}},
+	}
+	mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(mockStream, nil)
+	mockFeatureClient.On("ListStale", mock.Anything, mock.Anything).Return(&featurev1.StaleFlags{}, nil).Maybe()
+
+	server := &Server{
+		templates:     ParseTemplates(context.Background()),
+		featureClient: mockFeatureClient,
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/features/list", nil)
+	w := httptest.NewRecorder()
+
+	server.handleFeaturesList(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, "New booking flow")
+	assert.Contains(t, body, "team-checkout")
+	assert.Contains(t, body, `<mark class="tag">checkout</mark>`)
+	assert.Contains(t, body, "updated 2025-06-01 12:30 by alice")
+}
+
+func TestHandleFeaturesList_Stale(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		stale    *featurev1.StaleFlags
+		err      error
+		expected string
+	}{
+		{"stale", &featurev1.StaleFlags{Flags: []*featurev1.StaleFlag{{Key: "RELEASE", Reason: featurev1.StaleReason_STALE_REASON_EXPIRED}}}, nil, "BOOKING:,RELEASE:expired,"},
+		{"unavailable", nil, status.Error(codes.Unimplemented, "unknown method"), "BOOKING:,RELEASE:,"},
+		{"failed", nil, status.Error(codes.Unavailable, "connection refused"), "BOOKING:,RELEASE:,"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mockFeatureClient := new(MockFeatureClient)
+			mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(&MockStreamClient{items: []*featurev1.KeyValue{
+				{Key: "RELEASE", Value: "true"},
+				{Key: "BOOKING", Value: "true"},
+			}}, nil)
+			mockFeatureClient.On("ListStale", mock.Anything, mock.Anything).Return(tt.stale, tt.err)
+			server := &Server{
+				templates:     template.Must(template.New("features_list.gohtml").Parse(`{{range .Features}}{{.Key}}:{{.Stale}},{{end}}`)),
+				featureClient: mockFeatureClient,
+			}
+
+			w := httptest.NewRecorder()
+			server.handleFeaturesList(w, httptest.NewRequest(http.MethodGet, "/features/list", nil))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expected, w.Body.String())
+		})
+	}
+
+	// the badge and the expiry in the full template
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(&MockStreamClient{items: []*featurev1.KeyValue{
+		{Key: "RELEASE", Value: "true", Metadata: &featurev1.Metadata{
+			ExpiresAt: timestamppb.New(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)),
+			Lifecycle: featurev1.Lifecycle_LIFECYCLE_TEMPORARY,
+		}},
+	}}, nil)
+	mockFeatureClient.On("ListStale", mock.Anything, mock.Anything).Return(&featurev1.StaleFlags{Flags: []*featurev1.StaleFlag{
+		{Key: "RELEASE", Reason: featurev1.StaleReason_STALE_REASON_EXPIRED},
+	}}, nil)
+	server := &Server{templates: ParseTemplates(context.Background()), featureClient: mockFeatureClient}
+	w := httptest.NewRecorder()
+	server.handleFeaturesList(w, httptest.NewRequest(http.MethodGet, "/features/list", nil))
+	assert.Contains(t, w.Body.String(), "stale: expired</mark>")
+	assert.Contains(t, w.Body.String(), "temporary expires 2025-06-01")
+}
+
+func TestHandleFeatureCreate_Metadata(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("Set", mock.Anything, &featurev1.KeyValue{
+		Key:      "BOOKING",
+		Value:    "true",
+		Type:     featurev1.ValueType_VALUE_TYPE_BOOLEAN,
+		Metadata: &featurev1.Metadata{Description: "New booking flow", Owner: "team-checkout", Tags: []string{"checkout", "q3"},
+			ExpiresAt: timestamppb.New(time.Date(2025, 9, 30, 0, 0, 0, 0, time.UTC)), Lifecycle: featurev1.Lifecycle_LIFECYCLE_TEMPORARY},
+	}).Return(&emptypb.Empty{}, nil)
+	mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(&MockStreamClient{}, nil)
+	mockFeatureClient.On("ListStale", mock.Anything, mock.Anything).Return(&featurev1.StaleFlags{}, nil).Maybe()
+
+	server := &Server{
+		templates:     template.Must(template.New("features_list.gohtml").Parse(`Features: {{len .Features}}`)),
+		featureClient: mockFeatureClient,
+	}
+
+	form := url.Values{
+		"key":         {"BOOKING"},
+		"value":       {"true"},
+		"type":        {"boolean"},
+		"description": {"New booking flow"},
+		"owner":       {"team-checkout"},
+		"tags":        {"checkout, q3,"},
+		"expires":     {"2025-09-30"},
+		"lifecycle":   {"temporary"},
+	}
+	req := httptest.NewRequest(http.MethodPost, "/features/create", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	server.handleFeatureCreate(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockFeatureClient.AssertExpectations(t)
+}
+
+func TestRulesJSON(t *testing.T) {
+	rules := []*featurev1.Rule{{
+		Name:  "beta",
+		Value: "on",
+		Conditions: []*featurev1.Condition{
+			{Attribute: "appVersion", Operator: featurev1.Operator_OPERATOR_SEMVER_GREATER_OR_EQUAL, Values: []string{"2.0.0"}},
+		},
+	}}
+	text := rulesToJSON(rules)
+	assert.Contains(t, text, `"operator": "semver_greater_or_equal"`)
+
+	parsed, err := parseRules(text)
+	assert.NoError(t, err)
+	assert.Equal(t, rules[0].Conditions[0].Operator, parsed[0].Conditions[0].Operator)
+	assert.Equal(t, "on", parsed[0].Value)
+
+	assert.Equal(t, "[]", rulesToJSON(nil))
+	parsed, err = parseRules("  ")
+	assert.NoError(t, err)
+	assert.Empty(t, parsed)
+
+	_, err = parseRules(`[{"conditions": [{"attribute": "plan", "operator": "like", "values": ["pro"]}]}]`)
+	assert.Error(t, err)
+	_, err = parseRules(`{`)
+	assert.Error(t, err)
+}
+
+func TestHandleFeatureRules(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("SetRules", mock.Anything, &featurev1.Rules{
+		Key: "CHECKOUT",
+		Rules: []*featurev1.Rule{{
+			Value:      "new",
+			Conditions: []*featurev1.Condition{{Attribute: "tenant", Operator: featurev1.Operator_OPERATOR_IN, Values: []string{"acme"}}},
+		}},
+	}).Return(&emptypb.Empty{}, nil)
+	mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(&MockStreamClient{}, nil)
+	mockFeatureClient.On("ListStale", mock.Anything, mock.Anything).Return(&featurev1.StaleFlags{}, nil).Maybe()
+
+	server := &Server{
+		templates:     template.Must(template.New("features_list.gohtml").Parse(`Features: {{len .Features}}`)),
+		featureClient: mockFeatureClient,
+	}
+
+	form := url.Values{"key": {"CHECKOUT"}, "rules": {`[{"conditions": [{"attribute": "tenant", "operator": "in", "values": ["acme"]}], "value": "new"}]`}}
+	req := httptest.NewRequest(http.MethodPost, "/features/rules", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	server.handleFeatureRules(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockFeatureClient.AssertExpectations(t)
+}
+
+func TestHandleFeatureRules_Invalid(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("SetRules", mock.Anything, mock.Anything).
+		Return(nil, status.Error(codes.InvalidArgument, "invalid rules for 'CHECKOUT': rule #1 has no conditions"))
+
+	server := &Server{
+		templates:     template.Must(template.New("features_list.gohtml").Parse(`Features: {{len .Features}}`)),
+		featureClient: mockFeatureClient,
+	}
+
+	for rules, message := range map[string]string{
+		`not json`:           "invalid rules",
+		`[{"value": "new"}]`: "has no conditions",
+	} {
+		form := url.Values{"key": {"CHECKOUT"}, "rules": {rules}}
+		req := httptest.NewRequest(http.MethodPost, "/features/rules", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+
+		server.handleFeatureRules(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), message)
+	}
+}
+
+func TestHandleFeaturesList_Rules(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockStream := &MockStreamClient{
+		items: []*featurev1.KeyValue{{
+			Key:      "CHECKOUT",
+			Value:    "classic",
+			Editable: true,
+			Rules: []*featurev1.Rule{{
+				Value:      "new",
+				Conditions: []*featurev1.Condition{{Attribute: "region", Operator: featurev1.Operator_OPERATOR_EQUALS, Values: []string{"eu"}}},
+			}},
+			Rollout: &featurev1.Rollout{Variants: []*featurev1.Variant{{Value: "new", Percentage: 5}}},
+		}},
+	}
+	mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(mockStream, nil)
+	mockFeatureClient.On("ListStale", mock.Anything, mock.Anything).Return(&featurev1.StaleFlags{}, nil).Maybe()
+
+	server := &Server{
+		templates:     ParseTemplates(context.Background()),
+		featureClient: mockFeatureClient,
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/features/list", nil)
+	w := httptest.NewRecorder()
+
+	server.handleFeaturesList(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, "Targeting rules (1)")
+	assert.Contains(t, body, `/features/rules`)
+	assert.Contains(t, body, "&#34;operator&#34;: &#34;equals&#34;")
+	assert.Contains(t, body, "Rollout: new=5")
+	assert.Contains(t, body, `/features/rollout`)
+	assert.Contains(t, body, `/features/history?key=CHECKOUT&editable=true`)
+}
+
+func TestParseVariants(t *testing.T) {
+	variants, err := parseVariants("true=5, blue = 12.5%")
+	assert.NoError(t, err)
+	assert.Equal(t, []*featurev1.Variant{{Value: "true", Percentage: 5}, {Value: "blue", Percentage: 12.5}}, variants)
+	assert.Equal(t, "true=5, blue=12.5", formatVariants(variants))
+
+	variants, err = parseVariants("")
+	assert.NoError(t, err)
+	assert.Empty(t, variants)
+
+	_, err = parseVariants("true")
+	assert.Error(t, err)
+	_, err = parseVariants("true=some")
+	assert.Error(t, err)
+}
+
+func TestHandleFeatureRollout(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("SetRollout", mock.Anything, &featurev1.SetRolloutRequest{
+		Key:     "BOOKING",
+		Rollout: &featurev1.Rollout{BucketBy: "tenant", Variants: []*featurev1.Variant{{Value: "true", Percentage: 25}}},
+	}).Return(&emptypb.Empty{}, nil)
+	mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(&MockStreamClient{}, nil)
+	mockFeatureClient.On("ListStale", mock.Anything, mock.Anything).Return(&featurev1.StaleFlags{}, nil).Maybe()
+
+	server := &Server{
+		templates:     template.Must(template.New("features_list.gohtml").Parse(`Features: {{len .Features}}`)),
+		featureClient: mockFeatureClient,
+	}
+
+	form := url.Values{"key": {"BOOKING"}, "variants": {"true=25"}, "bucketBy": {"tenant"}}
+	req := httptest.NewRequest(http.MethodPost, "/features/rollout", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	server.handleFeatureRollout(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockFeatureClient.AssertExpectations(t)
+}
+
+func TestHandleFeatureRollout_Invalid(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("SetRollout", mock.Anything, mock.Anything).
+		Return(nil, status.Error(codes.InvalidArgument, "invalid rollout for 'BOOKING': percentages of the variants add up to 120.00, more than 100"))
+
+	server := &Server{
+		templates:     template.Must(template.New("features_list.gohtml").Parse(`Features: {{len .Features}}`)),
+		featureClient: mockFeatureClient,
+	}
+
+	for variants, message := range map[string]string{
+		"true":              "expected value=percentage",
+		"true=60, false=60": "more than 100",
+	} {
+		form := url.Values{"key": {"BOOKING"}, "variants": {variants}}
+		req := httptest.NewRequest(http.MethodPost, "/features/rollout", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+
+		server.handleFeatureRollout(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), message)
+	}
+}
+
+func TestHandleFeatureHistory(t *testing.T) {
+	changed := timestamppb.New(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("History", mock.Anything, &featurev1.Key{Name: "COLOR"}).Return(&featurev1.HistoryResponse{
+		Entries: []*featurev1.HistoryEntry{
+			{Key: "COLOR", Revision: 1, Type: featurev1.EventType_EVENT_TYPE_CREATE, NewValue: "red", Timestamp: changed, Source: "PreSet"},
+			{Key: "COLOR", Revision: 2, Type: featurev1.EventType_EVENT_TYPE_UPDATE, OldValue: "red", NewValue: "blue", Actor: "alice", Timestamp: changed, Source: "Set"},
+		},
+	}, nil)
+
+	server := &Server{
+		templates:     ParseTemplates(context.Background()),
+		featureClient: mockFeatureClient,
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/features/history?key=COLOR&editable=true", nil)
+	w := httptest.NewRecorder()
+
+	server.handleFeatureHistory(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	// newest first
+	assert.Less(t, strings.Index(body, "<code>red</code> → <code>blue</code>"), strings.Index(body, "created <code>red</code>"))
+	assert.Contains(t, body, "alice")
+	assert.Contains(t, body, "2025-06-01 12:00")
+	assert.Contains(t, body, "/features/rollback")
+
+	// read-only features cannot be rolled back
+	req = httptest.NewRequest(http.MethodGet, "/features/history?key=COLOR", nil)
+	w = httptest.NewRecorder()
+	server.handleFeatureHistory(w, req)
+	assert.NotContains(t, w.Body.String(), "/features/rollback")
+}
+
+func TestHandleFeatureHistory_Redacted(t *testing.T) {
+	changed := timestamppb.New(time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("History", mock.Anything, &featurev1.Key{Name: "API_KEY"}).Return(&featurev1.HistoryResponse{
+		Entries: []*featurev1.HistoryEntry{
+			{Key: "API_KEY", Revision: 2, Type: featurev1.EventType_EVENT_TYPE_UPDATE, Actor: "alice", Timestamp: changed, Source: "Set", Redacted: true},
+		},
+	}, nil)
+
+	server := &Server{
+		templates:     ParseTemplates(context.Background()),
+		featureClient: mockFeatureClient,
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/features/history?key=API_KEY", nil)
+	w := httptest.NewRecorder()
+
+	server.handleFeatureHistory(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, "changed <small>(value hidden)</small>")
+	assert.NotContains(t, body, "<code>")
+}
+
+func TestHandleFeatureRollback(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("Rollback", mock.Anything, &featurev1.RollbackRequest{Key: "COLOR", Revision: 1}).Return(&emptypb.Empty{}, nil)
+	mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(&MockStreamClient{}, nil)
+	mockFeatureClient.On("ListStale", mock.Anything, mock.Anything).Return(&featurev1.StaleFlags{}, nil).Maybe()
+
+	server := &Server{
+		templates:     template.Must(template.New("features_list.gohtml").Parse(`Features: {{len .Features}}`)),
+		featureClient: mockFeatureClient,
+	}
+
+	form := url.Values{"key": {"COLOR"}, "revision": {"1"}}
+	req := httptest.NewRequest(http.MethodPost, "/features/rollback", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	server.handleFeatureRollback(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockFeatureClient.AssertExpectations(t)
+
+	// a revision is required
+	form = url.Values{"key": {"COLOR"}}
+	req = httptest.NewRequest(http.MethodPost, "/features/rollback", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+
+	server.handleFeatureRollback(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestBatchOperations(t *testing.T) {
+	form := url.Values{
+		"key":            {"COLOR", "SIZE", "THEME"},
+		"value.COLOR":    {"blue"},
+		"original.COLOR": {"red"},
+		"revision.COLOR": {"3"},
+		"value.SIZE":     {"large"},
+		"original.SIZE":  {"large"},
+		"revision.SIZE":  {"1"},
+		"value.THEME":    {"dark"},
+		"original.THEME": {"dark"},
+		"delete":         {"THEME"},
+	}
+	operations, err := batchOperations(form)
+	assert.NoError(t, err)
+	// the unchanged SIZE is left out
+	assert.Len(t, operations, 2)
+	assert.Equal(t, &featurev1.KeyValue{Key: "COLOR", Value: "blue", Revision: 3}, operations[0].GetSet())
+	assert.Equal(t, "THEME", operations[1].GetDelete().GetName())
+
+	form.Set("revision.COLOR", "latest")
+	_, err = batchOperations(form)
+	assert.Error(t, err)
+}
+
+func TestHandleFeatureBatch(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("Batch", mock.Anything, mock.MatchedBy(func(req *featurev1.BatchRequest) bool {
+		return len(req.Operations) == 2
+	})).Return(&emptypb.Empty{}, nil).Once()
+	mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(&MockStreamClient{}, nil)
+	mockFeatureClient.On("ListStale", mock.Anything, mock.Anything).Return(&featurev1.StaleFlags{}, nil).Maybe()
+
+	server := &Server{
+		templates:     template.Must(template.New("features_list.gohtml").Parse(`Features: {{len .Features}}`)),
+		featureClient: mockFeatureClient,
+	}
+
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/features/batch", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		server.handleFeatureBatch(w, req)
+		return w
+	}
+
+	w := post(url.Values{"key": {"COLOR", "SIZE"}, "value.COLOR": {"blue"}, "original.COLOR": {"red"}, "delete": {"SIZE"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockFeatureClient.AssertExpectations(t)
+
+	// nothing changed
+	w = post(url.Values{"key": {"COLOR"}, "value.COLOR": {"red"}, "original.COLOR": {"red"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// a rejected operation fails the whole batch
+	mockFeatureClient.On("Batch", mock.Anything, mock.Anything).
+		Return(nil, status.Error(codes.FailedPrecondition, "a key of the batch was changed by someone else"))
+	w = post(url.Values{"key": {"COLOR"}, "value.COLOR": {"blue"}, "original.COLOR": {"red"}, "revision.COLOR": {"2"}})
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestHandleVersion(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockVersion    string
+		mockError      error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "successful version fetch",
+			mockVersion:    "v1.2.3",
+			mockError:      nil,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "v1.2.3",
+		},
+		{
+			name:           "error fetching version",
+			mockVersion:    "",
+			mockError:      io.EOF,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockMetaClient := new(MockMetaClient)
+
+			if tt.mockError == nil {
+				mockMetaClient.On("Meta", mock.Anything, mock.Anything).Return(&metav1.MetaResponse{Version: tt.mockVersion}, nil)
+			} else {
+				mockMetaClient.On("Meta", mock.Anything, mock.Anything).Return(nil, tt.mockError)
+			}
+
+			server := &Server{
+				metaClient: mockMetaClient,
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/version", nil)
+			w := httptest.NewRecorder()
+
+			server.handleVersion(w, req)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedBody, string(body))
+
+			if tt.expectedBody != "" {
+				assert.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+			}
+
+			mockMetaClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRegisterHandlers(t *testing.T) {
+	// Create minimal templates for testing
+	tmpl := template.Must(template.New("index.gohtml").Parse(`Test`))
+	template.Must(tmpl.New("features_list.gohtml").Parse(`Test`))
+
+	mockFeatureClient := new(MockFeatureClient)
+	mockMetaClient := new(MockMetaClient)
+
+	server := &Server{
+		templates:     tmpl,
+		featureClient: mockFeatureClient,
+		metaClient:    mockMetaClient,
+	}
+	mux := http.NewServeMux()
+
+	server.registerHandlers(mux)
+
+	// Test that routes are registered by making test requests
+	testRoutes := []struct {
+		method         string
+		path           string
+		skipBadRequest bool
+	}{
+		{http.MethodGet, "/", false},
+		{http.MethodGet, "/healthz", false},
+		// Skip these as they require mock setup
+		// {http.MethodGet, "/features/list", true},
+		// {http.MethodPost, "/features/delete", true},
+	}
+
+	for _, route := range testRoutes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			req := httptest.NewRequest(route.method, route.path, nil)
+			w := httptest.NewRecorder()
+
+			mux.ServeHTTP(w, req)
+
+			// Should not return 404 if route is registered
+			assert.NotEqual(t, http.StatusNotFound, w.Code, "Route should be registered")
+		})
+	}
+}
+
+// TestSubpathSupport verifies that routes work correctly with a subpath prefix.
+func TestSubpathSupport(t *testing.T) {
+	tests := []struct {
+		name     string
+		subpath  string
+		testPath string
+		expected int
+	}{
+		{
+			name:     "no subpath - root",
+			subpath:  "",
+			testPath: "/",
+			expected: http.StatusOK,
+		},
 		{
 			name:     "no subpath - health",
 			subpath:  "",
@@ -566,3 +1454,501 @@ func TestSubpathSupport(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleFeatureWatch(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockStream := &MockWatchStreamClient{
+		events: []*featurev1.WatchEvent{
+			{Type: featurev1.EventType_EVENT_TYPE_SNAPSHOT, KeyValue: &featurev1.KeyValue{Key: "feature1", Value: "value1"}, Revision: 4},
+			{Type: featurev1.EventType_EVENT_TYPE_SYNCED, Revision: 4},
+			{Type: featurev1.EventType_EVENT_TYPE_UPDATE, KeyValue: &featurev1.KeyValue{Key: "feature1", Value: "value2"}, Revision: 5},
+		},
+	}
+	mockFeatureClient.On("Watch", mock.Anything, &featurev1.WatchRequest{Revision: 3}).Return(mockStream, nil)
+
+	server := &Server{
+		featureClient: mockFeatureClient,
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/features/watch", nil)
+	req.Header.Set("Last-Event-ID", "3")
+	w := httptest.NewRecorder()
+
+	server.handleFeatureWatch(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	// snapshot entries are not forwarded
+	assert.NotContains(t, string(body), "value1")
+	assert.Contains(t, string(body), "id: 4\nevent: change\ndata: {\"type\":\"synced\",\"revision\":4}\n\n")
+	assert.Contains(t, string(body), "id: 5\nevent: change\ndata: {\"type\":\"update\",\"key\":\"feature1\",\"value\":\"value2\",\"revision\":5}\n\n")
+
+	mockFeatureClient.AssertExpectations(t)
+}
+
+func TestHandleFeatureWatch_BackendError(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("Watch", mock.Anything, mock.Anything).Return(nil, io.ErrUnexpectedEOF)
+
+	server := &Server{
+		featureClient: mockFeatureClient,
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/features/watch", nil)
+	w := httptest.NewRecorder()
+
+	server.handleFeatureWatch(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestHandleSetsList(t *testing.T) {
+	tmpl := template.Must(template.New("sets.gohtml").Parse(`{{range .Sets}}{{.Name}}{{if eq .Name $.Selected}}*{{end}} {{end}}`))
+
+	t.Run("selected set is sent to the backend", func(t *testing.T) {
+		mockFeatureClient := new(MockFeatureClient)
+		mockFeatureClient.On("ListSets", mock.MatchedBy(func(ctx context.Context) bool {
+			md, _ := metadata.FromOutgoingContext(ctx)
+			return slices.Equal(md.Get(constant.FlagSetMetadata), []string{"shop"})
+		}), mock.Anything).Return(&featurev1.FlagSets{Sets: []*featurev1.FlagSet{{Name: "default"}, {Name: "shop"}}}, nil)
+		server := &Server{templates: tmpl, featureClient: mockFeatureClient}
+
+		req := httptest.NewRequest(http.MethodGet, "/sets/list", nil)
+		req.AddCookie(&http.Cookie{Name: constant.FlagSetCookieName, Value: "shop"})
+		w := httptest.NewRecorder()
+		server.handleSetsList(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "default shop* ", w.Body.String())
+		mockFeatureClient.AssertExpectations(t)
+	})
+
+	t.Run("no selector without other sets", func(t *testing.T) {
+		mockFeatureClient := new(MockFeatureClient)
+		mockFeatureClient.On("ListSets", mock.Anything, mock.Anything).Return(&featurev1.FlagSets{Sets: []*featurev1.FlagSet{{Name: "default"}}}, nil)
+		server := &Server{templates: tmpl, featureClient: mockFeatureClient}
+
+		w := httptest.NewRecorder()
+		server.handleSetsList(w, httptest.NewRequest(http.MethodGet, "/sets/list", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("no selector for backends without sets", func(t *testing.T) {
+		mockFeatureClient := new(MockFeatureClient)
+		mockFeatureClient.On("ListSets", mock.Anything, mock.Anything).Return(nil, status.Error(codes.Unimplemented, "unknown method ListSets"))
+		server := &Server{templates: tmpl, featureClient: mockFeatureClient}
+
+		w := httptest.NewRecorder()
+		server.handleSetsList(w, httptest.NewRequest(http.MethodGet, "/sets/list", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+}
+
+func TestHandleSetSelect(t *testing.T) {
+	server := &Server{subpath: "/ui"}
+
+	req := httptest.NewRequest(http.MethodPost, "/ui/sets/select", strings.NewReader(url.Values{"set": {"shop"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	server.handleSetSelect(w, req)
+
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/ui/", w.Header().Get("Location"))
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, constant.FlagSetCookieName, cookies[0].Name)
+		assert.Equal(t, "shop", cookies[0].Value)
+		assert.Equal(t, "/ui/", cookies[0].Path)
+	}
+
+	// selecting the default set removes the cookie
+	req = httptest.NewRequest(http.MethodPost, "/ui/sets/select", strings.NewReader(url.Values{"set": {"default"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	server.handleSetSelect(w, req)
+	cookies = w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, -1, cookies[0].MaxAge)
+	}
+}
+
+// inEnvironment matches outgoing contexts that select the environment
+func inEnvironment(environment string) any {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		return slices.Equal(md.Get(constant.EnvironmentMetadata), []string{environment})
+	})
+}
+
+func TestHandleEnvironmentMatrix(t *testing.T) {
+	t.Run("features side by side", func(t *testing.T) {
+		mockFeatureClient := new(MockFeatureClient)
+		mockFeatureClient.On("ListEnvironments", mock.Anything, mock.Anything).Return(&featurev1.Environments{Names: []string{"staging", "prod"}}, nil)
+		mockFeatureClient.On("GetAll", inEnvironment("staging"), mock.Anything).Return(&MockStreamClient{items: []*featurev1.KeyValue{
+			{Key: "SIZE", Value: "10"},
+			{Key: "COLOR", Value: "blue"},
+		}}, nil)
+		mockFeatureClient.On("GetAll", inEnvironment("prod"), mock.Anything).Return(&MockStreamClient{items: []*featurev1.KeyValue{
+			{Key: "COLOR", Value: "red"},
+			{Key: "SIZE", Value: "10"},
+		}}, nil)
+		server := &Server{templates: ParseTemplates(context.Background()), featureClient: mockFeatureClient, subpath: "/ui"}
+
+		w := httptest.NewRecorder()
+		server.handleEnvironmentMatrix(w, httptest.NewRequest(http.MethodGet, "/ui/environments/matrix", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		assert.Contains(t, body, "<th>staging</th>")
+		assert.Contains(t, body, "<th>prod</th>")
+		assert.Less(t, strings.Index(body, "COLOR"), strings.Index(body, "SIZE"), "rows are sorted by key")
+		assert.Equal(t, 1, strings.Count(body, "Differs between the environments"))
+		assert.Equal(t, 2, strings.Count(body, `name="to" value="prod"`))
+		mockFeatureClient.AssertExpectations(t)
+	})
+
+	t.Run("no matrix without environments", func(t *testing.T) {
+		mockFeatureClient := new(MockFeatureClient)
+		mockFeatureClient.On("ListEnvironments", mock.Anything, mock.Anything).Return(&featurev1.Environments{}, nil)
+		server := &Server{templates: ParseTemplates(context.Background()), featureClient: mockFeatureClient}
+
+		w := httptest.NewRecorder()
+		server.handleEnvironmentMatrix(w, httptest.NewRequest(http.MethodGet, "/environments/matrix", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+}
+
+func TestHandleEnvironmentPromote(t *testing.T) {
+	t.Run("preview", func(t *testing.T) {
+		mockFeatureClient := new(MockFeatureClient)
+		mockFeatureClient.On("Promote", mock.Anything, mock.MatchedBy(func(req *featurev1.PromoteRequest) bool {
+			return req.Key == "COLOR" && req.From == "staging" && req.To == "prod" && req.DryRun
+		})).Return(&featurev1.PromoteResponse{
+			Changes: []string{`value: "red" -> "blue"`},
+			Target:  &featurev1.KeyValue{Key: "COLOR", Value: "red", Revision: 3},
+		}, nil)
+		server := &Server{templates: ParseTemplates(context.Background()), featureClient: mockFeatureClient}
+
+		form := url.Values{"key": {"COLOR"}, "from": {"staging"}, "to": {"prod"}, "dry_run": {"true"}}
+		req := httptest.NewRequest(http.MethodPost, "/environments/promote", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		server.handleEnvironmentPromote(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		assert.Contains(t, body, "value: &#34;red&#34; -&gt; &#34;blue&#34;")
+		assert.Contains(t, body, `name="revision" value="3"`)
+		mockFeatureClient.AssertExpectations(t)
+	})
+
+	t.Run("promote", func(t *testing.T) {
+		mockFeatureClient := new(MockFeatureClient)
+		mockFeatureClient.On("Promote", mock.Anything, mock.MatchedBy(func(req *featurev1.PromoteRequest) bool {
+			return !req.DryRun && req.Revision == 3
+		})).Return(&featurev1.PromoteResponse{Applied: true}, nil)
+		mockFeatureClient.On("ListEnvironments", mock.Anything, mock.Anything).Return(&featurev1.Environments{Names: []string{"staging", "prod"}}, nil)
+		mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(&MockStreamClient{}, nil)
+		mockFeatureClient.On("ListStale", mock.Anything, mock.Anything).Return(&featurev1.StaleFlags{}, nil).Maybe()
+		server := &Server{
+			templates:     template.Must(template.New("matrix.gohtml").Parse(`Rows: {{len .Rows}}`)),
+			featureClient: mockFeatureClient,
+		}
+
+		form := url.Values{"key": {"COLOR"}, "from": {"staging"}, "to": {"prod"}, "revision": {"3"}}
+		req := httptest.NewRequest(http.MethodPost, "/environments/promote", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		server.handleEnvironmentPromote(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "Rows: 0", w.Body.String())
+		mockFeatureClient.AssertExpectations(t)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		mockFeatureClient := new(MockFeatureClient)
+		mockFeatureClient.On("Promote", mock.Anything, mock.Anything).Return(nil, status.Error(codes.PermissionDenied, "field 'COLOR' is not editable"))
+		server := &Server{featureClient: mockFeatureClient}
+
+		form := url.Values{"key": {"COLOR"}, "from": {"staging"}, "to": {"prod"}}
+		req := httptest.NewRequest(http.MethodPost, "/environments/promote", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		server.handleEnvironmentPromote(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "not editable")
+	})
+}
+
+func TestHandleEnvironmentSelect(t *testing.T) {
+	server := &Server{subpath: "/ui"}
+
+	req := httptest.NewRequest(http.MethodPost, "/ui/environments/select", strings.NewReader(url.Values{"environment": {"prod"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	server.handleEnvironmentSelect(w, req)
+
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, constant.EnvironmentCookieName, cookies[0].Name)
+		assert.Equal(t, "prod", cookies[0].Value)
+	}
+
+	// the selected environment is sent with every call
+	ctx := server.getAuthenticatedContext(context.Background(), func() *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/ui/", nil)
+		r.AddCookie(cookies[0])
+		return r
+	}())
+	md, _ := metadata.FromOutgoingContext(ctx)
+	assert.Equal(t, []string{"prod"}, md.Get(constant.EnvironmentMetadata))
+}
+
+func TestParseScheduleTime(t *testing.T) {
+	at, err := parseScheduleTime("2030-01-01T09:00", "-60")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2030, 1, 1, 8, 0, 0, 0, time.UTC), at)
+	at, err = parseScheduleTime("2030-01-01T09:00", "")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC), at)
+
+	_, err = parseScheduleTime("tomorrow", "")
+	assert.Error(t, err)
+	_, err = parseScheduleTime("2030-01-01T09:00", "east")
+	assert.Error(t, err)
+}
+
+func TestHandleSchedulesList(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("ListSchedules", mock.Anything, mock.Anything).Return(&featurev1.Schedules{Schedules: []*featurev1.Schedule{
+		{Id: "4f2a", Key: "COLOR", Value: "blue", At: timestamppb.New(time.Date(2030, 1, 1, 8, 0, 0, 0, time.UTC)), Restart: true, CreatedBy: "alice"},
+		{Id: "9c1b", Key: "TOKEN", Redacted: true, At: timestamppb.New(time.Date(2030, 1, 2, 8, 0, 0, 0, time.UTC)), Error: "field 'TOKEN' is not editable"},
+	}}, nil)
+	server := &Server{templates: ParseTemplates(context.Background()), featureClient: mockFeatureClient}
+
+	req := httptest.NewRequest(http.MethodGet, "/schedules/list", nil)
+	w := httptest.NewRecorder()
+	server.handleSchedulesList(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, "2030-01-01T08:00:00Z")
+	assert.Contains(t, body, "<code>blue</code>")
+	assert.Contains(t, body, "+ restart")
+	assert.Contains(t, body, "(value hidden)")
+	assert.Contains(t, body, "failed: field &#39;TOKEN&#39; is not editable")
+	assert.Contains(t, body, `name="id" value="9c1b"`)
+	mockFeatureClient.AssertExpectations(t)
+}
+
+func TestHandleSchedulesList_Unimplemented(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("ListSchedules", mock.Anything, mock.Anything).Return(nil, status.Error(codes.Unimplemented, "unknown method"))
+	server := &Server{templates: ParseTemplates(context.Background()), featureClient: mockFeatureClient}
+
+	req := httptest.NewRequest(http.MethodGet, "/schedules/list", nil)
+	w := httptest.NewRecorder()
+	server.handleSchedulesList(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestHandleScheduleCreate(t *testing.T) {
+	t.Run("created", func(t *testing.T) {
+		mockFeatureClient := new(MockFeatureClient)
+		mockFeatureClient.On("ScheduleSet", mock.Anything, mock.MatchedBy(func(req *featurev1.ScheduleSetRequest) bool {
+			return req.Key == "COLOR" && req.Value == "blue" && req.Restart &&
+				req.At.AsTime().Equal(time.Date(2030, 1, 1, 7, 0, 0, 0, time.UTC))
+		})).Return(&featurev1.Schedule{Id: "4f2a"}, nil)
+		mockFeatureClient.On("ListSchedules", mock.Anything, mock.Anything).Return(&featurev1.Schedules{}, nil)
+		server := &Server{
+			templates:     template.Must(template.New("schedules.gohtml").Parse(`Schedules: {{len .Schedules}}`)),
+			featureClient: mockFeatureClient,
+		}
+
+		form := url.Values{"key": {"COLOR"}, "value": {"blue"}, "at": {"2030-01-01T09:00"}, "tz_offset": {"-120"}, "restart": {"true"}}
+		req := httptest.NewRequest(http.MethodPost, "/schedules/create", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		server.handleScheduleCreate(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "Schedules: 0", w.Body.String())
+		mockFeatureClient.AssertExpectations(t)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		mockFeatureClient := new(MockFeatureClient)
+		mockFeatureClient.On("ScheduleSet", mock.Anything, mock.Anything).Return(nil, status.Error(codes.InvalidArgument, "at must be in the future"))
+		server := &Server{templates: ParseTemplates(context.Background()), featureClient: mockFeatureClient}
+
+		form := url.Values{"key": {"COLOR"}, "value": {"blue"}, "at": {"2020-01-01T09:00"}}
+		req := httptest.NewRequest(http.MethodPost, "/schedules/create", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		server.handleScheduleCreate(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "at must be in the future")
+	})
+}
+
+func TestHandleScheduleCancel(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("CancelSchedule", mock.Anything, mock.MatchedBy(func(req *featurev1.Schedule) bool {
+		return req.Id == "gone"
+	})).Return(nil, status.Error(codes.NotFound, "schedule 'gone' not found"))
+	mockFeatureClient.On("CancelSchedule", mock.Anything, mock.MatchedBy(func(req *featurev1.Schedule) bool {
+		return req.Id == "4f2a"
+	})).Return(&emptypb.Empty{}, nil)
+	mockFeatureClient.On("ListSchedules", mock.Anything, mock.Anything).Return(&featurev1.Schedules{}, nil)
+	server := &Server{
+		templates:     template.Must(template.New("schedules.gohtml").Parse(`Schedules: {{len .Schedules}}`)),
+		featureClient: mockFeatureClient,
+	}
+
+	for id, code := range map[string]int{"4f2a": http.StatusOK, "gone": http.StatusNotFound} {
+		form := url.Values{"id": {id}}
+		req := httptest.NewRequest(http.MethodPost, "/schedules/cancel", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		server.handleScheduleCancel(w, req)
+		assert.Equal(t, code, w.Code, id)
+	}
+	mockFeatureClient.AssertExpectations(t)
+}
+
+func TestParsePrerequisites(t *testing.T) {
+	prerequisites, err := parsePrerequisites(" NEW_CHECKOUT = true, THEME=a=b,")
+	assert.NoError(t, err)
+	assert.Equal(t, []*featurev1.Prerequisite{{Key: "NEW_CHECKOUT", Value: "true"}, {Key: "THEME", Value: "a=b"}}, prerequisites)
+	assert.Equal(t, "NEW_CHECKOUT=true, THEME=a=b", formatPrerequisites(prerequisites))
+
+	_, err = parsePrerequisites("NEW_CHECKOUT")
+	assert.Error(t, err)
+	_, err = parsePrerequisites("=true")
+	assert.Error(t, err)
+}
+
+func TestHandleFeaturePrerequisites(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("SetPrerequisites", mock.Anything, &featurev1.Prerequisites{
+		Key:           "NEW_CHECKOUT_V2",
+		Prerequisites: []*featurev1.Prerequisite{{Key: "NEW_CHECKOUT", Value: "true"}},
+	}).Return(&emptypb.Empty{}, nil)
+	mockFeatureClient.On("SetPrerequisites", mock.Anything, &featurev1.Prerequisites{
+		Key:           "NEW_CHECKOUT",
+		Prerequisites: []*featurev1.Prerequisite{{Key: "NEW_CHECKOUT_V2", Value: "true"}},
+	}).Return(nil, status.Error(codes.InvalidArgument, "invalid prerequisites for 'NEW_CHECKOUT': prerequisites form a cycle: NEW_CHECKOUT -> NEW_CHECKOUT_V2 -> NEW_CHECKOUT"))
+	mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(&MockStreamClient{}, nil)
+	mockFeatureClient.On("ListStale", mock.Anything, mock.Anything).Return(&featurev1.StaleFlags{}, nil).Maybe()
+
+	server := &Server{
+		templates:     template.Must(template.New("features_list.gohtml").Parse(`Features: {{len .Features}}`)),
+		featureClient: mockFeatureClient,
+	}
+
+	for _, tt := range []struct {
+		form url.Values
+		code int
+		body string
+	}{
+		{url.Values{"key": {"NEW_CHECKOUT_V2"}, "prerequisites": {"NEW_CHECKOUT=true"}}, http.StatusOK, "Features: 0"},
+		{url.Values{"key": {"NEW_CHECKOUT"}, "prerequisites": {"NEW_CHECKOUT_V2=true"}}, http.StatusBadRequest, "form a cycle"},
+		{url.Values{"key": {"NEW_CHECKOUT"}, "prerequisites": {"NEW_CHECKOUT_V2"}}, http.StatusBadRequest, "expected KEY=value"},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/features/prerequisites", strings.NewReader(tt.form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		server.handleFeaturePrerequisites(w, req)
+		assert.Equal(t, tt.code, w.Code)
+		assert.Contains(t, w.Body.String(), tt.body)
+	}
+	mockFeatureClient.AssertExpectations(t)
+}
+
+func TestHandleFeatureDelete_Prerequisite(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("Delete", mock.Anything, &featurev1.Key{Name: "NEW_CHECKOUT"}).
+		Return(nil, status.Error(codes.FailedPrecondition, "'NEW_CHECKOUT' is a prerequisite of NEW_CHECKOUT_V2"))
+	server := &Server{featureClient: mockFeatureClient}
+
+	form := url.Values{"key": {"NEW_CHECKOUT"}}
+	req := httptest.NewRequest(http.MethodPost, "/features/delete", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	server.handleFeatureDelete(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "is a prerequisite of NEW_CHECKOUT_V2")
+}
+
+func TestBuildGraph(t *testing.T) {
+	graph := buildGraph([]*featurev1.KeyValue{
+		{Key: "COLOR", Value: "red"},
+		{Key: "NEW_CHECKOUT", Value: "false"},
+		{Key: "NEW_CHECKOUT_V2", Value: "true", Prerequisites: []*featurev1.Prerequisite{{Key: "NEW_CHECKOUT", Value: "true"}}},
+		{Key: "CHECKOUT_THEME", Value: "dark", Prerequisites: []*featurev1.Prerequisite{
+			{Key: "NEW_CHECKOUT_V2", Value: "true"},
+			{Key: "DELETED", Value: "on"},
+		}},
+	})
+
+	// COLOR has no prerequisites and is required by nothing
+	columns := make(map[string]int)
+	for _, node := range graph.Nodes {
+		columns[node.Key] = (node.X - graphMargin) / (graphNodeWidth + graphColumnGap)
+	}
+	assert.Equal(t, map[string]int{"NEW_CHECKOUT": 0, "DELETED": 0, "NEW_CHECKOUT_V2": 1, "CHECKOUT_THEME": 2}, columns)
+	assert.Equal(t, 3*graphNodeWidth+2*graphColumnGap+2*graphMargin, graph.Width)
+
+	unmet := make(map[string]bool)
+	for _, edge := range graph.Edges {
+		unmet[edge.From+"->"+edge.To] = edge.Unmet
+	}
+	assert.Equal(t, map[string]bool{
+		"NEW_CHECKOUT->NEW_CHECKOUT_V2":   true,
+		"NEW_CHECKOUT_V2->CHECKOUT_THEME": false,
+		"DELETED->CHECKOUT_THEME":         true,
+	}, unmet)
+}
+
+func TestHandleFeatureGraph(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(&MockStreamClient{items: []*featurev1.KeyValue{
+		{Key: "NEW_CHECKOUT", Value: "true"},
+		{Key: "NEW_CHECKOUT_V2", Value: "true", Prerequisites: []*featurev1.Prerequisite{{Key: "NEW_CHECKOUT", Value: "true"}}},
+	}}, nil).Once()
+	mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(&MockStreamClient{items: []*featurev1.KeyValue{
+		{Key: "COLOR", Value: "red"},
+	}}, nil).Once()
+	server := &Server{
+		templates:     ParseTemplates(context.Background()),
+		featureClient: mockFeatureClient,
+	}
+
+	w := httptest.NewRecorder()
+	server.handleFeatureGraph(w, httptest.NewRequest(http.MethodGet, "/features/graph", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<svg")
+	assert.Contains(t, w.Body.String(), "NEW_CHECKOUT_V2 requires NEW_CHECKOUT = true")
+
+	// without prerequisites the panel stays empty
+	w = httptest.NewRecorder()
+	server.handleFeatureGraph(w, httptest.NewRequest(http.MethodGet, "/features/graph", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Body.String())
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"path"
+	"slices"
+
+	featurev1 "github.com/dkrizic/feature/ui/repository/feature/v1"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Permissions are the methods the roles of the user allow, used to hide what the user cannot do.
+// The backend enforces them, so a backend without roles or an error shows everything.
+type Permissions struct {
+	unrestricted bool
+	// grants maps the methods to the key patterns they may change, nil means all keys
+	grants map[string][]string
+}
+
+// unrestricted allows everything
+var unrestricted = Permissions{unrestricted: true}
+
+func newPermissions(p *featurev1.Permissions) Permissions {
+	if p.GetUnrestricted() {
+		return unrestricted
+	}
+	permissions := Permissions{grants: make(map[string][]string)}
+	for _, grant := range p.GetGrants() {
+		permissions.grants[grant.Method] = grant.Keys
+	}
+	return permissions
+}
+
+// Can reports whether the user may call the method at all, e.g. Restart
+func (p Permissions) Can(method string) bool {
+	if p.unrestricted {
+		return true
+	}
+	_, ok := p.grants[method]
+	return ok
+}
+
+// CanChange reports whether the user may call the method on the key, e.g. Delete
+func (p Permissions) CanChange(method, key string) bool {
+	if p.unrestricted {
+		return true
+	}
+	patterns, ok := p.grants[method]
+	if !ok {
+		return false
+	}
+	if len(patterns) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		matched, _ := path.Match(pattern, key)
+		return matched
+	})
+}
+
+// permissions fetches the permissions of the logged in user from the backend
+func (s *Server) permissions(ctx context.Context, r *http.Request) Permissions {
+	p, err := s.featureClient.GetPermissions(s.getAuthenticatedContext(ctx, r), &emptypb.Empty{})
+	if err != nil {
+		if status.Code(err) != grpccodes.Unimplemented {
+			slog.WarnContext(ctx, "Failed to fetch permissions, showing everything", "error", err)
+		}
+		return unrestricted
+	}
+	return newPermissions(p)
+}
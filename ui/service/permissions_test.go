@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	featurev1 "github.com/dkrizic/feature/ui/repository/feature/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPermissions(t *testing.T) {
+	editor := newPermissions(&featurev1.Permissions{Grants: []*featurev1.Grant{
+		{Method: "GetAll"},
+		{Method: "Set", Keys: []string{"CHECKOUT_*"}},
+	}})
+	assert.True(t, editor.Can("Set"))
+	assert.True(t, editor.CanChange("Set", "CHECKOUT_V2"))
+	assert.False(t, editor.CanChange("Set", "COLOR"))
+	assert.True(t, editor.CanChange("GetAll", "COLOR"))
+	assert.False(t, editor.Can("Restart"))
+	assert.False(t, editor.CanChange("Delete", "CHECKOUT_V2"))
+
+	admin := newPermissions(&featurev1.Permissions{Unrestricted: true})
+	assert.True(t, admin.CanChange("Delete", "COLOR"))
+}
+
+func TestHandleFeaturesList_Permissions(t *testing.T) {
+	mockFeatureClient := &MockFeatureClient{permissions: &featurev1.Permissions{Grants: []*featurev1.Grant{
+		{Method: "GetAll"},
+		{Method: "Delete", Keys: []string{"CHECKOUT_*"}},
+		{Method: "Set", Keys: []string{"CHECKOUT_*"}},
+	}}}
+	mockStream := &MockStreamClient{
+		items: []*featurev1.KeyValue{
+			{Key: "CHECKOUT_V2", Value: "true", Editable: true},
+			{Key: "COLOR", Value: "red", Editable: true},
+		},
+	}
+	mockFeatureClient.On("GetAll", mock.Anything, mock.Anything).Return(mockStream, nil)
+	mockFeatureClient.On("ListStale", mock.Anything, mock.Anything).Return(&featurev1.StaleFlags{}, nil).Maybe()
+
+	server := &Server{
+		templates:     ParseTemplates(context.Background()),
+		featureClient: mockFeatureClient,
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/features/list", nil)
+	w := httptest.NewRecorder()
+
+	server.handleFeaturesList(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `name="value.CHECKOUT_V2"`)
+	assert.NotContains(t, body, `name="value.COLOR"`, "the roles do not allow changing COLOR")
+	assert.Contains(t, body, `name="delete" value="CHECKOUT_V2"`)
+	assert.NotContains(t, body, `name="delete" value="COLOR"`)
+	assert.Contains(t, body, "Protected")
+	assert.NotContains(t, body, "Your roles do not allow creating", "creating is allowed for some keys")
+}
+
+func TestHandleIndex_Permissions(t *testing.T) {
+	mockFeatureClient := &MockFeatureClient{permissions: &featurev1.Permissions{Grants: []*featurev1.Grant{{Method: "GetAll"}}}}
+	server := &Server{
+		templates:      ParseTemplates(context.Background()),
+		featureClient:  mockFeatureClient,
+		restartEnabled: true,
+		restartName:    "shop",
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+
+	server.handleIndex(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "Restart shop")
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	featurev1 "github.com/dkrizic/feature/ui/repository/feature/v1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// scheduleTimeLayout is the value of a datetime-local input
+const scheduleTimeLayout = "2006-01-02T15:04"
+
+// ScheduleView is a schedule as shown in the schedules panel
+type ScheduleView struct {
+	ID          string
+	At          string
+	Environment string
+	Key         string
+	Value       string
+	Redacted    bool
+	Restart     bool
+	CreatedBy   string
+	Error       string
+	// Cancellable is set if the roles of the user allow cancelling the schedule
+	Cancellable bool
+}
+
+// parseScheduleTime parses the local time of a datetime-local input. offset is the time zone offset
+// of the browser in minutes as reported by Date.getTimezoneOffset, UTC if empty.
+func parseScheduleTime(value, offset string) (time.Time, error) {
+	at, err := time.Parse(scheduleTimeLayout, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if offset == "" {
+		return at, nil
+	}
+	minutes, err := strconv.Atoi(offset)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return at.Add(time.Duration(minutes) * time.Minute), nil
+}
+
+// handleSchedulesList renders the schedules of the selected flag set with a form to schedule a
+// change. It stays empty if the backend does not support scheduling.
+func (s *Server) handleSchedulesList(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "handleSchedulesList")
+	defer span.End()
+
+	result, err := s.featureClient.ListSchedules(s.getAuthenticatedContext(ctx, r), &emptypb.Empty{})
+	if status.Code(err) == grpccodes.Unimplemented {
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list schedules", "error", err)
+		http.Error(w, "Failed to fetch schedules", http.StatusInternalServerError)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	permissions := s.permissions(ctx, r)
+	data := struct {
+		Schedules []ScheduleView
+		Subpath   string
+		// CanSchedule is set if the roles of the user allow scheduling changes
+		CanSchedule bool
+	}{
+		Subpath:     s.subpath,
+		CanSchedule: permissions.Can("ScheduleSet"),
+	}
+	for _, schedule := range result.Schedules {
+		data.Schedules = append(data.Schedules, ScheduleView{
+			ID:          schedule.Id,
+			At:          schedule.At.AsTime().Format(time.RFC3339),
+			Environment: schedule.Environment,
+			Key:         schedule.Key,
+			Value:       schedule.Value,
+			Redacted:    schedule.Redacted,
+			Restart:     schedule.Restart,
+			CreatedBy:   schedule.CreatedBy,
+			Error:       schedule.Error,
+			Cancellable: permissions.CanChange("CancelSchedule", schedule.Key),
+		})
+	}
+
+	if err := s.templates.ExecuteTemplate(w, "schedules.gohtml", data); err != nil {
+		slog.ErrorContext(ctx, "Failed to render schedules template", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+}
+
+// handleScheduleCreate schedules a change of a feature in the selected environment and re-renders
+// the schedules
+func (s *Server) handleScheduleCreate(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "handleScheduleCreate")
+	defer span.End()
+
+	// Parse form data
+	if err := r.ParseForm(); err != nil {
+		slog.ErrorContext(ctx, "Failed to parse form", "error", err)
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	key := r.FormValue("key")
+	if key == "" {
+		slog.ErrorContext(ctx, "Missing key parameter")
+		http.Error(w, "Missing key parameter", http.StatusBadRequest)
+		span.SetStatus(codes.Error, "Missing key parameter")
+		return
+	}
+	at, err := parseScheduleTime(r.FormValue("at"), r.FormValue("tz_offset"))
+	if err != nil {
+		slog.ErrorContext(ctx, "Invalid time", "at", r.FormValue("at"), "error", err)
+		http.Error(w, "Invalid time", http.StatusBadRequest)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	req := &featurev1.ScheduleSetRequest{
+		Key:     key,
+		Value:   r.FormValue("value"),
+		At:      timestamppb.New(at),
+		Restart: r.FormValue("restart") == "true",
+	}
+	schedule, err := s.featureClient.ScheduleSet(s.getAuthenticatedContext(ctx, r), req)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to schedule feature", "key", key, "error", err)
+		if status.Code(err) == grpccodes.PermissionDenied {
+			http.Error(w, status.Convert(err).Message(), http.StatusForbidden)
+		} else {
+			writeSetError(w, err, "Failed to schedule feature")
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	slog.InfoContext(ctx, "Feature scheduled", "id", schedule.Id, "key", key, "at", at)
+
+	s.handleSchedulesList(w, r)
+}
+
+// handleScheduleCancel cancels a schedule and re-renders the schedules
+func (s *Server) handleScheduleCancel(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "handleScheduleCancel")
+	defer span.End()
+
+	id := r.FormValue("id")
+	if id == "" {
+		slog.ErrorContext(ctx, "Missing id parameter")
+		http.Error(w, "Missing id parameter", http.StatusBadRequest)
+		span.SetStatus(codes.Error, "Missing id parameter")
+		return
+	}
+
+	if _, err := s.featureClient.CancelSchedule(s.getAuthenticatedContext(ctx, r), &featurev1.Schedule{Id: id}); err != nil {
+		slog.ErrorContext(ctx, "Failed to cancel schedule", "id", id, "error", err)
+		switch status.Code(err) {
+		case grpccodes.PermissionDenied:
+			http.Error(w, status.Convert(err).Message(), http.StatusForbidden)
+		case grpccodes.NotFound:
+			http.Error(w, status.Convert(err).Message(), http.StatusNotFound)
+		default:
+			http.Error(w, "Failed to cancel schedule", http.StatusInternalServerError)
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	slog.InfoContext(ctx, "Schedule cancelled", "id", id)
+
+	s.handleSchedulesList(w, r)
+}
@@ -47,8 +47,21 @@ func (c *basicAuthCreds) RequireTransportSecurity() bool {
 	return false
 }
 
-// getAuthenticatedContext creates a context with authentication metadata from the session
+// getAuthenticatedContext creates a context with authentication metadata from the session and the
+// selected flag set and environment
 func (s *Server) getAuthenticatedContext(ctx context.Context, r *http.Request) context.Context {
+	return s.contextIn(ctx, r, s.environment(r))
+}
+
+// contextIn is getAuthenticatedContext for an environment other than the selected one, empty for
+// the default environment
+func (s *Server) contextIn(ctx context.Context, r *http.Request, environment string) context.Context {
+	if set := s.flagSet(r); set != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, constant.FlagSetMetadata, set)
+	}
+	if environment != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, constant.EnvironmentMetadata, environment)
+	}
 	creds := s.getSessionCredentials(r)
 	if creds != nil {
 		// Create basicAuthCreds and get metadata
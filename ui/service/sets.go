@@ -0,0 +1,92 @@
+package service
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/dkrizic/feature/ui/constant"
+	featurev1 "github.com/dkrizic/feature/ui/repository/feature/v1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// defaultFlagSet names the default flag set of the backend
+const defaultFlagSet = "default"
+
+// flagSet returns the flag set selected in the browser, empty for the default set
+func (s *Server) flagSet(r *http.Request) string {
+	cookie, err := r.Cookie(constant.FlagSetCookieName)
+	if err != nil || cookie.Value == defaultFlagSet {
+		return ""
+	}
+	return cookie.Value
+}
+
+// handleSetsList renders the flag set selector. It stays empty if the backend has no other sets
+// than the default one, or does not support them.
+func (s *Server) handleSetsList(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "handleSetsList")
+	defer span.End()
+
+	// Get authenticated context with credentials from session
+	authCtx := s.getAuthenticatedContext(ctx, r)
+
+	result, err := s.featureClient.ListSets(authCtx, &emptypb.Empty{})
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to list flag sets", "error", err)
+		return
+	}
+	if len(result.Sets) < 2 {
+		return
+	}
+
+	selected := s.flagSet(r)
+	if selected == "" {
+		selected = defaultFlagSet
+	}
+	data := struct {
+		Sets     []*featurev1.FlagSet
+		Selected string
+		Subpath  string
+	}{
+		Sets:     result.Sets,
+		Selected: selected,
+		Subpath:  s.subpath,
+	}
+
+	if err := s.templates.ExecuteTemplate(w, "sets.gohtml", data); err != nil {
+		slog.ErrorContext(ctx, "Failed to render sets template", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+}
+
+// handleSetSelect remembers the selected flag set in a cookie and reloads the page
+func (s *Server) handleSetSelect(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "handleSetSelect")
+	defer span.End()
+
+	set := r.FormValue("set")
+	if set == "" {
+		set = defaultFlagSet
+	}
+	slog.InfoContext(ctx, "Selecting flag set", "set", set)
+
+	cookie := &http.Cookie{
+		Name:     constant.FlagSetCookieName,
+		Value:    set,
+		Path:     s.subpath + "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	}
+	if set == defaultFlagSet {
+		cookie.Value = ""
+		cookie.MaxAge = -1
+	}
+	http.SetCookie(w, cookie)
+
+	// the whole page shows the selected set, including the watch and the restart target
+	http.Redirect(w, r, s.subpath+"/", http.StatusSeeOther)
+}
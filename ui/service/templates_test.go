@@ -19,5 +19,10 @@ func TestParseTemplates(t *testing.T) {
 		// Templates should have been loaded from the embedded FS
 		assert.NotNil(t, tmpl.Lookup("index.gohtml"), "index.gohtml template should exist")
 		assert.NotNil(t, tmpl.Lookup("features_list.gohtml"), "features_list.gohtml template should exist")
+		assert.NotNil(t, tmpl.Lookup("history.gohtml"), "history.gohtml template should exist")
+		assert.NotNil(t, tmpl.Lookup("matrix.gohtml"), "matrix.gohtml template should exist")
+		assert.NotNil(t, tmpl.Lookup("promote.gohtml"), "promote.gohtml template should exist")
+		assert.NotNil(t, tmpl.Lookup("schedules.gohtml"), "schedules.gohtml template should exist")
+		assert.NotNil(t, tmpl.Lookup("graph.gohtml"), "graph.gohtml template should exist")
 	}
 }
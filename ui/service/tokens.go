@@ -0,0 +1,205 @@
+package service
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	featurev1 "github.com/dkrizic/feature/ui/repository/feature/v1"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// tokenScopes maps the scopes offered by the form to those of the API
+var tokenScopes = map[string]featurev1.TokenScope{
+	"read":    featurev1.TokenScope_TOKEN_SCOPE_READ,
+	"write":   featurev1.TokenScope_TOKEN_SCOPE_WRITE,
+	"restart": featurev1.TokenScope_TOKEN_SCOPE_RESTART,
+}
+
+// TokenView is an API token as shown in the tokens panel
+type TokenView struct {
+	ID        string
+	Name      string
+	Scopes    string
+	ExpiresAt string
+	Expired   bool
+	CreatedBy string
+	CreatedAt string
+}
+
+func newTokenView(token *featurev1.Token, now time.Time) TokenView {
+	scopes := make([]string, 0, len(token.Scopes))
+	for _, scope := range token.Scopes {
+		for name, value := range tokenScopes {
+			if value == scope {
+				scopes = append(scopes, name)
+			}
+		}
+	}
+	view := TokenView{
+		ID:        token.Id,
+		Name:      token.Name,
+		Scopes:    strings.Join(scopes, ", "),
+		CreatedBy: token.CreatedBy,
+		CreatedAt: token.CreatedAt.AsTime().Format(time.RFC3339),
+	}
+	if token.ExpiresAt != nil {
+		view.ExpiresAt = token.ExpiresAt.AsTime().Format(time.RFC3339)
+		view.Expired = !now.Before(token.ExpiresAt.AsTime())
+	}
+	return view
+}
+
+// handleTokensList renders the API tokens with a form to create one. It stays empty if the backend
+// has no API tokens or the user may not manage them.
+func (s *Server) handleTokensList(w http.ResponseWriter, r *http.Request) {
+	s.renderTokens(w, r, nil)
+}
+
+// renderTokens renders the tokens panel, with the secret of a token that was just created
+func (s *Server) renderTokens(w http.ResponseWriter, r *http.Request, created *featurev1.CreateTokenResponse) {
+	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "renderTokens")
+	defer span.End()
+
+	result, err := s.featureClient.ListTokens(s.getAuthenticatedContext(ctx, r), &emptypb.Empty{})
+	if code := status.Code(err); code == grpccodes.Unimplemented || code == grpccodes.PermissionDenied {
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list tokens", "error", err)
+		http.Error(w, "Failed to fetch tokens", http.StatusInternalServerError)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	permissions := s.permissions(ctx, r)
+	data := struct {
+		Tokens  []TokenView
+		Subpath string
+		// Created is the token just created, its Secret is shown once
+		Created   *TokenView
+		Secret    string
+		CanCreate bool
+		CanRevoke bool
+	}{
+		Subpath:   s.subpath,
+		CanCreate: permissions.Can("CreateToken"),
+		CanRevoke: permissions.Can("RevokeToken"),
+	}
+	now := time.Now()
+	for _, token := range result.Tokens {
+		data.Tokens = append(data.Tokens, newTokenView(token, now))
+	}
+	if created != nil {
+		view := newTokenView(created.Token, now)
+		data.Created = &view
+		data.Secret = created.Secret
+	}
+
+	if err := s.templates.ExecuteTemplate(w, "tokens.gohtml", data); err != nil {
+		slog.ErrorContext(ctx, "Failed to render tokens template", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+}
+
+// handleTokenCreate creates an API token and re-renders the tokens with its secret
+func (s *Server) handleTokenCreate(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "handleTokenCreate")
+	defer span.End()
+
+	// Parse form data
+	if err := r.ParseForm(); err != nil {
+		slog.ErrorContext(ctx, "Failed to parse form", "error", err)
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	name := r.FormValue("name")
+	if name == "" {
+		slog.ErrorContext(ctx, "Missing name parameter")
+		http.Error(w, "Missing name parameter", http.StatusBadRequest)
+		span.SetStatus(codes.Error, "Missing name parameter")
+		return
+	}
+	req := &featurev1.CreateTokenRequest{Name: name}
+	for _, value := range r.Form["scope"] {
+		scope, ok := tokenScopes[value]
+		if !ok {
+			slog.ErrorContext(ctx, "Invalid scope", "scope", value)
+			http.Error(w, "Invalid scope", http.StatusBadRequest)
+			span.SetStatus(codes.Error, "Invalid scope")
+			return
+		}
+		req.Scopes = append(req.Scopes, scope)
+	}
+	if expires := r.FormValue("expires"); expires != "" {
+		at, err := parseScheduleTime(expires, r.FormValue("tz_offset"))
+		if err != nil {
+			slog.ErrorContext(ctx, "Invalid expiry", "expires", expires, "error", err)
+			http.Error(w, "Invalid expiry", http.StatusBadRequest)
+			span.SetStatus(codes.Error, err.Error())
+			return
+		}
+		req.ExpiresAt = timestamppb.New(at)
+	}
+
+	created, err := s.featureClient.CreateToken(s.getAuthenticatedContext(ctx, r), req)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create token", "name", name, "error", err)
+		switch status.Code(err) {
+		case grpccodes.PermissionDenied:
+			http.Error(w, status.Convert(err).Message(), http.StatusForbidden)
+		case grpccodes.InvalidArgument:
+			http.Error(w, status.Convert(err).Message(), http.StatusBadRequest)
+		case grpccodes.AlreadyExists:
+			http.Error(w, status.Convert(err).Message(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to create token", http.StatusInternalServerError)
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	slog.InfoContext(ctx, "Token created", "id", created.Token.Id, "name", name)
+
+	s.renderTokens(w, r, created)
+}
+
+// handleTokenRevoke revokes an API token and re-renders the tokens
+func (s *Server) handleTokenRevoke(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("ui/service").Start(r.Context(), "handleTokenRevoke")
+	defer span.End()
+
+	id := r.FormValue("id")
+	if id == "" {
+		slog.ErrorContext(ctx, "Missing id parameter")
+		http.Error(w, "Missing id parameter", http.StatusBadRequest)
+		span.SetStatus(codes.Error, "Missing id parameter")
+		return
+	}
+
+	if _, err := s.featureClient.RevokeToken(s.getAuthenticatedContext(ctx, r), &featurev1.Token{Id: id}); err != nil {
+		slog.ErrorContext(ctx, "Failed to revoke token", "id", id, "error", err)
+		switch status.Code(err) {
+		case grpccodes.PermissionDenied:
+			http.Error(w, status.Convert(err).Message(), http.StatusForbidden)
+		case grpccodes.NotFound:
+			http.Error(w, status.Convert(err).Message(), http.StatusNotFound)
+		default:
+			http.Error(w, "Failed to revoke token", http.StatusInternalServerError)
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	slog.InfoContext(ctx, "Token revoked", "id", id)
+
+	s.renderTokens(w, r, nil)
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	featurev1 "github.com/dkrizic/feature/ui/repository/feature/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestHandleTokensList(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("ListTokens", mock.Anything, mock.Anything).Return(&featurev1.Tokens{Tokens: []*featurev1.Token{
+		{Id: "4f2a", Name: "ci", Scopes: []featurev1.TokenScope{featurev1.TokenScope_TOKEN_SCOPE_READ, featurev1.TokenScope_TOKEN_SCOPE_WRITE}, CreatedBy: "alice", CreatedAt: timestamppb.Now()},
+		{Id: "9c1b", Name: "old", Scopes: []featurev1.TokenScope{featurev1.TokenScope_TOKEN_SCOPE_READ}, ExpiresAt: timestamppb.New(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)), CreatedAt: timestamppb.Now()},
+	}}, nil)
+	server := &Server{templates: ParseTemplates(context.Background()), featureClient: mockFeatureClient}
+
+	req := httptest.NewRequest(http.MethodGet, "/tokens/list", nil)
+	w := httptest.NewRecorder()
+	server.handleTokensList(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, "<strong>ci</strong>")
+	assert.Contains(t, body, "read, write")
+	assert.Contains(t, body, "2020-01-01T00:00:00Z<br><small>expired</small>")
+	assert.Contains(t, body, `name="id" value="9c1b"`)
+	assert.Contains(t, body, `hx-post="/tokens/create"`)
+}
+
+func TestHandleTokensList_Hidden(t *testing.T) {
+	for _, code := range []codes.Code{codes.Unimplemented, codes.PermissionDenied} {
+		mockFeatureClient := new(MockFeatureClient)
+		mockFeatureClient.On("ListTokens", mock.Anything, mock.Anything).Return(nil, status.Error(code, ""))
+		server := &Server{templates: ParseTemplates(context.Background()), featureClient: mockFeatureClient}
+
+		req := httptest.NewRequest(http.MethodGet, "/tokens/list", nil)
+		w := httptest.NewRecorder()
+		server.handleTokensList(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Body.String(), code.String())
+	}
+}
+
+func TestHandleTokenCreate(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("CreateToken", mock.Anything, mock.MatchedBy(func(req *featurev1.CreateTokenRequest) bool {
+		return req.Name == "ci" && len(req.Scopes) == 2 && req.Scopes[1] == featurev1.TokenScope_TOKEN_SCOPE_RESTART &&
+			req.ExpiresAt.AsTime().Equal(time.Date(2030, 1, 1, 7, 0, 0, 0, time.UTC))
+	})).Return(&featurev1.CreateTokenResponse{
+		Token:  &featurev1.Token{Id: "4f2a", Name: "ci", CreatedAt: timestamppb.Now()},
+		Secret: "ft_4f2a_secret",
+	}, nil)
+	mockFeatureClient.On("ListTokens", mock.Anything, mock.Anything).Return(&featurev1.Tokens{}, nil)
+	server := &Server{templates: ParseTemplates(context.Background()), featureClient: mockFeatureClient}
+
+	form := url.Values{"name": {"ci"}, "scope": {"read", "restart"}, "expires": {"2030-01-01T08:00"}, "tz_offset": {"-60"}}
+	req := httptest.NewRequest(http.MethodPost, "/tokens/create", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	server.handleTokenCreate(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<code style=\"word-break: break-all;\">ft_4f2a_secret</code>")
+	mockFeatureClient.AssertExpectations(t)
+
+	form = url.Values{"name": {"ci"}, "scope": {"admin"}}
+	req = httptest.NewRequest(http.MethodPost, "/tokens/create", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	server.handleTokenCreate(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleTokenRevoke(t *testing.T) {
+	mockFeatureClient := new(MockFeatureClient)
+	mockFeatureClient.On("RevokeToken", mock.Anything, &featurev1.Token{Id: "4f2a"}).Return(nil, status.Error(codes.NotFound, "token not found: 4f2a"))
+	server := &Server{templates: ParseTemplates(context.Background()), featureClient: mockFeatureClient}
+
+	form := url.Values{"id": {"4f2a"}}
+	req := httptest.NewRequest(http.MethodPost, "/tokens/revoke", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	server.handleTokenRevoke(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "token not found")
+}